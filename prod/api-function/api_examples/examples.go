@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Example statuses; ExecuteTurn2Combined never selects retired examples
+const (
+	StatusActive  = "ACTIVE"
+	StatusRetired = "RETIRED"
+)
+
+// ImageRef mirrors the state bucket reference of an example image
+type ImageRef struct {
+	Key    string `json:"key" dynamodbav:"key"`
+	Format string `json:"format" dynamodbav:"format"`
+}
+
+// PositionAnswer is the reviewed answer for one position. An empty Issue
+// marks a position confirmed as correct.
+type PositionAnswer struct {
+	Position string `json:"position" dynamodbav:"position"`
+	Expected string `json:"expected,omitempty" dynamodbav:"expected,omitempty"`
+	Found    string `json:"found,omitempty" dynamodbav:"found,omitempty"`
+	Issue    string `json:"issue,omitempty" dynamodbav:"issue,omitempty"`
+	Note     string `json:"note,omitempty" dynamodbav:"note,omitempty"`
+}
+
+// Example mirrors the few-shot example ExecuteTurn2Combined reads from the
+// examples table (see workflow-function/shared/examples)
+type Example struct {
+	ExampleID        string           `json:"exampleId" dynamodbav:"exampleId"`
+	VerificationID   string           `json:"verificationId" dynamodbav:"verificationId"`
+	VerificationType string           `json:"verificationType" dynamodbav:"verificationType"`
+	LayoutID         int              `json:"layoutId,omitempty" dynamodbav:"layoutId,omitempty"`
+	LayoutPrefix     string           `json:"layoutPrefix,omitempty" dynamodbav:"layoutPrefix,omitempty"`
+	VendingMachineID string           `json:"vendingMachineId,omitempty" dynamodbav:"vendingMachineId,omitempty"`
+	MachineModel     string           `json:"machineModel,omitempty" dynamodbav:"machineModel,omitempty"`
+	Tags             []string         `json:"tags,omitempty" dynamodbav:"tags,omitempty"`
+	Products         []string         `json:"products,omitempty" dynamodbav:"products,omitempty"`
+	ReferenceImage   ImageRef         `json:"referenceImage" dynamodbav:"referenceImage"`
+	CheckingImage    ImageRef         `json:"checkingImage" dynamodbav:"checkingImage"`
+	Positions        []PositionAnswer `json:"positions" dynamodbav:"positions"`
+	Answer           string           `json:"answer,omitempty" dynamodbav:"answer,omitempty"`
+	Status           string           `json:"exampleStatus" dynamodbav:"exampleStatus"`
+	ReviewedBy       string           `json:"reviewedBy" dynamodbav:"reviewedBy"`
+	ReviewedAt       string           `json:"reviewedAt" dynamodbav:"reviewedAt"`
+	RetiredAt        string           `json:"retiredAt,omitempty" dynamodbav:"retiredAt,omitempty"`
+}
+
+// VerificationRecord holds the verification record attributes an example is
+// built from
+type VerificationRecord struct {
+	VerificationID    string `dynamodbav:"verificationId"`
+	VerificationAt    string `dynamodbav:"verificationAt"`
+	VerificationType  string `dynamodbav:"verificationType"`
+	VendingMachineID  string `dynamodbav:"vendingMachineId"`
+	LayoutID          int    `dynamodbav:"layoutId"`
+	LayoutPrefix      string `dynamodbav:"layoutPrefix"`
+	ReferenceImageURL string `dynamodbav:"referenceImageUrl"`
+	CheckingImageURL  string `dynamodbav:"checkingImageUrl"`
+	// DiscrepantPositions is the number of discrepancies the workflow
+	// reported, absent until the verification completed
+	DiscrepantPositions *int `dynamodbav:"discrepantPositions,omitempty"`
+}
+
+// ExampleRequest is the body of POST /api/verifications/{verificationId}/example
+type ExampleRequest struct {
+	ReviewedBy   string           `json:"reviewedBy"`
+	Positions    []PositionAnswer `json:"positions"`
+	Answer       string           `json:"answer,omitempty"`
+	Tags         []string         `json:"tags,omitempty"`
+	Products     []string         `json:"products,omitempty"`
+	MachineModel string           `json:"machineModel,omitempty"`
+	// DatePartition overrides the yyyy/mm/dd state bucket partition of the
+	// verification's images, derived from verificationAt by default
+	DatePartition string `json:"datePartition,omitempty"`
+}
+
+// exampleID returns the ID of a verification's example; confirming a
+// verification again replaces its example
+func exampleID(verificationID string) string {
+	return "ex-" + verificationID
+}
+
+// buildExample builds the example of a reviewed verification. The images are
+// the base64 copies the workflow stored in the state bucket under
+// {yyyy}/{mm}/{dd}/{verificationId}/images/.
+func buildExample(record VerificationRecord, req ExampleRequest, loc *time.Location, now time.Time) (Example, error) {
+	if strings.TrimSpace(req.ReviewedBy) == "" {
+		return Example{}, fmt.Errorf("reviewedBy is required")
+	}
+	if len(req.Positions) == 0 && strings.TrimSpace(req.Answer) == "" {
+		return Example{}, fmt.Errorf("positions or answer is required")
+	}
+	for _, p := range req.Positions {
+		if strings.TrimSpace(p.Position) == "" {
+			return Example{}, fmt.Errorf("every position needs a position code")
+		}
+	}
+	if record.VerificationType == "" {
+		return Example{}, fmt.Errorf("verification %s has no verificationType", record.VerificationID)
+	}
+
+	partition := req.DatePartition
+	if partition == "" {
+		at, err := time.Parse(time.RFC3339, record.VerificationAt)
+		if err != nil {
+			return Example{}, fmt.Errorf("verification %s has an invalid verificationAt %q", record.VerificationID, record.VerificationAt)
+		}
+		at = at.In(loc)
+		partition = fmt.Sprintf("%04d/%02d/%02d", at.Year(), at.Month(), at.Day())
+	}
+
+	return Example{
+		ExampleID:        exampleID(record.VerificationID),
+		VerificationID:   record.VerificationID,
+		VerificationType: record.VerificationType,
+		LayoutID:         record.LayoutID,
+		LayoutPrefix:     record.LayoutPrefix,
+		VendingMachineID: record.VendingMachineID,
+		MachineModel:     strings.TrimSpace(req.MachineModel),
+		Tags:             trimAll(req.Tags),
+		Products:         trimAll(req.Products),
+		ReferenceImage: ImageRef{
+			Key:    imageKey(partition, record.VerificationID, "reference"),
+			Format: imageFormat(record.ReferenceImageURL, "png"),
+		},
+		CheckingImage: ImageRef{
+			Key:    imageKey(partition, record.VerificationID, "checking"),
+			Format: imageFormat(record.CheckingImageURL, "jpeg"),
+		},
+		Positions:  req.Positions,
+		Answer:     strings.TrimSpace(req.Answer),
+		Status:     StatusActive,
+		ReviewedBy: strings.TrimSpace(req.ReviewedBy),
+		ReviewedAt: now.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// imageKey mirrors examples.ImageKey of the workflow functions
+func imageKey(datePartition, verificationID, imageType string) string {
+	return fmt.Sprintf("%s/%s/images/%s-base64.base64", datePartition, verificationID, imageType)
+}
+
+// imageFormat returns the Bedrock image format of an image URL's extension
+func imageFormat(url, fallback string) string {
+	switch strings.ToLower(strings.TrimPrefix(path.Ext(url), ".")) {
+	case "jpg", "jpeg":
+		return "jpeg"
+	case "png":
+		return "png"
+	case "gif":
+		return "gif"
+	case "webp":
+		return "webp"
+	}
+	return fallback
+}
+
+func trimAll(values []string) []string {
+	var out []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// reviewerOverride reports whether the reviewed positions overturn the
+// verification outcome: the reviewer found issues where the workflow reported
+// no discrepancy, or none where it reported some. ok is false when the
+// outcomes cannot be compared, i.e. the verification has not completed or
+// the review only carries an answer text.
+func reviewerOverride(record VerificationRecord, positions []PositionAnswer) (override, ok bool) {
+	if record.DiscrepantPositions == nil || len(positions) == 0 {
+		return false, false
+	}
+	reviewedIssues := false
+	for _, p := range positions {
+		if strings.TrimSpace(p.Issue) != "" {
+			reviewedIssues = true
+			break
+		}
+	}
+	return reviewedIssues != (*record.DiscrepantPositions > 0), true
+}
+
+// filterExamples returns the examples matching the list query parameters
+// verificationType, tag and status (ACTIVE by default, ALL for every
+// status), newest review first
+func filterExamples(all []Example, params map[string]string) []Example {
+	status := strings.ToUpper(params["status"])
+	if status == "" {
+		status = StatusActive
+	}
+	out := []Example{}
+	for _, e := range all {
+		if status != "ALL" && e.Status != status {
+			continue
+		}
+		if t := params["verificationType"]; t != "" && e.VerificationType != t {
+			continue
+		}
+		if tag := params["tag"]; tag != "" && !containsFold(e.Tags, tag) {
+			continue
+		}
+		out = append(out, e)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].ReviewedAt > out[j].ReviewedAt })
+	return out
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuildExample(t *testing.T) {
+	record := VerificationRecord{
+		VerificationID:    "verif-1",
+		VerificationAt:    "2026-10-17T23:30:00Z",
+		VerificationType:  "LAYOUT_VS_CHECKING",
+		VendingMachineID:  "VM-7",
+		LayoutID:          7,
+		LayoutPrefix:      "abc",
+		ReferenceImageURL: "s3://reference/processed/7/abc/image.png",
+		CheckingImageURL:  "s3://checking/VM-7/photo.JPG",
+	}
+	req := ExampleRequest{
+		ReviewedBy: " reviewer ",
+		Positions:  []PositionAnswer{{Position: "A01"}, {Position: "B02", Expected: "Pepsi", Issue: "Missing"}},
+		Tags:       []string{"lighting:dark", " "},
+		Products:   []string{" Pepsi "},
+	}
+	hanoi := time.FixedZone("ICT", 7*3600)
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+	example, err := buildExample(record, req, hanoi, now)
+	if err != nil {
+		t.Fatalf("buildExample failed: %v", err)
+	}
+	if example.ExampleID != "ex-verif-1" || example.Status != StatusActive || example.ReviewedBy != "reviewer" || example.ReviewedAt != "2026-10-18T09:00:00Z" {
+		t.Errorf("Unexpected example %+v", example)
+	}
+	if want := (ImageRef{Key: "2026/10/18/verif-1/images/reference-base64.base64", Format: "png"}); example.ReferenceImage != want {
+		t.Errorf("Expected the partition in the configured timezone, got %+v", example.ReferenceImage)
+	}
+	if example.CheckingImage.Format != "jpeg" {
+		t.Errorf("Expected a jpeg checking image, got %q", example.CheckingImage.Format)
+	}
+	if !reflect.DeepEqual(example.Tags, []string{"lighting:dark"}) || !reflect.DeepEqual(example.Products, []string{"Pepsi"}) {
+		t.Errorf("Expected trimmed tags and products, got %v %v", example.Tags, example.Products)
+	}
+
+	req.DatePartition = "2026/10/17"
+	if example, _ = buildExample(record, req, time.UTC, now); example.CheckingImage.Key != "2026/10/17/verif-1/images/checking-base64.base64" {
+		t.Errorf("Expected the partition override, got %q", example.CheckingImage.Key)
+	}
+
+	for name, bad := range map[string]ExampleRequest{
+		"reviewer":  {Positions: req.Positions},
+		"positions": {ReviewedBy: "r"},
+		"position":  {ReviewedBy: "r", Positions: []PositionAnswer{{Expected: "Pepsi"}}},
+	} {
+		if _, err := buildExample(record, bad, time.UTC, now); err == nil {
+			t.Errorf("Expected the request without %s to be rejected", name)
+		}
+	}
+}
+
+func TestFilterExamples(t *testing.T) {
+	all := []Example{
+		{ExampleID: "a", VerificationType: "LAYOUT_VS_CHECKING", Status: StatusActive, Tags: []string{"Lighting:Dark"}, ReviewedAt: "2026-10-01T00:00:00Z"},
+		{ExampleID: "b", VerificationType: "PREVIOUS_VS_CURRENT", Status: StatusActive, ReviewedAt: "2026-10-03T00:00:00Z"},
+		{ExampleID: "c", VerificationType: "LAYOUT_VS_CHECKING", Status: StatusRetired, ReviewedAt: "2026-10-02T00:00:00Z"},
+	}
+	ids := func(examples []Example) []string {
+		out := []string{}
+		for _, e := range examples {
+			out = append(out, e.ExampleID)
+		}
+		return out
+	}
+
+	for _, tc := range []struct {
+		params map[string]string
+		want   []string
+	}{
+		{nil, []string{"b", "a"}},
+		{map[string]string{"status": "all"}, []string{"b", "c", "a"}},
+		{map[string]string{"verificationType": "LAYOUT_VS_CHECKING", "status": "ALL"}, []string{"c", "a"}},
+		{map[string]string{"tag": "lighting:dark"}, []string{"a"}},
+		{map[string]string{"status": "retired"}, []string{"c"}},
+	} {
+		if got := ids(filterExamples(all, tc.params)); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("filterExamples(%v) = %v, want %v", tc.params, got, tc.want)
+		}
+	}
+}
+
+func TestReviewerOverride(t *testing.T) {
+	none, two := 0, 2
+	confirmed := []PositionAnswer{{Position: "A01"}}
+	missing := []PositionAnswer{{Position: "A01"}, {Position: "B02", Issue: "Missing"}}
+
+	cases := []struct {
+		name         string
+		discrepant   *int
+		positions    []PositionAnswer
+		wantOverride bool
+		wantOK       bool
+	}{
+		{"issue the workflow missed", &none, missing, true, true},
+		{"discrepancies the reviewer rejected", &two, confirmed, true, true},
+		{"confirmed discrepancies", &two, missing, false, true},
+		{"confirmed correct", &none, confirmed, false, true},
+		{"verification not completed", nil, missing, false, false},
+		{"answer text only", &two, nil, false, false},
+	}
+	for _, c := range cases {
+		record := VerificationRecord{VerificationID: "verif-1", DiscrepantPositions: c.discrepant}
+		override, ok := reviewerOverride(record, c.positions)
+		if override != c.wantOverride || ok != c.wantOK {
+			t.Errorf("%s: got (%v, %v), want (%v, %v)", c.name, override, ok, c.wantOverride, c.wantOK)
+		}
+	}
+}
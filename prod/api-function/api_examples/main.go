@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	log                   *logrus.Logger
+	dynamoClient          *dynamodb.Client
+	verificationTableName string
+	examplesTableName     string
+	partitionLocation     *time.Location
+)
+
+// errNotFound reports a missing verification or example
+var errNotFound = errors.New("not found")
+
+// ErrorResponse represents an error response
+type ErrorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
+}
+
+// ExamplesResponse represents the list response
+type ExamplesResponse struct {
+	Examples []Example `json:"examples"`
+	Total    int       `json:"total"`
+}
+
+// setup loads the configuration and the AWS clients. It runs from main
+// rather than init so the tests do not need the environment.
+func setup() {
+	// Initialize logger
+	log = logrus.New()
+	logLevel := os.Getenv("LOG_LEVEL")
+	if level, err := logrus.ParseLevel(logLevel); err == nil {
+		log.SetLevel(level)
+	} else {
+		log.SetLevel(logrus.InfoLevel)
+	}
+	log.SetFormatter(&logrus.JSONFormatter{})
+
+	// Load environment variables
+	verificationTableName = os.Getenv("DYNAMODB_VERIFICATION_TABLE")
+	if verificationTableName == "" {
+		log.Fatal("DYNAMODB_VERIFICATION_TABLE environment variable is required")
+	}
+	examplesTableName = os.Getenv("FEW_SHOT_EXAMPLES_TABLE")
+	if examplesTableName == "" {
+		log.Fatal("FEW_SHOT_EXAMPLES_TABLE environment variable is required")
+	}
+
+	timezone := os.Getenv("DATE_PARTITION_TIMEZONE")
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	var err error
+	partitionLocation, err = time.LoadLocation(timezone)
+	if err != nil {
+		log.WithError(err).Fatal("invalid DATE_PARTITION_TIMEZONE")
+	}
+
+	log.WithFields(logrus.Fields{
+		"verificationTable": verificationTableName,
+		"examplesTable":     examplesTableName,
+		"timezone":          timezone,
+	}).Info("Environment variables loaded successfully")
+
+	// Initialize AWS clients
+	cfg, err := config.LoadDefaultConfig(context.Background())
+	if err != nil {
+		log.WithError(err).Fatal("unable to load AWS SDK config")
+	}
+
+	dynamoClient = dynamodb.NewFromConfig(cfg)
+}
+
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	log.WithFields(logrus.Fields{
+		"method": request.HTTPMethod,
+		"path":   request.Path,
+		"params": request.PathParameters,
+	}).Info("Few-shot example request received")
+
+	// Set CORS headers
+	headers := map[string]string{
+		"Content-Type":                     "application/json",
+		"Access-Control-Allow-Origin":      "*",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Allow-Headers":     "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token",
+		"Access-Control-Allow-Methods":     "GET,POST,DELETE,OPTIONS",
+	}
+
+	switch request.HTTPMethod {
+	case "OPTIONS":
+		return events.APIGatewayProxyResponse{StatusCode: 200, Headers: headers}, nil
+	case "POST":
+		return handleConfirm(ctx, request, headers)
+	case "GET":
+		return handleList(ctx, request, headers)
+	case "DELETE":
+		return handleRetire(ctx, request, headers)
+	}
+	return createErrorResponse(405, "Method not allowed", "Only GET, POST and DELETE requests are supported", headers)
+}
+
+// handleConfirm stores a reviewed verification as a few-shot example
+func handleConfirm(ctx context.Context, request events.APIGatewayProxyRequest, headers map[string]string) (events.APIGatewayProxyResponse, error) {
+	verificationID := strings.TrimSpace(request.PathParameters["verificationId"])
+	if verificationID == "" {
+		return createErrorResponse(400, "Invalid path parameters", "verificationId is required", headers)
+	}
+	var req ExampleRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return createErrorResponse(400, "Invalid request body", err.Error(), headers)
+	}
+
+	record, err := getVerification(ctx, verificationID)
+	if errors.Is(err, errNotFound) {
+		return createErrorResponse(404, "Verification not found", fmt.Sprintf("verification %s does not exist", verificationID), headers)
+	}
+	if err != nil {
+		log.WithError(err).Error("Failed to get verification")
+		return createErrorResponse(500, "Failed to get verification", err.Error(), headers)
+	}
+
+	now := time.Now()
+	example, err := buildExample(*record, req, partitionLocation, now)
+	if err != nil {
+		return createErrorResponse(400, "Invalid example", err.Error(), headers)
+	}
+	if err := putExample(ctx, example); err != nil {
+		log.WithError(err).Error("Failed to store example")
+		return createErrorResponse(500, "Failed to store example", err.Error(), headers)
+	}
+	if override, ok := reviewerOverride(*record, example.Positions); ok {
+		// The experiment reports read the override from the verification
+		// record; the example is kept when recording it fails
+		if err := recordReviewerOverride(ctx, *record, override); err != nil {
+			log.WithError(err).WithField("verificationId", verificationID).Warn("Failed to record reviewer override")
+		}
+	}
+
+	log.WithFields(logrus.Fields{
+		"exampleId":      example.ExampleID,
+		"verificationId": verificationID,
+		"reviewedBy":     example.ReviewedBy,
+		"positions":      len(example.Positions),
+		"tags":           example.Tags,
+	}).Info("Few-shot example stored")
+	return createJSONResponse(201, example, headers)
+}
+
+// handleList lists the examples matching the query parameters
+func handleList(ctx context.Context, request events.APIGatewayProxyRequest, headers map[string]string) (events.APIGatewayProxyResponse, error) {
+	all, err := scanExamples(ctx)
+	if err != nil {
+		log.WithError(err).Error("Failed to scan examples")
+		return createErrorResponse(500, "Failed to query examples", err.Error(), headers)
+	}
+	examples := filterExamples(all, request.QueryStringParameters)
+	return createJSONResponse(200, ExamplesResponse{Examples: examples, Total: len(examples)}, headers)
+}
+
+// handleRetire retires an example so it is no longer selected
+func handleRetire(ctx context.Context, request events.APIGatewayProxyRequest, headers map[string]string) (events.APIGatewayProxyResponse, error) {
+	id := strings.TrimSpace(request.PathParameters["exampleId"])
+	if id == "" {
+		return createErrorResponse(400, "Invalid path parameters", "exampleId is required", headers)
+	}
+	example, err := retireExample(ctx, id, time.Now())
+	if errors.Is(err, errNotFound) {
+		return createErrorResponse(404, "Example not found", fmt.Sprintf("example %s does not exist", id), headers)
+	}
+	if err != nil {
+		log.WithError(err).Error("Failed to retire example")
+		return createErrorResponse(500, "Failed to retire example", err.Error(), headers)
+	}
+	log.WithField("exampleId", id).Info("Few-shot example retired")
+	return createJSONResponse(200, example, headers)
+}
+
+// getVerification returns the latest record of a verification
+func getVerification(ctx context.Context, verificationID string) (*VerificationRecord, error) {
+	out, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(verificationTableName),
+		KeyConditionExpression: aws.String("verificationId = :id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":id": &types.AttributeValueMemberS{Value: verificationID},
+		},
+		ScanIndexForward: aws.Bool(false),
+		Limit:            aws.Int32(1),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query verification: %w", err)
+	}
+	if len(out.Items) == 0 {
+		return nil, errNotFound
+	}
+	var record VerificationRecord
+	if err := attributevalue.UnmarshalMap(out.Items[0], &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal verification: %w", err)
+	}
+	return &record, nil
+}
+
+// recordReviewerOverride stores whether the review overturned the
+// verification outcome as reviewerOverride on the verification record
+func recordReviewerOverride(ctx context.Context, record VerificationRecord, override bool) error {
+	_, err := dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(verificationTableName),
+		Key: map[string]types.AttributeValue{
+			"verificationId": &types.AttributeValueMemberS{Value: record.VerificationID},
+			"verificationAt": &types.AttributeValueMemberS{Value: record.VerificationAt},
+		},
+		UpdateExpression:    aws.String("SET reviewerOverride = :override"),
+		ConditionExpression: aws.String("attribute_exists(verificationId)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":override": &types.AttributeValueMemberBOOL{Value: override},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update verification: %w", err)
+	}
+	return nil
+}
+
+func putExample(ctx context.Context, example Example) error {
+	item, err := attributevalue.MarshalMap(example)
+	if err != nil {
+		return fmt.Errorf("failed to marshal example: %w", err)
+	}
+	if _, err := dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(examplesTableName),
+		Item:      item,
+	}); err != nil {
+		return fmt.Errorf("failed to put example: %w", err)
+	}
+	return nil
+}
+
+func scanExamples(ctx context.Context) ([]Example, error) {
+	var examples []Example
+	paginator := dynamodb.NewScanPaginator(dynamoClient, &dynamodb.ScanInput{
+		TableName: aws.String(examplesTableName),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan table: %w", err)
+		}
+		for _, item := range page.Items {
+			var example Example
+			if err := attributevalue.UnmarshalMap(item, &example); err != nil {
+				log.WithError(err).Warn("Failed to unmarshal example")
+				continue
+			}
+			examples = append(examples, example)
+		}
+	}
+	return examples, nil
+}
+
+func retireExample(ctx context.Context, id string, now time.Time) (*Example, error) {
+	out, err := dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(examplesTableName),
+		Key: map[string]types.AttributeValue{
+			"exampleId": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:    aws.String("SET exampleStatus = :retired, retiredAt = :now"),
+		ConditionExpression: aws.String("attribute_exists(exampleId)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":retired": &types.AttributeValueMemberS{Value: StatusRetired},
+			":now":     &types.AttributeValueMemberS{Value: now.UTC().Format(time.RFC3339)},
+		},
+		ReturnValues: types.ReturnValueAllNew,
+	})
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return nil, errNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update example: %w", err)
+	}
+	var example Example
+	if err := attributevalue.UnmarshalMap(out.Attributes, &example); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal example: %w", err)
+	}
+	return &example, nil
+}
+
+func createJSONResponse(statusCode int, body interface{}, headers map[string]string) (events.APIGatewayProxyResponse, error) {
+	responseBody, err := json.Marshal(body)
+	if err != nil {
+		log.WithError(err).Error("Failed to marshal response")
+		return createErrorResponse(500, "Internal server error", "Failed to process response", headers)
+	}
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers:    headers,
+		Body:       string(responseBody),
+	}, nil
+}
+
+func createErrorResponse(statusCode int, error, message string, headers map[string]string) (events.APIGatewayProxyResponse, error) {
+	errorResp := ErrorResponse{
+		Error:   error,
+		Message: message,
+		Code:    fmt.Sprintf("HTTP_%d", statusCode),
+	}
+
+	body, _ := json.Marshal(errorResp)
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers:    headers,
+		Body:       string(body),
+	}, nil
+}
+
+func main() {
+	setup()
+	lambda.Start(handler)
+}
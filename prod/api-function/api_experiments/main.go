@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	log                   *logrus.Logger
+	dynamoClient          *dynamodb.Client
+	verificationTableName string
+	modelPricing          map[string]ModelPrice
+)
+
+// reportAttributes are the verification record attributes the report reads
+var reportAttributes = []string{
+	"verificationId", "verificationAt", "verificationStatus", "overallAccuracy",
+	"discrepantPositions", "reviewerOverride", "experimentAssignments",
+	"turn1ExperimentUsage", "turn2ExperimentUsage",
+}
+
+// ErrorResponse represents an error response
+type ErrorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
+}
+
+// setup loads the configuration and the AWS clients. It runs from main
+// rather than init so the tests do not need the environment.
+func setup() {
+	// Initialize logger
+	log = logrus.New()
+	logLevel := os.Getenv("LOG_LEVEL")
+	if level, err := logrus.ParseLevel(logLevel); err == nil {
+		log.SetLevel(level)
+	} else {
+		log.SetLevel(logrus.InfoLevel)
+	}
+	log.SetFormatter(&logrus.JSONFormatter{})
+
+	// Load environment variables
+	verificationTableName = os.Getenv("DYNAMODB_VERIFICATION_TABLE")
+	if verificationTableName == "" {
+		log.Fatal("DYNAMODB_VERIFICATION_TABLE environment variable is required")
+	}
+
+	var err error
+	modelPricing, err = parseModelPricing(os.Getenv("MODEL_PRICING"))
+	if err != nil {
+		log.WithError(err).Fatal("unable to load model pricing")
+	}
+
+	log.WithFields(logrus.Fields{
+		"verificationTable": verificationTableName,
+		"pricedModels":      len(modelPricing),
+	}).Info("Environment variables loaded successfully")
+
+	// Initialize AWS clients
+	cfg, err := config.LoadDefaultConfig(context.Background())
+	if err != nil {
+		log.WithError(err).Fatal("unable to load AWS SDK config")
+	}
+
+	dynamoClient = dynamodb.NewFromConfig(cfg)
+}
+
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	log.WithFields(logrus.Fields{
+		"method": request.HTTPMethod,
+		"path":   request.Path,
+		"params": request.PathParameters,
+	}).Info("Experiment report request received")
+
+	// Set CORS headers
+	headers := map[string]string{
+		"Content-Type":                     "application/json",
+		"Access-Control-Allow-Origin":      "*",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Allow-Headers":     "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token",
+		"Access-Control-Allow-Methods":     "GET,OPTIONS",
+	}
+
+	// Handle OPTIONS request for CORS
+	if request.HTTPMethod == "OPTIONS" {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 200,
+			Headers:    headers,
+			Body:       "",
+		}, nil
+	}
+
+	// Only allow GET requests
+	if request.HTTPMethod != "GET" {
+		return createErrorResponse(405, "Method not allowed", "Only GET requests are supported", headers)
+	}
+
+	experimentID := strings.TrimSpace(request.PathParameters["experimentId"])
+	if experimentID == "" {
+		return createErrorResponse(400, "Invalid path parameters", "experimentId is required", headers)
+	}
+
+	records, err := scanExperimentRecords(ctx, experimentID, request.QueryStringParameters)
+	if err != nil {
+		log.WithError(err).Error("Failed to scan experiment records")
+		return createErrorResponse(500, "Failed to query verifications", err.Error(), headers)
+	}
+
+	report := buildReport(experimentID, records, modelPricing)
+	if report.Total == 0 {
+		return createErrorResponse(404, "Experiment not found", fmt.Sprintf("no verifications are assigned to experiment %s", experimentID), headers)
+	}
+
+	// Convert response to JSON
+	responseBody, err := json.Marshal(report)
+	if err != nil {
+		log.WithError(err).Error("Failed to marshal response")
+		return createErrorResponse(500, "Internal server error", "Failed to process response", headers)
+	}
+
+	log.WithFields(logrus.Fields{
+		"experimentId": experimentID,
+		"total":        report.Total,
+		"variants":     len(report.Variants),
+	}).Info("Experiment report request completed successfully")
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers:    headers,
+		Body:       string(responseBody),
+	}, nil
+}
+
+// scanExperimentRecords scans the verification table for the records assigned
+// to experimentID, optionally bounded by the fromDate and toDate parameters
+func scanExperimentRecords(ctx context.Context, experimentID string, params map[string]string) ([]ExperimentRecord, error) {
+	filterExpressions := []string{"attribute_exists(#assignments.#experimentId)"}
+	expressionAttributeNames := map[string]string{
+		"#assignments":  "experimentAssignments",
+		"#experimentId": experimentID,
+	}
+	expressionAttributeValues := make(map[string]types.AttributeValue)
+
+	if fromDate := params["fromDate"]; fromDate != "" {
+		filterExpressions = append(filterExpressions, "verificationAt >= :fromDate")
+		expressionAttributeValues[":fromDate"] = &types.AttributeValueMemberS{Value: fromDate}
+	}
+	if toDate := params["toDate"]; toDate != "" {
+		filterExpressions = append(filterExpressions, "verificationAt <= :toDate")
+		expressionAttributeValues[":toDate"] = &types.AttributeValueMemberS{Value: toDate}
+	}
+
+	projection := make([]string, len(reportAttributes))
+	for i, attribute := range reportAttributes {
+		name := "#p" + fmt.Sprint(i)
+		expressionAttributeNames[name] = attribute
+		projection[i] = name
+	}
+
+	input := &dynamodb.ScanInput{
+		TableName:                aws.String(verificationTableName),
+		FilterExpression:         aws.String(strings.Join(filterExpressions, " AND ")),
+		ProjectionExpression:     aws.String(strings.Join(projection, ", ")),
+		ExpressionAttributeNames: expressionAttributeNames,
+	}
+	if len(expressionAttributeValues) > 0 {
+		input.ExpressionAttributeValues = expressionAttributeValues
+	}
+
+	log.WithFields(logrus.Fields{
+		"experimentId": experimentID,
+		"filterExpr":   input.FilterExpression,
+	}).Debug("Scanning DynamoDB table")
+
+	var records []ExperimentRecord
+	paginator := dynamodb.NewScanPaginator(dynamoClient, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan table: %w", err)
+		}
+		for _, item := range page.Items {
+			var record ExperimentRecord
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				log.WithError(err).Warn("Failed to unmarshal verification record")
+				continue
+			}
+			records = append(records, record)
+		}
+	}
+
+	return records, nil
+}
+
+func createErrorResponse(statusCode int, error, message string, headers map[string]string) (events.APIGatewayProxyResponse, error) {
+	errorResp := ErrorResponse{
+		Error:   error,
+		Message: message,
+		Code:    fmt.Sprintf("HTTP_%d", statusCode),
+	}
+
+	body, _ := json.Marshal(errorResp)
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers:    headers,
+		Body:       string(body),
+	}, nil
+}
+
+func main() {
+	setup()
+	lambda.Start(handler)
+}
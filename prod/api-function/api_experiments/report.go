@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Assignment mirrors the experiment assignment the workflow functions store
+// under experimentAssignments on the verification record
+type Assignment struct {
+	ExperimentID    string   `json:"experimentId" dynamodbav:"experimentId"`
+	Variant         string   `json:"variant" dynamodbav:"variant"`
+	TemplateType    string   `json:"templateType" dynamodbav:"templateType"`
+	TemplateVersion string   `json:"templateVersion,omitempty" dynamodbav:"templateVersion,omitempty"`
+	ModelID         string   `json:"modelId,omitempty" dynamodbav:"modelId,omitempty"`
+	Temperature     *float64 `json:"temperature,omitempty" dynamodbav:"temperature,omitempty"`
+}
+
+// TurnUsage mirrors the per-turn model and token usage the turn functions
+// store as turn1ExperimentUsage and turn2ExperimentUsage
+type TurnUsage struct {
+	ModelID        string `json:"modelId" dynamodbav:"modelId"`
+	InputTokens    int    `json:"inputTokens" dynamodbav:"inputTokens"`
+	OutputTokens   int    `json:"outputTokens" dynamodbav:"outputTokens"`
+	ThinkingTokens int    `json:"thinkingTokens,omitempty" dynamodbav:"thinkingTokens,omitempty"`
+	TotalTokens    int    `json:"totalTokens" dynamodbav:"totalTokens"`
+}
+
+// ExperimentRecord holds the verification record attributes the report reads
+type ExperimentRecord struct {
+	VerificationID        string                `dynamodbav:"verificationId"`
+	VerificationAt        string                `dynamodbav:"verificationAt"`
+	VerificationStatus    string                `dynamodbav:"verificationStatus"`
+	OverallAccuracy       *float64              `dynamodbav:"overallAccuracy,omitempty"`
+	DiscrepantPositions   *int                  `dynamodbav:"discrepantPositions,omitempty"`
+	ReviewerOverride      *bool                 `dynamodbav:"reviewerOverride,omitempty"`
+	ExperimentAssignments map[string]Assignment `dynamodbav:"experimentAssignments"`
+	Turn1ExperimentUsage  *TurnUsage            `dynamodbav:"turn1ExperimentUsage,omitempty"`
+	Turn2ExperimentUsage  *TurnUsage            `dynamodbav:"turn2ExperimentUsage,omitempty"`
+}
+
+// ModelPrice is the on-demand price of a model in USD per 1,000 tokens.
+// Output tokens include extended thinking tokens.
+type ModelPrice struct {
+	InputPer1K  float64 `json:"inputPer1K"`
+	OutputPer1K float64 `json:"outputPer1K"`
+}
+
+// ExperimentReport represents the API response structure
+type ExperimentReport struct {
+	ExperimentID string          `json:"experimentId"`
+	TemplateType string          `json:"templateType,omitempty"`
+	Total        int             `json:"total"`
+	Variants     []VariantReport `json:"variants"`
+}
+
+// VariantReport holds the outcomes of the verifications assigned to one variant
+type VariantReport struct {
+	Variant         string   `json:"variant"`
+	TemplateVersion string   `json:"templateVersion,omitempty"`
+	ModelID         string   `json:"modelId,omitempty"`
+	Temperature     *float64 `json:"temperature,omitempty"`
+
+	Verifications int `json:"verifications"`
+	// Completed counts the verifications with an overall accuracy; the
+	// accuracy and discrepancy figures are computed over them
+	Completed            int      `json:"completed"`
+	AverageAccuracy      float64  `json:"averageAccuracy"`
+	DiscrepancyRate      float64  `json:"discrepancyRate"`
+	AverageDiscrepancies float64  `json:"averageDiscrepantPositions"`
+	Reviewed             int      `json:"reviewed"`
+	ReviewerOverrides    int      `json:"reviewerOverrides"`
+	ReviewerOverrideRate float64  `json:"reviewerOverrideRate"`
+	InputTokens          int      `json:"inputTokens"`
+	OutputTokens         int      `json:"outputTokens"`
+	ThinkingTokens       int      `json:"thinkingTokens"`
+	EstimatedCostUSD     float64  `json:"estimatedCostUsd"`
+	AverageCostUSD       float64  `json:"averageCostUsd"`
+	UnpricedModels       []string `json:"unpricedModels,omitempty"`
+}
+
+// parseModelPricing reads the MODEL_PRICING JSON object of model ID to price
+func parseModelPricing(data string) (map[string]ModelPrice, error) {
+	pricing := make(map[string]ModelPrice)
+	if data == "" {
+		return pricing, nil
+	}
+	if err := json.Unmarshal([]byte(data), &pricing); err != nil {
+		return nil, fmt.Errorf("invalid MODEL_PRICING: %w", err)
+	}
+	return pricing, nil
+}
+
+// buildReport aggregates the records assigned to experimentID by variant.
+// Variants are sorted by name and rates are fractions between 0 and 1.
+func buildReport(experimentID string, records []ExperimentRecord, pricing map[string]ModelPrice) *ExperimentReport {
+	report := &ExperimentReport{ExperimentID: experimentID, Variants: []VariantReport{}}
+
+	type totals struct {
+		VariantReport
+		accuracySum     float64
+		discrepancySum  int
+		withDiscrepancy int
+		unpriced        map[string]bool
+	}
+	byVariant := make(map[string]*totals)
+
+	for _, record := range records {
+		assignment, ok := record.ExperimentAssignments[experimentID]
+		if !ok {
+			continue
+		}
+		report.Total++
+		if report.TemplateType == "" {
+			report.TemplateType = assignment.TemplateType
+		}
+
+		t, ok := byVariant[assignment.Variant]
+		if !ok {
+			t = &totals{
+				VariantReport: VariantReport{
+					Variant:         assignment.Variant,
+					TemplateVersion: assignment.TemplateVersion,
+					ModelID:         assignment.ModelID,
+					Temperature:     assignment.Temperature,
+				},
+				unpriced: make(map[string]bool),
+			}
+			byVariant[assignment.Variant] = t
+		}
+		t.Verifications++
+
+		if record.OverallAccuracy != nil {
+			t.Completed++
+			t.accuracySum += *record.OverallAccuracy
+			if record.DiscrepantPositions != nil {
+				t.discrepancySum += *record.DiscrepantPositions
+				if *record.DiscrepantPositions > 0 {
+					t.withDiscrepancy++
+				}
+			}
+		}
+
+		if record.ReviewerOverride != nil {
+			t.Reviewed++
+			if *record.ReviewerOverride {
+				t.ReviewerOverrides++
+			}
+		}
+
+		for _, usage := range []*TurnUsage{record.Turn1ExperimentUsage, record.Turn2ExperimentUsage} {
+			if usage == nil {
+				continue
+			}
+			t.InputTokens += usage.InputTokens
+			t.OutputTokens += usage.OutputTokens
+			t.ThinkingTokens += usage.ThinkingTokens
+			price, ok := pricing[usage.ModelID]
+			if !ok {
+				t.unpriced[usage.ModelID] = true
+				continue
+			}
+			t.EstimatedCostUSD += float64(usage.InputTokens)/1000*price.InputPer1K +
+				float64(usage.OutputTokens)/1000*price.OutputPer1K
+		}
+	}
+
+	for _, t := range byVariant {
+		v := t.VariantReport
+		if v.Completed > 0 {
+			v.AverageAccuracy = t.accuracySum / float64(v.Completed)
+			v.DiscrepancyRate = float64(t.withDiscrepancy) / float64(v.Completed)
+			v.AverageDiscrepancies = float64(t.discrepancySum) / float64(v.Completed)
+		}
+		if v.Reviewed > 0 {
+			v.ReviewerOverrideRate = float64(v.ReviewerOverrides) / float64(v.Reviewed)
+		}
+		v.AverageCostUSD = v.EstimatedCostUSD / float64(v.Verifications)
+		for model := range t.unpriced {
+			v.UnpricedModels = append(v.UnpricedModels, model)
+		}
+		sort.Strings(v.UnpricedModels)
+		report.Variants = append(report.Variants, v)
+	}
+	sort.Slice(report.Variants, func(i, j int) bool {
+		return report.Variants[i].Variant < report.Variants[j].Variant
+	})
+
+	return report
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBuildReportAggregatesByVariant(t *testing.T) {
+	accuracy := func(v float64) *float64 { return &v }
+	count := func(v int) *int { return &v }
+	override := func(v bool) *bool { return &v }
+	assigned := func(variant string) map[string]Assignment {
+		return map[string]Assignment{
+			"pvc-v12": {ExperimentID: "pvc-v12", Variant: variant, TemplateType: "turn2-previous-vs-current"},
+			"other":   {ExperimentID: "other", Variant: "a"},
+		}
+	}
+
+	records := []ExperimentRecord{
+		{
+			VerificationID:        "v1",
+			OverallAccuracy:       accuracy(90),
+			DiscrepantPositions:   count(2),
+			ReviewerOverride:      override(true),
+			ExperimentAssignments: assigned("control"),
+			Turn1ExperimentUsage:  &TurnUsage{ModelID: "model-a", InputTokens: 1000, OutputTokens: 500},
+			Turn2ExperimentUsage:  &TurnUsage{ModelID: "model-a", InputTokens: 2000, OutputTokens: 500},
+		},
+		{
+			VerificationID:        "v2",
+			OverallAccuracy:       accuracy(100),
+			DiscrepantPositions:   count(0),
+			ReviewerOverride:      override(false),
+			ExperimentAssignments: assigned("control"),
+		},
+		{
+			VerificationID:        "v3",
+			ExperimentAssignments: assigned("treatment"),
+			Turn1ExperimentUsage:  &TurnUsage{ModelID: "model-b", InputTokens: 100, OutputTokens: 100},
+		},
+		{VerificationID: "v4", ExperimentAssignments: map[string]Assignment{"other": {Variant: "a"}}},
+	}
+	pricing := map[string]ModelPrice{"model-a": {InputPer1K: 0.003, OutputPer1K: 0.015}}
+
+	report := buildReport("pvc-v12", records, pricing)
+	if report.Total != 3 || report.TemplateType != "turn2-previous-vs-current" || len(report.Variants) != 2 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+
+	control := report.Variants[0]
+	if control.Variant != "control" || control.Verifications != 2 || control.Completed != 2 {
+		t.Fatalf("Unexpected control variant %+v", control)
+	}
+	if control.AverageAccuracy != 95 || control.DiscrepancyRate != 0.5 || control.AverageDiscrepancies != 1 {
+		t.Errorf("Unexpected control outcomes %+v", control)
+	}
+	if control.Reviewed != 2 || control.ReviewerOverrideRate != 0.5 {
+		t.Errorf("Unexpected control override rate %+v", control)
+	}
+	if math.Abs(control.EstimatedCostUSD-0.024) > 1e-9 || math.Abs(control.AverageCostUSD-0.012) > 1e-9 {
+		t.Errorf("Expected $0.024 total cost, got %f", control.EstimatedCostUSD)
+	}
+
+	treatment := report.Variants[1]
+	if treatment.Completed != 0 || treatment.Reviewed != 0 || treatment.EstimatedCostUSD != 0 {
+		t.Errorf("Unexpected treatment variant %+v", treatment)
+	}
+	if len(treatment.UnpricedModels) != 1 || treatment.UnpricedModels[0] != "model-b" {
+		t.Errorf("Expected model-b to be reported unpriced, got %v", treatment.UnpricedModels)
+	}
+}
+
+func TestParseModelPricing(t *testing.T) {
+	if pricing, err := parseModelPricing(""); err != nil || len(pricing) != 0 {
+		t.Errorf("Expected empty pricing, got %v %v", pricing, err)
+	}
+	pricing, err := parseModelPricing(`{"model-a": {"inputPer1K": 0.003, "outputPer1K": 0.015}}`)
+	if err != nil || pricing["model-a"].OutputPer1K != 0.015 {
+		t.Errorf("Unexpected pricing %v %v", pricing, err)
+	}
+	if _, err := parseModelPricing(`[1]`); err == nil {
+		t.Error("Expected invalid pricing to fail")
+	}
+}
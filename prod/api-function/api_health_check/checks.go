@@ -0,0 +1,447 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsarn "github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	brtypes "github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	sfntypes "github.com/aws/aws-sdk-go-v2/service/sfn/types"
+)
+
+// Health check modes selected with the `mode` query parameter.
+const (
+	ModeLiveness  = "liveness"  // process is up, no dependency calls
+	ModeReadiness = "readiness" // configured tables and buckets are reachable
+	ModeDeep      = "deep"      // readiness plus Bedrock, state machine and template probes
+)
+
+const (
+	StatusHealthy   = "healthy"
+	StatusDegraded  = "degraded"
+	StatusUnhealthy = "unhealthy"
+	StatusSkipped   = "skipped"
+)
+
+// DynamoDBAPI is the subset of the DynamoDB client used by the health check.
+type DynamoDBAPI interface {
+	DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error)
+}
+
+// S3API is the subset of the S3 client used by the health check.
+type S3API interface {
+	HeadBucket(ctx context.Context, params *s3.HeadBucketInput, optFns ...func(*s3.Options)) (*s3.HeadBucketOutput, error)
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+}
+
+// BedrockAPI is the subset of the Bedrock runtime client used by the deep probe.
+type BedrockAPI interface {
+	Converse(ctx context.Context, params *bedrockruntime.ConverseInput, optFns ...func(*bedrockruntime.Options)) (*bedrockruntime.ConverseOutput, error)
+}
+
+// StateMachineAPI is the subset of the Step Functions client used by the deep check.
+type StateMachineAPI interface {
+	DescribeStateMachine(ctx context.Context, params *sfn.DescribeStateMachineInput, optFns ...func(*sfn.Options)) (*sfn.DescribeStateMachineOutput, error)
+}
+
+// namedResource is a configured dependency; Required resources are reported
+// as unhealthy when they are not configured.
+type namedResource struct {
+	Name     string
+	Value    string
+	Required bool
+}
+
+// CheckerConfig lists the dependencies verified by the health check.
+type CheckerConfig struct {
+	Tables          []namedResource
+	Buckets         []namedResource
+	BedrockModel    string
+	StateMachineArn string
+	TemplatePaths   []string
+	BedrockProbeTTL time.Duration
+	// BedrockProbeFailureTTL caches failed probes for a shorter time, so a
+	// restored model access is reported soon
+	BedrockProbeFailureTTL time.Duration
+}
+
+// DependencyCheck is the result of a single dependency call.
+type DependencyCheck struct {
+	Name      string `json:"name"`
+	Target    string `json:"target,omitempty"`
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Message   string `json:"message,omitempty"`
+}
+
+// HealthChecker runs the dependency checks against injectable clients.
+type HealthChecker struct {
+	dynamo  DynamoDBAPI
+	s3      S3API
+	bedrock BedrockAPI
+	states  StateMachineAPI
+	cfg     CheckerConfig
+
+	now  func() time.Time
+	stat func(string) (os.FileInfo, error)
+
+	mu          sync.Mutex
+	bedrockLast *bedrockProbe
+}
+
+type bedrockProbe struct {
+	info ServiceInfo
+	at   time.Time
+}
+
+// NewHealthChecker creates a HealthChecker. Any client may be nil, in which
+// case the checks that need it are reported as skipped.
+func NewHealthChecker(dynamo DynamoDBAPI, s3Client S3API, bedrock BedrockAPI, states StateMachineAPI, cfg CheckerConfig) *HealthChecker {
+	if cfg.BedrockProbeTTL <= 0 {
+		cfg.BedrockProbeTTL = 5 * time.Minute
+	}
+	if cfg.BedrockProbeFailureTTL <= 0 {
+		cfg.BedrockProbeFailureTTL = 30 * time.Second
+	}
+	if cfg.BedrockProbeFailureTTL > cfg.BedrockProbeTTL {
+		cfg.BedrockProbeFailureTTL = cfg.BedrockProbeTTL
+	}
+	return &HealthChecker{
+		dynamo:  dynamo,
+		s3:      s3Client,
+		bedrock: bedrock,
+		states:  states,
+		cfg:     cfg,
+		now:     time.Now,
+		stat:    os.Stat,
+	}
+}
+
+// Run executes the checks for mode and returns the aggregated response.
+func (h *HealthChecker) Run(ctx context.Context, mode string) HealthResponse {
+	response := HealthResponse{
+		Status:    StatusHealthy,
+		Mode:      mode,
+		Version:   healthCheckVersion,
+		Timestamp: h.now().UTC().Format(time.RFC3339),
+		Services:  make(map[string]ServiceInfo),
+		Ready:     true,
+	}
+	if mode == ModeLiveness {
+		return response
+	}
+
+	checks := map[string]func(context.Context) ServiceInfo{
+		"dynamodb": h.checkDynamoDB,
+		"s3":       h.checkS3,
+	}
+	if mode == ModeDeep {
+		checks["bedrock"] = h.probeBedrock
+		checks["stepfunctions"] = h.checkStateMachine
+		checks["templates"] = h.checkTemplates
+	} else {
+		checks["bedrock"] = h.checkBedrockConfig
+	}
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) ServiceInfo) {
+			defer wg.Done()
+			info := check(ctx)
+			mu.Lock()
+			response.Services[name] = info
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	for _, info := range response.Services {
+		if info.Status == StatusUnhealthy || info.Status == StatusDegraded {
+			response.Status = StatusDegraded
+			response.Ready = false
+		}
+	}
+	return response
+}
+
+// timed runs fn and returns a DependencyCheck with its latency.
+func (h *HealthChecker) timed(name, target string, fn func() error) DependencyCheck {
+	start := h.now()
+	err := fn()
+	check := DependencyCheck{
+		Name:      name,
+		Target:    target,
+		Status:    StatusHealthy,
+		LatencyMs: h.now().Sub(start).Milliseconds(),
+	}
+	if err != nil {
+		check.Status = StatusUnhealthy
+		check.Message = err.Error()
+	}
+	return check
+}
+
+// summarize folds per-resource checks into a ServiceInfo. The message names
+// the first failing resource, matching the shallow check's wording.
+func summarize(checks []DependencyCheck, okMessage, kind string) ServiceInfo {
+	info := ServiceInfo{Status: StatusHealthy, Message: okMessage, Checks: checks}
+	for _, c := range checks {
+		info.LatencyMs += c.LatencyMs
+		if c.Status == StatusUnhealthy && info.Status == StatusHealthy {
+			info.Status = StatusUnhealthy
+			info.Message = fmt.Sprintf("Failed to access %s %s: %s", strings.ReplaceAll(c.Name, "_", " "), kind, c.Message)
+		}
+	}
+	return info
+}
+
+func (h *HealthChecker) checkDynamoDB(ctx context.Context) ServiceInfo {
+	details := make(map[string]string)
+	var checks []DependencyCheck
+	for _, table := range h.cfg.Tables {
+		if table.Value == "" {
+			if table.Required {
+				checks = append(checks, DependencyCheck{Name: table.Name, Status: StatusUnhealthy, Message: "not configured"})
+			}
+			continue
+		}
+		details[table.Name+"_table"] = table.Value
+		if h.dynamo == nil {
+			checks = append(checks, DependencyCheck{Name: table.Name, Target: table.Value, Status: StatusSkipped})
+			continue
+		}
+		name := table.Value
+		checks = append(checks, h.timed(table.Name, name, func() error {
+			out, err := h.dynamo.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(name)})
+			if err != nil {
+				return err
+			}
+			if out.Table != nil && out.Table.TableStatus == "DELETING" {
+				return fmt.Errorf("table is being deleted")
+			}
+			return nil
+		}))
+	}
+	info := summarize(checks, "DynamoDB tables accessible", "table")
+	info.Details = details
+	if info.Status != StatusHealthy {
+		log.WithField("message", info.Message).Error("DynamoDB health check failed")
+	}
+	return info
+}
+
+func (h *HealthChecker) checkS3(ctx context.Context) ServiceInfo {
+	details := make(map[string]string)
+	var checks []DependencyCheck
+	for _, bucket := range h.cfg.Buckets {
+		if bucket.Value == "" {
+			if bucket.Required {
+				checks = append(checks, DependencyCheck{Name: bucket.Name, Status: StatusUnhealthy, Message: "not configured"})
+			}
+			continue
+		}
+		details[bucket.Name+"_bucket"] = bucket.Value
+		if h.s3 == nil {
+			checks = append(checks, DependencyCheck{Name: bucket.Name, Target: bucket.Value, Status: StatusSkipped})
+			continue
+		}
+		name := bucket.Value
+		checks = append(checks, h.timed(bucket.Name, name, func() error {
+			_, err := h.s3.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(name)})
+			return err
+		}))
+	}
+	info := summarize(checks, "S3 buckets accessible", "bucket")
+	info.Details = details
+	if info.Status != StatusHealthy {
+		log.WithField("message", info.Message).Error("S3 health check failed")
+	}
+	return info
+}
+
+// checkBedrockConfig is the shallow Bedrock check: the model must be configured.
+func (h *HealthChecker) checkBedrockConfig(ctx context.Context) ServiceInfo {
+	info := ServiceInfo{
+		Status:  StatusHealthy,
+		Message: "Bedrock model configured",
+		Details: map[string]string{"model_id": h.cfg.BedrockModel},
+	}
+	if h.cfg.BedrockModel == "" {
+		info.Status = StatusUnhealthy
+		info.Message = "Bedrock model ID is not configured"
+	}
+	return info
+}
+
+// probeBedrock verifies model access with a 1-token Converse call, which works
+// for any model family the Converse API supports. Results are
+// cached for BedrockProbeTTL, failures for BedrockProbeFailureTTL, so frequent
+// deep checks do not consume quota. The cache lock is not held during the
+// invocation; concurrent checks with an expired cache may both probe.
+func (h *HealthChecker) probeBedrock(ctx context.Context) ServiceInfo {
+	info := h.checkBedrockConfig(ctx)
+	if info.Status != StatusHealthy {
+		return info
+	}
+	if h.bedrock == nil {
+		info.Status = StatusSkipped
+		info.Message = "Bedrock client not configured"
+		return info
+	}
+
+	if cached, ok := h.cachedBedrockProbe(); ok {
+		return cached
+	}
+
+	check := h.timed("Bedrock", h.cfg.BedrockModel, func() error {
+		_, err := h.bedrock.Converse(ctx, probeInput(h.cfg.BedrockModel))
+		return err
+	})
+	info = summarize([]DependencyCheck{check}, "Bedrock model invocable", "model")
+	info.Details = map[string]string{"model_id": h.cfg.BedrockModel}
+	if info.Status != StatusHealthy {
+		log.WithField("message", info.Message).Error("Bedrock probe failed")
+	}
+	h.mu.Lock()
+	h.bedrockLast = &bedrockProbe{info: info, at: h.now()}
+	h.mu.Unlock()
+	return info
+}
+
+// cachedBedrockProbe returns the last probe result while it is fresh
+func (h *HealthChecker) cachedBedrockProbe() (ServiceInfo, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	last := h.bedrockLast
+	if last == nil {
+		return ServiceInfo{}, false
+	}
+	ttl := h.cfg.BedrockProbeTTL
+	if last.info.Status != StatusHealthy {
+		ttl = h.cfg.BedrockProbeFailureTTL
+	}
+	if h.now().Sub(last.at) >= ttl {
+		return ServiceInfo{}, false
+	}
+	cached := last.info
+	cached.Cached = true
+	return cached, true
+}
+
+// probeInput is a minimal Converse request limited to one output token.
+func probeInput(modelID string) *bedrockruntime.ConverseInput {
+	return &bedrockruntime.ConverseInput{
+		ModelId: aws.String(modelID),
+		Messages: []brtypes.Message{{
+			Role:    brtypes.ConversationRoleUser,
+			Content: []brtypes.ContentBlock{&brtypes.ContentBlockMemberText{Value: "ping"}},
+		}},
+		InferenceConfig: &brtypes.InferenceConfiguration{MaxTokens: aws.Int32(1)},
+	}
+}
+
+func (h *HealthChecker) checkStateMachine(ctx context.Context) ServiceInfo {
+	if h.cfg.StateMachineArn == "" {
+		return ServiceInfo{Status: StatusUnhealthy, Message: "State machine ARN is not configured"}
+	}
+	if h.states == nil {
+		return ServiceInfo{Status: StatusSkipped, Message: "Step Functions client not configured"}
+	}
+	arn := h.cfg.StateMachineArn
+	var smStatus string
+	check := h.timed("Step Functions", arn, func() error {
+		out, err := h.states.DescribeStateMachine(ctx, &sfn.DescribeStateMachineInput{
+			StateMachineArn: aws.String(arn),
+		}, stateMachineRegion(arn))
+		if err != nil {
+			return err
+		}
+		smStatus = string(out.Status)
+		if out.Status != "" && out.Status != sfntypes.StateMachineStatusActive {
+			return fmt.Errorf("state machine status is %s", smStatus)
+		}
+		return nil
+	})
+	info := summarize([]DependencyCheck{check}, "State machine active", "state machine")
+	info.Details = map[string]string{"state_machine_arn": arn, "state_machine_status": smStatus}
+	if info.Status != StatusHealthy {
+		log.WithField("message", info.Message).Error("Step Functions health check failed")
+	}
+	return info
+}
+
+// stateMachineRegion calls Step Functions in the region of the state machine,
+// which may differ from the region of the function
+func stateMachineRegion(arn string) func(*sfn.Options) {
+	return func(o *sfn.Options) {
+		if parsed, err := awsarn.Parse(arn); err == nil && parsed.Region != "" {
+			o.Region = parsed.Region
+		}
+	}
+}
+
+// checkTemplates verifies each configured template path. Paths of the form
+// s3://bucket/key are checked with HeadObject, anything else on the local
+// filesystem.
+func (h *HealthChecker) checkTemplates(ctx context.Context) ServiceInfo {
+	if len(h.cfg.TemplatePaths) == 0 {
+		return ServiceInfo{Status: StatusSkipped, Message: "No template paths configured"}
+	}
+	checks := make([]DependencyCheck, 0, len(h.cfg.TemplatePaths))
+	for _, path := range h.cfg.TemplatePaths {
+		path := path
+		if bucket, key, ok := parseS3Path(path); ok {
+			if h.s3 == nil {
+				checks = append(checks, DependencyCheck{Name: "template", Target: path, Status: StatusSkipped})
+				continue
+			}
+			checks = append(checks, h.timed("template", path, func() error {
+				_, err := h.s3.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+				return err
+			}))
+			continue
+		}
+		checks = append(checks, h.timed("template", path, func() error {
+			_, err := h.stat(path)
+			return err
+		}))
+	}
+	info := summarize(checks, "Template paths accessible", "path")
+	if info.Status != StatusHealthy {
+		log.WithField("message", info.Message).Error("Template health check failed")
+	}
+	return info
+}
+
+func parseS3Path(path string) (bucket, key string, ok bool) {
+	rest, found := strings.CutPrefix(path, "s3://")
+	if !found {
+		return "", "", false
+	}
+	bucket, key, _ = strings.Cut(rest, "/")
+	return bucket, key, bucket != ""
+}
+
+// splitList parses a comma-separated environment value.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -3,45 +3,44 @@ package main
 import (
 	"context"
 	"encoding/json"
-
-	//"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
 	"github.com/sirupsen/logrus"
 )
 
+// healthCheckVersion is reported in every response.
+const healthCheckVersion = "1.2.1"
+
 var (
-	log               *logrus.Logger
-	dynamoClient      *dynamodb.Client
-	s3Client          *s3.Client
-	bedrockClient     *bedrockruntime.Client
-	verificationTable string
-	conversationTable string
-	referenceBucket   string
-	checkingBucket    string
-	resultsBucket     string
-	bedrockModel      string
+	log     *logrus.Logger
+	checker *HealthChecker
 )
 
 type HealthResponse struct {
 	Status    string                 `json:"status"`
+	Mode      string                 `json:"mode"`
+	Ready     bool                   `json:"ready"`
 	Version   string                 `json:"version"`
 	Timestamp string                 `json:"timestamp"`
 	Services  map[string]ServiceInfo `json:"services"`
 }
 
 type ServiceInfo struct {
-	Status  string      `json:"status"`
-	Message string      `json:"message,omitempty"`
-	Details interface{} `json:"details,omitempty"`
+	Status    string            `json:"status"`
+	Message   string            `json:"message,omitempty"`
+	Details   interface{}       `json:"details,omitempty"`
+	LatencyMs int64             `json:"latency_ms"`
+	Cached    bool              `json:"cached,omitempty"`
+	Checks    []DependencyCheck `json:"checks,omitempty"`
 }
 
 func init() {
@@ -56,21 +55,41 @@ func init() {
 	log.SetFormatter(&logrus.JSONFormatter{})
 
 	// Load environment variables
-	verificationTable = os.Getenv("DYNAMODB_VERIFICATION_TABLE")
-	conversationTable = os.Getenv("DYNAMODB_CONVERSATION_TABLE")
-	referenceBucket = os.Getenv("REFERENCE_BUCKET")
-	checkingBucket = os.Getenv("CHECKING_BUCKET")
-	resultsBucket = os.Getenv("RESULTS_BUCKET")
-	bedrockModel = os.Getenv("BEDROCK_MODEL")
+	checkerConfig := CheckerConfig{
+		Tables: []namedResource{
+			{Name: "verification", Value: os.Getenv("DYNAMODB_VERIFICATION_TABLE"), Required: true},
+			{Name: "conversation", Value: os.Getenv("DYNAMODB_CONVERSATION_TABLE"), Required: true},
+			{Name: "layout", Value: os.Getenv("DYNAMODB_LAYOUT_TABLE")},
+		},
+		Buckets: []namedResource{
+			{Name: "reference", Value: os.Getenv("REFERENCE_BUCKET"), Required: true},
+			{Name: "checking", Value: os.Getenv("CHECKING_BUCKET"), Required: true},
+			{Name: "results", Value: os.Getenv("RESULTS_BUCKET"), Required: true},
+			{Name: "state", Value: os.Getenv("STATE_BUCKET")},
+		},
+		BedrockModel:    os.Getenv("BEDROCK_MODEL"),
+		StateMachineArn: os.Getenv("STEP_FUNCTIONS_STATE_MACHINE_ARN"),
+		TemplatePaths:   splitList(os.Getenv("TEMPLATE_PATHS")),
+	}
+	if ttl, err := time.ParseDuration(os.Getenv("BEDROCK_PROBE_CACHE_TTL")); err == nil {
+		checkerConfig.BedrockProbeTTL = ttl
+	}
+	if ttl, err := time.ParseDuration(os.Getenv("BEDROCK_PROBE_FAILURE_CACHE_TTL")); err == nil {
+		checkerConfig.BedrockProbeFailureTTL = ttl
+	}
 
-	log.WithFields(logrus.Fields{
-		"verificationTable": verificationTable,
-		"conversationTable": conversationTable,
-		"referenceBucket":   referenceBucket,
-		"checkingBucket":    checkingBucket,
-		"resultsBucket":     resultsBucket,
-		"bedrockModel":      bedrockModel,
-	}).Info("Environment variables loaded successfully")
+	fields := logrus.Fields{
+		"bedrockModel":    checkerConfig.BedrockModel,
+		"stateMachineArn": checkerConfig.StateMachineArn,
+		"templatePaths":   checkerConfig.TemplatePaths,
+	}
+	for _, table := range checkerConfig.Tables {
+		fields[table.Name+"Table"] = table.Value
+	}
+	for _, bucket := range checkerConfig.Buckets {
+		fields[bucket.Name+"Bucket"] = bucket.Value
+	}
+	log.WithFields(fields).Info("Environment variables loaded successfully")
 
 	// Initialize AWS clients
 	cfg, err := config.LoadDefaultConfig(context.Background())
@@ -78,42 +97,36 @@ func init() {
 		log.WithError(err).Fatal("unable to load AWS SDK config")
 	}
 
-	dynamoClient = dynamodb.NewFromConfig(cfg)
-	s3Client = s3.NewFromConfig(cfg)
-	bedrockClient = bedrockruntime.NewFromConfig(cfg)
+	checker = NewHealthChecker(
+		dynamodb.NewFromConfig(cfg),
+		s3.NewFromConfig(cfg),
+		bedrockruntime.NewFromConfig(cfg),
+		sfn.NewFromConfig(cfg),
+		checkerConfig,
+	)
 }
 
-func handler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	log.Info("Health check started")
-	
-	// Prepare the health check response
-	response := HealthResponse{
-		Status:    "healthy", // Default to healthy, will be updated based on checks
-		Version:   "1.0.0",   // Can be set from an environment variable or build info
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
-		Services:  make(map[string]ServiceInfo),
+// requestedMode returns the health check mode from the query string. `deep=true`
+// is accepted as a shorthand for `mode=deep`; an explicit mode is strict, so a
+// failed readiness or deep check answers 503.
+func requestedMode(params map[string]string) (mode string, strict bool) {
+	mode = strings.ToLower(params["mode"])
+	if mode == "" && strings.EqualFold(params["deep"], "true") {
+		mode = ModeDeep
 	}
-
-	// Check DynamoDB Tables
-	dynamoHealth := checkDynamoDB(ctx)
-	response.Services["dynamodb"] = dynamoHealth
-	if dynamoHealth.Status != "healthy" {
-		response.Status = "degraded"
+	switch mode {
+	case ModeLiveness, ModeReadiness, ModeDeep:
+		return mode, true
+	default:
+		return ModeReadiness, false
 	}
+}
 
-	// Check S3 Buckets
-	s3Health := checkS3(ctx)
-	response.Services["s3"] = s3Health
-	if s3Health.Status != "healthy" {
-		response.Status = "degraded"
-	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	mode, strict := requestedMode(request.QueryStringParameters)
+	log.WithField("mode", mode).Info("Health check started")
 
-	// Check Bedrock
-	bedrockHealth := checkBedrock(ctx)
-	response.Services["bedrock"] = bedrockHealth
-	if bedrockHealth.Status != "healthy" {
-		response.Status = "degraded"
-	}
+	response := checker.Run(ctx, mode)
 
 	// Convert response to JSON
 	responseBody, err := json.Marshal(response)
@@ -132,126 +145,21 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (events
 		"Access-Control-Allow-Credentials": "true",
 	}
 
-	log.Info("Health check completed with status: " + response.Status)
+	statusCode := 200
+	if strict && !response.Ready {
+		statusCode = 503
+	}
+
+	log.WithFields(logrus.Fields{"mode": mode, "statusCode": statusCode}).Info("Health check completed with status: " + response.Status)
 
 	// Return API Gateway response
 	return events.APIGatewayProxyResponse{
-		StatusCode: 200,
+		StatusCode: statusCode,
 		Headers:    headers,
 		Body:       string(responseBody),
 	}, nil
 }
 
-func checkDynamoDB(ctx context.Context) ServiceInfo {
-	result := ServiceInfo{
-		Status: "healthy",
-		Details: map[string]string{
-			"verification_table": verificationTable,
-			"conversation_table": conversationTable,
-		},
-	}
-
-	// Check verification table
-	_, err := dynamoClient.DescribeTable(ctx, &dynamodb.DescribeTableInput{
-		TableName: aws.String(verificationTable),
-	})
-	if err != nil {
-		log.WithError(err).Error("Failed to describe verification table")
-		result.Status = "unhealthy"
-		result.Message = "Failed to access verification table: " + err.Error()
-		return result
-	}
-
-	// Check conversation table
-	_, err = dynamoClient.DescribeTable(ctx, &dynamodb.DescribeTableInput{
-		TableName: aws.String(conversationTable),
-	})
-	if err != nil {
-		log.WithError(err).Error("Failed to describe conversation table")
-		result.Status = "unhealthy"
-		result.Message = "Failed to access conversation table: " + err.Error()
-		return result
-	}
-
-	result.Message = "DynamoDB tables accessible"
-	return result
-}
-
-func checkS3(ctx context.Context) ServiceInfo {
-	result := ServiceInfo{
-		Status: "healthy",
-		Details: map[string]string{
-			"reference_bucket": referenceBucket,
-			"checking_bucket":  checkingBucket,
-			"results_bucket":   resultsBucket,
-		},
-	}
-
-	// Check reference bucket
-	_, err := s3Client.HeadBucket(ctx, &s3.HeadBucketInput{
-		Bucket: aws.String(referenceBucket),
-	})
-	if err != nil {
-		log.WithError(err).Error("Failed to access reference bucket")
-		result.Status = "unhealthy"
-		result.Message = "Failed to access reference bucket: " + err.Error()
-		return result
-	}
-
-	// Check checking bucket
-	_, err = s3Client.HeadBucket(ctx, &s3.HeadBucketInput{
-		Bucket: aws.String(checkingBucket),
-	})
-	if err != nil {
-		log.WithError(err).Error("Failed to access checking bucket")
-		result.Status = "unhealthy"
-		result.Message = "Failed to access checking bucket: " + err.Error()
-		return result
-	}
-
-	// Check results bucket
-	_, err = s3Client.HeadBucket(ctx, &s3.HeadBucketInput{
-		Bucket: aws.String(resultsBucket),
-	})
-	if err != nil {
-		log.WithError(err).Error("Failed to access results bucket")
-		result.Status = "unhealthy"
-		result.Message = "Failed to access results bucket: " + err.Error()
-		return result
-	}
-
-	result.Message = "S3 buckets accessible"
-	return result
-}
-
-func checkBedrock(ctx context.Context) ServiceInfo {
-	result := ServiceInfo{
-		Status: "healthy",
-		Details: map[string]string{
-			"model_id": bedrockModel,
-		},
-	}
-
-	// Check if we can at least get the model info
-	// Note: Real implementation would need to adapt to Bedrock API
-	// This is a simplified check - in a real implementation you might want to:
-	// 1. Use GetFoundationModel to check if the model exists
-	// 2. Or do a simple inference request with minimal tokens
-	
-	// For now, we'll just check that the model ID is not empty
-	if bedrockModel == "" {
-		result.Status = "unhealthy"
-		result.Message = "Bedrock model ID is not configured"
-		return result
-	}
-
-	// Add a more robust check here if needed
-	// For example, you could make a small inference request to verify the model is responding
-
-	result.Message = "Bedrock model available"
-	return result
-}
-
 func main() {
 	lambda.Start(handler)
-}
\ No newline at end of file
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	brtypes "github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	sfntypes "github.com/aws/aws-sdk-go-v2/service/sfn/types"
+)
+
+type fakeDynamo struct {
+	calls   int
+	missing map[string]bool
+}
+
+func (f *fakeDynamo) DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	f.calls++
+	if f.missing[aws.ToString(params.TableName)] {
+		return nil, errors.New("ResourceNotFoundException")
+	}
+	return &dynamodb.DescribeTableOutput{}, nil
+}
+
+type fakeS3 struct {
+	mu      sync.Mutex
+	calls   int
+	missing map[string]bool
+}
+
+func (f *fakeS3) lookup(key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.missing[key] {
+		return errors.New("NotFound")
+	}
+	return nil
+}
+
+func (f *fakeS3) HeadBucket(ctx context.Context, params *s3.HeadBucketInput, optFns ...func(*s3.Options)) (*s3.HeadBucketOutput, error) {
+	return &s3.HeadBucketOutput{}, f.lookup(aws.ToString(params.Bucket))
+}
+
+func (f *fakeS3) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	return &s3.HeadObjectOutput{}, f.lookup(aws.ToString(params.Bucket) + "/" + aws.ToString(params.Key))
+}
+
+type fakeBedrock struct {
+	calls   int
+	err     error
+	invoke  func()
+	request *bedrockruntime.ConverseInput
+}
+
+func (f *fakeBedrock) Converse(ctx context.Context, params *bedrockruntime.ConverseInput, optFns ...func(*bedrockruntime.Options)) (*bedrockruntime.ConverseOutput, error) {
+	f.calls++
+	f.request = params
+	if f.invoke != nil {
+		f.invoke()
+	}
+	return &bedrockruntime.ConverseOutput{}, f.err
+}
+
+type fakeStates struct {
+	status string
+	region string
+}
+
+func (f *fakeStates) DescribeStateMachine(ctx context.Context, params *sfn.DescribeStateMachineInput, optFns ...func(*sfn.Options)) (*sfn.DescribeStateMachineOutput, error) {
+	var o sfn.Options
+	for _, fn := range optFns {
+		fn(&o)
+	}
+	f.region = o.Region
+	return &sfn.DescribeStateMachineOutput{
+		StateMachineArn: params.StateMachineArn,
+		Status:          sfntypes.StateMachineStatus(f.status),
+	}, nil
+}
+
+// fakeClock advances by one millisecond on every reading so each check
+// reports a non-zero latency.
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(time.Millisecond)
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func testConfig() CheckerConfig {
+	return CheckerConfig{
+		Tables: []namedResource{
+			{Name: "verification", Value: "verifications", Required: true},
+			{Name: "conversation", Value: "conversations", Required: true},
+			{Name: "layout", Value: ""},
+		},
+		Buckets: []namedResource{
+			{Name: "reference", Value: "ref-bucket", Required: true},
+			{Name: "checking", Value: "check-bucket", Required: true},
+		},
+		BedrockModel:    "anthropic.claude-test",
+		StateMachineArn: "arn:aws:states:us-east-1:123456789012:stateMachine:verify",
+		BedrockProbeTTL: time.Minute,
+	}
+}
+
+func newTestChecker(cfg CheckerConfig, dynamo DynamoDBAPI, s3c S3API, br BedrockAPI, states StateMachineAPI) (*HealthChecker, *fakeClock) {
+	h := NewHealthChecker(dynamo, s3c, br, states, cfg)
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	h.now = clock.now
+	return h, clock
+}
+
+func TestLivenessMakesNoDependencyCalls(t *testing.T) {
+	dynamo, s3c, br := &fakeDynamo{}, &fakeS3{}, &fakeBedrock{}
+	h, _ := newTestChecker(testConfig(), dynamo, s3c, br, &fakeStates{status: "ACTIVE"})
+
+	resp := h.Run(context.Background(), ModeLiveness)
+	if resp.Status != StatusHealthy || !resp.Ready || len(resp.Services) != 0 {
+		t.Fatalf("unexpected liveness response: %+v", resp)
+	}
+	if dynamo.calls+s3c.calls+br.calls != 0 {
+		t.Errorf("liveness called dependencies: dynamo=%d s3=%d bedrock=%d", dynamo.calls, s3c.calls, br.calls)
+	}
+}
+
+func TestReadinessReportsLatencyAndFailures(t *testing.T) {
+	dynamo := &fakeDynamo{}
+	s3c := &fakeS3{missing: map[string]bool{"check-bucket": true}}
+	br := &fakeBedrock{}
+	h, _ := newTestChecker(testConfig(), dynamo, s3c, br, &fakeStates{status: "ACTIVE"})
+
+	resp := h.Run(context.Background(), ModeReadiness)
+	if resp.Status != StatusDegraded || resp.Ready {
+		t.Fatalf("expected degraded and not ready, got %s ready=%v", resp.Status, resp.Ready)
+	}
+	if br.calls != 0 {
+		t.Errorf("readiness should not invoke Bedrock, got %d calls", br.calls)
+	}
+	if _, ok := resp.Services["stepfunctions"]; ok {
+		t.Error("readiness should not check the state machine")
+	}
+
+	db := resp.Services["dynamodb"]
+	if db.Status != StatusHealthy || len(db.Checks) != 2 || db.LatencyMs <= 0 {
+		t.Errorf("unexpected dynamodb result: %+v", db)
+	}
+	bucket := resp.Services["s3"]
+	if bucket.Status != StatusUnhealthy || bucket.Message != "Failed to access checking bucket: NotFound" {
+		t.Errorf("unexpected s3 result: %+v", bucket)
+	}
+}
+
+func TestReadinessFlagsMissingRequiredTable(t *testing.T) {
+	cfg := testConfig()
+	cfg.Tables[1].Value = ""
+	h, _ := newTestChecker(cfg, &fakeDynamo{}, &fakeS3{}, &fakeBedrock{}, nil)
+
+	db := h.Run(context.Background(), ModeReadiness).Services["dynamodb"]
+	if db.Status != StatusUnhealthy || db.Message != "Failed to access conversation table: not configured" {
+		t.Errorf("unexpected dynamodb result: %+v", db)
+	}
+}
+
+func TestDeepProbeCachesBedrockResult(t *testing.T) {
+	br := &fakeBedrock{}
+	h, clock := newTestChecker(testConfig(), &fakeDynamo{}, &fakeS3{}, br, &fakeStates{status: "ACTIVE"})
+
+	first := h.Run(context.Background(), ModeDeep)
+	if first.Status != StatusHealthy || first.Services["bedrock"].Cached {
+		t.Fatalf("unexpected first deep response: %+v", first)
+	}
+	second := h.Run(context.Background(), ModeDeep)
+	if !second.Services["bedrock"].Cached || br.calls != 1 {
+		t.Errorf("expected cached probe, calls=%d cached=%v", br.calls, second.Services["bedrock"].Cached)
+	}
+
+	clock.advance(2 * time.Minute)
+	br.err = errors.New("AccessDeniedException")
+	third := h.Run(context.Background(), ModeDeep)
+	if br.calls != 2 || third.Services["bedrock"].Status != StatusUnhealthy || third.Ready {
+		t.Errorf("expected fresh failing probe after TTL, calls=%d result=%+v", br.calls, third.Services["bedrock"])
+	}
+
+	// Failures are cached for the shorter failure TTL only
+	br.err = nil
+	if fourth := h.Run(context.Background(), ModeDeep); !fourth.Services["bedrock"].Cached || br.calls != 2 {
+		t.Errorf("expected the failure cached, calls=%d result=%+v", br.calls, fourth.Services["bedrock"])
+	}
+	clock.advance(time.Minute / 2)
+	fifth := h.Run(context.Background(), ModeDeep)
+	if br.calls != 3 || fifth.Services["bedrock"].Status != StatusHealthy || fifth.Services["bedrock"].Cached {
+		t.Errorf("expected a fresh probe after the failure TTL, calls=%d result=%+v", br.calls, fifth.Services["bedrock"])
+	}
+}
+
+func TestBedrockProbeDoesNotHoldLockDuringInvocation(t *testing.T) {
+	br := &fakeBedrock{}
+	h, _ := newTestChecker(testConfig(), &fakeDynamo{}, &fakeS3{}, br, nil)
+	held := false
+	br.invoke = func() {
+		if h.mu.TryLock() {
+			h.mu.Unlock()
+		} else {
+			held = true
+		}
+	}
+
+	if info := h.probeBedrock(context.Background()); info.Status != StatusHealthy {
+		t.Fatalf("unexpected probe result: %+v", info)
+	}
+	if held {
+		t.Error("the cache lock was held while invoking Bedrock")
+	}
+	if cached, ok := h.cachedBedrockProbe(); !ok || !cached.Cached {
+		t.Errorf("expected the probe result cached, got %+v", cached)
+	}
+}
+
+func TestBedrockProbeSendsOneTokenConverse(t *testing.T) {
+	br := &fakeBedrock{}
+	h, _ := newTestChecker(testConfig(), &fakeDynamo{}, &fakeS3{}, br, nil)
+
+	if info := h.probeBedrock(context.Background()); info.Status != StatusHealthy {
+		t.Fatalf("unexpected probe result: %+v", info)
+	}
+	req := br.request
+	if req == nil {
+		t.Fatal("Converse was not called")
+	}
+	if aws.ToString(req.ModelId) != "anthropic.claude-test" {
+		t.Errorf("probe sent to model %q", aws.ToString(req.ModelId))
+	}
+	if req.InferenceConfig == nil || aws.ToInt32(req.InferenceConfig.MaxTokens) != 1 {
+		t.Errorf("expected a 1-token limit, got %+v", req.InferenceConfig)
+	}
+	if len(req.Messages) != 1 || req.Messages[0].Role != brtypes.ConversationRoleUser || len(req.Messages[0].Content) != 1 {
+		t.Fatalf("expected a single user message, got %+v", req.Messages)
+	}
+	if text, ok := req.Messages[0].Content[0].(*brtypes.ContentBlockMemberText); !ok || text.Value == "" {
+		t.Errorf("expected a text block, got %#v", req.Messages[0].Content[0])
+	}
+	if req.System != nil || req.ToolConfig != nil || req.AdditionalModelRequestFields != nil {
+		t.Error("probe should not send model-specific fields")
+	}
+}
+
+func TestDeepChecksStateMachineAndTemplates(t *testing.T) {
+	dir := t.TempDir()
+	local := filepath.Join(dir, "v1.0.tmpl")
+	if err := os.WriteFile(local, []byte("{{.}}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := testConfig()
+	cfg.TemplatePaths = []string{local, "s3://templates/turn1/v1.0.tmpl", "s3://templates/turn2/v1.0.tmpl"}
+	s3c := &fakeS3{missing: map[string]bool{"templates/turn2/v1.0.tmpl": true}}
+	states := &fakeStates{status: "DELETING"}
+	h, _ := newTestChecker(cfg, &fakeDynamo{}, s3c, &fakeBedrock{}, states)
+
+	resp := h.Run(context.Background(), ModeDeep)
+	sm := resp.Services["stepfunctions"]
+	if sm.Status != StatusUnhealthy || sm.Message != "Failed to access Step Functions state machine: state machine status is DELETING" {
+		t.Errorf("expected unhealthy state machine, got %+v", sm)
+	}
+	if states.region != "us-east-1" {
+		t.Errorf("expected the call in the state machine's region, got %q", states.region)
+	}
+	tmpl := resp.Services["templates"]
+	if tmpl.Status != StatusUnhealthy || len(tmpl.Checks) != 3 {
+		t.Fatalf("unexpected template result: %+v", tmpl)
+	}
+	if tmpl.Checks[0].Status != StatusHealthy || tmpl.Checks[1].Status != StatusHealthy || tmpl.Checks[2].Status != StatusUnhealthy {
+		t.Errorf("unexpected per-template statuses: %+v", tmpl.Checks)
+	}
+}
+
+func TestRequestedMode(t *testing.T) {
+	cases := []struct {
+		params map[string]string
+		mode   string
+		strict bool
+	}{
+		{nil, ModeReadiness, false},
+		{map[string]string{"mode": "liveness"}, ModeLiveness, true},
+		{map[string]string{"deep": "true"}, ModeDeep, true},
+		{map[string]string{"mode": "Readiness"}, ModeReadiness, true},
+		{map[string]string{"mode": "bogus"}, ModeReadiness, false},
+	}
+	for _, c := range cases {
+		mode, strict := requestedMode(c.params)
+		if mode != c.mode || strict != c.strict {
+			t.Errorf("requestedMode(%v) = %s, %v; want %s, %v", c.params, mode, strict, c.mode, c.strict)
+		}
+	}
+}
+
+func TestHandlerReturns503WhenStrictCheckFails(t *testing.T) {
+	checker, _ = newTestChecker(testConfig(), &fakeDynamo{missing: map[string]bool{"verifications": true}}, &fakeS3{}, &fakeBedrock{}, nil)
+
+	strict, err := handler(context.Background(), events.APIGatewayProxyRequest{QueryStringParameters: map[string]string{"mode": "readiness"}})
+	if err != nil || strict.StatusCode != 503 {
+		t.Errorf("expected 503 for failed readiness, got %d (%v)", strict.StatusCode, err)
+	}
+	legacy, err := handler(context.Background(), events.APIGatewayProxyRequest{})
+	if err != nil || legacy.StatusCode != 200 {
+		t.Errorf("expected 200 for default mode, got %d (%v)", legacy.StatusCode, err)
+	}
+}
@@ -22,8 +22,20 @@ type Config struct {
 	TitleFontSize    float64
 	HeaderFontSize   float64
 	PositionFontSize float64
+
+	// Multi-door (combo) machines render every sub-layout
+	SubLayoutArrangement string  // ArrangementHorizontal or ArrangementVertical
+	SubLayoutSpacing     float64 // gap between sub-layout grids
+	SubLayoutLabelHeight float64 // height of the label drawn above each sub-layout
+	SubLayoutLabelFormat string  // fmt format for the label, receives the 1-based sub-layout number
 }
 
+// Sub-layout arrangements
+const (
+	ArrangementHorizontal = "horizontal" // side by side
+	ArrangementVertical   = "vertical"   // stacked
+)
+
 var config = Config{
 	BucketName:       "", // Empty by default, will be set from S3 event
 	S3Region:         "us-east-1",
@@ -46,6 +58,11 @@ var config = Config{
 	TitleFontSize:    18.0,
 	HeaderFontSize:   14.0,
 	PositionFontSize: 14.0,
+
+	SubLayoutArrangement: ArrangementHorizontal,
+	SubLayoutSpacing:     60.0,
+	SubLayoutLabelHeight: 30.0,
+	SubLayoutLabelFormat: "Door %d",
 }
 
 func GetConfig() Config {
@@ -55,3 +72,12 @@ func GetConfig() Config {
 func UpdateBucketName(bucketName string) {
 	config.BucketName = bucketName
 }
+
+// UpdateSubLayoutArrangement sets how multiple sub-layouts are arranged.
+// Unknown values are ignored.
+func UpdateSubLayoutArrangement(arrangement string) {
+	switch arrangement {
+	case ArrangementHorizontal, ArrangementVertical:
+		config.SubLayoutArrangement = arrangement
+	}
+}
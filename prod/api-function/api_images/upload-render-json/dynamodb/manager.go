@@ -3,21 +3,28 @@ package dynamodb
 import (
 	"context"
 	"fmt"
-	"strings"
+	"strconv"
 	"time"
 
+	"api_images_upload_render/config"
 	"api_images_upload_render/renderer"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 )
 
+// DynamoDBAPI is the part of the DynamoDB client the manager uses
+type DynamoDBAPI interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
+}
+
 // Manager handles DynamoDB operations for layout metadata
 type Manager struct {
-	client    *dynamodb.Client
-	tableName string
+	client       DynamoDBAPI
+	tableName    string
+	machineIndex string
 }
 
 // LayoutMetadata represents the structure stored in DynamoDB
@@ -33,6 +40,17 @@ type LayoutMetadata struct {
 	MachineStructure   MachineStructure  `json:"machineStructure" dynamodbav:"machineStructure"`
 	RowProductMapping  map[string]map[string]string `json:"rowProductMapping" dynamodbav:"rowProductMapping"`
 	ProductPositionMap map[string]ProductInfo `json:"productPositionMap" dynamodbav:"productPositionMap"`
+	// RenderGeometry is the renderer configuration the reference image was drawn with
+	RenderGeometry *RenderGeometry `json:"renderGeometry,omitempty" dynamodbav:"renderGeometry,omitempty"`
+	// MachineModel is the layout's machineModelId, used to match few-shot examples
+	MachineModel string `json:"machineModel,omitempty" dynamodbav:"machineModel,omitempty"`
+
+	// Version history per vending machine, see versions.go
+	Version              int    `json:"version,omitempty" dynamodbav:"version,omitempty"`
+	EffectiveFrom        string `json:"effectiveFrom,omitempty" dynamodbav:"effectiveFrom,omitempty"`
+	EffectiveTo          string `json:"effectiveTo,omitempty" dynamodbav:"effectiveTo,omitempty"`
+	PreviousLayoutID     int64  `json:"previousLayoutId,omitempty" dynamodbav:"previousLayoutId,omitempty"`
+	PreviousLayoutPrefix string `json:"previousLayoutPrefix,omitempty" dynamodbav:"previousLayoutPrefix,omitempty"`
 }
 
 // MachineStructure represents the physical structure of the vending machine
@@ -41,6 +59,55 @@ type MachineStructure struct {
 	ColumnsPerRow  int      `json:"columnsPerRow" dynamodbav:"columnsPerRow"`
 	ColumnOrder    []string `json:"columnOrder" dynamodbav:"columnOrder"`
 	RowOrder       []string `json:"rowOrder" dynamodbav:"rowOrder"`
+	SubLayouts     []SubLayoutStructure `json:"subLayouts,omitempty" dynamodbav:"subLayouts,omitempty"`
+}
+
+// SubLayoutStructure describes one door of a multi-door (combo) machine
+type SubLayoutStructure struct {
+	Index         int      `json:"index" dynamodbav:"index"`
+	Label         string   `json:"label" dynamodbav:"label"`
+	RowCount      int      `json:"rowCount" dynamodbav:"rowCount"`
+	ColumnsPerRow int      `json:"columnsPerRow" dynamodbav:"columnsPerRow"`
+	RowOrder      []string `json:"rowOrder" dynamodbav:"rowOrder"`
+}
+
+// RenderGeometry is the layout math of the rendered reference image, in layout
+// units before CanvasScale. It lets consumers such as the annotated result of
+// FinalizeAndStoreResults locate cells on the image without copying the
+// renderer configuration.
+type RenderGeometry struct {
+	CanvasScale          float64 `json:"canvasScale" dynamodbav:"canvasScale"`
+	Padding              float64 `json:"padding" dynamodbav:"padding"`
+	TitlePadding         float64 `json:"titlePadding" dynamodbav:"titlePadding"`
+	HeaderHeight         float64 `json:"headerHeight" dynamodbav:"headerHeight"`
+	CellWidth            float64 `json:"cellWidth" dynamodbav:"cellWidth"`
+	CellHeight           float64 `json:"cellHeight" dynamodbav:"cellHeight"`
+	CellSpacing          float64 `json:"cellSpacing" dynamodbav:"cellSpacing"`
+	RowSpacing           float64 `json:"rowSpacing" dynamodbav:"rowSpacing"`
+	FooterHeight         float64 `json:"footerHeight" dynamodbav:"footerHeight"`
+	MaxColumns           int     `json:"maxColumns" dynamodbav:"maxColumns"`
+	SubLayoutArrangement string  `json:"subLayoutArrangement" dynamodbav:"subLayoutArrangement"`
+	SubLayoutSpacing     float64 `json:"subLayoutSpacing" dynamodbav:"subLayoutSpacing"`
+	SubLayoutLabelHeight float64 `json:"subLayoutLabelHeight" dynamodbav:"subLayoutLabelHeight"`
+}
+
+// newRenderGeometry returns the geometry the renderer uses with cfg
+func newRenderGeometry(cfg config.Config) *RenderGeometry {
+	return &RenderGeometry{
+		CanvasScale:          cfg.CanvasScale,
+		Padding:              cfg.Padding,
+		TitlePadding:         cfg.TitlePadding,
+		HeaderHeight:         cfg.HeaderHeight,
+		CellWidth:            cfg.CellWidth,
+		CellHeight:           cfg.CellHeight,
+		CellSpacing:          cfg.CellSpacing,
+		RowSpacing:           cfg.RowSpacing,
+		FooterHeight:         cfg.FooterHeight,
+		MaxColumns:           cfg.NumColumns,
+		SubLayoutArrangement: cfg.SubLayoutArrangement,
+		SubLayoutSpacing:     cfg.SubLayoutSpacing,
+		SubLayoutLabelHeight: cfg.SubLayoutLabelHeight,
+	}
 }
 
 // ProductInfo represents product information for a specific position
@@ -53,8 +120,9 @@ type ProductInfo struct {
 	Status              int    `json:"status" dynamodbav:"status"`
 }
 
-// NewManager creates a new DynamoDB manager
-func NewManager(ctx context.Context, region, tableName string) (*Manager, error) {
+// NewManager creates a new DynamoDB manager. machineIndex is the GSI on
+// vendingMachineId and effectiveFrom used to find a machine's current layout.
+func NewManager(ctx context.Context, region, tableName, machineIndex string) (*Manager, error) {
 	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %v", err)
@@ -63,13 +131,15 @@ func NewManager(ctx context.Context, region, tableName string) (*Manager, error)
 	client := dynamodb.NewFromConfig(cfg)
 	
 	return &Manager{
-		client:    client,
-		tableName: tableName,
+		client:       client,
+		tableName:    tableName,
+		machineIndex: machineIndex,
 	}, nil
 }
 
-// StoreLayoutMetadata stores layout metadata in DynamoDB
-func (m *Manager) StoreLayoutMetadata(ctx context.Context, layout *renderer.Layout, layoutPrefix, s3Bucket, processedKey, sourceKey string) error {
+// StoreLayoutMetadata stores layout metadata in DynamoDB as the newest version
+// of the vending machine's layout and closes the version it supersedes.
+func (m *Manager) StoreLayoutMetadata(ctx context.Context, layout *renderer.Layout, layoutPrefix, s3Bucket, processedKey, sourceKey string, opts VersionOptions) (*LayoutMetadata, error) {
 	now := time.Now().UTC().Format(time.RFC3339)
 	
 	// Build machine structure from layout data
@@ -81,8 +151,15 @@ func (m *Manager) StoreLayoutMetadata(ctx context.Context, layout *renderer.Layo
 	// Build product position map
 	productPositionMap := m.buildProductPositionMap(layout)
 	
-	// Extract vendor machine ID and location (in a real implementation, this could come from the layout data)
-	vendingMachineID := fmt.Sprintf("VM-%d", layout.LayoutID)
+	// Vending machine ID defaults to one per layout ID when the upload does not name the machine
+	vendingMachineID := opts.VendingMachineID
+	if vendingMachineID == "" {
+		vendingMachineID = fmt.Sprintf("VM-%d", layout.LayoutID)
+	}
+	effectiveFrom := opts.EffectiveFrom
+	if effectiveFrom.IsZero() {
+		effectiveFrom = time.Now()
+	}
 	location := "Default Location" // This would come from the layout in a real implementation
 	
 	metadata := LayoutMetadata{
@@ -97,55 +174,60 @@ func (m *Manager) StoreLayoutMetadata(ctx context.Context, layout *renderer.Layo
 		MachineStructure:  machineStructure,
 		RowProductMapping: rowProductMapping,
 		ProductPositionMap: productPositionMap,
+		RenderGeometry:     newRenderGeometry(config.GetConfig()),
+		EffectiveFrom:      formatVersionTime(effectiveFrom),
 	}
-
-	// Convert to DynamoDB attribute values
-	item, err := attributevalue.MarshalMap(metadata)
-	if err != nil {
-		return fmt.Errorf("failed to marshal layout metadata: %v", err)
+	if layout.MachineModelID != nil {
+		metadata.MachineModel = strconv.Itoa(*layout.MachineModelID)
 	}
 
-	// Put item with condition to prevent overwriting existing items
-	_, err = m.client.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName:           aws.String(m.tableName),
-		Item:                item,
-		ConditionExpression: aws.String("attribute_not_exists(layoutId)"),
-	})
-
-	if err != nil {
-		if strings.Contains(err.Error(), "ConditionalCheckFailedException") {
-			return fmt.Errorf("layout with ID %d already exists", layout.LayoutID)
-		}
-		return fmt.Errorf("failed to store layout metadata: %v", err)
+	if err := m.storeVersion(ctx, &metadata); err != nil {
+		return nil, err
 	}
-
-	return nil
+	return &metadata, nil
 }
 
-// buildMachineStructure extracts machine structure from layout
+// buildMachineStructure extracts machine structure from layout. Rows of all
+// sub-layouts are listed in order; SubLayouts describes each door separately
+// for multi-door machines.
 func (m *Manager) buildMachineStructure(layout *renderer.Layout) MachineStructure {
-	if len(layout.SubLayoutList) == 0 || len(layout.SubLayoutList[0].TrayList) == 0 {
+	if layout.TrayCount() == 0 {
 		return MachineStructure{}
 	}
 
-	trays := layout.SubLayoutList[0].TrayList
-	rowCount := len(trays)
-	
-	// Determine columns per row (assuming all rows have the same number of columns)
+	keys := renderer.NewPositionKeys(*layout)
+	var rowOrder []string
+	var subLayouts []SubLayoutStructure
 	columnsPerRow := 0
-	if len(trays) > 0 && len(trays[0].SlotList) > 0 {
+
+	for subIdx, sub := range layout.SubLayoutList {
+		if len(sub.TrayList) == 0 {
+			continue
+		}
+
 		// Find the maximum slot number to determine column count
-		for _, slot := range trays[0].SlotList {
-			if slot.SlotNo > columnsPerRow {
-				columnsPerRow = slot.SlotNo
+		subColumns := 0
+		subRows := make([]string, len(sub.TrayList))
+		for i, tray := range sub.TrayList {
+			subRows[i] = keys.Row(subIdx, tray.TrayCode)
+			for _, slot := range tray.SlotList {
+				if slot.SlotNo > subColumns {
+					subColumns = slot.SlotNo
+				}
 			}
 		}
-	}
+		if subColumns > columnsPerRow {
+			columnsPerRow = subColumns
+		}
+		rowOrder = append(rowOrder, subRows...)
 
-	// Build row order
-	rowOrder := make([]string, rowCount)
-	for i, tray := range trays {
-		rowOrder[i] = tray.TrayCode
+		subLayouts = append(subLayouts, SubLayoutStructure{
+			Index:         subIdx,
+			Label:         renderer.SubLayoutLabel(subIdx),
+			RowCount:      len(sub.TrayList),
+			ColumnsPerRow: subColumns,
+			RowOrder:      subRows,
+		})
 	}
 
 	// Build column order (1, 2, 3, ...)
@@ -154,58 +236,62 @@ func (m *Manager) buildMachineStructure(layout *renderer.Layout) MachineStructur
 		columnOrder[i] = fmt.Sprintf("%d", i+1)
 	}
 
-	return MachineStructure{
-		RowCount:      rowCount,
+	structure := MachineStructure{
+		RowCount:      len(rowOrder),
 		ColumnsPerRow: columnsPerRow,
 		ColumnOrder:   columnOrder,
 		RowOrder:      rowOrder,
 	}
+	// Single-door layouts keep the original shape
+	if len(subLayouts) > 1 {
+		structure.SubLayouts = subLayouts
+	}
+	return structure
 }
 
 // buildRowProductMapping creates a mapping of row -> column -> product name
 func (m *Manager) buildRowProductMapping(layout *renderer.Layout) map[string]map[string]string {
 	mapping := make(map[string]map[string]string)
-	
-	if len(layout.SubLayoutList) == 0 {
-		return mapping
-	}
+	keys := renderer.NewPositionKeys(*layout)
+
+	for subIdx, sub := range layout.SubLayoutList {
+		for _, tray := range sub.TrayList {
+			rowMapping := make(map[string]string)
+
+			for _, slot := range tray.SlotList {
+				columnKey := fmt.Sprintf("%d", slot.SlotNo)
+				rowMapping[columnKey] = slot.ProductTemplateName
+			}
 
-	for _, tray := range layout.SubLayoutList[0].TrayList {
-		rowMapping := make(map[string]string)
-		
-		for _, slot := range tray.SlotList {
-			columnKey := fmt.Sprintf("%d", slot.SlotNo)
-			rowMapping[columnKey] = slot.ProductTemplateName
+			mapping[keys.Row(subIdx, tray.TrayCode)] = rowMapping
 		}
-		
-		mapping[tray.TrayCode] = rowMapping
 	}
 
 	return mapping
 }
 
-// buildProductPositionMap creates a mapping of position -> product info
+// buildProductPositionMap creates a mapping of position -> product info.
+// Position keys are unique across sub-layouts (see renderer.PositionKeys).
 func (m *Manager) buildProductPositionMap(layout *renderer.Layout) map[string]ProductInfo {
 	positionMap := make(map[string]ProductInfo)
-	
-	if len(layout.SubLayoutList) == 0 {
-		return positionMap
-	}
+	keys := renderer.NewPositionKeys(*layout)
+
+	for subIdx, sub := range layout.SubLayoutList {
+		for _, tray := range sub.TrayList {
+			for _, slot := range tray.SlotList {
+				position := keys.Position(subIdx, tray.TrayCode, slot.SlotNo)
+
+				productInfo := ProductInfo{
+					ProductID:            slot.ProductId,
+					ProductTemplateID:    slot.ProductTemplateId,
+					ProductTemplateName:  slot.ProductTemplateName,
+					ProductTemplateImage: slot.ProductTemplateImage,
+					MaxQuantity:          slot.MaxQuantity,
+					Status:               slot.Status,
+				}
 
-	for _, tray := range layout.SubLayoutList[0].TrayList {
-		for _, slot := range tray.SlotList {
-			position := fmt.Sprintf("%s%d", tray.TrayCode, slot.SlotNo)
-			
-			productInfo := ProductInfo{
-				ProductID:           slot.ProductId,
-				ProductTemplateID:   slot.ProductTemplateId,
-				ProductTemplateName: slot.ProductTemplateName,
-				ProductTemplateImage: slot.ProductTemplateImage,
-				MaxQuantity:         slot.MaxQuantity,
-				Status:              slot.Status,
+				positionMap[position] = productInfo
 			}
-			
-			positionMap[position] = productInfo
 		}
 	}
 
@@ -0,0 +1,33 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"testing"
+
+	"api_images_upload_render/config"
+)
+
+func TestNewRenderGeometryMirrorsConfig(t *testing.T) {
+	cfg := config.GetConfig()
+	cfg.CellWidth = 175
+	cfg.SubLayoutArrangement = config.ArrangementVertical
+
+	geo := newRenderGeometry(cfg)
+	if geo.CellWidth != 175 || geo.CellHeight != cfg.CellHeight || geo.MaxColumns != cfg.NumColumns || geo.SubLayoutArrangement != "vertical" {
+		t.Errorf("Expected the geometry of the config, got %+v", geo)
+	}
+
+	data, err := json.Marshal(LayoutMetadata{RenderGeometry: geo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored struct {
+		RenderGeometry map[string]interface{} `json:"renderGeometry"`
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored.RenderGeometry["cellWidth"] != 175.0 || stored.RenderGeometry["subLayoutArrangement"] != "vertical" {
+		t.Errorf("Unexpected stored geometry %v", stored.RenderGeometry)
+	}
+}
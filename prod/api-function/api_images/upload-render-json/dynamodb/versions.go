@@ -0,0 +1,284 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DefaultMachineIndex is the GSI (partition key vendingMachineId, sort key
+// effectiveFrom, projection ALL) that orders a machine's layout versions.
+// Items without effectiveFrom, i.e. layouts stored before versioning, are not
+// in the index.
+const DefaultMachineIndex = "VendingMachineIndex"
+
+var (
+	// ErrVersionOutOfOrder is returned when a new version would not take
+	// effect after the machine's current version
+	ErrVersionOutOfOrder = errors.New("layout version is out of order")
+	// ErrVersionConflict is returned when the current version changed while
+	// the new version was stored
+	ErrVersionConflict = errors.New("layout version changed concurrently")
+)
+
+// VersionOptions identifies the machine a layout is uploaded for and when it
+// takes effect
+type VersionOptions struct {
+	VendingMachineID string
+	EffectiveFrom    time.Time
+}
+
+// versionTimeFormat is RFC3339 in UTC with fixed-width milliseconds, so that
+// effective dates sort as strings and uploads within the same second keep
+// their order
+const versionTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// formatVersionTime formats effective dates so that they sort as strings
+func formatVersionTime(t time.Time) string {
+	return t.UTC().Format(versionTimeFormat)
+}
+
+// MachineHeadItemType marks the per-machine head item. Head items share the
+// layout table, so scans of the table filter on attribute_not_exists(itemType).
+const MachineHeadItemType = "MACHINE_HEAD"
+
+// machineHead points at the machine's current layout version. Every new
+// version rewrites it under a condition on the version it read, which
+// serializes concurrent uploads for the same machine. It is keyed by
+// layoutId 0, which no layout uses, and has no vendingMachineId or
+// effectiveFrom, so it is not in the machine index.
+type machineHead struct {
+	LayoutID            int64  `dynamodbav:"layoutId"`
+	LayoutPrefix        string `dynamodbav:"layoutPrefix"`
+	ItemType            string `dynamodbav:"itemType"`
+	MachineID           string `dynamodbav:"machineId"`
+	Version             int    `dynamodbav:"version"`
+	CurrentLayoutID     int64  `dynamodbav:"currentLayoutId"`
+	CurrentLayoutPrefix string `dynamodbav:"currentLayoutPrefix"`
+	UpdatedAt           string `dynamodbav:"updatedAt"`
+}
+
+// machineHeadKey returns the table key of the machine's head item
+func machineHeadKey(vendingMachineID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"layoutId":     &types.AttributeValueMemberN{Value: "0"},
+		"layoutPrefix": &types.AttributeValueMemberS{Value: "MACHINE#" + vendingMachineID},
+	}
+}
+
+// getMachineHead reads the machine's head item; it returns nil if the machine
+// has none yet
+func (m *Manager) getMachineHead(ctx context.Context, vendingMachineID string) (*machineHead, error) {
+	result, err := m.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(m.tableName),
+		Key:            machineHeadKey(vendingMachineID),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the head item of %s: %v", vendingMachineID, err)
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var head machineHead
+	if err := attributevalue.UnmarshalMap(result.Item, &head); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the head item of %s: %v", vendingMachineID, err)
+	}
+	return &head, nil
+}
+
+// getLayout reads a layout by its key; it returns nil if there is no such layout
+func (m *Manager) getLayout(ctx context.Context, layoutID int64, layoutPrefix string) (*LayoutMetadata, error) {
+	result, err := m.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(m.tableName),
+		Key: map[string]types.AttributeValue{
+			"layoutId":     &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", layoutID)},
+			"layoutPrefix": &types.AttributeValueMemberS{Value: layoutPrefix},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get layout %d/%s: %v", layoutID, layoutPrefix, err)
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var layout LayoutMetadata
+	if err := attributevalue.UnmarshalMap(result.Item, &layout); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal layout %d/%s: %v", layoutID, layoutPrefix, err)
+	}
+	return &layout, nil
+}
+
+// currentVersion returns the machine's current version and its head item.
+// The head item is read consistently; machines without one fall back to the
+// version with the latest effectiveFrom in the machine index. Both are nil if
+// the machine has no versions yet.
+func (m *Manager) currentVersion(ctx context.Context, vendingMachineID string) (*LayoutMetadata, *machineHead, error) {
+	head, err := m.getMachineHead(ctx, vendingMachineID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if head != nil {
+		current, err := m.getLayout(ctx, head.CurrentLayoutID, head.CurrentLayoutPrefix)
+		if err != nil {
+			return nil, nil, err
+		}
+		if current == nil {
+			return nil, nil, fmt.Errorf("head item of %s points at missing layout %d/%s",
+				vendingMachineID, head.CurrentLayoutID, head.CurrentLayoutPrefix)
+		}
+		return current, head, nil
+	}
+
+	result, err := m.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(m.tableName),
+		IndexName:              aws.String(m.machineIndex),
+		KeyConditionExpression: aws.String("vendingMachineId = :vm"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":vm": &types.AttributeValueMemberS{Value: vendingMachineID},
+		},
+		ScanIndexForward: aws.Bool(false),
+		Limit:            aws.Int32(1),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query %s: %v", m.machineIndex, err)
+	}
+	if len(result.Items) == 0 {
+		return nil, nil, nil
+	}
+
+	var current LayoutMetadata
+	if err := attributevalue.UnmarshalMap(result.Items[0], &current); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal current layout version: %v", err)
+	}
+	return &current, nil, nil
+}
+
+// storeVersion numbers the metadata after the machine's current version and
+// stores it in one transaction that also:
+//   - creates the machine's head item (attribute_not_exists) or moves it to the
+//     new version, conditioned on the version it pointed at, so two concurrent
+//     uploads cannot both become the next version
+//   - sets the current version's effectiveTo to the new version's
+//     effectiveFrom, guarded by its updatedAt
+func (m *Manager) storeVersion(ctx context.Context, metadata *LayoutMetadata) error {
+	current, head, err := m.currentVersion(ctx, metadata.VendingMachineID)
+	if err != nil {
+		return fmt.Errorf("failed to look up the current layout version: %v", err)
+	}
+
+	metadata.Version = 1
+	if current != nil {
+		if metadata.EffectiveFrom <= current.EffectiveFrom {
+			return fmt.Errorf("%w: effectiveFrom %s must be after %s, when version %d of %s took effect",
+				ErrVersionOutOfOrder, metadata.EffectiveFrom, current.EffectiveFrom, current.Version, metadata.VendingMachineID)
+		}
+		metadata.Version = current.Version + 1
+		metadata.PreviousLayoutID = current.LayoutID
+		metadata.PreviousLayoutPrefix = current.LayoutPrefix
+	}
+
+	item, err := attributevalue.MarshalMap(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal layout metadata: %v", err)
+	}
+
+	// Put item with condition to prevent overwriting existing items
+	transactItems := []types.TransactWriteItem{{
+		Put: &types.Put{
+			TableName:           aws.String(m.tableName),
+			Item:                item,
+			ConditionExpression: aws.String("attribute_not_exists(layoutId)"),
+		},
+	}}
+
+	if head == nil {
+		headItem, err := attributevalue.MarshalMap(machineHead{
+			LayoutID:            0,
+			LayoutPrefix:        "MACHINE#" + metadata.VendingMachineID,
+			ItemType:            MachineHeadItemType,
+			MachineID:           metadata.VendingMachineID,
+			Version:             metadata.Version,
+			CurrentLayoutID:     metadata.LayoutID,
+			CurrentLayoutPrefix: metadata.LayoutPrefix,
+			UpdatedAt:           metadata.UpdatedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal the head item of %s: %v", metadata.VendingMachineID, err)
+		}
+		transactItems = append(transactItems, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName:           aws.String(m.tableName),
+				Item:                headItem,
+				ConditionExpression: aws.String("attribute_not_exists(layoutId)"),
+			},
+		})
+	} else {
+		transactItems = append(transactItems, types.TransactWriteItem{
+			Update: &types.Update{
+				TableName:           aws.String(m.tableName),
+				Key:                 machineHeadKey(metadata.VendingMachineID),
+				UpdateExpression:    aws.String("SET version = :version, currentLayoutId = :id, currentLayoutPrefix = :prefix, updatedAt = :now"),
+				ConditionExpression: aws.String("version = :seen"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":version": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", metadata.Version)},
+					":id":      &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", metadata.LayoutID)},
+					":prefix":  &types.AttributeValueMemberS{Value: metadata.LayoutPrefix},
+					":now":     &types.AttributeValueMemberS{Value: metadata.UpdatedAt},
+					":seen":    &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", head.Version)},
+				},
+			},
+		})
+	}
+
+	// Close the current version unless it was already retired earlier
+	if current != nil && (current.EffectiveTo == "" || current.EffectiveTo > metadata.EffectiveFrom) {
+		transactItems = append(transactItems, types.TransactWriteItem{
+			Update: &types.Update{
+				TableName: aws.String(m.tableName),
+				Key: map[string]types.AttributeValue{
+					"layoutId":     &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", current.LayoutID)},
+					"layoutPrefix": &types.AttributeValueMemberS{Value: current.LayoutPrefix},
+				},
+				UpdateExpression:    aws.String("SET effectiveTo = :to, updatedAt = :now"),
+				ConditionExpression: aws.String("updatedAt = :seen"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":to":   &types.AttributeValueMemberS{Value: metadata.EffectiveFrom},
+					":now":  &types.AttributeValueMemberS{Value: metadata.UpdatedAt},
+					":seen": &types.AttributeValueMemberS{Value: current.UpdatedAt},
+				},
+			},
+		})
+	}
+
+	_, err = m.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		var canceled *types.TransactionCanceledException
+		if errors.As(err, &canceled) {
+			reasons := canceled.CancellationReasons
+			if len(reasons) > 0 && aws.ToString(reasons[0].Code) == "ConditionalCheckFailed" {
+				return fmt.Errorf("layout with ID %d already exists", metadata.LayoutID)
+			}
+			for _, reason := range reasons[1:] {
+				if aws.ToString(reason.Code) == "ConditionalCheckFailed" {
+					return fmt.Errorf("%w: %s got a new version while storing version %d",
+						ErrVersionConflict, metadata.VendingMachineID, metadata.Version)
+				}
+			}
+		}
+		return fmt.Errorf("failed to store layout metadata: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// fakeDynamo serves GetItem from items keyed by "layoutId/layoutPrefix" and
+// records the transactions it is sent
+type fakeDynamo struct {
+	items        map[string]map[string]types.AttributeValue
+	transactions []*dynamodb.TransactWriteItemsInput
+	transactErr  error
+}
+
+func itemKey(key map[string]types.AttributeValue) string {
+	id := key["layoutId"].(*types.AttributeValueMemberN).Value
+	prefix := key["layoutPrefix"].(*types.AttributeValueMemberS).Value
+	return id + "/" + prefix
+}
+
+func (f *fakeDynamo) put(t *testing.T, v interface{}) {
+	item, err := attributevalue.MarshalMap(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.items[itemKey(item)] = item
+}
+
+func (f *fakeDynamo) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{Item: f.items[itemKey(params.Key)]}, nil
+}
+
+func (f *fakeDynamo) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return &dynamodb.QueryOutput{}, nil
+}
+
+func (f *fakeDynamo) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	f.transactions = append(f.transactions, params)
+	return &dynamodb.TransactWriteItemsOutput{}, f.transactErr
+}
+
+func newTestMetadata(layoutID int64, prefix, effectiveFrom string) *LayoutMetadata {
+	return &LayoutMetadata{
+		LayoutID:         layoutID,
+		LayoutPrefix:     prefix,
+		VendingMachineID: "VM-1",
+		UpdatedAt:        "2026-10-18T10:00:00Z",
+		EffectiveFrom:    effectiveFrom,
+	}
+}
+
+func TestStoreVersionCreatesMachineHead(t *testing.T) {
+	client := &fakeDynamo{items: map[string]map[string]types.AttributeValue{}}
+	m := &Manager{client: client, tableName: "layouts", machineIndex: DefaultMachineIndex}
+
+	metadata := newTestMetadata(10, "p1", "2026-10-18T10:00:00.000Z")
+	if err := m.storeVersion(context.Background(), metadata); err != nil {
+		t.Fatalf("storeVersion failed: %v", err)
+	}
+	if metadata.Version != 1 {
+		t.Errorf("Expected version 1, got %d", metadata.Version)
+	}
+
+	items := client.transactions[0].TransactItems
+	if len(items) != 2 {
+		t.Fatalf("Expected the layout and the head item, got %d items", len(items))
+	}
+	head := items[1].Put
+	if head == nil || aws.ToString(head.ConditionExpression) != "attribute_not_exists(layoutId)" {
+		t.Fatalf("Expected the head item to be created under attribute_not_exists, got %+v", items[1])
+	}
+	if itemKey(head.Item) != "0/MACHINE#VM-1" {
+		t.Errorf("Expected head key 0/MACHINE#VM-1, got %s", itemKey(head.Item))
+	}
+	if head.Item["itemType"].(*types.AttributeValueMemberS).Value != MachineHeadItemType {
+		t.Error("Expected the head item to carry its itemType")
+	}
+	if _, ok := head.Item["vendingMachineId"]; ok {
+		t.Error("Head item must stay out of the machine index")
+	}
+}
+
+func TestStoreVersionMovesMachineHead(t *testing.T) {
+	client := &fakeDynamo{items: map[string]map[string]types.AttributeValue{}}
+	client.put(t, newTestMetadata(10, "p1", "2026-10-18T10:00:00.000Z"))
+	client.put(t, machineHead{
+		LayoutPrefix: "MACHINE#VM-1", ItemType: MachineHeadItemType, MachineID: "VM-1",
+		Version: 1, CurrentLayoutID: 10, CurrentLayoutPrefix: "p1",
+	})
+	// The stored layout is version 1
+	client.items["10/p1"]["version"] = &types.AttributeValueMemberN{Value: "1"}
+	m := &Manager{client: client, tableName: "layouts", machineIndex: DefaultMachineIndex}
+
+	// Same second as version 1, but later
+	metadata := newTestMetadata(11, "p2", "2026-10-18T10:00:00.250Z")
+	if err := m.storeVersion(context.Background(), metadata); err != nil {
+		t.Fatalf("storeVersion failed: %v", err)
+	}
+	if metadata.Version != 2 || metadata.PreviousLayoutID != 10 || metadata.PreviousLayoutPrefix != "p1" {
+		t.Errorf("Expected version 2 after 10/p1, got %+v", metadata)
+	}
+
+	items := client.transactions[0].TransactItems
+	if len(items) != 3 {
+		t.Fatalf("Expected the layout, the head and the closed version, got %d items", len(items))
+	}
+	head := items[1].Update
+	if head == nil || aws.ToString(head.ConditionExpression) != "version = :seen" {
+		t.Fatalf("Expected the head item to be moved under a version condition, got %+v", items[1])
+	}
+	if head.ExpressionAttributeValues[":seen"].(*types.AttributeValueMemberN).Value != "1" {
+		t.Errorf("Expected the head condition on version 1")
+	}
+	closed := items[2].Update
+	if closed == nil || itemKey(closed.Key) != "10/p1" {
+		t.Fatalf("Expected version 1 to be closed, got %+v", items[2])
+	}
+
+	// Not later than the current version
+	client.transactions = nil
+	err := m.storeVersion(context.Background(), newTestMetadata(12, "p3", "2026-10-18T10:00:00.000Z"))
+	if !errors.Is(err, ErrVersionOutOfOrder) || client.transactions != nil {
+		t.Errorf("Expected ErrVersionOutOfOrder without a write, got %v", err)
+	}
+}
+
+func TestStoreVersionHeadConflict(t *testing.T) {
+	client := &fakeDynamo{
+		items: map[string]map[string]types.AttributeValue{},
+		transactErr: &types.TransactionCanceledException{CancellationReasons: []types.CancellationReason{
+			{Code: aws.String("None")},
+			{Code: aws.String("ConditionalCheckFailed")},
+		}},
+	}
+	m := &Manager{client: client, tableName: "layouts", machineIndex: DefaultMachineIndex}
+
+	err := m.storeVersion(context.Background(), newTestMetadata(10, "p1", "2026-10-18T10:00:00.000Z"))
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Expected ErrVersionConflict when another upload created the head item, got %v", err)
+	}
+}
+
+func TestFormatVersionTimeSortsWithinASecond(t *testing.T) {
+	at := time.Date(2026, 10, 18, 10, 0, 0, 0, time.FixedZone("ICT", 7*3600))
+	first := formatVersionTime(at)
+	second := formatVersionTime(at.Add(500 * time.Millisecond))
+	if first != "2026-10-18T03:00:00.000Z" {
+		t.Errorf("Expected UTC with milliseconds, got %s", first)
+	}
+	if !(first < second) {
+		t.Errorf("Expected %s to sort before %s", first, second)
+	}
+	if _, err := time.Parse(time.RFC3339, second); err != nil {
+		t.Errorf("Expected an RFC3339 timestamp, got %v", err)
+	}
+}
@@ -0,0 +1,523 @@
+// Package imagecache caches product images for the layout renderer. Images
+// are keyed by a hash of their URL and kept in an in-memory LRU backed by an
+// S3 prefix, so warm and cold Lambda instances alike avoid re-downloading the
+// same product images on every render.
+package imagecache
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3API is the subset of the S3 client used by the cache
+type S3API interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// Options configures a Cache
+type Options struct {
+	// MaxEntries is the capacity of the in-memory LRU
+	MaxEntries int
+	// RevalidateAfter is the age after which an entry is revalidated with the
+	// origin using its ETag / Last-Modified
+	RevalidateAfter time.Duration
+	// NegativeTTL is how long a transient download failure is remembered
+	NegativeTTL time.Duration
+	// BrokenTTL is how long a permanently broken image (404, 410 or not an
+	// image) is remembered and served as the placeholder tile
+	BrokenTTL time.Duration
+	// Bucket and Prefix locate the S3 backing store; an empty bucket keeps
+	// the cache in memory only
+	Bucket string
+	Prefix string
+	// Attempts is the number of download attempts for transient failures
+	Attempts int
+}
+
+// DefaultOptions returns the options used when nothing is configured
+func DefaultOptions() Options {
+	return Options{
+		MaxEntries:      500,
+		RevalidateAfter: 24 * time.Hour,
+		NegativeTTL:     10 * time.Minute,
+		BrokenTTL:       24 * time.Hour,
+		Prefix:          "image-cache",
+		Attempts:        2,
+	}
+}
+
+// Stats counts cache outcomes; see Cache.Stats
+type Stats struct {
+	MemoryHits    int64 `json:"memoryHits"`
+	S3Hits        int64 `json:"s3Hits"`
+	Downloads     int64 `json:"downloads"`
+	Revalidations int64 `json:"revalidations"`
+	NotModified   int64 `json:"notModified"`
+	StaleServed   int64 `json:"staleServed"`
+	NegativeHits  int64 `json:"negativeHits"`
+	Placeholders  int64 `json:"placeholders"`
+	Failures      int64 `json:"failures"`
+	S3Errors      int64 `json:"s3Errors"`
+}
+
+// Sub returns the counts accumulated since an earlier snapshot
+func (s Stats) Sub(earlier Stats) Stats {
+	return Stats{
+		MemoryHits:    s.MemoryHits - earlier.MemoryHits,
+		S3Hits:        s.S3Hits - earlier.S3Hits,
+		Downloads:     s.Downloads - earlier.Downloads,
+		Revalidations: s.Revalidations - earlier.Revalidations,
+		NotModified:   s.NotModified - earlier.NotModified,
+		StaleServed:   s.StaleServed - earlier.StaleServed,
+		NegativeHits:  s.NegativeHits - earlier.NegativeHits,
+		Placeholders:  s.Placeholders - earlier.Placeholders,
+		Failures:      s.Failures - earlier.Failures,
+		S3Errors:      s.S3Errors - earlier.S3Errors,
+	}
+}
+
+// HitRatio is the share of loads served without downloading
+func (s Stats) HitRatio() float64 {
+	hits := s.MemoryHits + s.S3Hits
+	total := hits + s.Downloads
+	if total == 0 {
+		return 0
+	}
+	return float64(hits) / float64(total)
+}
+
+// S3 object metadata keys
+const (
+	metaStatus       = "cache-status"
+	metaETag         = "source-etag"
+	metaLastModified = "source-last-modified"
+	metaValidatedAt  = "validated-at"
+
+	statusOK     = "ok"
+	statusBroken = "broken"
+)
+
+type entry struct {
+	key          string
+	img          image.Image
+	etag         string
+	lastModified string
+	validatedAt  time.Time
+}
+
+type negativeEntry struct {
+	permanent bool
+	reason    string
+	expires   time.Time
+}
+
+// Cache is safe for concurrent use
+type Cache struct {
+	opts   Options
+	s3     S3API
+	client *http.Client
+	now    func() time.Time
+
+	mu       sync.Mutex
+	lru      *list.List
+	entries  map[string]*list.Element
+	negative map[string]negativeEntry
+	stats    Stats
+
+	placeholder image.Image
+}
+
+// New creates a cache. s3Client may be nil, in which case only the
+// in-memory layer is used.
+func New(s3Client S3API, opts Options) *Cache {
+	defaults := DefaultOptions()
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = defaults.MaxEntries
+	}
+	if opts.RevalidateAfter <= 0 {
+		opts.RevalidateAfter = defaults.RevalidateAfter
+	}
+	if opts.NegativeTTL <= 0 {
+		opts.NegativeTTL = defaults.NegativeTTL
+	}
+	if opts.BrokenTTL <= 0 {
+		opts.BrokenTTL = defaults.BrokenTTL
+	}
+	if opts.Attempts <= 0 {
+		opts.Attempts = defaults.Attempts
+	}
+	opts.Prefix = strings.Trim(opts.Prefix, "/")
+	if opts.Bucket == "" {
+		s3Client = nil
+	}
+
+	return &Cache{
+		opts:        opts,
+		s3:          s3Client,
+		client:      &http.Client{Timeout: 20 * time.Second},
+		now:         time.Now,
+		lru:         list.New(),
+		entries:     make(map[string]*list.Element),
+		negative:    make(map[string]negativeEntry),
+		placeholder: newPlaceholder(),
+	}
+}
+
+// Key returns the cache key of an image URL
+func Key(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+// Stats returns a snapshot of the counters since the cache was created
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// Placeholder returns the tile served for permanently broken images
+func (c *Cache) Placeholder() image.Image {
+	return c.placeholder
+}
+
+// Load returns the image at url. Permanently broken images return the
+// placeholder tile and no error; transient failures return an error so the
+// caller can decide how to degrade.
+func (c *Cache) Load(ctx context.Context, url string) (image.Image, error) {
+	if url == "" {
+		return nil, fmt.Errorf("empty image URL")
+	}
+	key := Key(url)
+	now := c.now()
+
+	c.mu.Lock()
+	if neg, ok := c.negative[key]; ok {
+		if now.Before(neg.expires) {
+			c.stats.NegativeHits++
+			c.mu.Unlock()
+			return c.negativeResult(neg)
+		}
+		delete(c.negative, key)
+	}
+	e := c.getEntry(key)
+	if e != nil && now.Sub(e.validatedAt) < c.opts.RevalidateAfter {
+		c.stats.MemoryHits++
+		c.mu.Unlock()
+		return e.img, nil
+	}
+	c.mu.Unlock()
+
+	if e == nil {
+		var neg *negativeEntry
+		e, neg = c.loadFromS3(ctx, key)
+		if neg != nil {
+			c.remember(key, *neg)
+			c.count(func(s *Stats) { s.NegativeHits++ })
+			return c.negativeResult(*neg)
+		}
+		if e != nil {
+			c.count(func(s *Stats) { s.S3Hits++ })
+			c.putEntry(e)
+			if now.Sub(e.validatedAt) < c.opts.RevalidateAfter {
+				return e.img, nil
+			}
+		}
+	}
+
+	if e != nil {
+		return c.revalidate(ctx, url, e)
+	}
+	return c.download(ctx, url, key)
+}
+
+// download fetches an image that is not cached
+func (c *Cache) download(ctx context.Context, url, key string) (image.Image, error) {
+	res := c.fetch(ctx, url, "", "")
+	if res.err != nil {
+		return c.fail(ctx, key, res)
+	}
+	c.count(func(s *Stats) { s.Downloads++ })
+	return c.store(ctx, key, res)
+}
+
+// revalidate checks a stale entry with the origin. The stale image is served
+// when the origin cannot be reached.
+func (c *Cache) revalidate(ctx context.Context, url string, e *entry) (image.Image, error) {
+	c.count(func(s *Stats) { s.Revalidations++ })
+	res := c.fetch(ctx, url, e.etag, e.lastModified)
+	switch {
+	case res.notModified:
+		c.count(func(s *Stats) { s.NotModified++ })
+		c.mu.Lock()
+		e.validatedAt = c.now()
+		c.mu.Unlock()
+		return e.img, nil
+	case res.err == nil:
+		c.count(func(s *Stats) { s.Downloads++ })
+		return c.store(ctx, e.key, res)
+	case res.permanent:
+		c.removeEntry(e.key)
+		return c.fail(ctx, e.key, res)
+	default:
+		// Retry the origin after NegativeTTL rather than on every load
+		c.mu.Lock()
+		c.stats.StaleServed++
+		e.validatedAt = c.now().Add(c.opts.NegativeTTL - c.opts.RevalidateAfter)
+		c.mu.Unlock()
+		return e.img, nil
+	}
+}
+
+// store decodes a downloaded image and writes it to both layers
+func (c *Cache) store(ctx context.Context, key string, res fetchResult) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(res.data))
+	if err != nil {
+		res.err = fmt.Errorf("failed to decode image: %w", err)
+		res.permanent = true
+		return c.fail(ctx, key, res)
+	}
+
+	e := &entry{key: key, img: img, etag: res.etag, lastModified: res.lastModified, validatedAt: c.now()}
+	c.putEntry(e)
+	c.putS3(ctx, key, res.data, map[string]string{
+		metaStatus:       statusOK,
+		metaETag:         res.etag,
+		metaLastModified: res.lastModified,
+		metaValidatedAt:  e.validatedAt.UTC().Format(time.RFC3339),
+	})
+	return img, nil
+}
+
+// fail records a failed download. Permanent failures are also written to S3
+// so other instances skip the URL.
+func (c *Cache) fail(ctx context.Context, key string, res fetchResult) (image.Image, error) {
+	neg := negativeEntry{permanent: res.permanent, reason: res.err.Error(), expires: c.now().Add(c.opts.NegativeTTL)}
+	if res.permanent {
+		neg.expires = c.now().Add(c.opts.BrokenTTL)
+		c.putS3(ctx, key, nil, map[string]string{
+			metaStatus:      statusBroken,
+			metaValidatedAt: c.now().UTC().Format(time.RFC3339),
+		})
+	}
+	c.remember(key, neg)
+	c.count(func(s *Stats) { s.Failures++ })
+	return c.negativeResult(neg)
+}
+
+func (c *Cache) negativeResult(neg negativeEntry) (image.Image, error) {
+	if neg.permanent {
+		c.count(func(s *Stats) { s.Placeholders++ })
+		return c.placeholder, nil
+	}
+	return nil, fmt.Errorf("image unavailable: %s", neg.reason)
+}
+
+type fetchResult struct {
+	data         []byte
+	etag         string
+	lastModified string
+	notModified  bool
+	permanent    bool
+	err          error
+}
+
+// fetch downloads url, conditionally when a validator is given, retrying
+// transient failures
+func (c *Cache) fetch(ctx context.Context, url, etag, lastModified string) fetchResult {
+	var res fetchResult
+	for attempt := 0; attempt < c.opts.Attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return fetchResult{err: ctx.Err()}
+			case <-time.After(time.Duration(attempt) * 200 * time.Millisecond):
+			}
+		}
+		res = c.fetchOnce(ctx, url, etag, lastModified)
+		if res.err == nil || res.permanent {
+			return res
+		}
+	}
+	return res
+}
+
+func (c *Cache) fetchOnce(ctx context.Context, url, etag, lastModified string) fetchResult {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fetchResult{err: err, permanent: true}
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fetchResult{err: err}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return fetchResult{notModified: true}
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return fetchResult{err: fmt.Errorf("HTTP status %d", resp.StatusCode), permanent: true}
+	case resp.StatusCode != http.StatusOK:
+		return fetchResult{err: fmt.Errorf("HTTP status %d", resp.StatusCode)}
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fetchResult{err: err}
+	}
+	return fetchResult{
+		data:         data,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}
+}
+
+// loadFromS3 returns the cached entry or negative marker stored under key
+func (c *Cache) loadFromS3(ctx context.Context, key string) (*entry, *negativeEntry) {
+	if c.s3 == nil {
+		return nil, nil
+	}
+	out, err := c.s3.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &c.opts.Bucket,
+		Key:    c.objectKey(key),
+	})
+	if err != nil {
+		var noKey *types.NoSuchKey
+		if !errors.As(err, &noKey) {
+			c.count(func(s *Stats) { s.S3Errors++ })
+		}
+		return nil, nil
+	}
+	defer out.Body.Close()
+
+	validatedAt, _ := time.Parse(time.RFC3339, out.Metadata[metaValidatedAt])
+	if out.Metadata[metaStatus] == statusBroken {
+		expires := validatedAt.Add(c.opts.BrokenTTL)
+		if !c.now().Before(expires) {
+			return nil, nil
+		}
+		return nil, &negativeEntry{permanent: true, reason: "marked broken in cache", expires: expires}
+	}
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		c.count(func(s *Stats) { s.S3Errors++ })
+		return nil, nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		c.count(func(s *Stats) { s.S3Errors++ })
+		return nil, nil
+	}
+	return &entry{
+		key:          key,
+		img:          img,
+		etag:         out.Metadata[metaETag],
+		lastModified: out.Metadata[metaLastModified],
+		validatedAt:  validatedAt,
+	}, nil
+}
+
+// putS3 writes an object to the backing store. Failures only cost a future
+// download, so they are counted and otherwise ignored.
+func (c *Cache) putS3(ctx context.Context, key string, data []byte, metadata map[string]string) {
+	if c.s3 == nil {
+		return
+	}
+	_, err := c.s3.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:   &c.opts.Bucket,
+		Key:      c.objectKey(key),
+		Body:     bytes.NewReader(data),
+		Metadata: metadata,
+	})
+	if err != nil {
+		c.count(func(s *Stats) { s.S3Errors++ })
+	}
+}
+
+func (c *Cache) objectKey(key string) *string {
+	objectKey := key
+	if c.opts.Prefix != "" {
+		objectKey = c.opts.Prefix + "/" + key
+	}
+	return &objectKey
+}
+
+// getEntry returns the entry for key and marks it recently used; c.mu must be held
+func (c *Cache) getEntry(key string) *entry {
+	el, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	c.lru.MoveToFront(el)
+	return el.Value.(*entry)
+}
+
+func (c *Cache) putEntry(e *entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[e.key]; ok {
+		el.Value = e
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[e.key] = c.lru.PushFront(e)
+	for c.lru.Len() > c.opts.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).key)
+	}
+}
+
+func (c *Cache) removeEntry(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+	}
+}
+
+func (c *Cache) remember(key string, neg negativeEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for k, v := range c.negative {
+		if !now.Before(v.expires) {
+			delete(c.negative, k)
+		}
+	}
+	c.negative[key] = neg
+}
+
+func (c *Cache) count(update func(*Stats)) {
+	c.mu.Lock()
+	update(&c.stats)
+	c.mu.Unlock()
+}
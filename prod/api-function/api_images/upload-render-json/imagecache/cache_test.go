@@ -0,0 +1,234 @@
+package imagecache
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]*s3.PutObjectInput
+	data    map[string][]byte
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: make(map[string]*s3.PutObjectInput), data: make(map[string][]byte)}
+}
+
+func (f *fakeS3) GetObject(ctx context.Context, in *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, ok := f.objects[*in.Key]
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(f.data[*in.Key])), Metadata: obj.Metadata}, nil
+}
+
+func (f *fakeS3) PutObject(ctx context.Context, in *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	data, _ := io.ReadAll(in.Body)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[*in.Key] = in
+	f.data[*in.Key] = data
+	return &s3.PutObjectOutput{}, nil
+}
+
+type origin struct {
+	mu       sync.Mutex
+	requests int
+	status   int
+	etag     string
+	body     []byte
+}
+
+func (o *origin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.requests++
+	if o.status != 0 && o.status != http.StatusOK {
+		w.WriteHeader(o.status)
+		return
+	}
+	if o.etag != "" && r.Header.Get("If-None-Match") == o.etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", o.etag)
+	w.Write(o.body)
+}
+
+func (o *origin) set(status int) {
+	o.mu.Lock()
+	o.status = status
+	o.mu.Unlock()
+}
+
+func (o *origin) count() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.requests
+}
+
+func pngBytes(t *testing.T) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newTestCache(store S3API, clock *time.Time) *Cache {
+	opts := DefaultOptions()
+	opts.Bucket = "cache-bucket"
+	opts.Attempts = 1
+	c := New(store, opts)
+	c.now = func() time.Time { return *clock }
+	return c
+}
+
+func TestLoadCachesInMemoryAndS3(t *testing.T) {
+	o := &origin{etag: `"v1"`, body: pngBytes(t)}
+	server := httptest.NewServer(o)
+	defer server.Close()
+
+	clock := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	store := newFakeS3()
+	c := newTestCache(store, &clock)
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Load(context.Background(), server.URL+"/a.png"); err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+	}
+	if o.count() != 1 {
+		t.Errorf("Expected 1 download, got %d", o.count())
+	}
+	if got := c.Stats(); got.Downloads != 1 || got.MemoryHits != 2 {
+		t.Errorf("Unexpected stats %+v", got)
+	}
+	obj, ok := store.objects["image-cache/"+Key(server.URL+"/a.png")]
+	if !ok || obj.Metadata[metaETag] != `"v1"` {
+		t.Fatalf("Expected the image in S3 with its ETag, got %+v", obj)
+	}
+
+	// A cold instance is served from S3 without contacting the origin
+	cold := newTestCache(store, &clock)
+	if _, err := cold.Load(context.Background(), server.URL+"/a.png"); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if o.count() != 1 || cold.Stats().S3Hits != 1 {
+		t.Errorf("Expected an S3 hit, got %d downloads and %+v", o.count(), cold.Stats())
+	}
+}
+
+func TestLoadRevalidatesStaleEntries(t *testing.T) {
+	o := &origin{etag: `"v1"`, body: pngBytes(t)}
+	server := httptest.NewServer(o)
+	defer server.Close()
+
+	clock := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	c := newTestCache(nil, &clock)
+	url := server.URL + "/a.png"
+
+	if _, err := c.Load(context.Background(), url); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	// Stale entry, origin answers 304
+	clock = clock.Add(25 * time.Hour)
+	if _, err := c.Load(context.Background(), url); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := c.Stats(); got.Revalidations != 1 || got.NotModified != 1 {
+		t.Errorf("Expected a 304 revalidation, got %+v", got)
+	}
+
+	// Stale entry, origin down: the stale image is served
+	clock = clock.Add(25 * time.Hour)
+	o.set(http.StatusInternalServerError)
+	img, err := c.Load(context.Background(), url)
+	if err != nil || img == c.Placeholder() {
+		t.Fatalf("Expected the stale image, got %v, %v", img, err)
+	}
+	if c.Stats().StaleServed != 1 {
+		t.Errorf("Expected a stale serve, got %+v", c.Stats())
+	}
+}
+
+func TestLoadNegativeCaching(t *testing.T) {
+	o := &origin{status: http.StatusNotFound, body: pngBytes(t)}
+	server := httptest.NewServer(o)
+	defer server.Close()
+
+	clock := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	store := newFakeS3()
+	c := newTestCache(store, &clock)
+
+	// Permanently broken images get the placeholder, once per BrokenTTL
+	for i := 0; i < 2; i++ {
+		img, err := c.Load(context.Background(), server.URL+"/gone.png")
+		if err != nil || img != c.Placeholder() {
+			t.Fatalf("Expected the placeholder, got %v, %v", img, err)
+		}
+	}
+	if o.count() != 1 {
+		t.Errorf("Expected 1 request for a broken image, got %d", o.count())
+	}
+	cold := newTestCache(store, &clock)
+	if img, _ := cold.Load(context.Background(), server.URL+"/gone.png"); img != cold.Placeholder() || o.count() != 1 {
+		t.Errorf("Expected the broken marker in S3 to be honoured")
+	}
+
+	// Transient failures return an error and are retried after NegativeTTL
+	o.set(http.StatusServiceUnavailable)
+	for i := 0; i < 2; i++ {
+		if _, err := c.Load(context.Background(), server.URL+"/flaky.png"); err == nil {
+			t.Fatal("Expected an error for a transient failure")
+		}
+	}
+	if o.count() != 2 {
+		t.Errorf("Expected 1 request for the flaky image, got %d", o.count()-1)
+	}
+	clock = clock.Add(11 * time.Minute)
+	o.set(http.StatusOK)
+	if _, err := c.Load(context.Background(), server.URL+"/flaky.png"); err != nil {
+		t.Errorf("Expected the flaky image after NegativeTTL, got %v", err)
+	}
+}
+
+func TestLRUEviction(t *testing.T) {
+	o := &origin{body: pngBytes(t)}
+	server := httptest.NewServer(o)
+	defer server.Close()
+
+	clock := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	opts := DefaultOptions()
+	opts.MaxEntries = 2
+	c := New(nil, opts)
+	c.now = func() time.Time { return clock }
+
+	for _, name := range []string{"a", "b", "a", "c", "a", "b"} {
+		if _, err := c.Load(context.Background(), server.URL+"/"+name); err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+	}
+	// b is evicted by c and downloaded again; a stays hot
+	if o.count() != 4 {
+		t.Errorf("Expected 4 downloads, got %d", o.count())
+	}
+}
@@ -0,0 +1,35 @@
+package imagecache
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+const placeholderSize = 200
+
+// newPlaceholder draws the tile served for permanently broken images: a
+// light grey square with a border and a diagonal cross, so reviewers can tell
+// a broken product image from an empty slot.
+func newPlaceholder() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, placeholderSize, placeholderSize))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{240, 240, 240, 255}), image.Point{}, draw.Src)
+
+	line := color.RGBA{200, 200, 200, 255}
+	const width = 6
+	for i := 0; i < placeholderSize; i++ {
+		for w := 0; w < width; w++ {
+			// Border
+			img.Set(i, w, line)
+			img.Set(i, placeholderSize-1-w, line)
+			img.Set(w, i, line)
+			img.Set(placeholderSize-1-w, i, line)
+		}
+		// Cross
+		for w := -width / 2; w < width/2; w++ {
+			img.Set(i+w, i, line)
+			img.Set(placeholderSize-1-i+w, i, line)
+		}
+	}
+	return img
+}
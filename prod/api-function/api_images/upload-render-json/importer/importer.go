@@ -0,0 +1,307 @@
+// Package importer converts planograms maintained in spreadsheets into the
+// vendor layout format. Each spreadsheet row describes one slot: its row
+// (tray code), slot number, product, maximum quantity and product image.
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"api_images_upload_render/renderer"
+)
+
+// Options configures an import
+type Options struct {
+	// LayoutID is the layoutId of the imported layout
+	LayoutID int64
+	// Mapping names the spreadsheet columns
+	Mapping ColumnMapping
+	// Sheet is the XLSX worksheet to read; the first worksheet when empty
+	Sheet string
+}
+
+// RowError is a problem with a spreadsheet row. Row is the 1-based row number
+// shown by spreadsheet applications and Column the header of the cell.
+type RowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e RowError) String() string {
+	if e.Column == "" {
+		return fmt.Sprintf("row %d: %s", e.Row, e.Message)
+	}
+	return fmt.Sprintf("row %d, %s: %s", e.Row, e.Column, e.Message)
+}
+
+// Result is an imported layout and the rows that could not be imported
+type Result struct {
+	Layout renderer.Layout
+	// Rows is the number of non-empty rows below the header
+	Rows   int
+	Errors []RowError
+}
+
+// Valid reports whether every row was imported
+func (r *Result) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// ErrorMessages returns the errors as "row N, column: message" strings
+func (r *Result) ErrorMessages() []string {
+	messages := make([]string, len(r.Errors))
+	for i, rowErr := range r.Errors {
+		messages[i] = rowErr.String()
+	}
+	return messages
+}
+
+// SlotCount returns the number of imported slots
+func (r *Result) SlotCount() int {
+	count := 0
+	for _, sub := range r.Layout.SubLayoutList {
+		for _, tray := range sub.TrayList {
+			count += len(tray.SlotList)
+		}
+	}
+	return count
+}
+
+// Supported reports whether the file is a spreadsheet the importer reads
+func Supported(fileName string) bool {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv", ".xlsx":
+		return true
+	}
+	return false
+}
+
+// Import reads a CSV or XLSX planogram into a single sub-layout. The error is
+// only set for files that cannot be read at all; problems with individual
+// rows, including missing columns, are collected in Result.Errors.
+func Import(fileName string, data []byte, opts Options) (*Result, error) {
+	if opts.LayoutID < 1 {
+		return nil, fmt.Errorf("layoutId must be a positive integer, got %d", opts.LayoutID)
+	}
+
+	var records []record
+	var err error
+	switch ext := strings.ToLower(filepath.Ext(fileName)); ext {
+	case ".csv":
+		records, err = readCSV(data)
+	case ".xlsx":
+		records, err = readXLSX(data, opts.Sheet)
+	default:
+		return nil, fmt.Errorf("unsupported planogram file type: %s", ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return convert(records, opts)
+}
+
+// record is a spreadsheet row with its 1-based row number
+type record struct {
+	row   int
+	cells []string
+}
+
+func (r record) blank() bool {
+	for _, cell := range r.cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// cell returns the trimmed value at index, "" for absent cells
+func (r record) cell(index int) string {
+	if index < 0 || index >= len(r.cells) {
+		return ""
+	}
+	return strings.TrimSpace(r.cells[index])
+}
+
+// readCSV reads comma- or semicolon-separated rows; the separator is the one
+// that occurs more often in the first line
+func readCSV(data []byte) ([]record, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	var records []record
+	for {
+		cells, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %v", err)
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, record{row: line, cells: cells})
+	}
+	return records, nil
+}
+
+// convert builds the layout from the header row and the rows below it
+func convert(records []record, opts Options) (*Result, error) {
+	headerIdx := -1
+	for i, rec := range records {
+		if !rec.blank() {
+			headerIdx = i
+			break
+		}
+	}
+	if headerIdx < 0 {
+		return nil, errors.New("spreadsheet is empty; expected a header row")
+	}
+	header := records[headerIdx]
+
+	result := &Result{Layout: renderer.Layout{LayoutID: opts.LayoutID}}
+	cols, missing := opts.Mapping.resolve(header.cells)
+	for _, name := range missing {
+		result.Errors = append(result.Errors, RowError{Row: header.row, Column: name, Message: "column not found in header"})
+	}
+	if len(missing) > 0 {
+		return result, nil
+	}
+
+	headerName := func(index int) string {
+		return header.cell(index)
+	}
+	trays := make(map[string]*renderer.Tray)
+	positions := make(map[string]int)
+
+	for _, rec := range records[headerIdx+1:] {
+		if rec.blank() {
+			continue
+		}
+		result.Rows++
+		fail := func(index int, format string, args ...interface{}) {
+			result.Errors = append(result.Errors, RowError{Row: rec.row, Column: headerName(index), Message: fmt.Sprintf(format, args...)})
+		}
+
+		trayCode := strings.ToUpper(rec.cell(cols.row))
+		switch {
+		case trayCode == "":
+			fail(cols.row, "missing")
+		case strings.IndexFunc(trayCode, func(r rune) bool { return r < 'A' || r > 'Z' }) >= 0:
+			fail(cols.row, "must be a row letter such as A, got %q", rec.cell(cols.row))
+			trayCode = ""
+		}
+
+		slotNo, ok := parseCount(rec.cell(cols.slot), 1)
+		if !ok {
+			fail(cols.slot, "must be a slot number of at least 1, got %q", rec.cell(cols.slot))
+		}
+		productID, ok := parseCount(rec.cell(cols.productID), 0)
+		if !ok {
+			fail(cols.productID, "must be a product ID, got %q", rec.cell(cols.productID))
+		}
+		productTemplateID, ok := parseCount(rec.cell(cols.productTemplateID), 0)
+		if !ok {
+			fail(cols.productTemplateID, "must be a product template ID, got %q", rec.cell(cols.productTemplateID))
+		}
+		maxQuantity, ok := parseCount(rec.cell(cols.maxQuantity), 0)
+		if !ok {
+			fail(cols.maxQuantity, "must be a non-negative integer, got %q", rec.cell(cols.maxQuantity))
+		}
+		if trayCode == "" || slotNo < 1 {
+			continue
+		}
+
+		position := fmt.Sprintf("%s%d", trayCode, slotNo)
+		if first, seen := positions[position]; seen {
+			fail(cols.slot, "duplicate position %s, first defined on row %d", position, first)
+			continue
+		}
+		positions[position] = rec.row
+
+		tray := trays[trayCode]
+		if tray == nil {
+			tray = &renderer.Tray{TrayCode: trayCode}
+			trays[trayCode] = tray
+		}
+		tray.SlotList = append(tray.SlotList, renderer.Slot{
+			ProductId:            productID,
+			ProductTemplateId:    productTemplateID,
+			MaxQuantity:          maxQuantity,
+			SlotNo:               slotNo,
+			Status:               renderer.SlotStatusActive,
+			Position:             position,
+			ProductTemplateName:  rec.cell(cols.productName),
+			ProductTemplateImage: rec.cell(cols.image),
+		})
+	}
+
+	result.Layout.SubLayoutList = []renderer.SubLayout{{TrayList: sortTrays(trays)}}
+	return result, nil
+}
+
+// sortTrays orders trays A..Z, AA.. and numbers them from 1, with slots
+// ordered by slot number
+func sortTrays(trays map[string]*renderer.Tray) []renderer.Tray {
+	codes := make([]string, 0, len(trays))
+	for code := range trays {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if len(codes[i]) != len(codes[j]) {
+			return len(codes[i]) < len(codes[j])
+		}
+		return codes[i] < codes[j]
+	})
+
+	list := make([]renderer.Tray, 0, len(codes))
+	for i, code := range codes {
+		tray := *trays[code]
+		tray.TrayNo = i + 1
+		sort.Slice(tray.SlotList, func(a, b int) bool {
+			return tray.SlotList[a].SlotNo < tray.SlotList[b].SlotNo
+		})
+		list = append(list, tray)
+	}
+	return list
+}
+
+// parseCount parses a whole number of at least min. Spreadsheets may store
+// integers as decimals such as "12.0", which are accepted; an empty cell is
+// 0 when min is 0.
+func parseCount(value string, min int) (int, bool) {
+	if value == "" {
+		return 0, min == 0
+	}
+	value = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, value)
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		f, ferr := strconv.ParseFloat(value, 64)
+		if ferr != nil || f != math.Trunc(f) || f > math.MaxInt32 || f < math.MinInt32 {
+			return 0, false
+		}
+		n = int(f)
+	}
+	return n, n >= min
+}
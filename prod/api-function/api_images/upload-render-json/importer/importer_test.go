@@ -0,0 +1,164 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"testing"
+
+	"api_images_upload_render/renderer"
+)
+
+func TestImportCSV(t *testing.T) {
+	data := "\xef\xbb\xbfRow,Slot,Product ID,Product Name,Max Quantity,Image\n" +
+		"B,1,204,Coca Light,8,https://example.com/204.png\n" +
+		"A,2,101,\"Coca-Cola, 330ml\",10.0,https://example.com/101.png\n" +
+		",,,,,\n" +
+		"a,1,,,,\n"
+
+	result, err := Import("planogram.csv", []byte(data), Options{LayoutID: 42, Mapping: DefaultMapping()})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if !result.Valid() {
+		t.Fatalf("Unexpected errors: %v", result.ErrorMessages())
+	}
+	if result.Rows != 3 || result.SlotCount() != 3 {
+		t.Errorf("Expected 3 rows and slots, got %d and %d", result.Rows, result.SlotCount())
+	}
+
+	want := renderer.Layout{LayoutID: 42, SubLayoutList: []renderer.SubLayout{{TrayList: []renderer.Tray{
+		{TrayCode: "A", TrayNo: 1, SlotList: []renderer.Slot{
+			{SlotNo: 1, Status: renderer.SlotStatusActive, Position: "A1"},
+			{ProductId: 101, MaxQuantity: 10, SlotNo: 2, Status: renderer.SlotStatusActive, Position: "A2", ProductTemplateName: "Coca-Cola, 330ml", ProductTemplateImage: "https://example.com/101.png"},
+		}},
+		{TrayCode: "B", TrayNo: 2, SlotList: []renderer.Slot{
+			{ProductId: 204, MaxQuantity: 8, SlotNo: 1, Status: renderer.SlotStatusActive, Position: "B1", ProductTemplateName: "Coca Light", ProductTemplateImage: "https://example.com/204.png"},
+		}},
+	}}}}
+	if !reflect.DeepEqual(result.Layout, want) {
+		t.Errorf("got %+v, want %+v", result.Layout, want)
+	}
+}
+
+func TestImportCSVRowErrors(t *testing.T) {
+	data := "row;slot;productId;maxQuantity\n" +
+		"A;1;101;10\n" +
+		"A;0;102;ten\n" +
+		"7;2;103;5\n" +
+		"A;1;104;5\n"
+
+	result, err := Import("planogram.csv", []byte(data), Options{LayoutID: 1, Mapping: DefaultMapping()})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	want := []string{
+		`row 3, slot: must be a slot number of at least 1, got "0"`,
+		`row 3, maxQuantity: must be a non-negative integer, got "ten"`,
+		`row 4, row: must be a row letter such as A, got "7"`,
+		`row 5, slot: duplicate position A1, first defined on row 2`,
+	}
+	if got := result.ErrorMessages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestImportColumnMapping(t *testing.T) {
+	mapping, err := ParseMapping(DefaultMapping(), "row=Tray, slot=Slot No,productId=SKU")
+	if err != nil {
+		t.Fatalf("ParseMapping failed: %v", err)
+	}
+	data := "Tray,Slot No,SKU,maxQuantity\nC,3,55,4\n"
+	result, err := Import("planogram.csv", []byte(data), Options{LayoutID: 1, Mapping: mapping})
+	if err != nil || !result.Valid() {
+		t.Fatalf("Import failed: %v %v", err, result.ErrorMessages())
+	}
+	slot := result.Layout.SubLayoutList[0].TrayList[0].SlotList[0]
+	if slot.Position != "C3" || slot.ProductId != 55 || slot.MaxQuantity != 4 {
+		t.Errorf("Unexpected slot %+v", slot)
+	}
+
+	result, err = Import("planogram.csv", []byte(data), Options{LayoutID: 1, Mapping: DefaultMapping()})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	want := []string{"row 1, row: column not found in header", "row 1, slot: column not found in header", "row 1, productId: column not found in header"}
+	if got := result.ErrorMessages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	for _, spec := range []string{"shelf=Tray", "row", "row="} {
+		if _, err := ParseMapping(DefaultMapping(), spec); err == nil {
+			t.Errorf("Expected an error for %q", spec)
+		}
+	}
+}
+
+func TestImportXLSX(t *testing.T) {
+	data := buildWorkbook(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Notes" sheetId="1" r:id="rId1"/><sheet name="Planogram" sheetId="2" r:id="rId2"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Target="/xl/worksheets/sheet2.xml"/></Relationships>`,
+		"xl/sharedStrings.xml":     `<sst><si><t>row</t></si><si><t>slot</t></si><si><t>productId</t></si><si><t>maxQuantity</t></si><si><r><t>Trà </t></r><r><t>Xanh</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData/></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c><c r="D1" t="s"><v>3</v></c><c r="F1" t="inlineStr"><is><t>productName</t></is></c></row>
+<row r="3"><c r="A3" t="inlineStr"><is><t>B</t></is></c><c r="B3"><v>4</v></c><c r="C3"><v>310</v></c><c r="D3"><v>6</v></c><c r="F3" t="s"><v>4</v></c></row>
+</sheetData></worksheet>`,
+	})
+
+	result, err := Import("planogram.xlsx", data, Options{LayoutID: 7, Mapping: DefaultMapping(), Sheet: "Planogram"})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if !result.Valid() || result.Rows != 1 {
+		t.Fatalf("Expected 1 valid row, got %d: %v", result.Rows, result.ErrorMessages())
+	}
+	slot := result.Layout.SubLayoutList[0].TrayList[0].SlotList[0]
+	want := renderer.Slot{ProductId: 310, MaxQuantity: 6, SlotNo: 4, Status: renderer.SlotStatusActive, Position: "B4", ProductTemplateName: "Trà Xanh"}
+	if !reflect.DeepEqual(slot, want) {
+		t.Errorf("got %+v, want %+v", slot, want)
+	}
+
+	if _, err := Import("planogram.xlsx", data, Options{LayoutID: 7, Mapping: DefaultMapping()}); err == nil {
+		t.Error("Expected an error for the empty first worksheet")
+	}
+	if _, err := Import("planogram.xlsx", data, Options{LayoutID: 7, Mapping: DefaultMapping(), Sheet: "Missing"}); err == nil {
+		t.Error("Expected an error for an unknown worksheet")
+	}
+	if _, err := Import("planogram.xlsx", []byte("not a zip"), Options{LayoutID: 7, Mapping: DefaultMapping()}); err == nil {
+		t.Error("Expected an error for a corrupt workbook")
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	cases := map[string]int{"A1": 0, "Z9": 25, "AA10": 26, "AB1": 27}
+	for ref, want := range cases {
+		if got, err := columnIndex(ref); err != nil || got != want {
+			t.Errorf("%s: got %d (%v), want %d", ref, got, err, want)
+		}
+	}
+	if _, err := columnIndex("12"); err == nil {
+		t.Error("Expected an error for a reference without a column")
+	}
+}
+
+func buildWorkbook(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, content := range parts {
+		part, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := part.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,126 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ColumnMapping names the spreadsheet column each slot field is read from.
+// Headers are matched ignoring case, spaces, underscores and dashes, so the
+// default "maxQuantity" also matches a "Max Quantity" column.
+type ColumnMapping struct {
+	Row               string `json:"row"`
+	Slot              string `json:"slot"`
+	ProductID         string `json:"productId"`
+	ProductTemplateID string `json:"productTemplateId"`
+	ProductName       string `json:"productName"`
+	MaxQuantity       string `json:"maxQuantity"`
+	Image             string `json:"image"`
+}
+
+// DefaultMapping returns the mapping used when no columns are configured
+func DefaultMapping() ColumnMapping {
+	return ColumnMapping{
+		Row:               "row",
+		Slot:              "slot",
+		ProductID:         "productId",
+		ProductTemplateID: "productTemplateId",
+		ProductName:       "productName",
+		MaxQuantity:       "maxQuantity",
+		Image:             "image",
+	}
+}
+
+// ParseMapping overrides columns of base with comma-separated field=header
+// pairs, e.g. "row=Tray,slot=Slot No,image=Image URL". An empty spec
+// returns base unchanged.
+func ParseMapping(base ColumnMapping, spec string) (ColumnMapping, error) {
+	mapping := base
+	for _, pair := range strings.Split(spec, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, header, ok := strings.Cut(pair, "=")
+		name, header = strings.TrimSpace(name), strings.TrimSpace(header)
+		if !ok || header == "" {
+			return base, fmt.Errorf("invalid column mapping %q: expected field=header", pair)
+		}
+		target := mapping.field(name)
+		if target == nil {
+			return base, fmt.Errorf("unknown column mapping field: %s", name)
+		}
+		*target = header
+	}
+	return mapping, nil
+}
+
+// field returns the header of the named field, matched like the JSON names
+func (m *ColumnMapping) field(name string) *string {
+	switch normalizeHeader(name) {
+	case "row":
+		return &m.Row
+	case "slot":
+		return &m.Slot
+	case "productid":
+		return &m.ProductID
+	case "producttemplateid":
+		return &m.ProductTemplateID
+	case "productname":
+		return &m.ProductName
+	case "maxquantity":
+		return &m.MaxQuantity
+	case "image":
+		return &m.Image
+	}
+	return nil
+}
+
+// columns is the position of each mapped column in the header row; -1 when
+// an optional column is absent
+type columns struct {
+	row, slot, productID, productTemplateID, productName, maxQuantity, image int
+}
+
+// resolve locates the mapped columns in the header row and returns the
+// headers of the required columns that are missing
+func (m ColumnMapping) resolve(header []string) (columns, []string) {
+	index := make(map[string]int, len(header))
+	for i, cell := range header {
+		key := normalizeHeader(cell)
+		if _, seen := index[key]; !seen && key != "" {
+			index[key] = i
+		}
+	}
+
+	var missing []string
+	lookup := func(name string, required bool) int {
+		if i, ok := index[normalizeHeader(name)]; ok {
+			return i
+		}
+		if required {
+			missing = append(missing, name)
+		}
+		return -1
+	}
+
+	cols := columns{
+		row:               lookup(m.Row, true),
+		slot:              lookup(m.Slot, true),
+		productID:         lookup(m.ProductID, true),
+		productTemplateID: lookup(m.ProductTemplateID, false),
+		productName:       lookup(m.ProductName, false),
+		maxQuantity:       lookup(m.MaxQuantity, true),
+		image:             lookup(m.Image, false),
+	}
+	return cols, missing
+}
+
+func normalizeHeader(header string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '_' || r == '-' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, strings.TrimPrefix(header, "\ufeff"))
+}
@@ -0,0 +1,209 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxXLSXPartSize limits the uncompressed size of each workbook part read
+const maxXLSXPartSize = 64 * 1024 * 1024
+
+// Workbook XML parts, reduced to what the importer reads
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+// xlsxText is plain text or rich text made of runs
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Number int `xml:"r,attr"`
+		Cells  []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXLSX reads the rows of a worksheet, the first one when sheet is empty.
+// Cells hold their displayed text; formulas contribute their cached value.
+func readXLSX(data []byte, sheet string) ([]record, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XLSX workbook: %v", err)
+	}
+	parts := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		parts[file.Name] = file
+	}
+
+	var workbook xlsxWorkbook
+	if err := decodePart(parts, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var rels xlsxRelationships
+	if err := decodePart(parts, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	var sharedStrings xlsxSharedStrings
+	if _, ok := parts["xl/sharedStrings.xml"]; ok {
+		if err := decodePart(parts, "xl/sharedStrings.xml", &sharedStrings); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(workbook.Sheets) == 0 {
+		return nil, fmt.Errorf("XLSX workbook has no worksheets")
+	}
+	rid := workbook.Sheets[0].RID
+	if sheet != "" {
+		rid = ""
+		for _, s := range workbook.Sheets {
+			if s.Name == sheet {
+				rid = s.RID
+				break
+			}
+		}
+		if rid == "" {
+			return nil, fmt.Errorf("worksheet not found: %s", sheet)
+		}
+	}
+
+	var sheetPart string
+	for _, rel := range rels.Relationships {
+		if rel.ID == rid {
+			if strings.HasPrefix(rel.Target, "/") {
+				sheetPart = strings.TrimPrefix(rel.Target, "/")
+			} else {
+				sheetPart = path.Join("xl", rel.Target)
+			}
+			break
+		}
+	}
+	if sheetPart == "" {
+		return nil, fmt.Errorf("worksheet %s has no relationship in the workbook", rid)
+	}
+
+	var worksheet xlsxWorksheet
+	if err := decodePart(parts, sheetPart, &worksheet); err != nil {
+		return nil, err
+	}
+
+	records := make([]record, 0, len(worksheet.Rows))
+	previousRow := 0
+	for _, row := range worksheet.Rows {
+		number := row.Number
+		if number == 0 {
+			number = previousRow + 1
+		}
+		previousRow = number
+
+		rec := record{row: number}
+		for _, cell := range row.Cells {
+			column := len(rec.cells)
+			if cell.Ref != "" {
+				if column, err = columnIndex(cell.Ref); err != nil {
+					return nil, err
+				}
+			}
+
+			var value string
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(strings.TrimSpace(cell.Value))
+				if err != nil || index < 0 || index >= len(sharedStrings.Items) {
+					return nil, fmt.Errorf("cell %s refers to an unknown shared string %q", cell.Ref, cell.Value)
+				}
+				value = sharedStrings.Items[index].String()
+			case "inlineStr":
+				value = cell.Inline.String()
+			case "b":
+				value = map[string]string{"1": "TRUE", "0": "FALSE"}[cell.Value]
+			default:
+				value = cell.Value
+			}
+
+			for len(rec.cells) <= column {
+				rec.cells = append(rec.cells, "")
+			}
+			rec.cells[column] = value
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// decodePart unmarshals a workbook part
+func decodePart(parts map[string]*zip.File, name string, v interface{}) error {
+	file, ok := parts[name]
+	if !ok {
+		return fmt.Errorf("XLSX workbook is missing %s", name)
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", name, err)
+	}
+	defer reader.Close()
+
+	if err := xml.NewDecoder(io.LimitReader(reader, maxXLSXPartSize)).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", name, err)
+	}
+	return nil
+}
+
+// columnIndex returns the 0-based column of a cell reference such as "C12"
+func columnIndex(ref string) (int, error) {
+	column := 0
+	letters := 0
+	for _, r := range strings.ToUpper(ref) {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A'+1)
+		letters++
+	}
+	if letters == 0 || letters > 3 {
+		return 0, fmt.Errorf("invalid cell reference: %s", ref)
+	}
+	return column - 1, nil
+}
@@ -12,7 +12,9 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
@@ -22,6 +24,8 @@ import (
 
 	appconfig "api_images_upload_render/config"
 	"api_images_upload_render/dynamodb"
+	"api_images_upload_render/imagecache"
+	"api_images_upload_render/importer"
 	"api_images_upload_render/logger"
 	"api_images_upload_render/prefix"
 	"api_images_upload_render/renderer"
@@ -36,6 +40,17 @@ var (
 	jsonRenderPath     string
 	jsonRenderBucket   string
 	jsonRenderS3Path   string
+	// validationMode is the default layout validation mode, overridden per
+	// request with the validation query parameter
+	validationMode = renderer.ValidationLenient
+	// importMapping names the planogram spreadsheet columns, overridden per
+	// request with the columnMapping query parameter
+	importMapping = importer.DefaultMapping()
+	// renderTheme is the default render theme, overridden per request with
+	// the theme or tenant query parameter
+	renderTheme = renderer.DefaultThemeName
+	// tenantThemes maps tenants to render themes
+	tenantThemes = map[string]string{}
 )
 
 // UploadResponse represents the response structure for the file upload API
@@ -45,6 +60,19 @@ type UploadResponse struct {
 	Files        []UploadedFile `json:"files,omitempty"`
 	Errors       []string       `json:"errors,omitempty"`
 	RenderResult *RenderResult  `json:"renderResult,omitempty"`
+	// Validation lists the issues found in an uploaded layout
+	Validation *renderer.ValidationResult `json:"validation,omitempty"`
+	// Import describes a planogram spreadsheet converted to layout JSON
+	Import *ImportSummary `json:"import,omitempty"`
+}
+
+// ImportSummary describes a planogram spreadsheet (CSV or XLSX) imported as
+// layout JSON
+type ImportSummary struct {
+	SourceFile string              `json:"sourceFile"`
+	Rows       int                 `json:"rows"`
+	Slots      int                 `json:"slots"`
+	Errors     []importer.RowError `json:"errors,omitempty"`
 }
 
 // UploadedFile represents information about an uploaded file
@@ -64,6 +92,17 @@ type RenderResult struct {
 	LayoutPrefix  string `json:"layoutPrefix,omitempty"`
 	ProcessedKey  string `json:"processedKey,omitempty"`
 	Message       string `json:"message,omitempty"`
+	// FormatKeys maps each rendered format (png, svg, pdf) to its S3 key
+	FormatKeys map[string]string `json:"formatKeys,omitempty"`
+	// CapacityKeys maps each format of the capacity view to its S3 key
+	CapacityKeys map[string]string `json:"capacityKeys,omitempty"`
+	// Theme and label language the layout was rendered with
+	Theme    string `json:"theme,omitempty"`
+	Language string `json:"language,omitempty"`
+	// Layout version of the vending machine, set when metadata is stored
+	VendingMachineID string `json:"vendingMachineId,omitempty"`
+	Version          int    `json:"version,omitempty"`
+	EffectiveFrom    string `json:"effectiveFrom,omitempty"`
 }
 
 // ErrorResponse represents an error response
@@ -90,6 +129,7 @@ func isBinaryFile(filename string) bool {
 		".tiff": true,
 		".tif":  true,
 		".pdf":  true,
+		".xlsx": true,
 	}
 	return binaryExtensions[ext]
 }
@@ -125,6 +165,11 @@ func validateBinaryFileIntegrity(filename string, fileBytes []byte) error {
 		if len(fileBytes) < 4 || !bytes.Equal(fileBytes[:4], []byte("%PDF")) {
 			return fmt.Errorf("PDF file signature missing or corrupted")
 		}
+	case ".xlsx":
+		// XLSX files are ZIP archives and should start with "PK\x03\x04"
+		if len(fileBytes) < 4 || !bytes.Equal(fileBytes[:4], []byte("PK\x03\x04")) {
+			return fmt.Errorf("XLSX file signature missing or corrupted")
+		}
 	}
 
 	// Check for UTF-8 replacement characters which indicate binary corruption
@@ -246,6 +291,18 @@ func init() {
 	referenceBucket = os.Getenv("REFERENCE_BUCKET")
 	checkingBucket = os.Getenv("CHECKING_BUCKET")
 	jsonRenderPath = os.Getenv("JSON_RENDER_PATH")
+	appconfig.UpdateSubLayoutArrangement(os.Getenv("SUBLAYOUT_ARRANGEMENT"))
+
+	mode, err := renderer.ParseValidationMode(os.Getenv("LAYOUT_VALIDATION_MODE"))
+	if err != nil {
+		log.WithError(err).Fatal("Invalid LAYOUT_VALIDATION_MODE. Expected: strict or lenient")
+	}
+	validationMode = mode
+
+	importMapping, err = importer.ParseMapping(importer.DefaultMapping(), os.Getenv("IMPORT_COLUMN_MAPPING"))
+	if err != nil {
+		log.WithError(err).Fatal("Invalid IMPORT_COLUMN_MAPPING. Expected: field=header pairs separated by commas")
+	}
 
 	if referenceBucket == "" || checkingBucket == "" {
 		log.Fatal("REFERENCE_BUCKET and CHECKING_BUCKET environment variables are required")
@@ -272,6 +329,133 @@ func init() {
 	}
 
 	s3Client = s3.NewFromConfig(cfg)
+
+	renderer.SetImageCache(newImageCache())
+	loadRenderThemes()
+}
+
+// loadRenderThemes registers the theme files in RENDER_THEME_DIR (default
+// "themes") and reads the default theme and tenant themes
+func loadRenderThemes() {
+	themeDir := os.Getenv("RENDER_THEME_DIR")
+	if themeDir == "" {
+		themeDir = "themes"
+	}
+	names, err := renderer.LoadThemes(themeDir)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid render theme")
+	}
+
+	if value := os.Getenv("RENDER_THEME"); value != "" {
+		renderTheme = value
+	}
+	if _, err := renderer.LookupTheme(renderTheme); err != nil {
+		log.WithError(err).Fatal("Invalid RENDER_THEME")
+	}
+
+	for _, pair := range strings.Split(os.Getenv("RENDER_TENANT_THEMES"), ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		tenant, theme, ok := strings.Cut(pair, "=")
+		tenant, theme = strings.TrimSpace(tenant), strings.TrimSpace(theme)
+		if !ok || tenant == "" {
+			log.WithField("value", pair).Fatal("Invalid RENDER_TENANT_THEMES. Expected: tenant=theme pairs separated by commas")
+		}
+		if _, err := renderer.LookupTheme(theme); err != nil {
+			log.WithError(err).WithField("tenant", tenant).Fatal("Invalid RENDER_TENANT_THEMES")
+		}
+		tenantThemes[tenant] = theme
+	}
+
+	log.WithFields(logrus.Fields{
+		"themeDir":     themeDir,
+		"themes":       names,
+		"defaultTheme": renderTheme,
+		"tenantThemes": tenantThemes,
+	}).Info("Render themes configured")
+}
+
+// parseRenderOptions selects the render theme from the theme query parameter,
+// else the tenant's theme, else RENDER_THEME, the label language from lang,
+// else the theme's language, and the view. A stock overlay is only accepted
+// with view=capacity.
+func parseRenderOptions(params map[string]string, stock []byte) (renderer.RenderOptions, error) {
+	name := renderTheme
+	if tenant := strings.TrimSpace(params["tenant"]); tenant != "" {
+		theme, ok := tenantThemes[tenant]
+		if !ok {
+			return renderer.RenderOptions{}, fmt.Errorf("unknown tenant: %s", tenant)
+		}
+		name = theme
+	}
+	if theme := strings.TrimSpace(params["theme"]); theme != "" {
+		name = theme
+	}
+
+	theme, err := renderer.LookupTheme(name)
+	if err != nil {
+		return renderer.RenderOptions{}, err
+	}
+	opts := renderer.RenderOptions{Theme: theme, Language: strings.ToLower(strings.TrimSpace(params["lang"]))}
+	if opts.Language != "" {
+		if _, err := renderer.LabelsFor(opts.Language); err != nil {
+			return renderer.RenderOptions{}, err
+		}
+	}
+
+	if opts.View, err = renderer.ParseView(params["view"]); err != nil {
+		return renderer.RenderOptions{}, err
+	}
+	if len(bytes.TrimSpace(stock)) > 0 {
+		if opts.View != renderer.ViewCapacity {
+			return renderer.RenderOptions{}, fmt.Errorf("a stock overlay requires view=capacity")
+		}
+		if opts.Stock, err = renderer.ParseStockOverlay(stock); err != nil {
+			return renderer.RenderOptions{}, err
+		}
+	}
+	return opts, nil
+}
+
+// newImageCache builds the product image cache from the environment. Images
+// are persisted under IMAGE_CACHE_S3_PATH (default s3://REFERENCE_BUCKET/image-cache).
+func newImageCache() *imagecache.Cache {
+	opts := imagecache.DefaultOptions()
+	opts.Bucket = referenceBucket
+	if cachePath := os.Getenv("IMAGE_CACHE_S3_PATH"); cachePath != "" {
+		bucket, path, err := parseS3URI(cachePath)
+		if err != nil || bucket == "" {
+			log.WithField("value", cachePath).Fatal("Invalid IMAGE_CACHE_S3_PATH format. Expected: s3://bucket-name/path/")
+		}
+		opts.Bucket = bucket
+		opts.Prefix = path
+	}
+	if value := os.Getenv("IMAGE_CACHE_SIZE"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			log.WithError(err).Fatal("Invalid IMAGE_CACHE_SIZE")
+		}
+		opts.MaxEntries = size
+	}
+	if value := os.Getenv("IMAGE_CACHE_REVALIDATE_AFTER"); value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			log.WithError(err).Fatal("Invalid IMAGE_CACHE_REVALIDATE_AFTER")
+		}
+		opts.RevalidateAfter = ttl
+	}
+	if os.Getenv("IMAGE_CACHE_DISABLE_S3") == "true" {
+		opts.Bucket = ""
+	}
+
+	log.WithFields(logrus.Fields{
+		"bucket":          opts.Bucket,
+		"prefix":          opts.Prefix,
+		"maxEntries":      opts.MaxEntries,
+		"revalidateAfter": opts.RevalidateAfter.String(),
+	}).Info("Product image cache configured")
+	return imagecache.New(s3Client, opts)
 }
 
 func handler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
@@ -369,15 +553,16 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (events
 	}, nil
 }
 
-// parseMultipartForm parses multipart form data from API Gateway request
-func parseMultipartForm(request events.APIGatewayProxyRequest) ([]byte, string, error) {
+// parseMultipartForm parses multipart form data from API Gateway request. It
+// returns the file part and the other form fields, such as the stock overlay.
+func parseMultipartForm(request events.APIGatewayProxyRequest) ([]byte, string, map[string][]byte, error) {
 	contentType := request.Headers["content-type"]
 	if contentType == "" {
 		contentType = request.Headers["Content-Type"]
 	}
 
 	if !strings.Contains(contentType, "multipart/form-data") {
-		return nil, "", fmt.Errorf("request must be multipart/form-data")
+		return nil, "", nil, fmt.Errorf("request must be multipart/form-data")
 	}
 
 	// Get the body
@@ -389,7 +574,7 @@ func parseMultipartForm(request events.APIGatewayProxyRequest) ([]byte, string,
 		// Decode base64 body
 		bodyBytes, err = base64.StdEncoding.DecodeString(body)
 		if err != nil {
-			return nil, "", fmt.Errorf("failed to decode base64 body: %v", err)
+			return nil, "", nil, fmt.Errorf("failed to decode base64 body: %v", err)
 		}
 		log.WithField("bodySize", len(bodyBytes)).Info("Decoded base64 encoded body")
 	} else {
@@ -402,46 +587,59 @@ func parseMultipartForm(request events.APIGatewayProxyRequest) ([]byte, string,
 		// Check if the body contains potential binary corruption indicators
 		if strings.Contains(body, "\ufffd") {
 			log.Error("Detected UTF-8 replacement characters in request body - binary data is corrupted")
-			return nil, "", fmt.Errorf("binary data corruption detected - ensure API Gateway is configured for base64 encoding")
+			return nil, "", nil, fmt.Errorf("binary data corruption detected - ensure API Gateway is configured for base64 encoding")
 		}
 	}
 
 	// Parse the content type to get the boundary
 	_, params, err := mime.ParseMediaType(contentType)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to parse content type: %v", err)
+		return nil, "", nil, fmt.Errorf("failed to parse content type: %v", err)
 	}
 
 	boundary, ok := params["boundary"]
 	if !ok {
-		return nil, "", fmt.Errorf("no boundary found in content type")
+		return nil, "", nil, fmt.Errorf("no boundary found in content type")
 	}
 
 	// Create a multipart reader
 	reader := multipart.NewReader(bytes.NewReader(bodyBytes), boundary)
 
 	// Parse the multipart form
+	var fileBytes []byte
+	var fileName string
+	fields := make(map[string][]byte)
 	for {
 		part, err := reader.NextPart()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			return nil, "", fmt.Errorf("failed to read multipart part: %v", err)
+			return nil, "", nil, fmt.Errorf("failed to read multipart part: %v", err)
+		}
+
+		// Other form fields are returned by name
+		if part.FormName() != "file" {
+			value, err := io.ReadAll(io.LimitReader(part, MaxFileSize))
+			if err != nil {
+				return nil, "", nil, fmt.Errorf("failed to read form field %s: %v", part.FormName(), err)
+			}
+			fields[part.FormName()] = value
+			continue
 		}
 
 		// Check if this is the file part
-		if part.FormName() == "file" {
+		if fileBytes == nil {
 			// Read the file content
-			fileBytes, err := io.ReadAll(part)
+			fileBytes, err = io.ReadAll(part)
 			if err != nil {
-				return nil, "", fmt.Errorf("failed to read file content: %v", err)
+				return nil, "", nil, fmt.Errorf("failed to read file content: %v", err)
 			}
 
 			// Get the filename
-			fileName := part.FileName()
+			fileName = part.FileName()
 			if fileName == "" {
-				return nil, "", fmt.Errorf("no filename provided")
+				return nil, "", nil, fmt.Errorf("no filename provided")
 			}
 
 			// Log file information for debugging
@@ -451,17 +649,18 @@ func parseMultipartForm(request events.APIGatewayProxyRequest) ([]byte, string,
 				"isBase64":     request.IsBase64Encoded,
 				"contentType":  part.Header.Get("Content-Type"),
 			}).Info("Successfully parsed file from multipart form")
-
-			return fileBytes, fileName, nil
 		}
 	}
 
-	return nil, "", fmt.Errorf("no file found in multipart form")
+	if fileBytes == nil {
+		return nil, "", nil, fmt.Errorf("no file found in multipart form")
+	}
+	return fileBytes, fileName, fields, nil
 }
 
 func processFileUpload(ctx context.Context, request events.APIGatewayProxyRequest, bucketName, uploadPath string) (*UploadResponse, error) {
 	// Parse multipart form data
-	fileBytes, fileName, err := parseMultipartForm(request)
+	fileBytes, fileName, fields, err := parseMultipartForm(request)
 	if err != nil {
 		log.WithError(err).Error("Failed to parse multipart form")
 		return &UploadResponse{
@@ -483,6 +682,54 @@ func processFileUpload(ctx context.Context, request events.APIGatewayProxyReques
 		}
 	}
 
+	// Parse requested render formats (PNG is always rendered)
+	renderFormats, err := renderer.ParseFormats(request.QueryStringParameters["formats"])
+	if err != nil {
+		return &UploadResponse{
+			Success: false,
+			Message: "Invalid render formats",
+			Errors:  []string{err.Error()},
+		}, nil
+	}
+
+	// Parse the render theme, label language, view and stock overlay
+	renderOpts, err := parseRenderOptions(request.QueryStringParameters, fields["stock"])
+	if err != nil {
+		return &UploadResponse{
+			Success: false,
+			Message: "Invalid render options",
+			Errors:  []string{err.Error()},
+		}, nil
+	}
+
+	// Parse the layout validation mode
+	mode := validationMode
+	if value := request.QueryStringParameters["validation"]; value != "" {
+		mode, err = renderer.ParseValidationMode(value)
+		if err != nil {
+			return &UploadResponse{
+				Success: false,
+				Message: "Invalid validation mode",
+				Errors:  []string{err.Error()},
+			}, nil
+		}
+	}
+
+	// Parse the layout version options
+	versionOpts := dynamodb.VersionOptions{
+		VendingMachineID: strings.TrimSpace(request.QueryStringParameters["vendingMachineId"]),
+	}
+	if value := request.QueryStringParameters["effectiveFrom"]; value != "" {
+		versionOpts.EffectiveFrom, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return &UploadResponse{
+				Success: false,
+				Message: "Invalid effectiveFrom",
+				Errors:  []string{"effectiveFrom must be an RFC3339 timestamp"},
+			}, nil
+		}
+	}
+
 	// Validate file type
 	if !isAllowedFileType(fileName) {
 		return &UploadResponse{
@@ -508,6 +755,52 @@ func processFileUpload(ctx context.Context, request events.APIGatewayProxyReques
 		s3Key = uploadPath + "/" + fileName
 	}
 
+	// Planogram spreadsheets in the render path are imported as layout JSON
+	// and continue through the same validation and render path
+	originalName := fileName
+	var importSummary *ImportSummary
+	if importer.Supported(fileName) {
+		jsonName := strings.TrimSuffix(fileName, filepath.Ext(fileName)) + ".json"
+		jsonKey := strings.TrimSuffix(s3Key, filepath.Ext(s3Key)) + ".json"
+		if shouldRenderFile(jsonKey, jsonName, bucketName) {
+			layoutJSON, result, err := importPlanogram(request.QueryStringParameters, fileName, fileBytes)
+			if err != nil {
+				log.WithError(err).WithField("fileName", fileName).Error("Failed to import planogram")
+				return &UploadResponse{
+					Success: false,
+					Message: "Failed to import planogram",
+					Errors:  []string{err.Error()},
+				}, nil
+			}
+			importSummary = &ImportSummary{
+				SourceFile: fileName,
+				Rows:       result.Rows,
+				Slots:      result.SlotCount(),
+				Errors:     result.Errors,
+			}
+			if !result.Valid() {
+				log.WithFields(logrus.Fields{
+					"fileName": fileName,
+					"errors":   result.ErrorMessages(),
+				}).Error("Planogram import reported row errors")
+				return &UploadResponse{
+					Success: false,
+					Message: "Planogram import failed",
+					Errors:  result.ErrorMessages(),
+					Import:  importSummary,
+				}, nil
+			}
+
+			log.WithFields(logrus.Fields{
+				"fileName": fileName,
+				"rows":     importSummary.Rows,
+				"slots":    importSummary.Slots,
+				"key":      jsonKey,
+			}).Info("Planogram imported as layout JSON")
+			fileName, s3Key, fileBytes = jsonName, jsonKey, layoutJSON
+		}
+	}
+
 	// Process JSON files to remove multipart boundaries and validate content
 	processedFileBytes, err := processJSONFile(fileName, fileBytes)
 	if err != nil {
@@ -522,6 +815,42 @@ func processFileUpload(ctx context.Context, request events.APIGatewayProxyReques
 	// Use processed file bytes for upload
 	fileBytes = processedFileBytes
 
+	// Layouts in the render path are validated before they are stored
+	render := shouldRenderFile(s3Key, fileName, bucketName)
+	var validation *renderer.ValidationResult
+	if render {
+		validation, err = validateLayoutFile(fileBytes, mode)
+		if err != nil {
+			log.WithError(err).Error("Failed to parse layout JSON")
+			return &UploadResponse{
+				Success: false,
+				Message: "Invalid layout JSON",
+				Errors:  []string{err.Error()},
+			}, nil
+		}
+		if !validation.Valid() {
+			log.WithFields(logrus.Fields{
+				"fileName": fileName,
+				"mode":     mode,
+				"errors":   validation.ErrorMessages(),
+			}).Error("Layout validation failed")
+			return &UploadResponse{
+				Success:    false,
+				Message:    "Layout validation failed",
+				Errors:     validation.ErrorMessages(),
+				Validation: validation,
+				Import:     importSummary,
+			}, nil
+		}
+		if len(validation.Warnings) > 0 {
+			log.WithFields(logrus.Fields{
+				"fileName": fileName,
+				"mode":     mode,
+				"warnings": validation.WarningMessages(),
+			}).Warn("Layout validation reported warnings")
+		}
+	}
+
 	// Upload to S3
 	contentLength := int64(len(fileBytes))
 	if contentLength > MaxFileSize {
@@ -540,7 +869,7 @@ func processFileUpload(ctx context.Context, request events.APIGatewayProxyReques
 	}
 
 	uploadedFile := UploadedFile{
-		OriginalName: fileName,
+		OriginalName: originalName,
 		Key:          s3Key,
 		Size:         contentLength,
 		ContentType:  contentTypeHeader,
@@ -551,11 +880,15 @@ func processFileUpload(ctx context.Context, request events.APIGatewayProxyReques
 		Success: true,
 		Message: "File uploaded successfully",
 		Files:   []UploadedFile{uploadedFile},
+		Import:  importSummary,
+	}
+	if validation != nil && len(validation.Warnings) > 0 {
+		response.Validation = validation
 	}
 
 	// Check if we should render this file (JSON in the configured render path)
-	if shouldRenderFile(s3Key, fileName, bucketName) {
-		renderResult := processJSONRender(ctx, bucketName, s3Key)
+	if render {
+		renderResult := processJSONRender(ctx, bucketName, s3Key, renderFormats, versionOpts, renderOpts)
 		response.RenderResult = renderResult
 		
 		if renderResult.Rendered {
@@ -568,6 +901,42 @@ func processFileUpload(ctx context.Context, request events.APIGatewayProxyReques
 	return response, nil
 }
 
+// importPlanogram converts a planogram spreadsheet to layout JSON. The
+// layoutId query parameter is required; columnMapping and sheet are optional.
+func importPlanogram(params map[string]string, fileName string, fileBytes []byte) ([]byte, *importer.Result, error) {
+	layoutID, err := strconv.ParseInt(params["layoutId"], 10, 64)
+	if err != nil || layoutID < 1 {
+		return nil, nil, fmt.Errorf("layoutId query parameter must be a positive integer for spreadsheet uploads")
+	}
+	mapping, err := importer.ParseMapping(importMapping, params["columnMapping"])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := importer.Import(fileName, fileBytes, importer.Options{
+		LayoutID: layoutID,
+		Mapping:  mapping,
+		Sheet:    params["sheet"],
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	layoutJSON, err := json.MarshalIndent(result.Layout, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode layout: %v", err)
+	}
+	return layoutJSON, result, nil
+}
+
+// validateLayoutFile parses an uploaded layout and validates it
+func validateLayoutFile(fileBytes []byte, mode renderer.ValidationMode) (*renderer.ValidationResult, error) {
+	var layout renderer.Layout
+	if err := json.Unmarshal(fileBytes, &layout); err != nil {
+		return nil, fmt.Errorf("failed to parse layout: %v", err)
+	}
+	return renderer.ValidateLayout(layout, mode), nil
+}
+
 // parseS3URI parses an S3 URI and returns bucket and path
 func parseS3URI(s3URI string) (bucket, path string, err error) {
 	// Handle simple path format (backward compatibility)
@@ -609,7 +978,7 @@ func shouldRenderFile(s3Key, fileName string, bucketName string) bool {
 }
 
 // processJSONRender handles the rendering of JSON layout files
-func processJSONRender(ctx context.Context, bucketName, s3Key string) *RenderResult {
+func processJSONRender(ctx context.Context, bucketName, s3Key string, formats []renderer.Format, versionOpts dynamodb.VersionOptions, renderOpts renderer.RenderOptions) *RenderResult {
 	log.WithFields(logrus.Fields{
 		"bucket": bucketName,
 		"key":    s3Key,
@@ -679,7 +1048,7 @@ func processJSONRender(ctx context.Context, bucketName, s3Key string) *RenderRes
 		}
 	}
 
-	if len(layout.SubLayoutList) == 0 || len(layout.SubLayoutList[0].TrayList) == 0 {
+	if layout.TrayCount() == 0 {
 		log.Error("Layout contains no trays")
 		renderLogger.Error(ctx, layout.LayoutID, "layout contains no trays")
 		return &RenderResult{
@@ -702,8 +1071,16 @@ func processJSONRender(ctx context.Context, bucketName, s3Key string) *RenderRes
 
 	renderLogger.Info(ctx, layout.LayoutID, fmt.Sprintf("generated layout prefix: %s", layoutPrefix))
 
+	// Product image cache counters are reported per render
+	cacheStatsBefore := renderer.ImageCacheStats()
+
+	// The reference image and its formats always show the planogram view
+	planogramOpts := renderOpts
+	planogramOpts.View = renderer.ViewPlanogram
+	planogramOpts.Stock = nil
+
 	// Render the layout to an image
-	imgBytes, err := renderer.RenderLayoutToBytes(*layout)
+	imgBytes, err := renderer.RenderLayoutWithOptions(*layout, renderer.FormatPNG, planogramOpts)
 	if err != nil {
 		log.WithError(err).Error("Failed to render layout")
 		renderLogger.Error(ctx, layout.LayoutID, fmt.Sprintf("failed to render layout: %v", err))
@@ -729,29 +1106,94 @@ func processJSONRender(ctx context.Context, bucketName, s3Key string) *RenderRes
 
 	renderLogger.Info(ctx, layout.LayoutID, fmt.Sprintf("successfully generated and uploaded image to %s", processedKey))
 
-	// Store metadata in DynamoDB (optional)
-	err = storeLayoutMetadata(ctx, layout, layoutPrefix, bucketName, processedKey, s3Key, renderLogger)
-	if err != nil {
-		log.WithError(err).Warn("Failed to store layout metadata, but render was successful")
-		// Don't fail the entire operation if metadata storage fails
+	// Render additional formats next to the PNG; failures do not fail the render
+	formatKeys := map[string]string{string(renderer.FormatPNG): processedKey}
+	for _, format := range formats {
+		if format == renderer.FormatPNG {
+			continue
+		}
+		formatKey := s3utils.GenerateFormatKey(processedKey, format)
+		data, err := renderer.RenderLayoutWithOptions(*layout, format, planogramOpts)
+		if err == nil {
+			err = s3utils.UploadFile(ctx, s3Client, bucketName, formatKey, data, format.ContentType())
+		}
+		if err != nil {
+			log.WithError(err).WithField("format", format).Warn("Failed to render additional format")
+			renderLogger.Error(ctx, layout.LayoutID, fmt.Sprintf("failed to render %s: %v", format, err))
+			continue
+		}
+		formatKeys[string(format)] = formatKey
+		renderLogger.Info(ctx, layout.LayoutID, fmt.Sprintf("successfully generated and uploaded %s to %s", format, formatKey))
+	}
+
+	// Render the capacity view in every format next to the reference image;
+	// failures do not fail the render
+	var capacityKeys map[string]string
+	if renderOpts.View == renderer.ViewCapacity {
+		capacityKeys = make(map[string]string)
+		for _, format := range formats {
+			viewKey := s3utils.GenerateViewKey(processedKey, renderer.ViewCapacity, format)
+			data, err := renderer.RenderLayoutWithOptions(*layout, format, renderOpts)
+			if err == nil {
+				err = s3utils.UploadFile(ctx, s3Client, bucketName, viewKey, data, format.ContentType())
+			}
+			if err != nil {
+				log.WithError(err).WithField("format", format).Warn("Failed to render capacity view")
+				renderLogger.Error(ctx, layout.LayoutID, fmt.Sprintf("failed to render capacity view as %s: %v", format, err))
+				continue
+			}
+			capacityKeys[string(format)] = viewKey
+			renderLogger.Info(ctx, layout.LayoutID, fmt.Sprintf("successfully generated and uploaded capacity view to %s", viewKey))
+		}
 	}
 
-	return &RenderResult{
+	cacheStats := renderer.ImageCacheStats().Sub(cacheStatsBefore)
+	log.WithFields(logrus.Fields{
+		"layoutId":   layout.LayoutID,
+		"imageCache": cacheStats,
+		"hitRatio":   cacheStats.HitRatio(),
+	}).Info("Product image cache stats")
+	renderLogger.InfoWithData(ctx, layout.LayoutID, "product image cache stats", cacheStats)
+
+	result := &RenderResult{
 		Rendered:     true,
 		LayoutID:     layout.LayoutID,
 		LayoutPrefix: layoutPrefix,
 		ProcessedKey: processedKey,
 		Message:      "Layout rendered successfully",
+		FormatKeys:   formatKeys,
+		CapacityKeys: capacityKeys,
+		Theme:        renderOpts.Theme.Name,
+		Language:     renderOpts.Language,
+	}
+	if result.Language == "" {
+		result.Language = renderOpts.Theme.Language
 	}
+
+	// Store metadata in DynamoDB (optional)
+	metadata, err := storeLayoutMetadata(ctx, layout, layoutPrefix, bucketName, processedKey, s3Key, versionOpts, renderLogger)
+	if err != nil {
+		log.WithError(err).Warn("Failed to store layout metadata, but render was successful")
+		// Don't fail the entire operation if metadata storage fails
+		result.Message = fmt.Sprintf("Layout rendered successfully, but metadata was not stored: %v", err)
+	} else if metadata != nil {
+		result.VendingMachineID = metadata.VendingMachineID
+		result.Version = metadata.Version
+		result.EffectiveFrom = metadata.EffectiveFrom
+	}
+
+	return result
 }
 
-// storeLayoutMetadata stores layout metadata in DynamoDB
-func storeLayoutMetadata(ctx context.Context, layout *renderer.Layout, layoutPrefix, s3Bucket, processedKey, sourceKey string, renderLogger *logger.Logger) error {
+// storeLayoutMetadata stores layout metadata in DynamoDB as a new version of
+// the vending machine's layout. It returns nil metadata when the table is not
+// configured or the layout was already stored.
+func storeLayoutMetadata(ctx context.Context, layout *renderer.Layout, layoutPrefix, s3Bucket, processedKey, sourceKey string, versionOpts dynamodb.VersionOptions, renderLogger *logger.Logger) (*dynamodb.LayoutMetadata, error) {
 	// Get DynamoDB table name from environment variable
 	tableName := os.Getenv("DYNAMODB_LAYOUT_TABLE")
 	if tableName == "" {
 		renderLogger.Info(ctx, layout.LayoutID, "DYNAMODB_LAYOUT_TABLE not set, skipping metadata storage")
-		return nil // Not an error if table is not configured
+		return nil, nil // Not an error if table is not configured
 	}
 
 	// Get AWS region
@@ -760,27 +1202,37 @@ func storeLayoutMetadata(ctx context.Context, layout *renderer.Layout, layoutPre
 		dynamoRegion = "us-east-1" // Default region
 	}
 
+	// GSI that orders each machine's layout versions
+	machineIndex := os.Getenv("DYNAMODB_LAYOUT_MACHINE_INDEX")
+	if machineIndex == "" {
+		machineIndex = dynamodb.DefaultMachineIndex
+	}
+
 	// Initialize DynamoDB manager
-	dbManager, err := dynamodb.NewManager(ctx, dynamoRegion, tableName)
+	dbManager, err := dynamodb.NewManager(ctx, dynamoRegion, tableName, machineIndex)
 	if err != nil {
 		renderLogger.Error(ctx, layout.LayoutID, fmt.Sprintf("failed to initialize DynamoDB manager: %v", err))
-		return err
+		return nil, err
 	}
 
 	// Store layout metadata in DynamoDB
-	err = dbManager.StoreLayoutMetadata(ctx, layout, layoutPrefix, s3Bucket, processedKey, sourceKey)
+	metadata, err := dbManager.StoreLayoutMetadata(ctx, layout, layoutPrefix, s3Bucket, processedKey, sourceKey, versionOpts)
 	if err != nil {
 		// If it's a conditional check failure, the item already exists, which is not a fatal error
 		if !strings.Contains(err.Error(), "already exists") {
 			renderLogger.Error(ctx, layout.LayoutID, fmt.Sprintf("failed to store layout metadata: %v", err))
-			return err
+			return nil, err
 		}
 		renderLogger.Info(ctx, layout.LayoutID, fmt.Sprintf("layout metadata already exists: %v", err))
-	} else {
-		renderLogger.Info(ctx, layout.LayoutID, "successfully stored layout metadata in DynamoDB")
+		return nil, nil
 	}
 
-	return nil
+	renderLogger.InfoWithData(ctx, layout.LayoutID, "successfully stored layout metadata in DynamoDB", map[string]interface{}{
+		"vendingMachineId": metadata.VendingMachineID,
+		"version":          metadata.Version,
+		"effectiveFrom":    metadata.EffectiveFrom,
+	})
+	return metadata, nil
 }
 
 func isAllowedFileType(filename string) bool {
@@ -799,6 +1251,7 @@ func isAllowedFileType(filename string) bool {
 		".json": true,
 		".csv":  true,
 		".xml":  true,
+		".xlsx": true,
 	}
 	return allowedExtensions[ext]
 }
@@ -819,6 +1272,7 @@ func detectContentType(filename string) string {
 		".json": "application/json",
 		".csv":  "text/csv",
 		".xml":  "application/xml",
+		".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
 	}
 
 	if contentType, exists := contentTypes[ext]; exists {
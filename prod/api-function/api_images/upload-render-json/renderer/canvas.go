@@ -0,0 +1,180 @@
+package renderer
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+
+	"api_images_upload_render/config"
+
+	"github.com/fogleman/gg"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+)
+
+// Format is an output format of the layout renderer
+type Format string
+
+const (
+	FormatPNG Format = "png"
+	FormatSVG Format = "svg"
+	FormatPDF Format = "pdf"
+)
+
+// ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatSVG:
+		return "image/svg+xml"
+	case FormatPDF:
+		return "application/pdf"
+	default:
+		return "image/png"
+	}
+}
+
+// Extension returns the file extension of the format, including the dot
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// ParseFormats parses a comma-separated list such as "svg,pdf". PNG is always
+// part of the result, first, because it is the reference image used by the
+// verification workflow.
+func ParseFormats(value string) ([]Format, error) {
+	formats := []Format{FormatPNG}
+	seen := map[Format]bool{FormatPNG: true}
+	for _, item := range strings.Split(value, ",") {
+		format := Format(strings.ToLower(strings.TrimSpace(item)))
+		if format == "" || seen[format] {
+			continue
+		}
+		switch format {
+		case FormatSVG, FormatPDF:
+			formats = append(formats, format)
+			seen[format] = true
+		default:
+			return nil, fmt.Errorf("unsupported render format: %s", format)
+		}
+	}
+	return formats, nil
+}
+
+// Canvas is a drawing backend. Coordinates are in layout units (the unscaled
+// values from config); each backend maps them to its own output space.
+type Canvas interface {
+	SetColor(r, g, b float64)
+	SetFont(path string, size float64) error
+	MeasureString(s string) (w, h float64)
+	// DrawText draws s anchored at (x, y) like gg.DrawStringAnchored
+	DrawText(s string, x, y, ax, ay float64)
+	Line(x1, y1, x2, y2, width float64)
+	FillRect(x, y, w, h float64)
+	StrokeRect(x, y, w, h, width float64)
+	DrawImage(img image.Image, x, y, w, h float64)
+	Encode() ([]byte, error)
+}
+
+// imageResolution is the resolution, in pixels per layout unit, of the
+// images embedded by the vector backends: 2 px per point prints product
+// images at 144 dpi
+const imageResolution = 2.0
+
+// fitImage downscales img to the size it is drawn at, w x h layout units at
+// imageResolution. Smaller images are returned unchanged; they are scaled by
+// the viewer.
+func fitImage(img image.Image, w, h float64) image.Image {
+	b := img.Bounds()
+	width := min(b.Dx(), int(math.Ceil(w*imageResolution)))
+	height := min(b.Dy(), int(math.Ceil(h*imageResolution)))
+	if width <= 0 || height <= 0 || (width == b.Dx() && height == b.Dy()) {
+		return img
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// NewCanvas creates a canvas of the given layout size for format. The theme
+// names the bold fonts and the SVG font family.
+func NewCanvas(format Format, cfg config.Config, theme *Theme, width, height float64) (Canvas, error) {
+	switch format {
+	case FormatPNG:
+		return newPNGCanvas(cfg, width, height), nil
+	case FormatSVG:
+		return newSVGCanvas(theme, width, height), nil
+	case FormatPDF:
+		return newPDFCanvas(theme, width, height), nil
+	default:
+		return nil, fmt.Errorf("unsupported render format: %s", format)
+	}
+}
+
+// fontFaces caches the font faces of a canvas by path and size; text with
+// fallback fonts switches faces for every run
+type fontFaces map[fontFaceKey]font.Face
+
+type fontFaceKey struct {
+	path string
+	size float64
+}
+
+func (f fontFaces) load(path string, size float64) (font.Face, error) {
+	key := fontFaceKey{path: path, size: size}
+	if face, ok := f[key]; ok {
+		return face, nil
+	}
+	face, err := gg.LoadFontFace(path, size)
+	if err != nil {
+		return nil, err
+	}
+	f[key] = face
+	return face, nil
+}
+
+// textMetrics measures text with the same font faces as the PNG backend, so
+// the vector backends wrap and anchor text exactly like the raster image.
+type textMetrics struct {
+	dc        *gg.Context
+	faces     fontFaces
+	boldFonts map[string]bool
+	fontPath  string
+	fontSize  float64
+}
+
+func newTextMetrics(theme *Theme) textMetrics {
+	boldFonts := make(map[string]bool)
+	if theme != nil {
+		for _, path := range theme.Fonts.Bold {
+			boldFonts[path] = true
+		}
+	}
+	return textMetrics{dc: gg.NewContext(1, 1), faces: make(fontFaces), boldFonts: boldFonts}
+}
+
+func (m *textMetrics) SetFont(path string, size float64) error {
+	face, err := m.faces.load(path, size)
+	if err != nil {
+		return err
+	}
+	m.dc.SetFontFace(face)
+	m.fontPath = path
+	m.fontSize = size
+	return nil
+}
+
+func (m *textMetrics) MeasureString(s string) (float64, float64) {
+	return m.dc.MeasureString(s)
+}
+
+// anchor returns the baseline origin of s anchored at (x, y)
+func (m *textMetrics) anchor(s string, x, y, ax, ay float64) (float64, float64) {
+	w, h := m.MeasureString(s)
+	return x - ax*w, y + ay*h
+}
+
+// isBold reports whether the current font is one of the theme's bold fonts
+func (m *textMetrics) isBold() bool {
+	return m.boldFonts[m.fontPath]
+}
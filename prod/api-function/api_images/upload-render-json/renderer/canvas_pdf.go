@@ -0,0 +1,343 @@
+package renderer
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"fmt"
+	"image"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/math/fixed"
+)
+
+// pdfCanvas writes a single-page PDF in layout units (1 unit = 1 pt). The
+// configured TrueType fonts are embedded so product names in any script
+// print correctly.
+type pdfCanvas struct {
+	textMetrics
+	width, height float64
+	content       bytes.Buffer
+	fonts         map[string]*pdfFont
+	fontOrder     []string
+	font          *pdfFont
+	images        []*pdfImage
+	// imagesByKey and imagesByHash map drawn images to their XObject number
+	// (1-based), so an image drawn in several slots is embedded once
+	imagesByKey  map[pdfImageKey]int
+	imagesByHash map[[sha256.Size]byte]int
+}
+
+// pdfImage is the RGB pixel data of an image XObject
+type pdfImage struct {
+	width, height int
+	pixels        []byte
+}
+
+// pdfImageKey identifies an image drawn at a given size; only images of
+// comparable types, e.g. *image.RGBA values, are keyed
+type pdfImageKey struct {
+	img  image.Image
+	w, h float64
+}
+
+// pdfFont is an embedded TrueType font addressed by glyph index (Identity-H).
+// runes maps the used glyphs back to text for the ToUnicode CMap, so the PDF
+// text can be searched and copied.
+type pdfFont struct {
+	name   string
+	data   []byte
+	ttf    *truetype.Font
+	widths map[truetype.Index]int
+	runes  map[truetype.Index]rune
+}
+
+func newPDFCanvas(theme *Theme, width, height float64) *pdfCanvas {
+	c := &pdfCanvas{
+		textMetrics:  newTextMetrics(theme),
+		width:        width,
+		height:       height,
+		fonts:        make(map[string]*pdfFont),
+		imagesByKey:  make(map[pdfImageKey]int),
+		imagesByHash: make(map[[sha256.Size]byte]int),
+	}
+	// Flip the y axis so layout coordinates can be used unchanged
+	fmt.Fprintf(&c.content, "1 0 0 -1 0 %.2f cm\n", height)
+	return c
+}
+
+func (c *pdfCanvas) SetFont(path string, size float64) error {
+	if err := c.textMetrics.SetFont(path, size); err != nil {
+		return err
+	}
+	font, ok := c.fonts[path]
+	if !ok {
+		ttf, err := parseFontFile(path)
+		if err != nil {
+			return err
+		}
+		data, err := readFontFile(path)
+		if err != nil {
+			return err
+		}
+		font = &pdfFont{
+			name:   fmt.Sprintf("F%d", len(c.fontOrder)+1),
+			data:   data,
+			ttf:    ttf,
+			widths: make(map[truetype.Index]int),
+			runes:  make(map[truetype.Index]rune),
+		}
+		c.fonts[path] = font
+		c.fontOrder = append(c.fontOrder, path)
+	}
+	c.font = font
+	return nil
+}
+
+func (c *pdfCanvas) SetColor(r, g, b float64) {
+	fmt.Fprintf(&c.content, "%.3f %.3f %.3f rg %.3f %.3f %.3f RG\n", r, g, b, r, g, b)
+}
+
+func (c *pdfCanvas) DrawText(s string, x, y, ax, ay float64) {
+	if c.font == nil || s == "" {
+		return
+	}
+	x, y = c.anchor(s, x, y, ax, ay)
+	var glyphs strings.Builder
+	for _, r := range s {
+		idx := c.font.ttf.Index(r)
+		if _, ok := c.font.widths[idx]; !ok {
+			c.font.widths[idx] = int(c.font.ttf.HMetric(fixed.Int26_6(1000), idx).AdvanceWidth)
+			c.font.runes[idx] = r
+		}
+		fmt.Fprintf(&glyphs, "%04X", uint16(idx))
+	}
+	// Text space is flipped back so glyphs are upright
+	fmt.Fprintf(&c.content, "BT /%s %.2f Tf 1 0 0 -1 %.2f %.2f Tm <%s> Tj ET\n",
+		c.font.name, c.fontSize, x, y, glyphs.String())
+}
+
+func (c *pdfCanvas) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&c.content, "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, y1, x2, y2)
+}
+
+func (c *pdfCanvas) FillRect(x, y, w, h float64) {
+	fmt.Fprintf(&c.content, "%.2f %.2f %.2f %.2f re f\n", x, y, w, h)
+}
+
+func (c *pdfCanvas) StrokeRect(x, y, w, h, width float64) {
+	fmt.Fprintf(&c.content, "%.2f w %.2f %.2f %.2f %.2f re S\n", width, x, y, w, h)
+}
+
+func (c *pdfCanvas) DrawImage(img image.Image, x, y, w, h float64) {
+	// Image space is the unit square; flip it back so the image is upright
+	fmt.Fprintf(&c.content, "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n", w, -h, x, y+h, c.addImage(img, w, h))
+}
+
+// addImage returns the XObject number of img drawn at w x h layout units.
+// The image is downscaled to the drawn size at imageResolution, and images
+// with the same pixels, such as a product stocked in several slots, share
+// one XObject.
+func (c *pdfCanvas) addImage(img image.Image, w, h float64) int {
+	var key pdfImageKey
+	comparable := img != nil && reflect.TypeOf(img).Comparable()
+	if comparable {
+		key = pdfImageKey{img: img, w: w, h: h}
+		if num, ok := c.imagesByKey[key]; ok {
+			return num
+		}
+	}
+
+	scaled := fitImage(img, w, h)
+	b := scaled.Bounds()
+	data := &pdfImage{width: b.Dx(), height: b.Dy(), pixels: rgbPixels(scaled)}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%dx%d\n", data.width, data.height)
+	hash.Write(data.pixels)
+	var sum [sha256.Size]byte
+	copy(sum[:], hash.Sum(nil))
+
+	num, ok := c.imagesByHash[sum]
+	if !ok {
+		c.images = append(c.images, data)
+		num = len(c.images)
+		c.imagesByHash[sum] = num
+	}
+	if comparable {
+		c.imagesByKey[key] = num
+	}
+	return num
+}
+
+func (c *pdfCanvas) Encode() ([]byte, error) {
+	w := &pdfWriter{}
+	w.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Object numbers: 1 catalog, 2 pages, 3 page, 4 content, then fonts and images
+	next := 5
+	fontRefs := make([]string, 0, len(c.fontOrder))
+	fontObjs := make([]int, len(c.fontOrder))
+	for i, path := range c.fontOrder {
+		fontObjs[i] = next
+		fontRefs = append(fontRefs, fmt.Sprintf("/%s %d 0 R", c.fonts[path].name, next))
+		next += 5 // Type0, CIDFont, descriptor, font file, ToUnicode CMap
+	}
+	imageRefs := make([]string, 0, len(c.images))
+	imageObjs := make([]int, len(c.images))
+	for i := range c.images {
+		imageObjs[i] = next
+		imageRefs = append(imageRefs, fmt.Sprintf("/Im%d %d 0 R", i+1, next))
+		next++
+	}
+
+	w.object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	w.object(2, "<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+	w.object(3, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Contents 4 0 R /Resources << /Font << %s >> /XObject << %s >> >> >>",
+		c.width, c.height, strings.Join(fontRefs, " "), strings.Join(imageRefs, " ")))
+	if err := w.stream(4, "", c.content.Bytes()); err != nil {
+		return nil, err
+	}
+
+	for i, path := range c.fontOrder {
+		if err := w.font(fontObjs[i], c.fonts[path], path); err != nil {
+			return nil, err
+		}
+	}
+	for i, img := range c.images {
+		if err := w.image(imageObjs[i], img); err != nil {
+			return nil, err
+		}
+	}
+
+	return w.finish(next - 1), nil
+}
+
+// pdfWriter serializes numbered objects and the cross-reference table
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets map[int]int
+}
+
+func (w *pdfWriter) object(num int, body string) {
+	if w.offsets == nil {
+		w.offsets = make(map[int]int)
+	}
+	w.offsets[num] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", num, body)
+}
+
+// stream writes a Flate-compressed stream object with extra dictionary entries
+func (w *pdfWriter) stream(num int, dict string, data []byte) error {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	w.object(num, fmt.Sprintf("<< %s /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream",
+		dict, compressed.Len(), compressed.String()))
+	return nil
+}
+
+func (w *pdfWriter) font(num int, font *pdfFont, path string) error {
+	baseFont := strings.Map(func(r rune) rune {
+		if r < '!' || r > '~' || strings.ContainsRune("()<>[]{}/%#", r) {
+			return -1
+		}
+		return r
+	}, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+
+	bounds := font.ttf.Bounds(fixed.Int26_6(1000))
+	glyphs := make([]int, 0, len(font.widths))
+	for idx := range font.widths {
+		glyphs = append(glyphs, int(idx))
+	}
+	sort.Ints(glyphs)
+	var widths strings.Builder
+	for _, g := range glyphs {
+		fmt.Fprintf(&widths, "%d [%d] ", g, font.widths[truetype.Index(g)])
+	}
+
+	w.object(num, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		baseFont, num+1, num+4))
+	w.object(num+1, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /CIDToGIDMap /Identity /W [%s] >>",
+		baseFont, num+2, strings.TrimSpace(widths.String())))
+	w.object(num+2, fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		baseFont, bounds.Min.X, bounds.Min.Y, bounds.Max.X, bounds.Max.Y, bounds.Max.Y, bounds.Min.Y, bounds.Max.Y, num+3))
+	if err := w.stream(num+3, fmt.Sprintf("/Length1 %d", len(font.data)), font.data); err != nil {
+		return err
+	}
+	return w.stream(num+4, "", toUnicodeCMap(glyphs, font.runes))
+}
+
+// toUnicodeCMap maps the glyph indexes used as CIDs by the Identity-H
+// encoding to the characters they were drawn for
+func toUnicodeCMap(glyphs []int, runes map[truetype.Index]rune) []byte {
+	// Glyph 0 (.notdef) stands for every character the font lacks
+	mapped := make([]int, 0, len(glyphs))
+	for _, g := range glyphs {
+		if g != 0 {
+			mapped = append(mapped, g)
+		}
+	}
+
+	var cmap bytes.Buffer
+	cmap.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	cmap.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	cmap.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	cmap.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	// bfchar sections hold at most 100 entries
+	for start := 0; start < len(mapped); start += 100 {
+		end := min(start+100, len(mapped))
+		fmt.Fprintf(&cmap, "%d beginbfchar\n", end-start)
+		for _, g := range mapped[start:end] {
+			fmt.Fprintf(&cmap, "<%04X> <", uint16(g))
+			for _, unit := range utf16.Encode([]rune{runes[truetype.Index(g)]}) {
+				fmt.Fprintf(&cmap, "%04X", unit)
+			}
+			cmap.WriteString(">\n")
+		}
+		cmap.WriteString("endbfchar\n")
+	}
+	cmap.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return cmap.Bytes()
+}
+
+// image writes img as a Flate-compressed RGB XObject
+func (w *pdfWriter) image(num int, img *pdfImage) error {
+	return w.stream(num, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8",
+		img.width, img.height), img.pixels)
+}
+
+// rgbPixels returns the RGB samples of img, compositing transparency onto
+// white
+func rgbPixels(img image.Image) []byte {
+	b := img.Bounds()
+	pixels := make([]byte, 0, b.Dx()*b.Dy()*3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := img.At(x, y).RGBA()
+			white := 0xffff - a
+			pixels = append(pixels, byte((r+white)>>8), byte((g+white)>>8), byte((bl+white)>>8))
+		}
+	}
+	return pixels
+}
+
+func (w *pdfWriter) finish(lastObj int) []byte {
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", lastObj+1)
+	for i := 1; i <= lastObj; i++ {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", w.offsets[i])
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", lastObj+1, xref)
+	return w.buf.Bytes()
+}
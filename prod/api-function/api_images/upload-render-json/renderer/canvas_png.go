@@ -0,0 +1,82 @@
+package renderer
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+
+	"api_images_upload_render/config"
+
+	"github.com/fogleman/gg"
+)
+
+// pngCanvas draws with gg at cfg.CanvasScale
+type pngCanvas struct {
+	dc    *gg.Context
+	faces fontFaces
+	scale float64
+}
+
+func newPNGCanvas(cfg config.Config, width, height float64) *pngCanvas {
+	dc := gg.NewContext(int(width*cfg.CanvasScale), int(height*cfg.CanvasScale))
+	dc.Scale(cfg.CanvasScale, cfg.CanvasScale)
+	return &pngCanvas{dc: dc, faces: make(fontFaces), scale: cfg.CanvasScale}
+}
+
+func (c *pngCanvas) SetColor(r, g, b float64) {
+	c.dc.SetRGB(r, g, b)
+}
+
+func (c *pngCanvas) SetFont(path string, size float64) error {
+	face, err := c.faces.load(path, size)
+	if err != nil {
+		return err
+	}
+	c.dc.SetFontFace(face)
+	return nil
+}
+
+func (c *pngCanvas) MeasureString(s string) (float64, float64) {
+	return c.dc.MeasureString(s)
+}
+
+func (c *pngCanvas) DrawText(s string, x, y, ax, ay float64) {
+	c.dc.DrawStringAnchored(s, x, y, ax, ay)
+}
+
+func (c *pngCanvas) Line(x1, y1, x2, y2, width float64) {
+	c.dc.SetLineWidth(width / c.scale)
+	c.dc.DrawLine(x1, y1, x2, y2)
+	c.dc.Stroke()
+}
+
+func (c *pngCanvas) FillRect(x, y, w, h float64) {
+	c.dc.DrawRectangle(x, y, w, h)
+	c.dc.Fill()
+}
+
+func (c *pngCanvas) StrokeRect(x, y, w, h, width float64) {
+	c.dc.SetLineWidth(width / c.scale)
+	c.dc.DrawRectangle(x, y, w, h)
+	c.dc.Stroke()
+}
+
+func (c *pngCanvas) DrawImage(img image.Image, x, y, w, h float64) {
+	c.dc.Push()
+	c.dc.Translate(x, y)
+	c.dc.Scale(w/float64(img.Bounds().Dx()), h/float64(img.Bounds().Dy()))
+	c.dc.DrawImage(img, 0, 0)
+	c.dc.Pop()
+}
+
+func (c *pngCanvas) Encode() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := &png.Encoder{
+		CompressionLevel: png.BestSpeed,
+	}
+	if err := encoder.Encode(&buf, c.dc.Image()); err != nil {
+		return nil, fmt.Errorf("failed to encode PNG: %v", err)
+	}
+	return buf.Bytes(), nil
+}
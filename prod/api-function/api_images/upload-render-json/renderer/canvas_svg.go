@@ -0,0 +1,85 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+)
+
+// svgCanvas writes an SVG document in layout units
+type svgCanvas struct {
+	textMetrics
+	width, height float64
+	family        string
+	color         string
+	body          strings.Builder
+}
+
+func newSVGCanvas(theme *Theme, width, height float64) *svgCanvas {
+	family := "Arial, Helvetica, sans-serif"
+	if theme != nil && theme.Fonts.Family != "" {
+		family = theme.Fonts.Family
+	}
+	return &svgCanvas{textMetrics: newTextMetrics(theme), width: width, height: height, family: family, color: "#000000"}
+}
+
+func (c *svgCanvas) SetColor(r, g, b float64) {
+	c.color = hexColor(r, g, b)
+}
+
+func (c *svgCanvas) DrawText(s string, x, y, ax, ay float64) {
+	x, y = c.anchor(s, x, y, ax, ay)
+	weight := "normal"
+	if c.isBold() {
+		weight = "bold"
+	}
+	fmt.Fprintf(&c.body, `<text x="%.2f" y="%.2f" font-family="%s" font-size="%.2f" font-weight="%s" fill="%s">%s</text>`+"\n",
+		x, y, xmlEscape(c.family), c.fontSize, weight, c.color, xmlEscape(s))
+}
+
+func (c *svgCanvas) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&c.body, `<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="%s" stroke-width="%.2f"/>`+"\n",
+		x1, y1, x2, y2, c.color, width)
+}
+
+func (c *svgCanvas) FillRect(x, y, w, h float64) {
+	fmt.Fprintf(&c.body, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="%s"/>`+"\n", x, y, w, h, c.color)
+}
+
+func (c *svgCanvas) StrokeRect(x, y, w, h, width float64) {
+	fmt.Fprintf(&c.body, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="none" stroke="%s" stroke-width="%.2f"/>`+"\n",
+		x, y, w, h, c.color, width)
+}
+
+// DrawImage embeds the image, downscaled to the drawn size, as a PNG data URI
+func (c *svgCanvas) DrawImage(img image.Image, x, y, w, h float64) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, fitImage(img, w, h)); err != nil {
+		return
+	}
+	fmt.Fprintf(&c.body, `<image x="%.2f" y="%.2f" width="%.2f" height="%.2f" preserveAspectRatio="none" href="data:image/png;base64,%s"/>`+"\n",
+		x, y, w, h, base64.StdEncoding.EncodeToString(buf.Bytes()))
+}
+
+func (c *svgCanvas) Encode() ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.2f %.2f">`+"\n",
+		c.width, c.height, c.width, c.height)
+	buf.WriteString(c.body.String())
+	buf.WriteString("</svg>\n")
+	return buf.Bytes(), nil
+}
+
+func hexColor(r, g, b float64) string {
+	return fmt.Sprintf("#%02x%02x%02x", int(r*255+0.5), int(g*255+0.5), int(b*255+0.5))
+}
+
+var xmlReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+
+func xmlEscape(s string) string {
+	return xmlReplacer.Replace(s)
+}
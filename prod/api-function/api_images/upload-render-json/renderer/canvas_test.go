@@ -0,0 +1,215 @@
+package renderer
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// testFont writes a TrueType font to a temporary file for the canvases
+func testFont(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "goregular.ttf")
+	if err := os.WriteFile(path, goregular.TTF, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func solidImage(width, height int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// drawSample draws text with XML and PDF special characters, shapes and
+// the same product image in two slots plus an identical copy of it
+func drawSample(t *testing.T, c Canvas) {
+	t.Helper()
+	if err := c.SetFont(testFont(t), 12); err != nil {
+		t.Fatalf("SetFont failed: %v", err)
+	}
+	product := solidImage(400, 400, color.RGBA{R: 200, A: 255})
+	c.SetColor(0, 0, 0)
+	c.DrawText(`Coke & "Pepsi" <330ml> (x2) \ Trà xanh`, 10, 20, 0, 0)
+	c.Line(0, 0, 100, 100, 1)
+	c.FillRect(10, 30, 50, 50)
+	c.StrokeRect(10, 30, 50, 50, 0.5)
+	c.DrawImage(product, 10, 100, 60, 60)
+	c.DrawImage(product, 80, 100, 60, 60)
+	c.DrawImage(solidImage(400, 400, color.RGBA{R: 200, A: 255}), 150, 100, 60, 60)
+}
+
+func TestPDFCanvasWellFormed(t *testing.T) {
+	c := newPDFCanvas(nil, 300, 200)
+	drawSample(t, c)
+	data, err := c.Encode()
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(data)
+	if m == nil {
+		t.Fatal("Expected the file to end with startxref and the EOF marker")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(data[xref:], []byte("xref\n0 ")) {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+	lines := strings.Split(string(data[xref:]), "\n")
+	size, _ := strconv.Atoi(strings.Fields(lines[1])[1])
+	if !strings.Contains(string(data[xref:]), fmt.Sprintf("/Size %d ", size)) {
+		t.Errorf("Expected the trailer /Size to match the %d xref entries", size)
+	}
+	for num := 1; num < size; num++ {
+		entry := lines[2+num]
+		if len(entry) != 19 || !strings.HasSuffix(entry, " 00000 n ") {
+			t.Fatalf("Malformed xref entry %d: %q", num, entry)
+		}
+		offset, _ := strconv.Atoi(entry[:10])
+		if want := fmt.Sprintf("%d 0 obj\n", num); !bytes.HasPrefix(data[offset:], []byte(want)) {
+			t.Errorf("xref offset %d of object %d points at %q", offset, num, data[offset:min(offset+12, len(data))])
+		}
+	}
+
+	// Every stream length matches its data and the data inflates; the first
+	// stream is the page content
+	var content, cmap []byte
+	streams := regexp.MustCompile(`/Length (\d+)[^\n]*>>\nstream\n`)
+	for i, loc := range streams.FindAllSubmatchIndex(data, -1) {
+		length, _ := strconv.Atoi(string(data[loc[2]:loc[3]]))
+		end := loc[1] + length
+		if !bytes.HasPrefix(data[end:], []byte("\nendstream")) {
+			t.Errorf("Stream at %d is not %d bytes long", loc[1], length)
+			continue
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(data[loc[1]:end]))
+		if err != nil {
+			t.Errorf("Stream at %d is not Flate-compressed: %v", loc[1], err)
+			continue
+		}
+		inflated, err := io.ReadAll(zr)
+		if err != nil {
+			t.Errorf("Stream at %d does not inflate: %v", loc[1], err)
+		}
+		if i == 0 {
+			content = inflated
+		}
+		if bytes.Contains(inflated, []byte("beginbfchar")) {
+			cmap = inflated
+		}
+	}
+
+	if n := bytes.Count(data, []byte("/Subtype /Image")); n != 1 {
+		t.Errorf("Expected the repeated product image once, got %d image XObjects", n)
+	}
+	if !bytes.Contains(data, []byte("/Width 120 /Height 120")) {
+		t.Error("Expected the image downscaled to 60pt at 2 px per point")
+	}
+	if n := bytes.Count(content, []byte("/Im1 Do")); n != 3 {
+		t.Errorf("Expected all three draws to use /Im1, got %d", n)
+	}
+
+	// The Identity-H glyph codes map back to the drawn characters
+	if !regexp.MustCompile(`/Encoding /Identity-H /DescendantFonts \[\d+ 0 R\] /ToUnicode \d+ 0 R`).Match(data) {
+		t.Error("Expected the font to reference a ToUnicode CMap")
+	}
+	ttf, err := truetype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range "Coke&Trà" {
+		want := fmt.Sprintf("<%04X> <%04X>", ttf.Index(r), r)
+		if !bytes.Contains(cmap, []byte(want)) {
+			t.Errorf("Expected the CMap to map %q as %s", r, want)
+		}
+	}
+	if _, chars, _ := bytes.Cut(cmap, []byte("beginbfchar")); bytes.Contains(chars, []byte("<0000> <")) {
+		t.Error("Expected .notdef to be left unmapped")
+	}
+}
+
+func TestParseFontFileCachesPerPath(t *testing.T) {
+	path := testFont(t)
+	first, err := parseFontFile(path)
+	if err != nil {
+		t.Fatalf("parseFontFile failed: %v", err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	second, err := parseFontFile(path)
+	if err != nil || second != first {
+		t.Errorf("Expected the cached font, got %p (%v)", second, err)
+	}
+
+	if data, err := readFontFile(path); err != nil || !bytes.Equal(data, goregular.TTF) {
+		t.Errorf("Expected the cached font file, got %d bytes (%v)", len(data), err)
+	}
+}
+
+func TestSVGCanvasWellFormed(t *testing.T) {
+	c := newSVGCanvas(&Theme{Fonts: ThemeFonts{Family: `"Noto Sans" & Arial`}}, 300, 200)
+	drawSample(t, c)
+	data, err := c.Encode()
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var root string
+	var texts []string
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("SVG is not well-formed XML: %v", err)
+		}
+		switch tok := token.(type) {
+		case xml.StartElement:
+			if root == "" {
+				root = tok.Name.Local
+			}
+		case xml.CharData:
+			if s := strings.TrimSpace(string(tok)); s != "" {
+				texts = append(texts, s)
+			}
+		}
+	}
+	if root != "svg" {
+		t.Errorf("Expected an svg root element, got %q", root)
+	}
+	if len(texts) != 1 || texts[0] != `Coke & "Pepsi" <330ml> (x2) \ Trà xanh` {
+		t.Errorf("Expected the text to round-trip, got %q", texts)
+	}
+}
+
+func TestFitImage(t *testing.T) {
+	large := solidImage(400, 300, color.White)
+	if b := fitImage(large, 50, 100).Bounds(); b.Dx() != 100 || b.Dy() != 200 {
+		t.Errorf("Expected 100x200, got %v", b)
+	}
+	small := solidImage(40, 40, color.White)
+	if fitImage(small, 60, 60) != image.Image(small) {
+		t.Error("Expected images smaller than the drawn size to be kept")
+	}
+}
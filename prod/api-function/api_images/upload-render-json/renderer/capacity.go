@@ -0,0 +1,167 @@
+package renderer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"api_images_upload_render/config"
+)
+
+// View is what a render shows in each cell
+type View string
+
+const (
+	// ViewPlanogram shows product images and names; it is the reference image
+	// used by the verification workflow
+	ViewPlanogram View = "planogram"
+	// ViewCapacity also shows each slot's maximum quantity, greys out
+	// inactive slots and, with a stock overlay, draws fill bars for restocking
+	ViewCapacity View = "capacity"
+)
+
+// SlotStatusActive is the status of a slot that is selling
+const SlotStatusActive = 1
+
+// Active reports whether the slot is selling
+func (s Slot) Active() bool {
+	return s.Status == SlotStatusActive
+}
+
+// ParseView parses "planogram" or "capacity"; an empty value is planogram
+func ParseView(value string) (View, error) {
+	switch view := View(strings.ToLower(strings.TrimSpace(value))); view {
+	case "":
+		return ViewPlanogram, nil
+	case ViewPlanogram, ViewCapacity:
+		return view, nil
+	default:
+		return "", fmt.Errorf("unsupported render view: %s", value)
+	}
+}
+
+// StockLevel is the current stock of a position: a counted quantity, or a
+// fill ratio from 0 to 1 when only the fill level was detected
+type StockLevel struct {
+	Quantity *int     `json:"quantity,omitempty"`
+	Fill     *float64 `json:"fill,omitempty"`
+}
+
+// UnmarshalJSON accepts a bare number as a quantity
+func (l *StockLevel) UnmarshalJSON(data []byte) error {
+	var quantity int
+	if err := json.Unmarshal(data, &quantity); err == nil {
+		l.Quantity = &quantity
+		return nil
+	}
+	type stockLevel StockLevel
+	var level stockLevel
+	if err := json.Unmarshal(data, &level); err != nil {
+		return fmt.Errorf("expected a quantity or an object with quantity or fill")
+	}
+	*l = StockLevel(level)
+	return nil
+}
+
+// ParseStockOverlay parses a JSON object of stock levels by position key,
+// e.g. {"A1": 3, "B2": {"fill": 0.5}}. Positions use the keys of the rendered
+// image, such as "D2-A1" for qualified multi-door layouts.
+func ParseStockOverlay(data []byte) (map[string]StockLevel, error) {
+	var stock map[string]StockLevel
+	if err := json.Unmarshal(data, &stock); err != nil {
+		return nil, fmt.Errorf("invalid stock overlay: %v", err)
+	}
+	for position, level := range stock {
+		switch {
+		case level.Quantity == nil && level.Fill == nil:
+			return nil, fmt.Errorf("invalid stock overlay: %s: quantity or fill is required", position)
+		case level.Quantity != nil && *level.Quantity < 0:
+			return nil, fmt.Errorf("invalid stock overlay: %s: quantity must not be negative", position)
+		case level.Fill != nil && (*level.Fill < 0 || *level.Fill > 1):
+			return nil, fmt.Errorf("invalid stock overlay: %s: fill must be between 0 and 1", position)
+		}
+	}
+	return stock, nil
+}
+
+// ratio returns the fill ratio of the level for a slot of maxQuantity, from 0
+// to 1, and the quantity shown, which is estimated from a fill level
+func (l StockLevel) ratio(maxQuantity int) (float64, int, bool) {
+	if l.Quantity != nil {
+		if maxQuantity <= 0 {
+			return math.Min(float64(*l.Quantity), 1), *l.Quantity, true
+		}
+		return math.Min(float64(*l.Quantity)/float64(maxQuantity), 1), *l.Quantity, true
+	}
+	return *l.Fill, int(math.Round(*l.Fill * float64(maxQuantity))), false
+}
+
+// drawCapacity annotates a cell of the capacity view below the cell: the
+// maximum quantity, or a fill bar with the stock, the maximum quantity and
+// the quantity to refill when the position has a stock level
+func drawCapacity(dc Canvas, cfg config.Config, style *renderStyle, slot *Slot, cellX, rowY float64, level *StockLevel) error {
+	pen, err := style.pen(dc, false, 10.0)
+	if err != nil {
+		return fmt.Errorf("failed to load capacity font: %v", err)
+	}
+	footerY := rowY + cfg.CellHeight
+
+	if level == nil {
+		setColor(dc, style.text)
+		pen.DrawText(fmt.Sprintf(style.labels.MaxQuantity, slot.MaxQuantity), cellX+cfg.CellWidth/2, footerY+cfg.FooterHeight/2, 0.5, 0.5)
+		return nil
+	}
+
+	ratio, quantity, counted := level.ratio(slot.MaxQuantity)
+	barY := footerY + 4
+	barHeight := 8.0
+	setColor(dc, style.placeholder)
+	dc.FillRect(cellX, barY, cfg.CellWidth, barHeight)
+	switch {
+	case ratio >= 0.5:
+		setColor(dc, style.stockHigh)
+	case ratio >= 0.2:
+		setColor(dc, style.stockMedium)
+	default:
+		setColor(dc, style.stockLow)
+	}
+	if ratio > 0 {
+		dc.FillRect(cellX, barY, cfg.CellWidth*ratio, barHeight)
+	}
+	setColor(dc, style.cellBorder)
+	dc.StrokeRect(cellX, barY, cfg.CellWidth, barHeight, 0.5)
+
+	text := fmt.Sprintf("%d/%d", quantity, slot.MaxQuantity)
+	if !counted {
+		text = "~" + text
+	}
+	if refill := slot.MaxQuantity - quantity; refill > 0 {
+		text += fmt.Sprintf(" (+%d)", refill)
+	}
+	setColor(dc, style.text)
+	pen.DrawText(text, cellX+cfg.CellWidth/2, barY+barHeight+(cfg.FooterHeight-barHeight-4)/2, 0.5, 0.5)
+	return nil
+}
+
+// drawInactiveSlot draws an inactive slot of the capacity view: the position,
+// the inactive label and the product name without the product image
+func drawInactiveSlot(dc Canvas, cfg config.Config, style *renderStyle, slot *Slot, positionCode string, cellX, rowY float64) error {
+	pen, err := style.pen(dc, true, cfg.PositionFontSize)
+	if err != nil {
+		return fmt.Errorf("failed to load position font: %v", err)
+	}
+	setColor(dc, style.muted)
+	pen.DrawText(positionCode, cellX+8, rowY+16, 0, 0)
+	pen.DrawText(style.labels.Inactive, cellX+cfg.CellWidth/2, rowY+cfg.CellHeight/2-10, 0.5, 0.5)
+
+	if pen, err = style.pen(dc, false, 12.0); err != nil {
+		return fmt.Errorf("failed to load product font: %v", err)
+	}
+	if name := strings.TrimSpace(slot.ProductTemplateName); name != "" {
+		for i, line := range splitTextToLines(pen, name, cfg.CellWidth-20) {
+			pen.DrawText(line, cellX+cfg.CellWidth/2, rowY+cfg.CellHeight/2+15+float64(i)*18, 0.5, 0.5)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package renderer
+
+import (
+	"testing"
+)
+
+func TestParseView(t *testing.T) {
+	cases := map[string]View{"": ViewPlanogram, "planogram": ViewPlanogram, " Capacity ": ViewCapacity}
+	for value, want := range cases {
+		if got, err := ParseView(value); err != nil || got != want {
+			t.Errorf("%q: got %q, %v", value, got, err)
+		}
+	}
+	if _, err := ParseView("stock"); err == nil {
+		t.Error("Expected an error for an unknown view")
+	}
+}
+
+func TestParseStockOverlay(t *testing.T) {
+	stock, err := ParseStockOverlay([]byte(`{"A1": 3, "B2": {"fill": 0.5}, "D2-A1": {"quantity": 0}}`))
+	if err != nil {
+		t.Fatalf("ParseStockOverlay failed: %v", err)
+	}
+	if q := stock["A1"].Quantity; q == nil || *q != 3 {
+		t.Errorf("Unexpected A1 level %+v", stock["A1"])
+	}
+	if f := stock["B2"].Fill; f == nil || *f != 0.5 || stock["B2"].Quantity != nil {
+		t.Errorf("Unexpected B2 level %+v", stock["B2"])
+	}
+	if q := stock["D2-A1"].Quantity; q == nil || *q != 0 {
+		t.Errorf("Unexpected D2-A1 level %+v", stock["D2-A1"])
+	}
+
+	invalid := map[string]string{
+		"not an object":     `[1, 2]`,
+		"negative quantity": `{"A1": -1}`,
+		"fill above one":    `{"A1": {"fill": 1.5}}`,
+		"empty level":       `{"A1": {}}`,
+		"text quantity":     `{"A1": "three"}`,
+	}
+	for name, data := range invalid {
+		if _, err := ParseStockOverlay([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestStockLevelRatio(t *testing.T) {
+	quantity, fill := 4, 0.25
+	cases := []struct {
+		level       StockLevel
+		maxQuantity int
+		ratio       float64
+		quantity    int
+		counted     bool
+	}{
+		{StockLevel{Quantity: &quantity}, 8, 0.5, 4, true},
+		{StockLevel{Quantity: &quantity}, 2, 1, 4, true},
+		{StockLevel{Fill: &fill}, 10, 0.25, 3, false},
+	}
+	for _, tc := range cases {
+		ratio, q, counted := tc.level.ratio(tc.maxQuantity)
+		if ratio != tc.ratio || q != tc.quantity || counted != tc.counted {
+			t.Errorf("%+v of %d: got %v, %d, %v", tc.level, tc.maxQuantity, ratio, q, counted)
+		}
+	}
+}
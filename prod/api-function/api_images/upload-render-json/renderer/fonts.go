@@ -0,0 +1,126 @@
+package renderer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"unicode"
+
+	"github.com/golang/freetype/truetype"
+)
+
+// Font files and parsed fonts by path, shared by all renders
+var (
+	fontsMu     sync.Mutex
+	fontFiles   = map[string][]byte{}
+	parsedFonts = map[string]*truetype.Font{}
+)
+
+// readFontFile returns the contents of the font file at path
+func readFontFile(path string) ([]byte, error) {
+	fontsMu.Lock()
+	defer fontsMu.Unlock()
+	return readFontFileLocked(path)
+}
+
+func readFontFileLocked(path string) ([]byte, error) {
+	if data, ok := fontFiles[path]; ok {
+		return data, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fontFiles[path] = data
+	return data, nil
+}
+
+func parseFontFile(path string) (*truetype.Font, error) {
+	fontsMu.Lock()
+	defer fontsMu.Unlock()
+	if font, ok := parsedFonts[path]; ok {
+		return font, nil
+	}
+	data, err := readFontFileLocked(path)
+	if err != nil {
+		return nil, err
+	}
+	font, err := truetype.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse font %s: %v", path, err)
+	}
+	parsedFonts[path] = font
+	return font, nil
+}
+
+// fontChain is a primary font and its fallbacks
+type fontChain struct {
+	paths []string
+	fonts []*truetype.Font
+}
+
+// newFontChain loads the fonts of a chain. The primary font is required;
+// fallbacks that cannot be loaded are logged and skipped so a missing
+// optional font does not fail the render.
+func newFontChain(paths []string) (*fontChain, error) {
+	chain := &fontChain{}
+	for i, path := range paths {
+		font, err := parseFontFile(path)
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			log.Printf("Skipping fallback font %s: %v", path, err)
+			continue
+		}
+		chain.paths = append(chain.paths, path)
+		chain.fonts = append(chain.fonts, font)
+	}
+	return chain, nil
+}
+
+// textRun is a part of a string drawn with one font
+type textRun struct {
+	path string
+	text string
+}
+
+// runs splits s into runs of the first font of the chain that has a glyph
+// for each character. Spaces and characters no font has stay in the current
+// run, so a string in a single script is a single run.
+func (c *fontChain) runs(s string) []textRun {
+	var runs []textRun
+	current := -1
+	start := 0
+	for i, r := range s {
+		font := current
+		if !unicode.IsSpace(r) {
+			font = c.fontFor(r, current)
+		}
+		if font == -1 {
+			font = 0
+		}
+		if font != current {
+			if current >= 0 && i > start {
+				runs = append(runs, textRun{path: c.paths[current], text: s[start:i]})
+			}
+			current, start = font, i
+		}
+	}
+	if current >= 0 && start < len(s) {
+		runs = append(runs, textRun{path: c.paths[current], text: s[start:]})
+	}
+	return runs
+}
+
+// fontFor returns the first font with a glyph for r, or fallback when no
+// font has one
+func (c *fontChain) fontFor(r rune, fallback int) int {
+	for i, font := range c.fonts {
+		if font.Index(r) != 0 {
+			return i
+		}
+	}
+	return fallback
+}
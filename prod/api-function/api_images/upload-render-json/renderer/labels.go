@@ -0,0 +1,65 @@
+package renderer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Label languages
+const (
+	LanguageEnglish    = "en"
+	LanguageVietnamese = "vi"
+)
+
+// Labels are the texts drawn on a rendered layout in one language
+type Labels struct {
+	// Title is the default title template, see Theme.Title
+	Title string
+	// SubLayout is a fmt format receiving the 1-based sub-layout number
+	SubLayout string
+	// Product is drawn for slots without a product name
+	Product          string
+	ImageUnavailable string
+	// GeneratedAt is a fmt format receiving the formatted render time
+	GeneratedAt string
+	DateFormat  string
+	// MaxQuantity is a fmt format receiving a slot's maximum quantity
+	MaxQuantity string
+	Inactive    string
+}
+
+var labels = map[string]Labels{
+	LanguageEnglish: {
+		Title:            "Kootoro Vending Machine Layout (ID: {layoutId})",
+		SubLayout:        "Door %d",
+		Product:          "Product",
+		ImageUnavailable: "Image Unavailable",
+		GeneratedAt:      "Generated at: %s",
+		DateFormat:       "Jan 02, 2006 15:04:05",
+		MaxQuantity:      "Max %d",
+		Inactive:         "Inactive",
+	},
+	LanguageVietnamese: {
+		Title:            "Sơ đồ máy bán hàng Kootoro (ID: {layoutId})",
+		SubLayout:        "Cửa %d",
+		Product:          "Sản phẩm",
+		ImageUnavailable: "Không có hình ảnh",
+		GeneratedAt:      "Tạo lúc: %s",
+		DateFormat:       "02/01/2006 15:04:05",
+		MaxQuantity:      "Tối đa %d",
+		Inactive:         "Ngừng bán",
+	},
+}
+
+// LabelsFor returns the labels of a language; "" is English
+func LabelsFor(language string) (Labels, error) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language == "" {
+		language = LanguageEnglish
+	}
+	l, ok := labels[language]
+	if !ok {
+		return Labels{}, fmt.Errorf("unsupported label language: %s", language)
+	}
+	return l, nil
+}
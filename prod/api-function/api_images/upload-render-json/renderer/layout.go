@@ -0,0 +1,61 @@
+package renderer
+
+import (
+	"fmt"
+
+	"api_images_upload_render/config"
+)
+
+// TrayCount returns the number of trays across all sub-layouts.
+func (l Layout) TrayCount() int {
+	count := 0
+	for _, sub := range l.SubLayoutList {
+		count += len(sub.TrayList)
+	}
+	return count
+}
+
+// SubLayoutLabel returns the display label of the sub-layout at index (0-based).
+func SubLayoutLabel(index int) string {
+	return fmt.Sprintf(config.GetConfig().SubLayoutLabelFormat, index+1)
+}
+
+// PositionKeys builds row and position keys that stay unique across
+// sub-layouts. Keys are only qualified with the sub-layout number when a tray
+// code appears in more than one sub-layout, so single-door layouts and combo
+// machines with distinct tray codes keep plain keys such as "A1".
+type PositionKeys struct {
+	qualified bool
+}
+
+// NewPositionKeys inspects the layout's tray codes.
+func NewPositionKeys(layout Layout) PositionKeys {
+	owner := make(map[string]int)
+	for subIdx, sub := range layout.SubLayoutList {
+		for _, tray := range sub.TrayList {
+			if first, seen := owner[tray.TrayCode]; seen && first != subIdx {
+				return PositionKeys{qualified: true}
+			}
+			owner[tray.TrayCode] = subIdx
+		}
+	}
+	return PositionKeys{}
+}
+
+// Qualified reports whether keys carry a sub-layout prefix.
+func (k PositionKeys) Qualified() bool {
+	return k.qualified
+}
+
+// Row returns the row key, e.g. "A" or "D2-A" when qualified.
+func (k PositionKeys) Row(subIndex int, trayCode string) string {
+	if !k.qualified {
+		return trayCode
+	}
+	return fmt.Sprintf("D%d-%s", subIndex+1, trayCode)
+}
+
+// Position returns the position key, e.g. "A1" or "D2-A1" when qualified.
+func (k PositionKeys) Position(subIndex int, trayCode string, slotNo int) string {
+	return fmt.Sprintf("%s%d", k.Row(subIndex, trayCode), slotNo)
+}
@@ -1,26 +1,31 @@
 package renderer
 
 import (
-	"bytes"
 	"context"
-	"crypto/md5"
 	"fmt"
-	"image"
-	"image/png"
-	"io"
 	"log"
-	"net/http"
-	"os"
-	"path/filepath"
 	"sort"
 	"strings"
 	"time"
 
 	"api_images_upload_render/config"
-
-	"github.com/fogleman/gg"
+	"api_images_upload_render/imagecache"
 )
 
+// imageCache serves product images. It is memory-only until main installs
+// the S3-backed cache with SetImageCache.
+var imageCache = imagecache.New(nil, imagecache.DefaultOptions())
+
+// SetImageCache replaces the product image cache
+func SetImageCache(cache *imagecache.Cache) {
+	imageCache = cache
+}
+
+// ImageCacheStats returns the product image cache counters
+func ImageCacheStats() imagecache.Stats {
+	return imageCache.Stats()
+}
+
 type Layout struct {
 	LayoutID        int64       `json:"layoutId"`
 	LayoutType      *int        `json:"layoutType,omitempty"`      // Optional field from real data
@@ -53,95 +58,340 @@ type Slot struct {
 	ProductTemplateImage string `json:"productTemplateImage"`
 }
 
-func RenderLayoutToBytes(layout Layout) ([]byte, error) {
-	cfg := config.GetConfig()
+// subLayoutGrid is the measured grid of one sub-layout and its position on
+// the canvas, relative to the content origin below the title.
+type subLayoutGrid struct {
+	index   int
+	trays   []Tray
+	columns int
+	width   float64
+	height  float64
+	offsetX float64
+	offsetY float64
+}
 
-	// Check if there are any trays to render
-	if len(layout.SubLayoutList) == 0 || len(layout.SubLayoutList[0].TrayList) == 0 {
-		return nil, fmt.Errorf("no trays found in layout")
+// measureSubLayouts computes the grid of every non-empty sub-layout and
+// arranges them side by side or stacked. It returns the grids and the total
+// content size.
+func measureSubLayouts(cfg config.Config, layout Layout) ([]subLayoutGrid, float64, float64) {
+	var grids []subLayoutGrid
+	for idx, sub := range layout.SubLayoutList {
+		if len(sub.TrayList) == 0 {
+			continue
+		}
+		grids = append(grids, subLayoutGrid{index: idx, trays: sub.TrayList})
 	}
 
-	trays := layout.SubLayoutList[0].TrayList
-	numRows := len(trays)
+	// Labels are only drawn when more than one sub-layout is rendered
+	labelHeight := 0.0
+	if len(grids) > 1 {
+		labelHeight = cfg.SubLayoutLabelHeight
+	}
+
+	for i := range grids {
+		g := &grids[i]
+
+		// Calculate the actual number of columns needed based on the JSON data
+		for _, tray := range g.trays {
+			for _, slot := range tray.SlotList {
+				if slot.SlotNo > g.columns {
+					g.columns = slot.SlotNo
+				}
+			}
+		}
+
+		// Ensure we have at least 1 column and don't exceed the maximum
+		if g.columns == 0 {
+			g.columns = 1
+		}
+		if g.columns > cfg.NumColumns {
+			g.columns = cfg.NumColumns
+		}
+
+		numRows := len(g.trays)
+		g.width = float64(g.columns)*cfg.CellWidth + float64(g.columns-1)*cfg.CellSpacing
+		g.height = labelHeight + cfg.HeaderHeight +
+			float64(numRows)*(cfg.CellHeight+cfg.FooterHeight) +
+			float64(numRows-1)*cfg.RowSpacing
+	}
 
-	// Calculate the actual number of columns needed based on the JSON data
-	actualColumns := 0
-	for _, tray := range trays {
-		for _, slot := range tray.SlotList {
-			if slot.SlotNo > actualColumns {
-				actualColumns = slot.SlotNo
+	var contentWidth, contentHeight float64
+	for i := range grids {
+		g := &grids[i]
+		if cfg.SubLayoutArrangement == config.ArrangementVertical {
+			if i > 0 {
+				contentHeight += cfg.SubLayoutSpacing
+			}
+			g.offsetY = contentHeight
+			contentHeight += g.height
+			if g.width > contentWidth {
+				contentWidth = g.width
+			}
+		} else {
+			if i > 0 {
+				contentWidth += cfg.SubLayoutSpacing
+			}
+			g.offsetX = contentWidth
+			contentWidth += g.width
+			if g.height > contentHeight {
+				contentHeight = g.height
 			}
 		}
 	}
-	
-	// Ensure we have at least 1 column and don't exceed the maximum
-	if actualColumns == 0 {
-		actualColumns = 1
+	return grids, contentWidth, contentHeight
+}
+
+// RenderLayoutToBytes renders every sub-layout of the layout to a PNG image.
+// Multi-door machines are arranged according to cfg.SubLayoutArrangement and
+// each door is labelled.
+func RenderLayoutToBytes(layout Layout) ([]byte, error) {
+	return RenderLayout(layout, FormatPNG)
+}
+
+// RenderOptions selects the theme, label language and view of a render
+type RenderOptions struct {
+	// Theme defaults to the default theme
+	Theme *Theme
+	// Language overrides Theme.Language when set
+	Language string
+	// View defaults to ViewPlanogram
+	View View
+	// Stock is the current stock by position key, drawn as fill bars in the
+	// capacity view
+	Stock map[string]StockLevel
+}
+
+// RenderLayout renders the layout in the given format with the default theme
+func RenderLayout(layout Layout, format Format) ([]byte, error) {
+	return RenderLayoutWithOptions(layout, format, RenderOptions{})
+}
+
+// renderStyle is a theme resolved for drawing
+type renderStyle struct {
+	theme         *Theme
+	labels        Labels
+	regular, bold *fontChain
+	view          View
+	stock         map[string]StockLevel
+
+	background, title, text, muted, position rgb
+	cellBackground, cellBorder, separator    rgb
+	placeholder                              rgb
+	inactive, stockHigh, stockMedium         rgb
+	stockLow                                 rgb
+}
+
+func newRenderStyle(opts RenderOptions) (*renderStyle, error) {
+	theme := opts.Theme
+	if theme == nil {
+		var err error
+		if theme, err = LookupTheme(""); err != nil {
+			return nil, err
+		}
+	}
+	language := theme.Language
+	if opts.Language != "" {
+		language = opts.Language
+	}
+	labels, err := LabelsFor(language)
+	if err != nil {
+		return nil, err
+	}
+
+	regular, err := newFontChain(theme.Fonts.Regular)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load font: %v", err)
+	}
+	bold, err := newFontChain(theme.Fonts.Bold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bold font: %v", err)
+	}
+
+	view := opts.View
+	if view == "" {
+		view = ViewPlanogram
+	}
+
+	return &renderStyle{
+		theme:          theme,
+		labels:         labels,
+		regular:        regular,
+		bold:           bold,
+		view:           view,
+		stock:          opts.Stock,
+		background:     mustColor(theme.Colors.Background),
+		title:          mustColor(theme.Colors.Title),
+		text:           mustColor(theme.Colors.Text),
+		muted:          mustColor(theme.Colors.Muted),
+		position:       mustColor(theme.Colors.Position),
+		cellBackground: mustColor(theme.Colors.CellBackground),
+		cellBorder:     mustColor(theme.Colors.CellBorder),
+		separator:      mustColor(theme.Colors.Separator),
+		placeholder:    mustColor(theme.Colors.Placeholder),
+		inactive:       mustColor(theme.Colors.Inactive),
+		stockHigh:      mustColor(theme.Colors.StockHigh),
+		stockMedium:    mustColor(theme.Colors.StockMedium),
+		stockLow:       mustColor(theme.Colors.StockLow),
+	}, nil
+}
+
+// pen returns a pen of the regular or bold font chain with its primary font
+// selected
+func (s *renderStyle) pen(dc Canvas, bold bool, size float64) (textPen, error) {
+	chain := s.regular
+	if bold {
+		chain = s.bold
+	}
+	pen := textPen{dc: dc, chain: chain, size: size}
+	return pen, pen.use()
+}
+
+// RenderLayoutWithOptions renders the layout in the given format. All formats
+// share the same layout math; only the drawing backend differs.
+func RenderLayoutWithOptions(layout Layout, format Format, opts RenderOptions) ([]byte, error) {
+	cfg := config.GetConfig()
+
+	// Check if there are any trays to render
+	if layout.TrayCount() == 0 {
+		return nil, fmt.Errorf("no trays found in layout")
 	}
-	if actualColumns > cfg.NumColumns {
-		actualColumns = cfg.NumColumns
+
+	style, err := newRenderStyle(opts)
+	if err != nil {
+		return nil, err
 	}
 
-	canvasWidth := cfg.Padding*2 + float64(actualColumns)*cfg.CellWidth + float64(actualColumns-1)*cfg.CellSpacing
-	canvasHeight := cfg.Padding*2 + cfg.TitlePadding + cfg.HeaderHeight +
-		float64(numRows)*(cfg.CellHeight+cfg.FooterHeight) +
-		float64(numRows-1)*cfg.RowSpacing +
+	grids, contentWidth, contentHeight := measureSubLayouts(cfg, layout)
+	keys := NewPositionKeys(layout)
+
+	canvasWidth := cfg.Padding*2 + contentWidth
+	canvasHeight := cfg.Padding*2 + cfg.TitlePadding + contentHeight +
 		cfg.FooterHeight + cfg.MetadataHeight
 
-	dc := gg.NewContext(int(canvasWidth*cfg.CanvasScale), int(canvasHeight*cfg.CanvasScale))
-	dc.Scale(cfg.CanvasScale, cfg.CanvasScale)
+	dc, err := NewCanvas(format, cfg, style.theme, canvasWidth, canvasHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set background
+	setColor(dc, style.background)
+	dc.FillRect(0, 0, canvasWidth, canvasHeight)
 
-	// Set white background
-	dc.SetRGB(1, 1, 1)
-	dc.Clear()
+	// Draw logo
+	if style.theme.Logo != "" {
+		drawLogo(dc, cfg, style.theme.Logo)
+	}
 
-	// Load fonts
-	titleFont, err := gg.LoadFontFace(cfg.BoldFontPath, cfg.TitleFontSize)
+	// Draw title
+	titleTemplate := style.theme.Title
+	if titleTemplate == "" {
+		titleTemplate = style.labels.Title
+	}
+	title := style.theme.title(titleTemplate, layout.LayoutID)
+	pen, err := style.pen(dc, true, cfg.TitleFontSize)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load title font: %v", err)
 	}
-	dc.SetFontFace(titleFont)
+	setColor(dc, style.title)
+	pen.DrawText(title, canvasWidth/2, cfg.Padding, 0.5, 0.5)
 
-	// Draw title
-	title := fmt.Sprintf("Kootoro Vending Machine Layout (ID: %d)", layout.LayoutID)
-	dc.SetRGB(0, 0, 0)
-	dc.DrawStringAnchored(title, canvasWidth/2, cfg.Padding, 0.5, 0.5)
+	for _, grid := range grids {
+		originX := cfg.Padding + grid.offsetX
+		originY := cfg.Padding + cfg.TitlePadding + grid.offsetY
+		if err := drawSubLayout(dc, cfg, style, grid, originX, originY, len(grids) > 1, keys); err != nil {
+			return nil, err
+		}
+	}
 
-	// Load column font
-	columnFont, err := gg.LoadFontFace(cfg.FontPath, cfg.HeaderFontSize)
+	// Draw footer
+	if pen, err = style.pen(dc, true, 18.0); err != nil {
+		return nil, fmt.Errorf("failed to load footer font: %v", err)
+	}
+	footerText := title
+	if style.theme.Footer != "" {
+		footerText = style.theme.title(style.theme.Footer, layout.LayoutID)
+	}
+	footerY := canvasHeight - cfg.Padding/2 - cfg.MetadataHeight
+	setColor(dc, style.title)
+	pen.DrawText(footerText, canvasWidth/2, footerY, 0.5, 0.5)
+
+	// Draw metadata
+	if pen, err = style.pen(dc, false, 12.0); err != nil {
+		return nil, fmt.Errorf("failed to load metadata font: %v", err)
+	}
+	now := time.Now()
+	formattedDate := now.Format(style.labels.DateFormat)
+	metadataText := fmt.Sprintf(style.labels.GeneratedAt, formattedDate)
+	setColor(dc, style.muted)
+	pen.DrawText(metadataText, canvasWidth/2, canvasHeight-10, 0.5, 0.5)
+
+	return dc.Encode()
+}
+
+// drawLogo draws the theme logo left of the title, scaled to the title
+// height. A logo that cannot be loaded is skipped.
+func drawLogo(dc Canvas, cfg config.Config, url string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ImageLoadTimeout)*time.Second)
+	img, err := imageCache.Load(ctx, url)
+	cancel()
 	if err != nil {
-		return nil, fmt.Errorf("failed to load column font: %v", err)
+		log.Printf("Failed to load theme logo %s: %v", url, err)
+		return
+	}
+	bounds := img.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return
+	}
+	height := cfg.TitlePadding * 0.8
+	width := height * float64(bounds.Dx()) / float64(bounds.Dy())
+	dc.DrawImage(img, cfg.Padding, cfg.Padding-height/2, width, height)
+}
+
+// drawSubLayout draws one sub-layout grid with its top-left corner at
+// (originX, originY).
+func drawSubLayout(dc Canvas, cfg config.Config, style *renderStyle, grid subLayoutGrid, originX, originY float64, labelled bool, keys PositionKeys) error {
+	// Draw sub-layout label
+	if labelled {
+		pen, err := style.pen(dc, true, cfg.HeaderFontSize)
+		if err != nil {
+			return fmt.Errorf("failed to load sub-layout label font: %v", err)
+		}
+		setColor(dc, style.text)
+		pen.DrawText(fmt.Sprintf(style.labels.SubLayout, grid.index+1), originX+grid.width/2, originY+cfg.SubLayoutLabelHeight/2, 0.5, 0.5)
+		originY += cfg.SubLayoutLabelHeight
+	}
+
+	// Load column font
+	if _, err := style.pen(dc, false, cfg.HeaderFontSize); err != nil {
+		return fmt.Errorf("failed to load column font: %v", err)
 	}
-	dc.SetFontFace(columnFont)
 
 	// Draw column numbers
-	for col := 0; col < actualColumns; col++ {
-		x := cfg.Padding + float64(col)*(cfg.CellWidth+cfg.CellSpacing) + cfg.CellWidth/2
-		y := cfg.Padding + cfg.TitlePadding + cfg.HeaderHeight/2
-		dc.DrawStringAnchored(fmt.Sprintf("%d", col+1), x, y, 0.5, 0.5)
+	setColor(dc, style.text)
+	for col := 0; col < grid.columns; col++ {
+		x := originX + float64(col)*(cfg.CellWidth+cfg.CellSpacing) + cfg.CellWidth/2
+		y := originY + cfg.HeaderHeight/2
+		dc.DrawText(fmt.Sprintf("%d", col+1), x, y, 0.5, 0.5)
 	}
 
 	// Draw rows
-	for rowIdx, tray := range trays {
+	for rowIdx, tray := range grid.trays {
 		rowLetter := tray.TrayCode
-		rowY := cfg.Padding + cfg.TitlePadding + cfg.HeaderHeight + float64(rowIdx)*(cfg.CellHeight+cfg.FooterHeight+cfg.RowSpacing)
+		rowY := originY + cfg.HeaderHeight + float64(rowIdx)*(cfg.CellHeight+cfg.FooterHeight+cfg.RowSpacing)
 
 		if rowIdx > 0 {
 			separatorY := rowY - cfg.RowSpacing/2
-			dc.SetRGB(0.784, 0.784, 0.784)
-			dc.SetLineWidth(1.0 / cfg.CanvasScale)
-			dc.DrawLine(cfg.Padding, separatorY, canvasWidth-cfg.Padding, separatorY)
-			dc.Stroke()
+			setColor(dc, style.separator)
+			dc.Line(originX, separatorY, originX+grid.width, separatorY, 1.0)
 		}
 
 		// Draw row letter
-		dc.SetRGB(0, 0, 0)
-		rowFont, err := gg.LoadFontFace(cfg.FontPath, 16.0)
+		setColor(dc, style.text)
+		rowPen, err := style.pen(dc, false, 16.0)
 		if err != nil {
-			return nil, fmt.Errorf("failed to load row font: %v", err)
+			return fmt.Errorf("failed to load row font: %v", err)
 		}
-		dc.SetFontFace(rowFont)
-		dc.DrawStringAnchored(rowLetter, cfg.Padding-cfg.TextPadding, rowY+cfg.CellHeight/2, 1.0, 0.5)
+		rowPen.DrawText(rowLetter, originX-cfg.TextPadding, rowY+cfg.CellHeight/2, 1.0, 0.5)
 
 		// Sort slots by slotNo
 		sort.Slice(tray.SlotList, func(i, j int) bool {
@@ -149,32 +399,50 @@ func RenderLayoutToBytes(layout Layout) ([]byte, error) {
 		})
 
 		// Load position font
-		positionFont, err := gg.LoadFontFace(cfg.BoldFontPath, cfg.PositionFontSize)
+		positionPen, err := style.pen(dc, true, cfg.PositionFontSize)
 		if err != nil {
-			return nil, fmt.Errorf("failed to load position font: %v", err)
+			return fmt.Errorf("failed to load position font: %v", err)
 		}
-		dc.SetFontFace(positionFont)
 
-		for col := 0; col < actualColumns; col++ {
+		for col := 0; col < grid.columns; col++ {
 			slot := findSlotByNo(tray.SlotList, col+1)
-			cellX := cfg.Padding + float64(col)*(cfg.CellWidth+cfg.CellSpacing)
+			cellX := originX + float64(col)*(cfg.CellWidth+cfg.CellSpacing)
+			inactive := style.view == ViewCapacity && slot != nil && !slot.Active()
 
 			// Draw cell background
-			dc.SetRGB(0.98, 0.98, 0.98)
-			dc.DrawRectangle(cellX, rowY, cfg.CellWidth, cfg.CellHeight)
-			dc.Fill()
+			setColor(dc, style.cellBackground)
+			if inactive {
+				setColor(dc, style.inactive)
+			}
+			dc.FillRect(cellX, rowY, cfg.CellWidth, cfg.CellHeight)
 
 			// Draw cell border
-			dc.SetRGB(0.706, 0.706, 0.706)
-			dc.SetLineWidth(1.0 / cfg.CanvasScale)
-			dc.DrawRectangle(cellX, rowY, cfg.CellWidth, cfg.CellHeight)
-			dc.Stroke()
+			setColor(dc, style.cellBorder)
+			dc.StrokeRect(cellX, rowY, cfg.CellWidth, cfg.CellHeight, 1.0)
+
+			if inactive {
+				if err := drawInactiveSlot(dc, cfg, style, slot, keys.Position(grid.index, tray.TrayCode, col+1), cellX, rowY); err != nil {
+					return err
+				}
+				continue
+			}
 
 			if slot != nil {
 				// Draw position code
-				positionCode := fmt.Sprintf("%s%d", rowLetter, col+1)
-				dc.SetRGB(0, 0, 0.588)
-				dc.DrawString(positionCode, cellX+8, rowY+16)
+				positionCode := keys.Position(grid.index, tray.TrayCode, col+1)
+				setColor(dc, style.position)
+				positionPen.DrawText(positionCode, cellX+8, rowY+16, 0, 0)
+
+				// Capacity view annotations below the cell
+				if style.view == ViewCapacity {
+					var level *StockLevel
+					if stock, ok := style.stock[positionCode]; ok {
+						level = &stock
+					}
+					if err := drawCapacity(dc, cfg, style, slot, cellX, rowY, level); err != nil {
+						return err
+					}
+				}
 
 				// Product image
 				imgX := cellX + (cfg.CellWidth-cfg.ImageSize)/2
@@ -182,94 +450,47 @@ func RenderLayoutToBytes(layout Layout) ([]byte, error) {
 
 				// Load the image
 				loadImageCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ImageLoadTimeout)*time.Second)
-				img, err := loadImageFromURL(loadImageCtx, slot.ProductTemplateImage)
+				img, err := imageCache.Load(loadImageCtx, slot.ProductTemplateImage)
 				cancel()
 				if err != nil {
 					log.Printf("Failed to load image for %s: %v", slot.Position, err)
 					// Draw placeholder
-					dc.SetRGB(0.941, 0.941, 0.941)
-					dc.DrawRectangle(imgX, imgY, cfg.ImageSize, cfg.ImageSize)
-					dc.Fill()
-					dc.SetRGB(0.784, 0.784, 0.784)
-					dc.SetLineWidth(0.5 / cfg.CanvasScale)
-					dc.DrawRectangle(imgX, imgY, cfg.ImageSize, cfg.ImageSize)
-					dc.Stroke()
+					setColor(dc, style.placeholder)
+					dc.FillRect(imgX, imgY, cfg.ImageSize, cfg.ImageSize)
+					setColor(dc, style.separator)
+					dc.StrokeRect(imgX, imgY, cfg.ImageSize, cfg.ImageSize, 0.5)
 
 					// Load placeholder font
-					placeholderFont, err := gg.LoadFontFace(cfg.FontPath, 10.0)
-					if err == nil {
-						dc.SetFontFace(placeholderFont)
+					if placeholderPen, err := style.pen(dc, false, 10.0); err == nil {
+						setColor(dc, style.muted)
+						placeholderPen.DrawText(style.labels.ImageUnavailable, cellX+cfg.CellWidth/2, imgY+cfg.ImageSize/2, 0.5, 0.5)
 					}
-					dc.SetRGB(0.588, 0.588, 0.588)
-					dc.DrawStringAnchored("Image Unavailable", cellX+cfg.CellWidth/2, imgY+cfg.ImageSize/2, 0.5, 0.5)
 				} else {
 					// Scale and draw image
-					dc.Push()
-					dc.Translate(imgX, imgY)
-					scaleX := cfg.ImageSize / float64(img.Bounds().Dx())
-					scaleY := cfg.ImageSize / float64(img.Bounds().Dy())
-					dc.Scale(scaleX, scaleY)
-					dc.DrawImage(img, 0, 0)
-					dc.Pop()
+					dc.DrawImage(img, imgX, imgY, cfg.ImageSize, cfg.ImageSize)
 				}
 
 				// Draw product name
 				nameY := imgY + cfg.ImageSize + 15
-				productFont, err := gg.LoadFontFace(cfg.FontPath, 12.0)
+				namePen, err := style.pen(dc, false, 12.0)
 				if err != nil {
-					return nil, fmt.Errorf("failed to load product font: %v", err)
+					return fmt.Errorf("failed to load product font: %v", err)
 				}
-				dc.SetFontFace(productFont)
-				dc.SetRGB(0, 0, 0)
+				setColor(dc, style.text)
 				productName := strings.TrimSpace(slot.ProductTemplateName)
 				if productName == "" {
-					productName = "Sản phẩm"
+					productName = style.labels.Product
 				}
 				maxWidth := cfg.CellWidth - 20
-				lines := splitTextToLines(dc, productName, maxWidth)
+				lines := splitTextToLines(namePen, productName, maxWidth)
 				for i, line := range lines {
 					lineY := nameY + float64(i)*18
-					dc.DrawStringAnchored(line, cellX+cfg.CellWidth/2, lineY, 0.5, 0.5)
+					namePen.DrawText(line, cellX+cfg.CellWidth/2, lineY, 0.5, 0.5)
 				}
 			}
 		}
 	}
-
-	// Draw footer
-	footerFont, err := gg.LoadFontFace(cfg.BoldFontPath, 18.0)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load footer font: %v", err)
-	}
-	dc.SetFontFace(footerFont)
-	footerText := fmt.Sprintf("Kootoro Vending Machine Layout (ID: %d)", layout.LayoutID)
-	footerY := canvasHeight - cfg.Padding/2 - cfg.MetadataHeight
-	dc.SetRGB(0, 0, 0)
-	dc.DrawStringAnchored(footerText, canvasWidth/2, footerY, 0.5, 0.5)
-
-	// Draw metadata
-	metadataFont, err := gg.LoadFontFace(cfg.FontPath, 12.0)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load metadata font: %v", err)
-	}
-	dc.SetFontFace(metadataFont)
-	now := time.Now()
-	formattedDate := now.Format("Jan 02, 2006 15:04:05")
-	metadataText := fmt.Sprintf("Generated at: %s", formattedDate)
-	dc.SetRGB(0.392, 0.392, 0.392)
-	dc.DrawStringAnchored(metadataText, canvasWidth/2, canvasHeight-10, 0.5, 0.5)
-
-	// Encode the image to PNG
-	var buf bytes.Buffer
-	img := dc.Image()
-	encoder := &png.Encoder{
-		CompressionLevel: png.BestSpeed,
-	}
-	err = encoder.Encode(&buf, img)
-	if err != nil {
-		return nil, fmt.Errorf("failed to encode PNG: %v", err)
-	}
-
-	return buf.Bytes(), nil
+	return nil
 }
 
 func findSlotByNo(slots []Slot, slotNo int) *Slot {
@@ -281,81 +502,18 @@ func findSlotByNo(slots []Slot, slotNo int) *Slot {
 	return nil
 }
 
-func splitTextToLines(dc *gg.Context, text string, maxWidth float64) []string {
-	words := strings.Split(text, " ")
-	if len(words) == 0 {
-		return []string{""}
-	}
-	lines := []string{}
-	currentLine := words[0]
-	for _, word := range words[1:] {
-		testLine := currentLine + " " + word
-		w, _ := dc.MeasureString(testLine)
-		if w <= maxWidth {
-			currentLine = testLine
-		} else {
-			lines = append(lines, currentLine)
-			currentLine = word
-		}
-	}
-	if currentLine != "" {
-		lines = append(lines, currentLine)
-	}
+// textMeasurer is implemented by every Canvas
+type textMeasurer interface {
+	MeasureString(s string) (float64, float64)
+}
+
+// splitTextToLines wraps text into at most two lines; the second line ends
+// with "..." when the text is longer
+func splitTextToLines(dc textMeasurer, text string, maxWidth float64) []string {
+	lines := wrapText(dc, text, maxWidth)
 	if len(lines) > 2 {
-		lines[1] = lines[1][:len(lines[1])-3] + "..."
+		lines[1] = ellipsize(dc, lines[1], maxWidth)
 		return lines[:2]
 	}
 	return lines
 }
-
-func loadImageFromURL(ctx context.Context, url string) (image.Image, error) {
-	if url == "" {
-		return nil, fmt.Errorf("empty image URL")
-	}
-
-	cacheDir := "/tmp/image_cache"
-	err := os.MkdirAll(cacheDir, 0755)
-	if err != nil {
-		return nil, err
-	}
-
-	hash := md5.Sum([]byte(url))
-	cachedFile := filepath.Join(cacheDir, fmt.Sprintf("%x.png", hash))
-
-	if _, err := os.Stat(cachedFile); err == nil {
-		f, err := os.Open(cachedFile)
-		if err != nil {
-			return nil, err
-		}
-		defer f.Close()
-		img, _, err := image.Decode(f)
-		return img, err
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	client := &http.Client{
-		Timeout: 20 * time.Second,
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP status %d", resp.StatusCode)
-	}
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	err = os.WriteFile(cachedFile, data, 0644)
-	if err != nil {
-		log.Printf("Warning: Failed to write image to cache: %v", err)
-	}
-	img, _, err := image.Decode(bytes.NewReader(data))
-	return img, err
-}
@@ -0,0 +1,192 @@
+package renderer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// textPen draws text of one size with a font chain, switching the canvas
+// font between runs of different fonts
+type textPen struct {
+	dc    Canvas
+	chain *fontChain
+	size  float64
+}
+
+// use selects the primary font of the pen
+func (p textPen) use() error {
+	return p.dc.SetFont(p.chain.paths[0], p.size)
+}
+
+// MeasureString returns the width of all runs and the height of the primary
+// font, so text in any script shares the line height
+func (p textPen) MeasureString(s string) (float64, float64) {
+	if p.use() != nil {
+		return 0, 0
+	}
+	_, height := p.dc.MeasureString(s)
+	width := 0.0
+	for _, run := range p.chain.runs(s) {
+		if p.dc.SetFont(run.path, p.size) != nil {
+			continue
+		}
+		w, _ := p.dc.MeasureString(run.text)
+		width += w
+	}
+	return width, height
+}
+
+// DrawText draws s anchored at (x, y) like Canvas.DrawText. Runs drawn with
+// fallback fonts share the baseline of the primary font.
+func (p textPen) DrawText(s string, x, y, ax, ay float64) {
+	runs := p.chain.runs(s)
+	if len(runs) == 0 {
+		return
+	}
+	if len(runs) == 1 && runs[0].path == p.chain.paths[0] {
+		if p.use() == nil {
+			p.dc.DrawText(s, x, y, ax, ay)
+		}
+		return
+	}
+
+	width, height := p.MeasureString(s)
+	penX, baseline := x-ax*width, y+ay*height
+	for _, run := range runs {
+		if p.dc.SetFont(run.path, p.size) != nil {
+			continue
+		}
+		p.dc.DrawText(run.text, penX, baseline, 0, 0)
+		w, _ := p.dc.MeasureString(run.text)
+		penX += w
+	}
+}
+
+// wrapToken is a word, or a single character of a script written without
+// spaces, and whether a space precedes it
+type wrapToken struct {
+	text  string
+	space bool
+}
+
+// breaksAnywhere reports whether lines may break before and after r, as in
+// Chinese, Japanese, Thai, Lao, Khmer and Myanmar text
+func breaksAnywhere(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana,
+		unicode.Thai, unicode.Lao, unicode.Khmer, unicode.Myanmar)
+}
+
+func tokenizeText(text string) []wrapToken {
+	var tokens []wrapToken
+	var word strings.Builder
+	wordSpace, pendingSpace := false, false
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, wrapToken{text: word.String(), space: wordSpace})
+			word.Reset()
+		}
+	}
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+			pendingSpace = true
+		case breaksAnywhere(r):
+			flush()
+			tokens = append(tokens, wrapToken{text: string(r), space: pendingSpace})
+			pendingSpace = false
+		default:
+			if word.Len() == 0 {
+				wordSpace, pendingSpace = pendingSpace, false
+			}
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// wrapText breaks text into lines no wider than maxWidth. Lines break at
+// spaces and between characters of scripts written without spaces; words
+// wider than a line are broken between characters.
+func wrapText(m textMeasurer, text string, maxWidth float64) []string {
+	fits := func(s string) bool {
+		w, _ := m.MeasureString(s)
+		return w <= maxWidth
+	}
+
+	var lines []string
+	line := ""
+	for _, token := range tokenizeText(text) {
+		candidate := token.text
+		if line != "" {
+			candidate = line + token.text
+			if token.space {
+				candidate = line + " " + token.text
+			}
+		}
+		if fits(candidate) {
+			line = candidate
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+		line = token.text
+		for !fits(line) {
+			head, rest := splitToFit(m, line, maxWidth)
+			if rest == "" {
+				break
+			}
+			lines = append(lines, head)
+			line = rest
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// splitToFit returns the longest prefix of s, at least one character, that
+// fits maxWidth and the rest of s. Combining marks stay with their base
+// character.
+func splitToFit(m textMeasurer, s string, maxWidth float64) (string, string) {
+	runes := []rune(s)
+	n := nextCharacter(runes, 0)
+	for n < len(runes) {
+		next := nextCharacter(runes, n)
+		if w, _ := m.MeasureString(string(runes[:next])); w > maxWidth {
+			break
+		}
+		n = next
+	}
+	return string(runes[:n]), string(runes[n:])
+}
+
+// nextCharacter returns the index after the character starting at i and its
+// combining marks
+func nextCharacter(runes []rune, i int) int {
+	i++
+	for i < len(runes) && unicode.Is(unicode.Mn, runes[i]) {
+		i++
+	}
+	return i
+}
+
+// ellipsize shortens s by characters until s followed by "..." fits maxWidth
+func ellipsize(m textMeasurer, s string, maxWidth float64) string {
+	runes := []rune(strings.TrimSpace(s))
+	for len(runes) > 0 {
+		candidate := strings.TrimSpace(string(runes)) + "..."
+		if w, _ := m.MeasureString(candidate); w <= maxWidth {
+			return candidate
+		}
+		end := len(runes) - 1
+		for end > 0 && unicode.Is(unicode.Mn, runes[end]) {
+			end--
+		}
+		runes = runes[:end]
+	}
+	return "..."
+}
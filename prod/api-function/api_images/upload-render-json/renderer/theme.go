@@ -0,0 +1,230 @@
+package renderer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"api_images_upload_render/config"
+)
+
+// DefaultThemeName is the theme used when none is selected
+const DefaultThemeName = "kootoro"
+
+// Theme is the branding and language of a rendered layout. Theme files only
+// need the fields that differ from the default theme.
+type Theme struct {
+	Name string `json:"name"`
+	// Language selects the labels drawn on the image, e.g. "en" or "vi"
+	Language string `json:"language"`
+	// Title and Footer are templates; {layoutId} is replaced with the layout
+	// ID. Empty templates use the title of the language.
+	Title  string      `json:"title,omitempty"`
+	Footer string      `json:"footer,omitempty"`
+	Logo   string      `json:"logo,omitempty"` // URL of a logo drawn left of the title
+	Colors ThemeColors `json:"colors"`
+	Fonts  ThemeFonts  `json:"fonts"`
+}
+
+// ThemeColors are hex colors such as "#1f4e79"
+type ThemeColors struct {
+	Background     string `json:"background"`
+	Title          string `json:"title"`
+	Text           string `json:"text"`
+	Muted          string `json:"muted"`
+	Position       string `json:"position"`
+	CellBackground string `json:"cellBackground"`
+	CellBorder     string `json:"cellBorder"`
+	Separator      string `json:"separator"`
+	Placeholder    string `json:"placeholder"`
+	// Capacity view: inactive slots and fill bars by fill level
+	Inactive    string `json:"inactive"`
+	StockHigh   string `json:"stockHigh"`
+	StockMedium string `json:"stockMedium"`
+	StockLow    string `json:"stockLow"`
+}
+
+// ThemeFonts are TrueType font chains. Each character is drawn with the first
+// font of the chain that has a glyph for it, so fallbacks only need to cover
+// the scripts the primary font lacks.
+type ThemeFonts struct {
+	Regular []string `json:"regular"`
+	Bold    []string `json:"bold"`
+	// Family is the CSS font-family of SVG output
+	Family string `json:"family"`
+}
+
+// DefaultTheme returns the Kootoro theme in English
+func DefaultTheme() Theme {
+	cfg := config.GetConfig()
+	return Theme{
+		Name:     DefaultThemeName,
+		Language: LanguageEnglish,
+		Colors: ThemeColors{
+			Background:     "#ffffff",
+			Title:          "#000000",
+			Text:           "#000000",
+			Muted:          "#646464",
+			Position:       "#000096",
+			CellBackground: "#fafafa",
+			CellBorder:     "#b4b4b4",
+			Separator:      "#c8c8c8",
+			Placeholder:    "#f0f0f0",
+			Inactive:       "#d9d9d9",
+			StockHigh:      "#2e7d32",
+			StockMedium:    "#f9a825",
+			StockLow:       "#c62828",
+		},
+		Fonts: ThemeFonts{
+			Regular: []string{cfg.FontPath},
+			Bold:    []string{cfg.BoldFontPath},
+			Family:  "Arial, Helvetica, sans-serif",
+		},
+	}
+}
+
+// ParseTheme parses a theme file on top of the default theme and validates it
+func ParseTheme(data []byte) (*Theme, error) {
+	theme := DefaultTheme()
+	theme.Name = ""
+	if err := json.Unmarshal(data, &theme); err != nil {
+		return nil, fmt.Errorf("failed to parse theme: %v", err)
+	}
+	if err := theme.Validate(); err != nil {
+		return nil, err
+	}
+	return &theme, nil
+}
+
+// Validate checks the language, colors and font chains of the theme
+func (t *Theme) Validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return fmt.Errorf("theme name is required")
+	}
+	if _, err := LabelsFor(t.Language); err != nil {
+		return fmt.Errorf("theme %s: %v", t.Name, err)
+	}
+	colors := map[string]string{
+		"background":     t.Colors.Background,
+		"title":          t.Colors.Title,
+		"text":           t.Colors.Text,
+		"muted":          t.Colors.Muted,
+		"position":       t.Colors.Position,
+		"cellBackground": t.Colors.CellBackground,
+		"cellBorder":     t.Colors.CellBorder,
+		"separator":      t.Colors.Separator,
+		"placeholder":    t.Colors.Placeholder,
+		"inactive":       t.Colors.Inactive,
+		"stockHigh":      t.Colors.StockHigh,
+		"stockMedium":    t.Colors.StockMedium,
+		"stockLow":       t.Colors.StockLow,
+	}
+	for name, value := range colors {
+		if _, err := parseColor(value); err != nil {
+			return fmt.Errorf("theme %s: colors.%s: %v", t.Name, name, err)
+		}
+	}
+	if len(t.Fonts.Regular) == 0 || len(t.Fonts.Bold) == 0 {
+		return fmt.Errorf("theme %s: fonts.regular and fonts.bold need at least one font", t.Name)
+	}
+	return nil
+}
+
+// Theme registry, filled by LoadThemes
+var (
+	themesMu sync.RWMutex
+	themes   = map[string]*Theme{}
+)
+
+// LoadThemes registers every *.json theme file in dir. A missing directory is
+// not an error. It returns the names of the loaded themes.
+func LoadThemes(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return names, fmt.Errorf("failed to read theme %s: %v", file, err)
+		}
+		theme, err := ParseTheme(data)
+		if err != nil {
+			return names, fmt.Errorf("%s: %v", filepath.Base(file), err)
+		}
+		RegisterTheme(theme)
+		names = append(names, theme.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// RegisterTheme adds or replaces a theme by name
+func RegisterTheme(theme *Theme) {
+	themesMu.Lock()
+	defer themesMu.Unlock()
+	themes[theme.Name] = theme
+}
+
+// LookupTheme returns a registered theme. An empty name selects
+// DefaultThemeName, which is built in unless a theme file overrides it.
+func LookupTheme(name string) (*Theme, error) {
+	if name == "" {
+		name = DefaultThemeName
+	}
+	themesMu.RLock()
+	theme, ok := themes[name]
+	themesMu.RUnlock()
+	if ok {
+		return theme, nil
+	}
+	if name == DefaultThemeName {
+		theme := DefaultTheme()
+		return &theme, nil
+	}
+	return nil, fmt.Errorf("unknown render theme: %s", name)
+}
+
+// title expands a title template
+func (t *Theme) title(template string, layoutID int64) string {
+	return strings.ReplaceAll(template, "{layoutId}", strconv.FormatInt(layoutID, 10))
+}
+
+// rgb is a parsed color
+type rgb struct {
+	r, g, b float64
+}
+
+func setColor(dc Canvas, c rgb) {
+	dc.SetColor(c.r, c.g, c.b)
+}
+
+// parseColor parses "#rrggbb" or "#rgb"
+func parseColor(value string) (rgb, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return rgb{}, fmt.Errorf("invalid color %q: expected #rrggbb", value)
+	}
+	return rgb{
+		r: float64(n>>16&0xff) / 255,
+		g: float64(n>>8&0xff) / 255,
+		b: float64(n&0xff) / 255,
+	}, nil
+}
+
+// mustColor parses a color of a validated theme
+func mustColor(value string) rgb {
+	c, _ := parseColor(value)
+	return c
+}
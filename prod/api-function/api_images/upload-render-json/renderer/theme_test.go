@@ -0,0 +1,99 @@
+package renderer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseThemeInheritsDefaults(t *testing.T) {
+	theme, err := ParseTheme([]byte(`{"name": "brand-a", "language": "vi", "colors": {"title": "#1f4e79"}}`))
+	if err != nil {
+		t.Fatalf("ParseTheme failed: %v", err)
+	}
+	defaults := DefaultTheme()
+	if theme.Colors.Title != "#1f4e79" || theme.Colors.Text != defaults.Colors.Text {
+		t.Errorf("Unexpected colors %+v", theme.Colors)
+	}
+	if !reflect.DeepEqual(theme.Fonts, defaults.Fonts) {
+		t.Errorf("Expected default fonts, got %+v", theme.Fonts)
+	}
+	if got := theme.title("{layoutId} / {layoutId}", 42); got != "42 / 42" {
+		t.Errorf("Unexpected title %q", got)
+	}
+}
+
+func TestParseThemeInvalid(t *testing.T) {
+	cases := map[string]string{
+		"missing name":     `{}`,
+		"unknown language": `{"name": "x", "language": "fr"}`,
+		"invalid color":    `{"name": "x", "colors": {"muted": "grey"}}`,
+		"empty font chain": `{"name": "x", "fonts": {"bold": []}}`,
+	}
+	for name, data := range cases {
+		if _, err := ParseTheme([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestLoadThemes(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "brand-b.json"), []byte(`{"name": "brand-b", "language": "vi"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	names, err := LoadThemes(dir)
+	if err != nil || !reflect.DeepEqual(names, []string{"brand-b"}) {
+		t.Fatalf("LoadThemes returned %v, %v", names, err)
+	}
+	if theme, err := LookupTheme("brand-b"); err != nil || theme.Language != LanguageVietnamese {
+		t.Errorf("LookupTheme returned %+v, %v", theme, err)
+	}
+	if theme, err := LookupTheme(""); err != nil || theme.Name != DefaultThemeName {
+		t.Errorf("Expected the default theme, got %+v, %v", theme, err)
+	}
+	if _, err := LookupTheme("missing"); err == nil {
+		t.Error("Expected an error for an unknown theme")
+	}
+	if _, err := LoadThemes(filepath.Join(dir, "missing")); err != nil {
+		t.Errorf("Expected no error for a missing directory, got %v", err)
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	c, err := parseColor("#fff")
+	if err != nil || c != (rgb{1, 1, 1}) {
+		t.Errorf("got %+v, %v", c, err)
+	}
+	if _, err := parseColor("#12345"); err == nil {
+		t.Error("Expected an error for a 5-digit color")
+	}
+}
+
+// runeMeasurer measures every character as 10 units wide
+type runeMeasurer struct{}
+
+func (runeMeasurer) MeasureString(s string) (float64, float64) {
+	return float64(utf8.RuneCountInString(s)) * 10, 12
+}
+
+func TestSplitTextToLines(t *testing.T) {
+	cases := []struct {
+		text string
+		want []string
+	}{
+		{"Trà Xanh Không Độ", []string{"Trà Xanh", "Không Độ"}},
+		{"Nước suối Aquafina 500ml", []string{"Nước suối", "Aquafin..."}},
+		{"可口可乐零度汽水瓶装特价", []string{"可口可乐零度汽水瓶装", "特价"}},
+		{"Supercalifragilistic", []string{"Supercalif", "ragilistic"}},
+		{"เครื่องดื่มชาเขียวญี่ปุ่น", []string{"เครื่องดื่", "มชาเขีย..."}},
+	}
+	for _, tc := range cases {
+		got := splitTextToLines(runeMeasurer{}, tc.text, 100)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: got %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package renderer
+
+import (
+	"fmt"
+	"strings"
+
+	"api_images_upload_render/config"
+)
+
+// ValidationMode decides how strictly a layout is checked before rendering
+type ValidationMode string
+
+const (
+	// ValidationLenient only rejects layouts that cannot be rendered at all;
+	// data the renderer skips or draws incompletely is reported as warnings
+	ValidationLenient ValidationMode = "lenient"
+	// ValidationStrict also rejects layouts whose image would not match the
+	// data, e.g. duplicate or out-of-range slots
+	ValidationStrict ValidationMode = "strict"
+)
+
+// ParseValidationMode parses "strict" or "lenient"; an empty value is lenient
+func ParseValidationMode(value string) (ValidationMode, error) {
+	switch mode := ValidationMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "":
+		return ValidationLenient, nil
+	case ValidationLenient, ValidationStrict:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unsupported validation mode: %s", value)
+	}
+}
+
+// ValidationIssue is a problem found at a JSON path of the layout, e.g.
+// "subLayoutList[0].trayList[2].slotList[5].slotNo"
+type ValidationIssue struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (i ValidationIssue) String() string {
+	return i.Path + ": " + i.Message
+}
+
+// ValidationResult holds the issues found by ValidateLayout
+type ValidationResult struct {
+	Mode     ValidationMode    `json:"mode"`
+	Errors   []ValidationIssue `json:"errors,omitempty"`
+	Warnings []ValidationIssue `json:"warnings,omitempty"`
+}
+
+// Valid reports whether the layout may be rendered
+func (r *ValidationResult) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// ErrorMessages returns the errors as "path: message" strings
+func (r *ValidationResult) ErrorMessages() []string {
+	messages := make([]string, len(r.Errors))
+	for i, issue := range r.Errors {
+		messages[i] = issue.String()
+	}
+	return messages
+}
+
+// WarningMessages returns the warnings as "path: message" strings
+func (r *ValidationResult) WarningMessages() []string {
+	messages := make([]string, len(r.Warnings))
+	for i, issue := range r.Warnings {
+		messages[i] = issue.String()
+	}
+	return messages
+}
+
+// fail records an issue that prevents rendering in every mode
+func (r *ValidationResult) fail(path, format string, args ...interface{}) {
+	r.Errors = append(r.Errors, ValidationIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// invalid records an issue that makes the image differ from the data: an
+// error in strict mode, a warning in lenient mode
+func (r *ValidationResult) invalid(path, format string, args ...interface{}) {
+	if r.Mode == ValidationStrict {
+		r.fail(path, format, args...)
+		return
+	}
+	r.warn(path, format, args...)
+}
+
+// warn records an issue that is reported but never rejects the layout
+func (r *ValidationResult) warn(path, format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, ValidationIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// ValidateLayout checks the layout semantically, beyond being valid JSON. The
+// checks mirror what the renderer does with the data: slots outside
+// 1..NumColumns are not drawn, only the first of several slots with the same
+// slotNo is drawn and empty sub-layouts are skipped.
+func ValidateLayout(layout Layout, mode ValidationMode) *ValidationResult {
+	if mode == "" {
+		mode = ValidationLenient
+	}
+	result := &ValidationResult{Mode: mode}
+	numColumns := config.GetConfig().NumColumns
+
+	if layout.LayoutID <= 0 {
+		result.fail("layoutId", "must be a positive integer, got %d", layout.LayoutID)
+	}
+	if len(layout.SubLayoutList) == 0 {
+		result.fail("subLayoutList", "must contain at least one sub-layout")
+		return result
+	}
+	if layout.TrayCount() == 0 {
+		result.fail("subLayoutList", "contains no trays")
+		return result
+	}
+
+	for subIdx, sub := range layout.SubLayoutList {
+		subPath := fmt.Sprintf("subLayoutList[%d]", subIdx)
+		if len(sub.TrayList) == 0 {
+			result.invalid(subPath+".trayList", "is empty; the sub-layout is not drawn")
+			continue
+		}
+
+		trayCodes := make(map[string]bool)
+		for trayIdx, tray := range sub.TrayList {
+			trayPath := fmt.Sprintf("%s.trayList[%d]", subPath, trayIdx)
+			trayCode := strings.TrimSpace(tray.TrayCode)
+			switch {
+			case trayCode == "":
+				result.invalid(trayPath+".trayCode", "missing")
+			case trayCodes[trayCode]:
+				result.invalid(trayPath+".trayCode", "duplicate %s", trayCode)
+			default:
+				trayCodes[trayCode] = true
+			}
+
+			if len(tray.SlotList) == 0 {
+				result.warn(trayPath+".slotList", "is empty")
+				continue
+			}
+
+			slotNos := make(map[int]bool)
+			for slotIdx, slot := range tray.SlotList {
+				slotPath := fmt.Sprintf("%s.slotList[%d]", trayPath, slotIdx)
+				switch {
+				case slot.SlotNo < 1:
+					result.invalid(slotPath+".slotNo", "must be at least 1, got %d", slot.SlotNo)
+				case slot.SlotNo > numColumns:
+					result.invalid(slotPath+".slotNo", "%d exceeds the maximum of %d columns", slot.SlotNo, numColumns)
+				case slotNos[slot.SlotNo]:
+					result.invalid(slotPath+".slotNo", "duplicate %d", slot.SlotNo)
+				default:
+					slotNos[slot.SlotNo] = true
+				}
+
+				if trayCode != "" && slot.SlotNo > 0 && slot.Position != "" {
+					if expected := fmt.Sprintf("%s%d", trayCode, slot.SlotNo); slot.Position != expected {
+						result.warn(slotPath+".position", "%s does not match trayCode and slotNo (%s)", slot.Position, expected)
+					}
+				}
+				if slot.ProductId != 0 && strings.TrimSpace(slot.ProductTemplateImage) == "" {
+					result.warn(slotPath+".productTemplateImage", "missing for product %d", slot.ProductId)
+				}
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,105 @@
+package renderer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func validLayout() Layout {
+	return Layout{
+		LayoutID: 1,
+		SubLayoutList: []SubLayout{{TrayList: []Tray{
+			{TrayCode: "A", TrayNo: 1, SlotList: []Slot{
+				{SlotNo: 1, Position: "A1", ProductId: 10, ProductTemplateImage: "https://example.com/1.png"},
+				{SlotNo: 2, Position: "A2"},
+			}},
+			{TrayCode: "B", TrayNo: 2, SlotList: []Slot{{SlotNo: 1, Position: "B1"}}},
+		}}},
+	}
+}
+
+func TestValidateLayoutValid(t *testing.T) {
+	result := ValidateLayout(validLayout(), ValidationStrict)
+	if !result.Valid() || len(result.Warnings) != 0 {
+		t.Errorf("Expected no issues, got %+v", result)
+	}
+}
+
+func TestValidateLayoutModes(t *testing.T) {
+	layout := validLayout()
+	trays := layout.SubLayoutList[0].TrayList
+	trays[0].SlotList = append(trays[0].SlotList, Slot{SlotNo: 2}, Slot{SlotNo: 99})
+	trays[1].TrayCode = "A"
+	trays[1].SlotList[0].Position = ""
+	layout.SubLayoutList = append(layout.SubLayoutList, SubLayout{})
+
+	want := []string{
+		"subLayoutList[0].trayList[0].slotList[2].slotNo: duplicate 2",
+		"subLayoutList[0].trayList[0].slotList[3].slotNo: 99 exceeds the maximum of 20 columns",
+		"subLayoutList[0].trayList[1].trayCode: duplicate A",
+		"subLayoutList[1].trayList: is empty; the sub-layout is not drawn",
+	}
+
+	strict := ValidateLayout(layout, ValidationStrict)
+	if got := strict.ErrorMessages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Strict errors:\n got %q\nwant %q", got, want)
+	}
+
+	lenient := ValidateLayout(layout, ValidationLenient)
+	if !lenient.Valid() {
+		t.Errorf("Expected lenient mode to accept the layout, got %q", lenient.ErrorMessages())
+	}
+	if got := lenient.WarningMessages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Lenient warnings:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestValidateLayoutWarnings(t *testing.T) {
+	layout := validLayout()
+	slots := layout.SubLayoutList[0].TrayList[0].SlotList
+	slots[0].Position = "A5"
+	slots[0].ProductTemplateImage = ""
+
+	result := ValidateLayout(layout, ValidationStrict)
+	want := []string{
+		"subLayoutList[0].trayList[0].slotList[0].position: A5 does not match trayCode and slotNo (A1)",
+		"subLayoutList[0].trayList[0].slotList[0].productTemplateImage: missing for product 10",
+	}
+	if !result.Valid() || !reflect.DeepEqual(result.WarningMessages(), want) {
+		t.Errorf("Expected warnings only, got errors %q warnings %q", result.ErrorMessages(), result.WarningMessages())
+	}
+}
+
+func TestValidateLayoutUnrenderable(t *testing.T) {
+	cases := map[string]struct {
+		layout Layout
+		want   []string
+	}{
+		"no sub-layouts": {
+			Layout{LayoutID: 1},
+			[]string{"subLayoutList: must contain at least one sub-layout"},
+		},
+		"no trays": {
+			Layout{LayoutID: 0, SubLayoutList: []SubLayout{{}}},
+			[]string{"layoutId: must be a positive integer, got 0", "subLayoutList: contains no trays"},
+		},
+	}
+	for name, tc := range cases {
+		// These fail in lenient mode too
+		result := ValidateLayout(tc.layout, ValidationLenient)
+		if got := result.ErrorMessages(); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %q, want %q", name, got, tc.want)
+		}
+	}
+}
+
+func TestParseValidationMode(t *testing.T) {
+	for value, want := range map[string]ValidationMode{"": ValidationLenient, "Strict": ValidationStrict, " lenient ": ValidationLenient} {
+		if got, err := ParseValidationMode(value); err != nil || got != want {
+			t.Errorf("ParseValidationMode(%q) = %q, %v", value, got, err)
+		}
+	}
+	if _, err := ParseValidationMode("pedantic"); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+}
@@ -91,6 +91,19 @@ func GenerateProcessedKey(objectKey string, layoutID int64, layoutPrefix string)
 		year, month, date, layoutID, layoutPrefix)
 }
 
+// GenerateFormatKey returns the key of another render format stored next to
+// the PNG processed key, e.g. ..._reference_image.svg
+func GenerateFormatKey(processedKey string, format renderer.Format) string {
+	return strings.TrimSuffix(processedKey, filepath.Ext(processedKey)) + format.Extension()
+}
+
+// GenerateViewKey returns the key of a render of another view stored next to
+// the PNG processed key, e.g. ..._capacity.png
+func GenerateViewKey(processedKey string, view renderer.View, format renderer.Format) string {
+	base := strings.TrimSuffix(processedKey, filepath.Ext(processedKey))
+	return strings.TrimSuffix(base, "_reference_image") + "_" + string(view) + format.Extension()
+}
+
 func UploadImage(ctx context.Context, s3Client *s3.Client, bucket, key string, imgBytes []byte) error {
 	contentType := "image/png"
 	_, err := s3Client.PutObject(ctx, &s3.PutObjectInput{
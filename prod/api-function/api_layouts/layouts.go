@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"api_layouts/planogram"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/sirupsen/logrus"
+)
+
+// presignExpiry is how long presigned reference image URLs are valid
+const presignExpiry = time.Hour
+
+// searchScanLimit is the number of layouts a search reads at most. Search
+// scans the table and matches products in the Lambda, so its cost grows with
+// the table; narrower filters search more of the matching layouts.
+const searchScanLimit = 5000
+
+// Retirement reasons accepted by POST .../retire
+var retireReasons = map[string]bool{
+	"REPLACED":               true,
+	"DISCONTINUED":           true,
+	"INCORRECT_DATA":         true,
+	"MACHINE_DECOMMISSIONED": true,
+	"OTHER":                  true,
+}
+
+// ListResponse is a page of layouts
+type ListResponse struct {
+	Results    []LayoutSummary `json:"results"`
+	Pagination CursorInfo      `json:"pagination"`
+}
+
+// CursorInfo represents cursor pagination metadata
+type CursorInfo struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// LayoutDetail is a layout with its position map and a presigned URL of its
+// reference image
+type LayoutDetail struct {
+	*LayoutRecord
+	LayoutStatus               string `json:"layoutStatus"`
+	ReferenceImagePresignedURL string `json:"referenceImagePresignedUrl,omitempty"`
+	PresignedURLExpiresAt      string `json:"presignedUrlExpiresAt,omitempty"`
+}
+
+// SearchResponse lists the layouts stocking products that match a query.
+// Truncated is set when the search stopped after searchScanLimit layouts.
+type SearchResponse struct {
+	Query     string         `json:"query"`
+	Total     int            `json:"total"`
+	Truncated bool           `json:"truncated,omitempty"`
+	Results   []SearchResult `json:"results"`
+}
+
+// SearchResult is a layout and its positions whose products match the query
+type SearchResult struct {
+	Layout  LayoutSummary            `json:"layout"`
+	Matches []planogram.ProductMatch `json:"matches"`
+}
+
+// RetireRequest is the body of POST .../retire
+type RetireRequest struct {
+	Reason    string `json:"reason"`
+	Note      string `json:"note,omitempty"`
+	RetiredBy string `json:"retiredBy,omitempty"`
+}
+
+// handleList serves GET /api/layouts
+func handleList(ctx context.Context, params map[string]string) (*ListResponse, error) {
+	filter, err := parseListFilter(params)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := parseLimit(params)
+	if err != nil {
+		return nil, err
+	}
+
+	records, nextCursor, err := listLayouts(ctx, filter, limit, params["cursor"])
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ListResponse{
+		Results:    make([]LayoutSummary, 0, len(records)),
+		Pagination: CursorInfo{Limit: limit, NextCursor: nextCursor},
+	}
+	for i := range records {
+		response.Results = append(response.Results, records[i].Summary())
+	}
+	return response, nil
+}
+
+// handleGet serves GET /api/layouts/{layoutId}/{layoutPrefix}
+func handleGet(ctx context.Context, layoutIDParam, layoutPrefix string) (*LayoutDetail, error) {
+	layoutID, err := parseLayoutKey(layoutIDParam, layoutPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := getLayout(ctx, layoutID, layoutPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, notFound("Layout %d/%s not found", layoutID, layoutPrefix)
+	}
+
+	detail := &LayoutDetail{LayoutRecord: record, LayoutStatus: record.Status()}
+	if url, err := presignReferenceImage(ctx, record.ReferenceImageURL); err != nil {
+		// The layout is still useful without its image
+		log.WithError(err).WithField("referenceImageUrl", record.ReferenceImageURL).Warn("Failed to presign reference image")
+	} else if url != "" {
+		detail.ReferenceImagePresignedURL = url
+		detail.PresignedURLExpiresAt = time.Now().UTC().Add(presignExpiry).Format(time.RFC3339)
+	}
+	return detail, nil
+}
+
+// handleSearch serves GET /api/layouts/search?q=
+func handleSearch(ctx context.Context, params map[string]string) (*SearchResponse, error) {
+	query := strings.TrimSpace(params["q"])
+	terms := planogram.ParseQuery(query)
+	if len(terms) == 0 {
+		return nil, badRequest("q is required")
+	}
+	filter, err := parseListFilter(params)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := parseLimit(params)
+	if err != nil {
+		return nil, err
+	}
+
+	records, truncated, err := scanLayouts(ctx, filter, searchScanLimit)
+	if err != nil {
+		return nil, err
+	}
+	if truncated {
+		log.WithField("query", query).Warnf("Search stopped after %d layouts", searchScanLimit)
+	}
+
+	results := []SearchResult{}
+	for i := range records {
+		if matches := planogram.Match(records[i].ProductPositionMap, terms); len(matches) > 0 {
+			results = append(results, SearchResult{Layout: records[i].Summary(), Matches: matches})
+		}
+	}
+
+	// Layouts stocking the products at most positions first, then newest first
+	sort.SliceStable(results, func(i, j int) bool {
+		if len(results[i].Matches) != len(results[j].Matches) {
+			return len(results[i].Matches) > len(results[j].Matches)
+		}
+		return results[i].Layout.CreatedAt > results[j].Layout.CreatedAt
+	})
+
+	response := &SearchResponse{Query: query, Total: len(results), Truncated: truncated, Results: results}
+	if len(results) > limit {
+		response.Results = results[:limit]
+	}
+	return response, nil
+}
+
+// handleRetire serves POST /api/layouts/{layoutId}/{layoutPrefix}/retire
+func handleRetire(ctx context.Context, layoutIDParam, layoutPrefix, body string) (*LayoutSummary, error) {
+	layoutID, err := parseLayoutKey(layoutIDParam, layoutPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var retire RetireRequest
+	if err := json.Unmarshal([]byte(body), &retire); err != nil {
+		return nil, invalidBody("body must be a JSON object with a reason")
+	}
+	retire.Reason = strings.ToUpper(strings.TrimSpace(retire.Reason))
+	retire.Note = strings.TrimSpace(retire.Note)
+	retire.RetiredBy = strings.TrimSpace(retire.RetiredBy)
+	if !retireReasons[retire.Reason] {
+		return nil, invalidBody("reason must be one of REPLACED, DISCONTINUED, INCORRECT_DATA, MACHINE_DECOMMISSIONED, OTHER")
+	}
+	if retire.Reason == "OTHER" && retire.Note == "" {
+		return nil, invalidBody("note is required when reason is OTHER")
+	}
+
+	record, err := retireLayout(ctx, layoutID, layoutPrefix, retire)
+	if err != nil {
+		return nil, err
+	}
+
+	log.WithFields(logrus.Fields{
+		"layoutId":     layoutID,
+		"layoutPrefix": layoutPrefix,
+		"reason":       retire.Reason,
+		"retiredBy":    retire.RetiredBy,
+	}).Info("Layout retired")
+
+	summary := record.Summary()
+	return &summary, nil
+}
+
+func invalidBody(format string, args ...interface{}) error {
+	return &requestError{statusCode: 400, title: "Invalid request body", message: fmt.Sprintf(format, args...)}
+}
+
+// parseListFilter parses the filters shared by list and search
+func parseListFilter(params map[string]string) (ListFilter, error) {
+	filter := ListFilter{VendingMachineID: strings.TrimSpace(params["vendingMachineId"])}
+
+	if value := params["productId"]; value != "" {
+		productID, err := strconv.Atoi(value)
+		if err != nil || productID < 1 {
+			return filter, badRequest("invalid productId: must be a positive integer")
+		}
+		filter.ProductID = productID
+	}
+
+	// createdAt is stored as UTC RFC3339, so the dates are normalized to compare as strings
+	for name, target := range map[string]*string{"fromDate": &filter.FromDate, "toDate": &filter.ToDate} {
+		if value := params[name]; value != "" {
+			date, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return filter, badRequest("invalid %s format: must be RFC3339", name)
+			}
+			*target = date.UTC().Format(time.RFC3339)
+		}
+	}
+
+	if value := params["includeRetired"]; value != "" {
+		includeRetired, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, badRequest("invalid includeRetired: must be true or false")
+		}
+		filter.IncludeRetired = includeRetired
+	}
+	return filter, nil
+}
+
+func parseLimit(params map[string]string) (int, error) {
+	limitStr := params["limit"]
+	if limitStr == "" {
+		return 20, nil
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 || limit > 100 {
+		return 0, badRequest("invalid limit: must be between 1 and 100")
+	}
+	return limit, nil
+}
+
+func parseLayoutKey(layoutIDParam, layoutPrefix string) (int64, error) {
+	layoutID, err := strconv.ParseInt(layoutIDParam, 10, 64)
+	if err != nil || layoutID < 1 || layoutPrefix == "" {
+		return 0, badRequest("layoutId and layoutPrefix path parameters are required")
+	}
+	return layoutID, nil
+}
+
+// presignReferenceImage returns a presigned GET URL for an s3:// reference
+// image URL; other URLs are not presigned and return ""
+func presignReferenceImage(ctx context.Context, referenceImageURL string) (string, error) {
+	location, ok := strings.CutPrefix(referenceImageURL, "s3://")
+	if !ok {
+		return "", nil
+	}
+	bucket, key, ok := strings.Cut(location, "/")
+	if !ok || bucket == "" || key == "" {
+		return "", fmt.Errorf("invalid S3 URL: %s", referenceImageURL)
+	}
+
+	presignClient := s3.NewPresignClient(s3Client)
+	presigned, err := presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, func(opts *s3.PresignOptions) {
+		opts.Expires = presignExpiry
+	})
+	if err != nil {
+		return "", err
+	}
+	return presigned.URL, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func statusCode(err error) int {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		return reqErr.statusCode
+	}
+	return 0
+}
+
+func TestHandleListPaginates(t *testing.T) {
+	newFakeDynamo(t, testLayout(1, "VM-1", 101), testLayout(2, "VM-1", 102), testLayout(3, "VM-1", 101))
+	ctx := context.Background()
+
+	first, err := handleList(ctx, map[string]string{"limit": "1", "productId": "101"})
+	if err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+	if len(first.Results) != 1 || first.Results[0].LayoutID != 1 || first.Results[0].PositionCount != 1 || first.Pagination.NextCursor == "" {
+		t.Fatalf("Unexpected first page %+v", first)
+	}
+
+	second, err := handleList(ctx, map[string]string{"limit": "1", "productId": "101", "cursor": first.Pagination.NextCursor})
+	if err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+	if len(second.Results) != 1 || second.Results[0].LayoutID != 3 {
+		t.Errorf("Unexpected second page %+v", second)
+	}
+
+	if _, err := handleList(ctx, map[string]string{"cursor": "bogus"}); statusCode(err) != 400 {
+		t.Errorf("Expected 400 for an invalid cursor, got %v", err)
+	}
+	if _, err := handleList(ctx, map[string]string{"limit": "101"}); statusCode(err) != 400 {
+		t.Errorf("Expected 400 for a limit above 100, got %v", err)
+	}
+}
+
+func TestHandleSearch(t *testing.T) {
+	newFakeDynamo(t, testLayout(1, "VM-1", 101), testLayout(2, "VM-1", 101, 101), testLayout(3, "VM-1", 102), testLayout(4, "VM-1", 101))
+	ctx := context.Background()
+
+	response, err := handleSearch(ctx, map[string]string{"q": "product 101", "limit": "2"})
+	if err != nil {
+		t.Fatalf("handleSearch failed: %v", err)
+	}
+	if response.Total != 3 || response.Truncated || len(response.Results) != 2 {
+		t.Fatalf("Unexpected response %+v", response)
+	}
+	// Most matching positions first, then newest first
+	if response.Results[0].Layout.LayoutID != 2 || response.Results[1].Layout.LayoutID != 4 {
+		t.Errorf("Unexpected order %d, %d", response.Results[0].Layout.LayoutID, response.Results[1].Layout.LayoutID)
+	}
+
+	if _, err := handleSearch(ctx, map[string]string{"q": " - "}); statusCode(err) != 400 {
+		t.Errorf("Expected 400 without search terms, got %v", err)
+	}
+}
+
+func TestHandleSearchReportsTruncatedScan(t *testing.T) {
+	records := make([]LayoutRecord, searchScanLimit+1)
+	for i := range records {
+		records[i] = testLayout(int64(i+1), "VM-1", 102)
+	}
+	records[searchScanLimit] = testLayout(searchScanLimit+1, "VM-1", 101)
+	fake := newFakeDynamo(t, records...)
+
+	response, err := handleSearch(context.Background(), map[string]string{"q": "101"})
+	if err != nil {
+		t.Fatalf("handleSearch failed: %v", err)
+	}
+	if !response.Truncated || response.Total != 0 {
+		t.Errorf("Expected a truncated search without results, got %+v", response)
+	}
+	if len(fake.scanLimits) != 1 || fake.scanLimits[0] != searchScanLimit {
+		t.Errorf("Expected one scan of %d layouts, got %v", searchScanLimit, fake.scanLimits)
+	}
+}
+
+func TestHandleRetire(t *testing.T) {
+	newFakeDynamo(t, testLayout(1, "VM-1", 101))
+	ctx := context.Background()
+
+	for _, body := range []string{`not json`, `{"reason": "BROKEN"}`, `{"reason": "other"}`} {
+		if _, err := handleRetire(ctx, "1", "prefix-1", body); statusCode(err) != 400 {
+			t.Errorf("Body %s: expected 400, got %v", body, err)
+		}
+	}
+
+	summary, err := handleRetire(ctx, "1", "prefix-1", `{"reason": " replaced ", "retiredBy": "ops"}`)
+	if err != nil {
+		t.Fatalf("handleRetire failed: %v", err)
+	}
+	if summary.LayoutStatus != LayoutStatusRetired || summary.RetiredReason != "REPLACED" || summary.RetiredAt == "" {
+		t.Errorf("Expected the retired summary, got %+v", summary)
+	}
+
+	if _, err := handleRetire(ctx, "1", "prefix-1", `{"reason": "DISCONTINUED"}`); statusCode(err) != 409 {
+		t.Errorf("Expected 409 for an already retired layout, got %v", err)
+	}
+	if _, err := handleRetire(ctx, "2", "prefix-2", `{"reason": "DISCONTINUED"}`); statusCode(err) != 404 {
+		t.Errorf("Expected 404 for a missing layout, got %v", err)
+	}
+	if _, err := handleRetire(ctx, "0", "prefix-1", `{"reason": "DISCONTINUED"}`); statusCode(err) != 400 {
+		t.Errorf("Expected 400 for an invalid layoutId, got %v", err)
+	}
+}
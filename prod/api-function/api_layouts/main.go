@@ -0,0 +1,347 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"api_layouts/planogram"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	log              *logrus.Logger
+	dynamoClient     DynamoDBAPI
+	s3Client         *s3.Client
+	layoutTableName  string
+	machineIndexName string
+)
+
+// VersionsResponse lists a machine's layout versions, newest first
+type VersionsResponse struct {
+	VendingMachineID string          `json:"vendingMachineId"`
+	Versions         []LayoutSummary `json:"versions"`
+}
+
+// DiffResponse compares two layout versions position by position
+type DiffResponse struct {
+	From    LayoutSummary              `json:"from"`
+	To      LayoutSummary              `json:"to"`
+	Summary planogram.Summary          `json:"summary"`
+	Changes []planogram.PositionChange `json:"changes"`
+}
+
+// ErrorResponse represents an error response
+type ErrorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
+}
+
+// requestError is returned for requests that cannot be served as asked
+type requestError struct {
+	statusCode int
+	title      string
+	message    string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &requestError{statusCode: 400, title: "Invalid query parameters", message: fmt.Sprintf(format, args...)}
+}
+
+func notFound(format string, args ...interface{}) error {
+	return &requestError{statusCode: 404, title: "Layout not found", message: fmt.Sprintf(format, args...)}
+}
+
+func init() {
+	// Initialize logger
+	log = logrus.New()
+	logLevel := os.Getenv("LOG_LEVEL")
+	if level, err := logrus.ParseLevel(logLevel); err == nil {
+		log.SetLevel(level)
+	} else {
+		log.SetLevel(logrus.InfoLevel)
+	}
+	log.SetFormatter(&logrus.JSONFormatter{})
+
+	// Load environment variables
+	layoutTableName = os.Getenv("DYNAMODB_LAYOUT_TABLE")
+	if layoutTableName == "" {
+		log.Fatal("DYNAMODB_LAYOUT_TABLE environment variable is required")
+	}
+
+	machineIndexName = os.Getenv("DYNAMODB_LAYOUT_MACHINE_INDEX")
+	if machineIndexName == "" {
+		machineIndexName = "VendingMachineIndex"
+	}
+
+	log.WithFields(logrus.Fields{
+		"layoutTable":  layoutTableName,
+		"machineIndex": machineIndexName,
+	}).Info("Environment variables loaded successfully")
+
+	// Initialize AWS clients
+	cfg, err := config.LoadDefaultConfig(context.Background())
+	if err != nil {
+		log.WithError(err).Fatal("unable to load AWS SDK config")
+	}
+
+	dynamoClient = dynamodb.NewFromConfig(cfg)
+	s3Client = s3.NewFromConfig(cfg)
+}
+
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	log.WithFields(logrus.Fields{
+		"method": request.HTTPMethod,
+		"path":   request.Path,
+		"params": request.QueryStringParameters,
+	}).Info("Layouts request received")
+
+	// Set CORS headers
+	headers := map[string]string{
+		"Content-Type":                     "application/json",
+		"Access-Control-Allow-Origin":      "*",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Allow-Headers":     "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token",
+		"Access-Control-Allow-Methods":     "GET,POST,OPTIONS",
+	}
+
+	// Handle OPTIONS request for CORS
+	if request.HTTPMethod == "OPTIONS" {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 200,
+			Headers:    headers,
+			Body:       "",
+		}, nil
+	}
+
+	var response interface{}
+	var err error
+	segments := layoutPathSegments(request.Path)
+	switch {
+	case len(segments) == 3 && segments[2] == "retire":
+		if request.HTTPMethod != "POST" {
+			return createErrorResponse(405, "Method not allowed", "Only POST requests are supported", headers)
+		}
+		response, err = handleRetire(ctx, segments[0], segments[1], request.Body)
+	case request.HTTPMethod != "GET":
+		return createErrorResponse(405, "Method not allowed", "Only GET requests are supported", headers)
+	case len(segments) == 0:
+		response, err = handleList(ctx, request.QueryStringParameters)
+	case len(segments) == 1 && segments[0] == "versions":
+		response, err = handleVersions(ctx, request.QueryStringParameters)
+	case len(segments) == 1 && segments[0] == "diff":
+		response, err = handleDiff(ctx, request.QueryStringParameters)
+	case len(segments) == 1 && segments[0] == "search":
+		response, err = handleSearch(ctx, request.QueryStringParameters)
+	case len(segments) == 2:
+		response, err = handleGet(ctx, segments[0], segments[1])
+	default:
+		return createErrorResponse(404, "Not found", fmt.Sprintf("Unknown path: %s", request.Path), headers)
+	}
+	if err != nil {
+		if reqErr, ok := err.(*requestError); ok {
+			log.WithError(err).Warn("Layouts request rejected")
+			return createErrorResponse(reqErr.statusCode, reqErr.title, reqErr.message, headers)
+		}
+		log.WithError(err).Error("Failed to query layouts")
+		return createErrorResponse(500, "Failed to query layouts", err.Error(), headers)
+	}
+
+	// Convert response to JSON
+	responseBody, err := json.Marshal(response)
+	if err != nil {
+		log.WithError(err).Error("Failed to marshal response")
+		return createErrorResponse(500, "Internal server error", "Failed to process response", headers)
+	}
+
+	log.WithField("path", request.Path).Info("Layouts request completed successfully")
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers:    headers,
+		Body:       string(responseBody),
+	}, nil
+}
+
+// handleVersions serves GET /api/layouts/versions?vendingMachineId=
+func handleVersions(ctx context.Context, params map[string]string) (*VersionsResponse, error) {
+	vendingMachineID := params["vendingMachineId"]
+	if vendingMachineID == "" {
+		return nil, badRequest("vendingMachineId is required")
+	}
+
+	records, err := queryVersions(ctx, vendingMachineID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &VersionsResponse{VendingMachineID: vendingMachineID, Versions: []LayoutSummary{}}
+	for i := range records {
+		response.Versions = append(response.Versions, records[i].Summary())
+	}
+	return response, nil
+}
+
+// handleDiff serves GET /api/layouts/diff. The two layouts are either versions
+// of one machine (vendingMachineId, fromVersion, toVersion) or given by key
+// (fromLayoutId, fromLayoutPrefix, toLayoutId, toLayoutPrefix).
+func handleDiff(ctx context.Context, params map[string]string) (*DiffResponse, error) {
+	var from, to *LayoutRecord
+	var err error
+	if params["vendingMachineId"] != "" {
+		from, to, err = resolveVersions(ctx, params)
+	} else {
+		from, to, err = resolveLayoutKeys(ctx, params)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	changes, summary := planogram.Diff(from.ProductPositionMap, to.ProductPositionMap)
+	return &DiffResponse{
+		From:    from.Summary(),
+		To:      to.Summary(),
+		Summary: summary,
+		Changes: changes,
+	}, nil
+}
+
+// resolveVersions looks up two versions of a machine's layout. toVersion
+// defaults to the latest version and fromVersion to the one before toVersion.
+func resolveVersions(ctx context.Context, params map[string]string) (*LayoutRecord, *LayoutRecord, error) {
+	vendingMachineID := params["vendingMachineId"]
+	toVersion, err := parseVersion(params, "toVersion")
+	if err != nil {
+		return nil, nil, err
+	}
+	fromVersion, err := parseVersion(params, "fromVersion")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	records, err := queryVersions(ctx, vendingMachineID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil, notFound("No layout versions found for %s", vendingMachineID)
+	}
+
+	if toVersion == 0 {
+		toVersion = records[0].Version
+	}
+	if fromVersion == 0 {
+		fromVersion = toVersion - 1
+	}
+
+	from, to := findVersion(records, fromVersion), findVersion(records, toVersion)
+	if from == nil {
+		return nil, nil, notFound("Version %d of %s not found", fromVersion, vendingMachineID)
+	}
+	if to == nil {
+		return nil, nil, notFound("Version %d of %s not found", toVersion, vendingMachineID)
+	}
+	return from, to, nil
+}
+
+// resolveLayoutKeys looks up two layouts by layoutId and layoutPrefix
+func resolveLayoutKeys(ctx context.Context, params map[string]string) (*LayoutRecord, *LayoutRecord, error) {
+	var layouts [2]*LayoutRecord
+	for i, side := range []string{"from", "to"} {
+		idParam, prefixParam := side+"LayoutId", side+"LayoutPrefix"
+		layoutID, err := strconv.ParseInt(params[idParam], 10, 64)
+		if err != nil || params[prefixParam] == "" {
+			return nil, nil, badRequest("either vendingMachineId or fromLayoutId, fromLayoutPrefix, toLayoutId and toLayoutPrefix are required")
+		}
+
+		layout, err := getLayout(ctx, layoutID, params[prefixParam])
+		if err != nil {
+			return nil, nil, err
+		}
+		if layout == nil {
+			return nil, nil, notFound("Layout %d/%s not found", layoutID, params[prefixParam])
+		}
+		layouts[i] = layout
+	}
+	return layouts[0], layouts[1], nil
+}
+
+// parseVersion parses an optional positive version number; 0 means not set
+func parseVersion(params map[string]string, name string) (int, error) {
+	value := params[name]
+	if value == "" {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 1 {
+		return 0, badRequest("invalid %s: must be a positive integer", name)
+	}
+	return version, nil
+}
+
+func findVersion(records []LayoutRecord, version int) *LayoutRecord {
+	for i := range records {
+		if records[i].Version == version {
+			return &records[i]
+		}
+	}
+	return nil
+}
+
+// layoutPathSegments returns the unescaped path segments after "layouts", e.g.
+// ["123", "20250601-080000-K2M4P", "retire"] for
+// /api/layouts/123/20250601-080000-K2M4P/retire
+func layoutPathSegments(path string) []string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range parts {
+		if part != "layouts" {
+			continue
+		}
+		segments := make([]string, 0, len(parts)-i-1)
+		for _, segment := range parts[i+1:] {
+			if unescaped, err := url.PathUnescape(segment); err == nil {
+				segment = unescaped
+			}
+			if segment != "" {
+				segments = append(segments, segment)
+			}
+		}
+		return segments
+	}
+	return nil
+}
+
+func createErrorResponse(statusCode int, error, message string, headers map[string]string) (events.APIGatewayProxyResponse, error) {
+	errorResp := ErrorResponse{
+		Error:   error,
+		Message: message,
+		Code:    fmt.Sprintf("HTTP_%d", statusCode),
+	}
+
+	body, _ := json.Marshal(errorResp)
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers:    headers,
+		Body:       string(body),
+	}, nil
+}
+
+func main() {
+	lambda.Start(handler)
+}
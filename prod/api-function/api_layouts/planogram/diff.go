@@ -0,0 +1,127 @@
+// Package planogram compares the product position maps of layouts
+package planogram
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ProductInfo is the product stocked at a position of a layout
+type ProductInfo struct {
+	ProductID            int    `json:"productId" dynamodbav:"productId"`
+	ProductTemplateID    int    `json:"productTemplateId" dynamodbav:"productTemplateId"`
+	ProductTemplateName  string `json:"productTemplateName" dynamodbav:"productTemplateName"`
+	ProductTemplateImage string `json:"productTemplateImage" dynamodbav:"productTemplateImage"`
+	MaxQuantity          int    `json:"maxQuantity" dynamodbav:"maxQuantity"`
+	Status               int    `json:"status" dynamodbav:"status"`
+}
+
+// Change types reported for a position
+const (
+	ChangeProductChanged     = "PRODUCT_CHANGED"
+	ChangeSlotAdded          = "SLOT_ADDED"
+	ChangeSlotRemoved        = "SLOT_REMOVED"
+	ChangeMaxQuantityChanged = "MAX_QUANTITY_CHANGED"
+)
+
+// PositionChange describes how one position differs between two layouts
+type PositionChange struct {
+	Position string       `json:"position"`
+	Changes  []string     `json:"changes"`
+	From     *ProductInfo `json:"from,omitempty"`
+	To       *ProductInfo `json:"to,omitempty"`
+}
+
+// Summary counts the positions per change type
+type Summary struct {
+	SlotsAdded         int `json:"slotsAdded"`
+	SlotsRemoved       int `json:"slotsRemoved"`
+	ProductsChanged    int `json:"productsChanged"`
+	MaxQuantityChanged int `json:"maxQuantityChanged"`
+	Unchanged          int `json:"unchanged"`
+}
+
+// Diff compares two product position maps position by position. A
+// product counts as changed when its productId or productTemplateId differs;
+// a position can be both PRODUCT_CHANGED and MAX_QUANTITY_CHANGED. Changes are
+// ordered by position, row first and then numeric column (A2 before A10).
+func Diff(from, to map[string]ProductInfo) ([]PositionChange, Summary) {
+	var summary Summary
+	changes := []PositionChange{}
+
+	positions := make(map[string]bool, len(from)+len(to))
+	for position := range from {
+		positions[position] = true
+	}
+	for position := range to {
+		positions[position] = true
+	}
+
+	sorted := make([]string, 0, len(positions))
+	for position := range positions {
+		sorted = append(sorted, position)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return lessPosition(sorted[i], sorted[j])
+	})
+
+	for _, position := range sorted {
+		before, inFrom := from[position]
+		after, inTo := to[position]
+
+		switch {
+		case !inFrom:
+			summary.SlotsAdded++
+			changes = append(changes, PositionChange{Position: position, Changes: []string{ChangeSlotAdded}, To: &after})
+		case !inTo:
+			summary.SlotsRemoved++
+			changes = append(changes, PositionChange{Position: position, Changes: []string{ChangeSlotRemoved}, From: &before})
+		default:
+			var kinds []string
+			if before.ProductID != after.ProductID || before.ProductTemplateID != after.ProductTemplateID {
+				summary.ProductsChanged++
+				kinds = append(kinds, ChangeProductChanged)
+			}
+			if before.MaxQuantity != after.MaxQuantity {
+				summary.MaxQuantityChanged++
+				kinds = append(kinds, ChangeMaxQuantityChanged)
+			}
+			if len(kinds) == 0 {
+				summary.Unchanged++
+				continue
+			}
+			changes = append(changes, PositionChange{Position: position, Changes: kinds, From: &before, To: &after})
+		}
+	}
+
+	return changes, summary
+}
+
+// lessPosition orders position keys such as "A2", "A10" and "D2-A1" by their
+// row part and then by the numeric column at the end
+func lessPosition(a, b string) bool {
+	rowA, colA := splitPosition(a)
+	rowB, colB := splitPosition(b)
+	if rowA != rowB {
+		return rowA < rowB
+	}
+	if colA != colB {
+		return colA < colB
+	}
+	return a < b
+}
+
+// splitPosition splits a position key into its row part and trailing column
+// number; keys without a trailing number get column -1
+func splitPosition(position string) (string, int) {
+	end := len(position)
+	for end > 0 && position[end-1] >= '0' && position[end-1] <= '9' {
+		end--
+	}
+	column, err := strconv.Atoi(position[end:])
+	if err != nil {
+		return strings.ToUpper(position), -1
+	}
+	return strings.ToUpper(position[:end]), column
+}
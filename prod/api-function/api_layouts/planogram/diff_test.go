@@ -0,0 +1,66 @@
+package planogram
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	from := map[string]ProductInfo{
+		"A1":  {ProductID: 1, ProductTemplateID: 10, MaxQuantity: 5},
+		"A2":  {ProductID: 2, ProductTemplateID: 20, MaxQuantity: 5},
+		"A10": {ProductID: 3, ProductTemplateID: 30, MaxQuantity: 5},
+		"B1":  {ProductID: 4, ProductTemplateID: 40, MaxQuantity: 8},
+	}
+	to := map[string]ProductInfo{
+		"A1":  {ProductID: 1, ProductTemplateID: 10, MaxQuantity: 5},
+		"A2":  {ProductID: 7, ProductTemplateID: 70, MaxQuantity: 6},
+		"A10": {ProductID: 3, ProductTemplateID: 30, MaxQuantity: 4},
+		"B2":  {ProductID: 4, ProductTemplateID: 40, MaxQuantity: 8},
+	}
+
+	changes, summary := Diff(from, to)
+
+	want := map[string][]string{
+		"A2":  {ChangeProductChanged, ChangeMaxQuantityChanged},
+		"A10": {ChangeMaxQuantityChanged},
+		"B1":  {ChangeSlotRemoved},
+		"B2":  {ChangeSlotAdded},
+	}
+	var order []string
+	for _, change := range changes {
+		order = append(order, change.Position)
+		if !reflect.DeepEqual(change.Changes, want[change.Position]) {
+			t.Errorf("%s: got %v, want %v", change.Position, change.Changes, want[change.Position])
+		}
+	}
+	if wantOrder := []string{"A2", "A10", "B1", "B2"}; !reflect.DeepEqual(order, wantOrder) {
+		t.Errorf("Expected order %v, got %v", wantOrder, order)
+	}
+
+	wantSummary := Summary{SlotsAdded: 1, SlotsRemoved: 1, ProductsChanged: 1, MaxQuantityChanged: 2, Unchanged: 1}
+	if summary != wantSummary {
+		t.Errorf("Expected summary %+v, got %+v", wantSummary, summary)
+	}
+
+	if changes[2].To != nil || changes[2].From.ProductID != 4 {
+		t.Errorf("Expected removed slot to carry only the old product, got %+v", changes[2])
+	}
+}
+
+func TestDiffIdentical(t *testing.T) {
+	layout := map[string]ProductInfo{"A1": {ProductID: 1}}
+	changes, summary := Diff(layout, layout)
+	if len(changes) != 0 || summary.Unchanged != 1 {
+		t.Errorf("Expected no changes, got %+v %+v", changes, summary)
+	}
+}
+
+func TestLessPosition(t *testing.T) {
+	cases := [][2]string{{"A2", "A10"}, {"A10", "B1"}, {"D1-A3", "D1-B1"}, {"D1-A12", "D2-A1"}}
+	for _, c := range cases {
+		if !lessPosition(c[0], c[1]) || lessPosition(c[1], c[0]) {
+			t.Errorf("Expected %s before %s", c[0], c[1])
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package planogram
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ProductMatch is a position whose product matches a search
+type ProductMatch struct {
+	Position string      `json:"position"`
+	Product  ProductInfo `json:"product"`
+}
+
+// ParseQuery splits a search query into lower-case terms at every character
+// that is not a letter or digit
+func ParseQuery(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Match returns the positions whose product matches every term, ordered by
+// position. A term matches when it is the prefix of a word of the product
+// name or equals the productId or productTemplateId.
+func Match(positions map[string]ProductInfo, terms []string) []ProductMatch {
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var matches []ProductMatch
+	for position, product := range positions {
+		if matchesAll(product, terms) {
+			matches = append(matches, ProductMatch{Position: position, Product: product})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return lessPosition(matches[i].Position, matches[j].Position)
+	})
+	return matches
+}
+
+// ContainsProduct reports whether the product is stocked at any position
+func ContainsProduct(positions map[string]ProductInfo, productID int) bool {
+	for _, product := range positions {
+		if product.ProductID == productID {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAll(product ProductInfo, terms []string) bool {
+	if product.ProductID == 0 && product.ProductTemplateName == "" {
+		return false
+	}
+	words := ParseQuery(product.ProductTemplateName)
+	ids := []string{strconv.Itoa(product.ProductID), strconv.Itoa(product.ProductTemplateID)}
+
+	for _, term := range terms {
+		if !matchesTerm(term, words, ids) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesTerm(term string, words, ids []string) bool {
+	for _, id := range ids {
+		if term == id {
+			return true
+		}
+	}
+	for _, word := range words {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package planogram
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	got := ParseQuery("  Coca-Cola 330ml, Trà Xanh ")
+	want := []string{"coca", "cola", "330ml", "trà", "xanh"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	positions := map[string]ProductInfo{
+		"A1":  {ProductID: 101, ProductTemplateID: 5, ProductTemplateName: "Coca-Cola 330ml"},
+		"A10": {ProductID: 101, ProductTemplateID: 5, ProductTemplateName: "Coca-Cola 330ml"},
+		"B2":  {ProductID: 204, ProductTemplateID: 9, ProductTemplateName: "Coca Light"},
+		"C1":  {ProductID: 310, ProductTemplateID: 12, ProductTemplateName: "Trà Xanh Không Độ"},
+		"C2":  {},
+	}
+
+	cases := map[string][]string{
+		"coca":       {"A1", "A10", "B2"},
+		"coca cola":  {"A1", "A10"},
+		"COLA 330":   {"A1", "A10"},
+		"204":        {"B2"},
+		"trà xanh":   {"C1"},
+		"ola":        nil,
+		"pepsi coca": nil,
+	}
+	for query, want := range cases {
+		var got []string
+		for _, match := range Match(positions, ParseQuery(query)) {
+			got = append(got, match.Position)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: got %v, want %v", query, got, want)
+		}
+	}
+
+	if matches := Match(positions, nil); matches != nil {
+		t.Errorf("Expected no matches for an empty query, got %v", matches)
+	}
+}
+
+func TestContainsProduct(t *testing.T) {
+	positions := map[string]ProductInfo{"A1": {ProductID: 101}}
+	if !ContainsProduct(positions, 101) || ContainsProduct(positions, 102) {
+		t.Error("ContainsProduct returned the wrong result")
+	}
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"api_layouts/planogram"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDBAPI is the subset of the DynamoDB client used by the layouts API
+type DynamoDBAPI interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+}
+
+// machineHeadItemType marks the per-machine head items upload-render-json
+// keeps in the layout table next to the layouts; they are not layouts
+const machineHeadItemType = "MACHINE_HEAD"
+
+// LayoutRecord is a layout metadata item written by upload-render-json
+type LayoutRecord struct {
+	LayoutID           int64                            `json:"layoutId" dynamodbav:"layoutId"`
+	LayoutPrefix       string                           `json:"layoutPrefix" dynamodbav:"layoutPrefix"`
+	VendingMachineID   string                           `json:"vendingMachineId" dynamodbav:"vendingMachineId"`
+	Location           string                           `json:"location,omitempty" dynamodbav:"location"`
+	CreatedAt          string                           `json:"createdAt" dynamodbav:"createdAt"`
+	UpdatedAt          string                           `json:"updatedAt" dynamodbav:"updatedAt"`
+	ReferenceImageURL  string                           `json:"referenceImageUrl" dynamodbav:"referenceImageUrl"`
+	SourceJSONURL      string                           `json:"sourceJsonUrl,omitempty" dynamodbav:"sourceJsonUrl"`
+	MachineStructure   map[string]interface{}           `json:"machineStructure,omitempty" dynamodbav:"machineStructure"`
+	ProductPositionMap map[string]planogram.ProductInfo `json:"productPositionMap,omitempty" dynamodbav:"productPositionMap"`
+
+	Version              int    `json:"version,omitempty" dynamodbav:"version,omitempty"`
+	EffectiveFrom        string `json:"effectiveFrom,omitempty" dynamodbav:"effectiveFrom,omitempty"`
+	EffectiveTo          string `json:"effectiveTo,omitempty" dynamodbav:"effectiveTo,omitempty"`
+	PreviousLayoutID     int64  `json:"previousLayoutId,omitempty" dynamodbav:"previousLayoutId,omitempty"`
+	PreviousLayoutPrefix string `json:"previousLayoutPrefix,omitempty" dynamodbav:"previousLayoutPrefix,omitempty"`
+
+	LayoutStatus  string `json:"layoutStatus,omitempty" dynamodbav:"layoutStatus,omitempty"`
+	RetiredAt     string `json:"retiredAt,omitempty" dynamodbav:"retiredAt,omitempty"`
+	RetiredReason string `json:"retiredReason,omitempty" dynamodbav:"retiredReason,omitempty"`
+	RetiredNote   string `json:"retiredNote,omitempty" dynamodbav:"retiredNote,omitempty"`
+	RetiredBy     string `json:"retiredBy,omitempty" dynamodbav:"retiredBy,omitempty"`
+
+	ItemType string `json:"-" dynamodbav:"itemType,omitempty"`
+}
+
+// Layout statuses; layouts stored without a status are active
+const (
+	LayoutStatusActive  = "ACTIVE"
+	LayoutStatusRetired = "RETIRED"
+)
+
+// Status returns the layout status, ACTIVE if none is stored
+func (r *LayoutRecord) Status() string {
+	if r.LayoutStatus == "" {
+		return LayoutStatusActive
+	}
+	return r.LayoutStatus
+}
+
+// LayoutSummary describes a layout without its position map
+type LayoutSummary struct {
+	LayoutID          int64  `json:"layoutId"`
+	LayoutPrefix      string `json:"layoutPrefix"`
+	VendingMachineID  string `json:"vendingMachineId,omitempty"`
+	Location          string `json:"location,omitempty"`
+	Version           int    `json:"version,omitempty"`
+	EffectiveFrom     string `json:"effectiveFrom,omitempty"`
+	EffectiveTo       string `json:"effectiveTo,omitempty"`
+	LayoutStatus      string `json:"layoutStatus"`
+	RetiredAt         string `json:"retiredAt,omitempty"`
+	RetiredReason     string `json:"retiredReason,omitempty"`
+	ReferenceImageURL string `json:"referenceImageUrl"`
+	CreatedAt         string `json:"createdAt"`
+	PositionCount     int    `json:"positionCount"`
+}
+
+// Summary returns the summary of the record
+func (r *LayoutRecord) Summary() LayoutSummary {
+	return LayoutSummary{
+		LayoutID:          r.LayoutID,
+		LayoutPrefix:      r.LayoutPrefix,
+		VendingMachineID:  r.VendingMachineID,
+		Location:          r.Location,
+		Version:           r.Version,
+		EffectiveFrom:     r.EffectiveFrom,
+		EffectiveTo:       r.EffectiveTo,
+		LayoutStatus:      r.Status(),
+		RetiredAt:         r.RetiredAt,
+		RetiredReason:     r.RetiredReason,
+		ReferenceImageURL: r.ReferenceImageURL,
+		CreatedAt:         r.CreatedAt,
+		PositionCount:     len(r.ProductPositionMap),
+	}
+}
+
+// ListFilter selects the layouts returned by listLayouts and searchLayouts
+type ListFilter struct {
+	VendingMachineID string
+	ProductID        int
+	FromDate         string
+	ToDate           string
+	IncludeRetired   bool
+}
+
+// scanInput builds a scan of the layout table with the filters DynamoDB can
+// evaluate; the product filter is applied to the results
+func (f ListFilter) scanInput() *dynamodb.ScanInput {
+	// Machine head items share the table but are not layouts
+	conditions := []string{"attribute_not_exists(itemType)"}
+	values := map[string]types.AttributeValue{}
+
+	if f.VendingMachineID != "" {
+		conditions = append(conditions, "vendingMachineId = :vm")
+		values[":vm"] = &types.AttributeValueMemberS{Value: f.VendingMachineID}
+	}
+	if f.FromDate != "" {
+		conditions = append(conditions, "createdAt >= :from")
+		values[":from"] = &types.AttributeValueMemberS{Value: f.FromDate}
+	}
+	if f.ToDate != "" {
+		conditions = append(conditions, "createdAt <= :to")
+		values[":to"] = &types.AttributeValueMemberS{Value: f.ToDate}
+	}
+	if !f.IncludeRetired {
+		conditions = append(conditions, "(attribute_not_exists(layoutStatus) OR layoutStatus <> :retired)")
+		values[":retired"] = &types.AttributeValueMemberS{Value: LayoutStatusRetired}
+	}
+
+	input := &dynamodb.ScanInput{
+		TableName:        aws.String(layoutTableName),
+		FilterExpression: aws.String(strings.Join(conditions, " AND ")),
+	}
+	if len(values) > 0 {
+		input.ExpressionAttributeValues = values
+	}
+	return input
+}
+
+func (f ListFilter) matches(record *LayoutRecord) bool {
+	return f.ProductID == 0 || planogram.ContainsProduct(record.ProductPositionMap, f.ProductID)
+}
+
+// layoutCursor is the position of a listing, the last key evaluated by the scan
+type layoutCursor struct {
+	LayoutID     string `json:"layoutId"`
+	LayoutPrefix string `json:"layoutPrefix"`
+}
+
+func encodeCursor(key map[string]types.AttributeValue) string {
+	id, idOK := key["layoutId"].(*types.AttributeValueMemberN)
+	prefix, prefixOK := key["layoutPrefix"].(*types.AttributeValueMemberS)
+	if !idOK || !prefixOK {
+		return ""
+	}
+	data, _ := json.Marshal(layoutCursor{LayoutID: id.Value, LayoutPrefix: prefix.Value})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(cursor string) (map[string]types.AttributeValue, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	var c layoutCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.LayoutPrefix == "" {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	if _, err := strconv.ParseInt(c.LayoutID, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return map[string]types.AttributeValue{
+		"layoutId":     &types.AttributeValueMemberN{Value: c.LayoutID},
+		"layoutPrefix": &types.AttributeValueMemberS{Value: c.LayoutPrefix},
+	}, nil
+}
+
+// listLayouts returns up to limit layouts matching the filter, starting after
+// the cursor, and the cursor of the next page ("" on the last page). Each scan
+// request evaluates at most the number of layouts still missing, so the page
+// never overshoots the limit and no layout is skipped between pages.
+func listLayouts(ctx context.Context, filter ListFilter, limit int, cursor string) ([]LayoutRecord, string, error) {
+	input := filter.scanInput()
+	if cursor != "" {
+		startKey, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", badRequest("%v", err)
+		}
+		input.ExclusiveStartKey = startKey
+	}
+
+	records := []LayoutRecord{}
+	for {
+		input.Limit = aws.Int32(int32(limit - len(records)))
+		page, err := dynamoClient.Scan(ctx, input)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to scan layouts: %w", err)
+		}
+		for _, item := range page.Items {
+			var record LayoutRecord
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				log.WithError(err).Warn("Failed to unmarshal layout")
+				continue
+			}
+			if filter.matches(&record) {
+				records = append(records, record)
+			}
+		}
+
+		if len(page.LastEvaluatedKey) == 0 {
+			return records, "", nil
+		}
+		if len(records) >= limit {
+			return records, encodeCursor(page.LastEvaluatedKey), nil
+		}
+		input.ExclusiveStartKey = page.LastEvaluatedKey
+	}
+}
+
+// scanLayouts returns the layouts matching the filter among the first
+// maxScanned layouts of the table, and whether the scan stopped before the end
+// of the table. Every layout scanned costs read capacity, matching or not.
+func scanLayouts(ctx context.Context, filter ListFilter, maxScanned int) ([]LayoutRecord, bool, error) {
+	input := filter.scanInput()
+	var records []LayoutRecord
+	scanned := 0
+	for {
+		input.Limit = aws.Int32(int32(maxScanned - scanned))
+		page, err := dynamoClient.Scan(ctx, input)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to scan layouts: %w", err)
+		}
+		scanned += int(page.ScannedCount)
+		for _, item := range page.Items {
+			var record LayoutRecord
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				log.WithError(err).Warn("Failed to unmarshal layout")
+				continue
+			}
+			if filter.matches(&record) {
+				records = append(records, record)
+			}
+		}
+
+		if len(page.LastEvaluatedKey) == 0 {
+			return records, false, nil
+		}
+		if scanned >= maxScanned {
+			return records, true, nil
+		}
+		input.ExclusiveStartKey = page.LastEvaluatedKey
+	}
+}
+
+// retireLayout marks the layout as retired so that it is no longer used for
+// new verifications. The item, its image and its version history are kept.
+func retireLayout(ctx context.Context, layoutID int64, layoutPrefix string, retire RetireRequest) (*LayoutRecord, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	update := "SET layoutStatus = :retired, retiredAt = :now, retiredReason = :reason, updatedAt = :now"
+	values := map[string]types.AttributeValue{
+		":retired": &types.AttributeValueMemberS{Value: LayoutStatusRetired},
+		":now":     &types.AttributeValueMemberS{Value: now},
+		":reason":  &types.AttributeValueMemberS{Value: retire.Reason},
+	}
+	if retire.Note != "" {
+		update += ", retiredNote = :note"
+		values[":note"] = &types.AttributeValueMemberS{Value: retire.Note}
+	}
+	if retire.RetiredBy != "" {
+		update += ", retiredBy = :by"
+		values[":by"] = &types.AttributeValueMemberS{Value: retire.RetiredBy}
+	}
+
+	result, err := dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(layoutTableName),
+		Key: map[string]types.AttributeValue{
+			"layoutId":     &types.AttributeValueMemberN{Value: strconv.FormatInt(layoutID, 10)},
+			"layoutPrefix": &types.AttributeValueMemberS{Value: layoutPrefix},
+		},
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String("attribute_exists(layoutId) AND attribute_not_exists(itemType) AND (attribute_not_exists(layoutStatus) OR layoutStatus <> :retired)"),
+		ExpressionAttributeValues: values,
+		ReturnValues:              types.ReturnValueAllNew,
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if !errors.As(err, &conditionFailed) {
+			return nil, fmt.Errorf("failed to retire layout %d/%s: %w", layoutID, layoutPrefix, err)
+		}
+
+		// Tell a missing layout from one that is already retired
+		existing, getErr := getLayout(ctx, layoutID, layoutPrefix)
+		if getErr != nil {
+			return nil, getErr
+		}
+		if existing == nil {
+			return nil, notFound("Layout %d/%s not found", layoutID, layoutPrefix)
+		}
+		return nil, &requestError{
+			statusCode: 409,
+			title:      "Layout already retired",
+			message:    fmt.Sprintf("Layout %d/%s was retired at %s (%s)", layoutID, layoutPrefix, existing.RetiredAt, existing.RetiredReason),
+		}
+	}
+
+	var record LayoutRecord
+	if err := attributevalue.UnmarshalMap(result.Attributes, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal layout %d/%s: %w", layoutID, layoutPrefix, err)
+	}
+	return &record, nil
+}
+
+// getLayout reads a layout by its key; it returns nil if there is no such
+// layout or the key is a machine head item
+func getLayout(ctx context.Context, layoutID int64, layoutPrefix string) (*LayoutRecord, error) {
+	result, err := dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(layoutTableName),
+		Key: map[string]types.AttributeValue{
+			"layoutId":     &types.AttributeValueMemberN{Value: strconv.FormatInt(layoutID, 10)},
+			"layoutPrefix": &types.AttributeValueMemberS{Value: layoutPrefix},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get layout %d/%s: %w", layoutID, layoutPrefix, err)
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var record LayoutRecord
+	if err := attributevalue.UnmarshalMap(result.Item, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal layout %d/%s: %w", layoutID, layoutPrefix, err)
+	}
+	if record.ItemType == machineHeadItemType {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// queryVersions returns all versions of the machine's layout, newest first
+func queryVersions(ctx context.Context, vendingMachineID string) ([]LayoutRecord, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(layoutTableName),
+		IndexName:              aws.String(machineIndexName),
+		KeyConditionExpression: aws.String("vendingMachineId = :vm"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":vm": &types.AttributeValueMemberS{Value: vendingMachineID},
+		},
+		ScanIndexForward: aws.Bool(false),
+	}
+
+	var records []LayoutRecord
+	paginator := dynamodb.NewQueryPaginator(dynamoClient, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query %s: %w", machineIndexName, err)
+		}
+		for _, item := range page.Items {
+			var record LayoutRecord
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				log.WithError(err).Warn("Failed to unmarshal layout version")
+				continue
+			}
+			records = append(records, record)
+		}
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Version > records[j].Version
+	})
+	return records, nil
+}
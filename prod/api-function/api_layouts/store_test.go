@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"api_layouts/planogram"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// fakeDynamo is an in-memory layout table. Scan evaluates the filters built
+// by ListFilter.scanInput and, like DynamoDB, applies Limit before the
+// filter and returns a LastEvaluatedKey whenever it stops at the limit.
+type fakeDynamo struct {
+	items      []map[string]types.AttributeValue // in scan order
+	scanLimits []int32
+}
+
+// newFakeDynamo installs a fake table holding the records for the test
+func newFakeDynamo(t *testing.T, records ...LayoutRecord) *fakeDynamo {
+	t.Helper()
+	f := &fakeDynamo{}
+	for _, record := range records {
+		item, err := attributevalue.MarshalMap(record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.items = append(f.items, item)
+	}
+
+	previous := dynamoClient
+	dynamoClient = f
+	t.Cleanup(func() { dynamoClient = previous })
+	return f
+}
+
+func stringAttr(item map[string]types.AttributeValue, name string) string {
+	return attrString(item[name])
+}
+
+func attrString(value types.AttributeValue) string {
+	switch v := value.(type) {
+	case *types.AttributeValueMemberS:
+		return v.Value
+	case *types.AttributeValueMemberN:
+		return v.Value
+	}
+	return ""
+}
+
+func itemKey(item map[string]types.AttributeValue) string {
+	return stringAttr(item, "layoutId") + "/" + stringAttr(item, "layoutPrefix")
+}
+
+func (f *fakeDynamo) find(key map[string]types.AttributeValue) int {
+	for i, item := range f.items {
+		if itemKey(item) == itemKey(key) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f *fakeDynamo) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	if i := f.find(params.Key); i >= 0 {
+		return &dynamodb.GetItemOutput{Item: f.items[i]}, nil
+	}
+	return &dynamodb.GetItemOutput{}, nil
+}
+
+func (f *fakeDynamo) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	i := f.find(params.Key)
+	if i < 0 || stringAttr(f.items[i], "itemType") != "" || stringAttr(f.items[i], "layoutStatus") == LayoutStatusRetired {
+		return nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+	}
+	for _, assignment := range strings.Split(strings.TrimPrefix(aws.ToString(params.UpdateExpression), "SET "), ", ") {
+		name, value, _ := strings.Cut(assignment, " = ")
+		f.items[i][name] = params.ExpressionAttributeValues[value]
+	}
+	return &dynamodb.UpdateItemOutput{Attributes: f.items[i]}, nil
+}
+
+func (f *fakeDynamo) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	limit := int(aws.ToInt32(params.Limit))
+	f.scanLimits = append(f.scanLimits, int32(limit))
+
+	start := 0
+	if params.ExclusiveStartKey != nil {
+		start = f.find(params.ExclusiveStartKey) + 1
+	}
+	output := &dynamodb.ScanOutput{}
+	for i := start; i < len(f.items); i++ {
+		if limit > 0 && int(output.ScannedCount) == limit {
+			output.LastEvaluatedKey = map[string]types.AttributeValue{
+				"layoutId":     f.items[i-1]["layoutId"],
+				"layoutPrefix": f.items[i-1]["layoutPrefix"],
+			}
+			break
+		}
+		output.ScannedCount++
+		if f.matchesFilter(f.items[i], params.ExpressionAttributeValues) {
+			output.Items = append(output.Items, f.items[i])
+			output.Count++
+		}
+	}
+	return output, nil
+}
+
+func (f *fakeDynamo) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return nil, errors.New("Query is not supported by the fake")
+}
+
+// matchesFilter evaluates the conditions of ListFilter.scanInput
+func (f *fakeDynamo) matchesFilter(item, values map[string]types.AttributeValue) bool {
+	if _, ok := item["itemType"]; ok {
+		return false
+	}
+	if vm, ok := values[":vm"]; ok && stringAttr(item, "vendingMachineId") != attrString(vm) {
+		return false
+	}
+	if from, ok := values[":from"]; ok && stringAttr(item, "createdAt") < attrString(from) {
+		return false
+	}
+	if to, ok := values[":to"]; ok && stringAttr(item, "createdAt") > attrString(to) {
+		return false
+	}
+	if _, ok := values[":retired"]; ok && stringAttr(item, "layoutStatus") == LayoutStatusRetired {
+		return false
+	}
+	return true
+}
+
+// testLayout returns a layout of the machine stocking the products
+func testLayout(id int64, vendingMachineID string, productIDs ...int) LayoutRecord {
+	positions := map[string]planogram.ProductInfo{}
+	for i, productID := range productIDs {
+		positions[fmt.Sprintf("A%02d", i+1)] = planogram.ProductInfo{
+			ProductID:           productID,
+			ProductTemplateName: fmt.Sprintf("Product %d", productID),
+		}
+	}
+	return LayoutRecord{
+		LayoutID:           id,
+		LayoutPrefix:       fmt.Sprintf("prefix-%d", id),
+		VendingMachineID:   vendingMachineID,
+		CreatedAt:          fmt.Sprintf("2026-10-%02dT08:00:00Z", id),
+		ReferenceImageURL:  fmt.Sprintf("s3://reference/processed/%d/image.png", id),
+		ProductPositionMap: positions,
+	}
+}
+
+func layoutIDs(records []LayoutRecord) []int64 {
+	ids := make([]int64, len(records))
+	for i, record := range records {
+		ids[i] = record.LayoutID
+	}
+	return ids
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	key := map[string]types.AttributeValue{
+		"layoutId":     &types.AttributeValueMemberN{Value: "23591"},
+		"layoutPrefix": &types.AttributeValueMemberS{Value: "20250601-080000-K2M4P"},
+	}
+	cursor := encodeCursor(key)
+	if cursor == "" || strings.ContainsAny(cursor, "+/=") {
+		t.Fatalf("Expected a URL-safe cursor, got %q", cursor)
+	}
+	decoded, err := decodeCursor(cursor)
+	if err != nil {
+		t.Fatalf("decodeCursor failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, key) {
+		t.Errorf("Expected %v, got %v", key, decoded)
+	}
+
+	if got := encodeCursor(map[string]types.AttributeValue{"layoutId": key["layoutId"]}); got != "" {
+		t.Errorf("Expected no cursor without a layoutPrefix, got %q", got)
+	}
+	for _, cursor := range []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte(`not json`)),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"layoutId":"abc","layoutPrefix":"p"}`)),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"layoutId":"1"}`)),
+	} {
+		if _, err := decodeCursor(cursor); err == nil {
+			t.Errorf("Expected cursor %q to be rejected", cursor)
+		}
+	}
+}
+
+func TestListLayoutsPagesThroughFilters(t *testing.T) {
+	records := []LayoutRecord{
+		testLayout(1, "VM-1", 101),
+		testLayout(2, "VM-2", 101),
+		testLayout(3, "VM-1", 102),
+		testLayout(4, "VM-1", 101, 102),
+		testLayout(5, "VM-1", 101),
+		testLayout(6, "VM-1", 103),
+		testLayout(7, "VM-1", 101),
+		testLayout(8, "VM-2", 101),
+		testLayout(9, "VM-1", 101),
+	}
+	records[4].LayoutStatus = LayoutStatusRetired
+	fake := newFakeDynamo(t, records...)
+
+	filter := ListFilter{VendingMachineID: "VM-1", ProductID: 101}
+	var got []int64
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > len(records) {
+			t.Fatal("Expected the listing to end")
+		}
+		page, next, err := listLayouts(context.Background(), filter, 2, cursor)
+		if err != nil {
+			t.Fatalf("listLayouts failed: %v", err)
+		}
+		if len(page) > 2 {
+			t.Errorf("Expected at most 2 layouts per page, got %d", len(page))
+		}
+		got = append(got, layoutIDs(page)...)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if want := []int64{1, 4, 7, 9}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected layouts %v, got %v", want, got)
+	}
+	for _, limit := range fake.scanLimits {
+		if limit < 1 || limit > 2 {
+			t.Errorf("Expected each scan to evaluate 1 or 2 layouts, got limits %v", fake.scanLimits)
+			break
+		}
+	}
+
+	all, next, err := listLayouts(context.Background(), ListFilter{IncludeRetired: true}, 100, "")
+	if err != nil || next != "" || len(all) != len(records) {
+		t.Errorf("Expected all %d layouts on one page, got %d (next %q, err %v)", len(records), len(all), next, err)
+	}
+}
+
+func TestScanLayoutsStopsAtMaxScanned(t *testing.T) {
+	newFakeDynamo(t, testLayout(1, "VM-1", 101), testLayout(2, "VM-1", 102), testLayout(3, "VM-1", 101), testLayout(4, "VM-1", 101))
+
+	records, truncated, err := scanLayouts(context.Background(), ListFilter{ProductID: 101}, 3)
+	if err != nil {
+		t.Fatalf("scanLayouts failed: %v", err)
+	}
+	if !truncated || !reflect.DeepEqual(layoutIDs(records), []int64{1, 3}) {
+		t.Errorf("Expected layouts [1 3] of the first 3 and a truncated scan, got %v (truncated %v)", layoutIDs(records), truncated)
+	}
+
+	records, truncated, err = scanLayouts(context.Background(), ListFilter{ProductID: 101}, 10)
+	if err != nil || truncated || len(records) != 3 {
+		t.Errorf("Expected the whole table, got %v (truncated %v, err %v)", layoutIDs(records), truncated, err)
+	}
+}
+
+func TestRetireLayout(t *testing.T) {
+	newFakeDynamo(t, testLayout(1, "VM-1", 101))
+	ctx := context.Background()
+
+	record, err := retireLayout(ctx, 1, "prefix-1", RetireRequest{Reason: "OTHER", Note: "moved", RetiredBy: "ops"})
+	if err != nil {
+		t.Fatalf("retireLayout failed: %v", err)
+	}
+	if record.Status() != LayoutStatusRetired || record.RetiredReason != "OTHER" || record.RetiredNote != "moved" || record.RetiredBy != "ops" || record.RetiredAt == "" {
+		t.Errorf("Unexpected retired layout %+v", record)
+	}
+	if stored, _ := getLayout(ctx, 1, "prefix-1"); stored == nil || stored.Status() != LayoutStatusRetired {
+		t.Errorf("Expected the stored layout to be retired, got %+v", stored)
+	}
+
+	var reqErr *requestError
+	_, err = retireLayout(ctx, 1, "prefix-1", RetireRequest{Reason: "REPLACED"})
+	if !errors.As(err, &reqErr) || reqErr.statusCode != 409 || !strings.Contains(reqErr.message, record.RetiredAt) {
+		t.Errorf("Expected 409 for an already retired layout, got %v", err)
+	}
+	_, err = retireLayout(ctx, 2, "prefix-2", RetireRequest{Reason: "REPLACED"})
+	if !errors.As(err, &reqErr) || reqErr.statusCode != 404 {
+		t.Errorf("Expected 404 for a missing layout, got %v", err)
+	}
+}
+
+func TestMachineHeadItemsAreNotLayouts(t *testing.T) {
+	head := LayoutRecord{LayoutID: 0, LayoutPrefix: "MACHINE#VM-1", ItemType: machineHeadItemType}
+	newFakeDynamo(t, testLayout(1, "VM-1", 101), head)
+	ctx := context.Background()
+
+	all, _, err := listLayouts(ctx, ListFilter{IncludeRetired: true}, 100, "")
+	if err != nil || !reflect.DeepEqual(layoutIDs(all), []int64{1}) {
+		t.Errorf("Expected only layout 1 to be listed, got %v (err %v)", layoutIDs(all), err)
+	}
+	if record, err := getLayout(ctx, 0, "MACHINE#VM-1"); err != nil || record != nil {
+		t.Errorf("Expected no layout for the head item, got %+v (err %v)", record, err)
+	}
+
+	var reqErr *requestError
+	_, err = retireLayout(ctx, 0, "MACHINE#VM-1", RetireRequest{Reason: "OTHER"})
+	if !errors.As(err, &reqErr) || reqErr.statusCode != 404 {
+		t.Errorf("Expected 404 when retiring the head item, got %v", err)
+	}
+}
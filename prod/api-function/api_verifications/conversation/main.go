@@ -49,6 +49,11 @@ type VerificationRecord struct {
 	// Processed paths from verification metadata
 	Turn1ProcessedPath string `json:"turn1ProcessedPath,omitempty" dynamodbav:"turn1ProcessedPath,omitempty"`
 	Turn2ProcessedPath string `json:"turn2ProcessedPath,omitempty" dynamodbav:"turn2ProcessedPath,omitempty"`
+	// Reasoning artifact paths, only present when extended thinking was enabled
+	Turn1ReasoningPath string `json:"turn1ReasoningPath,omitempty" dynamodbav:"turn1ReasoningPath,omitempty"`
+	Turn2ReasoningPath string `json:"turn2ReasoningPath,omitempty" dynamodbav:"turn2ReasoningPath,omitempty"`
+	// Annotated result image written by FinalizeAndStoreResults
+	ResultImageURL string `json:"resultImageUrl,omitempty" dynamodbav:"resultImageUrl,omitempty"`
 }
 
 // ConversationContent represents content from a single turn
@@ -57,6 +62,14 @@ type ConversationContent struct {
 	Content     string `json:"content"`
 	ContentType string `json:"contentType"`
 	S3Path      string `json:"s3Path"`
+	// Reasoning holds the extended-thinking artifact for the turn, if one was stored
+	Reasoning *ReasoningContent `json:"reasoning,omitempty"`
+}
+
+// ReasoningContent represents the stored reasoning artifact of a turn
+type ReasoningContent struct {
+	S3Path   string          `json:"s3Path"`
+	Artifact json.RawMessage `json:"artifact"`
 }
 
 // ConversationResponse represents the API response structure
@@ -65,6 +78,7 @@ type ConversationResponse struct {
 	Turn1Content   *ConversationContent  `json:"turn1Content,omitempty"`
 	Turn2Content   *ConversationContent  `json:"turn2Content,omitempty"`
 	Contents       []ConversationContent `json:"contents"`
+	ResultImageURL string                `json:"resultImageUrl,omitempty"`
 }
 
 // ErrorResponse represents an error response
@@ -161,6 +175,7 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (events
 	response := ConversationResponse{
 		VerificationID: verificationId,
 		Contents:       []ConversationContent{},
+		ResultImageURL: verificationRecord.ResultImageURL,
 	}
 
 	// Retrieve Turn1 content if path exists
@@ -174,6 +189,7 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (events
 				Content:     turn1Content,
 				ContentType: "text/markdown",
 				S3Path:      verificationRecord.Turn1ProcessedPath,
+				Reasoning:   getReasoningContent(ctx, 1, verificationRecord.Turn1ReasoningPath),
 			}
 			response.Turn1Content = &turn1
 			response.Contents = append(response.Contents, turn1)
@@ -191,6 +207,7 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (events
 				Content:     turn2Content,
 				ContentType: "text/markdown",
 				S3Path:      verificationRecord.Turn2ProcessedPath,
+				Reasoning:   getReasoningContent(ctx, 2, verificationRecord.Turn2ReasoningPath),
 			}
 			response.Turn2Content = &turn2
 			response.Contents = append(response.Contents, turn2)
@@ -217,6 +234,7 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (events
 		"turn1Available": response.Turn1Content != nil,
 		"turn2Available": response.Turn2Content != nil,
 		"totalContents":  len(response.Contents),
+		"hasResultImage": response.ResultImageURL != "",
 	}).Info("Get conversation request completed successfully")
 
 	return events.APIGatewayProxyResponse{
@@ -282,6 +300,8 @@ func getVerificationRecord(ctx context.Context, verificationId string) (*Verific
 		"verificationAt":     record.VerificationAt,
 		"turn1ProcessedPath": record.Turn1ProcessedPath,
 		"turn2ProcessedPath": record.Turn2ProcessedPath,
+		"turn1ReasoningPath": record.Turn1ReasoningPath,
+		"turn2ReasoningPath": record.Turn2ReasoningPath,
 	}).Debug("Successfully retrieved verification record")
 
 	return &record, nil
@@ -339,6 +359,34 @@ func getS3Content(ctx context.Context, s3Path string) (string, error) {
 	return string(content), nil
 }
 
+// getReasoningContent loads the reasoning artifact stored for a turn. It
+// returns nil when no artifact exists or it cannot be read, since reasoning
+// is supplementary to the processed conversation content.
+func getReasoningContent(ctx context.Context, turn int, s3Path string) *ReasoningContent {
+	if s3Path == "" {
+		return nil
+	}
+
+	content, err := getS3Content(ctx, s3Path)
+	if err != nil {
+		log.WithError(err).WithField("turn", turn).Warn("Failed to retrieve reasoning S3 content")
+		return nil
+	}
+
+	if !json.Valid([]byte(content)) {
+		log.WithFields(logrus.Fields{
+			"turn":   turn,
+			"s3Path": s3Path,
+		}).Warn("Reasoning artifact is not valid JSON")
+		return nil
+	}
+
+	return &ReasoningContent{
+		S3Path:   s3Path,
+		Artifact: json.RawMessage(content),
+	}
+}
+
 func main() {
 	lambda.Start(handler)
 }
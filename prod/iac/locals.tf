@@ -207,6 +207,16 @@ locals {
       lifecycle_policy     = null
       repository_policy    = null
     },
+    api_layouts = {
+      name                 = lower(join("-", compact([local.name_prefix, "ecr", "api-layouts", local.name_suffix])))
+      image_tag_mutability = "MUTABLE"
+      scan_on_push         = true
+      force_delete         = false
+      encryption_type      = "AES256"
+      kms_key              = null
+      lifecycle_policy     = null
+      repository_policy    = null
+    },
     # ECR repository for React frontend
     react_frontend = {
       name                 = lower(join("-", compact([local.name_prefix, "ecr", "react-frontend", local.name_suffix])))
@@ -477,6 +487,16 @@ locals {
         JSON_RENDER_PATH      = "s3://${local.s3_buckets.reference}/raw/"
         LOG_LEVEL             = "INFO"
       }
+    },
+    api_layouts = {
+      name        = lower(join("-", compact([local.name_prefix, "lambda", "api-layouts", local.name_suffix]))),
+      description = "API endpoints for listing, searching, retiring and comparing layouts",
+      memory_size = 512,
+      timeout     = 30,
+      environment_variables = {
+        DYNAMODB_LAYOUT_TABLE = local.dynamodb_tables.layout_metadata
+        LOG_LEVEL             = "INFO"
+      }
     }
   }
 
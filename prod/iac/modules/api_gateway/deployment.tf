@@ -95,6 +95,11 @@ resource "aws_api_gateway_deployment" "deployment" {
       aws_api_gateway_integration.image_upload_options.id,
       aws_api_gateway_method_response.image_upload_options.id,
       aws_api_gateway_integration_response.image_upload_options.id,
+
+      aws_api_gateway_method.layouts_any.id,
+      aws_api_gateway_integration.layouts_any.id,
+      aws_api_gateway_method.layouts_proxy_any.id,
+      aws_api_gateway_integration.layouts_proxy_any.id,
     ]))
   }
 
@@ -113,6 +118,8 @@ resource "aws_api_gateway_deployment" "deployment" {
     aws_api_gateway_integration.image_view_get,
     aws_api_gateway_integration.image_browser_get,
     aws_api_gateway_integration.image_upload_post,
+    aws_api_gateway_integration.layouts_any,
+    aws_api_gateway_integration.layouts_proxy_any,
 
     # Method responses
     aws_api_gateway_method_response.verifications_lookup_get,
@@ -191,6 +198,7 @@ resource "aws_api_gateway_stage" "stage" {
     image_view_lambda                = var.lambda_function_names["api_images_view"]
     image_browser_lambda             = var.lambda_function_names["api_images_browser"]
     image_upload_lambda              = var.lambda_function_names["api_images_upload_render"]
+    layouts_lambda                   = var.lambda_function_names["api_layouts"]
   }
 
   access_log_settings {
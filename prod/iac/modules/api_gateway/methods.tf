@@ -1124,6 +1124,45 @@ resource "aws_api_gateway_integration_response" "image_upload_post" {
   }
 }
 
+# Layouts - ANY /api/layouts and /api/layouts/{proxy+}
+# The api_layouts Lambda routes by path and answers OPTIONS itself
+resource "aws_api_gateway_method" "layouts_any" {
+  rest_api_id      = aws_api_gateway_rest_api.api.id
+  resource_id      = aws_api_gateway_resource.layouts.id
+  http_method      = "ANY"
+  authorization    = "NONE"
+  api_key_required = false
+}
+
+resource "aws_api_gateway_integration" "layouts_any" {
+  rest_api_id             = aws_api_gateway_rest_api.api.id
+  resource_id             = aws_api_gateway_resource.layouts.id
+  http_method             = aws_api_gateway_method.layouts_any.http_method
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = "arn:aws:apigateway:${var.region}:lambda:path/2015-03-31/functions/${var.lambda_function_arns["api_layouts"]}/invocations"
+}
+
+resource "aws_api_gateway_method" "layouts_proxy_any" {
+  rest_api_id      = aws_api_gateway_rest_api.api.id
+  resource_id      = aws_api_gateway_resource.layouts_proxy.id
+  http_method      = "ANY"
+  authorization    = "NONE"
+  api_key_required = false
+  request_parameters = {
+    "method.request.path.proxy" = true
+  }
+}
+
+resource "aws_api_gateway_integration" "layouts_proxy_any" {
+  rest_api_id             = aws_api_gateway_rest_api.api.id
+  resource_id             = aws_api_gateway_resource.layouts_proxy.id
+  http_method             = aws_api_gateway_method.layouts_proxy_any.http_method
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = "arn:aws:apigateway:${var.region}:lambda:path/2015-03-31/functions/${var.lambda_function_arns["api_layouts"]}/invocations"
+}
+
 # Lambda permissions for API Gateway to invoke Lambda functions
 resource "aws_lambda_permission" "api_gateway_lambda" {
   for_each = var.lambda_function_arns
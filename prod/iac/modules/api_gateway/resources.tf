@@ -124,3 +124,17 @@ resource "aws_api_gateway_resource" "image_upload" {
   path_part   = "upload"
 }
 
+# /api/layouts
+resource "aws_api_gateway_resource" "layouts" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  parent_id   = aws_api_gateway_resource.api.id
+  path_part   = "layouts"
+}
+
+# /api/layouts/{proxy+} - versions, diff, search, {layoutId}/{layoutPrefix}[/retire]
+resource "aws_api_gateway_resource" "layouts_proxy" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  parent_id   = aws_api_gateway_resource.layouts.id
+  path_part   = "{proxy+}"
+}
+
@@ -163,6 +163,11 @@ resource "aws_dynamodb_table" "layout_metadata" {
     type = "S"
   }
 
+  attribute {
+    name = "effectiveFrom"
+    type = "S"
+  }
+
   # LSI1: Sort by creation date for a specific layoutId
   local_secondary_index {
     name            = "CreatedAtIndex"
@@ -206,6 +211,19 @@ resource "aws_dynamodb_table" "layout_metadata" {
     write_capacity = var.billing_mode == "PROVISIONED" ? var.write_capacity : null
   }
 
+  # GSI4: Layout versions of a vending machine ordered by effective date.
+  # Only layouts stored with effectiveFrom (versioned uploads) are indexed.
+  global_secondary_index {
+    name            = "VendingMachineIndex"
+    hash_key        = "vendingMachineId"
+    range_key       = "effectiveFrom"
+    projection_type = "ALL"
+
+    # Only set capacity if using PROVISIONED billing mode
+    read_capacity  = var.billing_mode == "PROVISIONED" ? var.read_capacity : null
+    write_capacity = var.billing_mode == "PROVISIONED" ? var.write_capacity : null
+  }
+
   point_in_time_recovery {
     enabled = var.point_in_time_recovery
   }
@@ -17,6 +17,8 @@ import (
 	"github.com/aws/aws-lambda-go/lambdacontext"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 
 	internalConfig "workflow-function/ExecuteTurn1Combined/internal/config"
 	"workflow-function/ExecuteTurn1Combined/internal/handler"
@@ -30,8 +32,14 @@ import (
 	sharedBedrock "workflow-function/shared/bedrock"
 	"workflow-function/shared/errors"
 	"workflow-function/shared/logger"
+	"workflow-function/shared/s3state"
+	"workflow-function/shared/templateloader"
+	"workflow-function/shared/turnexecutor"
 )
 
+// turn1PromptTokenBudget bounds the estimated size of a rendered Turn1 prompt
+const turn1PromptTokenBudget = 16000
+
 // ApplicationContainer represents the enhanced dependency orchestration framework
 // with strategic separation between shared infrastructure and local control
 type ApplicationContainer struct {
@@ -41,10 +49,10 @@ type ApplicationContainer struct {
 	handler   *handler.Handler
 
 	// Strategic service abstractions with deterministic control patterns
-	s3Service      services.S3StateManager
+	store          *turnexecutor.S3Store
 	bedrockService services.BedrockService // Now using local implementation
-	dynamoService  services.DynamoDBService
-	promptService  services.PromptService
+	status         *turnexecutor.DynamoStatus
+	renderer       *turnexecutor.PromptRenderer
 }
 
 // SystemInitializationMetrics captures comprehensive bootstrap telemetry
@@ -151,10 +159,10 @@ func init() {
 		"template_base_path":       applicationContainer.config.Prompts.TemplateBasePath,
 		"template_version":         applicationContainer.config.Prompts.TemplateVersion,
 		"services_initialized": map[string]interface{}{
-			"s3_service":      applicationContainer.s3Service != nil,
+			"s3_service":      applicationContainer.store != nil,
 			"bedrock_service": applicationContainer.bedrockService != nil,
-			"dynamo_service":  applicationContainer.dynamoService != nil,
-			"prompt_service":  applicationContainer.promptService != nil,
+			"dynamo_service":  applicationContainer.status != nil,
+			"prompt_service":  applicationContainer.renderer != nil,
 			"architecture":    "deterministic_local_control",
 		},
 	})
@@ -281,10 +289,10 @@ func initializeApplicationContainer() (*ApplicationContainer, error) {
 
 	// Strategic handler initialization with enhanced services
 	handlerInstance, err := handler.NewHandler(
-		services.s3Service,
+		services.store,
 		services.bedrockService,
-		services.dynamoService,
-		services.promptService,
+		services.renderer,
+		services.status,
 		logger,
 		cfg,
 	)
@@ -326,30 +334,30 @@ func initializeApplicationContainer() (*ApplicationContainer, error) {
 		logger:         logger,
 		awsConfig:      awsConfig,
 		handler:        handlerInstance,
-		s3Service:      services.s3Service,
+		store:          services.store,
 		bedrockService: services.bedrockService,
-		dynamoService:  services.dynamoService,
-		promptService:  services.promptService,
+		status:         services.status,
+		renderer:       services.renderer,
 	}, nil
 }
 
 // ServiceLayerComponents encapsulates service dependencies with architectural metadata
 type ServiceLayerComponents struct {
-	s3Service      services.S3StateManager
+	store          *turnexecutor.S3Store
 	bedrockService services.BedrockService
-	dynamoService  services.DynamoDBService
-	promptService  services.PromptService
+	status         *turnexecutor.DynamoStatus
+	renderer       *turnexecutor.PromptRenderer
 }
 
 // initializeServiceLayerWithLocalBedrock implements strategic service initialization
 // with deterministic local Bedrock control architecture
 func initializeServiceLayerWithLocalBedrock(awsConfig aws.Config, cfg *internalConfig.Config, logger logger.Logger) (*ServiceLayerComponents, error) {
-	// S3 service initialization with enhanced error handling
-	s3Service, err := services.NewS3StateManager(*cfg, logger)
+	// S3 store initialization with enhanced error handling
+	stateManager, err := s3state.New(cfg.AWS.S3Bucket)
 	if err != nil {
 		return nil, errors.WrapError(err, errors.ErrorTypeS3,
 			"S3 service initialization failed", false).
-			WithComponent("S3StateManager").
+			WithComponent("S3Store").
 			WithOperation("NewS3StateManager").
 			WithCategory(errors.CategoryServer).
 			WithSeverity(errors.ErrorSeverityHigh).
@@ -367,6 +375,7 @@ func initializeServiceLayerWithLocalBedrock(awsConfig aws.Config, cfg *internalC
 				"Review VPC endpoints if using private subnets",
 			)
 	}
+	store := turnexecutor.NewS3Store(stateManager, cfg.CurrentDatePartition, logger)
 
 	// Strategic Bedrock service initialization with local control pattern
 	bedrockInitStart := time.Now()
@@ -463,13 +472,18 @@ func initializeServiceLayerWithLocalBedrock(awsConfig aws.Config, cfg *internalC
 		"operational_metrics": localClient.GetOperationalMetrics(),
 	})
 
-	// DynamoDB service initialization with enhanced error handling
-	dynamoService, err := services.NewDynamoDBService(cfg)
+	// DynamoDB status initialization with enhanced error handling
+	dynamoConfig, err := awsconfig.LoadDefaultConfig(
+		context.Background(),
+		awsconfig.WithRegion(cfg.AWS.Region),
+		awsconfig.WithRetryMaxAttempts(cfg.Processing.MaxRetries),
+		awsconfig.WithRetryMode(aws.RetryModeAdaptive),
+	)
 	if err != nil {
 		return nil, errors.WrapError(err, errors.ErrorTypeDynamoDB,
 			"DynamoDB service initialization failed", false).
-			WithComponent("DynamoDBService").
-			WithOperation("NewDynamoDBService").
+			WithComponent("DynamoStatus").
+			WithOperation("LoadDefaultConfig").
 			WithCategory(errors.CategoryServer).
 			WithSeverity(errors.ErrorSeverityHigh).
 			WithContext("verification_table", cfg.AWS.DynamoDBVerificationTable).
@@ -486,14 +500,48 @@ func initializeServiceLayerWithLocalBedrock(awsConfig aws.Config, cfg *internalC
 				"Review table status in AWS console",
 			)
 	}
+	status := turnexecutor.NewDynamoStatus(
+		dynamodb.NewFromConfig(dynamoConfig),
+		cfg.AWS.DynamoDBVerificationTable,
+		cfg.AWS.DynamoDBConversationTable,
+		cfg.Processing.MaxRetries,
+		logger,
+	)
+
+	// Prompt renderer initialization with enhanced error handling
+	loaderConfig := templateloader.Config{
+		BasePath:       cfg.Prompts.TemplateBasePath,
+		CacheEnabled:   true,
+		PinnedVersions: templateloader.PinnedVersionsFromEnv(),
+	}
+
+	// Templates in S3 override the bundled templates when configured
+	if cfg.Prompts.TemplateS3URI != "" {
+		source, err := templateloader.NewS3FallbackSource(s3.NewFromConfig(awsConfig),
+			cfg.Prompts.TemplateS3URI, cfg.Prompts.TemplateBasePath)
+		if err != nil {
+			return nil, errors.WrapError(err, errors.ErrorTypeConfig,
+				"failed to configure S3 template source", false).
+				WithComponent("PromptRenderer").
+				WithOperation("NewS3FallbackSource").
+				WithContext("template_s3_uri", cfg.Prompts.TemplateS3URI)
+		}
+		loaderConfig.Source = source
+	}
+
+	logger.Info("creating_template_loader", map[string]interface{}{
+		"base_path":       loaderConfig.BasePath,
+		"template_s3_uri": cfg.Prompts.TemplateS3URI,
+		"cache_enabled":   loaderConfig.CacheEnabled,
+		"pinned_versions": loaderConfig.PinnedVersions,
+	})
 
-	// Prompt service initialization with enhanced error handling
-	promptService, err := services.NewPromptService(cfg, logger)
+	templateLoader, err := templateloader.New(loaderConfig)
 	if err != nil {
 		return nil, errors.WrapError(err, errors.ErrorTypeTemplate,
 			"Prompt service initialization failed", false).
-			WithComponent("PromptService").
-			WithOperation("NewPromptService").
+			WithComponent("PromptRenderer").
+			WithOperation("NewTemplateLoader").
 			WithCategory(errors.CategoryServer).
 			WithSeverity(errors.ErrorSeverityHigh).
 			WithContext("template_base_path", cfg.Prompts.TemplateBasePath).
@@ -512,10 +560,10 @@ func initializeServiceLayerWithLocalBedrock(awsConfig aws.Config, cfg *internalC
 	}
 
 	return &ServiceLayerComponents{
-		s3Service:      s3Service,
+		store:          store,
 		bedrockService: bedrockService,
-		dynamoService:  dynamoService,
-		promptService:  promptService,
+		status:         status,
+		renderer:       turnexecutor.NewPromptRenderer(templateLoader, logger).WithTokenBudget(turn1PromptTokenBudget),
 	}, nil
 }
 
@@ -1,55 +1,33 @@
 package bedrock
 
 import (
-	"context"
-
 	"workflow-function/shared/bedrock"
 	"workflow-function/shared/errors"
 	"workflow-function/shared/logger"
 )
 
-// Client handles Bedrock API interactions
+// Client holds the shared Bedrock client together with the Turn 1 settings.
+// Turn 1 requests are built and sent by the shared turn executor.
 type Client struct {
-	adapter *Adapter
-	config  *Config
-	logger  logger.Logger
+	sharedClient *bedrock.BedrockClient
+	config       *Config
+	logger       logger.Logger
 }
 
 // NewClient creates a new Bedrock client
 func NewClient(sharedClient *bedrock.BedrockClient, config *Config, logger logger.Logger) *Client {
-	adapter := NewAdapter(sharedClient, logger)
 	return &Client{
-		adapter: adapter,
-		config:  config,
+		sharedClient: sharedClient,
+		config:       config,
 		logger: logger.WithFields(map[string]interface{}{
 			"component": "BedrockClient",
 		}),
 	}
 }
 
-// ProcessTurn1 handles Turn 1 processing
-func (c *Client) ProcessTurn1(ctx context.Context, systemPrompt, turnPrompt, base64Image string) (*BedrockResponse, error) {
-	// Apply operational timeout boundary
-	if c.config.Timeout > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, c.config.Timeout)
-		defer cancel()
-	}
-
-	// Direct delegation to adapter - no preprocessing
-	response, latencyMs, err := c.adapter.Converse(ctx, systemPrompt, turnPrompt, base64Image, c.config)
-	if err != nil {
-		return nil, err
-	}
-
-	// Enrich with metadata
-	if response.Metadata == nil {
-		response.Metadata = make(map[string]interface{})
-	}
-	response.Metadata["latency_ms"] = latencyMs
-	response.Metadata["model_id"] = c.config.ModelID
-
-	return response, nil
+// SharedClient returns the underlying shared Bedrock client
+func (c *Client) SharedClient() *bedrock.BedrockClient {
+	return c.sharedClient
 }
 
 // ValidateConfiguration ensures operational parameters are within bounds
@@ -86,6 +64,6 @@ func (c *Client) GetOperationalMetrics() map[string]interface{} {
 		"timeout_seconds":   c.config.Timeout.Seconds(),
 		"region":            c.config.Region,
 		"anthropic_version": c.config.AnthropicVersion,
-		"architecture":      "adapter_pattern",
+		"architecture":      "turn_executor",
 	}
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"workflow-function/shared/errors"
+	"workflow-function/shared/experiments"
 )
 
 // Config bundles all environment settings required by the Lambda.
@@ -26,6 +27,8 @@ type Config struct {
 		MaxRetries               int
 		BedrockConnectTimeoutSec int
 		BedrockCallTimeoutSec    int
+		ThinkingType             string
+		ThinkingBudgetTokens     int
 	}
 	Logging struct {
 		Level  string
@@ -34,6 +37,12 @@ type Config struct {
 	Prompts struct {
 		TemplateVersion  string
 		TemplateBasePath string
+		// TemplateS3URI optionally overrides the bundled templates with
+		// templates stored in S3, e.g. s3://bucket/templates
+		TemplateS3URI string
+		// Experiments assigns verifications to prompt experiment variants,
+		// see PROMPT_EXPERIMENTS
+		Experiments *experiments.Registry
 	}
 	DatePartitionTimezone string
 }
@@ -72,12 +81,19 @@ func LoadConfiguration() (*Config, error) {
 	cfg.Processing.MaxRetries = getInt("MAX_RETRIES", 1)
 	cfg.Processing.BedrockConnectTimeoutSec = getInt("BEDROCK_CONNECT_TIMEOUT_SEC", 10)
 	cfg.Processing.BedrockCallTimeoutSec = getInt("BEDROCK_CALL_TIMEOUT_SEC", 30)
+	cfg.Processing.ThinkingType = getEnv("THINKING_TYPE", "")
+	cfg.Processing.ThinkingBudgetTokens = getInt("THINKING_BUDGET_TOKENS", 0)
 
 	cfg.Logging.Level = getEnv("LOG_LEVEL", "INFO")
 	cfg.Logging.Format = getEnv("LOG_FORMAT", "json")
 
 	cfg.Prompts.TemplateVersion = getEnv("TURN1_PROMPT_VERSION", "v1.0")
 	cfg.Prompts.TemplateBasePath = getEnv("TEMPLATE_BASE_PATH", "/opt/templates")
+	cfg.Prompts.TemplateS3URI = getEnv("TEMPLATE_S3_URI", "")
+	cfg.Prompts.Experiments, err = experiments.FromEnv()
+	if err != nil {
+		return nil, errors.NewConfigError("InvalidEnv", err.Error(), experiments.EnvPromptExperiments)
+	}
 	cfg.DatePartitionTimezone = getEnv("DATE_PARTITION_TIMEZONE", "UTC")
 
 	// Validate configuration
@@ -2,6 +2,8 @@ package config
 
 import (
 	"time"
+
+	sharedBedrock "workflow-function/shared/bedrock"
 	"workflow-function/shared/errors"
 )
 
@@ -47,5 +49,30 @@ func (c *Config) Validate() error {
 			map[string]interface{}{"current_value": c.Processing.Temperature})
 	}
 
+	// Validate extended thinking settings
+	if c.Processing.ThinkingType != "" {
+		if c.Processing.ThinkingType != sharedBedrock.ThinkingTypeEnabled {
+			return errors.NewConfigError(
+				"ThinkingTypeInvalid",
+				"thinking type must be empty or \""+sharedBedrock.ThinkingTypeEnabled+"\"",
+				"THINKING_TYPE",
+			)
+		}
+		if c.Processing.ThinkingBudgetTokens < sharedBedrock.MinThinkingBudgetTokens {
+			return errors.NewValidationError("thinking budget is below the minimum",
+				map[string]interface{}{
+					"current_value": c.Processing.ThinkingBudgetTokens,
+					"minimum":       sharedBedrock.MinThinkingBudgetTokens,
+				})
+		}
+		if c.Processing.ThinkingBudgetTokens >= c.Processing.MaxTokens {
+			return errors.NewValidationError("thinking budget must be lower than max tokens",
+				map[string]interface{}{
+					"current_value": c.Processing.ThinkingBudgetTokens,
+					"max_tokens":    c.Processing.MaxTokens,
+				})
+		}
+	}
+
 	return nil
 }
@@ -6,27 +6,28 @@ import (
 	"time"
 
 	"workflow-function/ExecuteTurn1Combined/internal/config"
-	"workflow-function/ExecuteTurn1Combined/internal/models"
-	"workflow-function/ExecuteTurn1Combined/internal/services"
 	"workflow-function/shared/errors"
 	"workflow-function/shared/logger"
 	"workflow-function/shared/schema"
+	"workflow-function/shared/turnexecutor"
 )
 
-// DynamoManager wraps DynamoDB operations used by the handler.
+// DynamoManager records the Turn1 outcome on the verification and
+// conversation records.
 type DynamoManager struct {
-	dynamo services.DynamoDBService
+	status *turnexecutor.DynamoStatus
 	log    logger.Logger
 	config config.Config
 }
 
 // NewDynamoManager creates a DynamoManager instance.
-func NewDynamoManager(dynamo services.DynamoDBService, cfg config.Config, log logger.Logger) *DynamoManager {
-	return &DynamoManager{dynamo: dynamo, log: log, config: cfg}
+func NewDynamoManager(status *turnexecutor.DynamoStatus, cfg config.Config, log logger.Logger) *DynamoManager {
+	return &DynamoManager{status: status, log: log, config: cfg}
 }
 
-// Update writes the final status and conversation turn. It returns true
-// only if both writes succeed.
+// UpdateTurn1Completion writes the completion status, metrics and artifact
+// references and appends the conversation turn. It returns true only if both
+// writes succeed.
 func (d *DynamoManager) UpdateTurn1Completion(
 	ctx context.Context,
 	verificationID string,
@@ -34,28 +35,45 @@ func (d *DynamoManager) UpdateTurn1Completion(
 	statusEntry schema.StatusHistoryEntry,
 	turnEntry *schema.TurnResponse,
 	turn1Metrics *schema.TurnMetrics,
-	processedMarkdownRef *models.S3Reference,
-	conversationRef *models.S3Reference,
+	storage *StorageResult,
 ) bool {
 	dynamoOK := true
 
-	if processedMarkdownRef != nil && processedMarkdownRef.Key != "" {
+	if storage.ReasoningRef.Key != "" {
 		if turnEntry.Metadata == nil {
 			turnEntry.Metadata = make(map[string]interface{})
 		}
-		turnEntry.Metadata["turn1ProcessedPath"] = fmt.Sprintf("s3://%s/%s", processedMarkdownRef.Bucket, processedMarkdownRef.Key)
+		turnEntry.Metadata["turn1ReasoningPath"] = fmt.Sprintf("s3://%s/%s", storage.ReasoningRef.Bucket, storage.ReasoningRef.Key)
 	}
 
-	if err := d.dynamo.UpdateVerificationStatusEnhanced(ctx, verificationID, initialVerificationAt, statusEntry); err != nil {
-		d.logEnhancedDynamoDBError(err, "UpdateVerificationStatusEnhanced", verificationID, map[string]interface{}{
-			"verificationAt": initialVerificationAt,
-			"status":         statusEntry.Status,
-			"stage":          statusEntry.Stage,
+	if storage.ProcessedRef.Key != "" {
+		if turnEntry.Metadata == nil {
+			turnEntry.Metadata = make(map[string]interface{})
+		}
+		turnEntry.Metadata["turn1ProcessedPath"] = fmt.Sprintf("s3://%s/%s", storage.ProcessedRef.Bucket, storage.ProcessedRef.Key)
+	}
+
+	if err := d.status.CompleteTurn(ctx, verificationID, initialVerificationAt, &turnexecutor.TurnCompletion{
+		Turn:            turnEntry.TurnId,
+		Status:          statusEntry,
+		Metrics:         turn1Metrics,
+		ProcessedRef:    storage.ProcessedRef,
+		ConversationRef: storage.ConversationRef,
+		ReasoningRef:    storage.ReasoningRef,
+	}); err != nil {
+		d.logEnhancedDynamoDBError(err, "CompleteTurn1", verificationID, map[string]interface{}{
+			"verificationAt":     initialVerificationAt,
+			"status":             statusEntry.Status,
+			"stage":              statusEntry.Stage,
+			"hasMetrics":         turn1Metrics != nil,
+			"hasProcessedRef":    storage.ProcessedRef.Key != "",
+			"hasConversationRef": storage.ConversationRef.Key != "",
+			"hasReasoningRef":    storage.ReasoningRef.Key != "",
 		})
 		dynamoOK = false
 	}
 
-	if err := d.dynamo.UpdateConversationTurn(ctx, verificationID, turnEntry); err != nil {
+	if err := d.status.UpdateConversationTurn(ctx, verificationID, turnEntry); err != nil {
 		d.logEnhancedDynamoDBError(err, "UpdateConversationTurn", verificationID, map[string]interface{}{
 			"turnId":    turnEntry.TurnId,
 			"stage":     turnEntry.Stage,
@@ -64,16 +82,6 @@ func (d *DynamoManager) UpdateTurn1Completion(
 		dynamoOK = false
 	}
 
-	if err := d.dynamo.UpdateTurn1CompletionDetails(ctx, verificationID, initialVerificationAt, statusEntry, turn1Metrics, processedMarkdownRef, conversationRef); err != nil {
-		d.logEnhancedDynamoDBError(err, "UpdateTurn1CompletionDetails", verificationID, map[string]interface{}{
-			"verificationAt":        initialVerificationAt,
-			"hasMetrics":           turn1Metrics != nil,
-			"hasProcessedRef":      processedMarkdownRef != nil,
-			"hasConversationRef":   conversationRef != nil,
-		})
-		dynamoOK = false
-	}
-
 	return dynamoOK
 }
 
@@ -144,7 +152,7 @@ func (d *DynamoManager) logEnhancedDynamoDBError(err error, operation string, ve
 // getTableNameForOperation returns the appropriate table name for the given operation
 func (d *DynamoManager) getTableNameForOperation(operation string) string {
 	switch operation {
-	case "UpdateVerificationStatusEnhanced", "UpdateTurn1CompletionDetails":
+	case "CompleteTurn1":
 		return d.config.AWS.DynamoDBVerificationTable
 	case "UpdateConversationTurn":
 		return d.config.AWS.DynamoDBConversationTable
@@ -4,20 +4,20 @@ package handler
 import (
 	"context"
 	"workflow-function/ExecuteTurn1Combined/internal/models"
-	"workflow-function/ExecuteTurn1Combined/internal/services"
 	"workflow-function/shared/errors"
 	"workflow-function/shared/logger"
 	"workflow-function/shared/schema"
+	"workflow-function/shared/turnexecutor"
 )
 
 // EventTransformer orchestrates Step Functions event transformation with strategic schema integration
 type EventTransformer struct {
-	s3  services.S3StateManager
+	s3  *turnexecutor.S3Store
 	log logger.Logger
 }
 
 // NewEventTransformer creates a strategically enhanced event transformer
-func NewEventTransformer(s3 services.S3StateManager, log logger.Logger) *EventTransformer {
+func NewEventTransformer(s3 *turnexecutor.S3Store, log logger.Logger) *EventTransformer {
 	return &EventTransformer{
 		s3:  s3,
 		log: log,
@@ -273,6 +273,7 @@ func convertSchemaToLocalVerificationContext(schemaCtx schema.VerificationContex
 		VendingMachineId:  schemaCtx.VendingMachineId,
 		LayoutId:          schemaCtx.LayoutId,
 		LayoutPrefix:      schemaCtx.LayoutPrefix,
+		Locale:            schemaCtx.Locale,
 		LayoutMetadata:    extractLayoutMetadataMap(layoutMetadata),
 		HistoricalContext: extractHistoricalContextMap(schemaCtx),
 	}
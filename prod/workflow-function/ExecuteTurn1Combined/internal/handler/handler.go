@@ -15,26 +15,23 @@ import (
 	"workflow-function/shared/errors"
 	"workflow-function/shared/logger"
 	"workflow-function/shared/schema"
+	"workflow-function/shared/turnexecutor"
 )
 
 // Handler orchestrates the ExecuteTurn1Combined workflow with enhanced tracking and observability.
 type Handler struct {
-	cfg           config.Config
-	s3            services.S3StateManager
-	bedrock       services.BedrockService
-	dynamo        services.DynamoDBService
-	promptService services.PromptService
-	log           logger.Logger
+	cfg      config.Config
+	store    *turnexecutor.S3Store
+	status   *turnexecutor.DynamoStatus
+	executor *turnexecutor.Executor
+	locales  turnexecutor.LocaleResolver
+	log      logger.Logger
 
 	// Components for better code organization
 	processingTracker *ProcessingStagesTracker
-	statusTracker     *StatusTracker
 	responseBuilder   *ResponseBuilder
 	eventTransformer  *EventTransformer
-	promptGenerator   *PromptGenerator
-	contextLoader     *ContextLoader
 	historicalLoader  *HistoricalContextLoader
-	bedrockInvoker    *BedrockInvoker
 	storageManager    *StorageManager
 	dynamoManager     *DynamoManager
 	validator         *Validator
@@ -43,256 +40,101 @@ type Handler struct {
 
 // NewHandler wires together all dependencies for the Lambda with enhanced capabilities.
 func NewHandler(
-	s3Mgr services.S3StateManager,
-	bedrockClient services.BedrockService,
-	dynamoClient services.DynamoDBService,
-	promptGen services.PromptService,
+	store *turnexecutor.S3Store,
+	bedrockService services.BedrockService,
+	renderer turnexecutor.Renderer,
+	status *turnexecutor.DynamoStatus,
 	log logger.Logger,
 	cfg *config.Config,
 ) (*Handler, error) {
+	locales, _ := renderer.(turnexecutor.LocaleResolver)
 	return &Handler{
 		cfg:              *cfg,
-		s3:               s3Mgr,
-		bedrock:          bedrockClient,
-		dynamo:           dynamoClient,
-		promptService:    promptGen,
+		store:            store,
+		status:           status,
+		executor:         turnexecutor.New(bedrockService.TurnConverser(), renderer, nil, log),
+		locales:          locales,
 		log:              log,
 		responseBuilder:  NewResponseBuilder(*cfg),
-		eventTransformer: NewEventTransformer(s3Mgr, log),
-		promptGenerator:  NewPromptGenerator(promptGen, *cfg),
-		contextLoader:    NewContextLoader(s3Mgr, log),
-		historicalLoader: NewHistoricalContextLoader(s3Mgr, dynamoClient, log),
-		bedrockInvoker:   NewBedrockInvoker(bedrockClient, *cfg, log),
-		storageManager:   NewStorageManager(s3Mgr, *cfg, log),
-		dynamoManager:    NewDynamoManager(dynamoClient, *cfg, log),
+		eventTransformer: NewEventTransformer(store, log),
+		historicalLoader: NewHistoricalContextLoader(store, log),
+		storageManager:   NewStorageManager(store, *cfg, log),
+		dynamoManager:    NewDynamoManager(status, *cfg, log),
 		validator:        NewValidator(),
 	}, nil
 }
 
-// Handle executes a single Turn-1 verification cycle with comprehensive tracking.
-func (h *Handler) Handle(ctx context.Context, req *models.Turn1Request) (resp *schema.CombinedTurnResponse, err error) {
-	h.startTime = time.Now()
-	h.processingTracker = NewProcessingStagesTracker(h.startTime)
-	h.statusTracker = NewStatusTracker(h.startTime)
+// turn1Outcome holds the results the Turn1 responses are built from
+type turn1Outcome struct {
+	result        *turnexecutor.Result
+	promptRef     models.S3Reference
+	rawRef        models.S3Reference
+	storage       *StorageResult
+	totalDuration time.Duration
+	dynamoOK      bool
+}
 
-	// Initialize processing metrics
-	processingMetrics := h.initializeProcessingMetrics()
-	// Create context logger
+// Handle executes a single Turn-1 verification cycle with comprehensive tracking.
+func (h *Handler) Handle(ctx context.Context, req *models.Turn1Request) (*schema.CombinedTurnResponse, error) {
 	contextLogger := h.createContextLogger(req)
-	defer func() {
-		finalStatus := schema.StatusTurn1Completed
-		if err != nil {
-			if wfErr, ok := err.(*errors.WorkflowError); ok {
-				finalStatus = string(wfErr.Type)
-			} else {
-				finalStatus = schema.StatusTurn1Error
-			}
-		}
-		h.updateInitializationFile(ctx, req, finalStatus, contextLogger)
-	}()
-	// Validate request
-	if err := h.validator.ValidateRequest(req); err != nil {
-		h.processingTracker.RecordStage("validation", "failed", time.Since(h.startTime), map[string]interface{}{
-			"validation_error": err.Error(),
-		})
-		return nil, err
-	}
-	h.processingTracker.RecordStage("validation", "completed", time.Since(h.startTime), nil)
-
-	// Update initial status
-	h.updateStatus(ctx, req.VerificationID, schema.StatusTurn1Started, "initialization", map[string]interface{}{
-		"function_start_time": schema.FormatISO8601(),
-		"verification_type":   req.VerificationContext.VerificationType,
-	})
-
-	// STAGE 1: Load context (system prompt & base64 image)
-	loadResult := h.contextLoader.LoadContext(ctx, req)
-	if loadResult.Error != nil {
-		return h.handleContextLoadError(ctx, req.VerificationID, loadResult, contextLogger)
-	}
-	h.recordContextLoadSuccess(ctx, req.VerificationID, loadResult)
 
-	// STAGE 2: Load historical context if applicable
-	historicalDuration, _ := h.historicalLoader.LoadHistoricalContext(ctx, req, contextLogger)
-	if historicalDuration > 0 {
-		h.processingTracker.RecordStage("historical_context_loading", "completed", historicalDuration, map[string]interface{}{
-			"has_historical_context": req.VerificationContext.HistoricalContext != nil,
-		})
+	outcome, err := h.run(ctx, req, contextLogger)
+	if err != nil {
+		return nil, err
 	}
 
-	// STAGE 3: Generate prompt
-	promptResult := h.generatePrompt(ctx, req, loadResult.SystemPrompt)
-	if promptResult.Error != nil {
-		return h.handlePromptError(ctx, req.VerificationID, promptResult, contextLogger)
-	}
+	// Build response with all required fields for schema v2.1.0
+	response := h.responseBuilder.BuildCombinedTurnResponse(
+		req, outcome.result.Prompt, outcome.promptRef, outcome.rawRef, outcome.storage.ProcessedRef, outcome.storage.ConversationRef,
+		outcome.result, h.processingTracker.GetStages(), outcome.totalDuration.Milliseconds(),
+		outcome.result.LatencyMs, outcome.dynamoOK,
+	)
 
-	// Record prompt generation success
-	h.processingTracker.RecordStage("prompt_generation", "completed", promptResult.Duration, map[string]interface{}{
-		"template_version": h.cfg.Prompts.TemplateVersion,
-		"prompt_length":    len(promptResult.Prompt),
-		"template_used":    "turn1-combined",
-	})
+	// Validate and log completion
+	h.validateAndLogCompletion(response, outcome.totalDuration, outcome.result, contextLogger)
 
-	h.updateStatus(ctx, req.VerificationID, schema.StatusTurn1PromptPrepared, "prompt_generation", map[string]interface{}{
-		"prompt_length":          len(promptResult.Prompt),
-		"template_version":       h.cfg.Prompts.TemplateVersion,
-		"generation_duration_ms": promptResult.Duration.Milliseconds(),
-	})
+	return response, nil
+}
 
-	// STAGE 4: Invoke Bedrock
-	h.updateStatus(ctx, req.VerificationID, schema.StatusTurn1BedrockInvoked, "bedrock_invocation", map[string]interface{}{
-		"model_id":        h.cfg.AWS.BedrockModel,
-		"max_tokens":      h.cfg.Processing.MaxTokens,
-		"invocation_time": schema.FormatISO8601(),
+// HandleForStepFunction processes Turn1 and returns StepFunctionResponse
+func (h *Handler) HandleForStepFunction(ctx context.Context, req *models.Turn1Request) (*models.StepFunctionResponse, error) {
+	contextLogger := h.createContextLogger(req)
+	contextLogger.Info("Starting ExecuteTurn1Combined", map[string]interface{}{
+		"verification_type": req.VerificationContext.VerificationType,
+		"layout_id":         req.VerificationContext.LayoutId,
+		"schema_version":    h.validator.GetSchemaVersion(),
 	})
 
-	invokeResult := h.bedrockInvoker.InvokeBedrock(ctx, loadResult.SystemPrompt, promptResult.Prompt, loadResult.Base64Image, req.VerificationID)
-	if invokeResult.Error != nil {
-		return h.handleBedrockError(ctx, req.VerificationID, invokeResult)
-	}
-	h.recordBedrockSuccess(ctx, req.VerificationID, invokeResult, promptResult.TemplateProcessor)
-
-	var bedrockTextOutput string
-	if processedMap, ok := invokeResult.Response.Processed.(map[string]interface{}); ok {
-		if contentStr, ok := processedMap["content"].(string); ok {
-			bedrockTextOutput = contentStr
-		}
-	}
-	if bedrockTextOutput == "" {
-		var rawResp struct {
-			Content string `json:"content"`
-		}
-		_ = json.Unmarshal(invokeResult.Response.Raw, &rawResp)
-		bedrockTextOutput = rawResp.Content
-	}
-	parsedTurn1Data, parseErr := bedrockparser.ParseBedrockResponseAsMarkdown(bedrockTextOutput)
-	if parseErr != nil {
-		contextLogger.Warn("failed to parse bedrock response", map[string]interface{}{
-			"error": parseErr.Error(),
-		})
-	}
-
-	// STAGE 5: Store responses
-	h.updateStatus(ctx, req.VerificationID, schema.StatusTurn1ResponseProcessing, "response_processing", nil)
-
-	storageResult := h.storageManager.StoreResponses(ctx, req, invokeResult, promptResult, len(loadResult.Base64Image), parsedTurn1Data)
-	if storageResult.Error != nil {
-		return nil, storageResult.Error
-	}
-	h.recordStorageSuccess(storageResult)
-
-	// Store conversation with complete schema compliance
-	convRef, convErr := h.storageManager.StoreConversation(ctx, req.VerificationID, loadResult.SystemPrompt, promptResult.Prompt, loadResult.Base64Image, req.S3Refs.Images.ReferenceBase64, bedrockTextOutput, &invokeResult.Response.TokenUsage, invokeResult.Duration.Milliseconds(), invokeResult.Response.RequestID, h.cfg.AWS.BedrockModel, invokeResult.Response.Metadata)
-	if convErr != nil {
-		h.log.Warn("failed to store conversation", map[string]interface{}{
-			"error": convErr.Error(),
-		})
-	}
-
-	// STAGE 6: Store prompt with enhanced error handling
-	promptStart := time.Now()
-	promptRef, err := h.storageManager.StorePrompt(ctx, req, 1, promptResult)
+	outcome, err := h.run(ctx, req, contextLogger)
 	if err != nil {
-		enhancedErr := errors.WrapError(err, errors.ErrorTypeS3,
-			"failed to store prompt", true).
-			WithContext("verification_id", req.VerificationID).
-			WithContext("turn", 1).
-			WithContext("prompt_size", len(promptResult.Prompt)).
-			WithComponent("StorageManager").
-			WithOperation("StorePrompt").
-			WithCategory(errors.CategoryTransient).
-			WithRetryStrategy(errors.RetryExponential).
-			SetMaxRetries(3).
-			WithSeverity(errors.ErrorSeverityMedium).
-			WithSuggestions(
-				"Check S3 bucket permissions and connectivity",
-				"Verify S3 bucket exists and is accessible",
-				"Ensure sufficient S3 storage capacity",
-				"Check network connectivity to S3 service",
-			).
-			WithRecoveryHints(
-				"Retry the operation with exponential backoff",
-				"Check S3 service health status",
-				"Verify IAM permissions for S3 operations",
-			)
-		return nil, enhancedErr
-	}
-
-	// Record prompt storage success
-	h.processingTracker.RecordStage("prompt_storage", "completed", time.Since(promptStart), map[string]interface{}{
-		"prompt_length":  len(promptResult.Prompt),
-		"prompt_ref_key": promptRef.Key,
-	})
-
-	// STAGE 7: Update metrics and async operations
-	totalDuration := time.Since(h.startTime)
-	h.updateProcessingMetrics(processingMetrics, totalDuration, invokeResult)
-
-	// Prepare DynamoDB entries
-	statusEntry := schema.StatusHistoryEntry{
-		Status:           schema.StatusTurn1Completed,
-		Timestamp:        schema.FormatISO8601(),
-		FunctionName:     "ExecuteTurn1Combined",
-		ProcessingTimeMs: totalDuration.Milliseconds(),
-		Stage:            "turn1_completion",
-	}
-
-	turnEntry := &schema.TurnResponse{
-		TurnId:     1,
-		Timestamp:  schema.FormatISO8601(),
-		Prompt:     promptResult.Prompt,
-		ImageUrls:  map[string]string{
-			"reference": req.S3Refs.Images.ReferenceBase64.Key,
-		},
-		Response:   schema.BedrockApiResponse{RequestId: invokeResult.Response.RequestID},
-		LatencyMs:  invokeResult.Duration.Milliseconds(),
-		TokenUsage: &invokeResult.Response.TokenUsage,
-		Stage:      "REFERENCE_ANALYSIS",
-		Metadata: map[string]interface{}{
-			"model_id":        h.cfg.AWS.BedrockModel,
-			"verification_id": req.VerificationID,
-			"function_name":   "ExecuteTurn1Combined",
-		},
-	}
-
-	var turn1MetricsForDB *schema.TurnMetrics = nil
-	if processingMetrics != nil && processingMetrics.Turn1 != nil {
-		turn1MetricsForDB = processingMetrics.Turn1
+		return nil, err
 	}
 
-	// Perform DynamoDB updates synchronously
-	dynamoOK := h.dynamoManager.UpdateTurn1Completion(ctx, req.VerificationID, req.VerificationContext.VerificationAt, statusEntry, turnEntry, turn1MetricsForDB, &storageResult.ProcessedRef, &convRef)
+	// Build Step Function response
+	stepFunctionResponse := h.responseBuilder.BuildStepFunctionResponse(
+		req, outcome.promptRef, outcome.rawRef, outcome.storage.ProcessedRef, outcome.storage.ConversationRef,
+		outcome.result, outcome.totalDuration.Milliseconds(), outcome.result.LatencyMs, outcome.dynamoOK,
+	)
 
-	// Final status update
-	h.updateStatus(ctx, req.VerificationID, schema.StatusTurn1Completed, "completion", map[string]interface{}{
-		"total_duration_ms": totalDuration.Milliseconds(),
+	// Log completion
+	contextLogger.Info("Completed ExecuteTurn1Combined", map[string]interface{}{
+		"duration_ms":       outcome.totalDuration.Milliseconds(),
 		"processing_stages": h.processingTracker.GetStageCount(),
-		"status_updates":    h.statusTracker.GetHistoryCount(),
-		"dynamodb_updated":  dynamoOK,
+		"schema_version":    h.validator.GetSchemaVersion(),
+		"verification_id":   req.VerificationID,
+		"status":            schema.StatusTurn1Completed,
 	})
 
-	// Build response with all required fields for schema v2.1.0
-	response := h.responseBuilder.BuildCombinedTurnResponse(
-		req, promptResult.Prompt, promptRef, storageResult.RawRef, storageResult.ProcessedRef, convRef,
-		invokeResult.Response, h.processingTracker.GetStages(), totalDuration.Milliseconds(),
-		invokeResult.Duration.Milliseconds(), dynamoOK,
-	)
-
-	// Validate and log completion
-	h.validateAndLogCompletion(response, totalDuration, invokeResult.Response, contextLogger)
-
-	return response, nil
+	return stepFunctionResponse, nil
 }
 
-// HandleForStepFunction processes Turn1 and returns StepFunctionResponse
-func (h *Handler) HandleForStepFunction(ctx context.Context, req *models.Turn1Request) (resp *models.StepFunctionResponse, err error) {
-	// Initialize tracking
+// run executes Turn1 through the shared turn executor and records its
+// outcome. The final status is written back to initialization.json.
+func (h *Handler) run(ctx context.Context, req *models.Turn1Request, contextLogger logger.Logger) (outcome *turn1Outcome, err error) {
 	h.startTime = time.Now()
 	h.processingTracker = NewProcessingStagesTracker(h.startTime)
-	h.statusTracker = NewStatusTracker(h.startTime)
-
 	processingMetrics := h.initializeProcessingMetrics()
-	contextLogger := h.createContextLogger(req)
+
 	defer func() {
 		finalStatus := schema.StatusTurn1Completed
 		if err != nil {
@@ -304,98 +146,92 @@ func (h *Handler) HandleForStepFunction(ctx context.Context, req *models.Turn1Re
 		}
 		h.updateInitializationFile(ctx, req, finalStatus, contextLogger)
 	}()
-	contextLogger.Info("Starting ExecuteTurn1Combined", map[string]interface{}{
-		"verification_type": req.VerificationContext.VerificationType,
-		"layout_id":         req.VerificationContext.LayoutId,
-		"schema_version":    h.validator.GetSchemaVersion(),
-	})
 
 	// STAGE 1: Validation
-	h.processingTracker.RecordStage("validation", "started", 0, nil)
 	if err := h.validator.ValidateRequest(req); err != nil {
 		contextLogger.Error("input validation error", map[string]interface{}{
 			"validation_error": err.Error(),
 		})
-		h.processingTracker.RecordStage("validation", "failed", 0, map[string]interface{}{
-			"error": err.Error(),
+		h.processingTracker.RecordStage("validation", "failed", time.Since(h.startTime), map[string]interface{}{
+			"validation_error": err.Error(),
 		})
-		h.updateStatus(ctx, req.VerificationID, schema.StatusTurn1Error, "validation_failed", map[string]interface{}{
+		h.updateStatus(ctx, req, schema.StatusTurn1Error, map[string]interface{}{
 			"error_details": err.Error(),
 		})
 		return nil, err
 	}
-	h.processingTracker.RecordStage("validation", "completed", 0, nil)
+	h.processingTracker.RecordStage("validation", "completed", time.Since(h.startTime), nil)
 
-	// Update status to Turn1Started
-	h.updateStatus(ctx, req.VerificationID, schema.StatusTurn1Started, "turn1_start", map[string]interface{}{
-		"function_name": "ExecuteTurn1Combined",
+	h.updateStatus(ctx, req, schema.StatusTurn1Started, map[string]interface{}{
+		"function_name":     "ExecuteTurn1Combined",
+		"verification_type": req.VerificationContext.VerificationType,
 	})
 
-	// STAGE 2: Load context (system prompt and images)
-	contextLogger.Info("Loading context", map[string]interface{}{
-		"system_prompt_key": req.S3Refs.Prompts.System.Key,
-		"reference_image":   req.S3Refs.Images.ReferenceBase64.Key,
-	})
+	// STAGE 2: Load the system prompt; the reference image is loaded by the executor
+	loadStart := time.Now()
+	systemPrompt, err := h.store.LoadSystemPrompt(ctx, req.S3Refs.Prompts.System)
+	if err != nil {
+		return nil, h.handleContextLoadError(ctx, req, err, time.Since(loadStart), contextLogger)
+	}
+	h.recordContextLoadSuccess(ctx, req, systemPrompt, time.Since(loadStart))
 
-	loadResult := h.contextLoader.LoadContext(ctx, req)
-	if loadResult.Error != nil {
-		h.handleContextLoadError(ctx, req.VerificationID, loadResult, contextLogger)
-		return nil, loadResult.Error
+	// STAGE 3: Load historical context if applicable
+	historicalDuration, _ := h.historicalLoader.LoadHistoricalContext(ctx, req, contextLogger)
+	if historicalDuration > 0 {
+		h.processingTracker.RecordStage("historical_context_loading", "completed", historicalDuration, map[string]interface{}{
+			"has_historical_context": req.VerificationContext.HistoricalContext != nil,
+		})
 	}
-	h.recordContextLoadSuccess(ctx, req.VerificationID, loadResult)
 
-	// STAGE 3: Generate prompt
-	promptResult := h.generatePrompt(ctx, req, loadResult.SystemPrompt)
-	if promptResult.Error != nil {
-		h.handlePromptError(ctx, req.VerificationID, promptResult, contextLogger)
-		return nil, promptResult.Error
+	// STAGE 4: Build the template data and execute the turn
+	promptStart := time.Now()
+	// Render the template of the verification's locale when it has the version
+	spec := h.assignExperiment(req, turn1Spec(h.cfg, req.VerificationContext.VerificationType)).
+		WithLocale(h.locales, req.VerificationContext.Locale)
+	templateData, err := turn1TemplateData(req.VerificationContext, systemPrompt, spec.TemplateVersion)
+	if err != nil {
+		return nil, h.handlePromptError(ctx, req, err, time.Since(promptStart), contextLogger)
 	}
 
-	h.processingTracker.RecordStage("prompt_generation", "completed", promptResult.Duration, map[string]interface{}{
-		"prompt_length":    len(promptResult.Prompt),
-		"template_used":    h.promptGenerator.GetTemplateUsed(req.VerificationContext),
-		"template_version": h.cfg.Prompts.TemplateVersion,
-	})
+	requestStore := h.store.ForRequest().
+		WithImage(turnexecutor.ImageRoleReference, req.S3Refs.Images.ReferenceBase64, "").
+		WithPrompt(spec.Turn, turn1PromptInfo(req, spec.TemplateVersion))
+
+	result, err := h.executor.
+		WithStore(requestStore).
+		WithStatus(h.status.ForVerification(req.VerificationContext.VerificationAt)).
+		Execute(ctx, spec, &turnexecutor.Input{
+			VerificationID: req.VerificationID,
+			SystemPrompt:   systemPrompt,
+			TemplateData:   templateData,
+			Metadata: map[string]interface{}{
+				"model_id":        spec.ModelID,
+				"verification_id": req.VerificationID,
+				"function_name":   "ExecuteTurn1Combined",
+			},
+		})
+	if err != nil {
+		return nil, h.handleTurnError(ctx, req, err, time.Since(promptStart), contextLogger)
+	}
 
-	h.updateStatus(ctx, req.VerificationID, schema.StatusTurn1PromptPrepared, "prompt_prepared", map[string]interface{}{
-		"prompt_size":      len(promptResult.Prompt),
-		"template_version": h.cfg.Prompts.TemplateVersion,
+	h.processingTracker.RecordStage("prompt_generation", "completed", result.RenderDuration, map[string]interface{}{
+		"template_version": spec.TemplateVersion,
+		"prompt_length":    len(result.Prompt),
+		"template_used":    spec.TemplateType,
 	})
+	h.recordBedrockSuccess(ctx, req, result)
 
-	// STAGE 4: Invoke Bedrock
-	invokeResult := h.bedrockInvoker.InvokeBedrock(ctx, loadResult.SystemPrompt, promptResult.Prompt, loadResult.Base64Image, req.VerificationID)
-	if invokeResult.Error != nil {
-		h.handleBedrockError(ctx, req.VerificationID, invokeResult)
-		return nil, invokeResult.Error
-	}
-	h.recordBedrockSuccess(ctx, req.VerificationID, invokeResult, promptResult.TemplateProcessor)
-
-	var bedrockTextOutput string
-	if processedMap, ok := invokeResult.Response.Processed.(map[string]interface{}); ok {
-		if contentStr, ok := processedMap["content"].(string); ok {
-			bedrockTextOutput = contentStr
-		}
-	}
-	if bedrockTextOutput == "" {
-		var rawResp struct {
-			Content string `json:"content"`
-		}
-		_ = json.Unmarshal(invokeResult.Response.Raw, &rawResp)
-		bedrockTextOutput = rawResp.Content
-	}
-	parsedTurn1Data, parseErr := bedrockparser.ParseBedrockResponseAsMarkdown(bedrockTextOutput)
+	parsedTurn1Data, parseErr := bedrockparser.ParseBedrockResponseAsMarkdown(result.Content)
 	if parseErr != nil {
-		contextLogger.Warn("failed to parse bedrock response", map[string]interface{}{"error": parseErr.Error()})
+		contextLogger.Warn("failed to parse bedrock response", map[string]interface{}{
+			"error": parseErr.Error(),
+		})
 	}
 
-	// Store conversation with complete schema compliance
-	convRef, convErr := h.storageManager.StoreConversation(ctx, req.VerificationID, loadResult.SystemPrompt, promptResult.Prompt, loadResult.Base64Image, req.S3Refs.Images.ReferenceBase64, bedrockTextOutput, &invokeResult.Response.TokenUsage, invokeResult.Duration.Milliseconds(), invokeResult.Response.RequestID, h.cfg.AWS.BedrockModel, invokeResult.Response.Metadata)
-	if convErr != nil {
-		return nil, convErr
-	}
+	// STAGE 5: Store the processed analysis, reasoning and conversation
+	h.updateStatus(ctx, req, schema.StatusTurn1ResponseProcessing, nil)
 
-	// STAGE 5: Store response and processed analysis
-	storageResult := h.storageManager.StoreResponses(ctx, req, invokeResult, promptResult, len(loadResult.Base64Image), parsedTurn1Data)
+	storageResult := h.storageManager.StoreResults(ctx, req, result, systemPrompt, parsedTurn1Data)
 	if storageResult.Error != nil {
 		contextLogger.Error("storage error", map[string]interface{}{
 			"error": storageResult.Error.Error(),
@@ -404,46 +240,10 @@ func (h *Handler) HandleForStepFunction(ctx context.Context, req *models.Turn1Re
 	}
 	h.recordStorageSuccess(storageResult)
 
-	// STAGE 6: Store prompt with enhanced error handling
-	promptStart := time.Now()
-	promptRef, err := h.storageManager.StorePrompt(ctx, req, 1, promptResult)
-	if err != nil {
-		enhancedErr := errors.WrapError(err, errors.ErrorTypeS3,
-			"failed to store prompt", true).
-			WithContext("verification_id", req.VerificationID).
-			WithContext("turn", 1).
-			WithContext("prompt_size", len(promptResult.Prompt)).
-			WithComponent("StorageManager").
-			WithOperation("StorePrompt").
-			WithCategory(errors.CategoryTransient).
-			WithRetryStrategy(errors.RetryExponential).
-			SetMaxRetries(3).
-			WithSeverity(errors.ErrorSeverityMedium).
-			WithSuggestions(
-				"Check S3 bucket permissions and connectivity",
-				"Verify S3 bucket exists and is accessible",
-				"Ensure sufficient S3 storage capacity",
-				"Check network connectivity to S3 service",
-			).
-			WithRecoveryHints(
-				"Retry the operation with exponential backoff",
-				"Check S3 service health status",
-				"Verify IAM permissions for S3 operations",
-			)
-		return nil, enhancedErr
-	}
-
-	// Record prompt storage success
-	h.processingTracker.RecordStage("prompt_storage", "completed", time.Since(promptStart), map[string]interface{}{
-		"prompt_length":  len(promptResult.Prompt),
-		"prompt_ref_key": promptRef.Key,
-	})
-
-	// STAGE 7: Update metrics and async operations
+	// STAGE 6: Update metrics and record the completion
 	totalDuration := time.Since(h.startTime)
-	h.updateProcessingMetrics(processingMetrics, totalDuration, invokeResult)
+	h.updateProcessingMetrics(processingMetrics, totalDuration, result)
 
-	// Prepare DynamoDB entries
 	statusEntry := schema.StatusHistoryEntry{
 		Status:           schema.StatusTurn1Completed,
 		Timestamp:        schema.FormatISO8601(),
@@ -452,64 +252,23 @@ func (h *Handler) HandleForStepFunction(ctx context.Context, req *models.Turn1Re
 		Stage:            "turn1_completion",
 	}
 
-	turnEntry := &schema.TurnResponse{
-		TurnId:     1,
-		Timestamp:  schema.FormatISO8601(),
-		Prompt:     promptResult.Prompt,
-		ImageUrls:  map[string]string{
-			"reference": req.S3Refs.Images.ReferenceBase64.Key,
-		},
-		Response:   schema.BedrockApiResponse{RequestId: invokeResult.Response.RequestID},
-		LatencyMs:  invokeResult.Duration.Milliseconds(),
-		TokenUsage: &invokeResult.Response.TokenUsage,
-		Stage:      "REFERENCE_ANALYSIS",
-		Metadata: map[string]interface{}{
-			"model_id":        h.cfg.AWS.BedrockModel,
-			"verification_id": req.VerificationID,
-			"function_name":   "ExecuteTurn1Combined",
-		},
-	}
-
-	var turn1MetricsForDB *schema.TurnMetrics = nil
-	if processingMetrics != nil && processingMetrics.Turn1 != nil {
-		turn1MetricsForDB = processingMetrics.Turn1
-	}
-
-	// Store conversation with complete schema compliance (second call for step function)
-	convRef, convErr = h.storageManager.StoreConversation(ctx, req.VerificationID, loadResult.SystemPrompt, promptResult.Prompt, loadResult.Base64Image, req.S3Refs.Images.ReferenceBase64, bedrockTextOutput, &invokeResult.Response.TokenUsage, invokeResult.Duration.Milliseconds(), invokeResult.Response.RequestID, h.cfg.AWS.BedrockModel, invokeResult.Response.Metadata)
-	if convErr != nil {
-		h.log.Warn("failed to store conversation", map[string]interface{}{
-			"error": convErr.Error(),
-		})
+	turnEntry := *result.Turn
+	turnEntry.Metadata = map[string]interface{}{}
+	for k, v := range result.Turn.Metadata {
+		turnEntry.Metadata[k] = v
 	}
 
-	dynamoOK := h.dynamoManager.UpdateTurn1Completion(ctx, req.VerificationID, req.VerificationContext.VerificationAt, statusEntry, turnEntry, turn1MetricsForDB, &storageResult.ProcessedRef, &convRef)
-
-	// Final status update
-	h.updateStatus(ctx, req.VerificationID, schema.StatusTurn1Completed, "completion", map[string]interface{}{
-		"total_duration_ms": totalDuration.Milliseconds(),
-		"processing_stages": h.processingTracker.GetStageCount(),
-		"status_updates":    h.statusTracker.GetHistoryCount(),
-		"dynamodb_updated":  dynamoOK,
-	})
-
-	// Build Step Function response
-	stepFunctionResponse := h.responseBuilder.BuildStepFunctionResponse(
-		req, promptRef, storageResult.RawRef, storageResult.ProcessedRef, convRef,
-		invokeResult.Response, totalDuration.Milliseconds(), invokeResult.Duration.Milliseconds(), dynamoOK,
-	)
+	dynamoOK := h.dynamoManager.UpdateTurn1Completion(ctx, req.VerificationID, req.VerificationContext.VerificationAt, statusEntry, &turnEntry, processingMetrics.Turn1, storageResult)
+	h.recordExperimentAssignments(ctx, req, result)
 
-	// Log completion
-	contextLogger.Info("Completed ExecuteTurn1Combined", map[string]interface{}{
-		"duration_ms":       totalDuration.Milliseconds(),
-		"processing_stages": h.processingTracker.GetStageCount(),
-		"status_updates":    h.statusTracker.GetHistoryCount(),
-		"schema_version":    h.validator.GetSchemaVersion(),
-		"verification_id":   req.VerificationID,
-		"status":            schema.StatusTurn1Completed,
-	})
-
-	return stepFunctionResponse, nil
+	return &turn1Outcome{
+		result:        result,
+		promptRef:     requestStore.PromptRef(spec.Turn),
+		rawRef:        requestStore.TurnRef(spec.Turn),
+		storage:       storageResult,
+		totalDuration: totalDuration,
+		dynamoOK:      dynamoOK,
+	}, nil
 }
 
 // HandleTurn1Combined is the Lambda entrypoint invoked by Step Functions.
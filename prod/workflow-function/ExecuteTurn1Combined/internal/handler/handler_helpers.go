@@ -6,8 +6,10 @@ import (
 	"time"
 	"workflow-function/ExecuteTurn1Combined/internal/models"
 	"workflow-function/shared/errors"
+	"workflow-function/shared/experiments"
 	"workflow-function/shared/logger"
 	"workflow-function/shared/schema"
+	"workflow-function/shared/turnexecutor"
 )
 
 // initializeProcessingMetrics creates initial processing metrics
@@ -34,9 +36,10 @@ func (h *Handler) createContextLogger(req *models.Turn1Request) logger.Logger {
 	})
 }
 
-// updateStatus updates status with error handling
-func (h *Handler) updateStatus(ctx context.Context, verificationID, status, stage string, metadata map[string]interface{}) {
-	if err := h.statusTracker.UpdateStatusWithHistory(ctx, verificationID, status, stage, metadata); err != nil {
+// updateStatus records the current status on the verification record. Failures
+// are logged and do not fail the turn.
+func (h *Handler) updateStatus(ctx context.Context, req *models.Turn1Request, status string, metadata map[string]interface{}) {
+	if err := h.status.UpdateCurrentStatus(ctx, req.VerificationID, req.VerificationContext.VerificationAt, status, metadata); err != nil {
 		h.log.Warn("failed to update status", map[string]interface{}{
 			"error":  err.Error(),
 			"status": status,
@@ -44,20 +47,21 @@ func (h *Handler) updateStatus(ctx context.Context, verificationID, status, stag
 	}
 }
 
-// handleContextLoadError handles errors during context loading with enhanced error details
-func (h *Handler) handleContextLoadError(ctx context.Context, verificationID string, loadResult *LoadResult, contextLogger logger.Logger) (*schema.CombinedTurnResponse, error) {
-	h.processingTracker.RecordStage("context_loading", "failed", loadResult.Duration, map[string]interface{}{
-		"s3_operations": 2,
-		"error_type":    "s3_retrieval_failure",
+// handleContextLoadError handles errors while loading the system prompt or
+// reference image with enhanced error details
+func (h *Handler) handleContextLoadError(ctx context.Context, req *models.Turn1Request, loadErr error, duration time.Duration, contextLogger logger.Logger) error {
+	verificationID := req.VerificationID
+	h.processingTracker.RecordStage("context_loading", "failed", duration, map[string]interface{}{
+		"error_type": "s3_retrieval_failure",
 	})
 
-	h.updateStatus(ctx, verificationID, schema.StatusTurn1Error, "context_loading_failed", map[string]interface{}{
-		"error_details": loadResult.Error.Error(),
+	h.updateStatus(ctx, req, schema.StatusTurn1Error, map[string]interface{}{
+		"error_details": loadErr.Error(),
 	})
 
 	// Enhanced error handling with detailed context
 	var enhancedErr *errors.WorkflowError
-	if workflowErr, ok := loadResult.Error.(*errors.WorkflowError); ok {
+	if workflowErr, ok := loadErr.(*errors.WorkflowError); ok {
 		enhancedErr = workflowErr.
 			WithVerificationID(verificationID).
 			WithComponent("ContextLoader").
@@ -89,10 +93,9 @@ func (h *Handler) handleContextLoadError(ctx context.Context, verificationID str
 			"operation":      enhancedErr.Operation,
 			"suggestions":    enhancedErr.Suggestions,
 			"recovery_hints": enhancedErr.RecoveryHints,
-			"s3_operations":  2,
 		})
 	} else {
-		enhancedErr = errors.WrapError(loadResult.Error, errors.ErrorTypeS3,
+		enhancedErr = errors.WrapError(loadErr, errors.ErrorTypeS3,
 			"context loading failed", true).
 			WithVerificationID(verificationID).
 			WithComponent("ContextLoader").
@@ -103,63 +106,95 @@ func (h *Handler) handleContextLoadError(ctx context.Context, verificationID str
 			WithSeverity(errors.ErrorSeverityHigh)
 	}
 
-	return nil, enhancedErr
+	return enhancedErr
 }
 
 // recordContextLoadSuccess records successful context loading
-func (h *Handler) recordContextLoadSuccess(ctx context.Context, verificationID string, loadResult *LoadResult) {
-	h.processingTracker.RecordStage("context_loading", "completed", loadResult.Duration, map[string]interface{}{
-		"s3_operations":        2,
-		"concurrent_loading":   true,
-		"system_prompt_length": len(loadResult.SystemPrompt),
-		"image_data_length":    len(loadResult.Base64Image),
+func (h *Handler) recordContextLoadSuccess(ctx context.Context, req *models.Turn1Request, systemPrompt string, duration time.Duration) {
+	h.processingTracker.RecordStage("context_loading", "completed", duration, map[string]interface{}{
+		"s3_operations":        1,
+		"system_prompt_length": len(systemPrompt),
 	})
 
-	h.updateStatus(ctx, verificationID, schema.StatusTurn1ContextLoaded, "context_loading", map[string]interface{}{
-		"system_prompt_size":  len(loadResult.SystemPrompt),
-		"image_size":          len(loadResult.Base64Image),
-		"loading_duration_ms": loadResult.Duration.Milliseconds(),
+	h.updateStatus(ctx, req, schema.StatusTurn1ContextLoaded, map[string]interface{}{
+		"system_prompt_size":  len(systemPrompt),
+		"loading_duration_ms": duration.Milliseconds(),
 	})
 }
 
-// PromptResult contains prompt generation results
-type PromptResult struct {
-	Prompt            string
-	TemplateProcessor *schema.TemplateProcessor
-	Duration          time.Duration
-	Error             error
+// handleTurnError routes a failure of the executed turn to the handler of the
+// stage that failed
+func (h *Handler) handleTurnError(ctx context.Context, req *models.Turn1Request, err error, duration time.Duration, contextLogger logger.Logger) error {
+	operation := ""
+	if wfErr, ok := err.(*errors.WorkflowError); ok {
+		operation = wfErr.Operation
+	}
+	switch operation {
+	case turnexecutor.OperationRenderPrompt:
+		return h.handlePromptError(ctx, req, err, duration, contextLogger)
+	case turnexecutor.OperationLoadInputs:
+		return h.handleContextLoadError(ctx, req, err, duration, contextLogger)
+	default:
+		return h.handleBedrockError(ctx, req, err, duration)
+	}
 }
 
-// generatePrompt generates the Turn1 prompt
-func (h *Handler) generatePrompt(ctx context.Context, req *models.Turn1Request, systemPrompt string) *PromptResult {
-	startTime := time.Now()
-	prompt, templateProcessor, err := h.promptGenerator.GenerateTurn1PromptEnhanced(ctx, req.VerificationContext, systemPrompt)
+// assignExperiment applies the verification's assignment for the prompt
+// experiment of the Turn1 template to the spec
+func (h *Handler) assignExperiment(req *models.Turn1Request, spec *turnexecutor.TurnSpec) *turnexecutor.TurnSpec {
+	assignment, ok := h.cfg.Prompts.Experiments.AssignTemplate(spec.TemplateType, req.VerificationID)
+	if !ok {
+		return spec
+	}
+	h.log.Info("prompt_experiment_assigned", map[string]interface{}{
+		"verification_id":  req.VerificationID,
+		"experiment_id":    assignment.ExperimentID,
+		"variant":          assignment.Variant,
+		"template_type":    assignment.TemplateType,
+		"template_version": assignment.TemplateVersion,
+		"model_id":         assignment.ModelID,
+	})
+	return spec.WithAssignment(assignment)
+}
 
-	return &PromptResult{
-		Prompt:            prompt,
-		TemplateProcessor: templateProcessor,
-		Duration:          time.Since(startTime),
-		Error:             err,
+// recordExperimentAssignments stores the verification's experiment
+// assignments and the Turn1 token usage on the verification record. Failures
+// are logged and do not fail the turn.
+func (h *Handler) recordExperimentAssignments(ctx context.Context, req *models.Turn1Request, result *turnexecutor.Result) {
+	assignments := h.cfg.Prompts.Experiments.AssignAll(req.VerificationID)
+	usage := experiments.TurnUsage{
+		ModelID:        result.ModelID,
+		InputTokens:    result.Usage.InputTokens,
+		OutputTokens:   result.Usage.OutputTokens,
+		ThinkingTokens: result.Usage.ThinkingTokens,
+		TotalTokens:    result.Usage.TotalTokens,
+	}
+	if err := h.status.RecordExperimentAssignments(ctx, req.VerificationID, req.VerificationContext.VerificationAt, 1, assignments, usage); err != nil {
+		h.log.Warn("experiment_assignment_recording_failed", map[string]interface{}{
+			"verification_id": req.VerificationID,
+			"error":           err.Error(),
+		})
 	}
 }
 
 // handlePromptError handles errors during prompt generation with enhanced error details
-func (h *Handler) handlePromptError(ctx context.Context, verificationID string, result *PromptResult, contextLogger logger.Logger) (*schema.CombinedTurnResponse, error) {
-	h.processingTracker.RecordStage("prompt_generation", "failed", result.Duration, map[string]interface{}{
+func (h *Handler) handlePromptError(ctx context.Context, req *models.Turn1Request, promptErr error, duration time.Duration, contextLogger logger.Logger) error {
+	verificationID := req.VerificationID
+	h.processingTracker.RecordStage("prompt_generation", "failed", duration, map[string]interface{}{
 		"template_version": h.cfg.Prompts.TemplateVersion,
 		"error_type":       "prompt_generation_failure",
 	})
 
-	h.updateStatus(ctx, verificationID, schema.StatusTemplateProcessingError, "prompt_generation_failed", map[string]interface{}{
-		"error_details": result.Error.Error(),
+	h.updateStatus(ctx, req, schema.StatusTemplateProcessingError, map[string]interface{}{
+		"error_details": promptErr.Error(),
 	})
 
 	// Enhanced prompt error with detailed context
 	var enhancedErr *errors.WorkflowError
-	if workflowErr, ok := result.Error.(*errors.WorkflowError); ok {
+	if workflowErr, ok := promptErr.(*errors.WorkflowError); ok {
 		enhancedErr = workflowErr.
 			WithVerificationID(verificationID).
-			WithComponent("PromptService").
+			WithComponent("PromptRenderer").
 			WithOperation("GenerateTurn1Prompt").
 			WithCategory(errors.CategoryPermanent).
 			WithRetryStrategy(errors.RetryNone).
@@ -178,9 +213,9 @@ func (h *Handler) handlePromptError(ctx context.Context, verificationID string,
 				"Verify template loading configuration",
 			)
 	} else {
-		enhancedErr = errors.NewInternalError("prompt_service", result.Error).
+		enhancedErr = errors.NewInternalError("prompt_service", promptErr).
 			WithVerificationID(verificationID).
-			WithComponent("PromptService").
+			WithComponent("PromptRenderer").
 			WithOperation("GenerateTurn1Prompt").
 			WithCategory(errors.CategoryPermanent).
 			WithRetryStrategy(errors.RetryNone).
@@ -202,24 +237,25 @@ func (h *Handler) handlePromptError(ctx context.Context, verificationID string,
 		"template_version": h.cfg.Prompts.TemplateVersion,
 	})
 
-	return nil, enhancedErr
+	return enhancedErr
 }
 
 // handleBedrockError handles errors during Bedrock invocation with enhanced error details
-func (h *Handler) handleBedrockError(ctx context.Context, verificationID string, result *InvokeResult) (*schema.CombinedTurnResponse, error) {
-	h.processingTracker.RecordStage("bedrock_invocation", "failed", result.Duration, map[string]interface{}{
+func (h *Handler) handleBedrockError(ctx context.Context, req *models.Turn1Request, bedrockErr error, duration time.Duration) error {
+	verificationID := req.VerificationID
+	h.processingTracker.RecordStage("bedrock_invocation", "failed", duration, map[string]interface{}{
 		"model_id":   h.cfg.AWS.BedrockModel,
 		"max_tokens": h.cfg.Processing.MaxTokens,
 		"error_type": "bedrock_api_failure",
 	})
 
-	h.updateStatus(ctx, verificationID, schema.StatusTurn1Error, "bedrock_invocation_failed", map[string]interface{}{
-		"error_details": result.Error.Error(),
+	h.updateStatus(ctx, req, schema.StatusTurn1Error, map[string]interface{}{
+		"error_details": bedrockErr.Error(),
 	})
 
 	// Enhanced Bedrock error with detailed context
 	var enhancedErr *errors.WorkflowError
-	if workflowErr, ok := result.Error.(*errors.WorkflowError); ok {
+	if workflowErr, ok := bedrockErr.(*errors.WorkflowError); ok {
 		// Determine retry strategy and category based on error type
 		category := errors.CategoryServer
 		retryStrategy := errors.RetryExponential
@@ -262,7 +298,7 @@ func (h *Handler) handleBedrockError(ctx context.Context, verificationID string,
 				"Review Bedrock quotas and limits",
 			)
 	} else {
-		enhancedErr = errors.WrapError(result.Error, errors.ErrorTypeBedrock,
+		enhancedErr = errors.WrapError(bedrockErr, errors.ErrorTypeBedrock,
 			"Bedrock invocation failed", true).
 			WithVerificationID(verificationID).
 			WithComponent("BedrockService").
@@ -275,24 +311,24 @@ func (h *Handler) handleBedrockError(ctx context.Context, verificationID string,
 			WithContext("max_tokens", h.cfg.Processing.MaxTokens)
 	}
 
-	return nil, enhancedErr
+	return enhancedErr
 }
 
 // recordBedrockSuccess records successful Bedrock invocation
-func (h *Handler) recordBedrockSuccess(ctx context.Context, verificationID string, result *InvokeResult, templateProcessor *schema.TemplateProcessor) {
-	metadata := h.bedrockInvoker.GetInvocationMetadata(result.Response, result.Duration)
-	h.processingTracker.RecordStage("bedrock_invocation", "completed", result.Duration, metadata)
-
-	h.updateStatus(ctx, verificationID, schema.StatusTurn1BedrockCompleted, "bedrock_completion", map[string]interface{}{
-		"token_usage":        result.Response.TokenUsage,
-		"bedrock_request_id": result.Response.RequestID,
-		"latency_ms":         result.Duration.Milliseconds(),
+func (h *Handler) recordBedrockSuccess(ctx context.Context, req *models.Turn1Request, result *turnexecutor.Result) {
+	h.processingTracker.RecordStage("bedrock_invocation", "completed", time.Duration(result.LatencyMs)*time.Millisecond, map[string]interface{}{
+		"model_id":      result.ModelID,
+		"request_id":    result.RequestID,
+		"input_tokens":  result.Usage.InputTokens,
+		"output_tokens": result.Usage.OutputTokens,
+		"has_thinking":  len(result.Reasoning) > 0,
 	})
 
-	if templateProcessor != nil {
-		templateProcessor.InputTokens = result.Response.TokenUsage.InputTokens
-		templateProcessor.OutputTokens = result.Response.TokenUsage.OutputTokens
-	}
+	h.updateStatus(ctx, req, schema.StatusTurn1BedrockCompleted, map[string]interface{}{
+		"token_usage":        result.Usage,
+		"bedrock_request_id": result.RequestID,
+		"latency_ms":         result.LatencyMs,
+	})
 }
 
 // recordStorageSuccess records successful storage operations
@@ -302,16 +338,16 @@ func (h *Handler) recordStorageSuccess(result *StorageResult) {
 }
 
 // updateProcessingMetrics updates processing metrics with final values
-func (h *Handler) updateProcessingMetrics(metrics *schema.ProcessingMetrics, totalDuration time.Duration, invokeResult *InvokeResult) {
+func (h *Handler) updateProcessingMetrics(metrics *schema.ProcessingMetrics, totalDuration time.Duration, result *turnexecutor.Result) {
 	metrics.WorkflowTotal.EndTime = schema.FormatISO8601()
 	metrics.WorkflowTotal.TotalTimeMs = totalDuration.Milliseconds()
 	metrics.WorkflowTotal.FunctionCount = h.processingTracker.GetStageCount()
 
 	metrics.Turn1.EndTime = schema.FormatISO8601()
 	metrics.Turn1.TotalTimeMs = totalDuration.Milliseconds()
-	metrics.Turn1.BedrockLatencyMs = invokeResult.Duration.Milliseconds()
-	metrics.Turn1.ProcessingTimeMs = totalDuration.Milliseconds() - invokeResult.Duration.Milliseconds()
-	metrics.Turn1.TokenUsage = &invokeResult.Response.TokenUsage
+	metrics.Turn1.BedrockLatencyMs = result.LatencyMs
+	metrics.Turn1.ProcessingTimeMs = totalDuration.Milliseconds() - result.LatencyMs
+	metrics.Turn1.TokenUsage = &result.Usage
 }
 
 // updateInitializationFile writes the final status back to the input initialization.json
@@ -322,7 +358,7 @@ func (h *Handler) updateInitializationFile(ctx context.Context, req *models.Turn
 		return
 	}
 
-	initData, err := h.s3.LoadInitializationData(ctx, ref)
+	initData, err := h.store.LoadInitializationData(ctx, ref)
 	if err != nil {
 		contextLogger.Warn("failed to load initialization.json for update", map[string]interface{}{
 			"error": err.Error(),
@@ -335,7 +371,7 @@ func (h *Handler) updateInitializationFile(ctx context.Context, req *models.Turn
 	initData.VerificationContext.LastUpdatedAt = schema.FormatISO8601()
 	initData.SchemaVersion = schema.SchemaVersion
 
-	if _, err := h.s3.StoreJSONAtReference(ctx, ref, initData); err != nil {
+	if _, err := h.store.StoreJSONAtReference(ctx, ref, initData); err != nil {
 		contextLogger.Warn("failed to store updated initialization.json", map[string]interface{}{
 			"error": err.Error(),
 			"key":   ref.Key,
@@ -349,7 +385,7 @@ func (h *Handler) updateInitializationFile(ctx context.Context, req *models.Turn
 	})
 }
 // validateAndLogCompletion validates response and logs completion
-func (h *Handler) validateAndLogCompletion(response *schema.CombinedTurnResponse, totalDuration time.Duration, bedrockResp *models.BedrockResponse, contextLogger logger.Logger) {
+func (h *Handler) validateAndLogCompletion(response *schema.CombinedTurnResponse, totalDuration time.Duration, result *turnexecutor.Result, contextLogger logger.Logger) {
 	// Create Turn1Response for validation
 	turn1Response := &models.Turn1Response{
 		S3Refs: models.Turn1ResponseS3Refs{
@@ -360,8 +396,8 @@ func (h *Handler) validateAndLogCompletion(response *schema.CombinedTurnResponse
 		Summary: models.Summary{
 			AnalysisStage:    models.StageReferenceAnalysis,
 			ProcessingTimeMs: totalDuration.Milliseconds(),
-			TokenUsage:       bedrockResp.TokenUsage,
-			BedrockRequestID: bedrockResp.RequestID,
+			TokenUsage:       result.Usage,
+			BedrockRequestID: result.RequestID,
 		},
 	}
 
@@ -374,7 +410,6 @@ func (h *Handler) validateAndLogCompletion(response *schema.CombinedTurnResponse
 	contextLogger.Info("Completed ExecuteTurn1Combined", map[string]interface{}{
 		"duration_ms":       totalDuration.Milliseconds(),
 		"processing_stages": h.processingTracker.GetStageCount(),
-		"status_updates":    h.statusTracker.GetHistoryCount(),
 		"schema_version":    h.validator.GetSchemaVersion(),
 		"template_used":     response.TemplateUsed,
 	})
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 	"workflow-function/ExecuteTurn1Combined/internal/models"
+	"workflow-function/shared/turnexecutor"
 )
 
 // S3ReferenceTree represents a complete tree of S3 references for a verification
@@ -193,17 +194,17 @@ func isAllDigits(s string) bool {
 // buildSummary creates a summary of the turn execution
 func buildSummary(
 	totalDurationMs int64,
-	invoke *models.BedrockResponse,
+	result *turnexecutor.Result,
 	verificationType string,
 	bedrockLatencyMs int64,
 	dynamoOK bool,
 ) ExecutionSummary {
 	// Convert TokenUsage to TokenUsageDetailed
 	tokenUsage := TokenUsageDetailed{
-		Input:    invoke.TokenUsage.InputTokens,
-		Output:   invoke.TokenUsage.OutputTokens,
-		Thinking: invoke.TokenUsage.ThinkingTokens,
-		Total:    invoke.TokenUsage.TotalTokens,
+		Input:    result.Usage.InputTokens,
+		Output:   result.Usage.OutputTokens,
+		Thinking: result.Usage.ThinkingTokens,
+		Total:    result.Usage.TotalTokens,
 	}
 
 	// Default to true for conversation tracked and S3 storage completed
@@ -222,7 +223,7 @@ func buildSummary(
 		ProcessingTimeMs:    totalDurationMs,
 		TokenUsage:          tokenUsage,
 		BedrockLatencyMs:    bedrockLatencyMs,
-		BedrockRequestId:    invoke.RequestID,
+		BedrockRequestId:    result.RequestID,
 		DynamodbUpdated:     dynamodbUpdated,
 		ConversationTracked: conversationTracked,
 		S3StorageCompleted:  s3StorageCompleted,
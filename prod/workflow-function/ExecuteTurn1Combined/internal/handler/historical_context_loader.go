@@ -5,24 +5,22 @@ import (
 	"time"
 
 	"workflow-function/ExecuteTurn1Combined/internal/models"
-	"workflow-function/ExecuteTurn1Combined/internal/services"
 	"workflow-function/shared/logger"
 	"workflow-function/shared/schema"
+	"workflow-function/shared/turnexecutor"
 )
 
 // HistoricalContextLoader handles loading historical verification context
 type HistoricalContextLoader struct {
-	dynamo services.DynamoDBService
-	s3     services.S3StateManager
-	log    logger.Logger
+	s3  *turnexecutor.S3Store
+	log logger.Logger
 }
 
 // NewHistoricalContextLoader creates a new instance of HistoricalContextLoader
-func NewHistoricalContextLoader(s3 services.S3StateManager, dynamo services.DynamoDBService, log logger.Logger) *HistoricalContextLoader {
+func NewHistoricalContextLoader(s3 *turnexecutor.S3Store, log logger.Logger) *HistoricalContextLoader {
 	return &HistoricalContextLoader{
-		dynamo: dynamo,
-		s3:     s3,
-		log:    log,
+		s3:  s3,
+		log: log,
 	}
 }
 
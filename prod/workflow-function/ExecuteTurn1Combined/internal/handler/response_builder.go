@@ -4,6 +4,7 @@ import (
 	"workflow-function/ExecuteTurn1Combined/internal/config"
 	"workflow-function/ExecuteTurn1Combined/internal/models"
 	"workflow-function/shared/schema"
+	"workflow-function/shared/turnexecutor"
 )
 
 // ResponseBuilder handles building combined turn responses
@@ -23,7 +24,7 @@ func (r *ResponseBuilder) BuildCombinedTurnResponse(
 	req *models.Turn1Request,
 	renderedPrompt string,
 	promptRef, rawRef, procRef, convRef models.S3Reference,
-	invoke *models.BedrockResponse,
+	result *turnexecutor.Result,
 	stages []schema.ProcessingStage,
 	totalDurationMs int64,
 	bedrockLatencyMs int64,
@@ -39,24 +40,21 @@ func (r *ResponseBuilder) BuildCombinedTurnResponse(
 			"reference": req.S3Refs.Images.ReferenceBase64.Key,
 		},
 		Response: schema.BedrockApiResponse{
-			Content:   string(invoke.Raw),
-			RequestId: invoke.RequestID,
+			Content:   result.Content,
+			Thinking:  result.Thinking,
+			RequestId: result.RequestID,
 		},
 		LatencyMs:  totalDurationMs,
-		TokenUsage: &invoke.TokenUsage,
+		TokenUsage: &result.Usage,
 		Stage:      "REFERENCE_ANALYSIS",
 		Metadata: map[string]interface{}{
-			"model_id":        r.cfg.AWS.BedrockModel,
+			"model_id":        result.ModelID,
 			"verification_id": req.VerificationID,
 			"function_name":   "ExecuteTurn1Combined",
 		},
 	}
 
-	// Determine template used based on verification type
-	templateUsed := "turn1-layout-vs-checking"
-	if req.VerificationContext.VerificationType == schema.VerificationTypePreviousVsCurrent {
-		templateUsed = "turn1-previous-vs-current"
-	}
+	templateUsed := turn1TemplateType(req.VerificationContext.VerificationType)
 
 	// Build S3 reference tree
 	s3RefTree := buildS3RefTree(req.S3Refs, promptRef, rawRef, procRef, convRef)
@@ -67,7 +65,7 @@ func (r *ResponseBuilder) BuildCombinedTurnResponse(
 		"verification_type":  req.VerificationContext.VerificationType,
 		"s3_references":      s3RefTree,
 		"status":             schema.StatusTurn1Completed,
-		"summary":            buildSummary(totalDurationMs, invoke, req.VerificationContext.VerificationType, bedrockLatencyMs, dynamoOK),
+		"summary":            buildSummary(totalDurationMs, result, req.VerificationContext.VerificationType, bedrockLatencyMs, dynamoOK),
 		"schema_version":     schema.SchemaVersion,
 		"layout_integrated":  req.VerificationContext.LayoutId != 0,
 		"historical_context": req.VerificationContext.HistoricalContext != nil,
@@ -89,7 +87,7 @@ func (r *ResponseBuilder) BuildCombinedTurnResponse(
 func (r *ResponseBuilder) BuildStepFunctionResponse(
 	req *models.Turn1Request,
 	promptRef, rawRef, procRef, convRef models.S3Reference,
-	invoke *models.BedrockResponse,
+	result *turnexecutor.Result,
 	totalDurationMs int64,
 	bedrockLatencyMs int64,
 	dynamoOK bool,
@@ -127,7 +125,7 @@ func (r *ResponseBuilder) BuildStepFunctionResponse(
 	}
 
 	// Build summary in the expected format
-	summary := buildSummary(totalDurationMs, invoke, req.VerificationContext.VerificationType, bedrockLatencyMs, dynamoOK)
+	summary := buildSummary(totalDurationMs, result, req.VerificationContext.VerificationType, bedrockLatencyMs, dynamoOK)
 
 	// Convert ExecutionSummary to map[string]interface{}
 	summaryMap := map[string]interface{}{
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"time"
+
+	"workflow-function/ExecuteTurn1Combined/internal/config"
+	"workflow-function/ExecuteTurn1Combined/internal/models"
+	"workflow-function/shared/bedrock"
+	"workflow-function/shared/errors"
+	"workflow-function/shared/schema"
+	"workflow-function/shared/turnexecutor"
+)
+
+// maxSystemPromptLength bounds the system prompt accepted for Turn1
+const maxSystemPromptLength = 50000
+
+// turn1Spec declares the reference analysis turn: the reference image is sent
+// with the template selected by the verification type. The handler records
+// the start, error and completion statuses around the executed turn.
+func turn1Spec(cfg config.Config, verificationType string) *turnexecutor.TurnSpec {
+	temperature, topP := cfg.Processing.Temperature, cfg.Processing.TopP
+	return &turnexecutor.TurnSpec{
+		Turn:            bedrock.ExpectedTurn1Number,
+		Stage:           bedrock.AnalysisStageTurn1,
+		TemplateType:    turn1TemplateType(verificationType),
+		TemplateVersion: cfg.Prompts.TemplateVersion,
+		Images:          []string{turnexecutor.ImageRoleReference},
+		Statuses: turnexecutor.StatusSet{
+			PromptPrepared: schema.StatusTurn1PromptPrepared,
+			BedrockInvoked: schema.StatusTurn1BedrockInvoked,
+		},
+		ModelID: cfg.AWS.BedrockModel,
+		Inference: bedrock.InferenceConfig{
+			MaxTokens:   cfg.Processing.MaxTokens,
+			Temperature: &temperature,
+			TopP:        &topP,
+		},
+		Timeout: time.Duration(cfg.Processing.BedrockCallTimeoutSec) * time.Second,
+	}
+}
+
+// turn1TemplateType returns the Turn1 template of a verification type
+func turn1TemplateType(verificationType string) string {
+	switch verificationType {
+	case schema.VerificationTypeLayoutVsChecking:
+		return "turn1-layout-vs-checking"
+	case schema.VerificationTypePreviousVsCurrent:
+		return "turn1-previous-vs-current"
+	default:
+		return "turn1-default"
+	}
+}
+
+// turn1PromptInfo describes the context stored with the Turn1 prompt
+func turn1PromptInfo(req *models.Turn1Request, templateVersion string) turnexecutor.PromptInfo {
+	vCtx := req.VerificationContext
+	info := turnexecutor.PromptInfo{
+		VerificationType: vCtx.VerificationType,
+		TemplateVersion:  templateVersion,
+		Objective:        "Analyze reference image in detail",
+		ImageRole:        turnexecutor.ImageRoleReference,
+		ContextSources:   []string{"INITIALIZATION", "IMAGE_METADATA"},
+	}
+	if url, ok := vCtx.LayoutMetadata["referenceImageUrl"].(string); ok {
+		info.SourceURL = url
+	}
+	if vCtx.LayoutMetadata != nil {
+		info.ContextSources = append(info.ContextSources, "LAYOUT_METADATA")
+	}
+	if vCtx.HistoricalContext != nil {
+		info.ContextSources = append(info.ContextSources, "HISTORICAL_CONTEXT")
+	}
+	return info
+}
+
+// turn1TemplateData validates the inputs and builds the template data of the
+// Turn1 prompt. Layout dimensions are only provided for LAYOUT_VS_CHECKING;
+// for PREVIOUS_VS_CURRENT the model detects them and the historical context
+// is flattened for direct template access.
+func turn1TemplateData(vCtx models.VerificationContext, systemPrompt, templateVersion string) (map[string]interface{}, error) {
+	if err := validateTurn1Inputs(vCtx, systemPrompt); err != nil {
+		return nil, err
+	}
+	if err := vCtx.Validate(); err != nil {
+		return nil, errors.NewValidationError("invalid verification context", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	data := map[string]interface{}{
+		"VerificationType": vCtx.VerificationType,
+		"SystemPrompt":     systemPrompt,
+		"VendingMachineId": vCtx.VendingMachineId,
+		"VendingMachineID": vCtx.VendingMachineId, // Also add with uppercase ID for template compatibility
+		"TemplateVersion":  templateVersion,
+		"CreatedAt":        schema.FormatISO8601(),
+	}
+
+	switch vCtx.VerificationType {
+	case schema.VerificationTypeLayoutVsChecking:
+		turnexecutor.AddLayoutContext(data, vCtx.LayoutId, vCtx.LayoutPrefix, vCtx.LayoutMetadata)
+
+	case schema.VerificationTypePreviousVsCurrent:
+		if vCtx.HistoricalContext == nil {
+			break
+		}
+		for key, value := range vCtx.HistoricalContext {
+			data[key] = value
+		}
+		data["PreviousVerificationAt"] = stringOrDefault(vCtx.HistoricalContext, "PreviousVerificationAt", "unknown")
+		data["HoursSinceLastVerification"] = floatOrDefault(vCtx.HistoricalContext, "HoursSinceLastVerification", 0.0)
+		data["PreviousVerificationStatus"] = stringOrDefault(vCtx.HistoricalContext, "PreviousVerificationStatus", "unknown")
+		if summary, ok := vCtx.HistoricalContext["VerificationSummary"].(map[string]interface{}); ok {
+			data["VerificationSummary"] = verificationSummary(summary)
+		}
+	}
+
+	return data, nil
+}
+
+// validateTurn1Inputs rejects prompts that cannot be generated
+func validateTurn1Inputs(vCtx models.VerificationContext, systemPrompt string) error {
+	if vCtx.VerificationType == "" {
+		return errors.NewValidationError(
+			"verification type is required for prompt generation",
+			map[string]interface{}{
+				"vending_machine_id": vCtx.VendingMachineId,
+				"layout_id":          vCtx.LayoutId,
+			})
+	}
+	if len(systemPrompt) == 0 {
+		return errors.NewValidationError(
+			"system prompt cannot be empty",
+			map[string]interface{}{
+				"verification_type": vCtx.VerificationType,
+			})
+	}
+	if len(systemPrompt) > maxSystemPromptLength {
+		return errors.NewValidationError(
+			"system prompt exceeds reasonable size limit",
+			map[string]interface{}{
+				"system_prompt_length": len(systemPrompt),
+				"max_allowed_length":   maxSystemPromptLength,
+			})
+	}
+	return nil
+}
+
+// verificationSummary ensures the summary fields used by the
+// previous-vs-current template are present with numeric types
+func verificationSummary(summary map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"OverallAccuracy":       floatOrDefault(summary, "OverallAccuracy", 0.0),
+		"MissingProducts":       int(floatOrDefault(summary, "MissingProducts", 0)),
+		"IncorrectProductTypes": int(floatOrDefault(summary, "IncorrectProductTypes", 0)),
+		"EmptyPositionsCount":   int(floatOrDefault(summary, "EmptyPositionsCount", 0)),
+	}
+}
+
+func floatOrDefault(data map[string]interface{}, key string, defaultValue float64) float64 {
+	switch v := data[key].(type) {
+	case float64:
+		return v
+	case float32:
+		return float64(v)
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	}
+	return defaultValue
+}
+
+func stringOrDefault(data map[string]interface{}, key, defaultValue string) string {
+	if s, ok := data[key].(string); ok {
+		return s
+	}
+	return defaultValue
+}
@@ -6,15 +6,16 @@ import (
 
 	localBedrock "workflow-function/ExecuteTurn1Combined/internal/bedrock"
 	"workflow-function/ExecuteTurn1Combined/internal/config"
-	"workflow-function/ExecuteTurn1Combined/internal/models"
 	sharedBedrock "workflow-function/shared/bedrock"
 	"workflow-function/shared/errors"
 	"workflow-function/shared/logger"
+	"workflow-function/shared/turnexecutor"
 )
 
 // BedrockService defines the interface for AI model integration
 type BedrockService interface {
-	Converse(ctx context.Context, systemPrompt, turnPrompt, base64Image string) (*models.BedrockResponse, error)
+	// TurnConverser exposes the Bedrock client to the shared turn executor
+	TurnConverser() turnexecutor.Converser
 }
 
 // bedrockService implements AI model integration
@@ -34,8 +35,8 @@ func NewBedrockService(ctx context.Context, cfg config.Config) (BedrockService,
 		cfg.AWS.Region,
 		cfg.AWS.AnthropicVersion,
 		cfg.Processing.MaxTokens,
-		"",
-		0,
+		cfg.Processing.ThinkingType,
+		cfg.Processing.ThinkingBudgetTokens,
 	)
 
 	sharedClient, err := sharedBedrock.NewBedrockClient(ctx, cfg.AWS.BedrockModel, clientConfig)
@@ -53,8 +54,8 @@ func NewBedrockService(ctx context.Context, cfg config.Config) (BedrockService,
 		MaxTokens:        cfg.Processing.MaxTokens,
 		Temperature:      cfg.Processing.Temperature,
 		TopP:             cfg.Processing.TopP,
-		ThinkingType:     "",
-		ThinkingBudget:   0,
+		ThinkingType:     cfg.Processing.ThinkingType,
+		ThinkingBudget:   cfg.Processing.ThinkingBudgetTokens,
 		Timeout:          time.Duration(cfg.Processing.BedrockCallTimeoutSec) * time.Second,
 		Region:           cfg.AWS.Region,
 	}
@@ -68,6 +69,8 @@ func NewBedrockService(ctx context.Context, cfg config.Config) (BedrockService,
 		"max_tokens":  cfg.Processing.MaxTokens,
 		"temperature": cfg.Processing.Temperature,
 		"top_p":       cfg.Processing.TopP,
+		"thinking":    cfg.Processing.ThinkingType,
+		"budget":      cfg.Processing.ThinkingBudgetTokens,
 	})
 
 	return &bedrockService{
@@ -87,41 +90,7 @@ func NewBedrockServiceWithLocalClient(client *localBedrock.Client, cfg config.Co
 	}
 }
 
-// Converse implements multimodal AI inference
-func (s *bedrockService) Converse(ctx context.Context, systemPrompt, turnPrompt, base64Image string) (*models.BedrockResponse, error) {
-	operationStart := time.Now()
-
-	s.logger.Info("bedrock_converse_initiated", map[string]interface{}{
-		"system_prompt_size": len(systemPrompt),
-		"turn_prompt_size":   len(turnPrompt),
-		"base64_image_size":  len(base64Image),
-	})
-
-	response, err := s.client.ProcessTurn1(ctx, systemPrompt, turnPrompt, base64Image)
-	if err != nil {
-		s.logger.Error("bedrock_api_error", map[string]interface{}{
-			"error": err.Error(),
-		})
-		return nil, err
-	}
-
-	// Convert to models.BedrockResponse
-	bedrockResponse := &models.BedrockResponse{
-		Raw:        response.Raw,
-		Processed:  map[string]interface{}{"content": response.Content},
-		TokenUsage: response.TokenUsage,
-		RequestID:  response.RequestID,
-		Metadata:   response.Metadata, // Metadata already includes thinking content from adapter
-	}
-
-	totalDuration := time.Since(operationStart)
-
-	s.logger.Info("bedrock_converse_completed", map[string]interface{}{
-		"total_duration_ms": totalDuration.Milliseconds(),
-		"token_usage":       bedrockResponse.TokenUsage,
-		"request_id":        bedrockResponse.RequestID,
-		"success":           true,
-	})
-
-	return bedrockResponse, nil
+// TurnConverser returns the shared Bedrock client used by the turn executor
+func (s *bedrockService) TurnConverser() turnexecutor.Converser {
+	return s.client.SharedClient()
 }
@@ -17,6 +17,8 @@ import (
 	"github.com/aws/aws-lambda-go/lambdacontext"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 
 	internalConfig "workflow-function/ExecuteTurn2Combined/internal/config"
 	"workflow-function/ExecuteTurn2Combined/internal/handler"
@@ -27,7 +29,9 @@ import (
 	// Shared packages with strategic integration
 	"workflow-function/shared/errors"
 	"workflow-function/shared/logger"
+	"workflow-function/shared/s3state"
 	"workflow-function/shared/templateloader"
+	"workflow-function/shared/turnexecutor"
 )
 
 // ApplicationContainer represents the enhanced dependency orchestration framework
@@ -39,10 +43,10 @@ type ApplicationContainer struct {
 	handler   *handler.Turn2Handler
 
 	// Strategic service abstractions with deterministic control patterns
-	s3Service      services.S3StateManager
+	store          *turnexecutor.S3Store
 	bedrockService services.BedrockServiceTurn2
-	dynamoService  services.DynamoDBService
-	promptService  services.PromptServiceTurn2
+	status         *turnexecutor.DynamoStatus
+	renderer       *turnexecutor.PromptRenderer
 }
 
 // SystemInitializationMetrics captures comprehensive bootstrap telemetry
@@ -136,10 +140,10 @@ func init() {
 		"template_base_path":       applicationContainer.config.Prompts.TemplateBasePath,
 		"template_version":         applicationContainer.config.Prompts.TemplateVersion,
 		"services_initialized": map[string]interface{}{
-			"s3_service":      applicationContainer.s3Service != nil,
+			"s3_service":      applicationContainer.store != nil,
 			"bedrock_service": applicationContainer.bedrockService != nil,
-			"dynamo_service":  applicationContainer.dynamoService != nil,
-			"prompt_service":  applicationContainer.promptService != nil,
+			"dynamo_service":  applicationContainer.status != nil,
+			"prompt_service":  applicationContainer.renderer != nil,
 			"architecture":    "deterministic_local_control",
 		},
 	})
@@ -249,13 +253,12 @@ func initializeApplicationContainer() (*ApplicationContainer, error) {
 	initializationMetrics.ServiceInitializationTime = time.Since(serviceInitStartTime)
 
 	// Strategic handler initialization with enhanced services
-	contextLoader := handler.NewContextLoader(services.s3Service, logger)
 	handlerInstance := handler.NewTurn2Handler(
-		contextLoader,
-		services.promptService,
+		services.store,
 		services.bedrockService,
-		services.s3Service,
-		services.dynamoService,
+		services.renderer,
+		services.status,
+		services.examples,
 		logger,
 		*cfg,
 	)
@@ -265,30 +268,32 @@ func initializeApplicationContainer() (*ApplicationContainer, error) {
 		logger:         logger,
 		awsConfig:      awsConfig,
 		handler:        handlerInstance,
-		s3Service:      services.s3Service,
+		store:          services.store,
 		bedrockService: services.bedrockService,
-		dynamoService:  services.dynamoService,
-		promptService:  services.promptService,
+		status:         services.status,
+		renderer:       services.renderer,
 	}, nil
 }
 
 // ServiceLayerComponents encapsulates service dependencies with architectural metadata
 type ServiceLayerComponents struct {
-	s3Service      services.S3StateManager
+	store          *turnexecutor.S3Store
 	bedrockService services.BedrockServiceTurn2
-	dynamoService  services.DynamoDBService
-	promptService  services.PromptServiceTurn2
+	status         *turnexecutor.DynamoStatus
+	renderer       *turnexecutor.PromptRenderer
+	examples       *services.FewShotExamples
 }
 
 // initializeServiceLayerWithLocalBedrock implements strategic service initialization
 // with deterministic local Bedrock control architecture
 func initializeServiceLayerWithLocalBedrock(awsConfig aws.Config, cfg *internalConfig.Config, logger logger.Logger) (*ServiceLayerComponents, error) {
-	// S3 service initialization
-	s3Service, err := services.NewS3StateManager(*cfg, logger)
+	// S3 store initialization
+	stateManager, err := s3state.New(cfg.AWS.S3Bucket)
 	if err != nil {
 		return nil, errors.WrapError(err, errors.ErrorTypeS3,
 			"S3 service initialization failed", false)
 	}
+	store := turnexecutor.NewS3Store(stateManager, cfg.CurrentDatePartition, logger)
 
 	// Strategic Bedrock service initialization
 	bedrockInitStart := time.Now()
@@ -305,17 +310,42 @@ func initializeServiceLayerWithLocalBedrock(awsConfig aws.Config, cfg *internalC
 		"max_tokens":    cfg.Processing.MaxTokens,
 	})
 
-	// DynamoDB service initialization
-	dynamoService, err := services.NewDynamoDBService(cfg)
+	// DynamoDB status initialization
+	dynamoConfig, err := awsconfig.LoadDefaultConfig(
+		context.Background(),
+		awsconfig.WithRegion(cfg.AWS.Region),
+		awsconfig.WithRetryMaxAttempts(cfg.Processing.MaxRetries),
+		awsconfig.WithRetryMode(aws.RetryModeAdaptive),
+	)
 	if err != nil {
 		return nil, errors.WrapError(err, errors.ErrorTypeDynamoDB,
 			"DynamoDB service initialization failed", false)
 	}
+	dynamoClient := dynamodb.NewFromConfig(dynamoConfig)
+	status := turnexecutor.NewDynamoStatus(
+		dynamoClient,
+		cfg.AWS.DynamoDBVerificationTable,
+		cfg.AWS.DynamoDBConversationTable,
+		cfg.Processing.MaxRetries,
+		logger,
+	)
 
-	// Prompt service initialization
+	// Prompt renderer initialization
 	loaderCfg := templateloader.Config{
-		BasePath:     cfg.Prompts.TemplateBasePath,
-		CacheEnabled: true,
+		BasePath:       cfg.Prompts.TemplateBasePath,
+		CacheEnabled:   true,
+		PinnedVersions: templateloader.PinnedVersionsFromEnv(),
+	}
+	if cfg.Prompts.TemplateS3URI != "" {
+		// Templates in S3 override the bundled templates
+		source, err := templateloader.NewS3FallbackSource(s3.NewFromConfig(awsConfig),
+			cfg.Prompts.TemplateS3URI, cfg.Prompts.TemplateBasePath)
+		if err != nil {
+			return nil, errors.WrapError(err, errors.ErrorTypeConfig,
+				"S3 template source configuration failed", false).
+				WithContext("template_s3_uri", cfg.Prompts.TemplateS3URI)
+		}
+		loaderCfg.Source = source
 	}
 	loader, err := templateloader.New(loaderCfg)
 	if err != nil {
@@ -323,17 +353,12 @@ func initializeServiceLayerWithLocalBedrock(awsConfig aws.Config, cfg *internalC
 			"template loader initialization failed", false)
 	}
 
-	promptService, err := services.NewPromptServiceTurn2(loader, cfg, logger)
-	if err != nil {
-		return nil, errors.WrapError(err, errors.ErrorTypeInternal,
-			"Prompt service initialization failed", false)
-	}
-
 	return &ServiceLayerComponents{
-		s3Service:      s3Service,
+		store:          store,
 		bedrockService: bedrockService,
-		dynamoService:  dynamoService,
-		promptService:  promptService,
+		status:         status,
+		renderer:       turnexecutor.NewPromptRenderer(loader, logger),
+		examples:       services.NewFewShotExamples(dynamoClient, cfg.Prompts.FewShot.Table),
 	}, nil
 }
 
@@ -462,7 +487,7 @@ func HandleRequest(ctx context.Context, event json.RawMessage) (interface{}, err
 			"s3_references_count":         len(stepEvent.S3References),
 		})
 
-		transformer := handler.NewEventTransformer(applicationContainer.s3Service, contextLogger)
+		transformer := handler.NewEventTransformer(applicationContainer.store, contextLogger)
 		transformedReq, transformErr := transformer.TransformStepFunctionEvent(enrichedCtx, stepEvent)
 		if transformErr != nil {
 			contextLogger.Error("step_function_event_transformation_failed", map[string]interface{}{"error": transformErr.Error()})
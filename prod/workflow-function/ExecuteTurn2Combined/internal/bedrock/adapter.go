@@ -147,16 +147,45 @@ func (a *Adapter) translateResponse(response *sharedBedrock.ConverseResponse) *B
 	raw, _ := json.Marshal(response)
 
 	// Construct response with strategic metadata preservation
+	metadata := map[string]interface{}{
+		"model_id":    response.ModelID,
+		"stop_reason": response.StopReason,
+	}
+
+	// Preserve reasoning blocks so they can be persisted as a separate artifact
+	reasoningBlocks := sharedBedrock.ExtractReasoningBlocks(response)
+	metadata["has_thinking"] = len(reasoningBlocks) > 0
+	if len(reasoningBlocks) > 0 {
+		metadata["thinking"] = sharedBedrock.ExtractThinkingFromResponse(response)
+		metadata["thinking_blocks"] = ReasoningBlocksToMetadata(reasoningBlocks)
+	}
+
 	return &BedrockResponse{
 		Content:    content,
 		TokenUsage: tokenUsage,
 		RequestID:  response.RequestID,
 		Raw:        raw,
-		Metadata: map[string]interface{}{
-			"model_id":    response.ModelID,
-			"stop_reason": response.StopReason,
-		},
+		Metadata:   metadata,
+	}
+}
+
+// ReasoningBlocksToMetadata converts reasoning content blocks into the generic
+// map form carried in response metadata
+func ReasoningBlocksToMetadata(blocks []sharedBedrock.ContentBlock) []interface{} {
+	result := make([]interface{}, 0, len(blocks))
+	for _, block := range blocks {
+		entry := map[string]interface{}{
+			"type": block.Type,
+		}
+		if block.Text != "" {
+			entry["text"] = block.Text
+		}
+		if block.Signature != "" {
+			entry["signature"] = block.Signature
+		}
+		result = append(result, entry)
 	}
+	return result
 }
 
 // detectImageFormat detects image format from base64 data
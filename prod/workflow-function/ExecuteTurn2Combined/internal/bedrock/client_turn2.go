@@ -8,7 +8,6 @@ import (
 	sharedBedrock "workflow-function/shared/bedrock"
 	"workflow-function/shared/errors"
 	"workflow-function/shared/logger"
-	"workflow-function/shared/schema"
 )
 
 // ClientTurn2 extends Client with Turn2-specific functionality
@@ -24,8 +23,8 @@ func NewClientTurn2(cfg config.Config, log logger.Logger) (*ClientTurn2, error)
 		cfg.AWS.Region,
 		cfg.AWS.AnthropicVersion,
 		cfg.Processing.MaxTokens,
-		"",
-		0,
+		cfg.Processing.ThinkingType,
+		cfg.Processing.ThinkingBudgetTokens,
 	)
 
 	sharedClient, err := sharedBedrock.NewBedrockClient(
@@ -47,8 +46,8 @@ func NewClientTurn2(cfg config.Config, log logger.Logger) (*ClientTurn2, error)
 		MaxTokens:        cfg.Processing.MaxTokens,
 		Temperature:      cfg.Processing.Temperature,
 		TopP:             cfg.Processing.TopP,
-		ThinkingType:     "",
-		ThinkingBudget:   0,
+		ThinkingType:     cfg.Processing.ThinkingType,
+		ThinkingBudget:   cfg.Processing.ThinkingBudgetTokens,
 		Timeout:          time.Duration(cfg.Processing.BedrockCallTimeoutSec) * time.Second,
 		Region:           cfg.AWS.Region,
 	}
@@ -65,24 +64,7 @@ func NewClientTurn2(cfg config.Config, log logger.Logger) (*ClientTurn2, error)
 	}, nil
 }
 
-// ProcessTurn2 handles the complete Turn2 processing
-// MODIFICATION START: added imageFormat parameter
-func (c *ClientTurn2) ProcessTurn2(ctx context.Context, systemPrompt, turn2Prompt, base64Image, imageFormat string, turn1Response *schema.TurnResponse) (*schema.BedrockResponse, error) {
-	startTime := time.Now()
-
-	// Validate configuration
-	if err := c.ValidateConfiguration(); err != nil {
-		return nil, err
-	}
-
-	// Process Turn2 using adapter
-	response, err := c.adapterTurn2.ProcessTurn2(ctx, systemPrompt, turn2Prompt, base64Image, imageFormat, turn1Response)
-	if err != nil {
-		return nil, err
-	}
-
-	// Add processing time to response
-	response.ProcessingTimeMs = time.Since(startTime).Milliseconds()
-
-	return response, nil
+// SharedClient returns the underlying shared Bedrock client
+func (c *ClientTurn2) SharedClient() *sharedBedrock.BedrockClient {
+	return c.adapterTurn2.client
 }
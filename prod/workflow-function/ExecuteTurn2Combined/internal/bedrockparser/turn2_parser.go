@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"workflow-function/ExecuteTurn2Combined/internal/models"
+	"workflow-function/shared/templateloader"
 )
 
 // ParsedTurn2Markdown holds the cleaned Markdown content from Bedrock's Turn 2 response.
@@ -70,13 +71,13 @@ func ParseTurn2Response(text string) (*ParsedTurn2Data, error) {
 
 			if matches := discrepancyRe.FindStringSubmatch(line); len(matches) > 2 {
 				item := strings.TrimSpace(matches[1])
-				expected := strings.TrimSpace(matches[2])
+				expected := templateloader.NormalizePosition(matches[2])
 				found := ""
 				discrepancyType := "MISSING"
 				severity := "MEDIUM"
 
 				if len(matches) > 3 && matches[3] != "" {
-					found = strings.TrimSpace(matches[3])
+					found = templateloader.NormalizePosition(matches[3])
 					discrepancyType = "MISPLACED"
 					severity = "MEDIUM"
 				} else {
@@ -0,0 +1,40 @@
+package bedrockparser
+
+import (
+	"testing"
+
+	"workflow-function/ExecuteTurn2Combined/internal/models"
+)
+
+func TestParseTurn2ResponseNormalizesPositions(t *testing.T) {
+	text := `Verification outcome: INCORRECT
+
+Verified discrepancies:
+- Pepsi: expected in A1, not found
+- Coke: expected in b 3, found in B04
+- Red Bull: expected in D2-A1, found in d2-a 2
+
+**COMPARISON SUMMARY:** Three positions differ.`
+
+	result, err := ParseTurn2Response(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.Discrepancy{
+		{Item: "Pepsi", Expected: "A01", Type: "MISSING"},
+		{Item: "Coke", Expected: "B03", Found: "B04", Type: "MISPLACED"},
+		{Item: "Red Bull", Expected: "D2-A01", Found: "D2-A02", Type: "MISPLACED"},
+	}
+	if len(result.Discrepancies) != len(want) {
+		t.Fatalf("Expected %d discrepancies, got %+v", len(want), result.Discrepancies)
+	}
+	for i, w := range want {
+		got := result.Discrepancies[i]
+		if got.Item != w.Item || got.Expected != w.Expected || got.Found != w.Found || got.Type != w.Type {
+			t.Errorf("Discrepancy %d: expected %+v, got %+v", i, w, got)
+		}
+	}
+	if result.VerificationOutcome != "INCORRECT" || result.ComparisonSummary != "Three positions differ." {
+		t.Errorf("Unexpected outcome %s / %q", result.VerificationOutcome, result.ComparisonSummary)
+	}
+}
@@ -0,0 +1,83 @@
+package bedrockparser
+
+import (
+	"regexp"
+	"strings"
+
+	"workflow-function/ExecuteTurn2Combined/internal/models"
+	"workflow-function/shared/templateloader"
+)
+
+// Turn3 verdicts returned by the self-verification prompt.
+const (
+	Turn3VerdictConfirmed = "CONFIRMED"
+	Turn3VerdictRetracted = "RETRACTED"
+)
+
+// turn3VerdictRe matches one verdict line; whitespace is matched within the
+// line so a verdict without a reason does not take the next line as its reason
+var turn3VerdictRe = regexp.MustCompile(`(?im)^[ \t]*[-*][ \t]*(.+?)[ \t]*:[ \t]*\**(CONFIRMED|RETRACTED)\**[ \t]*(?:[-–:][ \t]*(.*))?$`)
+
+// Turn3Verdict is the model's decision for a single re-examined position.
+type Turn3Verdict struct {
+	Position string `json:"position"`
+	Verdict  string `json:"verdict"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// ParsedTurn3Data holds the self-verification verdicts and the resulting
+// discrepancy list.
+type ParsedTurn3Data struct {
+	Verdicts            []Turn3Verdict       `json:"verdicts"`
+	Confirmed           []models.Discrepancy `json:"confirmed"`
+	Retracted           []models.Discrepancy `json:"retracted"`
+	VerificationOutcome string               `json:"verificationOutcome"`
+	ComparisonSummary   string               `json:"comparisonSummary"`
+}
+
+// ParseTurn3Response extracts the per-position verdicts from the Turn 3 text
+// and applies them to the Turn 2 discrepancies. Positions the model did not
+// answer for are kept, so a partial answer never hides a discrepancy.
+func ParseTurn3Response(text string, turn2 []models.Discrepancy) *ParsedTurn3Data {
+	result := &ParsedTurn3Data{
+		Verdicts:  []Turn3Verdict{},
+		Confirmed: []models.Discrepancy{},
+		Retracted: []models.Discrepancy{},
+	}
+
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	verdicts := map[string]string{}
+	for _, m := range turn3VerdictRe.FindAllStringSubmatch(text, -1) {
+		position := templateloader.NormalizePosition(strings.Trim(m[1], "*"))
+		verdict := strings.ToUpper(m[2])
+		if _, seen := verdicts[position]; seen {
+			continue
+		}
+		verdicts[position] = verdict
+		result.Verdicts = append(result.Verdicts, Turn3Verdict{
+			Position: position,
+			Verdict:  verdict,
+			Reason:   strings.TrimSpace(m[3]),
+		})
+	}
+
+	for _, d := range turn2 {
+		if verdicts[templateloader.NormalizePosition(d.Expected)] == Turn3VerdictRetracted {
+			result.Retracted = append(result.Retracted, d)
+			continue
+		}
+		result.Confirmed = append(result.Confirmed, d)
+	}
+
+	result.VerificationOutcome = "CORRECT"
+	if len(result.Confirmed) > 0 {
+		result.VerificationOutcome = "INCORRECT"
+	}
+
+	summaryRe := regexp.MustCompile(`(?s)\*\*COMPARISON SUMMARY:\*\*\s*(.*?)(?:\n\n|\n\*\*|$)`)
+	if matches := summaryRe.FindStringSubmatch(text); len(matches) > 1 {
+		result.ComparisonSummary = strings.TrimSpace(matches[1])
+	}
+
+	return result
+}
@@ -0,0 +1,74 @@
+package bedrockparser
+
+import (
+	"testing"
+
+	"workflow-function/ExecuteTurn2Combined/internal/models"
+)
+
+func TestParseTurn3Response(t *testing.T) {
+	turn2 := []models.Discrepancy{
+		{Item: "Pepsi", Expected: "A01", Type: "MISSING"},
+		{Item: "Coke", Expected: "B03", Found: "B04", Type: "MISPLACED"},
+		{Item: "Red Bull", Expected: "D2-A01", Type: "MISSING"},
+		{Item: "Sprite", Expected: "C10", Type: "MISSING"},
+	}
+
+	tests := []struct {
+		name      string
+		text      string
+		retracted []string
+		outcome   string
+	}{
+		{
+			name: "plain positions match zero-padded discrepancies",
+			text: `**VERDICTS:**
+- A1: RETRACTED - the coil holds a Pepsi can
+- **B3**: CONFIRMED
+- d2-a1: **RETRACTED** – product visible behind the glare
+- C10: CONFIRMED`,
+			retracted: []string{"A01", "D2-A01"},
+			outcome:   "INCORRECT",
+		},
+		{
+			name: "first verdict for a position wins",
+			text: `- A01: RETRACTED
+- A1: CONFIRMED
+- B03: RETRACTED
+- Position D2-A01: RETRACTED
+- C10: RETRACTED`,
+			retracted: []string{"A01", "B03", "D2-A01", "C10"},
+			outcome:   "CORRECT",
+		},
+		{
+			name:    "positions without a verdict are kept",
+			text:    "- A01: CONFIRMED",
+			outcome: "INCORRECT",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ParseTurn3Response(tt.text, turn2)
+			if len(result.Retracted) != len(tt.retracted) {
+				t.Fatalf("Expected %d retracted discrepancies, got %+v", len(tt.retracted), result.Retracted)
+			}
+			for i, position := range tt.retracted {
+				if result.Retracted[i].Expected != position {
+					t.Errorf("Retracted %d: expected %s, got %s", i, position, result.Retracted[i].Expected)
+				}
+			}
+			if len(result.Confirmed)+len(result.Retracted) != len(turn2) {
+				t.Errorf("Expected every discrepancy to be confirmed or retracted, got %d + %d", len(result.Confirmed), len(result.Retracted))
+			}
+			if result.VerificationOutcome != tt.outcome {
+				t.Errorf("Expected outcome %s, got %s", tt.outcome, result.VerificationOutcome)
+			}
+		})
+	}
+
+	result := ParseTurn3Response("- a1: retracted - glare", turn2)
+	if len(result.Verdicts) != 1 || result.Verdicts[0].Position != "A01" || result.Verdicts[0].Verdict != Turn3VerdictRetracted || result.Verdicts[0].Reason != "glare" {
+		t.Errorf("Unexpected verdicts %+v", result.Verdicts)
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"workflow-function/shared/errors"
+	"workflow-function/shared/examples"
+	"workflow-function/shared/experiments"
 )
 
 // Config bundles all environment settings required by the Lambda.
@@ -27,6 +30,13 @@ type Config struct {
 		BedrockConnectTimeoutSec int
 		BedrockCallTimeoutSec    int
 		DiscrepancyThreshold     int
+		ThinkingType             string
+		ThinkingBudgetTokens     int
+		EnsembleSize             int
+		EnsembleModels           []string
+		Turn3Enabled             bool
+		Turn3MaxDiscrepancies    int
+		Turn3CropEnabled         bool
 	}
 	Logging struct {
 		Level  string
@@ -36,6 +46,16 @@ type Config struct {
 		TemplateVersion      string
 		TemplateBasePath     string
 		Turn2TemplateVersion string
+		Turn3TemplateVersion string
+		// TemplateS3URI optionally overrides the bundled templates with
+		// templates stored in S3, e.g. s3://bucket/templates
+		TemplateS3URI string
+		// Experiments assigns verifications to prompt experiment variants,
+		// see PROMPT_EXPERIMENTS
+		Experiments *experiments.Registry
+		// FewShot selects reviewed verifications added to the Turn2
+		// conversation as prior turns, see FEW_SHOT_EXAMPLES_TABLE
+		FewShot examples.Options
 	}
 	DatePartitionTimezone string
 }
@@ -76,6 +96,13 @@ func LoadConfiguration() (*Config, error) {
 	cfg.Processing.BedrockConnectTimeoutSec = getInt("BEDROCK_CONNECT_TIMEOUT_SEC", 10)
 	cfg.Processing.BedrockCallTimeoutSec = getInt("BEDROCK_CALL_TIMEOUT_SEC", 30)
 	cfg.Processing.DiscrepancyThreshold = getInt("DISCREPANCY_THRESHOLD", 5)
+	cfg.Processing.ThinkingType = getEnv("THINKING_TYPE", "")
+	cfg.Processing.ThinkingBudgetTokens = getInt("THINKING_BUDGET_TOKENS", 0)
+	cfg.Processing.EnsembleSize = getInt("TURN2_ENSEMBLE_SIZE", 1)
+	cfg.Processing.EnsembleModels = getList("TURN2_ENSEMBLE_MODELS")
+	cfg.Processing.Turn3Enabled = getBool("TURN3_ENABLED", false)
+	cfg.Processing.Turn3MaxDiscrepancies = getInt("TURN3_MAX_DISCREPANCIES", 20)
+	cfg.Processing.Turn3CropEnabled = getBool("TURN3_CROP_ENABLED", true)
 
 	cfg.Logging.Level = getEnv("LOG_LEVEL", "INFO")
 	cfg.Logging.Format = getEnv("LOG_FORMAT", "json")
@@ -83,6 +110,16 @@ func LoadConfiguration() (*Config, error) {
 	cfg.Prompts.TemplateVersion = getEnv("TURN1_PROMPT_VERSION", "v1.0")
 	cfg.Prompts.TemplateBasePath = getEnv("TEMPLATE_BASE_PATH", "/opt/templates")
 	cfg.Prompts.Turn2TemplateVersion = getEnv("TURN2_PROMPT_VERSION", "v1.0")
+	cfg.Prompts.Turn3TemplateVersion = getEnv("TURN3_PROMPT_VERSION", "v1.0")
+	cfg.Prompts.TemplateS3URI = getEnv("TEMPLATE_S3_URI", "")
+	cfg.Prompts.Experiments, err = experiments.FromEnv()
+	if err != nil {
+		return nil, errors.NewConfigError("InvalidEnv", err.Error(), experiments.EnvPromptExperiments)
+	}
+	cfg.Prompts.FewShot, err = examples.FromEnv()
+	if err != nil {
+		return nil, errors.NewConfigError("InvalidEnv", err.Error(), examples.EnvExamplesTable)
+	}
 	cfg.DatePartitionTimezone = getEnv("DATE_PARTITION_TIMEZONE", "UTC")
 
 	// Validate configuration
@@ -116,6 +153,35 @@ func getInt(key string, def int) int {
 	return def
 }
 
+func getBool(key string, def bool) bool {
+	if v := os.Getenv(key); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+// getList splits a comma separated environment value, dropping empty entries.
+func getList(key string) []string {
+	var out []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// EnsembleModelFor returns the model used for the i-th ensemble sample.
+// Models rotate through TURN2_ENSEMBLE_MODELS and fall back to BEDROCK_MODEL.
+func (c *Config) EnsembleModelFor(i int) string {
+	if len(c.Processing.EnsembleModels) == 0 {
+		return c.AWS.BedrockModel
+	}
+	return c.Processing.EnsembleModels[i%len(c.Processing.EnsembleModels)]
+}
+
 func getFloat(key string, def float64) float64 {
 	if v := os.Getenv(key); v != "" {
 		if f, err := strconv.ParseFloat(v, 64); err == nil {
@@ -2,9 +2,14 @@ package config
 
 import (
 	"time"
+
+	sharedBedrock "workflow-function/shared/bedrock"
 	"workflow-function/shared/errors"
 )
 
+// MaxEnsembleSize caps the number of parallel Turn2 samples per verification.
+const MaxEnsembleSize = 7
+
 // Validate performs validation on the loaded configuration
 func (c *Config) Validate() error {
 	// Validate Bedrock timeouts
@@ -47,5 +52,50 @@ func (c *Config) Validate() error {
 			map[string]interface{}{"current_value": c.Processing.Temperature})
 	}
 
+	// Validate self-consistency ensemble settings
+	if c.Processing.EnsembleSize < 1 || c.Processing.EnsembleSize > MaxEnsembleSize {
+		return errors.NewValidationError("turn2 ensemble size out of range",
+			map[string]interface{}{
+				"current_value": c.Processing.EnsembleSize,
+				"minimum":       1,
+				"maximum":       MaxEnsembleSize,
+			})
+	}
+
+	// Turn3 also runs for requests with a turnConfig.maxTurns of 3 or more, so
+	// its settings are validated even when TURN3_ENABLED is off
+	if c.Processing.Turn3MaxDiscrepancies <= 0 {
+		return errors.NewConfigError(
+			"Turn3MaxDiscrepanciesInvalid",
+			"turn3 max discrepancies must be greater than 0",
+			"TURN3_MAX_DISCREPANCIES",
+		)
+	}
+
+	// Validate extended thinking settings
+	if c.Processing.ThinkingType != "" {
+		if c.Processing.ThinkingType != sharedBedrock.ThinkingTypeEnabled {
+			return errors.NewConfigError(
+				"ThinkingTypeInvalid",
+				"thinking type must be empty or \""+sharedBedrock.ThinkingTypeEnabled+"\"",
+				"THINKING_TYPE",
+			)
+		}
+		if c.Processing.ThinkingBudgetTokens < sharedBedrock.MinThinkingBudgetTokens {
+			return errors.NewValidationError("thinking budget is below the minimum",
+				map[string]interface{}{
+					"current_value": c.Processing.ThinkingBudgetTokens,
+					"minimum":       sharedBedrock.MinThinkingBudgetTokens,
+				})
+		}
+		if c.Processing.ThinkingBudgetTokens >= c.Processing.MaxTokens {
+			return errors.NewValidationError("thinking budget must be lower than max tokens",
+				map[string]interface{}{
+					"current_value": c.Processing.ThinkingBudgetTokens,
+					"max_tokens":    c.Processing.MaxTokens,
+				})
+		}
+	}
+
 	return nil
 }
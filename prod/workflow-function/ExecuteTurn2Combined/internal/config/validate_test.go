@@ -0,0 +1,29 @@
+package config
+
+import "testing"
+
+func TestValidateTurn3MaxDiscrepancies(t *testing.T) {
+	valid := func() *Config {
+		c := &Config{DatePartitionTimezone: "UTC"}
+		c.Processing.BedrockConnectTimeoutSec = 10
+		c.Processing.BedrockCallTimeoutSec = 30
+		c.Processing.Temperature = 0.7
+		c.Processing.EnsembleSize = 1
+		c.Processing.Turn3MaxDiscrepancies = 20
+		return c
+	}
+
+	if err := valid().Validate(); err != nil {
+		t.Fatalf("Expected a valid configuration, got %v", err)
+	}
+
+	// Requests with a maxTurns of 3 run Turn3 even when TURN3_ENABLED is off
+	for _, enabled := range []bool{true, false} {
+		c := valid()
+		c.Processing.Turn3Enabled = enabled
+		c.Processing.Turn3MaxDiscrepancies = 0
+		if err := c.Validate(); err == nil {
+			t.Errorf("Expected TURN3_MAX_DISCREPANCIES=0 to be rejected with TURN3_ENABLED=%v", enabled)
+		}
+	}
+}
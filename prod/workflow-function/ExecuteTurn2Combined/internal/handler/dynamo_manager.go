@@ -2,19 +2,21 @@ package handler
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"workflow-function/ExecuteTurn2Combined/internal/config"
 	"workflow-function/ExecuteTurn2Combined/internal/models"
-	"workflow-function/ExecuteTurn2Combined/internal/services"
 	"workflow-function/shared/errors"
 	"workflow-function/shared/logger"
 	"workflow-function/shared/schema"
+	"workflow-function/shared/turnexecutor"
 )
 
-// DynamoManager wraps DynamoDB operations used by the handler.
+// DynamoManager records the Turn2 and Turn3 outcomes on the verification and
+// conversation records.
 type DynamoManager struct {
-	dynamo services.DynamoDBService
+	status *turnexecutor.DynamoStatus
 	log    logger.Logger
 	config config.Config
 }
@@ -31,49 +33,69 @@ type Turn2Result struct {
 	Discrepancies        []schema.Discrepancy
 	ComparisonSummary    string
 	ConversationRef      *models.S3Reference
+	ReasoningRef         *models.S3Reference
+}
+
+// Turn3Result holds data required to finalize the Turn3 self-verification
+type Turn3Result struct {
+	VerificationID       string
+	VerificationAt       string
+	StatusEntry          schema.StatusHistoryEntry
+	TurnEntry            *schema.TurnResponse
+	Metrics              *schema.TurnMetrics
+	ProcessedMarkdownRef *models.S3Reference
+	VerificationStatus   string
+	Discrepancies        []schema.Discrepancy
+	Retracted            []schema.Discrepancy
+	ComparisonSummary    string
 }
 
 // NewDynamoManager creates a DynamoManager instance.
-func NewDynamoManager(dynamo services.DynamoDBService, cfg config.Config, log logger.Logger) *DynamoManager {
-	return &DynamoManager{dynamo: dynamo, log: log, config: cfg}
+func NewDynamoManager(status *turnexecutor.DynamoStatus, cfg config.Config, log logger.Logger) *DynamoManager {
+	return &DynamoManager{status: status, log: log, config: cfg}
 }
 
-// Update writes the final status and conversation turn. It returns true
-// only if both writes succeed.
-func (d *DynamoManager) UpdateTurn1Completion(
-	ctx context.Context,
-	verificationID string,
-	initialVerificationAt string,
-	statusEntry schema.StatusHistoryEntry,
-	turnEntry *schema.TurnResponse,
-	turn1Metrics *schema.TurnMetrics,
-	processedMarkdownRef *models.S3Reference,
-) bool {
+// UpdateTurn2Completion persists Turn2 processing results and status. It
+// returns true only if both the verification and conversation writes succeed.
+func (d *DynamoManager) UpdateTurn2Completion(ctx context.Context, res Turn2Result) bool {
 	dynamoOK := true
 
-	if err := d.dynamo.UpdateVerificationStatusEnhanced(ctx, verificationID, initialVerificationAt, statusEntry); err != nil {
-		d.logEnhancedDynamoDBError(err, "UpdateVerificationStatusEnhanced", verificationID, map[string]interface{}{
-			"verificationAt": initialVerificationAt,
-			"status":         statusEntry.Status,
-			"stage":          statusEntry.Stage,
-		})
-		dynamoOK = false
+	if ref := derefS3(res.ReasoningRef); ref.Key != "" && res.TurnEntry != nil {
+		if res.TurnEntry.Metadata == nil {
+			res.TurnEntry.Metadata = make(map[string]interface{})
+		}
+		res.TurnEntry.Metadata["turn2ReasoningPath"] = fmt.Sprintf("s3://%s/%s", ref.Bucket, ref.Key)
 	}
 
-	if err := d.dynamo.UpdateConversationTurn(ctx, verificationID, turnEntry); err != nil {
-		d.logEnhancedDynamoDBError(err, "UpdateConversationTurn", verificationID, map[string]interface{}{
-			"turnId":    turnEntry.TurnId,
-			"stage":     turnEntry.Stage,
-			"timestamp": turnEntry.Timestamp,
+	if err := d.status.CompleteTurn(ctx, res.VerificationID, res.VerificationAt, &turnexecutor.TurnCompletion{
+		Turn:               res.TurnEntry.TurnId,
+		Status:             res.StatusEntry,
+		Metrics:            res.Metrics,
+		ProcessedRef:       derefS3(res.ProcessedMarkdownRef),
+		ConversationRef:    derefS3(res.ConversationRef),
+		ReasoningRef:       derefS3(res.ReasoningRef),
+		VerificationStatus: res.VerificationStatus,
+		ComparisonSummary:  res.ComparisonSummary,
+		Discrepancies:      res.Discrepancies,
+	}); err != nil {
+		d.logEnhancedDynamoDBError(err, "CompleteTurn2", res.VerificationID, map[string]interface{}{
+			"verificationAt":       res.VerificationAt,
+			"hasMetrics":           res.Metrics != nil,
+			"hasProcessedRef":      res.ProcessedMarkdownRef != nil,
+			"verificationStatus":   res.VerificationStatus,
+			"discrepancyCount":     len(res.Discrepancies),
+			"hasComparisonSummary": res.ComparisonSummary != "",
+			"hasConversationRef":   res.ConversationRef != nil,
+			"hasReasoningRef":      res.ReasoningRef != nil && res.ReasoningRef.Key != "",
 		})
 		dynamoOK = false
 	}
 
-	if err := d.dynamo.UpdateTurn1CompletionDetails(ctx, verificationID, initialVerificationAt, statusEntry, turn1Metrics, processedMarkdownRef, nil); err != nil {
-		d.logEnhancedDynamoDBError(err, "UpdateTurn1CompletionDetails", verificationID, map[string]interface{}{
-			"verificationAt":     initialVerificationAt,
-			"hasMetrics":        turn1Metrics != nil,
-			"hasProcessedRef":   processedMarkdownRef != nil,
+	if err := d.status.UpdateConversationTurn(ctx, res.VerificationID, res.TurnEntry); err != nil {
+		d.logEnhancedDynamoDBError(err, "UpdateConversationTurn", res.VerificationID, map[string]interface{}{
+			"turnId":    res.TurnEntry.TurnId,
+			"stage":     res.TurnEntry.Stage,
+			"timestamp": res.TurnEntry.Timestamp,
 		})
 		dynamoOK = false
 	}
@@ -81,37 +103,19 @@ func (d *DynamoManager) UpdateTurn1Completion(
 	return dynamoOK
 }
 
-// UpdateTurn2Completion persists Turn2 processing results and status
-func (d *DynamoManager) UpdateTurn2Completion(ctx context.Context, res Turn2Result) bool {
+// UpdateTurn3Completion appends the Turn3 conversation entry and replaces the
+// Turn2 discrepancies with the self-verified list
+func (d *DynamoManager) UpdateTurn3Completion(ctx context.Context, res Turn3Result) bool {
 	dynamoOK := true
 
-	// Debug logging to track verificationID flow
-	d.log.Debug("UpdateTurn2Completion_called", map[string]interface{}{
-		"verification_id":        res.VerificationID,
-		"verification_id_empty":  res.VerificationID == "",
-		"verification_id_length": len(res.VerificationID),
-		"verification_at":        res.VerificationAt,
-		"turn_id":               res.TurnEntry.TurnId,
-		"turn_stage":            res.TurnEntry.Stage,
-	})
-
-	if err := d.dynamo.UpdateVerificationStatusEnhanced(ctx, res.VerificationID, res.VerificationAt, res.StatusEntry); err != nil {
-		d.logEnhancedDynamoDBError(err, "UpdateVerificationStatusEnhanced", res.VerificationID, map[string]interface{}{
-			"verificationAt": res.VerificationAt,
-			"status":         res.StatusEntry.Status,
-			"stage":          res.StatusEntry.Stage,
-		})
-		dynamoOK = false
+	if ref := derefS3(res.ProcessedMarkdownRef); ref.Key != "" && res.TurnEntry != nil {
+		if res.TurnEntry.Metadata == nil {
+			res.TurnEntry.Metadata = make(map[string]interface{})
+		}
+		res.TurnEntry.Metadata["turn3ProcessedPath"] = fmt.Sprintf("s3://%s/%s", ref.Bucket, ref.Key)
 	}
 
-	// Additional debug logging before UpdateConversationTurn
-	d.log.Debug("before_UpdateConversationTurn", map[string]interface{}{
-		"verification_id":        res.VerificationID,
-		"verification_id_empty":  res.VerificationID == "",
-		"turn_entry_not_nil":     res.TurnEntry != nil,
-	})
-
-	if err := d.dynamo.UpdateConversationTurn(ctx, res.VerificationID, res.TurnEntry); err != nil {
+	if err := d.status.UpdateConversationTurn(ctx, res.VerificationID, res.TurnEntry); err != nil {
 		d.logEnhancedDynamoDBError(err, "UpdateConversationTurn", res.VerificationID, map[string]interface{}{
 			"turnId":    res.TurnEntry.TurnId,
 			"stage":     res.TurnEntry.Stage,
@@ -120,15 +124,21 @@ func (d *DynamoManager) UpdateTurn2Completion(ctx context.Context, res Turn2Resu
 		dynamoOK = false
 	}
 
-	if err := d.dynamo.UpdateTurn2CompletionDetails(ctx, res.VerificationID, res.VerificationAt, res.StatusEntry, res.Metrics, res.ProcessedMarkdownRef, res.VerificationStatus, res.Discrepancies, res.ComparisonSummary, res.ConversationRef); err != nil {
-		d.logEnhancedDynamoDBError(err, "UpdateTurn2CompletionDetails", res.VerificationID, map[string]interface{}{
-			"verificationAt":       res.VerificationAt,
-			"hasMetrics":          res.Metrics != nil,
-			"hasProcessedRef":     res.ProcessedMarkdownRef != nil,
-			"verificationStatus":  res.VerificationStatus,
-			"discrepancyCount":    len(res.Discrepancies),
-			"hasComparisonSummary": res.ComparisonSummary != "",
-			"hasConversationRef":  res.ConversationRef != nil,
+	if err := d.status.CompleteTurn(ctx, res.VerificationID, res.VerificationAt, &turnexecutor.TurnCompletion{
+		Turn:               res.TurnEntry.TurnId,
+		Status:             res.StatusEntry,
+		Metrics:            res.Metrics,
+		ProcessedRef:       derefS3(res.ProcessedMarkdownRef),
+		VerificationStatus: res.VerificationStatus,
+		ComparisonSummary:  res.ComparisonSummary,
+		Discrepancies:      res.Discrepancies,
+		Retracted:          res.Retracted,
+	}); err != nil {
+		d.logEnhancedDynamoDBError(err, "CompleteTurn3", res.VerificationID, map[string]interface{}{
+			"verificationAt":     res.VerificationAt,
+			"verificationStatus": res.VerificationStatus,
+			"discrepancyCount":   len(res.Discrepancies),
+			"retractedCount":     len(res.Retracted),
 		})
 		dynamoOK = false
 	}
@@ -136,6 +146,13 @@ func (d *DynamoManager) UpdateTurn2Completion(ctx context.Context, res Turn2Resu
 	return dynamoOK
 }
 
+func derefS3(ref *models.S3Reference) models.S3Reference {
+	if ref == nil {
+		return models.S3Reference{}
+	}
+	return *ref
+}
+
 // logEnhancedDynamoDBError provides comprehensive DynamoDB error logging with detailed context
 func (d *DynamoManager) logEnhancedDynamoDBError(err error, operation string, verificationID string, context map[string]interface{}) {
 	startTime := time.Now()
@@ -203,7 +220,7 @@ func (d *DynamoManager) logEnhancedDynamoDBError(err error, operation string, ve
 // getTableNameForOperation returns the appropriate table name for the given operation
 func (d *DynamoManager) getTableNameForOperation(operation string) string {
 	switch operation {
-	case "UpdateVerificationStatusEnhanced", "UpdateTurn1CompletionDetails", "UpdateTurn2CompletionDetails":
+	case "CompleteTurn2", "CompleteTurn3", "AppendStatus", "UpdateErrorTracking":
 		return d.config.AWS.DynamoDBVerificationTable
 	case "UpdateConversationTurn":
 		return d.config.AWS.DynamoDBConversationTable
@@ -0,0 +1,514 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"workflow-function/ExecuteTurn2Combined/internal/bedrockparser"
+	"workflow-function/ExecuteTurn2Combined/internal/config"
+	"workflow-function/ExecuteTurn2Combined/internal/models"
+	"workflow-function/shared/bedrock"
+	"workflow-function/shared/errors"
+	"workflow-function/shared/logger"
+	"workflow-function/shared/schema"
+	"workflow-function/shared/templateloader"
+	"workflow-function/shared/turnexecutor"
+)
+
+// Verdicts used by the ensemble vote in addition to the discrepancy types
+// produced by the Turn2 parser.
+const (
+	VerdictOK         = "OK"
+	VerdictNoMajority = "NO_MAJORITY"
+)
+
+// EnsembleSample is a single independent Turn2 sample. Each sample is stored
+// on its own so the vote can be audited afterwards.
+type EnsembleSample struct {
+	Index          int                            `json:"index"`
+	ModelId        string                         `json:"modelId"`
+	Content        string                         `json:"content,omitempty"`
+	Thinking       string                         `json:"thinking,omitempty"`
+	Parsed         *bedrockparser.ParsedTurn2Data `json:"parsed,omitempty"`
+	TotalPositions int                            `json:"totalPositions,omitempty"`
+	TokenUsage     *schema.TokenUsage             `json:"tokenUsage,omitempty"`
+	LatencyMs      int64                          `json:"latencyMs"`
+	Error          string                         `json:"error,omitempty"`
+	Timestamp      string                         `json:"timestamp"`
+
+	response *bedrock.ConverseResponse
+	err      error
+}
+
+// Succeeded reports whether the sample produced a parseable response.
+func (s *EnsembleSample) Succeeded() bool {
+	return s != nil && s.err == nil && s.Parsed != nil
+}
+
+// PositionVote is the outcome of the vote for a single position.
+type PositionVote struct {
+	Position    string              `json:"position"`
+	Verdict     string              `json:"verdict"`
+	Votes       map[string]int      `json:"votes"`
+	Agreement   float64             `json:"agreement"`
+	Discrepancy *models.Discrepancy `json:"discrepancy,omitempty"`
+}
+
+// EnsembleResult aggregates all samples of a self-consistency run.
+type EnsembleResult struct {
+	SampleCount           int                  `json:"sampleCount"`
+	SuccessfulSamples     int                  `json:"successfulSamples"`
+	Models                []string             `json:"models"`
+	Positions             []PositionVote       `json:"positions"`
+	Discrepancies         []models.Discrepancy `json:"discrepancies"`
+	VerificationOutcome   string               `json:"verificationOutcome"`
+	OutcomeAgreement      float64              `json:"outcomeAgreement"`
+	MeanAgreement         float64              `json:"meanAgreement"`
+	TotalPositionsChecked int                  `json:"totalPositionsChecked,omitempty"`
+	DiscrepancyCounts     map[string]int       `json:"discrepancyCounts"`
+	ComparisonSummary     string               `json:"comparisonSummary"`
+	SampleRefs            []models.S3Reference `json:"sampleRefs,omitempty"`
+}
+
+// ParsedData converts the vote into the structure produced by the Turn2 parser
+// so the rest of the pipeline does not need to know about the ensemble.
+func (r *EnsembleResult) ParsedData() *bedrockparser.ParsedTurn2Data {
+	return &bedrockparser.ParsedTurn2Data{
+		Discrepancies:       r.Discrepancies,
+		VerificationOutcome: r.VerificationOutcome,
+		ComparisonSummary:   r.ComparisonSummary,
+	}
+}
+
+// Summary returns the compact form of the result used in response metadata.
+func (r *EnsembleResult) Summary() map[string]interface{} {
+	disputed := 0
+	for _, p := range r.Positions {
+		if p.Verdict == VerdictNoMajority {
+			disputed++
+		}
+	}
+	return map[string]interface{}{
+		"sampleCount":       r.SampleCount,
+		"successfulSamples": r.SuccessfulSamples,
+		"models":            r.Models,
+		"outcomeAgreement":  r.OutcomeAgreement,
+		"meanAgreement":     r.MeanAgreement,
+		"disputedPositions": disputed,
+	}
+}
+
+// ensembleConverser samples the Turn2 request several times in parallel and
+// answers with the voted result, so the turn executor runs a self-consistency
+// ensemble like a single call. The vote of the last call is kept for the
+// handler and recorded in the turn metadata.
+type ensembleConverser struct {
+	converser      turnexecutor.Converser
+	store          *turnexecutor.S3Store
+	cfg            config.Config
+	log            logger.Logger
+	verificationID string
+	result         *EnsembleResult
+}
+
+// newEnsemble returns the ensemble converser for one request.
+func (h *Turn2Handler) newEnsemble(verificationID string) *ensembleConverser {
+	return &ensembleConverser{
+		converser:      h.converser,
+		store:          h.store,
+		cfg:            h.cfg,
+		log:            h.log,
+		verificationID: verificationID,
+	}
+}
+
+// Converse runs the configured number of samples of request, votes on their
+// per-position verdicts and returns an aggregate response whose content is
+// the voted Markdown. A majority of samples must succeed.
+func (e *ensembleConverser) Converse(ctx context.Context, request *bedrock.ConverseRequest) (*bedrock.ConverseResponse, int64, error) {
+	size := e.cfg.Processing.EnsembleSize
+	samples := make([]*EnsembleSample, size)
+
+	e.log.Info("turn2_ensemble_started", map[string]interface{}{
+		"verification_id": e.verificationID,
+		"ensemble_size":   size,
+		"models":          e.cfg.Processing.EnsembleModels,
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < size; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sampleRequest := *request
+			if len(e.cfg.Processing.EnsembleModels) > 0 {
+				sampleRequest.ModelId = e.cfg.EnsembleModelFor(i)
+			}
+			resp, latencyMs, err := e.converser.Converse(ctx, &sampleRequest)
+			samples[i] = newEnsembleSample(i, sampleRequest.ModelId, resp, latencyMs, err)
+		}(i)
+	}
+	wg.Wait()
+
+	required := (size + 1) / 2
+	successful := 0
+	var firstErr error
+	for _, s := range samples {
+		if s.Succeeded() {
+			successful++
+			continue
+		}
+		e.log.Warn("turn2_ensemble_sample_failed", map[string]interface{}{
+			"verification_id": e.verificationID,
+			"sample_index":    s.Index,
+			"model_id":        s.ModelId,
+			"error":           s.Error,
+		})
+		if firstErr == nil && s.err != nil {
+			firstErr = s.err
+		}
+	}
+	if successful < required {
+		if firstErr == nil {
+			firstErr = errors.NewBedrockError("ensemble samples returned no usable content", "ENSEMBLE_EMPTY", true)
+		}
+		return nil, 0, errors.WrapError(firstErr, errors.ErrorTypeBedrock,
+			"not enough successful Turn2 ensemble samples", true).
+			WithContext("verification_id", e.verificationID).
+			WithContext("ensemble_size", size).
+			WithContext("successful_samples", successful).
+			WithContext("required_samples", required)
+	}
+
+	result := voteOnPositions(samples)
+
+	for _, s := range samples {
+		ref, err := e.store.StoreJSON(ctx, e.verificationID, fmt.Sprintf("responses/turn2-ensemble-sample-%d.json", s.Index), s)
+		if err != nil {
+			e.log.Warn("failed_to_store_turn2_ensemble_sample", map[string]interface{}{
+				"error":           err.Error(),
+				"verification_id": e.verificationID,
+				"sample_index":    s.Index,
+			})
+			continue
+		}
+		result.SampleRefs = append(result.SampleRefs, ref)
+	}
+	if _, err := e.store.StoreJSON(ctx, e.verificationID, "responses/turn2-ensemble-result.json", result); err != nil {
+		e.log.Warn("failed_to_store_turn2_ensemble_result", map[string]interface{}{
+			"error":           err.Error(),
+			"verification_id": e.verificationID,
+		})
+	}
+
+	e.log.Info("turn2_ensemble_completed", map[string]interface{}{
+		"verification_id":      e.verificationID,
+		"successful_samples":   result.SuccessfulSamples,
+		"voted_discrepancies":  len(result.Discrepancies),
+		"verification_outcome": result.VerificationOutcome,
+		"mean_agreement":       result.MeanAgreement,
+	})
+
+	e.result = result
+	resp, latencyMs := buildEnsembleResponse(samples, result)
+	return resp, latencyMs, nil
+}
+
+// TurnMetadata records the vote summary with the executed turn.
+func (e *ensembleConverser) TurnMetadata() map[string]interface{} {
+	if e.result == nil {
+		return nil
+	}
+	return map[string]interface{}{"ensemble": e.result.Summary()}
+}
+
+// newEnsembleSample records the outcome of one sample invocation.
+func newEnsembleSample(index int, modelID string, resp *bedrock.ConverseResponse, latencyMs int64, err error) *EnsembleSample {
+	s := &EnsembleSample{
+		Index:     index,
+		ModelId:   modelID,
+		LatencyMs: latencyMs,
+		Timestamp: schema.FormatISO8601(),
+		response:  resp,
+		err:       err,
+	}
+	if err != nil {
+		s.Error = err.Error()
+		return s
+	}
+	if resp == nil {
+		s.err = fmt.Errorf("empty Bedrock response")
+		s.Error = s.err.Error()
+		return s
+	}
+
+	if resp.ModelID != "" {
+		s.ModelId = resp.ModelID
+	}
+	s.Content = bedrock.ExtractTextFromResponse(resp)
+	s.Thinking = bedrock.ExtractThinkingFromResponse(resp)
+	if resp.Usage != nil {
+		s.TokenUsage = &schema.TokenUsage{
+			InputTokens:  resp.Usage.InputTokens,
+			OutputTokens: resp.Usage.OutputTokens,
+			TotalTokens:  resp.Usage.TotalTokens,
+		}
+	}
+
+	parsed, perr := bedrockparser.ParseTurn2Response(s.Content)
+	if perr != nil || parsed == nil {
+		s.err = fmt.Errorf("sample response could not be parsed")
+		if perr != nil {
+			s.err = perr
+		}
+		s.Error = s.err.Error()
+		return s
+	}
+	s.Parsed = parsed
+	s.TotalPositions = extractTotalPositions(s.Content)
+	return s
+}
+
+// positionKey normalises a position label so "a1", " A1 " and "A01" vote
+// together.
+func positionKey(position string) string {
+	return templateloader.NormalizePosition(position)
+}
+
+// voteOnPositions combines the successful samples by majority vote. Every
+// position reported by any sample is voted on; a sample that did not report a
+// position votes OK for it. A discrepancy is kept only when a strict majority
+// of samples agree on its type.
+func voteOnPositions(samples []*EnsembleSample) *EnsembleResult {
+	result := &EnsembleResult{
+		SampleCount:       len(samples),
+		Positions:         []PositionVote{},
+		Discrepancies:     []models.Discrepancy{},
+		DiscrepancyCounts: map[string]int{},
+	}
+
+	var ok []*EnsembleSample
+	seenModels := map[string]bool{}
+	for _, s := range samples {
+		if s == nil {
+			continue
+		}
+		if !seenModels[s.ModelId] {
+			seenModels[s.ModelId] = true
+			result.Models = append(result.Models, s.ModelId)
+		}
+		if s.Succeeded() {
+			ok = append(ok, s)
+		}
+	}
+	result.SuccessfulSamples = len(ok)
+	if len(ok) == 0 {
+		return result
+	}
+
+	// Index every sample's discrepancies by position
+	perSample := make([]map[string]models.Discrepancy, len(ok))
+	positions := map[string]bool{}
+	for i, s := range ok {
+		perSample[i] = map[string]models.Discrepancy{}
+		for _, d := range s.Parsed.Discrepancies {
+			key := positionKey(d.Expected)
+			if key == "" {
+				continue
+			}
+			if _, dup := perSample[i][key]; !dup {
+				perSample[i][key] = d
+			}
+			positions[key] = true
+		}
+	}
+
+	keys := make([]string, 0, len(positions))
+	for k := range positions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	total := float64(len(ok))
+	agreementSum := 0.0
+	for _, key := range keys {
+		vote := PositionVote{Position: key, Votes: map[string]int{}}
+		for _, found := range perSample {
+			verdict := VerdictOK
+			if d, has := found[key]; has {
+				verdict = d.Type
+			}
+			vote.Votes[verdict]++
+		}
+
+		top, topCount := majority(vote.Votes)
+		vote.Agreement = float64(topCount) / total
+		vote.Verdict = top
+		if topCount*2 <= len(ok) {
+			vote.Verdict = VerdictNoMajority
+		}
+		if vote.Verdict != VerdictOK && vote.Verdict != VerdictNoMajority {
+			for _, found := range perSample {
+				if d, has := found[key]; has && d.Type == vote.Verdict {
+					d := d
+					vote.Discrepancy = &d
+					result.Discrepancies = append(result.Discrepancies, d)
+					result.DiscrepancyCounts[d.Type]++
+					break
+				}
+			}
+		}
+		agreementSum += vote.Agreement
+		result.Positions = append(result.Positions, vote)
+	}
+
+	result.MeanAgreement = 1
+	if len(keys) > 0 {
+		result.MeanAgreement = agreementSum / float64(len(keys))
+	}
+
+	result.VerificationOutcome = "CORRECT"
+	if len(result.Discrepancies) > 0 {
+		result.VerificationOutcome = "INCORRECT"
+	}
+	agreeing := 0
+	var totals []int
+	for _, s := range ok {
+		if strings.EqualFold(s.Parsed.VerificationOutcome, result.VerificationOutcome) {
+			agreeing++
+		}
+		if s.TotalPositions > 0 {
+			totals = append(totals, s.TotalPositions)
+		}
+	}
+	result.OutcomeAgreement = float64(agreeing) / total
+	if len(totals) > 0 {
+		sort.Ints(totals)
+		result.TotalPositionsChecked = totals[len(totals)/2]
+	}
+	// Every voted position was checked, whatever the samples reported
+	if result.TotalPositionsChecked < len(keys) {
+		result.TotalPositionsChecked = len(keys)
+	}
+	result.ComparisonSummary = votedSummary(result)
+
+	return result
+}
+
+// votedSummary describes the voted discrepancies. It is built from the vote
+// rather than taken from a sample, whose summary may describe discrepancies
+// the vote removed.
+func votedSummary(r *EnsembleResult) string {
+	summary := fmt.Sprintf("Self-consistency vote over %d of %d samples, mean agreement %.0f%%: ",
+		r.SuccessfulSamples, r.SampleCount, r.MeanAgreement*100)
+	if len(r.Discrepancies) == 0 {
+		return summary + "no discrepancy was reported by a majority of samples."
+	}
+
+	types := make([]string, 0, len(r.DiscrepancyCounts))
+	for t := range r.DiscrepancyCounts {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	counts := make([]string, 0, len(types))
+	for _, t := range types {
+		counts = append(counts, fmt.Sprintf("%d %s", r.DiscrepancyCounts[t], t))
+	}
+	positions := make([]string, 0, len(r.Discrepancies))
+	for _, d := range r.Discrepancies {
+		positions = append(positions, fmt.Sprintf("%s (%s)", positionKey(d.Expected), d.Type))
+	}
+	return summary + fmt.Sprintf("%d discrepant position(s), %s: %s.",
+		len(r.Discrepancies), strings.Join(counts, ", "), strings.Join(positions, ", "))
+}
+
+// majority returns the verdict with the most votes. Ties are broken by name
+// so the result is deterministic.
+func majority(votes map[string]int) (string, int) {
+	best, bestCount := "", -1
+	for verdict, count := range votes {
+		if count > bestCount || (count == bestCount && verdict < best) {
+			best, bestCount = verdict, count
+		}
+	}
+	return best, bestCount
+}
+
+// renderEnsembleMarkdown renders the vote in the same layout as a single
+// Turn2 response so downstream parsers read it unchanged.
+func renderEnsembleMarkdown(r *EnsembleResult) string {
+	var b strings.Builder
+
+	b.WriteString("# Turn 2 Ensemble Verification\n\n")
+	fmt.Fprintf(&b, "Verification outcome: %s\n\n", r.VerificationOutcome)
+
+	writeDiscrepancyList(&b, "Voted discrepancies:", r.Discrepancies)
+	fmt.Fprintf(&b, "**COMPARISON SUMMARY:** %s\n\n", r.ComparisonSummary)
+	writeVerificationSummary(&b, r.TotalPositionsChecked, r.Discrepancies, fmt.Sprintf("%.0f%%", r.MeanAgreement*100), r.VerificationOutcome)
+
+	if len(r.Positions) > 0 {
+		b.WriteString("## Position Agreement\n\n")
+		b.WriteString("| Position | Verdict | Agreement | Votes |\n")
+		b.WriteString("|----------|---------|-----------|-------|\n")
+		for _, p := range r.Positions {
+			verdicts := make([]string, 0, len(p.Votes))
+			for v := range p.Votes {
+				verdicts = append(verdicts, v)
+			}
+			sort.Strings(verdicts)
+			parts := make([]string, 0, len(verdicts))
+			for _, v := range verdicts {
+				parts = append(parts, fmt.Sprintf("%s=%d", v, p.Votes[v]))
+			}
+			fmt.Fprintf(&b, "| %s | %s | %.0f%% | %s |\n", p.Position, p.Verdict, p.Agreement*100, strings.Join(parts, ", "))
+		}
+	}
+
+	return b.String()
+}
+
+// buildEnsembleResponse folds the samples into one Converse response. Token
+// usage is summed, the latency is the slowest sample's and the reasoning
+// blocks, request ID and stop reason are taken from the first successful
+// sample.
+func buildEnsembleResponse(samples []*EnsembleSample, result *EnsembleResult) (*bedrock.ConverseResponse, int64) {
+	agg := &bedrock.ConverseResponse{
+		ModelID: strings.Join(result.Models, ","),
+		Usage:   &bedrock.TokenUsage{},
+	}
+
+	var latencyMs int64
+	var primary *bedrock.ConverseResponse
+	for _, s := range samples {
+		if s == nil || s.response == nil {
+			continue
+		}
+		resp := s.response
+		if resp.Usage != nil {
+			agg.Usage.InputTokens += resp.Usage.InputTokens
+			agg.Usage.OutputTokens += resp.Usage.OutputTokens
+			agg.Usage.TotalTokens += resp.Usage.TotalTokens
+		}
+		if s.LatencyMs > latencyMs {
+			latencyMs = s.LatencyMs
+		}
+		if primary == nil && s.Succeeded() {
+			primary = resp
+		}
+	}
+
+	if primary != nil {
+		agg.RequestID = primary.RequestID
+		agg.StopReason = primary.StopReason
+		agg.Content = append(agg.Content, bedrock.ExtractReasoningBlocks(primary)...)
+	}
+	agg.Content = append(agg.Content, bedrock.ContentBlock{
+		Type: bedrock.ContentTypeText,
+		Text: renderEnsembleMarkdown(result),
+	})
+
+	return agg, latencyMs
+}
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"workflow-function/ExecuteTurn2Combined/internal/bedrockparser"
+	"workflow-function/ExecuteTurn2Combined/internal/models"
+)
+
+// sample returns a successful ensemble sample reporting the discrepancies,
+// given as position and type pairs
+func sample(model string, discrepancies ...string) *EnsembleSample {
+	parsed := &bedrockparser.ParsedTurn2Data{Discrepancies: []models.Discrepancy{}, VerificationOutcome: "CORRECT"}
+	for i := 0; i+1 < len(discrepancies); i += 2 {
+		parsed.Discrepancies = append(parsed.Discrepancies, models.Discrepancy{
+			Item:     "Item " + discrepancies[i],
+			Expected: discrepancies[i],
+			Type:     discrepancies[i+1],
+		})
+		parsed.VerificationOutcome = "INCORRECT"
+	}
+	return &EnsembleSample{ModelId: model, Parsed: parsed}
+}
+
+func TestVoteOnPositions(t *testing.T) {
+	failed := &EnsembleSample{ModelId: "m", err: errors.New("throttled"), Error: "throttled"}
+
+	tests := []struct {
+		name       string
+		samples    []*EnsembleSample
+		successful int
+		verdicts   map[string]string
+		kept       []string
+		outcome    string
+	}{
+		{
+			name:       "majority keeps a discrepancy",
+			samples:    []*EnsembleSample{sample("m", "A01", "MISSING"), sample("m", "A01", "MISSING"), sample("m")},
+			successful: 3,
+			verdicts:   map[string]string{"A01": "MISSING"},
+			kept:       []string{"A01"},
+			outcome:    "INCORRECT",
+		},
+		{
+			name:       "minority report is outvoted",
+			samples:    []*EnsembleSample{sample("m", "B02", "MISSING"), sample("m"), sample("m")},
+			successful: 3,
+			verdicts:   map[string]string{"B02": VerdictOK},
+			outcome:    "CORRECT",
+		},
+		{
+			name:       "zero-padded and plain keys vote together",
+			samples:    []*EnsembleSample{sample("m", "A1", "MISSING"), sample("m", "A01", "MISSING"), sample("m", " a01 ", "MISSING")},
+			successful: 3,
+			verdicts:   map[string]string{"A01": "MISSING"},
+			kept:       []string{"A01"},
+			outcome:    "INCORRECT",
+		},
+		{
+			name:       "type disagreement without a strict majority",
+			samples:    []*EnsembleSample{sample("m", "C03", "MISSING"), sample("m", "C03", "MISPLACED"), sample("m")},
+			successful: 3,
+			verdicts:   map[string]string{"C03": VerdictNoMajority},
+			outcome:    "CORRECT",
+		},
+		{
+			name:       "tie between two samples",
+			samples:    []*EnsembleSample{sample("m", "D04", "MISSING"), sample("m")},
+			successful: 2,
+			verdicts:   map[string]string{"D04": VerdictNoMajority},
+			outcome:    "CORRECT",
+		},
+		{
+			name:       "failed sample does not vote",
+			samples:    []*EnsembleSample{sample("m", "E05", "MISSING"), sample("m", "E5", "MISSING"), failed},
+			successful: 2,
+			verdicts:   map[string]string{"E05": "MISSING"},
+			kept:       []string{"E05"},
+			outcome:    "INCORRECT",
+		},
+		{
+			name:       "no successful sample",
+			samples:    []*EnsembleSample{failed, nil},
+			successful: 0,
+			verdicts:   map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := voteOnPositions(tt.samples)
+			if result.SuccessfulSamples != tt.successful {
+				t.Errorf("Expected %d successful samples, got %d", tt.successful, result.SuccessfulSamples)
+			}
+			if len(result.Positions) != len(tt.verdicts) {
+				t.Fatalf("Expected %d voted positions, got %+v", len(tt.verdicts), result.Positions)
+			}
+			for _, vote := range result.Positions {
+				if want, ok := tt.verdicts[vote.Position]; !ok || vote.Verdict != want {
+					t.Errorf("Position %s: expected verdict %q, got %q (votes %v)", vote.Position, want, vote.Verdict, vote.Votes)
+				}
+			}
+			if len(result.Discrepancies) != len(tt.kept) {
+				t.Fatalf("Expected discrepancies at %v, got %+v", tt.kept, result.Discrepancies)
+			}
+			for i, position := range tt.kept {
+				if positionKey(result.Discrepancies[i].Expected) != position {
+					t.Errorf("Discrepancy %d: expected %s, got %s", i, position, result.Discrepancies[i].Expected)
+				}
+			}
+			if result.VerificationOutcome != tt.outcome {
+				t.Errorf("Expected outcome %q, got %q", tt.outcome, result.VerificationOutcome)
+			}
+		})
+	}
+}
+
+func TestMajority(t *testing.T) {
+	tests := []struct {
+		votes   map[string]int
+		verdict string
+		count   int
+	}{
+		{map[string]int{VerdictOK: 2, "MISSING": 1}, VerdictOK, 2},
+		{map[string]int{"MISSING": 3}, "MISSING", 3},
+		// Ties go to the verdict sorting first
+		{map[string]int{VerdictOK: 1, "MISSING": 1}, "MISSING", 1},
+		{map[string]int{"MISPLACED": 2, "MISSING": 2, VerdictOK: 1}, "MISPLACED", 2},
+		{map[string]int{}, "", -1},
+	}
+
+	for _, tt := range tests {
+		verdict, count := majority(tt.votes)
+		if verdict != tt.verdict || count != tt.count {
+			t.Errorf("majority(%v) = %s, %d, want %s, %d", tt.votes, verdict, count, tt.verdict, tt.count)
+		}
+	}
+}
+
+func TestVoteOnPositionsBuildsSummaryFromVote(t *testing.T) {
+	// The first sample also reports B02, which the other two outvote
+	first := sample("m", "A01", "MISSING", "B02", "MISPLACED")
+	first.Parsed.ComparisonSummary = "A01 is missing and B02 is misplaced."
+	first.TotalPositions = 2
+	second := sample("m", "A1", "MISSING")
+	second.TotalPositions = 12
+	third := sample("m", "a01", "MISSING")
+	third.TotalPositions = 12
+
+	result := voteOnPositions([]*EnsembleSample{first, second, third})
+
+	if len(result.Discrepancies) != 1 || positionKey(result.Discrepancies[0].Expected) != "A01" {
+		t.Fatalf("Expected only the A01 discrepancy to be kept, got %+v", result.Discrepancies)
+	}
+	if want := map[string]int{"MISSING": 1}; !reflect.DeepEqual(result.DiscrepancyCounts, want) {
+		t.Errorf("Expected discrepancy counts %v, got %v", want, result.DiscrepancyCounts)
+	}
+	if result.TotalPositionsChecked != 12 {
+		t.Errorf("Expected the median of 12 positions checked, got %d", result.TotalPositionsChecked)
+	}
+	want := "Self-consistency vote over 3 of 3 samples, mean agreement 83%: 1 discrepant position(s), 1 MISSING: A01 (MISSING)."
+	if result.ComparisonSummary != want {
+		t.Errorf("Expected summary %q, got %q", want, result.ComparisonSummary)
+	}
+
+	markdown := renderEnsembleMarkdown(result)
+	for _, line := range []string{
+		"* **Total Positions Checked:** 12",
+		"* **Correct Positions:** 11",
+		"* **Discrepant Positions:** 1",
+		"    * Missing Products: 1",
+	} {
+		if !strings.Contains(markdown, line+"\n") {
+			t.Errorf("Expected %q in the voted Markdown:\n%s", line, markdown)
+		}
+	}
+	if strings.Contains(markdown, "B02") && !strings.Contains(markdown, "| B02 | OK |") {
+		t.Errorf("Expected B02 only in the agreement table:\n%s", markdown)
+	}
+
+	clean := voteOnPositions([]*EnsembleSample{sample("m", "C03", "MISSING"), sample("m"), sample("m")})
+	if !strings.HasSuffix(clean.ComparisonSummary, "no discrepancy was reported by a majority of samples.") || clean.TotalPositionsChecked != 1 {
+		t.Errorf("Unexpected summary %q with %d positions checked", clean.ComparisonSummary, clean.TotalPositionsChecked)
+	}
+}
@@ -6,23 +6,23 @@ import (
 	"fmt"
 	"strings"
 	"workflow-function/ExecuteTurn2Combined/internal/models"
-	"workflow-function/ExecuteTurn2Combined/internal/services"
 	"workflow-function/shared/errors"
 	"workflow-function/shared/logger"
 	"workflow-function/shared/schema"
+	"workflow-function/shared/turnexecutor"
 )
 
 // EventTransformer orchestrates Step Functions event transformation with strategic schema integration
 type EventTransformer struct {
-	s3  services.S3StateManager
-	log logger.Logger
+	store *turnexecutor.S3Store
+	log   logger.Logger
 }
 
 // NewEventTransformer creates a strategically enhanced event transformer
-func NewEventTransformer(s3 services.S3StateManager, log logger.Logger) *EventTransformer {
+func NewEventTransformer(store *turnexecutor.S3Store, log logger.Logger) *EventTransformer {
 	return &EventTransformer{
-		s3:  s3,
-		log: log,
+		store: store,
+		log:   log,
 	}
 }
 
@@ -95,7 +95,7 @@ func (e *EventTransformer) TransformStepFunctionEvent(ctx context.Context, event
 		"size":   initRef.Size,
 	})
 
-	initData, err := e.s3.LoadInitializationData(ctx, initRef)
+	initData, err := e.store.LoadInitializationData(ctx, initRef)
 	if err != nil {
 		transformLogger.Error("initialization_data_load_failed", map[string]interface{}{
 			"error":  err.Error(),
@@ -171,7 +171,7 @@ func (e *EventTransformer) TransformStepFunctionEvent(ctx context.Context, event
 	})
 
 	// STRATEGIC ARCHITECTURE: Use schema-enhanced image metadata loader
-	metadata, err := e.s3.LoadImageMetadata(ctx, metadataRef)
+	metadata, err := e.store.LoadImageMetadata(ctx, metadataRef)
 	if err != nil {
 		transformLogger.Error("image_metadata_load_failed", map[string]interface{}{
 			"error":  err.Error(),
@@ -235,7 +235,7 @@ func (e *EventTransformer) TransformStepFunctionEvent(ctx context.Context, event
 				})
 
 				// STRATEGIC ENHANCEMENT: Use shared schema layout metadata loader
-				layoutData, err := e.s3.LoadLayoutMetadata(ctx, layoutMetadataRef)
+				layoutData, err := e.store.LoadLayoutMetadata(ctx, layoutMetadataRef)
 				if err != nil {
 					transformLogger.Warn("layout_metadata_load_failed", map[string]interface{}{
 						"error":  err.Error(),
@@ -476,9 +476,13 @@ func convertSchemaToLocalVerificationContext(schemaCtx schema.VerificationContex
 		VendingMachineId:  schemaCtx.VendingMachineId,
 		LayoutId:          schemaCtx.LayoutId,
 		LayoutPrefix:      schemaCtx.LayoutPrefix,
+		Locale:            schemaCtx.Locale,
 		LayoutMetadata:    extractLayoutMetadataMap(layoutMetadata),
 		HistoricalContext: extractHistoricalContextMap(schemaCtx),
 	}
+	if schemaCtx.TurnConfig != nil {
+		localCtx.MaxTurns = schemaCtx.TurnConfig.MaxTurns
+	}
 
 	return localCtx
 }
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"workflow-function/ExecuteTurn2Combined/internal/models"
+	"workflow-function/shared/bedrock"
+	"workflow-function/shared/examples"
+	"workflow-function/shared/schema"
+)
+
+// selectFewShotExamples returns the reviewed examples most relevant to the
+// verification with their images loaded, which are added to the Turn2
+// conversation as prior turns. Failures are logged and the turn continues
+// without the affected examples.
+func (h *Turn2Handler) selectFewShotExamples(ctx context.Context, req *models.Turn2Request, layoutMetadata map[string]interface{}) []examples.Turn {
+	opts := h.cfg.Prompts.FewShot
+	if !opts.Enabled() {
+		return nil
+	}
+	candidates, err := h.examples.Load(ctx, req.VerificationContext.VerificationType)
+	if err != nil {
+		h.log.Warn("few_shot_examples_load_failed", map[string]interface{}{
+			"verification_id": req.VerificationID,
+			"error":           err.Error(),
+		})
+		return nil
+	}
+
+	query := examples.Query{
+		VerificationID:   req.VerificationID,
+		VerificationType: req.VerificationContext.VerificationType,
+		LayoutID:         req.VerificationContext.LayoutId,
+		LayoutPrefix:     req.VerificationContext.LayoutPrefix,
+		VendingMachineID: req.VerificationContext.VendingMachineId,
+		Products:         layoutProductNames(layoutMetadata),
+	}
+	if model, ok := layoutMetadata["MachineModel"].(string); ok {
+		query.MachineModel = model
+	}
+
+	var turns []examples.Turn
+	var ids []string
+	for _, selected := range examples.Select(candidates, query, opts) {
+		turn, err := h.loadFewShotImages(ctx, selected)
+		if err != nil {
+			h.log.Warn("few_shot_example_images_load_failed", map[string]interface{}{
+				"verification_id": req.VerificationID,
+				"example_id":      selected.Example.ExampleID,
+				"error":           err.Error(),
+			})
+			continue
+		}
+		turns = append(turns, turn)
+		ids = append(ids, selected.Example.ExampleID)
+	}
+
+	h.log.Info("few_shot_examples_selected", map[string]interface{}{
+		"verification_id":  req.VerificationID,
+		"candidates":       len(candidates),
+		"example_ids":      ids,
+		"estimated_tokens": fewShotTokens(turns),
+		"token_budget":     opts.TokenBudget,
+	})
+	return turns
+}
+
+// loadFewShotImages loads the base64 images of a selected example from the
+// state bucket
+func (h *Turn2Handler) loadFewShotImages(ctx context.Context, selected examples.Selected) (examples.Turn, error) {
+	turn := examples.Turn{Selected: selected}
+	var err error
+	turn.ReferenceBase64, err = h.store.LoadBase64Image(ctx, schema.S3Reference{Bucket: h.store.Bucket(), Key: selected.Example.ReferenceImage.Key})
+	if err != nil {
+		return turn, err
+	}
+	turn.CheckingBase64, err = h.store.LoadBase64Image(ctx, schema.S3Reference{Bucket: h.store.Bucket(), Key: selected.Example.CheckingImage.Key})
+	return turn, err
+}
+
+// recordFewShotExamples stores the examples added to the Turn2 conversation
+// on the verification record. Failures are logged and do not fail the turn.
+func (h *Turn2Handler) recordFewShotExamples(ctx context.Context, req *models.Turn2Request, turns []examples.Turn) {
+	if len(turns) == 0 {
+		return
+	}
+	usage := make([]examples.Usage, len(turns))
+	for i, turn := range turns {
+		usage[i] = turn.Usage()
+	}
+	if err := h.status.RecordFewShotExamples(ctx, req.VerificationID, req.VerificationContext.VerificationAt, usage); err != nil {
+		h.log.Warn("few_shot_examples_recording_failed", map[string]interface{}{
+			"verification_id": req.VerificationID,
+			"error":           err.Error(),
+		})
+	}
+}
+
+// fewShotMessages returns the example turns as user and assistant messages:
+// the example's images with an introduction, then its reviewed answer
+func fewShotMessages(turns []examples.Turn) []bedrock.MessageWrapper {
+	messages := make([]bedrock.MessageWrapper, 0, 2*len(turns))
+	for i, turn := range turns {
+		messages = append(messages,
+			bedrock.CreateUserMessageWithContent(turn.Example.Prompt(i+1, len(turns)), []bedrock.ContentBlock{
+				bedrock.CreateImageContentFromBytes(turn.Example.ReferenceImage.Format, turn.ReferenceBase64),
+				bedrock.CreateImageContentFromBytes(turn.Example.CheckingImage.Format, turn.CheckingBase64),
+			}),
+			bedrock.CreateAssistantMessageWithText(turn.Example.ReviewedAnswer()),
+		)
+	}
+	return messages
+}
+
+func fewShotTokens(turns []examples.Turn) int {
+	total := 0
+	for _, turn := range turns {
+		total += turn.EstimatedTokens
+	}
+	return total
+}
+
+// layoutProductNames returns the distinct product names of the layout's
+// product position map, sorted
+func layoutProductNames(layoutMetadata map[string]interface{}) []string {
+	positions, _ := layoutMetadata["ProductPositionMap"].(map[string]interface{})
+	seen := make(map[string]bool)
+	var names []string
+	for _, info := range positions {
+		product, ok := info.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := product["productTemplateName"].(string)
+		name = strings.TrimSpace(name)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"workflow-function/shared/examples"
+)
+
+func TestFewShotMessages(t *testing.T) {
+	turns := []examples.Turn{{
+		Selected: examples.Selected{Example: examples.Example{
+			ExampleID:      "ex-1",
+			VerificationID: "verif-0",
+			ReferenceImage: examples.ImageRef{Key: "ref.base64", Format: "jpg"},
+			CheckingImage:  examples.ImageRef{Key: "chk.base64", Format: "png"},
+			Positions:      []examples.PositionAnswer{{Position: "A01", Expected: "Coke", Found: "Pepsi", Issue: "INCORRECT_PRODUCT_TYPE"}},
+		}},
+		ReferenceBase64: "cmVm",
+		CheckingBase64:  "Y2hr",
+	}}
+
+	msgs := fewShotMessages(turns)
+	if len(msgs) != 2 || msgs[0].Role != "user" || msgs[1].Role != "assistant" {
+		t.Fatalf("expected a user and an assistant message, got %+v", msgs)
+	}
+	user := msgs[0].Content
+	if len(user) != 3 || !strings.HasPrefix(user[0].Text, "[Example 1 of 1]") {
+		t.Fatalf("expected the introduction and two images, got %+v", user)
+	}
+	if user[1].Image.Format != "jpeg" || user[1].Image.Source.Bytes != "cmVm" || user[2].Image.Source.Bytes != "Y2hr" {
+		t.Errorf("unexpected example images %+v, %+v", user[1].Image, user[2].Image)
+	}
+	if !strings.Contains(msgs[1].Content[0].Text, "A01") {
+		t.Errorf("expected the reviewed answer, got %q", msgs[1].Content[0].Text)
+	}
+	if fewShotMessages(nil) == nil || len(fewShotMessages(nil)) != 0 {
+		t.Error("expected no messages without examples")
+	}
+}
+
+func TestLayoutProductNames(t *testing.T) {
+	layout := map[string]interface{}{
+		"ProductPositionMap": map[string]interface{}{
+			"A01": map[string]interface{}{"productTemplateName": "Pepsi"},
+			"A02": map[string]interface{}{"productTemplateName": " Coke "},
+			"B01": map[string]interface{}{"productTemplateName": "Pepsi"},
+			"B02": "invalid",
+		},
+	}
+	if got := layoutProductNames(layout); !reflect.DeepEqual(got, []string{"Coke", "Pepsi"}) {
+		t.Errorf("unexpected product names %v", got)
+	}
+	if got := layoutProductNames(nil); got != nil {
+		t.Errorf("expected no products without a layout, got %v", got)
+	}
+}
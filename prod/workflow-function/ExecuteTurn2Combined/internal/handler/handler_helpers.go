@@ -2,11 +2,14 @@ package handler
 
 import (
 	"context"
+	"strings"
 	"time"
 	"workflow-function/ExecuteTurn2Combined/internal/models"
 	"workflow-function/shared/errors"
+	"workflow-function/shared/experiments"
 	"workflow-function/shared/logger"
 	"workflow-function/shared/schema"
+	"workflow-function/shared/turnexecutor"
 )
 
 // initializeProcessingMetrics creates initial processing metrics
@@ -33,9 +36,10 @@ func (h *Turn2Handler) createContextLogger(req *models.Turn2Request) logger.Logg
 	})
 }
 
-// updateStatus updates status with error handling
-func (h *Turn2Handler) updateStatus(ctx context.Context, verificationID, status, stage string, metadata map[string]interface{}) {
-	if err := h.statusTracker.UpdateStatusWithHistory(ctx, verificationID, status, stage, metadata); err != nil {
+// updateStatus records the current status on the verification record. Failures
+// are logged and do not fail the turn.
+func (h *Turn2Handler) updateStatus(ctx context.Context, req *models.Turn2Request, status string, metadata map[string]interface{}) {
+	if err := h.status.UpdateCurrentStatus(ctx, req.VerificationID, req.VerificationContext.VerificationAt, status, metadata); err != nil {
 		h.log.Warn("failed to update status", map[string]interface{}{
 			"error":  err.Error(),
 			"status": status,
@@ -43,152 +47,278 @@ func (h *Turn2Handler) updateStatus(ctx context.Context, verificationID, status,
 	}
 }
 
-// handleContextLoadError handles errors during context loading
-func (h *Turn2Handler) handleContextLoadError(ctx context.Context, verificationID string, loadResult *LoadResult, contextLogger logger.Logger) (*schema.CombinedTurnResponse, error) {
-	h.processingTracker.RecordStage("context_loading", "failed", loadResult.Duration, map[string]interface{}{
-		"s3_operations": 2,
-		"error_type":    "s3_retrieval_failure",
-	})
-
-	h.updateStatus(ctx, verificationID, schema.StatusTurn2Error, "context_loading_failed", map[string]interface{}{
-		"error_details": loadResult.Error.Error(),
-	})
-
-	if workflowErr, ok := loadResult.Error.(*errors.WorkflowError); ok {
-		contextLogger.Error("turn2_context_loading_error", map[string]interface{}{
-			"error_type":    string(workflowErr.Type),
-			"error_code":    workflowErr.Code,
-			"retryable":     workflowErr.Retryable,
-			"severity":      string(workflowErr.Severity),
-			"s3_operations": 2,
-		})
+// turnError classifies a failure of the executed turn by the stage that
+// failed. It returns the enriched error and the stage recorded with it.
+func (h *Turn2Handler) turnError(req *models.Turn2Request, err error, spec *turnexecutor.TurnSpec) (*errors.WorkflowError, string) {
+	operation := ""
+	if wfErr, ok := err.(*errors.WorkflowError); ok {
+		operation = wfErr.Operation
+	}
+	switch operation {
+	case turnexecutor.OperationLoadInputs:
+		return h.contextLoadError(req, err), "context_loading"
+	case turnexecutor.OperationRenderPrompt:
+		return h.promptError(req, err, spec), "prompt_generation"
+	case turnexecutor.OperationParseResponse:
+		return h.parseError(err, req, nil, "ParseTurn2Response", "failed to parse Turn2 response"), "response_parsing"
+	default:
+		return h.bedrockError(req, err, spec), "bedrock_invocation"
 	}
-
-	return nil, loadResult.Error
 }
 
-// recordContextLoadSuccess records successful context loading
-func (h *Turn2Handler) recordContextLoadSuccess(ctx context.Context, verificationID string, loadResult *LoadResult) {
-	h.processingTracker.RecordStage("context_loading", "completed", loadResult.Duration, map[string]interface{}{
-		"s3_operations":        2,
-		"concurrent_loading":   true,
-		"system_prompt_length": len(loadResult.SystemPrompt),
-		"image_data_length":    len(loadResult.Base64Image),
-	})
-
-	h.updateStatus(ctx, verificationID, schema.StatusTurn2ContextLoaded, "context_loading", map[string]interface{}{
-		"system_prompt_size":  len(loadResult.SystemPrompt),
-		"image_size":          len(loadResult.Base64Image),
-		"loading_duration_ms": loadResult.Duration.Milliseconds(),
+// contextLoadError builds the error recorded when the Turn2 inputs cannot be
+// loaded
+func (h *Turn2Handler) contextLoadError(req *models.Turn2Request, loadErr error) *errors.WorkflowError {
+	wfErr := errors.WrapError(loadErr, errors.ErrorTypeS3,
+		"failed to load Turn2 context", true).
+		WithContext("verification_id", req.VerificationID).
+		WithContext("stage", "context_loading").
+		WithComponent("S3Store").
+		WithOperation("LoadContextTurn2").
+		WithCategory(errors.CategoryTransient).
+		WithRetryStrategy(errors.RetryExponential).
+		SetMaxRetries(3).
+		WithSeverity(errors.ErrorSeverityHigh).
+		WithSuggestions(
+			"Check S3 bucket permissions and connectivity",
+			"Verify that all required S3 objects exist",
+			"Ensure proper IAM roles are configured",
+		).
+		WithRecoveryHints(
+			"Retry the operation after a brief delay",
+			"Check AWS service status for S3 outages",
+			"Validate S3 bucket and key configurations",
+		)
+
+	h.log.Error("context_loading_failed", map[string]interface{}{
+		"error_type":     string(wfErr.Type),
+		"error_code":     wfErr.Code,
+		"message":        wfErr.Message,
+		"retryable":      wfErr.Retryable,
+		"severity":       string(wfErr.Severity),
+		"category":       string(wfErr.Category),
+		"retry_strategy": string(wfErr.RetryStrategy),
+		"component":      wfErr.Component,
+		"operation":      wfErr.Operation,
+		"suggestions":    wfErr.Suggestions,
+		"recovery_hints": wfErr.RecoveryHints,
 	})
+	return wfErr
 }
 
-// PromptResult contains prompt generation results
-type PromptResult struct {
-	Prompt            string
-	TemplateProcessor *schema.TemplateProcessor
-	Duration          time.Duration
-	Error             error
+// turn1LoadError builds the error recorded when the Turn1 response replayed
+// as history cannot be loaded
+func (h *Turn2Handler) turn1LoadError(req *models.Turn2Request, loadErr error) *errors.WorkflowError {
+	wfErr := errors.WrapError(loadErr, errors.ErrorTypeS3,
+		"failed to load Turn1 raw response", true).
+		WithContext("verification_id", req.VerificationID).
+		WithContext("stage", "context_loading").
+		WithContext("s3_key", req.S3Refs.Turn1.RawResponse.Key).
+		WithComponent("S3Store").
+		WithOperation("LoadTurnResponse").
+		WithCategory(errors.CategoryTransient).
+		WithRetryStrategy(errors.RetryExponential).
+		SetMaxRetries(3).
+		WithSeverity(errors.ErrorSeverityMedium).
+		WithSuggestions(
+			"Verify Turn1 response was properly stored",
+			"Check S3 object existence and permissions",
+			"Ensure Turn1 processing completed successfully",
+		).
+		WithRecoveryHints(
+			"Re-run Turn1 processing if response is missing",
+			"Check S3 bucket configuration and access policies",
+			"Validate the S3 key format and bucket name",
+		)
+
+	h.log.Error("turn1_raw_load_failed", map[string]interface{}{
+		"error_type":     string(wfErr.Type),
+		"error_code":     wfErr.Code,
+		"message":        wfErr.Message,
+		"retryable":      wfErr.Retryable,
+		"severity":       string(wfErr.Severity),
+		"category":       string(wfErr.Category),
+		"retry_strategy": string(wfErr.RetryStrategy),
+		"component":      wfErr.Component,
+		"operation":      wfErr.Operation,
+		"s3_key":         req.S3Refs.Turn1.RawResponse.Key,
+		"suggestions":    wfErr.Suggestions,
+		"recovery_hints": wfErr.RecoveryHints,
+	})
+	return wfErr
 }
 
-// generateTurn2Prompt generates the Turn2 prompt with Turn1 context
-func (h *Turn2Handler) generateTurn2Prompt(ctx context.Context, req *models.Turn2Request, systemPrompt string, turn1Response *schema.TurnResponse, turn1RawResponse []byte) *PromptResult {
-	startTime := time.Now()
-
-	// Create verification context for Turn2
-	vCtx := &schema.VerificationContext{
-		VerificationId:   req.VerificationID,
-		VerificationAt:   req.VerificationContext.VerificationAt,
-		VerificationType: req.VerificationContext.VerificationType,
-		VendingMachineId: req.VerificationContext.VendingMachineId,
-		LayoutId:         req.VerificationContext.LayoutId,
-		LayoutPrefix:     req.VerificationContext.LayoutPrefix,
+// promptError builds the error recorded when the Turn2 prompt cannot be
+// rendered. Errors already classified by the renderer, e.g. an exceeded token
+// budget, keep their type.
+func (h *Turn2Handler) promptError(req *models.Turn2Request, promptErr error, spec *turnexecutor.TurnSpec) *errors.WorkflowError {
+	wfErr, ok := promptErr.(*errors.WorkflowError)
+	if !ok {
+		wfErr = errors.WrapError(promptErr, errors.ErrorTypeTemplate,
+			"failed to generate Turn2 prompt", false)
 	}
-
-	prompt, templateProcessor, err := h.promptService.GenerateTurn2PromptWithMetrics(
-		ctx,
-		vCtx,
-		systemPrompt,
-		turn1Response,
-		turn1RawResponse,
-		nil, // TODO: Pass layout metadata when available
-	)
-
-	return &PromptResult{
-		Prompt:            prompt,
-		TemplateProcessor: templateProcessor,
-		Duration:          time.Since(startTime),
-		Error:             err,
-	}
-}
-
-// handlePromptError handles errors during prompt generation
-func (h *Turn2Handler) handlePromptError(ctx context.Context, verificationID string, result *PromptResult, contextLogger logger.Logger) (*schema.CombinedTurnResponse, error) {
-	h.processingTracker.RecordStage("prompt_generation", "failed", result.Duration, map[string]interface{}{
-		"template_version": h.cfg.Prompts.TemplateVersion,
-		"error_type":       "prompt_generation_failure",
-	})
-
-	h.updateStatus(ctx, verificationID, schema.StatusTemplateProcessingError, "prompt_generation_failed", map[string]interface{}{
-		"error_details": result.Error.Error(),
-	})
-
-	promptErr := errors.NewInternalError("prompt_service", result.Error).
-		WithContext("template_version", h.cfg.Prompts.TemplateVersion)
-
-	enrichedErr := errors.SetVerificationID(promptErr, verificationID)
-
-	contextLogger.Error("turn2_prompt_generation_error", map[string]interface{}{
-		"template_version": h.cfg.Prompts.TemplateVersion,
-		"error":            result.Error.Error(),
+	wfErr = wfErr.
+		WithContext("verification_id", req.VerificationID).
+		WithContext("stage", "prompt_generation").
+		WithContext("verification_type", string(req.VerificationContext.VerificationType)).
+		WithContext("template_type", spec.TemplateType).
+		WithContext("template_version", spec.TemplateVersion).
+		WithComponent("PromptRenderer").
+		WithOperation("GenerateTurn2Prompt").
+		WithCategory(errors.CategoryPermanent).
+		WithRetryStrategy(errors.RetryNone).
+		WithSeverity(errors.ErrorSeverityCritical).
+		WithSuggestions(
+			"Check template syntax and variable bindings",
+			"Verify all required template variables are provided",
+			"Ensure template files exist and are accessible",
+			"Validate Turn1 response format compatibility",
+		).
+		WithRecoveryHints(
+			"Review template configuration and syntax",
+			"Check template variable mappings",
+			"Verify Turn1 response structure matches expectations",
+			"Ensure layout metadata format is correct",
+		)
+
+	h.log.Error("prompt_generation_failed", map[string]interface{}{
+		"error_type":        string(wfErr.Type),
+		"error_code":        wfErr.Code,
+		"message":           wfErr.Message,
+		"retryable":         wfErr.Retryable,
+		"severity":          string(wfErr.Severity),
+		"category":          string(wfErr.Category),
+		"retry_strategy":    string(wfErr.RetryStrategy),
+		"component":         wfErr.Component,
+		"operation":         wfErr.Operation,
+		"verification_type": string(req.VerificationContext.VerificationType),
+		"suggestions":       wfErr.Suggestions,
+		"recovery_hints":    wfErr.RecoveryHints,
 	})
-
-	return nil, enrichedErr
+	return wfErr
 }
 
-// handleBedrockError handles errors during Bedrock invocation
-func (h *Turn2Handler) handleBedrockError(ctx context.Context, verificationID string, result *InvokeResult) (*schema.CombinedTurnResponse, error) {
-	h.processingTracker.RecordStage("bedrock_invocation", "failed", result.Duration, map[string]interface{}{
-		"model_id":   h.cfg.AWS.BedrockModel,
-		"max_tokens": h.cfg.Processing.MaxTokens,
-		"error_type": "bedrock_api_failure",
-	})
+// bedrockError builds the error recorded when the Bedrock call fails. The
+// retry strategy follows the error type reported by the Bedrock client.
+func (h *Turn2Handler) bedrockError(req *models.Turn2Request, bedrockErr error, spec *turnexecutor.TurnSpec) *errors.WorkflowError {
+	// Determine error category and retry strategy based on error type
+	category := errors.CategoryServer
+	retryStrategy := errors.RetryExponential
+	severity := errors.ErrorSeverityHigh
+	maxRetries := 3
+
+	errorType := errors.ErrorTypeBedrock
+	if wfErr, ok := bedrockErr.(*errors.WorkflowError); ok {
+		errorType = wfErr.Type
+	}
+	errorStr := strings.ToLower(bedrockErr.Error())
+	switch {
+	case errorType == errors.ErrorTypeThrottling || strings.Contains(errorStr, "throttling") || strings.Contains(errorStr, "rate limit"):
+		category = errors.CategoryCapacity
+		retryStrategy = errors.RetryJittered
+		severity = errors.ErrorSeverityMedium
+		maxRetries = 5
+	case errorType == errors.ErrorTypeValidation || strings.Contains(errorStr, "validation") || strings.Contains(errorStr, "invalid"):
+		category = errors.CategoryClient
+		retryStrategy = errors.RetryNone
+		severity = errors.ErrorSeverityCritical
+		maxRetries = 0
+	case errorType == errors.ErrorTypeTimeout || strings.Contains(errorStr, "timeout"):
+		category = errors.CategoryNetwork
+		retryStrategy = errors.RetryLinear
+		severity = errors.ErrorSeverityHigh
+		maxRetries = 2
+	}
 
-	h.updateStatus(ctx, verificationID, schema.StatusTurn2Error, "bedrock_invocation_failed", map[string]interface{}{
-		"error_details": result.Error.Error(),
+	wfErr, ok := bedrockErr.(*errors.WorkflowError)
+	if !ok {
+		wfErr = errors.WrapError(bedrockErr, errors.ErrorTypeBedrock,
+			"failed to invoke Bedrock for Turn2", maxRetries > 0)
+	}
+	wfErr = wfErr.
+		WithContext("verification_id", req.VerificationID).
+		WithContext("stage", "bedrock_invocation").
+		WithContext("model_id", spec.ModelID).
+		WithComponent("BedrockServiceTurn2").
+		WithOperation("ConverseWithHistory").
+		WithCategory(category).
+		WithRetryStrategy(retryStrategy).
+		SetMaxRetries(maxRetries).
+		WithSeverity(severity).
+		WithSuggestions(
+			"Check Bedrock service availability and quotas",
+			"Verify model permissions and access policies",
+			"Ensure prompt and image sizes are within limits",
+			"Check for service throttling or rate limits",
+		).
+		WithRecoveryHints(
+			"Retry with exponential backoff for transient errors",
+			"Review and optimize prompt size if too large",
+			"Check AWS service health dashboard",
+			"Verify Bedrock model availability in region",
+		)
+
+	h.log.Error("bedrock_invocation_failed", map[string]interface{}{
+		"error_type":     string(wfErr.Type),
+		"error_code":     wfErr.Code,
+		"message":        wfErr.Message,
+		"retryable":      wfErr.Retryable,
+		"severity":       string(wfErr.Severity),
+		"category":       string(wfErr.Category),
+		"retry_strategy": string(wfErr.RetryStrategy),
+		"max_retries":    wfErr.MaxRetries,
+		"component":      wfErr.Component,
+		"operation":      wfErr.Operation,
+		"model_id":       spec.ModelID,
+		"suggestions":    wfErr.Suggestions,
+		"recovery_hints": wfErr.RecoveryHints,
 	})
-
-	return nil, result.Error
+	return wfErr
 }
 
-// recordBedrockSuccess records successful Bedrock invocation
-func (h *Turn2Handler) recordBedrockSuccess(ctx context.Context, verificationID string, result *InvokeResult, templateProcessor *schema.TemplateProcessor) {
-	metadata := h.bedrockInvoker.GetInvocationMetadata(result.Response, result.Duration)
-	h.processingTracker.RecordStage("bedrock_invocation", "completed", result.Duration, metadata)
-
-	h.updateStatus(ctx, verificationID, schema.StatusTurn2BedrockCompleted, "bedrock_completion", map[string]interface{}{
-		"token_usage":        result.Response.TokenUsage,
-		"bedrock_request_id": result.Response.RequestID,
-		"latency_ms":         result.Duration.Milliseconds(),
-	})
-
-	if templateProcessor != nil {
-		templateProcessor.InputTokens = result.Response.TokenUsage.InputTokens
-		templateProcessor.OutputTokens = result.Response.TokenUsage.OutputTokens
+// parseError builds the error recorded when the Turn2 response cannot be
+// parsed. result may be nil when the executor's parser failed.
+func (h *Turn2Handler) parseError(parseErr error, req *models.Turn2Request, result *turnexecutor.Result, operation, message string) *errors.WorkflowError {
+	wfErr := errors.WrapError(parseErr, errors.ErrorTypeConversion, message, false).
+		WithContext("verification_id", req.VerificationID).
+		WithContext("stage", "response_parsing").
+		WithComponent("BedrockParser").
+		WithOperation(operation).
+		WithCategory(errors.CategoryPermanent).
+		WithRetryStrategy(errors.RetryNone).
+		WithSeverity(errors.ErrorSeverityCritical).
+		WithSuggestions(
+			"Check Bedrock response format and structure",
+			"Verify parser compatibility with model output",
+			"Review response content for unexpected format",
+			"Ensure model is generating expected markdown structure",
+		).
+		WithRecoveryHints(
+			"Review and update parser logic for model changes",
+			"Check if model output format has changed",
+			"Validate response against expected schema",
+			"Consider fallback parsing strategies",
+		)
+	if result != nil {
+		wfErr = wfErr.
+			WithContext("response_length", len(result.Content)).
+			WithContext("model_id", result.ModelID)
 	}
-}
 
-// updateProcessingMetrics updates processing metrics with final values for Turn2
-func (h *Turn2Handler) updateProcessingMetrics(metrics *schema.ProcessingMetrics, totalDuration time.Duration, invokeResult *InvokeResult) {
-	metrics.WorkflowTotal.EndTime = schema.FormatISO8601()
-	metrics.WorkflowTotal.TotalTimeMs = totalDuration.Milliseconds()
-	metrics.WorkflowTotal.FunctionCount = h.processingTracker.GetStageCount()
-
-	metrics.Turn2.EndTime = schema.FormatISO8601()
-	metrics.Turn2.TotalTimeMs = totalDuration.Milliseconds()
-	metrics.Turn2.BedrockLatencyMs = invokeResult.Duration.Milliseconds()
-	metrics.Turn2.ProcessingTimeMs = totalDuration.Milliseconds() - invokeResult.Duration.Milliseconds()
-	metrics.Turn2.TokenUsage = &invokeResult.Response.TokenUsage
+	h.log.Error("turn2_parsing_failed", map[string]interface{}{
+		"error_type":     string(wfErr.Type),
+		"error_code":     wfErr.Code,
+		"message":        wfErr.Message,
+		"retryable":      wfErr.Retryable,
+		"severity":       string(wfErr.Severity),
+		"category":       string(wfErr.Category),
+		"retry_strategy": string(wfErr.RetryStrategy),
+		"component":      wfErr.Component,
+		"operation":      wfErr.Operation,
+		"suggestions":    wfErr.Suggestions,
+		"recovery_hints": wfErr.RecoveryHints,
+	})
+	return wfErr
 }
 
 // updateInitializationFile writes the final status back to the input initialization.json
@@ -199,7 +329,7 @@ func (h *Turn2Handler) updateInitializationFile(ctx context.Context, req *models
 		return
 	}
 
-	initData, err := h.s3.LoadInitializationData(ctx, ref)
+	initData, err := h.store.LoadInitializationData(ctx, ref)
 	if err != nil {
 		contextLogger.Warn("failed to load initialization.json for update", map[string]interface{}{
 			"error": err.Error(),
@@ -212,7 +342,7 @@ func (h *Turn2Handler) updateInitializationFile(ctx context.Context, req *models
 	initData.VerificationContext.LastUpdatedAt = schema.FormatISO8601()
 	initData.SchemaVersion = schema.SchemaVersion
 
-	if _, err := h.s3.StoreJSONAtReference(ctx, ref, initData); err != nil {
+	if _, err := h.store.StoreJSONAtReference(ctx, ref, initData); err != nil {
 		contextLogger.Warn("failed to store updated initialization.json", map[string]interface{}{
 			"error": err.Error(),
 			"key":   ref.Key,
@@ -227,7 +357,7 @@ func (h *Turn2Handler) updateInitializationFile(ctx context.Context, req *models
 }
 
 // validateAndLogCompletion validates response and logs completion for Turn2
-func (h *Turn2Handler) validateAndLogCompletion(response *models.Turn2Response, totalDuration time.Duration, bedrockResp *schema.BedrockResponse, contextLogger logger.Logger) {
+func (h *Turn2Handler) validateAndLogCompletion(response *models.Turn2Response, totalDuration time.Duration, result *turnexecutor.Result, contextLogger logger.Logger) {
 	// Create Turn2Response for validation (response is already validated)
 	turn2Response := &models.Turn2Response{
 		S3Refs: models.Turn2ResponseS3Refs{
@@ -238,13 +368,8 @@ func (h *Turn2Handler) validateAndLogCompletion(response *models.Turn2Response,
 		Summary: models.Summary{
 			AnalysisStage:    models.StageProcessing,
 			ProcessingTimeMs: totalDuration.Milliseconds(),
-			TokenUsage: models.TokenUsage{
-				InputTokens:    bedrockResp.InputTokens,
-				OutputTokens:   bedrockResp.OutputTokens,
-				ThinkingTokens: bedrockResp.ThinkingTokens,
-				TotalTokens:    bedrockResp.InputTokens + bedrockResp.OutputTokens + bedrockResp.ThinkingTokens,
-			},
-			BedrockRequestID: "", // RequestID not available in BedrockResponse
+			TokenUsage:       result.Usage,
+			BedrockRequestID: result.RequestID,
 		},
 		Discrepancies:       response.Discrepancies,
 		VerificationOutcome: response.VerificationOutcome,
@@ -258,8 +383,6 @@ func (h *Turn2Handler) validateAndLogCompletion(response *models.Turn2Response,
 
 	contextLogger.Info("Completed ExecuteTurn2Combined", map[string]interface{}{
 		"duration_ms":          totalDuration.Milliseconds(),
-		"processing_stages":    h.processingTracker.GetStageCount(),
-		"status_updates":       h.statusTracker.GetHistoryCount(),
 		"discrepancy_count":    len(response.Discrepancies),
 		"verification_outcome": response.VerificationOutcome,
 	})
@@ -300,3 +423,41 @@ func (h *Turn2Handler) handleDirectRequest(ctx context.Context, req *models.Turn
 	h.log.LogOutputEvent(response)
 	return response, nil
 }
+
+// assignExperiment applies the verification's assignment for the prompt
+// experiment of the Turn2 template to the spec
+func (h *Turn2Handler) assignExperiment(req *models.Turn2Request, spec *turnexecutor.TurnSpec) *turnexecutor.TurnSpec {
+	assignment, ok := h.cfg.Prompts.Experiments.AssignTemplate(spec.TemplateType, req.VerificationID)
+	if !ok {
+		return spec
+	}
+	h.log.Info("prompt_experiment_assigned", map[string]interface{}{
+		"verification_id":  req.VerificationID,
+		"experiment_id":    assignment.ExperimentID,
+		"variant":          assignment.Variant,
+		"template_type":    assignment.TemplateType,
+		"template_version": assignment.TemplateVersion,
+		"model_id":         assignment.ModelID,
+	})
+	return spec.WithAssignment(assignment)
+}
+
+// recordExperimentAssignments stores the verification's experiment
+// assignments and the Turn2 token usage on the verification record. Failures
+// are logged and do not fail the turn.
+func (h *Turn2Handler) recordExperimentAssignments(ctx context.Context, req *models.Turn2Request, result *turnexecutor.Result) {
+	assignments := h.cfg.Prompts.Experiments.AssignAll(req.VerificationID)
+	usage := experiments.TurnUsage{
+		ModelID:        result.ModelID,
+		InputTokens:    result.Usage.InputTokens,
+		OutputTokens:   result.Usage.OutputTokens,
+		ThinkingTokens: result.Usage.ThinkingTokens,
+		TotalTokens:    result.Usage.TotalTokens,
+	}
+	if err := h.status.RecordExperimentAssignments(ctx, req.VerificationID, req.VerificationContext.VerificationAt, 2, assignments, usage); err != nil {
+		h.log.Warn("experiment_assignment_recording_failed", map[string]interface{}{
+			"verification_id": req.VerificationID,
+			"error":           err.Error(),
+		})
+	}
+}
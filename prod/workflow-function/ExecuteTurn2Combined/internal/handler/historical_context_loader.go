@@ -5,23 +5,21 @@ import (
 	"fmt"
 	"time"
 
-	"workflow-function/ExecuteTurn2Combined/internal/services"
 	"workflow-function/shared/logger"
+	"workflow-function/shared/turnexecutor"
 )
 
 // HistoricalContextLoader handles loading historical verification context
 type HistoricalContextLoader struct {
-	dynamo services.DynamoDBService
-	s3     services.S3StateManager
-	log    logger.Logger
+	store *turnexecutor.S3Store
+	log   logger.Logger
 }
 
 // NewHistoricalContextLoader creates a new instance of HistoricalContextLoader
-func NewHistoricalContextLoader(s3 services.S3StateManager, dynamo services.DynamoDBService, log logger.Logger) *HistoricalContextLoader {
+func NewHistoricalContextLoader(store *turnexecutor.S3Store, log logger.Logger) *HistoricalContextLoader {
 	return &HistoricalContextLoader{
-		dynamo: dynamo,
-		s3:     s3,
-		log:    log,
+		store: store,
+		log:   log,
 	}
 }
 
@@ -29,7 +27,7 @@ func NewHistoricalContextLoader(s3 services.S3StateManager, dynamo services.Dyna
 // This method is deprecated for Turn2 processing.
 func (h *HistoricalContextLoader) LoadHistoricalContext(ctx context.Context, req interface{}, contextLogger logger.Logger) (time.Duration, error) {
 	// This method is deprecated for Turn2 processing
-	// Historical context loading is now handled in LoadContextTurn2
+	// Historical context is loaded with the Turn1 response in ProcessTurn2Request
 	contextLogger.Warn("LoadHistoricalContext method is deprecated for Turn2 processing", nil)
 	return 0, fmt.Errorf("LoadHistoricalContext method is deprecated for Turn2 processing")
 }
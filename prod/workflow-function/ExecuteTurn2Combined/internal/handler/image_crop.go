@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"strings"
+
+	bedrock "workflow-function/shared/bedrock"
+	"workflow-function/shared/templateloader"
+)
+
+// cropRowMargin is the share of a row height kept above and below the cropped
+// band so products on the row boundary stay visible.
+const cropRowMargin = 0.5
+
+// layoutRowLabels returns the row labels of the layout, top row first. It uses
+// RowLabels when present and falls back to A, B, C... for RowCount rows.
+func layoutRowLabels(layoutMetadata map[string]interface{}) []string {
+	if layoutMetadata == nil {
+		return nil
+	}
+	switch labels := layoutMetadata["RowLabels"].(type) {
+	case []string:
+		if len(labels) > 0 {
+			return labels
+		}
+	case []interface{}:
+		out := make([]string, 0, len(labels))
+		for _, l := range labels {
+			if s, ok := l.(string); ok {
+				out = append(out, s)
+			}
+		}
+		if len(out) > 0 {
+			return out
+		}
+	}
+
+	rowCount := 0
+	switch v := layoutMetadata["RowCount"].(type) {
+	case int:
+		rowCount = v
+	case int64:
+		rowCount = int(v)
+	case float64:
+		rowCount = int(v)
+	}
+	labels := make([]string, 0, rowCount)
+	for i := 0; i < rowCount && i < 26; i++ {
+		labels = append(labels, string(rune('A'+i)))
+	}
+	return labels
+}
+
+// cropToRows crops the checking image to the horizontal band holding the rows
+// of the given positions. Rows are assumed to be evenly spaced from the top of
+// the image in label order. The original image and no row list are returned
+// when the rows cannot be located, every row is needed, or the image format is
+// not JPEG or PNG.
+func cropToRows(base64Image, imageFormat string, rowLabels []string, positions []string) (string, []string, error) {
+	if len(rowLabels) < 2 || len(positions) == 0 {
+		return base64Image, nil, nil
+	}
+	format := bedrock.NormalizeImageFormat(strings.TrimPrefix(strings.ToLower(imageFormat), "image/"))
+	if format != "jpeg" && format != "png" {
+		return base64Image, nil, nil
+	}
+
+	index := make(map[string]int, len(rowLabels))
+	for i, l := range rowLabels {
+		index[strings.ToUpper(strings.TrimSpace(l))] = i
+	}
+	minRow, maxRow := len(rowLabels), -1
+	for _, p := range positions {
+		i, ok := index[positionRow(p)]
+		if !ok {
+			return base64Image, nil, nil
+		}
+		if i < minRow {
+			minRow = i
+		}
+		if i > maxRow {
+			maxRow = i
+		}
+	}
+	if minRow == 0 && maxRow == len(rowLabels)-1 {
+		return base64Image, nil, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(base64Image)
+	if err != nil {
+		return base64Image, nil, fmt.Errorf("decode base64 image: %w", err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return base64Image, nil, fmt.Errorf("decode %s image: %w", format, err)
+	}
+	sub, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	})
+	if !ok {
+		return base64Image, nil, nil
+	}
+
+	bounds := img.Bounds()
+	rowHeight := float64(bounds.Dy()) / float64(len(rowLabels))
+	top := bounds.Min.Y + int(float64(minRow)*rowHeight-rowHeight*cropRowMargin)
+	bottom := bounds.Min.Y + int(float64(maxRow+1)*rowHeight+rowHeight*cropRowMargin)
+	if top < bounds.Min.Y {
+		top = bounds.Min.Y
+	}
+	if bottom > bounds.Max.Y {
+		bottom = bounds.Max.Y
+	}
+	cropped := sub.SubImage(image.Rect(bounds.Min.X, top, bounds.Max.X, bottom))
+
+	var buf bytes.Buffer
+	if format == "png" {
+		err = png.Encode(&buf, cropped)
+	} else {
+		err = jpeg.Encode(&buf, cropped, &jpeg.Options{Quality: 90})
+	}
+	if err != nil {
+		return base64Image, nil, fmt.Errorf("encode cropped image: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), rowLabels[minRow : maxRow+1], nil
+}
+
+// positionRow returns the row label of a reported position, e.g. "A" for
+// "a-1" or "D2-A01"
+func positionRow(position string) string {
+	id := templateloader.NormalizePosition(position)
+	if i := strings.LastIndex(id, "-"); i >= 0 {
+		id = id[i+1:]
+	}
+	return strings.TrimRight(id, "0123456789")
+}
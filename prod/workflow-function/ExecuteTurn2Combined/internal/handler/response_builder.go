@@ -131,12 +131,18 @@ func (r *ResponseBuilder) BuildTurn2StepFunctionResponse(
 	s3References["prompts_system"] = tree.Prompts.SystemPrompt
 	s3References["processing_initialization"] = tree.Initialization
 	s3References["images_metadata"] = tree.Images.Metadata
-	s3References["responses"] = map[string]interface{}{
+	responses := map[string]interface{}{
 		"turn2Raw":       tree.Responses.Turn2Raw,
 		"turn2Processed": tree.Responses.Turn2Processed,
 		"turn1Raw":       tree.Responses.Turn1Raw,
 		"turn1Processed": tree.Responses.Turn1Processed,
 	}
+	// Turn3 self-verification output supersedes turn2Processed downstream
+	if turn2Resp.S3Refs.Turn3ProcessedResponse.Key != "" {
+		responses["turn3Raw"] = turn2Resp.S3Refs.Turn3RawResponse
+		responses["turn3Processed"] = turn2Resp.S3Refs.Turn3ProcessedResponse
+	}
+	s3References["responses"] = responses
 
 	if tree.Prompts.Turn1Prompt.Key != "" {
 		s3References["prompts_turn1"] = tree.Prompts.Turn1Prompt
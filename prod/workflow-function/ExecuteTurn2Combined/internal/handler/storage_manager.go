@@ -2,74 +2,165 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
+	"time"
 
+	"workflow-function/ExecuteTurn2Combined/internal/config"
 	"workflow-function/ExecuteTurn2Combined/internal/models"
+	"workflow-function/shared/bedrock"
 	"workflow-function/shared/logger"
-	"workflow-function/shared/s3state"
+	"workflow-function/shared/schema"
+	"workflow-function/shared/turnexecutor"
 )
 
-// StorageManager handles saving Turn2 artifacts to S3 using an envelope
-// and exposes the resulting references in models.S3Reference format.
+// StorageManager stores the Turn2 artifacts derived from the executed turn.
+// The prompt and raw response are written by the turn executor.
 type StorageManager struct {
-	manager s3state.Manager
-	log     logger.Logger
+	store *turnexecutor.S3Store
+	cfg   config.Config
+	log   logger.Logger
 }
 
-func NewStorageManager(manager s3state.Manager, log logger.Logger) *StorageManager {
-	return &StorageManager{manager: manager, log: log}
+// NewStorageManager creates a new instance of StorageManager
+func NewStorageManager(store *turnexecutor.S3Store, cfg config.Config, log logger.Logger) *StorageManager {
+	return &StorageManager{store: store, cfg: cfg, log: log}
 }
 
-// SaveTurn2Outputs stores the raw Bedrock response and the processed analysis
-// into the provided envelope. The references are also mapped to concise keys
-// (turn2Raw and turn2Processed) for downstream use.
-func (s *StorageManager) SaveTurn2Outputs(ctx context.Context, envelope *s3state.Envelope, raw []byte, processed interface{}) (models.S3Reference, models.S3Reference, error) {
-	if envelope == nil {
-		return models.S3Reference{}, models.S3Reference{}, nil
-	}
+// StorageResult contains the results of storage operations
+type StorageResult struct {
+	ProcessedRef    models.S3Reference
+	ReasoningRef    models.S3Reference // empty when the model returned no reasoning
+	ConversationRef models.S3Reference
+	Duration        time.Duration
+}
 
-	// Save raw bytes
-	if err := s.manager.SaveToEnvelope(envelope, "responses", "turn2-raw-response.json", json.RawMessage(raw)); err != nil {
-		return models.S3Reference{}, models.S3Reference{}, err
+// StoreResults stores the processed markdown, the reasoning artifact, the
+// conversation and the processing metrics of the turn. Failures are logged
+// and leave the corresponding reference empty.
+func (s *StorageManager) StoreResults(ctx context.Context, req *models.Turn2Request, result *turnexecutor.Result, systemPrompt, markdown string, metrics *schema.ProcessingMetrics) *StorageResult {
+	startTime := time.Now()
+	storage := &StorageResult{}
+	verificationID := req.VerificationID
+
+	ref, err := s.store.StoreMarkdown(ctx, verificationID, bedrock.ExpectedTurn2Number, markdown)
+	if err != nil {
+		s.log.Warn("failed_to_store_markdown_response", map[string]interface{}{
+			"error":           err.Error(),
+			"verification_id": verificationID,
+		})
+	} else {
+		storage.ProcessedRef = ref
 	}
-	rawRef := envelope.GetReference("responses_turn2-raw-response")
-	if rawRef != nil {
-		envelope.AddReference("turn2Raw", rawRef)
+
+	if artifact := result.ReasoningArtifact(verificationID, result.Turn.Stage, s.cfg.Processing.ThinkingBudgetTokens); artifact != nil {
+		ref, err := s.store.StoreReasoning(ctx, verificationID, artifact)
+		if err != nil {
+			s.log.Warn("failed_to_store_reasoning", map[string]interface{}{
+				"error":           err.Error(),
+				"verification_id": verificationID,
+				"block_count":     len(artifact.Blocks),
+			})
+		} else {
+			storage.ReasoningRef = ref
+		}
 	}
 
-	// Save processed structure
-	if err := s.manager.SaveToEnvelope(envelope, "responses", "turn2-processed-response.md", processed); err != nil {
-		return models.S3Reference{}, models.S3Reference{}, err
+	ref, err = s.store.StoreConversation(ctx, verificationID, &turnexecutor.Conversation{
+		Turn:       bedrock.ExpectedTurn2Number,
+		Stage:      result.Turn.Stage,
+		Prior:      s.loadConversationMessages(ctx, req),
+		System:     systemPrompt,
+		UserPrompt: result.Prompt,
+		ImageRef:   req.S3Refs.Images.CheckingBase64,
+		Assistant:  markdown,
+		Usage:      &result.Usage,
+		LatencyMs:  result.LatencyMs,
+		RequestID:  result.RequestID,
+		ModelID:    result.ModelID,
+		Metadata:   map[string]interface{}{"stopReason": result.Turn.Response.StopReason},
+	})
+	if err != nil {
+		s.log.Warn("failed_to_store_conversation", map[string]interface{}{
+			"error":           err.Error(),
+			"verification_id": verificationID,
+		})
+	} else {
+		storage.ConversationRef = ref
 	}
-	procRef := envelope.GetReference("responses_turn2-processed-response")
-	if procRef != nil {
-		envelope.AddReference("turn2Processed", procRef)
+
+	if _, err := s.store.StoreProcessingMetrics(ctx, verificationID, metrics); err != nil {
+		s.log.Warn("failed_to_store_processing_metrics", map[string]interface{}{
+			"error":           err.Error(),
+			"verification_id": verificationID,
+		})
 	}
 
-	return toModelRef(rawRef), toModelRef(procRef), nil
+	storage.Duration = time.Since(startTime)
+	return storage
 }
 
-// SaveTurn2Prompt stores the rendered Turn2 prompt into the envelope and returns the reference.
-func (s *StorageManager) SaveTurn2Prompt(ctx context.Context, envelope *s3state.Envelope, prompt string) (models.S3Reference, error) {
-	if envelope == nil {
-		return models.S3Reference{}, nil
+// loadConversationMessages loads the Turn1 conversation the Turn2
+// conversation continues. It returns nil when none is available.
+func (s *StorageManager) loadConversationMessages(ctx context.Context, req *models.Turn2Request) []schema.BedrockMessage {
+	if req.S3Refs.Turn1.Conversation.Key == "" {
+		return nil
 	}
-
-	if err := s.manager.SaveToEnvelope(envelope, "prompts", "turn2-prompt.json", json.RawMessage([]byte(prompt))); err != nil {
-		return models.S3Reference{}, err
+	var convData struct {
+		Messages []map[string]interface{} `json:"messages"`
 	}
-
-	ref := envelope.GetReference("prompts_turn2-prompt")
-	if ref != nil {
-		envelope.AddReference("prompts_turn2", ref)
-		return toModelRef(ref), nil
+	if err := s.store.LoadJSON(ctx, req.S3Refs.Turn1.Conversation, &convData); err != nil {
+		s.log.Warn("failed_to_load_turn1_conversation", map[string]interface{}{
+			"error":           err.Error(),
+			"verification_id": req.VerificationID,
+		})
+		return nil
 	}
-	return models.S3Reference{}, nil
-}
 
-func toModelRef(ref *s3state.Reference) models.S3Reference {
-	if ref == nil {
-		return models.S3Reference{}
+	messages := make([]schema.BedrockMessage, 0, len(convData.Messages))
+	for _, m := range convData.Messages {
+		var msg schema.BedrockMessage
+		if role, ok := m["role"].(string); ok {
+			msg.Role = role
+		}
+		if contents, ok := m["content"].([]interface{}); ok {
+			for _, c := range contents {
+				cMap, ok := c.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if thinking, ok := cMap["thinking"].(string); ok {
+					msg.Content = append(msg.Content, schema.BedrockContent{Type: "thinking", Text: thinking})
+					continue
+				}
+				t, ok := cMap["type"].(string)
+				if !ok {
+					continue
+				}
+				bc := schema.BedrockContent{Type: t}
+				if text, ok := cMap["text"].(string); ok {
+					bc.Text = text
+				}
+				if img, ok := cMap["image"].(map[string]interface{}); ok {
+					var imgData schema.BedrockImageData
+					if format, ok := img["format"].(string); ok {
+						imgData.Format = format
+					}
+					if src, ok := img["source"].(map[string]interface{}); ok {
+						var source schema.BedrockImageSource
+						if data, ok := src["bytes"].(string); ok {
+							source.Type = "base64"
+							source.Data = data
+						}
+						if mt, ok := src["media_type"].(string); ok {
+							source.Media_type = mt
+						}
+						imgData.Source = source
+					}
+					bc.Image = &imgData
+				}
+				msg.Content = append(msg.Content, bc)
+			}
+		}
+		messages = append(messages, msg)
 	}
-	return models.S3Reference{Bucket: ref.Bucket, Key: ref.Key, Size: ref.Size}
+	return messages
 }
@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"workflow-function/ExecuteTurn2Combined/internal/bedrockparser"
+	"workflow-function/ExecuteTurn2Combined/internal/models"
+	"workflow-function/shared/bedrock"
+	"workflow-function/shared/errors"
+	"workflow-function/shared/schema"
+	"workflow-function/shared/templateloader"
+	"workflow-function/shared/turnexecutor"
+)
+
+// Turn3Outcome is the result of the Turn3 self-verification stage.
+type Turn3Outcome struct {
+	Parsed      *bedrockparser.ParsedTurn3Data
+	Result      *turnexecutor.Result
+	MarkdownRef models.S3Reference
+	RawRef      models.S3Reference
+	CropRows    []string
+	Unchecked   int
+	StartTime   time.Time
+}
+
+// shouldRunTurn3 reports whether the self-verification turn applies to this
+// request. It is enabled by TURN3_ENABLED or by a turnConfig.maxTurns of 3 or
+// more, and only runs when Turn2 reported discrepancies.
+func (h *Turn2Handler) shouldRunTurn3(req *models.Turn2Request, parsed *bedrockparser.ParsedTurn2Data) bool {
+	if parsed == nil || len(parsed.Discrepancies) == 0 {
+		return false
+	}
+	return h.cfg.Processing.Turn3Enabled || req.VerificationContext.MaxTurns >= 3
+}
+
+// runSelfVerification asks the model to re-examine only the positions it
+// reported in Turn2. Retracted discrepancies are dropped from the result;
+// positions beyond Turn3MaxDiscrepancies are kept without being re-checked.
+func (h *Turn2Handler) runSelfVerification(
+	ctx context.Context,
+	req *models.Turn2Request,
+	store *turnexecutor.RequestStore,
+	systemPrompt string,
+	turn1Response *schema.TurnResponse,
+	turn2 *turnexecutor.Result,
+	parsed *bedrockparser.ParsedTurn2Data,
+	layoutMetadata map[string]interface{},
+) (*Turn3Outcome, error) {
+	outcome := &Turn3Outcome{StartTime: time.Now()}
+
+	spec, err := h.turns.Spec(bedrock.ExpectedTurn3Number)
+	if err != nil {
+		return nil, err
+	}
+	checkingImage, err := store.LoadImage(ctx, req.VerificationID, turnexecutor.ImageRoleChecking)
+	if err != nil {
+		return nil, err
+	}
+
+	checked, unchecked := selectTurn3Discrepancies(parsed.Discrepancies, h.cfg.Processing.Turn3MaxDiscrepancies)
+	outcome.Unchecked = len(unchecked)
+
+	h.log.Info("turn3_self_verification_started", map[string]interface{}{
+		"verification_id":   req.VerificationID,
+		"discrepancy_count": len(parsed.Discrepancies),
+		"checked_count":     len(checked),
+		"crop_enabled":      h.cfg.Processing.Turn3CropEnabled,
+	})
+
+	image := checkingImage.Base64
+	if h.cfg.Processing.Turn3CropEnabled {
+		positions := make([]string, 0, len(checked))
+		for _, d := range checked {
+			positions = append(positions, d.Expected)
+		}
+		cropped, rows, err := cropToRows(checkingImage.Base64, checkingImage.Format, layoutRowLabels(layoutMetadata), positions)
+		if err != nil {
+			h.log.Warn("turn3_image_crop_failed", map[string]interface{}{
+				"error":           err.Error(),
+				"verification_id": req.VerificationID,
+				"impact":          "using_full_image",
+			})
+		} else {
+			image, outcome.CropRows = cropped, rows
+		}
+	}
+
+	// Ask for the verdicts in the verification's locale when the template has it
+	turnSpec := requestSpec(spec, turn1Response).WithLocale(h.locales, req.VerificationContext.Locale)
+	turnSpec.Parser = turnexecutor.ParserFunc(func(content string) (interface{}, error) {
+		return bedrockparser.ParseTurn3Response(content, checked), nil
+	})
+
+	result, err := h.executor.
+		WithStore(store).
+		WithStatus(h.status.ForVerification(req.VerificationContext.VerificationAt)).
+		Execute(ctx, turnSpec, &turnexecutor.Input{
+			VerificationID: req.VerificationID,
+			SystemPrompt:   systemPrompt,
+			TemplateData: map[string]interface{}{
+				"VerificationType": req.VerificationContext.VerificationType,
+				"VendingMachineId": req.VerificationContext.VendingMachineId,
+				"Discrepancies":    checked,
+				"Cropped":          len(outcome.CropRows) > 0,
+				"CropRows":         outcome.CropRows,
+				"TemplateVersion":  turnSpec.TemplateVersion,
+			},
+			Images: map[string]*turnexecutor.Image{
+				turnexecutor.ImageRoleChecking: {Base64: image, Format: checkingImage.Format},
+			},
+			History: map[int]*schema.TurnResponse{
+				bedrock.ExpectedTurn1Number: turn1Response,
+				bedrock.ExpectedTurn2Number: turn2.Turn,
+			},
+			Metadata: map[string]interface{}{
+				"cropRows":       outcome.CropRows,
+				"uncheckedCount": outcome.Unchecked,
+			},
+		})
+	if err != nil {
+		return nil, err
+	}
+	outcome.Result = result
+	outcome.RawRef = store.TurnRef(bedrock.ExpectedTurn3Number)
+
+	verdicts, ok := result.Parsed.(*bedrockparser.ParsedTurn3Data)
+	if !ok {
+		return nil, fmt.Errorf("unexpected turn3 parse result %T", result.Parsed)
+	}
+	verdicts.Confirmed = append(verdicts.Confirmed, unchecked...)
+	if len(verdicts.Confirmed) > 0 {
+		verdicts.VerificationOutcome = "INCORRECT"
+	}
+	summary := fmt.Sprintf("Self-verification retracted %d of %d discrepancies.", len(verdicts.Retracted), len(checked))
+	switch {
+	case verdicts.ComparisonSummary != "":
+		summary += " " + verdicts.ComparisonSummary
+	case parsed.ComparisonSummary != "" && len(verdicts.Retracted) == 0:
+		summary += " " + parsed.ComparisonSummary
+	}
+	verdicts.ComparisonSummary = summary
+	outcome.Parsed = verdicts
+
+	markdown := renderTurn3Markdown(verdicts, extractTotalPositions(turn2.Content))
+	outcome.MarkdownRef, err = store.StoreMarkdown(ctx, req.VerificationID, bedrock.ExpectedTurn3Number, markdown)
+	if err != nil {
+		return nil, errors.WrapError(err, errors.ErrorTypeS3,
+			"failed to store Turn3 markdown", true).
+			WithContext("verification_id", req.VerificationID)
+	}
+
+	h.log.Info("turn3_self_verification_completed", map[string]interface{}{
+		"verification_id":      req.VerificationID,
+		"confirmed_count":      len(verdicts.Confirmed),
+		"retracted_count":      len(verdicts.Retracted),
+		"unchecked_count":      outcome.Unchecked,
+		"verification_outcome": verdicts.VerificationOutcome,
+		"crop_rows":            outcome.CropRows,
+		"latency_ms":           result.LatencyMs,
+	})
+
+	return outcome, nil
+}
+
+// selectTurn3Discrepancies splits the Turn2 discrepancies into the ones Turn3
+// re-checks, the first max distinct positions, and the ones kept unchecked.
+// Repeated reports of a position are re-checked with its first report, since
+// one verdict per position applies to all of them.
+func selectTurn3Discrepancies(discrepancies []models.Discrepancy, max int) (checked, unchecked []models.Discrepancy) {
+	selected := map[string]bool{}
+	for _, d := range discrepancies {
+		position := templateloader.NormalizePosition(d.Expected)
+		if !selected[position] && len(selected) >= max {
+			unchecked = append(unchecked, d)
+			continue
+		}
+		selected[position] = true
+		checked = append(checked, d)
+	}
+	return checked, unchecked
+}
+
+// recordTurn3Completion writes the Turn3 conversation entry and the verified
+// discrepancy list to DynamoDB. It returns false if any write failed.
+func (h *Turn2Handler) recordTurn3Completion(ctx context.Context, req *models.Turn2Request, outcome *Turn3Outcome, finalStatus, summary string) bool {
+	result := outcome.Result
+	tokenUsage := &schema.TokenUsage{
+		InputTokens:  result.Usage.InputTokens,
+		OutputTokens: result.Usage.OutputTokens,
+		TotalTokens:  result.Usage.InputTokens + result.Usage.OutputTokens,
+	}
+	totalMs := time.Since(outcome.StartTime).Milliseconds()
+
+	statusEntry := schema.StatusHistoryEntry{
+		Status:           schema.StatusTurn3Completed,
+		Timestamp:        schema.FormatISO8601(),
+		FunctionName:     "ExecuteTurn2Combined",
+		ProcessingTimeMs: totalMs,
+		Stage:            "turn3_completion",
+		Metrics: map[string]interface{}{
+			"confirmed_count":      len(outcome.Parsed.Confirmed),
+			"retracted_count":      len(outcome.Parsed.Retracted),
+			"verification_outcome": finalStatus,
+		},
+	}
+
+	turnEntry := &schema.TurnResponse{
+		TurnId:    bedrock.ExpectedTurn3Number,
+		Timestamp: schema.FormatISO8601(),
+		Prompt:    result.Prompt,
+		ImageUrls: map[string]string{
+			"checking": req.S3Refs.Images.CheckingBase64.Key,
+		},
+		Response: schema.BedrockApiResponse{
+			Content: result.Content,
+			ModelId: result.ModelID,
+		},
+		LatencyMs:  result.LatencyMs,
+		TokenUsage: tokenUsage,
+		Stage:      bedrock.AnalysisStageTurn3,
+	}
+
+	return h.dynamoManager.UpdateTurn3Completion(ctx, Turn3Result{
+		VerificationID: req.VerificationID,
+		VerificationAt: req.VerificationContext.VerificationAt,
+		StatusEntry:    statusEntry,
+		TurnEntry:      turnEntry,
+		Metrics: &schema.TurnMetrics{
+			StartTime:        outcome.StartTime.Format(time.RFC3339),
+			EndTime:          time.Now().Format(time.RFC3339),
+			TotalTimeMs:      totalMs,
+			BedrockLatencyMs: result.LatencyMs,
+			ProcessingTimeMs: totalMs - result.LatencyMs,
+			TokenUsage:       tokenUsage,
+		},
+		ProcessedMarkdownRef: &outcome.MarkdownRef,
+		VerificationStatus:   finalStatus,
+		Discrepancies:        toSchemaDiscrepancies(outcome.Parsed.Confirmed),
+		Retracted:            toSchemaDiscrepancies(outcome.Parsed.Retracted),
+		ComparisonSummary:    summary,
+	})
+}
+
+// renderTurn3Markdown renders the self-verified result in the Turn2 markdown
+// layout so FinalizeAndStoreResults can read it in place of the Turn2 output.
+func renderTurn3Markdown(result *bedrockparser.ParsedTurn3Data, totalPositions int) string {
+	var b strings.Builder
+	b.WriteString("# Turn 3 Self-Verification\n\n")
+	fmt.Fprintf(&b, "Verification outcome: %s\n\n", result.VerificationOutcome)
+	writeDiscrepancyList(&b, "Verified discrepancies:", result.Confirmed)
+	fmt.Fprintf(&b, "**COMPARISON SUMMARY:** %s\n\n", result.ComparisonSummary)
+	writeVerificationSummary(&b, totalPositions, result.Confirmed, "", result.VerificationOutcome)
+
+	if len(result.Retracted) > 0 {
+		reasons := make(map[string]string, len(result.Verdicts))
+		for _, v := range result.Verdicts {
+			reasons[v.Position] = v.Reason
+		}
+		b.WriteString("\n## Retracted Discrepancies\n\n")
+		b.WriteString("| Position | Item | Reason |\n|---|---|---|\n")
+		for _, d := range result.Retracted {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", d.Expected, d.Item, reasons[templateloader.NormalizePosition(d.Expected)])
+		}
+	}
+	return b.String()
+}
+
+// toSchemaDiscrepancies converts parsed discrepancies to the DynamoDB format.
+func toSchemaDiscrepancies(in []models.Discrepancy) []schema.Discrepancy {
+	out := make([]schema.Discrepancy, 0, len(in))
+	for _, d := range in {
+		out = append(out, schema.Discrepancy{
+			Type:        d.Type,
+			Description: fmt.Sprintf("%s expected %s found %s", d.Item, d.Expected, d.Found),
+			Severity:    d.Severity,
+		})
+	}
+	return out
+}
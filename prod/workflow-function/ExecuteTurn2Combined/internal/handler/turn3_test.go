@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"testing"
+
+	"workflow-function/ExecuteTurn2Combined/internal/bedrockparser"
+	"workflow-function/ExecuteTurn2Combined/internal/models"
+)
+
+func TestSelectTurn3Discrepancies(t *testing.T) {
+	discrepancies := []models.Discrepancy{
+		{Item: "Pepsi", Expected: "A01", Type: "MISSING"},
+		{Item: "Coke", Expected: "A1", Type: "MISPLACED"},
+		{Item: "Sprite", Expected: "B02", Type: "MISSING"},
+		{Item: "Fanta", Expected: "C03", Type: "MISSING"},
+	}
+
+	tests := []struct {
+		name      string
+		max       int
+		checked   []string
+		unchecked []string
+	}{
+		{"all positions within the limit", 5, []string{"Pepsi", "Coke", "Sprite", "Fanta"}, nil},
+		{"repeated position counts once", 2, []string{"Pepsi", "Coke", "Sprite"}, []string{"Fanta"}},
+		{"limit of one position", 1, []string{"Pepsi", "Coke"}, []string{"Sprite", "Fanta"}},
+	}
+
+	items := func(in []models.Discrepancy) []string {
+		var out []string
+		for _, d := range in {
+			out = append(out, d.Item)
+		}
+		return out
+	}
+	equal := func(a, b []string) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if a[i] != b[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checked, unchecked := selectTurn3Discrepancies(discrepancies, tt.max)
+			if !equal(items(checked), tt.checked) || !equal(items(unchecked), tt.unchecked) {
+				t.Errorf("Expected %v checked and %v unchecked, got %v and %v", tt.checked, tt.unchecked, items(checked), items(unchecked))
+			}
+		})
+	}
+}
+
+func TestShouldRunTurn3(t *testing.T) {
+	parsed := &bedrockparser.ParsedTurn2Data{Discrepancies: []models.Discrepancy{{Expected: "A01", Type: "MISSING"}}}
+
+	tests := []struct {
+		name     string
+		enabled  bool
+		maxTurns int
+		parsed   *bedrockparser.ParsedTurn2Data
+		want     bool
+	}{
+		{"enabled by configuration", true, 0, parsed, true},
+		{"requested by turn config", false, 3, parsed, true},
+		{"two turns requested", false, 2, parsed, false},
+		{"no discrepancies to verify", true, 3, &bedrockparser.ParsedTurn2Data{}, false},
+		{"no parsed result", true, 3, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Turn2Handler{}
+			h.cfg.Processing.Turn3Enabled = tt.enabled
+			req := &models.Turn2Request{VerificationContext: models.VerificationContext{MaxTurns: tt.maxTurns}}
+			if got := h.shouldRunTurn3(req, tt.parsed); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPositionRow(t *testing.T) {
+	for in, want := range map[string]string{"A01": "A", "a-1": "A", "**B 3**": "B", "D2-C01": "C", "AA12": "AA"} {
+		if got := positionRow(in); got != want {
+			t.Errorf("positionRow(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"time"
+
+	"workflow-function/ExecuteTurn2Combined/internal/bedrockparser"
+	"workflow-function/ExecuteTurn2Combined/internal/config"
+	"workflow-function/ExecuteTurn2Combined/internal/models"
+	"workflow-function/shared/bedrock"
+	"workflow-function/shared/logger"
+	"workflow-function/shared/schema"
+	"workflow-function/shared/templateloader"
+	"workflow-function/shared/turnexecutor"
+)
+
+// newTurnRegistry declares the turns run by this function.
+func newTurnRegistry(cfg config.Config, log logger.Logger) *turnexecutor.Registry {
+	registry := turnexecutor.NewRegistry()
+	for _, spec := range []*turnexecutor.TurnSpec{turn2Spec(cfg), turn3Spec(cfg)} {
+		if err := registry.Register(spec); err != nil {
+			log.Warn("turn_spec_registration_failed", map[string]interface{}{
+				"turn":  spec.Turn,
+				"error": err.Error(),
+			})
+		}
+	}
+	return registry
+}
+
+// turn2Spec declares the comparison turn: the checking image is sent after
+// replaying Turn1. The template is selected per request by turn2TemplateType;
+// the handler records the start, error and completion statuses.
+func turn2Spec(cfg config.Config) *turnexecutor.TurnSpec {
+	temperature, topP := cfg.Processing.Temperature, cfg.Processing.TopP
+	return &turnexecutor.TurnSpec{
+		Turn:            bedrock.ExpectedTurn2Number,
+		Stage:           bedrock.AnalysisStageTurn2,
+		TemplateType:    templateloader.TemplateTypeTurn2LayoutVsChecking,
+		TemplateVersion: cfg.Prompts.Turn2TemplateVersion,
+		Images:          []string{turnexecutor.ImageRoleChecking},
+		History:         []int{bedrock.ExpectedTurn1Number},
+		Statuses: turnexecutor.StatusSet{
+			PromptPrepared: schema.StatusTurn2PromptPrepared,
+			BedrockInvoked: schema.StatusTurn2BedrockInvoked,
+		},
+		Parser: turnexecutor.ParserFunc(func(content string) (interface{}, error) {
+			return bedrockparser.ParseTurn2Response(content)
+		}),
+		ModelID: cfg.AWS.BedrockModel,
+		Inference: bedrock.InferenceConfig{
+			MaxTokens:   cfg.Processing.MaxTokens,
+			Temperature: &temperature,
+			TopP:        &topP,
+		},
+		Timeout: time.Duration(cfg.Processing.BedrockCallTimeoutSec) * time.Second,
+	}
+}
+
+// turn3Spec declares the self-verification turn: the checking image is sent
+// again after replaying Turn1 and Turn2. Completion is recorded by
+// recordTurn3Completion together with the verified discrepancies.
+func turn3Spec(cfg config.Config) *turnexecutor.TurnSpec {
+	temperature, topP := cfg.Processing.Temperature, cfg.Processing.TopP
+	return &turnexecutor.TurnSpec{
+		Turn:            bedrock.ExpectedTurn3Number,
+		Stage:           bedrock.AnalysisStageTurn3,
+		TemplateType:    templateloader.TemplateTypeTurn3SelfVerification,
+		TemplateVersion: cfg.Prompts.Turn3TemplateVersion,
+		Images:          []string{turnexecutor.ImageRoleChecking},
+		History:         []int{bedrock.ExpectedTurn1Number, bedrock.ExpectedTurn2Number},
+		Statuses: turnexecutor.StatusSet{
+			Started:        schema.StatusTurn3Started,
+			PromptPrepared: schema.StatusTurn3PromptPrepared,
+			BedrockInvoked: schema.StatusTurn3BedrockInvoked,
+		},
+		ModelID: cfg.AWS.BedrockModel,
+		Inference: bedrock.InferenceConfig{
+			MaxTokens:   cfg.Processing.MaxTokens,
+			Temperature: &temperature,
+			TopP:        &topP,
+		},
+		Timeout: time.Duration(cfg.Processing.BedrockCallTimeoutSec) * time.Second,
+	}
+}
+
+// requestSpec returns a copy of the registered spec for one request. Turns
+// without a stored Turn1 response run without its history.
+func requestSpec(spec *turnexecutor.TurnSpec, turn1 *schema.TurnResponse) *turnexecutor.TurnSpec {
+	s := *spec
+	if turn1 == nil {
+		s.History = make([]int, 0, len(spec.History))
+		for _, t := range spec.History {
+			if t != bedrock.ExpectedTurn1Number {
+				s.History = append(s.History, t)
+			}
+		}
+	}
+	return &s
+}
+
+// turn2TemplateType returns the Turn2 template of a verification type
+func turn2TemplateType(verificationType string) string {
+	switch verificationType {
+	case schema.VerificationTypeLayoutVsChecking:
+		return templateloader.TemplateTypeTurn2LayoutVsChecking
+	case schema.VerificationTypePreviousVsCurrent:
+		return templateloader.TemplateTypeTurn2PreviousVsCurrent
+	default:
+		return "turn2-default"
+	}
+}
+
+// turn2PromptInfo describes the context stored with the Turn2 prompt
+func turn2PromptInfo(req *models.Turn2Request, templateVersion string) turnexecutor.PromptInfo {
+	return turnexecutor.PromptInfo{
+		VerificationType: req.VerificationContext.VerificationType,
+		TemplateVersion:  templateVersion,
+		Objective:        "Compare checking image with reference layout",
+		ImageRole:        turnexecutor.ImageRoleChecking,
+		ContextSources:   []string{"TURN1_ANALYSIS", "IMAGE_METADATA", "LAYOUT_METADATA"},
+	}
+}
+
+// turn2TemplateData builds the template data of the Turn2 prompt. Layout
+// dimensions are only provided for LAYOUT_VS_CHECKING.
+func turn2TemplateData(vCtx *schema.VerificationContext, systemPrompt, templateVersion string, turn1 *schema.TurnResponse, layoutMetadata map[string]interface{}) map[string]interface{} {
+	data := map[string]interface{}{
+		"VerificationContext": vCtx,
+		"SystemPrompt":        systemPrompt,
+		"Turn1Response":       turn1,
+		"VendingMachineId":    vCtx.VendingMachineId,
+		"VendingMachineID":    vCtx.VendingMachineId, // Also add with uppercase ID for template compatibility
+		"TemplateVersion":     templateVersion,
+		"CreatedAt":           schema.FormatISO8601(),
+	}
+	if vCtx.VerificationType == schema.VerificationTypeLayoutVsChecking {
+		turnexecutor.AddLayoutContext(data, vCtx.LayoutId, vCtx.LayoutPrefix, layoutMetadata)
+	}
+	return data
+}
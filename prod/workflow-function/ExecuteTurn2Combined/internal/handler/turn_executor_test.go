@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"workflow-function/ExecuteTurn2Combined/internal/bedrockparser"
+	"workflow-function/ExecuteTurn2Combined/internal/config"
+	"workflow-function/shared/bedrock"
+	"workflow-function/shared/logger"
+	"workflow-function/shared/schema"
+	"workflow-function/shared/templateloader"
+	"workflow-function/shared/turnexecutor"
+)
+
+type fakeConverser struct {
+	request *bedrock.ConverseRequest
+}
+
+func (f *fakeConverser) Converse(ctx context.Context, request *bedrock.ConverseRequest) (*bedrock.ConverseResponse, int64, error) {
+	f.request = request
+	return &bedrock.ConverseResponse{
+		ModelID:    request.ModelId,
+		RequestID:  "req-2",
+		StopReason: "end_turn",
+		Content:    []bedrock.ContentBlock{{Type: bedrock.ContentTypeText, Text: "Total discrepancies: 0"}},
+		Usage:      &bedrock.TokenUsage{InputTokens: 20, OutputTokens: 5, TotalTokens: 25},
+	}, 40, nil
+}
+
+type recordingRenderer struct {
+	templateType string
+}
+
+func (r *recordingRenderer) RenderTemplateWithVersion(templateType, version string, data interface{}) (string, error) {
+	r.templateType = templateType
+	return "compare the images", nil
+}
+
+type memoryStore struct {
+	turns  map[int]*schema.TurnResponse
+	stored *schema.TurnResponse
+}
+
+func (s *memoryStore) LoadImage(ctx context.Context, verificationID, role string) (*turnexecutor.Image, error) {
+	return &turnexecutor.Image{Base64: "Y2hlY2tpbmc=", Format: "png"}, nil
+}
+
+func (s *memoryStore) LoadTurn(ctx context.Context, verificationID string, turn int) (*schema.TurnResponse, error) {
+	if t, ok := s.turns[turn]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("turn %d response is not stored for this request", turn)
+}
+
+func (s *memoryStore) StorePrompt(ctx context.Context, verificationID string, turn int, prompt string) error {
+	return nil
+}
+
+func (s *memoryStore) StoreTurn(ctx context.Context, verificationID string, turn *schema.TurnResponse) error {
+	s.stored = turn
+	return nil
+}
+
+func testTurn2Config() config.Config {
+	cfg := config.Config{}
+	cfg.AWS.BedrockModel = "default-model"
+	cfg.Processing.MaxTokens = 1000
+	cfg.Processing.Temperature = 0.2
+	cfg.Processing.BedrockCallTimeoutSec = 30
+	cfg.Prompts.Turn2TemplateVersion = "1.0"
+	return cfg
+}
+
+func TestTurn2SpecReplaysTurn1(t *testing.T) {
+	cfg := testTurn2Config()
+	log := logger.New("test", "handler")
+	spec, err := newTurnRegistry(cfg, log).Spec(bedrock.ExpectedTurn2Number)
+	if err != nil {
+		t.Fatalf("turn2 spec not registered: %v", err)
+	}
+
+	conv := &fakeConverser{}
+	renderer := &recordingRenderer{}
+	turn1 := &schema.TurnResponse{
+		TurnId:   1,
+		Prompt:   "turn1 prompt",
+		Response: schema.BedrockApiResponse{Content: "turn1 answer"},
+	}
+	store := &memoryStore{turns: map[int]*schema.TurnResponse{1: turn1}}
+
+	turnSpec := requestSpec(spec, turn1)
+	turnSpec.TemplateType = turn2TemplateType(schema.VerificationTypePreviousVsCurrent)
+
+	result, err := turnexecutor.New(conv, renderer, nil, log).WithStore(store).Execute(context.Background(), turnSpec, &turnexecutor.Input{
+		VerificationID: "verif-1",
+		SystemPrompt:   "system",
+	})
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+
+	if renderer.templateType != templateloader.TemplateTypeTurn2PreviousVsCurrent {
+		t.Errorf("rendered template %q, want the per-request template", renderer.templateType)
+	}
+	msgs := conv.request.Messages
+	if len(msgs) != 3 {
+		t.Fatalf("expected Turn1 and Turn2 messages, got %d", len(msgs))
+	}
+	if msgs[0].Content[0].Text != "turn1 prompt" || msgs[1].Content[0].Text != "turn1 answer" {
+		t.Errorf("Turn1 not replayed: %+v", msgs[:2])
+	}
+	last := msgs[2]
+	if len(last.Content) != 2 || last.Content[1].Image == nil || last.Content[1].Image.Format != "png" {
+		t.Fatalf("expected the Turn2 prompt with the checking image, got %+v", last)
+	}
+	if conv.request.ModelId != "default-model" || conv.request.System != "system" {
+		t.Errorf("unexpected request %+v", conv.request)
+	}
+
+	if _, ok := result.Parsed.(*bedrockparser.ParsedTurn2Data); !ok {
+		t.Errorf("expected parsed Turn2 data, got %T", result.Parsed)
+	}
+	if store.stored == nil || store.stored.TurnId != 2 || store.stored.Stage != bedrock.AnalysisStageTurn2 {
+		t.Errorf("turn not stored as expected: %+v", store.stored)
+	}
+	if result.RequestID != "req-2" || result.Usage.InputTokens != 20 {
+		t.Errorf("unexpected usage or request id %+v", result)
+	}
+}
+
+func TestTurn2SpecWithoutTurn1(t *testing.T) {
+	cfg := testTurn2Config()
+	log := logger.New("test", "handler")
+	spec, err := newTurnRegistry(cfg, log).Spec(bedrock.ExpectedTurn2Number)
+	if err != nil {
+		t.Fatalf("turn2 spec not registered: %v", err)
+	}
+
+	conv := &fakeConverser{}
+	// The store has no Turn1 response; loading it would fail the turn
+	store := &memoryStore{}
+	_, err = turnexecutor.New(conv, &recordingRenderer{}, nil, log).WithStore(store).Execute(context.Background(), requestSpec(spec, nil), &turnexecutor.Input{
+		VerificationID: "verif-1",
+		SystemPrompt:   "system",
+	})
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	if len(conv.request.Messages) != 1 {
+		t.Errorf("expected only the Turn2 message, got %d", len(conv.request.Messages))
+	}
+	if len(spec.History) != 1 {
+		t.Errorf("requestSpec modified the registered spec: %v", spec.History)
+	}
+}
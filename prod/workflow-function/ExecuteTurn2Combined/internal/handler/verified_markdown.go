@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"workflow-function/ExecuteTurn2Combined/internal/models"
+)
+
+// Helpers for the Markdown written in place of a single Turn2 answer (ensemble
+// vote, Turn3 self-verification). The layout follows the Turn2 templates so
+// both bedrockparser and FinalizeAndStoreResults read it unchanged.
+
+// writeDiscrepancyList writes discrepancies in the "- item: expected in X, ..."
+// form understood by ParseTurn2Response. The heading must contain
+// "discrepancies:" in lower case.
+func writeDiscrepancyList(b *strings.Builder, heading string, discrepancies []models.Discrepancy) {
+	if len(discrepancies) == 0 {
+		return
+	}
+	b.WriteString(heading + "\n")
+	for _, d := range discrepancies {
+		if d.Found != "" {
+			fmt.Fprintf(b, "- %s: expected in %s, found in %s\n", d.Item, d.Expected, d.Found)
+		} else {
+			fmt.Fprintf(b, "- %s: expected in %s, not found\n", d.Item, d.Expected)
+		}
+	}
+	b.WriteString("\n")
+}
+
+// writeVerificationSummary writes the VERIFICATION SUMMARY block. Accuracy is
+// omitted when the number of positions checked is unknown.
+func writeVerificationSummary(b *strings.Builder, totalPositions int, discrepancies []models.Discrepancy, confidence, outcome string) {
+	missing := 0
+	for _, d := range discrepancies {
+		if d.Type == "MISSING" {
+			missing++
+		}
+	}
+	correct := totalPositions - len(discrepancies)
+	if correct < 0 {
+		correct = 0
+	}
+
+	b.WriteString("**VERIFICATION SUMMARY:**\n")
+	fmt.Fprintf(b, "* **Total Positions Checked:** %d\n", totalPositions)
+	fmt.Fprintf(b, "* **Correct Positions:** %d\n", correct)
+	fmt.Fprintf(b, "* **Discrepant Positions:** %d\n", len(discrepancies))
+	fmt.Fprintf(b, "    * Missing Products: %d\n", missing)
+	if totalPositions > 0 {
+		fmt.Fprintf(b, "* **Overall Accuracy:** %.1f%%\n", float64(correct)*100/float64(totalPositions))
+	}
+	if confidence != "" {
+		fmt.Fprintf(b, "* **Overall Confidence:** %s\n", confidence)
+	}
+	fmt.Fprintf(b, "* **VERIFICATION STATUS:** %s\n", outcome)
+	fmt.Fprintf(b, "* **Verification Outcome:** %s\n\n", outcome)
+}
+
+var totalPositionsRe = regexp.MustCompile(`(?i)total\s+positions\s+checked:?\**\s*(\d+)`)
+
+// extractTotalPositions reads "Total Positions Checked" from a Turn2 answer.
+func extractTotalPositions(content string) int {
+	if m := totalPositionsRe.FindStringSubmatch(content); len(m) > 1 {
+		n, _ := strconv.Atoi(m[1])
+		return n
+	}
+	return 0
+}
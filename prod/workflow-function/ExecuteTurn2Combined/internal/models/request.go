@@ -64,6 +64,9 @@ type ProcessingReferences struct {
 type Turn2ResponseS3Refs struct {
 	RawResponse       S3Reference `json:"rawResponse"`
 	ProcessedResponse S3Reference `json:"processedResponse"`
+	// Turn3 self-verification output, present only when Turn3 ran
+	Turn3RawResponse       S3Reference `json:"turn3RawResponse,omitempty"`
+	Turn3ProcessedResponse S3Reference `json:"turn3ProcessedResponse,omitempty"`
 }
 
 // Summary contains metrics and identifiers for the execution.
@@ -9,13 +9,9 @@ import (
 // LOCAL TYPE DEFINITIONS (for this function's specific needs)
 // ===================================================================
 
-// S3Reference represents a pointer to an object in S3
-type S3Reference struct {
-	Bucket string `json:"bucket"`
-	Key    string `json:"key"`
-	ETag   string `json:"etag,omitempty"`
-	Size   int64  `json:"size,omitempty"`
-}
+// S3Reference represents a pointer to an object in S3. It is the shared
+// schema type so references pass unchanged to the shared turn executor.
+type S3Reference = schema.S3Reference
 
 // ExecutionStage represents the current stage of processing
 type ExecutionStage string
@@ -181,7 +177,6 @@ func ConvertS3ReferenceToSchema(localRef S3Reference) map[string]interface{} {
 	return map[string]interface{}{
 		"bucket": localRef.Bucket,
 		"key":    localRef.Key,
-		"etag":   localRef.ETag,
 		"size":   localRef.Size,
 	}
 }
@@ -21,6 +21,10 @@ type VerificationContext struct {
 	VendingMachineId string `json:"vendingMachineId,omitempty"`
 	LayoutId         int    `json:"layoutId,omitempty"`
 	LayoutPrefix     string `json:"layoutPrefix,omitempty"`
+	// Locale of the prompt templates, empty for the default templates
+	Locale string `json:"locale,omitempty"`
+	// MaxTurns mirrors TurnConfig.MaxTurns; 3 or more requests the Turn 3 self-verification
+	MaxTurns int `json:"maxTurns,omitempty"`
 }
 
 // Validate performs validation on the VerificationContext to ensure required fields are present
@@ -35,8 +35,8 @@ func NewBedrockService(ctx context.Context, cfg config.Config) (BedrockService,
 		cfg.AWS.Region,
 		cfg.AWS.AnthropicVersion,
 		cfg.Processing.MaxTokens,
-		"",
-		0,
+		cfg.Processing.ThinkingType,
+		cfg.Processing.ThinkingBudgetTokens,
 	)
 
 	sharedClient, err := sharedBedrock.NewBedrockClient(ctx, cfg.AWS.BedrockModel, clientConfig)
@@ -54,8 +54,8 @@ func NewBedrockService(ctx context.Context, cfg config.Config) (BedrockService,
 		MaxTokens:        cfg.Processing.MaxTokens,
 		Temperature:      cfg.Processing.Temperature,
 		TopP:             cfg.Processing.TopP,
-		ThinkingType:     "",
-		ThinkingBudget:   0,
+		ThinkingType:     cfg.Processing.ThinkingType,
+		ThinkingBudget:   cfg.Processing.ThinkingBudgetTokens,
 		Timeout:          time.Duration(cfg.Processing.BedrockCallTimeoutSec) * time.Second,
 		Region:           cfg.AWS.Region,
 	}
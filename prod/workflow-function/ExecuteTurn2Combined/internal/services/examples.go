@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"workflow-function/shared/errors"
+	"workflow-function/shared/examples"
+)
+
+// FewShotExamples loads the reviewed examples curated by api_examples
+type FewShotExamples struct {
+	client dynamodb.ScanAPIClient
+	table  string
+}
+
+// NewFewShotExamples creates a loader for the examples table. An empty table
+// loads no examples.
+func NewFewShotExamples(client dynamodb.ScanAPIClient, table string) *FewShotExamples {
+	return &FewShotExamples{client: client, table: table}
+}
+
+// Load returns the reviewed examples of a verification type. The curated
+// table is small, so it is scanned; retired examples are filtered out by the
+// selection.
+func (f *FewShotExamples) Load(ctx context.Context, verificationType string) ([]examples.Example, error) {
+	if f == nil || f.table == "" {
+		return nil, nil
+	}
+	filter := expression.Name("verificationType").Equal(expression.Value(verificationType))
+	expr, err := expression.NewBuilder().WithFilter(filter).Build()
+	if err != nil {
+		return nil, errors.WrapError(err, errors.ErrorTypeDynamoDB, "failed to build expression for few-shot examples", false)
+	}
+	input := &dynamodb.ScanInput{
+		TableName:                 &f.table,
+		FilterExpression:          expr.Filter(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	var out []examples.Example
+	paginator := dynamodb.NewScanPaginator(f.client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, errors.WrapError(err, errors.ErrorTypeDynamoDB, "failed to scan few-shot examples", true).
+				WithContext("table", f.table)
+		}
+		var items []examples.Example
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, errors.WrapError(err, errors.ErrorTypeDynamoDB, "failed to unmarshal few-shot examples", false).
+				WithContext("table", f.table)
+		}
+		out = append(out, items...)
+	}
+	return out, nil
+}
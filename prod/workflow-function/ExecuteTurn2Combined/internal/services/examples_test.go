@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type fakeScanner struct {
+	pages  [][]map[string]types.AttributeValue
+	inputs []*dynamodb.ScanInput
+	err    error
+}
+
+func (f *fakeScanner) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	f.inputs = append(f.inputs, params)
+	if f.err != nil {
+		return nil, f.err
+	}
+	page := len(f.inputs) - 1
+	out := &dynamodb.ScanOutput{Items: f.pages[page]}
+	if page+1 < len(f.pages) {
+		out.LastEvaluatedKey = map[string]types.AttributeValue{"exampleId": &types.AttributeValueMemberS{Value: fmt.Sprint(page)}}
+	}
+	return out, nil
+}
+
+func exampleItem(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"exampleId":        &types.AttributeValueMemberS{Value: id},
+		"verificationType": &types.AttributeValueMemberS{Value: "LAYOUT_VS_CHECKING"},
+		"layoutId":         &types.AttributeValueMemberN{Value: "7"},
+	}
+}
+
+func TestFewShotExamplesLoadsAllPages(t *testing.T) {
+	client := &fakeScanner{pages: [][]map[string]types.AttributeValue{
+		{exampleItem("ex-1")},
+		{exampleItem("ex-2")},
+	}}
+	got, err := NewFewShotExamples(client, "FewShotExamples").Load(context.Background(), "LAYOUT_VS_CHECKING")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(got) != 2 || got[0].ExampleID != "ex-1" || got[1].ExampleID != "ex-2" || got[1].LayoutID != 7 {
+		t.Errorf("unexpected examples %+v", got)
+	}
+	if len(client.inputs) != 2 || client.inputs[1].ExclusiveStartKey == nil {
+		t.Errorf("expected the second page to continue from the first, got %d scans", len(client.inputs))
+	}
+	in := client.inputs[0]
+	if *in.TableName != "FewShotExamples" || in.FilterExpression == nil {
+		t.Errorf("expected a filtered scan of the examples table, got %+v", in)
+	}
+}
+
+func TestFewShotExamplesWithoutTable(t *testing.T) {
+	client := &fakeScanner{}
+	got, err := NewFewShotExamples(client, "").Load(context.Background(), "LAYOUT_VS_CHECKING")
+	if err != nil || got != nil || len(client.inputs) != 0 {
+		t.Errorf("expected no scan without a table, got %v, %v", got, err)
+	}
+
+	client.err = fmt.Errorf("ResourceNotFoundException")
+	if _, err := NewFewShotExamples(client, "FewShotExamples").Load(context.Background(), "LAYOUT_VS_CHECKING"); err == nil {
+		t.Error("expected the scan error")
+	}
+}
@@ -73,7 +73,10 @@ cp -r ../shared/logger "$BUILD_CONTEXT/shared/"
 cp -r ../shared/schema "$BUILD_CONTEXT/shared/"
 cp -r ../shared/s3state "$BUILD_CONTEXT/shared/"
 cp -r ../shared/errors "$BUILD_CONTEXT/shared/"
+cp -r ../shared/examples "$BUILD_CONTEXT/shared/"
+cp -r ../shared/experiments "$BUILD_CONTEXT/shared/"
 cp -r ../shared/templateloader "$BUILD_CONTEXT/shared/"
+cp -r ../shared/turnexecutor "$BUILD_CONTEXT/shared/"
 
 # Copy template files to build context
 echo -e "${YELLOW}Copying template files...${NC}"
@@ -101,10 +104,13 @@ require (
 	github.com/aws/aws-sdk-go-v2/service/s3 v1.79.3
 	workflow-function/shared/bedrock v0.0.0
 	workflow-function/shared/errors v0.0.0
+	workflow-function/shared/examples v0.0.0
+	workflow-function/shared/experiments v0.0.0
 	workflow-function/shared/logger v0.0.0
 	workflow-function/shared/s3state v0.0.0
 	workflow-function/shared/schema v0.0.0
 	workflow-function/shared/templateloader v0.0.0
+	workflow-function/shared/turnexecutor v0.0.0
 )
 
 require (
@@ -133,6 +139,10 @@ replace workflow-function/shared/bedrock => ./shared/bedrock
 
 replace workflow-function/shared/errors => ./shared/errors
 
+replace workflow-function/shared/examples => ./shared/examples
+
+replace workflow-function/shared/experiments => ./shared/experiments
+
 replace workflow-function/shared/logger => ./shared/logger
 
 replace workflow-function/shared/s3state => ./shared/s3state
@@ -140,6 +150,8 @@ replace workflow-function/shared/s3state => ./shared/s3state
 replace workflow-function/shared/schema => ./shared/schema
 
 replace workflow-function/shared/templateloader => ./shared/templateloader
+
+replace workflow-function/shared/turnexecutor => ./shared/turnexecutor
 EOF
 
 # Build the image from the temporary build context
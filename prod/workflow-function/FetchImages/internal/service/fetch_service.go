@@ -571,6 +571,9 @@ func (s *FetchService) fetchAllDataInParallel(
 				layoutMap["sourceJsonUrl"] = layout.SourceJsonUrl
 				layoutMap["machineStructure"] = layout.MachineStructure
 				layoutMap["productPositionMap"] = layout.ProductPositionMap
+				if layout.RenderGeometry != nil {
+					layoutMap["renderGeometry"] = layout.RenderGeometry
+				}
 
 				results.LayoutMeta = layoutMap
 				s.logger.Info("Successfully fetched layout metadata", map[string]interface{}{
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"workflow-function/FinalizeAndStoreResults/internal/models"
+	"workflow-function/FinalizeAndStoreResults/internal/overlay"
+	"workflow-function/shared/s3state"
+	"workflow-function/shared/schema"
+)
+
+// storeAnnotatedResult draws the parsed discrepancies on the reference
+// planogram (and on the checking photo when a grid calibration is available)
+// and stores the image next to the verification summary. It returns nil when
+// there is nothing to annotate.
+func storeAnnotatedResult(ctx context.Context, verificationID string, vctx *models.VerificationContextData, layoutRef *s3state.Reference, parsed *models.Turn2ParsedData) (*s3state.Reference, error) {
+	var structure *models.MachineStructure
+	var renderGeometry *models.RenderGeometry
+	if layoutRef != nil {
+		var layout models.LayoutMetadata
+		if err := stateManager.RetrieveJSON(layoutRef, &layout); err != nil {
+			log.Warn("layout_metadata_load_failed", map[string]interface{}{
+				"verificationId": verificationID,
+				"key":            layoutRef.Key,
+				"error":          err.Error(),
+			})
+		} else {
+			structure = layout.MachineStructure
+			renderGeometry = layout.RenderGeometry
+		}
+	}
+
+	var panels []overlay.Panel
+
+	// The reference image is only a rendered planogram for layout verifications
+	if structure != nil && vctx.ReferenceImageUrl != "" &&
+		(vctx.VerificationType == "" || vctx.VerificationType == schema.VerificationTypeLayoutVsChecking) {
+		img, err := loadS3Image(ctx, vctx.ReferenceImageUrl)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load reference image: %w", err)
+		}
+		geo, stored := overlay.LayoutPlanogramGeometry(renderGeometry, appConfig.VerticalSubLayouts)
+		if !stored {
+			log.Info("render_geometry_defaulted", map[string]interface{}{
+				"verificationId": verificationID,
+			})
+		}
+		grid, err := overlay.NewPlanogramGrid(structure, img.Bounds().Dx(), geo)
+		if err != nil {
+			return nil, err
+		}
+		panels = append(panels, overlay.Panel{Title: "Reference layout", Image: img, Grid: grid})
+	}
+
+	if vctx.GridCalibration != nil && vctx.CheckingImageUrl != "" {
+		grid, err := overlay.NewCalibratedGrid(vctx.GridCalibration, structure)
+		if err != nil {
+			log.Warn("grid_calibration_invalid", map[string]interface{}{
+				"verificationId": verificationID,
+				"error":          err.Error(),
+			})
+		} else {
+			img, err := loadS3Image(ctx, vctx.CheckingImageUrl)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load checking image: %w", err)
+			}
+			panels = append(panels, overlay.Panel{Title: "Checking image", Image: img, Grid: grid})
+		}
+	}
+
+	if len(panels) == 0 {
+		log.Info("annotated_result_skipped", map[string]interface{}{
+			"verificationId":     verificationID,
+			"verificationType":   vctx.VerificationType,
+			"hasLayoutStructure": structure != nil,
+			"hasGridCalibration": vctx.GridCalibration != nil,
+		})
+		return nil, nil
+	}
+
+	result, err := overlay.Render(panels, parsed.Discrepancies, annotationFooter(verificationID, parsed), overlay.Options{
+		MaxPanelHeight: appConfig.AnnotatedImageMaxHeight,
+	})
+	if err != nil {
+		return nil, err
+	}
+	data, err := overlay.EncodePNG(result.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	ref, err := stateManager.StoreWithContentType(extractDatePathFromVerificationID(verificationID), "results/annotated-result.png", data, "image/png")
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("annotated_result_stored", map[string]interface{}{
+		"verificationId": verificationID,
+		"bucket":         ref.Bucket,
+		"key":            ref.Key,
+		"size":           ref.Size,
+		"panels":         len(panels),
+		"discrepancies":  len(parsed.Discrepancies),
+		"placed":         result.Placed,
+		"unplaced":       result.Unplaced,
+	})
+	return ref, nil
+}
+
+// annotationFooter summarizes the verification below the legend
+func annotationFooter(verificationID string, parsed *models.Turn2ParsedData) []string {
+	summary := parsed.VerificationSummary
+	status := parsed.VerificationStatus
+	if status == "" {
+		status = summary.VerificationOutcome
+	}
+	lines := []string{
+		strings.TrimSpace(fmt.Sprintf("%s  %s", verificationID, status)),
+		fmt.Sprintf("Checked %d  Correct %d  Discrepant %d  Accuracy %s",
+			summary.TotalPositionsChecked, summary.CorrectPositions, summary.DiscrepantPositions, summary.OverallAccuracy),
+	}
+	if len(parsed.Discrepancies) == 0 && summary.DiscrepantPositions > 0 {
+		lines = append(lines, "Discrepancies were reported without positions")
+	}
+	return lines
+}
+
+// loadS3Image downloads and decodes a PNG or JPEG from an s3://bucket/key URL
+func loadS3Image(ctx context.Context, url string) (image.Image, error) {
+	bucket, key, ok := strings.Cut(strings.TrimPrefix(url, "s3://"), "/")
+	if !strings.HasPrefix(url, "s3://") || !ok || bucket == "" || key == "" {
+		return nil, fmt.Errorf("invalid S3 URL: %s", url)
+	}
+
+	out, err := awsClients.S3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+
+	img, _, err := image.Decode(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", url, err)
+	}
+	return img, nil
+}
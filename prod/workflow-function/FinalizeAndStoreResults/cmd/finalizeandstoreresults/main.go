@@ -141,6 +141,19 @@ func parseInputAndExtractReferences(input interface{}) (*s3state.Envelope, *s3st
 		}
 	}
 
+	// A Turn3 self-verification result, when present, replaces the Turn2 output
+	turn3Ref := extractNestedReference(input, "responses", "turn3Processed")
+	if turn3Ref == nil {
+		turn3Ref = extractReferenceFromMap(s3Refs, "turn3Processed")
+	}
+	if turn3Ref != nil {
+		log.Info("using_turn3_processed_response", map[string]interface{}{
+			"verification_id": verificationID,
+			"key":             turn3Ref.Key,
+		})
+		turn2Ref = turn3Ref
+	}
+
 	// Create envelope with flat references structure for compatibility
 	envelope := &s3state.Envelope{
 		VerificationID: verificationID,
@@ -153,6 +166,11 @@ func parseInputAndExtractReferences(input interface{}) (*s3state.Envelope, *s3st
 	envelope.References["processing_initialization"] = initRef
 	envelope.References["turn2Processed"] = turn2Ref
 
+	// Layout metadata locates cells on the planogram for the annotated result
+	if layoutRef := extractReferenceFromMap(s3Refs, "processing_layout-metadata"); layoutRef != nil {
+		envelope.References["processing_layout-metadata"] = layoutRef
+	}
+
 	return envelope, initRef, turn2Ref, nil
 }
 
@@ -331,6 +349,23 @@ func HandleRequest(ctx context.Context, input interface{}) (*s3state.Envelope, e
 		})
 	}
 
+	// Draw the discrepancies on the reference image. The annotation is a
+	// review aid, so failures are logged and do not fail the workflow.
+	var resultImageUrl string
+	if appConfig.AnnotatedImageEnabled {
+		imageRef, err := storeAnnotatedResult(ctx, envelope.VerificationID, initData.VerificationContext,
+			envelope.References["processing_layout-metadata"], parsed)
+		if err != nil {
+			log.Warn("annotated_result_failed", map[string]interface{}{
+				"verificationId": envelope.VerificationID,
+				"error":          err.Error(),
+			})
+		} else if imageRef != nil {
+			resultImageUrl = fmt.Sprintf("s3://%s/%s", imageRef.Bucket, imageRef.Key)
+			envelope.References["results_annotated"] = imageRef
+		}
+	}
+
 	item := models.VerificationResultItem{
 		VerificationID:         envelope.VerificationID,
 		VerificationAt:         initData.VerificationContext.VerificationAt, // Use existing verificationAt to update the correct record
@@ -347,6 +382,7 @@ func HandleRequest(ctx context.Context, input interface{}) (*s3state.Envelope, e
 		InitialConfirmation:    parsed.InitialConfirmation,
 		VerificationSummary:    parsed.VerificationSummary,
 		PreviousVerificationID: initData.VerificationContext.PreviousVerificationID,
+		ResultImageUrl:         resultImageUrl,
 	}
 	if initData.VerificationContext.LayoutID == 0 {
 		item.LayoutID = nil
@@ -360,7 +396,7 @@ func HandleRequest(ctx context.Context, input interface{}) (*s3state.Envelope, e
 	}
 
 	// Update conversation history in DynamoDB with enhanced error handling
-	err = dynamodbhelper.UpdateConversationHistory(ctx, awsClients.DynamoDBClient, appConfig.ConversationHistoryTable, envelope.VerificationID, initData.VerificationContext.VerificationAt, log)
+	err = dynamodbhelper.UpdateConversationHistory(ctx, awsClients.DynamoDBClient, appConfig.ConversationHistoryTable, envelope.VerificationID, initData.VerificationContext.VerificationAt, resultImageUrl, log)
 	if err != nil {
 		// The enhanced helper already provides detailed error information and logging
 		return nil, err
@@ -386,6 +422,9 @@ func HandleRequest(ctx context.Context, input interface{}) (*s3state.Envelope, e
 	envelope.Summary["verificationStatus"] = verificationStatus // Use the corrected verificationStatus
 	envelope.Summary["verificationAt"] = now
 	envelope.Summary["message"] = "Verification finalized and stored"
+	if resultImageUrl != "" {
+		envelope.Summary["resultImageUrl"] = resultImageUrl
+	}
 
 	// Add results reference to envelope
 	if envelope.References == nil {
@@ -1,6 +1,6 @@
 module workflow-function/FinalizeAndStoreResults
 
-go 1.22
+go 1.24.0
 
 replace workflow-function/shared/logger => ../shared/logger
 
@@ -10,6 +10,8 @@ replace workflow-function/shared/s3state => ../shared/s3state
 
 replace workflow-function/shared/errors => ../shared/errors
 
+replace workflow-function/shared/templateloader => ../shared/templateloader
+
 require (
 	github.com/aws/aws-lambda-go v1.48.0
 	github.com/aws/aws-sdk-go-v2 v1.36.3
@@ -21,6 +23,7 @@ require (
 	workflow-function/shared/logger v0.0.0-00010101000000-000000000000
 	workflow-function/shared/s3state v0.0.0-00010101000000-000000000000
 	workflow-function/shared/schema v0.0.0-00010101000000-000000000000
+	workflow-function/shared/templateloader v0.0.0-00010101000000-000000000000
 )
 
 require (
@@ -41,4 +44,5 @@ require (
 	github.com/aws/aws-sdk-go-v2/service/ssooidc v1.23.3 // indirect
 	github.com/aws/aws-sdk-go-v2/service/sts v1.28.5 // indirect
 	github.com/aws/smithy-go v1.22.3 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
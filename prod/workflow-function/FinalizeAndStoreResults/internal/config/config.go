@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
 
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -14,6 +15,12 @@ type LambdaConfig struct {
 	VerificationResultsTable string
 	ConversationHistoryTable string
 	StateBucket              string
+	// AnnotatedImageEnabled turns the annotated result image on or off
+	AnnotatedImageEnabled bool
+	// AnnotatedImageMaxHeight caps the height of each image in the annotation
+	AnnotatedImageMaxHeight int
+	// VerticalSubLayouts must match SUBLAYOUT_ARRANGEMENT of the layout renderer
+	VerticalSubLayouts bool
 }
 
 func LoadEnvConfig() (*LambdaConfig, error) {
@@ -21,6 +28,16 @@ func LoadEnvConfig() (*LambdaConfig, error) {
 	cfg.VerificationResultsTable = os.Getenv("DYNAMODB_VERIFICATION_TABLE")
 	cfg.ConversationHistoryTable = os.Getenv("DYNAMODB_CONVERSATION_TABLE")
 	cfg.StateBucket = os.Getenv("STATE_BUCKET")
+	cfg.AnnotatedImageEnabled = os.Getenv("ANNOTATED_IMAGE_ENABLED") != "false"
+	cfg.AnnotatedImageMaxHeight = 1600
+	if v := os.Getenv("ANNOTATED_IMAGE_MAX_HEIGHT"); v != "" {
+		height, err := strconv.Atoi(v)
+		if err != nil || height <= 0 {
+			return nil, fmt.Errorf("invalid env ANNOTATED_IMAGE_MAX_HEIGHT: %s", v)
+		}
+		cfg.AnnotatedImageMaxHeight = height
+	}
+	cfg.VerticalSubLayouts = os.Getenv("SUBLAYOUT_ARRANGEMENT") == "vertical"
 
 	if cfg.VerificationResultsTable == "" {
 		return nil, fmt.Errorf("missing env DYNAMODB_VERIFICATION_TABLE")
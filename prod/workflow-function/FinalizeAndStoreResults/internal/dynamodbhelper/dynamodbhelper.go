@@ -303,6 +303,12 @@ func StoreVerificationResult(ctx context.Context, client *dynamodb.Client, table
 		"errorTracking = if_not_exists(errorTracking, :empty_map)",
 	)
 
+	// The annotated result image is optional
+	if item.ResultImageUrl != "" {
+		updateExpressionParts = append(updateExpressionParts, "resultImageUrl = :rimg")
+		expressionAttributeValues[":rimg"] = &types.AttributeValueMemberS{Value: item.ResultImageUrl}
+	}
+
 	// Conditionally include layoutId only if it has a valid value
 	// This prevents DynamoDB GSI validation errors for LayoutIndex when layoutId is null
 	if item.LayoutID != nil && *item.LayoutID > 0 {
@@ -361,7 +367,9 @@ func StoreVerificationResult(ctx context.Context, client *dynamodb.Client, table
 	return nil
 }
 
-func UpdateConversationHistory(ctx context.Context, client *dynamodb.Client, tableName, verificationID, expectedConversationAt string, log logger.Logger) error {
+// UpdateConversationHistory marks the latest conversation record of the
+// verification as completed and links the annotated result image, if any
+func UpdateConversationHistory(ctx context.Context, client *dynamodb.Client, tableName, verificationID, expectedConversationAt, resultImageUrl string, log logger.Logger) error {
 	if verificationID == "" {
 		validationErr := errors.NewValidationError("verificationID required", map[string]interface{}{
 			"field": "verificationID",
@@ -449,16 +457,23 @@ func UpdateConversationHistory(ctx context.Context, client *dynamodb.Client, tab
 		"newStatus":                "WORKFLOW_COMPLETED",
 	})
 
+	updateExpression := "SET turnStatus = :ts"
+	expressionAttributeValues := map[string]types.AttributeValue{
+		":ts": &types.AttributeValueMemberS{Value: "WORKFLOW_COMPLETED"},
+	}
+	if resultImageUrl != "" {
+		updateExpression += ", resultImageUrl = :rimg"
+		expressionAttributeValues[":rimg"] = &types.AttributeValueMemberS{Value: resultImageUrl}
+	}
+
 	updateInput := &dynamodb.UpdateItemInput{
 		TableName: &tableName,
 		Key: map[string]types.AttributeValue{
 			"verificationId": &types.AttributeValueMemberS{Value: verificationID},
 			"conversationAt": &types.AttributeValueMemberS{Value: actualConversationAt},
 		},
-		UpdateExpression: aws.String("SET turnStatus = :ts"),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":ts": &types.AttributeValueMemberS{Value: "WORKFLOW_COMPLETED"},
-		},
+		UpdateExpression:          aws.String(updateExpression),
+		ExpressionAttributeValues: expressionAttributeValues,
 	}
 
 	_, err = client.UpdateItem(ctx, updateInput)
@@ -466,7 +481,7 @@ func UpdateConversationHistory(ctx context.Context, client *dynamodb.Client, tab
 		enhancedErr := createEnhancedDynamoDBError("UpdateItem", tableName, err, map[string]interface{}{
 			"verificationId": verificationID,
 			"conversationAt": actualConversationAt,
-			"updateExpression": "SET turnStatus = :ts",
+			"updateExpression": updateExpression,
 			"newStatus": "WORKFLOW_COMPLETED",
 		})
 		enhancedErr.VerificationID = verificationID
@@ -71,6 +71,63 @@ type VerificationContextData struct {
 	PreviousVerificationID string                 `json:"previousVerificationId,omitempty"`
 	ResourceValidation     map[string]interface{} `json:"resourceValidation,omitempty"`
 	LastUpdatedAt          string                 `json:"lastUpdatedAt,omitempty"`
+	GridCalibration        *GridCalibration       `json:"gridCalibration,omitempty"`
+}
+
+// GridCalibration locates the slot grid in the checking image so discrepancies
+// can be drawn on the photo as well as on the planogram
+type GridCalibration struct {
+	// Corners of the slot grid in checking-image pixels: top-left, top-right,
+	// bottom-right, bottom-left
+	Corners  [4]Point `json:"corners"`
+	RowOrder []string `json:"rowOrder,omitempty"`
+	Columns  int      `json:"columns,omitempty"`
+}
+
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// LayoutMetadata is the subset of processing/layout-metadata.json needed to
+// locate cells on the rendered planogram
+
+type LayoutMetadata struct {
+	MachineStructure *MachineStructure `json:"machineStructure"`
+	RenderGeometry   *RenderGeometry   `json:"renderGeometry,omitempty"`
+}
+
+// RenderGeometry is the renderer configuration stored with the layout by
+// upload-render-json, in layout units before canvasScale
+type RenderGeometry struct {
+	CanvasScale          float64 `json:"canvasScale"`
+	Padding              float64 `json:"padding"`
+	TitlePadding         float64 `json:"titlePadding"`
+	HeaderHeight         float64 `json:"headerHeight"`
+	CellWidth            float64 `json:"cellWidth"`
+	CellHeight           float64 `json:"cellHeight"`
+	CellSpacing          float64 `json:"cellSpacing"`
+	RowSpacing           float64 `json:"rowSpacing"`
+	FooterHeight         float64 `json:"footerHeight"`
+	MaxColumns           int     `json:"maxColumns"`
+	SubLayoutArrangement string  `json:"subLayoutArrangement"`
+	SubLayoutSpacing     float64 `json:"subLayoutSpacing"`
+	SubLayoutLabelHeight float64 `json:"subLayoutLabelHeight"`
+}
+
+type MachineStructure struct {
+	RowCount      int                  `json:"rowCount"`
+	ColumnsPerRow int                  `json:"columnsPerRow"`
+	RowOrder      []string             `json:"rowOrder"`
+	SubLayouts    []SubLayoutStructure `json:"subLayouts,omitempty"`
+}
+
+type SubLayoutStructure struct {
+	Index         int      `json:"index"`
+	Label         string   `json:"label"`
+	RowCount      int      `json:"rowCount"`
+	ColumnsPerRow int      `json:"columnsPerRow"`
+	RowOrder      []string `json:"rowOrder"`
 }
 
 // Parsed Turn2 data
@@ -79,6 +136,22 @@ type Turn2ParsedData struct {
 	VerificationSummary OutputVerificationSummary
 	InitialConfirmation string
 	VerificationStatus  string
+	Discrepancies       []Discrepancy
+}
+
+// Discrepancy types drawn on the annotated result image
+const (
+	DiscrepancyMissing    = "MISSING"
+	DiscrepancyIncorrect  = "INCORRECT"
+	DiscrepancyUnexpected = "UNEXPECTED"
+)
+
+// Discrepancy is a single position-level finding from the Turn2 response.
+// Position uses the layout's position keys, e.g. "C4" or "D2-A1".
+type Discrepancy struct {
+	Position    string `json:"position"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
 }
 
 // DynamoDB item
@@ -110,4 +183,5 @@ type VerificationResultItem struct {
 	VerificationSummary    OutputVerificationSummary `dynamodbav:"verificationSummary"`
 	Metadata               map[string]interface{}    `dynamodbav:"metadata,omitempty"`
 	PreviousVerificationID string                    `dynamodbav:"previousVerificationId,omitempty"`
+	ResultImageUrl         string                    `dynamodbav:"resultImageUrl,omitempty"`
 }
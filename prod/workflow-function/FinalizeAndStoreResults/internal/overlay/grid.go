@@ -0,0 +1,280 @@
+package overlay
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"workflow-function/FinalizeAndStoreResults/internal/models"
+	"workflow-function/shared/templateloader"
+)
+
+// Point is a pixel position in an image
+type Point struct {
+	X, Y float64
+}
+
+// Quad is a cell outline: top-left, top-right, bottom-right, bottom-left
+type Quad [4]Point
+
+// Grid maps a position such as "C04" or "D2-A01" to its outline in an image
+type Grid interface {
+	Cell(position string) (Quad, bool)
+}
+
+// splitPosition splits a position in any form templateloader.NormalizePosition
+// accepts ("D2-A1", "d2-a01") into row "D2-A" and column 1
+func splitPosition(position string) (string, int, bool) {
+	id := templateloader.NormalizePosition(position)
+	i := strings.LastIndexFunc(id, func(r rune) bool { return r < '0' || r > '9' })
+	if i < 0 || i == len(id)-1 {
+		return "", 0, false
+	}
+	column, err := strconv.Atoi(id[i+1:])
+	if err != nil || column < 1 {
+		return "", 0, false
+	}
+	return id[:i+1], column, true
+}
+
+// sameRow reports whether a machine structure row key such as "A" or "d2-a"
+// names the row of a normalized position
+func sameRow(key, row string) bool {
+	return strings.EqualFold(strings.TrimSpace(key), row)
+}
+
+// PlanogramGeometry is the layout renderer configuration of upload-render-json
+// (in layout units, before CanvasScale). The reference planogram is located by
+// repeating the renderer's layout math.
+type PlanogramGeometry struct {
+	Padding              float64
+	TitlePadding         float64
+	HeaderHeight         float64
+	CellWidth            float64
+	CellHeight           float64
+	CellSpacing          float64
+	RowSpacing           float64
+	FooterHeight         float64
+	MaxColumns           int
+	SubLayoutSpacing     float64
+	SubLayoutLabelHeight float64
+	// VerticalSubLayouts stacks doors top to bottom instead of side by side
+	VerticalSubLayouts bool
+}
+
+// LayoutPlanogramGeometry returns the geometry stored with the layout when it
+// was rendered. Layouts rendered before the geometry was stored (or with an
+// incomplete one) fall back to DefaultPlanogramGeometry, with doors stacked
+// when verticalSubLayouts is set. The second result reports whether the
+// stored geometry was used.
+func LayoutPlanogramGeometry(render *models.RenderGeometry, verticalSubLayouts bool) (PlanogramGeometry, bool) {
+	if render == nil || render.CellWidth <= 0 || render.CellHeight <= 0 {
+		geo := DefaultPlanogramGeometry()
+		geo.VerticalSubLayouts = verticalSubLayouts
+		return geo, false
+	}
+	return PlanogramGeometry{
+		Padding:              render.Padding,
+		TitlePadding:         render.TitlePadding,
+		HeaderHeight:         render.HeaderHeight,
+		CellWidth:            render.CellWidth,
+		CellHeight:           render.CellHeight,
+		CellSpacing:          render.CellSpacing,
+		RowSpacing:           render.RowSpacing,
+		FooterHeight:         render.FooterHeight,
+		MaxColumns:           render.MaxColumns,
+		SubLayoutSpacing:     render.SubLayoutSpacing,
+		SubLayoutLabelHeight: render.SubLayoutLabelHeight,
+		VerticalSubLayouts:   render.SubLayoutArrangement == "vertical",
+	}, true
+}
+
+// DefaultPlanogramGeometry returns the renderer defaults, used for layouts
+// stored without their render geometry
+func DefaultPlanogramGeometry() PlanogramGeometry {
+	return PlanogramGeometry{
+		Padding:              20,
+		TitlePadding:         40,
+		HeaderHeight:         40,
+		CellWidth:            150,
+		CellHeight:           180,
+		CellSpacing:          10,
+		RowSpacing:           60,
+		FooterHeight:         30,
+		MaxColumns:           20,
+		SubLayoutSpacing:     60,
+		SubLayoutLabelHeight: 30,
+	}
+}
+
+// PlanogramGrid locates cells on a rendered layout image
+type PlanogramGrid struct {
+	geo         PlanogramGeometry
+	scale       float64
+	labelHeight float64
+	doors       []planogramDoor
+}
+
+type planogramDoor struct {
+	rows             []string
+	columns          int
+	offsetX, offsetY float64
+}
+
+// NewPlanogramGrid computes the cell positions for a planogram image of the
+// given pixel width. The scale is derived from the width, so images rendered
+// at any CanvasScale (or resized afterwards) line up.
+func NewPlanogramGrid(structure *models.MachineStructure, imageWidth int, geo PlanogramGeometry) (*PlanogramGrid, error) {
+	if structure == nil {
+		return nil, fmt.Errorf("machine structure is required")
+	}
+
+	var doors []planogramDoor
+	if len(structure.SubLayouts) > 0 {
+		for _, sub := range structure.SubLayouts {
+			if len(sub.RowOrder) > 0 {
+				doors = append(doors, planogramDoor{rows: sub.RowOrder, columns: sub.ColumnsPerRow})
+			}
+		}
+	} else if len(structure.RowOrder) > 0 {
+		doors = append(doors, planogramDoor{rows: structure.RowOrder, columns: structure.ColumnsPerRow})
+	}
+	if len(doors) == 0 {
+		return nil, fmt.Errorf("machine structure has no rows")
+	}
+
+	g := &PlanogramGrid{geo: geo, doors: doors}
+	if len(doors) > 1 {
+		g.labelHeight = geo.SubLayoutLabelHeight
+	}
+
+	var contentWidth float64
+	var contentHeight float64
+	for i := range g.doors {
+		d := &g.doors[i]
+		if d.columns < 1 {
+			d.columns = 1
+		}
+		if geo.MaxColumns > 0 && d.columns > geo.MaxColumns {
+			d.columns = geo.MaxColumns
+		}
+		rows := float64(len(d.rows))
+		width := float64(d.columns)*geo.CellWidth + float64(d.columns-1)*geo.CellSpacing
+		height := g.labelHeight + geo.HeaderHeight + rows*(geo.CellHeight+geo.FooterHeight) + (rows-1)*geo.RowSpacing
+
+		if geo.VerticalSubLayouts {
+			if i > 0 {
+				contentHeight += geo.SubLayoutSpacing
+			}
+			d.offsetY = contentHeight
+			contentHeight += height
+			if width > contentWidth {
+				contentWidth = width
+			}
+		} else {
+			if i > 0 {
+				contentWidth += geo.SubLayoutSpacing
+			}
+			d.offsetX = contentWidth
+			contentWidth += width
+		}
+	}
+
+	layoutWidth := geo.Padding*2 + contentWidth
+	if imageWidth <= 0 || layoutWidth <= 0 {
+		return nil, fmt.Errorf("invalid planogram width %d", imageWidth)
+	}
+	g.scale = float64(imageWidth) / layoutWidth
+	return g, nil
+}
+
+// Cell implements Grid
+func (g *PlanogramGrid) Cell(position string) (Quad, bool) {
+	row, column, ok := splitPosition(position)
+	if !ok {
+		return Quad{}, false
+	}
+	for _, d := range g.doors {
+		if column > d.columns {
+			continue
+		}
+		for rowIdx, key := range d.rows {
+			if !sameRow(key, row) {
+				continue
+			}
+			x := g.geo.Padding + d.offsetX + float64(column-1)*(g.geo.CellWidth+g.geo.CellSpacing)
+			y := g.geo.Padding + g.geo.TitlePadding + d.offsetY + g.labelHeight + g.geo.HeaderHeight +
+				float64(rowIdx)*(g.geo.CellHeight+g.geo.FooterHeight+g.geo.RowSpacing)
+			return rectQuad(x*g.scale, y*g.scale, g.geo.CellWidth*g.scale, g.geo.CellHeight*g.scale), true
+		}
+	}
+	return Quad{}, false
+}
+
+// CalibratedGrid locates cells in a photo from the four corners of the slot
+// grid, so moderate perspective is followed by interpolating between corners
+type CalibratedGrid struct {
+	corners Quad
+	rows    []string
+	columns int
+}
+
+// NewCalibratedGrid builds a grid from a calibration. Rows and columns
+// default to the machine structure when the calibration does not set them.
+func NewCalibratedGrid(calibration *models.GridCalibration, structure *models.MachineStructure) (*CalibratedGrid, error) {
+	if calibration == nil {
+		return nil, fmt.Errorf("grid calibration is required")
+	}
+
+	g := &CalibratedGrid{rows: calibration.RowOrder, columns: calibration.Columns}
+	for i, c := range calibration.Corners {
+		g.corners[i] = Point{X: c.X, Y: c.Y}
+	}
+	if structure != nil {
+		if len(g.rows) == 0 {
+			g.rows = structure.RowOrder
+		}
+		if g.columns == 0 {
+			g.columns = structure.ColumnsPerRow
+		}
+	}
+	if len(g.rows) == 0 || g.columns < 1 {
+		return nil, fmt.Errorf("grid calibration needs row order and column count")
+	}
+	if g.corners[0] == g.corners[2] {
+		return nil, fmt.Errorf("grid calibration corners are not set")
+	}
+	return g, nil
+}
+
+// Cell implements Grid
+func (g *CalibratedGrid) Cell(position string) (Quad, bool) {
+	row, column, ok := splitPosition(position)
+	if !ok || column > g.columns {
+		return Quad{}, false
+	}
+	for rowIdx, key := range g.rows {
+		if !sameRow(key, row) {
+			continue
+		}
+		u0 := float64(column-1) / float64(g.columns)
+		u1 := float64(column) / float64(g.columns)
+		v0 := float64(rowIdx) / float64(len(g.rows))
+		v1 := float64(rowIdx+1) / float64(len(g.rows))
+		return Quad{g.at(u0, v0), g.at(u1, v0), g.at(u1, v1), g.at(u0, v1)}, true
+	}
+	return Quad{}, false
+}
+
+// at interpolates bilinearly between the corners; u runs left to right and v
+// top to bottom, both in [0, 1]
+func (g *CalibratedGrid) at(u, v float64) Point {
+	tl, tr, br, bl := g.corners[0], g.corners[1], g.corners[2], g.corners[3]
+	top := Point{X: tl.X + (tr.X-tl.X)*u, Y: tl.Y + (tr.Y-tl.Y)*u}
+	bottom := Point{X: bl.X + (br.X-bl.X)*u, Y: bl.Y + (br.Y-bl.Y)*u}
+	return Point{X: top.X + (bottom.X-top.X)*v, Y: top.Y + (bottom.Y-top.Y)*v}
+}
+
+func rectQuad(x, y, w, h float64) Quad {
+	return Quad{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}}
+}
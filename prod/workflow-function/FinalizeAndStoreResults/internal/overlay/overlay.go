@@ -0,0 +1,316 @@
+// Package overlay draws verification discrepancies on the reference planogram
+// and, when the slot grid is calibrated, on the checking photo.
+package overlay
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+
+	"workflow-function/FinalizeAndStoreResults/internal/models"
+)
+
+// kindStyles lists the discrepancy types in legend order
+var kindStyles = []struct {
+	kind  string
+	label string
+	color color.RGBA
+}{
+	{models.DiscrepancyMissing, "Missing", color.RGBA{220, 38, 38, 255}},
+	{models.DiscrepancyIncorrect, "Incorrect", color.RGBA{245, 158, 11, 255}},
+	{models.DiscrepancyUnexpected, "Unexpected", color.RGBA{37, 99, 235, 255}},
+}
+
+const (
+	fillAlpha = 0.35
+	gap       = 20
+)
+
+var (
+	backgroundColor = color.RGBA{255, 255, 255, 255}
+	textColor       = color.RGBA{33, 37, 41, 255}
+	mutedColor      = color.RGBA{108, 117, 125, 255}
+)
+
+// Panel is an image with the grid that locates cells in it
+type Panel struct {
+	Title string
+	Image image.Image
+	Grid  Grid
+}
+
+// Options controls the output size
+type Options struct {
+	// MaxPanelHeight caps the height panels are scaled to; panels are never
+	// scaled up
+	MaxPanelHeight int
+}
+
+// Result is the annotated image and how many discrepancies were drawn
+type Result struct {
+	Image *image.RGBA
+	// Placed counts discrepancies drawn on at least one panel
+	Placed int
+	// Unplaced lists positions no panel could locate
+	Unplaced []string
+}
+
+// Render places the panels side by side, fills the cell of every discrepancy
+// with the color of its type and adds a legend and the footer lines below.
+func Render(panels []Panel, discrepancies []models.Discrepancy, footer []string, opts Options) (*Result, error) {
+	if len(panels) == 0 {
+		return nil, fmt.Errorf("at least one panel is required")
+	}
+
+	// Scale all panels to a common height
+	height := opts.MaxPanelHeight
+	for _, p := range panels {
+		if h := p.Image.Bounds().Dy(); height <= 0 || h < height {
+			height = h
+		}
+	}
+	if height <= 0 {
+		return nil, fmt.Errorf("panel images are empty")
+	}
+
+	textSize := int(math.Max(2, float64(height)/400))
+	titleHeight := 0
+	for _, p := range panels {
+		if p.Title != "" {
+			titleHeight = textHeight(textSize) + gap
+		}
+	}
+
+	widths := make([]int, len(panels))
+	totalWidth := gap
+	for i, p := range panels {
+		b := p.Image.Bounds()
+		widths[i] = int(math.Round(float64(b.Dx()) * float64(height) / float64(b.Dy())))
+		totalWidth += widths[i] + gap
+	}
+
+	// Keep the footer readable when the panels are narrow
+	for _, line := range footer {
+		if w := gap*2 + textWidth(line, textSize); w > totalWidth {
+			totalWidth = w
+		}
+	}
+
+	lineHeight := textHeight(textSize) + textSize*4
+	legendHeight := lineHeight + gap
+	footerHeight := len(footer)*lineHeight + gap
+	totalHeight := gap + titleHeight + height + legendHeight + footerHeight
+
+	canvas := image.NewRGBA(image.Rect(0, 0, totalWidth, totalHeight))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(backgroundColor), image.Point{}, draw.Src)
+
+	placed := make(map[string]bool)
+	border := int(math.Max(2, float64(height)/300))
+	x := gap
+	for i, p := range panels {
+		if p.Title != "" {
+			drawText(canvas, p.Title, x, gap, textSize, textColor)
+		}
+		top := gap + titleHeight
+		scaled := resize(p.Image, widths[i], height)
+		draw.Draw(canvas, image.Rect(x, top, x+widths[i], top+height), scaled, image.Point{}, draw.Src)
+
+		sx := float64(widths[i]) / float64(p.Image.Bounds().Dx())
+		sy := float64(height) / float64(p.Image.Bounds().Dy())
+		for _, d := range discrepancies {
+			quad, ok := p.Grid.Cell(d.Position)
+			if !ok {
+				continue
+			}
+			for j := range quad {
+				quad[j] = Point{X: float64(x) + quad[j].X*sx, Y: float64(top) + quad[j].Y*sy}
+			}
+			c := kindColor(d.Type)
+			fillQuad(canvas, quad, c, fillAlpha)
+			strokeQuad(canvas, quad, c, border)
+			drawLabel(canvas, d.Position, quad[0], textSize, c)
+			placed[d.Position] = true
+		}
+		x += widths[i] + gap
+	}
+
+	// Legend
+	y := gap + titleHeight + height + gap
+	x = gap
+	for _, style := range kindStyles {
+		count := 0
+		for _, d := range discrepancies {
+			if d.Type == style.kind {
+				count++
+			}
+		}
+		swatch := textHeight(textSize)
+		fillRect(canvas, image.Rect(x, y, x+swatch, y+swatch), style.color)
+		label := fmt.Sprintf("%s (%d)", style.label, count)
+		drawText(canvas, label, x+swatch+textSize*3, y, textSize, textColor)
+		x += swatch + textSize*3 + textWidth(label, textSize) + gap*2
+	}
+
+	// Footer
+	y += legendHeight
+	for i, line := range footer {
+		c := textColor
+		if i > 0 {
+			c = mutedColor
+		}
+		drawText(canvas, line, gap, y, textSize, c)
+		y += lineHeight
+	}
+
+	result := &Result{Image: canvas, Placed: len(placed)}
+	for _, d := range discrepancies {
+		if !placed[d.Position] {
+			result.Unplaced = append(result.Unplaced, d.Position)
+		}
+	}
+	return result, nil
+}
+
+// EncodePNG encodes the annotated image
+func EncodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := &png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func kindColor(kind string) color.RGBA {
+	for _, style := range kindStyles {
+		if style.kind == kind {
+			return style.color
+		}
+	}
+	return kindStyles[1].color
+}
+
+// resize scales src to w x h, averaging the source pixels covered by each
+// destination pixel
+func resize(src image.Image, w, h int) *image.RGBA {
+	b := src.Bounds()
+	rgba, ok := src.(*image.RGBA)
+	if !ok || b.Min != (image.Point{}) {
+		rgba = image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(rgba, rgba.Bounds(), src, b.Min, draw.Src)
+	}
+	sw, sh := b.Dx(), b.Dy()
+	if sw == w && sh == h {
+		return rgba
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for dy := 0; dy < h; dy++ {
+		y0, y1 := dy*sh/h, (dy+1)*sh/h
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for dx := 0; dx < w; dx++ {
+			x0, x1 := dx*sw/w, (dx+1)*sw/w
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				i := rgba.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += int(rgba.Pix[i+c])
+					}
+					i += 4
+				}
+			}
+			n := (x1 - x0) * (y1 - y0)
+			o := dst.PixOffset(dx, dy)
+			for c := 0; c < 4; c++ {
+				dst.Pix[o+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+	return dst
+}
+
+// fillQuad blends c over the pixels inside the convex quad
+func fillQuad(dst *image.RGBA, q Quad, c color.RGBA, alpha float64) {
+	minX, minY, maxX, maxY := quadBounds(q, dst.Bounds())
+	for y := minY; y < maxY; y++ {
+		for x := minX; x < maxX; x++ {
+			if !insideQuad(q, float64(x)+0.5, float64(y)+0.5) {
+				continue
+			}
+			o := dst.PixOffset(x, y)
+			dst.Pix[o] = blend(dst.Pix[o], c.R, alpha)
+			dst.Pix[o+1] = blend(dst.Pix[o+1], c.G, alpha)
+			dst.Pix[o+2] = blend(dst.Pix[o+2], c.B, alpha)
+		}
+	}
+}
+
+// strokeQuad draws the outline of the quad with the given width
+func strokeQuad(dst *image.RGBA, q Quad, c color.RGBA, width int) {
+	for i := range q {
+		a, b := q[i], q[(i+1)%len(q)]
+		steps := int(math.Max(math.Abs(b.X-a.X), math.Abs(b.Y-a.Y)))
+		for s := 0; s <= steps; s++ {
+			t := 0.0
+			if steps > 0 {
+				t = float64(s) / float64(steps)
+			}
+			x := int(a.X + (b.X-a.X)*t)
+			y := int(a.Y + (b.Y-a.Y)*t)
+			fillRect(dst, image.Rect(x-width/2, y-width/2, x-width/2+width, y-width/2+width), c)
+		}
+	}
+}
+
+// drawLabel draws the position key on a colored tab at the cell's top-left
+func drawLabel(dst *image.RGBA, text string, at Point, size int, c color.RGBA) {
+	x, y := int(at.X), int(at.Y)
+	pad := size * 2
+	fillRect(dst, image.Rect(x, y, x+textWidth(text, size)+pad*2, y+textHeight(size)+pad*2), c)
+	drawText(dst, text, x+pad, y+pad, size, backgroundColor)
+}
+
+func fillRect(dst *image.RGBA, r image.Rectangle, c color.RGBA) {
+	draw.Draw(dst, r.Intersect(dst.Bounds()), image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+func quadBounds(q Quad, clip image.Rectangle) (int, int, int, int) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range q {
+		minX, minY = math.Min(minX, p.X), math.Min(minY, p.Y)
+		maxX, maxY = math.Max(maxX, p.X), math.Max(maxY, p.Y)
+	}
+	r := image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY))).Intersect(clip)
+	return r.Min.X, r.Min.Y, r.Max.X, r.Max.Y
+}
+
+// insideQuad reports whether (x, y) is inside the convex quad, whichever way
+// its corners wind
+func insideQuad(q Quad, x, y float64) bool {
+	var pos, neg bool
+	for i := range q {
+		a, b := q[i], q[(i+1)%len(q)]
+		cross := (b.X-a.X)*(y-a.Y) - (b.Y-a.Y)*(x-a.X)
+		if cross > 0 {
+			pos = true
+		} else if cross < 0 {
+			neg = true
+		}
+	}
+	return !(pos && neg)
+}
+
+func blend(dst, src uint8, alpha float64) uint8 {
+	return uint8(float64(dst)*(1-alpha) + float64(src)*alpha + 0.5)
+}
@@ -0,0 +1,192 @@
+package overlay
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"workflow-function/FinalizeAndStoreResults/internal/models"
+)
+
+func TestPlanogramGridCell(t *testing.T) {
+	structure := &models.MachineStructure{RowOrder: []string{"A", "B"}, ColumnsPerRow: 3}
+	geo := DefaultPlanogramGeometry()
+
+	// 3 columns: 2*20 + 3*150 + 2*10 = 510 layout units, rendered at scale 4
+	grid, err := NewPlanogramGrid(structure, 2040, geo)
+	if err != nil {
+		t.Fatalf("NewPlanogramGrid failed: %v", err)
+	}
+
+	quad, ok := grid.Cell("B2")
+	if !ok {
+		t.Fatal("Expected B2 to be located")
+	}
+	// x = 20 + 160, y = 20 + 40 + 40 + 270
+	want := rectQuad(180*4, 370*4, 150*4, 180*4)
+	if quad != want {
+		t.Errorf("Expected %v, got %v", want, quad)
+	}
+
+	for _, position := range []string{"C1", "A4", "A0", "1"} {
+		if _, ok := grid.Cell(position); ok {
+			t.Errorf("Expected %s to be outside the grid", position)
+		}
+	}
+}
+
+func TestPlanogramGridSubLayouts(t *testing.T) {
+	structure := &models.MachineStructure{
+		SubLayouts: []models.SubLayoutStructure{
+			{RowOrder: []string{"D1-A"}, ColumnsPerRow: 2},
+			{RowOrder: []string{"D2-A"}, ColumnsPerRow: 2},
+		},
+	}
+	geo := DefaultPlanogramGeometry()
+
+	// Two 310-unit doors side by side with a 60-unit gap: 2*20 + 680 = 720
+	grid, err := NewPlanogramGrid(structure, 720, geo)
+	if err != nil {
+		t.Fatalf("NewPlanogramGrid failed: %v", err)
+	}
+
+	// x = 20 + 310 + 60, y = 20 + 40 + 30 (door label) + 40
+	want := rectQuad(390, 130, 150, 180)
+	for _, position := range []string{"D2-A1", "D2-A01", "d2-a01"} {
+		quad, ok := grid.Cell(position)
+		if !ok {
+			t.Fatalf("Expected %s to be located", position)
+		}
+		if quad != want {
+			t.Errorf("%s: expected %v, got %v", position, want, quad)
+		}
+	}
+}
+
+func TestLayoutPlanogramGeometry(t *testing.T) {
+	render := &models.RenderGeometry{
+		Padding: 10, TitlePadding: 30, HeaderHeight: 20, CellWidth: 200, CellHeight: 240,
+		CellSpacing: 5, RowSpacing: 40, FooterHeight: 25, MaxColumns: 12,
+		SubLayoutArrangement: "vertical", SubLayoutSpacing: 50, SubLayoutLabelHeight: 20,
+	}
+	geo, stored := LayoutPlanogramGeometry(render, false)
+	if !stored || geo.CellWidth != 200 || geo.MaxColumns != 12 || !geo.VerticalSubLayouts {
+		t.Fatalf("Expected the stored geometry, got %+v", geo)
+	}
+
+	// 2 columns: 2*10 + 2*200 + 5 = 425 layout units, rendered at scale 2
+	grid, err := NewPlanogramGrid(&models.MachineStructure{RowOrder: []string{"A", "B"}, ColumnsPerRow: 2}, 850, geo)
+	if err != nil {
+		t.Fatalf("NewPlanogramGrid failed: %v", err)
+	}
+	// x = 10 + 205, y = 10 + 30 + 20 + 305
+	if quad, _ := grid.Cell("B2"); quad != rectQuad(215*2, 365*2, 200*2, 240*2) {
+		t.Errorf("Unexpected B2 cell %v", quad)
+	}
+
+	for _, render := range []*models.RenderGeometry{nil, {Padding: 10}} {
+		geo, stored := LayoutPlanogramGeometry(render, true)
+		want := DefaultPlanogramGeometry()
+		want.VerticalSubLayouts = true
+		if stored || geo != want {
+			t.Errorf("Expected the defaults for %+v, got %+v", render, geo)
+		}
+	}
+}
+
+func TestCalibratedGridCell(t *testing.T) {
+	calibration := &models.GridCalibration{
+		Corners: [4]models.Point{{X: 100, Y: 100}, {X: 500, Y: 100}, {X: 500, Y: 300}, {X: 100, Y: 300}},
+	}
+	structure := &models.MachineStructure{RowOrder: []string{"A", "B"}, ColumnsPerRow: 4}
+
+	grid, err := NewCalibratedGrid(calibration, structure)
+	if err != nil {
+		t.Fatalf("NewCalibratedGrid failed: %v", err)
+	}
+	quad, ok := grid.Cell("B3")
+	if !ok {
+		t.Fatal("Expected B3 to be located")
+	}
+	want := rectQuad(300, 200, 100, 100)
+	if quad != want {
+		t.Errorf("Expected %v, got %v", want, quad)
+	}
+
+	if _, err := NewCalibratedGrid(&models.GridCalibration{}, structure); err == nil {
+		t.Error("Expected an error for a calibration without corners")
+	}
+}
+
+func TestCellNormalizesPosition(t *testing.T) {
+	calibration := &models.GridCalibration{
+		Corners:  [4]models.Point{{X: 0, Y: 0}, {X: 1200, Y: 0}, {X: 1200, Y: 300}, {X: 0, Y: 300}},
+		RowOrder: []string{"A", "B", "AA"},
+		Columns:  12,
+	}
+	grid, err := NewCalibratedGrid(calibration, nil)
+	if err != nil {
+		t.Fatalf("NewCalibratedGrid failed: %v", err)
+	}
+
+	tests := []struct {
+		position string
+		want     Quad
+	}{
+		{"a-01", rectQuad(0, 0, 100, 100)},
+		{"AA12", rectQuad(1100, 200, 100, 100)},
+		{"Row B slot 3", rectQuad(200, 100, 100, 100)},
+	}
+	for _, tt := range tests {
+		quad, ok := grid.Cell(tt.position)
+		if !ok {
+			t.Errorf("Expected %q to be located", tt.position)
+			continue
+		}
+		if quad != tt.want {
+			t.Errorf("%q: expected %v, got %v", tt.position, tt.want, quad)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	base := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	draw.Draw(base, base.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	grid := fixedGrid{"A1": rectQuad(100, 50, 100, 100)}
+	discrepancies := []models.Discrepancy{
+		{Position: "A1", Type: models.DiscrepancyMissing},
+		{Position: "Z9", Type: models.DiscrepancyUnexpected},
+	}
+
+	result, err := Render([]Panel{{Image: base, Grid: grid}}, discrepancies, []string{"verif-1 INCORRECT"}, Options{})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if result.Placed != 1 || len(result.Unplaced) != 1 || result.Unplaced[0] != "Z9" {
+		t.Errorf("Expected A1 placed and Z9 unplaced, got %d placed, unplaced %v", result.Placed, result.Unplaced)
+	}
+
+	// The panel is drawn unscaled at (gap, gap); the cell center is white
+	// blended with 35% of the missing color (220, 38, 38)
+	r, g, b, _ := result.Image.At(gap+150, gap+120).RGBA()
+	if r>>8 != 243 || g>>8 != 179 || b>>8 != 179 {
+		t.Errorf("Expected a red tint in the cell, got %d,%d,%d", r>>8, g>>8, b>>8)
+	}
+	// Outside the cell the panel is untouched
+	r, g, b, _ = result.Image.At(gap+20, gap+180).RGBA()
+	if r>>8 != 255 || g>>8 != 255 || b>>8 != 255 {
+		t.Errorf("Expected white outside the cell, got %d,%d,%d", r>>8, g>>8, b>>8)
+	}
+	if result.Image.Bounds().Dy() <= 200+2*gap {
+		t.Error("Expected room for the legend and footer below the panel")
+	}
+}
+
+type fixedGrid map[string]Quad
+
+func (g fixedGrid) Cell(position string) (Quad, bool) {
+	q, ok := g[position]
+	return q, ok
+}
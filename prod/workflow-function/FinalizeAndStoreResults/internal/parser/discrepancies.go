@@ -0,0 +1,124 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"workflow-function/FinalizeAndStoreResults/internal/models"
+	"workflow-function/shared/templateloader"
+)
+
+var numberedItemRe = regexp.MustCompile(`^\d+[.)]\s`)
+
+// Keywords are checked in this order: "expected in A1, not found" is missing,
+// not incorrect, and "unexpected" contains "expected".
+var discrepancyKeywords = []struct {
+	kind string
+	re   *regexp.Regexp
+}{
+	{models.DiscrepancyUnexpected, regexp.MustCompile(`(?i)\b(unexpected|extra|should be empty|not in (the )?reference)\b`)},
+	{models.DiscrepancyMissing, regexp.MustCompile(`(?i)\b(missing|empty|not found|absent|no product)\b`)},
+	{models.DiscrepancyIncorrect, regexp.MustCompile(`(?i)\b(incorrect|wrong|mismatch(ed)?|misplaced|instead|found in|different)\b`)},
+}
+
+// ParseDiscrepancies extracts position-level discrepancies from a markdown
+// response. It reads list items and table rows that name a position and
+// describe an issue, such as "- Row C 04: missing" or "| C4 | Coke | Pepsi |
+// Incorrect product |". Positions are reported as canonical IDs ("C04"), and
+// the first position on a line is used, so "expected in A1, found in B3" is
+// reported against A01.
+func ParseDiscrepancies(content string) []models.Discrepancy {
+	var discrepancies []models.Discrepancy
+	seen := make(map[string]bool)
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !isListOrTableLine(trimmed) {
+			continue
+		}
+
+		position := findPosition(trimmed)
+		if position == "" || seen[position] {
+			continue
+		}
+		kind := classifyDiscrepancy(trimmed)
+		if kind == "" {
+			continue
+		}
+
+		seen[position] = true
+		discrepancies = append(discrepancies, models.Discrepancy{
+			Position:    position,
+			Type:        kind,
+			Description: strings.Trim(trimmed, "-*|+ \t"),
+		})
+	}
+	return discrepancies
+}
+
+// discrepanciesFromJSON converts the discrepancy objects of the JSON Turn2
+// format, which carry a position and either a type or a free-form issue
+func discrepanciesFromJSON(items []interface{}) []models.Discrepancy {
+	var discrepancies []models.Discrepancy
+	for _, raw := range items {
+		entry, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		var text []string
+		for _, field := range []string{"position", "type", "issue", "description", "expected", "found"} {
+			if value, ok := entry[field]; ok && value != nil {
+				// Types such as INCORRECT_PRODUCT_TYPE are matched as words
+				text = append(text, strings.ReplaceAll(fmt.Sprint(value), "_", " "))
+			}
+		}
+		line := strings.Join(text, " ")
+
+		position := findPosition(line)
+		kind := classifyDiscrepancy(line)
+		if position == "" || kind == "" {
+			continue
+		}
+		discrepancies = append(discrepancies, models.Discrepancy{
+			Position:    position,
+			Type:        kind,
+			Description: line,
+		})
+	}
+	return discrepancies
+}
+
+func isListOrTableLine(line string) bool {
+	if line == "" {
+		return false
+	}
+	switch line[0] {
+	case '-', '*', '+', '|':
+		return true
+	}
+	return numberedItemRe.MatchString(line)
+}
+
+// findPosition returns the canonical ID ("C04", "D2-A01") of the first
+// position on the line. NormalizePosition returns the text unchanged when it
+// names no position, which the list, table and JSON lines parsed here never
+// are on their own.
+func findPosition(line string) string {
+	trimmed := strings.TrimSpace(line)
+	position := templateloader.NormalizePosition(trimmed)
+	if position == trimmed {
+		return ""
+	}
+	return position
+}
+
+func classifyDiscrepancy(line string) string {
+	for _, keyword := range discrepancyKeywords {
+		if keyword.re.MatchString(line) {
+			return keyword.kind
+		}
+	}
+	return ""
+}
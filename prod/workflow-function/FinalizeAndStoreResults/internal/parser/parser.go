@@ -28,6 +28,7 @@ func ParseTurn2ResponseData(data []byte) (*models.Turn2ParsedData, error) {
 		result := models.Turn2ParsedData{
 			VerificationStatus: turn2Response.VerificationOutcome,
 			InitialConfirmation: turn2Response.ComparisonSummary,
+			Discrepancies:      discrepanciesFromJSON(turn2Response.Discrepancies),
 		}
 
 		// Set verification summary based on the outcome
@@ -49,7 +50,9 @@ func ParseTurn2ResponseData(data []byte) (*models.Turn2ParsedData, error) {
 
 	// Fallback to markdown/text parsing
 	content := string(data)
-	result := models.Turn2ParsedData{}
+	result := models.Turn2ParsedData{
+		Discrepancies: ParseDiscrepancies(content),
+	}
 
 	// Extract VERIFICATION SUMMARY section - handle both bullet point and plain text formats
 	summaryRe := regexp.MustCompile(`(?s)VERIFICATION SUMMARY:?\n(.*?)(?:\n\n|\n\*\*|$)`)
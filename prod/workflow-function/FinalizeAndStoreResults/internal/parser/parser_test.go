@@ -2,6 +2,8 @@ package parser
 
 import (
 	"testing"
+
+	"workflow-function/FinalizeAndStoreResults/internal/models"
 )
 
 func TestParseVerificationStatus(t *testing.T) {
@@ -182,3 +184,150 @@ func TestParseActualTurn2Format(t *testing.T) {
 		t.Errorf("Expected VerificationOutcome %q, got %q", expectedOutcome, result.VerificationSummary.VerificationOutcome)
 	}
 }
+
+func TestParseDiscrepancies(t *testing.T) {
+	input := `**VERIFICATION SUMMARY:**
+* **Total Positions Checked:** 42
+* **Discrepant Positions:** 5
+    * Missing Products: 2
+
+discrepancies:
+- Coca Cola: expected in A1, found in B3
+- Pepsi: expected in C04, not found
+- Row F 07: unexpected product present
+
+| Position | Expected | Found | Issue |
+|----------|----------|-------|-------|
+| D2-A1 | Sting | Sting | Correct |
+| D2-B3 | Sting | Aquafina | Incorrect product |
+| E5 | Lavie | (empty) | Missing |
+- Pepsi: expected in C04, not found again`
+
+	expected := []models.Discrepancy{
+		{Position: "A01", Type: models.DiscrepancyIncorrect},
+		{Position: "C04", Type: models.DiscrepancyMissing},
+		{Position: "F07", Type: models.DiscrepancyUnexpected},
+		{Position: "D2-B03", Type: models.DiscrepancyIncorrect},
+		{Position: "E05", Type: models.DiscrepancyMissing},
+	}
+
+	result, err := ParseTurn2ResponseData([]byte(input))
+	if err != nil {
+		t.Fatalf("ParseTurn2ResponseData failed: %v", err)
+	}
+	if len(result.Discrepancies) != len(expected) {
+		t.Fatalf("Expected %d discrepancies, got %d: %+v", len(expected), len(result.Discrepancies), result.Discrepancies)
+	}
+	for i, want := range expected {
+		got := result.Discrepancies[i]
+		if got.Position != want.Position || got.Type != want.Type {
+			t.Errorf("Discrepancy %d: expected %s %s, got %s %s", i, want.Position, want.Type, got.Position, got.Type)
+		}
+	}
+}
+
+func TestParseLocalizedTurn2Format(t *testing.T) {
+	// Localized templates keep the English labels, statuses and issue types
+	// and only translate the free text
+	english := `**DETAILED DISCREPANCY REPORT:**
+| Position | Expected | Found | Issue |
+|----------|----------|-------|-------|
+| A01 | Coca Cola | Empty (Coils Visible) | Missing Product |
+| B03 | Sting | Aquafina | Incorrect Product Type |
+
+**VERIFICATION SUMMARY:**
+* **Total Positions Checked:** 42
+* **Correct Positions:** 40
+* **Discrepant Positions:** 2
+    * Missing Products: 1
+    * Incorrect Product Types: 1
+    * Unexpected Products: 0
+* **Empty Positions in Checking Image:** 1
+* **Overall Accuracy:** 95.2%
+* **Overall Confidence:** 90%
+* **VERIFICATION STATUS:** INCORRECT
+* **Verification Outcome:** Discrepancies Detected`
+	vietnamese := `**DETAILED DISCREPANCY REPORT:**
+| Position | Expected | Found | Issue |
+|----------|----------|-------|-------|
+| A01 | Coca Cola lon đỏ | Empty (Coils Visible) | Missing Product |
+| B03 | Sting chai đỏ | Aquafina chai xanh | Incorrect Product Type |
+
+**VERIFICATION SUMMARY:**
+* **Total Positions Checked:** 42
+* **Correct Positions:** 40
+* **Discrepant Positions:** 2 (bằng số mục ở trên)
+    * Missing Products: 1
+    * Incorrect Product Types: 1
+    * Unexpected Products: 0
+* **Empty Positions in Checking Image:** 1
+* **Overall Accuracy:** 95.2%
+* **Overall Confidence:** 90%
+* **VERIFICATION STATUS:** INCORRECT
+* **Verification Outcome:** Discrepancies Detected`
+
+	want, err := ParseTurn2ResponseData([]byte(english))
+	if err != nil {
+		t.Fatalf("ParseTurn2ResponseData failed: %v", err)
+	}
+	got, err := ParseTurn2ResponseData([]byte(vietnamese))
+	if err != nil {
+		t.Fatalf("ParseTurn2ResponseData failed: %v", err)
+	}
+
+	if got.VerificationStatus != "INCORRECT" || got.VerificationStatus != want.VerificationStatus {
+		t.Errorf("Expected verificationStatus %q, got %q", want.VerificationStatus, got.VerificationStatus)
+	}
+	if got.VerificationSummary.TotalPositionsChecked != 42 || got.VerificationSummary.DiscrepantPositions != 2 ||
+		got.VerificationSummary.DiscrepancyDetails.MissingProducts != want.VerificationSummary.DiscrepancyDetails.MissingProducts {
+		t.Errorf("Expected the summary %+v, got %+v", want.VerificationSummary, got.VerificationSummary)
+	}
+	if len(got.Discrepancies) != 2 || len(got.Discrepancies) != len(want.Discrepancies) {
+		t.Fatalf("Expected discrepancies %+v, got %+v", want.Discrepancies, got.Discrepancies)
+	}
+	for i := range want.Discrepancies {
+		if got.Discrepancies[i].Position != want.Discrepancies[i].Position || got.Discrepancies[i].Type != want.Discrepancies[i].Type {
+			t.Errorf("Discrepancy %d: expected %+v, got %+v", i, want.Discrepancies[i], got.Discrepancies[i])
+		}
+	}
+}
+
+func TestParseDiscrepanciesFromJSON(t *testing.T) {
+	input := `{"verificationOutcome": "INCORRECT", "discrepancies": [
+		{"position": "B2", "issue": "Missing product"},
+		{"position": "C3", "type": "INCORRECT_PRODUCT_TYPE", "expected": "Coke", "found": "Pepsi"},
+		{"issue": "no position"}
+	]}`
+
+	result, err := ParseTurn2ResponseData([]byte(input))
+	if err != nil {
+		t.Fatalf("ParseTurn2ResponseData failed: %v", err)
+	}
+	if len(result.Discrepancies) != 2 {
+		t.Fatalf("Expected 2 discrepancies, got %+v", result.Discrepancies)
+	}
+	if result.Discrepancies[0].Position != "B02" || result.Discrepancies[0].Type != models.DiscrepancyMissing {
+		t.Errorf("Unexpected first discrepancy: %+v", result.Discrepancies[0])
+	}
+	if result.Discrepancies[1].Position != "C03" || result.Discrepancies[1].Type != models.DiscrepancyIncorrect {
+		t.Errorf("Unexpected second discrepancy: %+v", result.Discrepancies[1])
+	}
+}
+
+func TestFindPositionCanonical(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"- a-01: missing", "A01"},
+		{"| D2-A01 | Sting | Aquafina | Incorrect product |", "D2-A01"},
+		{"- AA12: unexpected product", "AA12"},
+		{"- Row B slot 3 is empty", "B03"},
+		{"| Position | Expected | Found | Issue |", ""},
+	}
+	for _, tt := range tests {
+		if got := findPosition(tt.line); got != tt.want {
+			t.Errorf("findPosition(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
@@ -106,6 +106,13 @@ else
     echo -e "${RED}Warning: ../shared/errors not found${NC}"
 fi
 
+if [ -d "../shared/templateloader" ]; then
+    cp -r ../shared/templateloader "$BUILD_CONTEXT/shared/"
+    echo "✓ Copied shared/templateloader"
+else
+    echo -e "${RED}Warning: ../shared/templateloader not found${NC}"
+fi
+
 # Copy and modify go.mod file for Docker build
 echo -e "${YELLOW}Creating modified go.mod for Docker build...${NC}"
 cp go.mod "$BUILD_CONTEXT/go.mod"
@@ -115,6 +122,7 @@ sed -i.bak 's|replace workflow-function/shared/logger => ../shared/logger|replac
 sed -i.bak 's|replace workflow-function/shared/schema => ../shared/schema|replace workflow-function/shared/schema => ./shared/schema|g' "$BUILD_CONTEXT/go.mod"
 sed -i.bak 's|replace workflow-function/shared/s3state => ../shared/s3state|replace workflow-function/shared/s3state => ./shared/s3state|g' "$BUILD_CONTEXT/go.mod"
 sed -i.bak 's|replace workflow-function/shared/errors => ../shared/errors|replace workflow-function/shared/errors => ./shared/errors|g' "$BUILD_CONTEXT/go.mod"
+sed -i.bak 's|replace workflow-function/shared/templateloader => ../shared/templateloader|replace workflow-function/shared/templateloader => ./shared/templateloader|g' "$BUILD_CONTEXT/go.mod"
 
 # Remove backup file
 rm -f "$BUILD_CONTEXT/go.mod.bak"
@@ -38,9 +38,12 @@ type InitRequest struct {
 	ReferenceImageUrl     string              `json:"referenceImageUrl"`
 	CheckingImageUrl      string              `json:"checkingImageUrl"`
 	VendingMachineId      string              `json:"vendingMachineId,omitempty"`
+	TenantId              string              `json:"tenantId,omitempty"`
+	Locale                string              `json:"locale,omitempty"`
 	LayoutId              int                 `json:"layoutId,omitempty"`
 	LayoutPrefix          string              `json:"layoutPrefix,omitempty"`
 	PreviousVerificationId string             `json:"previousVerificationId,omitempty"`
+	VerificationAt        string              `json:"verificationAt,omitempty"`
 	ConversationConfig    *ConversationConfig `json:"conversationConfig,omitempty"`
 	RequestId             string              `json:"requestId,omitempty"`
 	RequestTimestamp      string              `json:"requestTimestamp,omitempty"`
@@ -64,11 +67,22 @@ func Handler(ctx context.Context, event interface{}) (interface{}, error) {
 	// 2) Initialize service with configuration
 	cfg := internal.Config{
 		LayoutTable:        os.Getenv("DYNAMODB_LAYOUT_TABLE"),
+		LayoutMachineIndex: getEnvWithDefault("DYNAMODB_LAYOUT_MACHINE_INDEX", internal.DefaultLayoutMachineIndex),
 		VerificationTable:  os.Getenv("DYNAMODB_VERIFICATION_TABLE"),
 		VerificationPrefix: getEnvWithDefault("VERIFICATION_PREFIX", "verif-"),
 		ReferenceBucket:    os.Getenv("REFERENCE_BUCKET"),
 		CheckingBucket:     os.Getenv("CHECKING_BUCKET"),
 		StateBucket:        os.Getenv("STATE_BUCKET"),
+		DefaultLocale:      os.Getenv("DEFAULT_LOCALE"),
+	}
+	for envVar, settings := range map[string]*map[string]string{
+		"MACHINE_LOCALES": &cfg.MachineLocales,
+		"TENANT_LOCALES":  &cfg.TenantLocales,
+	} {
+		if *settings, err = internal.ParseLocaleSettings(os.Getenv(envVar)); err != nil {
+			log.Printf("Invalid %s: %v", envVar, err)
+			return nil, fmt.Errorf("invalid %s: %w", envVar, err)
+		}
 	}
 	
 	// Create the service
@@ -282,6 +296,7 @@ func Handler(ctx context.Context, event interface{}) (interface{}, error) {
 		logDetails["referenceImageUrl"] = request.VerificationContext.ReferenceImageUrl
 		logDetails["checkingImageUrl"] = request.VerificationContext.CheckingImageUrl
 		logDetails["vendingMachineId"] = request.VerificationContext.VendingMachineId
+		logDetails["locale"] = request.VerificationContext.Locale
 
 		if request.VerificationContext.VerificationType == schema.VerificationTypeLayoutVsChecking {
 			logDetails["layoutId"] = request.VerificationContext.LayoutId
@@ -295,6 +310,7 @@ func Handler(ctx context.Context, event interface{}) (interface{}, error) {
 		logDetails["referenceImageUrl"] = request.ReferenceImageUrl
 		logDetails["checkingImageUrl"] = request.CheckingImageUrl
 		logDetails["vendingMachineId"] = request.VendingMachineId
+		logDetails["locale"] = request.Locale
 
 		if request.VerificationType == schema.VerificationTypeLayoutVsChecking {
 			logDetails["layoutId"] = request.LayoutId
@@ -338,9 +354,12 @@ func Handler(ctx context.Context, event interface{}) (interface{}, error) {
 		ReferenceImageUrl:     request.ReferenceImageUrl,
 		CheckingImageUrl:      request.CheckingImageUrl,
 		VendingMachineId:      request.VendingMachineId,
+		TenantId:              request.TenantId,
+		Locale:                request.Locale,
 		LayoutId:              request.LayoutId,
 		LayoutPrefix:          request.LayoutPrefix,
 		PreviousVerificationId: request.PreviousVerificationId,
+		VerificationAt:        request.VerificationAt,
 		RequestId:             request.RequestId,
 		RequestTimestamp:      request.RequestTimestamp,
 		ConversationConfig:    convConfig,
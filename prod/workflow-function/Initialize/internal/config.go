@@ -5,6 +5,9 @@ type Config struct {
 	// DynamoDB table names
 	LayoutTable       string
 	VerificationTable string
+
+	// GSI on vendingMachineId and effectiveFrom listing each machine's layout versions
+	LayoutMachineIndex string
 	
 	// Prefixes and naming
 	VerificationPrefix string
@@ -16,6 +19,12 @@ type Config struct {
 	
 	// Default TTL for DynamoDB items in days
 	DefaultTTLDays int
+
+	// Locale of the prompt templates by vending machine and by tenant, and
+	// for verifications without either; empty for the default templates
+	MachineLocales map[string]string
+	TenantLocales  map[string]string
+	DefaultLocale  string
 }
 
 // GetDefaultConfig returns a config with sensible defaults
@@ -43,9 +52,12 @@ type ProcessRequest struct {
 	ReferenceImageUrl     string
 	CheckingImageUrl      string
 	VendingMachineId      string
+	TenantId              string
+	Locale                string
 	LayoutId              int
 	LayoutPrefix          string
 	PreviousVerificationId string
+	VerificationAt        string
 	ConversationConfig    ConversationConfig
 	RequestId             string
 	RequestTimestamp      string
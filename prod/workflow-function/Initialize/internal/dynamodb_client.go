@@ -22,9 +22,16 @@ var (
 	ErrMissingPrimaryKey  = errors.New("missing primary key attributes")
 )
 
+// dynamoDBAPI is the part of the DynamoDB client used by DynamoDBClient
+type dynamoDBAPI interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+}
+
 // DynamoDBClient wraps DynamoDB operations with consistent error handling
 type DynamoDBClient struct {
-	client *dynamodb.Client
+	client dynamoDBAPI
 	logger logger.Logger
 	config Config
 }
@@ -299,9 +306,11 @@ func (c *DynamoDBClient) CalculateTTL(daysFromNow int) int64 {
 	return expiry.Unix()
 }
 
-// Client returns the underlying DynamoDB client
+// Client returns the underlying DynamoDB client, nil when the wrapper was
+// built around another implementation
 func (c *DynamoDBClient) Client() *dynamodb.Client {
-	return c.client
+	client, _ := c.client.(*dynamodb.Client)
+	return client
 }
 
 // MarshalMap is a convenience wrapper around the attributevalue.MarshalMap function
@@ -19,6 +19,7 @@ var (
 	ErrMissingRequiredField    = errors.New("missing required field")
 	ErrInvalidVerificationType  = errors.New("invalid verification type")
 	ErrSameReferenceAndChecking = errors.New("reference and checking images cannot be the same")
+	ErrInvalidVerificationAt    = errors.New("invalid verificationAt")
 )
 
 // InitializeService handles the logic for initializing verifications
@@ -78,9 +79,157 @@ func (s *InitializeService) Process(ctx context.Context, request ProcessRequest)
 		return nil, fmt.Errorf("failed to create verification context: %w", err)
 	}
 
+	// Select the locale of the prompt templates from the machine and tenant settings
+	s.resolveLocale(verificationContext)
+
+	// Resolve the layout from the reference image or the machine's layout versions
+	if envelope, err := s.resolveLayout(ctx, verificationContext); err != nil {
+		return envelope, err
+	}
+
+	// Validate the verification context
+	if err := s.validateVerificationContext(verificationContext); err != nil {
+		s.logger.Error("Verification context validation failed", map[string]interface{}{
+			"error": err.Error(),
+			"verificationId": verificationContext.VerificationId,
+		})
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	// Verify resources (S3 images, layout metadata)
+	resourceValidation, err := s.verifyResources(ctx, verificationContext)
+	if err != nil {
+		s.logger.Error("Resource verification failed", map[string]interface{}{
+			"error": err.Error(),
+			"verificationId": verificationContext.VerificationId,
+		})
+
+		code := "RESOURCE_VALIDATION_FAILED"
+		if errors.Is(err, ErrLayoutRetired) {
+			code = "LAYOUT_RETIRED"
+		}
+
+		errorInfo := &schema.ErrorInfo{
+			Code:      code,
+			Message:   err.Error(),
+			Timestamp: schema.FormatISO8601(),
+			Details: map[string]interface{}{
+				"resourceValidation": resourceValidation,
+			},
+		}
+
+		verificationContext.Error = errorInfo
+		verificationContext.Status = schema.StatusInitializationFailed
+
+		basicEnvelope, stateErr := s.createStateStructure(ctx, verificationContext)
+		if stateErr != nil {
+			s.logger.Error("Failed to create state structure after resource validation failure", map[string]interface{}{
+				"error": stateErr.Error(),
+				"verificationId": verificationContext.VerificationId,
+			})
+		} else if basicEnvelope != nil {
+			envelope := NewExtendedEnvelope(basicEnvelope)
+			envelope.VerificationContext = verificationContext
+			envelope.Envelope.SetStatus(schema.StatusInitializationFailed)
+			return envelope, err 
+		}
+		return nil, err
+	}
+
+	verificationContext.ResourceValidation = resourceValidation
+	verificationContext.Status = schema.StatusVerificationInitialized
+
+	basicEnvelope, err := s.createStateStructure(ctx, verificationContext)
+	if err != nil {
+		s.logger.Error("Failed to create state structure", map[string]interface{}{
+			"error":          err.Error(),
+			"verificationId": verificationContext.VerificationId,
+		})
+		return nil, fmt.Errorf("failed to create state structure: %w", err)
+	}
+	
+	var s3InitRef *s3state.Reference
+	if basicEnvelope.References != nil {
+		s3InitRef = basicEnvelope.References["processing_initialization"]
+	}
+	if s3InitRef == nil {
+		s.logger.Warn("No 'processing_initialization' S3 reference found in envelope to store in DynamoDB.", map[string]interface{}{
+			"verificationId": verificationContext.VerificationId,
+		})
+	}
+
+	err = s.verificationRepo.StoreMinimalRecord(ctx, verificationContext, s3InitRef)
+	if err != nil {
+		s.logger.Error("Failed to store verification record", map[string]interface{}{
+			"error":          err.Error(),
+			"verificationId": verificationContext.VerificationId,
+		})
+		return nil, fmt.Errorf("failed to store verification record: %w", err)
+	}
+
+	basicEnvelope.AddSummary("verificationType", verificationContext.VerificationType)
+	basicEnvelope.AddSummary("resourcesValidated", []string{"referenceImage", "checkingImage", "layoutMetadata"})
+	basicEnvelope.AddSummary("contextEstablished", true)
+	basicEnvelope.AddSummary("stateStructureCreated", true)
+
+	envelope := NewExtendedEnvelope(basicEnvelope)
+	envelope.VerificationContext = verificationContext
+
+	s.logger.Info("Verification initialized successfully", map[string]interface{}{
+		"verificationId":   verificationContext.VerificationId,
+		"verificationType": verificationContext.VerificationType,
+		"status":           verificationContext.Status,
+		"s3StateBucket":    s.config.StateBucket,
+	})
+
+	return envelope, nil
+}
+
+// resolveLayout fills in the layoutId and layoutPrefix a LAYOUT_VS_CHECKING
+// verification does not provide. A referenceImageUrl names the rendered layout,
+// so it is looked up by the reference image; without one, the version of the
+// vending machine's layout in force at verificationAt is used. On failure the
+// initialization state is saved with the error and the failed envelope is
+// returned.
+func (s *InitializeService) resolveLayout(ctx context.Context, verificationContext *schema.VerificationContext) (*ExtendedEnvelope, error) {
+	if verificationContext.VerificationType != schema.VerificationTypeLayoutVsChecking ||
+		(verificationContext.LayoutId != 0 && verificationContext.LayoutPrefix != "") {
+		return nil, nil
+	}
+
+	// Resolve the layout in force at verificationAt from the machine's layout versions
+	if verificationContext.ReferenceImageUrl == "" && verificationContext.VendingMachineId != "" {
+		if err := s.resolveLayoutInForce(ctx, verificationContext); err != nil {
+			verificationContext.Error = &schema.ErrorInfo{
+				Code:      "LAYOUT_RETIRED",
+				Message:   fmt.Sprintf("cannot resolve layout in force for vending machine %s at %s: %v", verificationContext.VendingMachineId, verificationContext.VerificationAt, err),
+				Timestamp: schema.FormatISO8601(),
+				Details: map[string]interface{}{
+					"vendingMachineId": verificationContext.VendingMachineId,
+					"verificationAt":   verificationContext.VerificationAt,
+					"lookupError":      err.Error(),
+				},
+			}
+			verificationContext.Status = schema.StatusInitializationFailed
+
+			basicEnvelope, stateErr := s.createStateStructure(ctx, verificationContext)
+			if stateErr != nil {
+				s.logger.Error("Failed to create state structure after layout resolution failure", map[string]interface{}{
+					"error":          stateErr.Error(),
+					"verificationId": verificationContext.VerificationId,
+				})
+				return nil, fmt.Errorf("layout resolution failed and unable to save state: %w", err)
+			}
+
+			envelope := NewExtendedEnvelope(basicEnvelope)
+			envelope.VerificationContext = verificationContext
+			envelope.Envelope.SetStatus(schema.StatusInitializationFailed)
+			return envelope, fmt.Errorf("layout resolution failed: %w", err)
+		}
+	}
+
 	// Attempt to Lookup LayoutId and LayoutPrefix if missing for LAYOUT_VS_CHECKING
-	if verificationContext.VerificationType == schema.VerificationTypeLayoutVsChecking &&
-		(verificationContext.LayoutId == 0 || verificationContext.LayoutPrefix == "") &&
+	if (verificationContext.LayoutId == 0 || verificationContext.LayoutPrefix == "") &&
 		verificationContext.ReferenceImageUrl != "" {
 
 		s.logger.Info("LayoutId/LayoutPrefix not fully provided for LAYOUT_VS_CHECKING with referenceImageUrl. Attempting lookup.", map[string]interface{}{
@@ -221,97 +370,71 @@ func (s *InitializeService) Process(ctx context.Context, request ProcessRequest)
 		}
 	}
 
-	// Validate the verification context
-	if err := s.validateVerificationContext(verificationContext); err != nil {
-		s.logger.Error("Verification context validation failed", map[string]interface{}{
-			"error": err.Error(),
-			"verificationId": verificationContext.VerificationId,
-		})
-		return nil, fmt.Errorf("validation failed: %w", err)
-	}
-
-	// Verify resources (S3 images, layout metadata)
-	resourceValidation, err := s.verifyResources(ctx, verificationContext)
-	if err != nil {
-		s.logger.Error("Resource verification failed", map[string]interface{}{
-			"error": err.Error(),
-			"verificationId": verificationContext.VerificationId,
-		})
-
-		errorInfo := &schema.ErrorInfo{
-			Code:      "RESOURCE_VALIDATION_FAILED",
-			Message:   err.Error(),
-			Timestamp: schema.FormatISO8601(),
-			Details: map[string]interface{}{
-				"resourceValidation": resourceValidation,
-			},
-		}
-
-		verificationContext.Error = errorInfo
-		verificationContext.Status = schema.StatusInitializationFailed
-
-		basicEnvelope, stateErr := s.createStateStructure(ctx, verificationContext)
-		if stateErr != nil {
-			s.logger.Error("Failed to create state structure after resource validation failure", map[string]interface{}{
-				"error": stateErr.Error(),
-				"verificationId": verificationContext.VerificationId,
-			})
-		} else if basicEnvelope != nil {
-			envelope := NewExtendedEnvelope(basicEnvelope)
-			envelope.VerificationContext = verificationContext
-			envelope.Envelope.SetStatus(schema.StatusInitializationFailed)
-			return envelope, err 
-		}
-		return nil, err
-	}
+	return nil, nil
+}
 
-	verificationContext.ResourceValidation = resourceValidation
-	verificationContext.Status = schema.StatusVerificationInitialized
+// resolveLayoutInForce fills the layoutId, layoutPrefix and referenceImageUrl
+// the verification is missing from the version of the vending machine's
+// layout in force at verificationAt. Without a version in force, as for
+// layouts stored before layout versions, when the VendingMachineIndex query
+// fails, or when the version in force is not the layoutId or layoutPrefix
+// the caller provided, the verification is left unchanged. A retired layout
+// in force is returned as ErrLayoutRetired.
+func (s *InitializeService) resolveLayoutInForce(ctx context.Context, verificationContext *schema.VerificationContext) error {
+	s.logger.Info("LayoutId/LayoutPrefix not fully provided for LAYOUT_VS_CHECKING with vendingMachineId. Resolving layout in force.", map[string]interface{}{
+		"verificationId":   verificationContext.VerificationId,
+		"vendingMachineId": verificationContext.VendingMachineId,
+		"verificationAt":   verificationContext.VerificationAt,
+	})
 
-	basicEnvelope, err := s.createStateStructure(ctx, verificationContext)
+	layout, err := s.layoutRepo.GetLayoutInForce(ctx, verificationContext.VendingMachineId, verificationContext.VerificationAt)
 	if err != nil {
-		s.logger.Error("Failed to create state structure", map[string]interface{}{
-			"error":          err.Error(),
-			"verificationId": verificationContext.VerificationId,
+		if errors.Is(err, ErrLayoutRetired) {
+			return err
+		}
+		s.logger.Warn("Layout in force not resolved from vendingMachineId", map[string]interface{}{
+			"verificationId":   verificationContext.VerificationId,
+			"vendingMachineId": verificationContext.VendingMachineId,
+			"verificationAt":   verificationContext.VerificationAt,
+			"error":            err.Error(),
 		})
-		return nil, fmt.Errorf("failed to create state structure: %w", err)
-	}
-	
-	var s3InitRef *s3state.Reference
-	if basicEnvelope.References != nil {
-		s3InitRef = basicEnvelope.References["processing_initialization"]
-	}
-	if s3InitRef == nil {
-		s.logger.Warn("No 'processing_initialization' S3 reference found in envelope to store in DynamoDB.", map[string]interface{}{
-			"verificationId": verificationContext.VerificationId,
+		return nil
+	}
+
+	if (verificationContext.LayoutId != 0 && verificationContext.LayoutId != layout.LayoutId) ||
+		(verificationContext.LayoutPrefix != "" && verificationContext.LayoutPrefix != layout.LayoutPrefix) {
+		s.logger.Warn("Layout in force does not match the provided layout, keeping the provided layout", map[string]interface{}{
+			"verificationId":       verificationContext.VerificationId,
+			"vendingMachineId":     verificationContext.VendingMachineId,
+			"providedLayoutId":     verificationContext.LayoutId,
+			"providedLayoutPrefix": verificationContext.LayoutPrefix,
+			"layoutId":             layout.LayoutId,
+			"layoutPrefix":         layout.LayoutPrefix,
 		})
+		return nil
 	}
 
-	err = s.verificationRepo.StoreMinimalRecord(ctx, verificationContext, s3InitRef)
-	if err != nil {
-		s.logger.Error("Failed to store verification record", map[string]interface{}{
-			"error":          err.Error(),
-			"verificationId": verificationContext.VerificationId,
-		})
-		return nil, fmt.Errorf("failed to store verification record: %w", err)
+	// Only fill what the caller did not provide
+	if verificationContext.LayoutId == 0 {
+		verificationContext.LayoutId = layout.LayoutId
+	}
+	if verificationContext.LayoutPrefix == "" {
+		verificationContext.LayoutPrefix = layout.LayoutPrefix
+	}
+	if verificationContext.ReferenceImageUrl == "" {
+		verificationContext.ReferenceImageUrl = layout.ReferenceImageUrl
 	}
 
-	basicEnvelope.AddSummary("verificationType", verificationContext.VerificationType)
-	basicEnvelope.AddSummary("resourcesValidated", []string{"referenceImage", "checkingImage", "layoutMetadata"})
-	basicEnvelope.AddSummary("contextEstablished", true)
-	basicEnvelope.AddSummary("stateStructureCreated", true)
-
-	envelope := NewExtendedEnvelope(basicEnvelope)
-	envelope.VerificationContext = verificationContext
-
-	s.logger.Info("Verification initialized successfully", map[string]interface{}{
-		"verificationId":   verificationContext.VerificationId,
-		"verificationType": verificationContext.VerificationType,
-		"status":           verificationContext.Status,
-		"s3StateBucket":    s.config.StateBucket,
+	s.logger.Info("Layout in force resolved from vendingMachineId", map[string]interface{}{
+		"verificationId":    verificationContext.VerificationId,
+		"layoutId":          layout.LayoutId,
+		"layoutPrefix":      layout.LayoutPrefix,
+		"version":           layout.Version,
+		"effectiveFrom":     layout.EffectiveFrom,
+		"effectiveTo":       layout.EffectiveTo,
+		"referenceImageUrl": verificationContext.ReferenceImageUrl,
 	})
-
-	return envelope, nil
+return nil
 }
 
 // createStateStructure creates the S3 state structure and saves the initialization context
@@ -450,6 +573,7 @@ func (s *InitializeService) verifyResources(ctx context.Context, verificationCon
 		}
 
 		exists, err := s.layoutRepo.VerifyLayoutExists(ctx, verificationContext.LayoutId, verificationContext.LayoutPrefix)
+		resourceValidation.LayoutExists = exists
 		if err != nil {
 			return resourceValidation, fmt.Errorf("error checking layout: %w", err)
 		}
@@ -514,12 +638,24 @@ func (s *InitializeService) createVerificationContext(request ProcessRequest) (*
 	now := time.Now().UTC()
 	isoTimestamp := now.Format(time.RFC3339)
 
+	// verificationAt may be backdated, e.g. to verify against the layout in force at the time
+	verificationAt := isoTimestamp
+	if request.VerificationAt != "" {
+		at, err := time.Parse(time.RFC3339, request.VerificationAt)
+		if err != nil {
+			return nil, fmt.Errorf("%w: must be RFC3339, got %q", ErrInvalidVerificationAt, request.VerificationAt)
+		}
+		verificationAt = at.UTC().Format(time.RFC3339)
+	}
+
 	verificationContext = &schema.VerificationContext{
 		VerificationId:      verificationId,
-		VerificationAt:      isoTimestamp,
+		VerificationAt:      verificationAt,
 		Status:              schema.StatusVerificationInitialized,
 		VerificationType:    request.VerificationType,
 		VendingMachineId:    request.VendingMachineId,
+		TenantId:            request.TenantId,
+		Locale:              request.Locale,
 		ReferenceImageUrl:   request.ReferenceImageUrl,
 		CheckingImageUrl:    request.CheckingImageUrl,
 	}
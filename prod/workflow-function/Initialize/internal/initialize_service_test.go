@@ -0,0 +1,212 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"workflow-function/shared/logger"
+	"workflow-function/shared/schema"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// fakeLayoutTable answers layout queries per index; an index mapped to an
+// error fails, for instance a GSI that has not been created
+type fakeLayoutTable struct {
+	items   map[string][]schema.LayoutMetadata
+	errs    map[string]error
+	queried []string
+}
+
+func (f *fakeLayoutTable) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{}, nil
+}
+
+func (f *fakeLayoutTable) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeLayoutTable) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	index := aws.ToString(params.IndexName)
+	f.queried = append(f.queried, index)
+	if err := f.errs[index]; err != nil {
+		return nil, err
+	}
+	out := &dynamodb.QueryOutput{}
+	for _, layout := range f.items[index] {
+		item, err := attributevalue.MarshalMap(layout)
+		if err != nil {
+			return nil, err
+		}
+		out.Items = append(out.Items, item)
+	}
+	return out, nil
+}
+
+func newTestInitializeService(table *fakeLayoutTable) *InitializeService {
+	cfg := Config{LayoutTable: "LayoutMetadata"}
+	log := logger.New("verification", "InitializeFunction")
+	dbClient := &DynamoDBClient{client: table, logger: log, config: cfg}
+	return &InitializeService{
+		config:     cfg,
+		logger:     log,
+		layoutRepo: NewLayoutRepository(dbClient, cfg, log),
+	}
+}
+
+var (
+	referenceLayout = schema.LayoutMetadata{
+		LayoutId:          23591,
+		LayoutPrefix:      "kvg2",
+		ReferenceImageUrl: "s3://reference-bucket/processed/kvg2/image.png",
+	}
+	layoutInForce = schema.LayoutMetadata{
+		LayoutId:          30001,
+		LayoutPrefix:      "v2",
+		EffectiveFrom:     "2025-01-01T00:00:00.000Z",
+		ReferenceImageUrl: "s3://reference-bucket/processed/v2/image.png",
+	}
+)
+
+func newLayoutVerificationContext(referenceImageUrl string) *schema.VerificationContext {
+	return &schema.VerificationContext{
+		VerificationId:    "verif-20251018093000-a1b2",
+		VerificationAt:    "2025-10-18T09:30:00Z",
+		VerificationType:  schema.VerificationTypeLayoutVsChecking,
+		VendingMachineId:  "VM-3245",
+		ReferenceImageUrl: referenceImageUrl,
+	}
+}
+
+func TestResolveLayoutPrefersReferenceImage(t *testing.T) {
+	// The version in force renders a different image than the one requested
+	table := &fakeLayoutTable{items: map[string][]schema.LayoutMetadata{
+		DefaultLayoutMachineIndex:    {layoutInForce},
+		DefaultGsiNameReferenceImage: {referenceLayout},
+	}}
+	service := newTestInitializeService(table)
+	verificationContext := newLayoutVerificationContext(referenceLayout.ReferenceImageUrl)
+
+	envelope, err := service.resolveLayout(context.Background(), verificationContext)
+	if err != nil || envelope != nil {
+		t.Fatalf("Expected the layout to resolve, got %v", err)
+	}
+	if verificationContext.LayoutId != 23591 || verificationContext.LayoutPrefix != "kvg2" {
+		t.Errorf("Expected the layout of the reference image, got %d/%s", verificationContext.LayoutId, verificationContext.LayoutPrefix)
+	}
+	if verificationContext.ReferenceImageUrl != referenceLayout.ReferenceImageUrl {
+		t.Errorf("Expected the reference image to be kept, got %s", verificationContext.ReferenceImageUrl)
+	}
+	for _, index := range table.queried {
+		if index == DefaultLayoutMachineIndex {
+			t.Errorf("Expected no layout in force lookup with a referenceImageUrl, got %v", table.queried)
+		}
+	}
+}
+
+func TestResolveLayoutInForce(t *testing.T) {
+	tests := []struct {
+		name    string
+		items   map[string][]schema.LayoutMetadata
+		errs    map[string]error
+		wantID  int
+		wantURL string
+	}{
+		{
+			name:    "version in force",
+			items:   map[string][]schema.LayoutMetadata{DefaultLayoutMachineIndex: {layoutInForce}},
+			wantID:  30001,
+			wantURL: layoutInForce.ReferenceImageUrl,
+		},
+		{
+			// Layouts stored before versioning have no effectiveFrom, so the
+			// machine index has no version in force
+			name: "no version in force",
+		},
+		{
+			name: "machine index not created",
+			errs: map[string]error{
+				DefaultLayoutMachineIndex: errors.New("ValidationException: The table does not have the specified index: VendingMachineIndex"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := &fakeLayoutTable{items: tt.items, errs: tt.errs}
+			service := newTestInitializeService(table)
+			verificationContext := newLayoutVerificationContext("")
+
+			envelope, err := service.resolveLayout(context.Background(), verificationContext)
+			if err != nil || envelope != nil {
+				t.Fatalf("Expected no initialization failure, got %v", err)
+			}
+			if verificationContext.LayoutId != tt.wantID || verificationContext.ReferenceImageUrl != tt.wantURL {
+				t.Errorf("Expected layout %d with %q, got %d/%s with %q", tt.wantID, tt.wantURL,
+					verificationContext.LayoutId, verificationContext.LayoutPrefix, verificationContext.ReferenceImageUrl)
+			}
+			if len(table.queried) == 0 || table.queried[0] != DefaultLayoutMachineIndex {
+				t.Errorf("Expected the layout in force to be queried, got %v", table.queried)
+			}
+			if verificationContext.Error != nil || verificationContext.Status == schema.StatusInitializationFailed {
+				t.Errorf("Expected no initialization error, got %+v", verificationContext.Error)
+			}
+		})
+	}
+}
+
+func TestResolveLayoutInForceKeepsProvidedLayoutId(t *testing.T) {
+	sameLayout := layoutInForce
+	sameLayout.LayoutId, sameLayout.LayoutPrefix = 23591, "kvg2"
+
+	tests := []struct {
+		name       string
+		inForce    schema.LayoutMetadata
+		wantPrefix string
+	}{
+		{name: "version in force is the provided layout", inForce: sameLayout, wantPrefix: "kvg2"},
+		{name: "version in force is another layout", inForce: layoutInForce, wantPrefix: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := &fakeLayoutTable{items: map[string][]schema.LayoutMetadata{
+				DefaultLayoutMachineIndex: {tt.inForce},
+			}}
+			service := newTestInitializeService(table)
+			verificationContext := newLayoutVerificationContext("")
+			verificationContext.LayoutId = 23591
+
+			if _, err := service.resolveLayout(context.Background(), verificationContext); err != nil {
+				t.Fatal(err)
+			}
+			if verificationContext.LayoutId != 23591 {
+				t.Errorf("Expected the provided layoutId to be kept, got %d", verificationContext.LayoutId)
+			}
+			if verificationContext.LayoutPrefix != tt.wantPrefix {
+				t.Errorf("Expected layoutPrefix %q, got %q", tt.wantPrefix, verificationContext.LayoutPrefix)
+			}
+		})
+	}
+}
+
+func TestResolveLayoutKeepsProvidedLayout(t *testing.T) {
+	table := &fakeLayoutTable{}
+	service := newTestInitializeService(table)
+	verificationContext := &schema.VerificationContext{
+		VerificationType: schema.VerificationTypeLayoutVsChecking,
+		VendingMachineId: "VM-3245",
+		LayoutId:         23591,
+		LayoutPrefix:     "kvg2",
+	}
+
+	if _, err := service.resolveLayout(context.Background(), verificationContext); err != nil {
+		t.Fatal(err)
+	}
+	if len(table.queried) != 0 {
+		t.Errorf("Expected no layout queries, got %v", table.queried)
+	}
+}
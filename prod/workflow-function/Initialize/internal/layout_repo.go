@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -18,12 +19,18 @@ var (
 	ErrLayoutNotFound            = errors.New("layout not found")
 	ErrGSIQueryFailed            = errors.New("GSI query failed")
 	ErrLayoutGsiNameNotDefined = errors.New("layout GSI name for reference image lookup not defined in config")
+	ErrNoLayoutInForce           = errors.New("no layout in force for vending machine")
+	ErrLayoutRetired             = errors.New("layout is retired")
 )
 
 const (
 	// DefaultGsiNameReferenceImage is an example GSI name.
 	// This should ideally be configurable if it varies.
 	DefaultGsiNameReferenceImage = "ReferenceImageIndex-gsi"
+
+	// DefaultLayoutMachineIndex is the GSI (partition key vendingMachineId,
+	// sort key effectiveFrom, projection ALL) listing each machine's layout versions
+	DefaultLayoutMachineIndex = "VendingMachineIndex"
 )
 
 // LayoutRepository handles operations on layout metadata
@@ -69,7 +76,7 @@ func (r *LayoutRepository) VerifyLayoutExists(ctx context.Context, layoutId int,
 	}
 
 	// Execute the GetItem operation
-	_, err = r.dbClient.GetItem(ctx, r.config.LayoutTable, key)
+	result, err := r.dbClient.GetItem(ctx, r.config.LayoutTable, key)
 	if err != nil {
 		if errors.Is(err, ErrItemNotFound) {
 			r.logger.Warn("Layout not found", map[string]interface{}{
@@ -87,6 +94,23 @@ func (r *LayoutRepository) VerifyLayoutExists(ctx context.Context, layoutId int,
 		return false, fmt.Errorf("failed to verify layout exists: %w", err)
 	}
 
+	// Retired layouts exist but must not be used for new verifications
+	var status struct {
+		LayoutStatus  string `dynamodbav:"layoutStatus"`
+		RetiredReason string `dynamodbav:"retiredReason"`
+	}
+	if err := attributevalue.UnmarshalMap(result.Item, &status); err != nil {
+		return false, fmt.Errorf("failed to unmarshal layout status: %w", err)
+	}
+	if status.LayoutStatus == schema.LayoutStatusRetired {
+		r.logger.Warn("Layout is retired", map[string]interface{}{
+			"layoutId":      layoutId,
+			"layoutPrefix":  layoutPrefix,
+			"retiredReason": status.RetiredReason,
+		})
+		return true, fmt.Errorf("%w: layout with ID %d and prefix '%s' (%s)", ErrLayoutRetired, layoutId, layoutPrefix, status.RetiredReason)
+	}
+
 	// Layout exists
 	r.logger.Info("Layout exists", map[string]interface{}{
 		"layoutId":     layoutId,
@@ -226,4 +250,107 @@ func (r *LayoutRepository) GetLayoutByReferenceImage(ctx context.Context, refere
 	})
 
 	return &metadata, nil
-}
\ No newline at end of file
+}
+
+// effectiveTimeFormat is the format upload-render-json stores effectiveFrom
+// and effectiveTo in
+const effectiveTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// GetLayoutInForce retrieves the version of the vending machine's layout that
+// was in force at the given RFC3339 time: the version with the latest
+// effectiveFrom not after it, unless that version ended (effectiveTo) before it.
+func (r *LayoutRepository) GetLayoutInForce(ctx context.Context, vendingMachineId, at string) (*schema.LayoutMetadata, error) {
+	if r.config.LayoutTable == "" {
+		return nil, ErrLayoutTableNotDefined
+	}
+	indexName := r.config.LayoutMachineIndex
+	if indexName == "" {
+		indexName = DefaultLayoutMachineIndex
+	}
+
+	// Effective dates are stored as UTC RFC3339 with fixed-width milliseconds
+	// so they compare as strings
+	atTime, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time %q: %w", at, err)
+	}
+	at = atTime.UTC().Format(effectiveTimeFormat)
+
+	r.logger.Debug("Getting layout in force", map[string]interface{}{
+		"vendingMachineId": vendingMachineId,
+		"at":               at,
+		"table":            r.config.LayoutTable,
+		"gsiName":          indexName,
+	})
+
+	exprValues, err := attributevalue.MarshalMap(map[string]string{
+		":vm": vendingMachineId,
+		":at": at,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal expression values: %w", err)
+	}
+
+	result, err := r.dbClient.Query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(r.config.LayoutTable),
+		IndexName:                 aws.String(indexName),
+		KeyConditionExpression:    aws.String("vendingMachineId = :vm AND effectiveFrom <= :at"),
+		ExpressionAttributeValues: exprValues,
+		ScanIndexForward:          aws.Bool(false),
+		Limit:                     aws.Int32(1),
+	})
+	if err != nil {
+		r.logger.Error("Failed to query layout versions", map[string]interface{}{
+			"error":            err.Error(),
+			"vendingMachineId": vendingMachineId,
+			"gsiName":          indexName,
+		})
+		return nil, fmt.Errorf("%w: %v", ErrGSIQueryFailed, err)
+	}
+
+	if len(result.Items) == 0 {
+		r.logger.Warn("No layout version took effect before the requested time", map[string]interface{}{
+			"vendingMachineId": vendingMachineId,
+			"at":               at,
+		})
+		return nil, ErrNoLayoutInForce
+	}
+
+	var metadata schema.LayoutMetadata
+	if err := attributevalue.UnmarshalMap(result.Items[0], &metadata); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal layout version: %w", err)
+	}
+
+	if metadata.EffectiveTo != "" && metadata.EffectiveTo <= at {
+		r.logger.Warn("Latest layout version ended before the requested time", map[string]interface{}{
+			"vendingMachineId": vendingMachineId,
+			"at":               at,
+			"layoutId":         metadata.LayoutId,
+			"layoutPrefix":     metadata.LayoutPrefix,
+			"effectiveTo":      metadata.EffectiveTo,
+		})
+		return nil, ErrNoLayoutInForce
+	}
+
+	if metadata.LayoutStatus == schema.LayoutStatusRetired {
+		r.logger.Warn("Layout version in force is retired", map[string]interface{}{
+			"vendingMachineId": vendingMachineId,
+			"at":               at,
+			"layoutId":         metadata.LayoutId,
+			"layoutPrefix":     metadata.LayoutPrefix,
+			"retiredReason":    metadata.RetiredReason,
+		})
+		return nil, fmt.Errorf("%w: version %d of %s (%s)", ErrLayoutRetired, metadata.Version, vendingMachineId, metadata.RetiredReason)
+	}
+
+	r.logger.Info("Layout in force retrieved", map[string]interface{}{
+		"vendingMachineId": vendingMachineId,
+		"at":               at,
+		"layoutId":         metadata.LayoutId,
+		"layoutPrefix":     metadata.LayoutPrefix,
+		"version":          metadata.Version,
+		"effectiveFrom":    metadata.EffectiveFrom,
+	})
+
+	return &metadata, nil
+}
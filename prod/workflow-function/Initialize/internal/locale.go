@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"workflow-function/shared/schema"
+)
+
+// localePattern matches normalized locales such as "vi" or "vi-vn", the
+// directory names of localized prompt templates
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// normalizeLocale converts a locale such as "vi_VN" to the template directory
+// form "vi-vn"; ok is false for invalid locales
+func normalizeLocale(locale string) (string, bool) {
+	locale = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(locale)), "_", "-")
+	return locale, localePattern.MatchString(locale)
+}
+
+// ParseLocaleSettings parses locale settings such as TENANT_LOCALES, given
+// as key=locale pairs separated by commas, e.g. "acme=vi,globex=en"
+func ParseLocaleSettings(value string) (map[string]string, error) {
+	settings := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, locale, found := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid locale setting %q: expected key=locale", pair)
+		}
+		normalized, ok := normalizeLocale(locale)
+		if !ok {
+			return nil, fmt.Errorf("invalid locale %q for %s", locale, key)
+		}
+		settings[key] = normalized
+	}
+	return settings, nil
+}
+
+// resolveLocale sets the locale of the verification's prompt templates: the
+// locale of the request, else the locale of the vending machine, else of the
+// tenant, else the default locale. The prompt functions fall back to the
+// default templates when the locale has none.
+func (s *InitializeService) resolveLocale(verificationContext *schema.VerificationContext) {
+	source := "request"
+	locale := verificationContext.Locale
+	if locale == "" {
+		source, locale = "machine", s.config.MachineLocales[verificationContext.VendingMachineId]
+	}
+	if locale == "" {
+		source, locale = "tenant", s.config.TenantLocales[verificationContext.TenantId]
+	}
+	if locale == "" {
+		source, locale = "default", s.config.DefaultLocale
+	}
+
+	normalized, ok := normalizeLocale(locale)
+	if locale != "" && !ok {
+		s.logger.Warn("Ignoring invalid locale, using the default templates", map[string]interface{}{
+			"verificationId": verificationContext.VerificationId,
+			"locale":         locale,
+			"source":         source,
+		})
+		normalized = ""
+	}
+	verificationContext.Locale = normalized
+	if normalized != "" {
+		s.logger.Info("Resolved verification locale", map[string]interface{}{
+			"verificationId": verificationContext.VerificationId,
+			"locale":         normalized,
+			"source":         source,
+			"tenantId":       verificationContext.TenantId,
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"testing"
+
+	"workflow-function/shared/logger"
+	"workflow-function/shared/schema"
+)
+
+func TestParseLocaleSettings(t *testing.T) {
+	settings, err := ParseLocaleSettings(" acme=vi_VN, globex=en ,")
+	if err != nil {
+		t.Fatalf("ParseLocaleSettings failed: %v", err)
+	}
+	if len(settings) != 2 || settings["acme"] != "vi-vn" || settings["globex"] != "en" {
+		t.Errorf("Expected normalized locales per key, got %v", settings)
+	}
+
+	for _, value := range []string{"acme", "=vi", "acme=vietnamese!"} {
+		if _, err := ParseLocaleSettings(value); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func TestResolveLocale(t *testing.T) {
+	s := &InitializeService{
+		config: Config{
+			MachineLocales: map[string]string{"VM-1": "vi"},
+			TenantLocales:  map[string]string{"acme": "th"},
+			DefaultLocale:  "en",
+		},
+		logger: logger.New("verification", "InitializeFunction"),
+	}
+
+	tests := []struct {
+		name    string
+		context schema.VerificationContext
+		want    string
+	}{
+		{"request", schema.VerificationContext{Locale: "vi_VN", VendingMachineId: "VM-1", TenantId: "acme"}, "vi-vn"},
+		{"machine", schema.VerificationContext{VendingMachineId: "VM-1", TenantId: "acme"}, "vi"},
+		{"tenant", schema.VerificationContext{VendingMachineId: "VM-2", TenantId: "acme"}, "th"},
+		{"default", schema.VerificationContext{VendingMachineId: "VM-2"}, "en"},
+		{"invalid", schema.VerificationContext{Locale: "not a locale"}, ""},
+	}
+	for _, tt := range tests {
+		vCtx := tt.context
+		s.resolveLocale(&vCtx)
+		if vCtx.Locale != tt.want {
+			t.Errorf("%s: expected locale %q, got %q", tt.name, tt.want, vCtx.Locale)
+		}
+	}
+}
@@ -91,6 +91,10 @@ func (r *VerificationRepository) StoreMinimalRecord(
 		// Basic metadata
 		VerificationType  string `json:"verificationType,omitempty" dynamodbav:"verificationType,omitempty"`
 		VendingMachineId  string `json:"vendingMachineId,omitempty" dynamodbav:"vendingMachineId,omitempty"`
+		TenantId          string `json:"tenantId,omitempty" dynamodbav:"tenantId,omitempty"`
+		
+		// Locale of the prompt templates, empty for the default templates
+		Locale            string `json:"locale,omitempty" dynamodbav:"locale,omitempty"`
 		
 		// Image URLs for verification
 		ReferenceImageUrl string `json:"referenceImageUrl,omitempty" dynamodbav:"referenceImageUrl,omitempty"`
@@ -116,6 +120,8 @@ func (r *VerificationRepository) StoreMinimalRecord(
 		Status:              verification.Status,
 		VerificationType:    verification.VerificationType,
 		VendingMachineId:    verification.VendingMachineId,
+		TenantId:            verification.TenantId,
+		Locale:              verification.Locale,
 		ReferenceImageUrl:   verification.ReferenceImageUrl,
 		CheckingImageUrl:    verification.CheckingImageUrl,
 		LayoutId:            verification.LayoutId,
@@ -152,6 +158,7 @@ func (r *VerificationRepository) StoreMinimalRecord(
 		"checkingImageUrl":   record.CheckingImageUrl,
 		"layoutId":           record.LayoutId,
 		"layoutPrefix":       record.LayoutPrefix,
+		"locale":             record.Locale,
 		"s3StateBucket":      record.S3StateBucket,
 		"s3StateKey":         record.S3StateKey,
 	})
@@ -235,6 +242,8 @@ func (r *VerificationRepository) StoreVerificationRecord(ctx context.Context, ve
 		Status            string `json:"status,omitempty" dynamodbav:"status,omitempty"`
 		VerificationType  string `json:"verificationType,omitempty" dynamodbav:"verificationType,omitempty"`
 		VendingMachineId  string `json:"vendingMachineId,omitempty" dynamodbav:"vendingMachineId,omitempty"`
+		TenantId          string `json:"tenantId,omitempty" dynamodbav:"tenantId,omitempty"`
+		Locale            string `json:"locale,omitempty" dynamodbav:"locale,omitempty"`
 		ReferenceImageUrl string `json:"referenceImageUrl,omitempty" dynamodbav:"referenceImageUrl,omitempty"`
 		CheckingImageUrl  string `json:"checkingImageUrl,omitempty" dynamodbav:"checkingImageUrl,omitempty"`
 		LayoutId          int    `json:"layoutId,omitempty" dynamodbav:"layoutId,omitempty"`
@@ -260,6 +269,8 @@ func (r *VerificationRepository) StoreVerificationRecord(ctx context.Context, ve
 		Status:              verification.Status,
 		VerificationType:    verification.VerificationType,
 		VendingMachineId:    verification.VendingMachineId,
+		TenantId:            verification.TenantId,
+		Locale:              verification.Locale,
 		ReferenceImageUrl:   verification.ReferenceImageUrl,
 		CheckingImageUrl:    verification.CheckingImageUrl,
 		LayoutId:            verification.LayoutId,
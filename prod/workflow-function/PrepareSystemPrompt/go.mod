@@ -6,24 +6,26 @@ replace (
 	workflow-function/shared/templateloader => ../shared/templateloader
 	workflow-function/shared/s3state => ../shared/s3state
 	workflow-function/shared/error => ../shared/errors
+	workflow-function/shared/experiments => ../shared/experiments
 )
 
 go 1.24.0
 
 require (
 	github.com/aws/aws-lambda-go v1.48.0
+	github.com/aws/aws-sdk-go-v2/config v1.22.0
 	github.com/aws/aws-sdk-go-v2/service/s3 v1.79.3
 	workflow-function/shared/logger v0.0.0-00010101000000-000000000000
 	workflow-function/shared/schema v0.0.0-00010101000000-000000000000
 	workflow-function/shared/templateloader v0.0.0-00010101000000-000000000000
 	workflow-function/shared/s3state v0.0.0-00010101000000-000000000000
 	workflow-function/shared/error v0.0.0-00010101000000-000000000000
+	workflow-function/shared/experiments v0.0.0-00010101000000-000000000000
 )
 
 require (
 	github.com/aws/aws-sdk-go-v2 v1.36.3 // indirect
 	github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream v1.6.10 // indirect
-	github.com/aws/aws-sdk-go-v2/config v1.22.0 // indirect
 	github.com/aws/aws-sdk-go-v2/credentials v1.16.0 // indirect
 	github.com/aws/aws-sdk-go-v2/feature/ec2/imds v1.14.11 // indirect
 	github.com/aws/aws-sdk-go-v2/internal/configsources v1.3.34 // indirect
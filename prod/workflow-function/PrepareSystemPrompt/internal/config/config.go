@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"workflow-function/shared/experiments"
 )
 
 const (
@@ -14,6 +16,7 @@ const (
 	EnvCheckingBucket       = "CHECKING_BUCKET"
 	EnvStateBucket          = "STATE_BUCKET"
 	EnvTemplateBasePath     = "TEMPLATE_BASE_PATH"
+	EnvTemplateS3URI        = "TEMPLATE_S3_URI"
 	EnvComponentName        = "COMPONENT_NAME"
 	EnvDatePartitionTimezone = "DATE_PARTITION_TIMEZONE"
 	EnvDebug                 = "DEBUG"
@@ -43,7 +46,9 @@ type Config struct {
 	
 	// Template settings
 	TemplateBasePath string
+	TemplateS3URI    string // optional S3 URI of templates overriding the bundled ones
 	PromptVersion    string
+	Experiments      *experiments.Registry // prompt experiments from PROMPT_EXPERIMENTS
 	
 	// Bedrock settings
 	MaxTokens        int
@@ -64,6 +69,7 @@ func LoadConfig() (*Config, error) {
 		ReferenceBucket:      getEnv(EnvReferenceBucket, ""),
 		CheckingBucket:       getEnv(EnvCheckingBucket, ""),
 		TemplateBasePath:     getEnv(EnvTemplateBasePath, DefaultTemplateBasePath),
+		TemplateS3URI:        getEnv(EnvTemplateS3URI, ""),
 		ComponentName:        getEnv(EnvComponentName, DefaultComponentName),
 		DatePartitionTimezone: getEnv(EnvDatePartitionTimezone, DefaultDatePartitionTimezone),
 		MaxTokens:            getIntEnv(EnvMaxTokens, DefaultMaxTokens),
@@ -79,6 +85,13 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 	
+	// Load prompt experiments
+	registry, err := experiments.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	config.Experiments = registry
+	
 	return config, nil
 }
 
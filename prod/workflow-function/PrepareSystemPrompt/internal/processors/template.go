@@ -1,10 +1,14 @@
 package processors
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"text/template"
 
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
 	"workflow-function/shared/logger"
 	"workflow-function/shared/schema"
 	"workflow-function/shared/templateloader"
@@ -24,8 +28,22 @@ type TemplateProcessor struct {
 func NewTemplateProcessor(cfg *config.Config, log logger.Logger) (*TemplateProcessor, error) {
 	// Configure template loader
 	tlConfig := templateloader.Config{
-		BasePath:     cfg.TemplateBasePath,
-		CacheEnabled: true,
+		BasePath:       cfg.TemplateBasePath,
+		CacheEnabled:   true,
+		PinnedVersions: templateloader.PinnedVersionsFromEnv(),
+	}
+
+	// Templates in S3 override the bundled templates when configured
+	if cfg.TemplateS3URI != "" {
+		awsCfg, err := awsconfig.LoadDefaultConfig(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS config for S3 templates: %w", err)
+		}
+		source, err := templateloader.NewS3FallbackSource(s3.NewFromConfig(awsCfg), cfg.TemplateS3URI, cfg.TemplateBasePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure S3 template source: %w", err)
+		}
+		tlConfig.Source = source
 	}
 	
 	tmplLoader, err := templateloader.New(tlConfig)
@@ -59,7 +77,8 @@ func (p *TemplateProcessor) GetTemplate(verificationType string) (*template.Temp
 	return tmpl, nil
 }
 
-// GetTemplateWithVersion gets a specific version of a template
+// GetTemplateWithVersion gets a specific version of a template. Localized
+// template types such as "layout-vs-checking/vi" are loaded as they are.
 func (p *TemplateProcessor) GetTemplateWithVersion(verificationType, version string) (*template.Template, error) {
 	// Map verification type to template type
 	templateType := MapVerificationTypeToTemplateType(verificationType)
@@ -251,12 +270,42 @@ func (p *TemplateProcessor) GenerateSystemPromptContent(vCtx *schema.Verificatio
 	// Get template version (from config or latest available)
 	promptVersion := p.GetLatestVersion(vCtx.VerificationType)
 	
+	// A prompt experiment on the system template pins the variant's version
+	templateType := MapVerificationTypeToTemplateType(vCtx.VerificationType)
+	if a, ok := p.config.Experiments.AssignTemplate(templateType, vCtx.VerificationId); ok && a.TemplateVersion != "" {
+		p.logger.Info("Prompt experiment assigned", map[string]interface{}{
+			"experimentId":    a.ExperimentID,
+			"variant":         a.Variant,
+			"templateType":    templateType,
+			"templateVersion": a.TemplateVersion,
+		})
+		promptVersion = a.TemplateVersion
+	}
+	
+	// Use the templates of the verification's locale when the version is localized,
+	// else the default templates
+	locale := p.templateLoader.ResolveLocale(templateType, vCtx.Locale, promptVersion)
+	if vCtx.Locale != "" {
+		p.logger.Info("Resolved template locale", map[string]interface{}{
+			"verificationId":  vCtx.VerificationId,
+			"requestedLocale": vCtx.Locale,
+			"locale":          locale,
+			"templateVersion": promptVersion,
+		})
+	}
+	templateType = templateloader.LocalizedTemplateType(templateType, locale)
+	
 	// Load template
-	tmpl, err := p.GetTemplateWithVersion(vCtx.VerificationType, promptVersion)
+	tmpl, err := p.GetTemplateWithVersion(templateType, promptVersion)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to get template: %w", err)
 	}
 	
+	// Check the data supplies what the template version's contract requires
+	if err := p.templateLoader.ValidateData(templateType, promptVersion, templateData); err != nil {
+		return "", "", fmt.Errorf("failed to validate template data: %w", err)
+	}
+	
 	// Render template
 	promptContent, err := p.RenderTemplate(tmpl, templateData)
 	if err != nil {
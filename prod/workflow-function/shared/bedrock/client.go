@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"reflect"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -150,28 +149,27 @@ func (bc *BedrockClient) Converse(ctx context.Context, request *ConverseRequest)
 		}
 	}
 
-	// Create inference config
-	inferenceConfig := &types.InferenceConfiguration{
-		MaxTokens: aws.Int32(int32(request.InferenceConfig.MaxTokens)),
+	// Resolve extended thinking: a per-request setting wins over the client default
+	thinking := request.Thinking
+	if thinking == nil {
+		thinking = bc.thinkingConfig()
 	}
-
-	if request.InferenceConfig.Temperature != nil {
-		inferenceConfig.Temperature = aws.Float32(float32(*request.InferenceConfig.Temperature))
+	if thinking.Enabled() && thinking.BudgetTokens >= request.InferenceConfig.MaxTokens {
+		return nil, 0, fmt.Errorf("thinking budget (%d) must be lower than max tokens (%d)",
+			thinking.BudgetTokens, request.InferenceConfig.MaxTokens)
 	}
 
-	if request.InferenceConfig.TopP != nil {
-		inferenceConfig.TopP = aws.Float32(float32(*request.InferenceConfig.TopP))
-	}
-
-	if len(request.InferenceConfig.StopSequences) > 0 {
-		inferenceConfig.StopSequences = request.InferenceConfig.StopSequences
+	// The request may target a different model than the client default
+	modelID := bc.modelID
+	if request.ModelId != "" {
+		modelID = request.ModelId
 	}
 
 	// Create Converse input
 	converseInput := &bedrockruntime.ConverseInput{
-		ModelId:         aws.String(bc.modelID),
+		ModelId:         aws.String(modelID),
 		Messages:        messages,
-		InferenceConfig: inferenceConfig,
+		InferenceConfig: buildInferenceConfig(request, thinking),
 	}
 
 	// Add system prompt if provided
@@ -198,17 +196,14 @@ func (bc *BedrockClient) Converse(ctx context.Context, request *ConverseRequest)
 		log.Printf("Added guardrail config with identifier: %s", request.GuardrailConfig.GuardrailIdentifier)
 	}
 
-	// Add cache control if provided
-	if request.CacheControl != nil && len(request.CacheControl) > 0 {
-		additionalFields := map[string]interface{}{
-			"cache_control": request.CacheControl,
-		}
+	// Add model-specific fields (cache control, extended thinking)
+	if additionalFields := buildAdditionalModelRequestFields(request, thinking); additionalFields != nil {
 		converseInput.AdditionalModelRequestFields = document.NewLazyDocument(additionalFields)
-		log.Printf("Added cache control: %v", request.CacheControl)
+		log.Printf("Added additional model request fields: %v", additionalFields)
 	}
 
 	// Log request details
-	log.Printf("Sending Converse API request to model %s with %d messages", bc.modelID, len(messages))
+	log.Printf("Sending Converse API request to model %s with %d messages", modelID, len(messages))
 
 	// Call Bedrock Converse API
 	result, err := bc.client.Converse(ctx, converseInput)
@@ -220,7 +215,7 @@ func (bc *BedrockClient) Converse(ctx context.Context, request *ConverseRequest)
 	latency := time.Since(startTime)
 
 	// Convert response to our format
-	response, err := bc.convertFromBedrockResponse(result, bc.modelID)
+	response, err := bc.convertFromBedrockResponse(result, modelID)
 	if err != nil {
 		return nil, latency.Milliseconds(), fmt.Errorf("failed to convert response: %w", err)
 	}
@@ -240,11 +235,18 @@ func (bc *BedrockClient) convertFromBedrockResponse(result *bedrockruntime.Conve
 		switch v := result.Output.(type) {
 		case *types.ConverseOutputMemberMessage:
 			for _, contentBlock := range v.Value.Content {
-				if cb, ok := contentBlock.(*types.ContentBlockMemberText); ok {
+				switch cb := contentBlock.(type) {
+				case *types.ContentBlockMemberText:
 					content = append(content, ContentBlock{
-						Type: "text",
+						Type: ContentTypeText,
 						Text: cb.Value,
 					})
+				case *types.ContentBlockMemberReasoningContent:
+					if block, ok := convertReasoningContent(cb.Value); ok {
+						content = append(content, block)
+					}
+				default:
+					log.Printf("Skipping unsupported content block type in response: %T", cb)
 				}
 			}
 		default:
@@ -295,134 +297,90 @@ func (bc *BedrockClient) convertFromBedrockResponse(result *bedrockruntime.Conve
 	}, nil
 }
 
-// extractValueFromUnknownType attempts to extract a string value from an unknown type
-// This is used for handling thinking content blocks with unknown SDK types
-func extractValueFromUnknownType(obj interface{}) string {
-	// Try to access common field names for thinking content
-	typeName := fmt.Sprintf("%T", obj)
-	log.Printf("Attempting to extract value from %s", typeName)
-
-	// Use type assertion to access Value field if available
-	if valuer, ok := obj.(interface{ GetValue() string }); ok {
-		return valuer.GetValue()
+// convertReasoningContent converts a typed reasoning block from the SDK into
+// a thinking content block
+func convertReasoningContent(reasoning types.ReasoningContentBlock) (ContentBlock, bool) {
+	switch r := reasoning.(type) {
+	case *types.ReasoningContentBlockMemberReasoningText:
+		block := ContentBlock{
+			Type: ContentTypeThinking,
+			Text: aws.ToString(r.Value.Text),
+		}
+		if r.Value.Signature != nil {
+			block.Signature = *r.Value.Signature
+		}
+		log.Printf("Found reasoning content block with %d characters", len(block.Text))
+		return block, true
+	case *types.ReasoningContentBlockMemberRedactedContent:
+		log.Printf("Found redacted reasoning content block (%d bytes)", len(r.Value))
+		return ContentBlock{
+			Type:            ContentTypeRedactedThinking,
+			RedactedContent: r.Value,
+		}, true
+	default:
+		log.Printf("Unknown reasoning content type in response: %T", r)
+		return ContentBlock{}, false
 	}
+}
 
-	// Try reflection as fallback
-	if textValue := extractValueFromStruct(obj); textValue != "" {
-		return textValue
+// thinkingConfig returns the client-level extended thinking configuration
+func (bc *BedrockClient) thinkingConfig() *ThinkingConfig {
+	if bc.config == nil || bc.config.ThinkingType == "" {
+		return nil
+	}
+	return &ThinkingConfig{
+		Type:         bc.config.ThinkingType,
+		BudgetTokens: bc.config.BudgetTokens,
 	}
-
-	// For debugging purposes
-	log.Printf("Could not extract value from %s", typeName)
-	return ""
 }
 
-// extractValueFromStruct uses reflection to extract text from struct fields
-func extractValueFromStruct(obj interface{}) string {
-	if obj == nil {
-		return ""
+// buildInferenceConfig converts the request's inference settings. Extended
+// thinking is incompatible with custom sampling parameters, so temperature
+// and topP are only forwarded when it is off.
+func buildInferenceConfig(request *ConverseRequest, thinking *ThinkingConfig) *types.InferenceConfiguration {
+	inferenceConfig := &types.InferenceConfiguration{
+		MaxTokens: aws.Int32(int32(request.InferenceConfig.MaxTokens)),
 	}
 
-	v := reflect.ValueOf(obj)
-	if v.Kind() == reflect.Ptr {
-		if v.IsNil() {
-			return ""
+	if thinking.Enabled() {
+		log.Printf("Thinking enabled (budget tokens: %d), omitting temperature and topP", thinking.BudgetTokens)
+	} else {
+		if request.InferenceConfig.Temperature != nil {
+			inferenceConfig.Temperature = aws.Float32(float32(*request.InferenceConfig.Temperature))
 		}
-		v = v.Elem()
-	}
 
-	if v.Kind() != reflect.Struct {
-		// Try to convert to string directly
-		if str, ok := obj.(string); ok {
-			return str
+		if request.InferenceConfig.TopP != nil {
+			inferenceConfig.TopP = aws.Float32(float32(*request.InferenceConfig.TopP))
 		}
-		return ""
 	}
 
-	// Log struct fields for debugging
-	typeName := v.Type().Name()
-	log.Printf("Extracting from struct %s with %d fields", typeName, v.NumField())
-
-	// Look for common field names that might contain text
-	fieldNames := []string{"Text", "Value", "Content", "Data", "Message", "Reasoning", "Thinking"}
-	for _, fieldName := range fieldNames {
-		field := v.FieldByName(fieldName)
-		if field.IsValid() {
-			log.Printf("Found field %s of kind %s", fieldName, field.Kind())
-			if field.Kind() == reflect.String {
-				value := field.String()
-				if value != "" {
-					log.Printf("Extracted %d chars from field %s", len(value), fieldName)
-					return value
-				}
-			}
-			// Handle nested structs or pointers
-			if field.Kind() == reflect.Ptr && !field.IsNil() {
-				if nestedValue := extractValueFromStruct(field.Interface()); nestedValue != "" {
-					log.Printf("Extracted %d chars from nested field %s", len(nestedValue), fieldName)
-					return nestedValue
-				}
-			}
-			if field.Kind() == reflect.Struct {
-				if nestedValue := extractValueFromStruct(field.Interface()); nestedValue != "" {
-					log.Printf("Extracted %d chars from nested struct field %s", len(nestedValue), fieldName)
-					return nestedValue
-				}
-			}
-		}
+	if len(request.InferenceConfig.StopSequences) > 0 {
+		inferenceConfig.StopSequences = request.InferenceConfig.StopSequences
 	}
 
-	// If no specific field found, try to find any string field recursively
-	for i := 0; i < v.NumField(); i++ {
-		field := v.Field(i)
-		fieldType := v.Type().Field(i)
-
-		// Skip unexported fields to avoid reflection panic
-		if fieldType.PkgPath != "" {
-			continue
-		}
-
-		log.Printf("Checking field %s of kind %s", fieldType.Name, field.Kind())
-
-		// Handle interface fields by getting their concrete value
-		if field.Kind() == reflect.Interface && !field.IsNil() {
-			log.Printf("Processing interface field %s", fieldType.Name)
-			concreteField := field.Elem()
-			log.Printf("Interface contains %s of kind %s", concreteField.Type(), concreteField.Kind())
+	return inferenceConfig
+}
 
-			// Try to extract from the concrete value
-			if concreteField.CanInterface() {
-				if nestedValue := extractValueFromStruct(concreteField.Interface()); nestedValue != "" {
-					log.Printf("Extracted value from interface field %s: %d chars", fieldType.Name, len(nestedValue))
-					return nestedValue
-				}
-			}
-			continue
-		}
+// buildAdditionalModelRequestFields assembles the provider-specific request
+// fields. It returns nil when there is nothing to send.
+func buildAdditionalModelRequestFields(request *ConverseRequest, thinking *ThinkingConfig) map[string]interface{} {
+	fields := map[string]interface{}{}
 
-		if field.Kind() == reflect.String && field.String() != "" {
-			value := field.String()
-			log.Printf("Found string field %s with %d chars", fieldType.Name, len(value))
-			return value
-		}
+	if len(request.CacheControl) > 0 {
+		fields["cache_control"] = request.CacheControl
+	}
 
-		// Recursively check nested structs
-		if field.Kind() == reflect.Ptr && !field.IsNil() {
-			if nestedValue := extractValueFromStruct(field.Interface()); nestedValue != "" {
-				log.Printf("Found nested value in pointer field %s", fieldType.Name)
-				return nestedValue
-			}
-		}
-		if field.Kind() == reflect.Struct {
-			if nestedValue := extractValueFromStruct(field.Interface()); nestedValue != "" {
-				log.Printf("Found nested value in struct field %s", fieldType.Name)
-				return nestedValue
-			}
+	if thinking.Enabled() {
+		fields["thinking"] = map[string]interface{}{
+			"type":          thinking.Type,
+			"budget_tokens": thinking.BudgetTokens,
 		}
 	}
 
-	log.Printf("No extractable value found in struct %s", typeName)
-	return ""
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
 }
 
 // handleBedrockError converts AWS SDK errors to our error types
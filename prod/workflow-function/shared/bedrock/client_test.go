@@ -0,0 +1,199 @@
+package bedrock
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+)
+
+func TestConvertReasoningContent(t *testing.T) {
+	tests := []struct {
+		name      string
+		reasoning types.ReasoningContentBlock
+		want      ContentBlock
+		ok        bool
+	}{
+		{
+			name: "text with signature",
+			reasoning: &types.ReasoningContentBlockMemberReasoningText{Value: types.ReasoningTextBlock{
+				Text:      aws.String("Row A looks complete"),
+				Signature: aws.String("sig-1"),
+			}},
+			want: ContentBlock{Type: ContentTypeThinking, Text: "Row A looks complete", Signature: "sig-1"},
+			ok:   true,
+		},
+		{
+			name:      "text without signature",
+			reasoning: &types.ReasoningContentBlockMemberReasoningText{Value: types.ReasoningTextBlock{Text: aws.String("Checking row B")}},
+			want:      ContentBlock{Type: ContentTypeThinking, Text: "Checking row B"},
+			ok:        true,
+		},
+		{
+			name:      "redacted",
+			reasoning: &types.ReasoningContentBlockMemberRedactedContent{Value: []byte{0x01, 0x02}},
+			want:      ContentBlock{Type: ContentTypeRedactedThinking, RedactedContent: []byte{0x01, 0x02}},
+			ok:        true,
+		},
+		{
+			name:      "unknown",
+			reasoning: &types.UnknownUnionMember{Tag: "future", Value: []byte("x")},
+			ok:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := convertReasoningContent(tt.reasoning)
+			if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("convertReasoningContent() = %+v, %v; want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestBuildAdditionalModelRequestFields(t *testing.T) {
+	enabled := &ThinkingConfig{Type: ThinkingTypeEnabled, BudgetTokens: 2048}
+	cacheControl := map[string]string{"type": "ephemeral"}
+
+	tests := []struct {
+		name     string
+		request  *ConverseRequest
+		thinking *ThinkingConfig
+		want     map[string]interface{}
+	}{
+		{
+			name:    "nothing to send",
+			request: &ConverseRequest{},
+			want:    nil,
+		},
+		{
+			name:     "disabled thinking",
+			request:  &ConverseRequest{},
+			thinking: &ThinkingConfig{Type: "disabled", BudgetTokens: 2048},
+			want:     nil,
+		},
+		{
+			name:     "thinking without a budget",
+			request:  &ConverseRequest{},
+			thinking: &ThinkingConfig{Type: ThinkingTypeEnabled},
+			want:     nil,
+		},
+		{
+			name:    "cache control",
+			request: &ConverseRequest{CacheControl: cacheControl},
+			want:    map[string]interface{}{"cache_control": cacheControl},
+		},
+		{
+			name:     "thinking budget",
+			request:  &ConverseRequest{},
+			thinking: enabled,
+			want: map[string]interface{}{
+				"thinking": map[string]interface{}{"type": ThinkingTypeEnabled, "budget_tokens": 2048},
+			},
+		},
+		{
+			name:     "cache control and thinking",
+			request:  &ConverseRequest{CacheControl: cacheControl},
+			thinking: enabled,
+			want: map[string]interface{}{
+				"cache_control": cacheControl,
+				"thinking":      map[string]interface{}{"type": ThinkingTypeEnabled, "budget_tokens": 2048},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildAdditionalModelRequestFields(tt.request, tt.thinking); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildAdditionalModelRequestFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildInferenceConfig(t *testing.T) {
+	temperature, topP := 0.2, 0.9
+	request := &ConverseRequest{InferenceConfig: InferenceConfig{
+		MaxTokens:     4096,
+		Temperature:   &temperature,
+		TopP:          &topP,
+		StopSequences: []string{"END"},
+	}}
+
+	tests := []struct {
+		name            string
+		thinking        *ThinkingConfig
+		wantTemperature *float32
+		wantTopP        *float32
+	}{
+		{name: "thinking off", wantTemperature: aws.Float32(0.2), wantTopP: aws.Float32(0.9)},
+		{name: "thinking disabled", thinking: &ThinkingConfig{Type: "disabled"}, wantTemperature: aws.Float32(0.2), wantTopP: aws.Float32(0.9)},
+		{name: "thinking on", thinking: &ThinkingConfig{Type: ThinkingTypeEnabled, BudgetTokens: 1024}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildInferenceConfig(request, tt.thinking)
+			if aws.ToInt32(got.MaxTokens) != 4096 || !reflect.DeepEqual(got.StopSequences, []string{"END"}) {
+				t.Errorf("Expected max tokens and stop sequences to be kept, got %d, %v", aws.ToInt32(got.MaxTokens), got.StopSequences)
+			}
+			if !reflect.DeepEqual(got.Temperature, tt.wantTemperature) {
+				t.Errorf("Temperature = %v, want %v", got.Temperature, tt.wantTemperature)
+			}
+			if !reflect.DeepEqual(got.TopP, tt.wantTopP) {
+				t.Errorf("TopP = %v, want %v", got.TopP, tt.wantTopP)
+			}
+		})
+	}
+}
+
+func TestReasoningBlocksFromResponse(t *testing.T) {
+	output := &bedrockruntime.ConverseOutput{
+		Output: &types.ConverseOutputMemberMessage{Value: types.Message{
+			Role: types.ConversationRoleAssistant,
+			Content: []types.ContentBlock{
+				&types.ContentBlockMemberReasoningContent{Value: &types.ReasoningContentBlockMemberReasoningText{
+					Value: types.ReasoningTextBlock{Text: aws.String("First, row A."), Signature: aws.String("sig-1")},
+				}},
+				&types.ContentBlockMemberReasoningContent{Value: &types.ReasoningContentBlockMemberRedactedContent{Value: []byte("hidden")}},
+				&types.ContentBlockMemberReasoningContent{Value: &types.UnknownUnionMember{Tag: "future"}},
+				&types.ContentBlockMemberReasoningContent{Value: &types.ReasoningContentBlockMemberReasoningText{
+					Value: types.ReasoningTextBlock{Text: aws.String("Then, row B.")},
+				}},
+				&types.ContentBlockMemberText{Value: "A01 is missing"},
+			},
+		}},
+		StopReason: types.StopReasonEndTurn,
+		Usage:      &types.TokenUsage{InputTokens: aws.Int32(10), OutputTokens: aws.Int32(5)},
+	}
+
+	response, err := (&BedrockClient{}).convertFromBedrockResponse(output, "model-1")
+	if err != nil {
+		t.Fatalf("convertFromBedrockResponse failed: %v", err)
+	}
+
+	want := []ContentBlock{
+		{Type: ContentTypeThinking, Text: "First, row A.", Signature: "sig-1"},
+		{Type: ContentTypeRedactedThinking, RedactedContent: []byte("hidden")},
+		{Type: ContentTypeThinking, Text: "Then, row B."},
+	}
+	if got := ExtractReasoningBlocks(response); !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractReasoningBlocks() = %+v, want %+v", got, want)
+	}
+	if got := ExtractThinkingFromResponse(response); got != "First, row A.\n\nThen, row B." {
+		t.Errorf("ExtractThinkingFromResponse() = %q", got)
+	}
+	if got := ExtractTextFromResponse(response); got != "A01 is missing" {
+		t.Errorf("ExtractTextFromResponse() = %q", got)
+	}
+	if response.Usage.TotalTokens != 15 || response.StopReason != "end_turn" {
+		t.Errorf("Unexpected usage %+v or stop reason %q", response.Usage, response.StopReason)
+	}
+
+	if ExtractReasoningBlocks(nil) != nil || ExtractThinkingFromResponse(nil) != "" {
+		t.Error("Expected no reasoning from a nil response")
+	}
+}
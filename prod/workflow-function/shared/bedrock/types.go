@@ -13,12 +13,28 @@ const (
 const (
 	AnalysisStageTurn1 = "REFERENCE_ANALYSIS"
 	AnalysisStageTurn2 = "CHECKING_ANALYSIS"
+	AnalysisStageTurn3 = "SELF_VERIFICATION"
 )
 
 // Expected turn numbers
 const (
 	ExpectedTurn1Number = 1
 	ExpectedTurn2Number = 2
+	ExpectedTurn3Number = 3
+)
+
+// Content block type identifiers
+const (
+	ContentTypeText             = "text"
+	ContentTypeImage            = "image"
+	ContentTypeThinking         = "thinking"
+	ContentTypeRedactedThinking = "redacted_thinking"
+)
+
+// Extended thinking settings
+const (
+	ThinkingTypeEnabled     = "enabled"
+	MinThinkingBudgetTokens = 1024
 )
 
 // ConverseRequest represents a request to the Bedrock Converse API
@@ -30,6 +46,19 @@ type ConverseRequest struct {
 	GuardrailConfig *GuardrailConfig  `json:"guardrailConfig,omitempty"`
 	CacheControl    map[string]string `json:"cache_control,omitempty"`
 	Reasoning       string            `json:"reasoning,omitempty"` // Added for Claude 3.5 Sonnet thinking support
+	Thinking        *ThinkingConfig   `json:"thinking,omitempty"`  // Overrides the client-level thinking configuration
+}
+
+// ThinkingConfig enables extended thinking for a request. It is sent to the
+// model through AdditionalModelRequestFields.
+type ThinkingConfig struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens"`
+}
+
+// Enabled reports whether the configuration turns on extended thinking
+func (t *ThinkingConfig) Enabled() bool {
+	return t != nil && t.Type == ThinkingTypeEnabled && t.BudgetTokens > 0
 }
 
 // MessageWrapper represents a message in the Converse API
@@ -38,11 +67,15 @@ type MessageWrapper struct {
 	Content []ContentBlock `json:"content"`
 }
 
-// ContentBlock represents a content block in a message
+// ContentBlock represents a content block in a message.
+// Reasoning blocks returned by the model use the thinking types, carry the
+// reasoning text in Text and the verification token in Signature.
 type ContentBlock struct {
-	Type  string      `json:"type"`
-	Text  string      `json:"text,omitempty"`
-	Image *ImageBlock `json:"image,omitempty"`
+	Type            string      `json:"type"`
+	Text            string      `json:"text,omitempty"`
+	Image           *ImageBlock `json:"image,omitempty"`
+	Signature       string      `json:"signature,omitempty"`
+	RedactedContent []byte      `json:"redactedContent,omitempty"`
 }
 
 // ImageBlock represents an image in a content block
@@ -208,10 +241,40 @@ func ExtractTextFromResponse(response *ConverseResponse) string {
 
 	var textParts []string
 	for _, content := range response.Content {
-		if content.Type == "text" {
+		if content.Type == ContentTypeText {
 			textParts = append(textParts, content.Text)
 		}
 	}
 
 	return strings.Join(textParts, "")
 }
+
+// ExtractReasoningBlocks returns the reasoning content blocks of a Converse
+// response in the order the model produced them
+func ExtractReasoningBlocks(response *ConverseResponse) []ContentBlock {
+	if response == nil {
+		return nil
+	}
+
+	var blocks []ContentBlock
+	for _, content := range response.Content {
+		if content.Type == ContentTypeThinking || content.Type == ContentTypeRedactedThinking {
+			blocks = append(blocks, content)
+		}
+	}
+
+	return blocks
+}
+
+// ExtractThinkingFromResponse joins the readable reasoning text of a Converse
+// response. Redacted reasoning is skipped.
+func ExtractThinkingFromResponse(response *ConverseResponse) string {
+	var parts []string
+	for _, block := range ExtractReasoningBlocks(response) {
+		if block.Type == ContentTypeThinking && block.Text != "" {
+			parts = append(parts, block.Text)
+		}
+	}
+
+	return strings.Join(parts, "\n\n")
+}
@@ -0,0 +1,237 @@
+// Package examples selects reviewer-confirmed verifications as few-shot
+// examples for the Turn2 conversation. Each example holds the images of a
+// past verification and its correct per-position answer; the most relevant
+// examples of a verification are added to the conversation as prior turns
+// within a token budget.
+package examples
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Environment variables configuring few-shot examples. Examples are disabled
+// unless FEW_SHOT_EXAMPLES_TABLE is set.
+const (
+	EnvExamplesTable = "FEW_SHOT_EXAMPLES_TABLE"
+	EnvMaxExamples   = "FEW_SHOT_MAX_EXAMPLES"
+	EnvTokenBudget   = "FEW_SHOT_TOKEN_BUDGET"
+)
+
+// Defaults of FEW_SHOT_MAX_EXAMPLES and FEW_SHOT_TOKEN_BUDGET
+const (
+	DefaultMaxExamples = 2
+	DefaultTokenBudget = 8000
+)
+
+// AttributeUsage is the verification record attribute listing the examples
+// added to the verification's Turn2 conversation
+const AttributeUsage = "fewShotExamples"
+
+// Example statuses; retired examples are kept but never selected
+const (
+	StatusActive  = "ACTIVE"
+	StatusRetired = "RETIRED"
+)
+
+// ImageTokens is the estimated input token cost of one example image, the
+// cost of an image of about 1.15 megapixels
+const ImageTokens = 1600
+
+// ImageRef locates a base64 encoded image in the state bucket
+type ImageRef struct {
+	Key    string `json:"key" dynamodbav:"key"`
+	Format string `json:"format" dynamodbav:"format"`
+}
+
+// ImageKey returns the state bucket key of a verification's base64 image,
+// e.g. 2026/10/18/verif-1/images/checking-base64.base64 for imageType
+// "checking"
+func ImageKey(datePartition, verificationID, imageType string) string {
+	return fmt.Sprintf("%s/%s/images/%s-base64.base64", datePartition, verificationID, imageType)
+}
+
+// PositionAnswer is the reviewed answer for one position. An empty Issue
+// marks a position the reviewer confirmed as correct.
+type PositionAnswer struct {
+	Position string `json:"position" dynamodbav:"position"`
+	Expected string `json:"expected,omitempty" dynamodbav:"expected,omitempty"`
+	Found    string `json:"found,omitempty" dynamodbav:"found,omitempty"`
+	Issue    string `json:"issue,omitempty" dynamodbav:"issue,omitempty"`
+	Note     string `json:"note,omitempty" dynamodbav:"note,omitempty"`
+}
+
+// Example is a reviewer-confirmed verification
+type Example struct {
+	ExampleID        string `json:"exampleId" dynamodbav:"exampleId"`
+	VerificationID   string `json:"verificationId" dynamodbav:"verificationId"`
+	VerificationType string `json:"verificationType" dynamodbav:"verificationType"`
+	LayoutID         int    `json:"layoutId,omitempty" dynamodbav:"layoutId,omitempty"`
+	LayoutPrefix     string `json:"layoutPrefix,omitempty" dynamodbav:"layoutPrefix,omitempty"`
+	VendingMachineID string `json:"vendingMachineId,omitempty" dynamodbav:"vendingMachineId,omitempty"`
+	MachineModel     string `json:"machineModel,omitempty" dynamodbav:"machineModel,omitempty"`
+	// Tags describe the conditions of the example, e.g. lighting:dark
+	Tags []string `json:"tags,omitempty" dynamodbav:"tags,omitempty"`
+	// Products are the products the model got wrong before review
+	Products       []string         `json:"products,omitempty" dynamodbav:"products,omitempty"`
+	ReferenceImage ImageRef         `json:"referenceImage" dynamodbav:"referenceImage"`
+	CheckingImage  ImageRef         `json:"checkingImage" dynamodbav:"checkingImage"`
+	Positions      []PositionAnswer `json:"positions" dynamodbav:"positions"`
+	// Answer optionally replaces the answer generated from Positions
+	Answer     string `json:"answer,omitempty" dynamodbav:"answer,omitempty"`
+	Status     string `json:"exampleStatus" dynamodbav:"exampleStatus"`
+	ReviewedBy string `json:"reviewedBy" dynamodbav:"reviewedBy"`
+	ReviewedAt string `json:"reviewedAt" dynamodbav:"reviewedAt"`
+}
+
+// Validate checks the example identifies its verification and images and
+// holds at least one reviewed position
+func (e Example) Validate() error {
+	if e.ExampleID == "" {
+		return fmt.Errorf("exampleId is required")
+	}
+	if e.VerificationID == "" || e.VerificationType == "" {
+		return fmt.Errorf("example %s: verificationId and verificationType are required", e.ExampleID)
+	}
+	if e.ReferenceImage.Key == "" || e.CheckingImage.Key == "" || e.ReferenceImage.Format == "" || e.CheckingImage.Format == "" {
+		return fmt.Errorf("example %s: reference and checking images with their formats are required", e.ExampleID)
+	}
+	if len(e.Positions) == 0 && strings.TrimSpace(e.Answer) == "" {
+		return fmt.Errorf("example %s: at least one reviewed position is required", e.ExampleID)
+	}
+	for _, p := range e.Positions {
+		if p.Position == "" {
+			return fmt.Errorf("example %s: position is required", e.ExampleID)
+		}
+	}
+	return nil
+}
+
+// Active reports whether the example may be selected
+func (e Example) Active() bool {
+	return e.Status == "" || e.Status == StatusActive
+}
+
+// Discrepancies returns the reviewed positions with an issue
+func (e Example) Discrepancies() []PositionAnswer {
+	var out []PositionAnswer
+	for _, p := range e.Positions {
+		if p.Issue != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Prompt returns the user message introducing the example's images
+func (e Example) Prompt(index, total int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Example %d of %d] Reviewed verification %s", index, total, e.VerificationID)
+	var details []string
+	if e.LayoutID != 0 {
+		details = append(details, fmt.Sprintf("layout %d", e.LayoutID))
+	}
+	if e.MachineModel != "" {
+		details = append(details, "machine model "+e.MachineModel)
+	}
+	details = append(details, e.Tags...)
+	if len(details) > 0 {
+		fmt.Fprintf(&b, " (%s)", strings.Join(details, ", "))
+	}
+	b.WriteString(". This is a past verification, not the vending machine under review. ")
+	b.WriteString("The first image is the reference image and the second image is the checking image.")
+	if len(e.Products) > 0 {
+		fmt.Fprintf(&b, " Products that were misidentified before review: %s.", strings.Join(e.Products, ", "))
+	}
+	b.WriteString(" Report the discrepancies.")
+	return b.String()
+}
+
+// ReviewedAnswer returns the assistant message holding the correct answer:
+// Answer if set, else a discrepancy report generated from Positions
+func (e Example) ReviewedAnswer() string {
+	if strings.TrimSpace(e.Answer) != "" {
+		return strings.TrimSpace(e.Answer)
+	}
+	discrepancies := e.Discrepancies()
+	var b strings.Builder
+	b.WriteString("**DETAILED DISCREPANCY REPORT:**\n")
+	fmt.Fprintf(&b, "* **Discrepancies Found:** %d\n", len(discrepancies))
+	for i, p := range discrepancies {
+		fmt.Fprintf(&b, "  %d. **Position:** %s\n", i+1, p.Position)
+		fmt.Fprintf(&b, "     * **Expected:** %s\n", valueOrEmpty(p.Expected))
+		fmt.Fprintf(&b, "     * **Found:** %s\n", valueOrEmpty(p.Found))
+		fmt.Fprintf(&b, "     * **Issue:** %s\n", p.Issue)
+		if p.Note != "" {
+			fmt.Fprintf(&b, "     * **Evidence:** %s\n", p.Note)
+		}
+	}
+	status := "CORRECT"
+	if len(discrepancies) > 0 {
+		status = "INCORRECT"
+	}
+	b.WriteString("\n**VERIFICATION SUMMARY:**\n")
+	fmt.Fprintf(&b, "* **Total Positions Checked:** %d\n", len(e.Positions))
+	fmt.Fprintf(&b, "* **Correct Positions:** %d\n", len(e.Positions)-len(discrepancies))
+	fmt.Fprintf(&b, "* **Discrepant Positions:** %d\n", len(discrepancies))
+	fmt.Fprintf(&b, "* **VERIFICATION STATUS:** %s", status)
+	return b.String()
+}
+
+func valueOrEmpty(s string) string {
+	if s == "" {
+		return "Empty"
+	}
+	return s
+}
+
+// EstimatedTokens estimates the input tokens the example adds to the
+// conversation: about four characters per text token plus ImageTokens per
+// image
+func (e Example) EstimatedTokens() int {
+	chars := len(e.Prompt(1, 1)) + len(e.ReviewedAnswer())
+	return (chars+3)/4 + 2*ImageTokens
+}
+
+// Options limit the examples added to a conversation
+type Options struct {
+	// Table is the DynamoDB table of the examples
+	Table string
+	// K is the maximum number of examples
+	K int
+	// TokenBudget caps the estimated tokens of the examples; zero means no cap
+	TokenBudget int
+}
+
+// Enabled reports whether examples are configured
+func (o Options) Enabled() bool {
+	return o.Table != "" && o.K > 0
+}
+
+// FromEnv reads the options from FEW_SHOT_EXAMPLES_TABLE,
+// FEW_SHOT_MAX_EXAMPLES and FEW_SHOT_TOKEN_BUDGET
+func FromEnv() (Options, error) {
+	o := Options{Table: os.Getenv(EnvExamplesTable)}
+	var err error
+	if o.K, err = nonNegative(EnvMaxExamples, DefaultMaxExamples); err != nil {
+		return Options{}, err
+	}
+	if o.TokenBudget, err = nonNegative(EnvTokenBudget, DefaultTokenBudget); err != nil {
+		return Options{}, err
+	}
+	return o, nil
+}
+
+func nonNegative(key string, def int) (int, error) {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s: %q is not a non-negative integer", key, v)
+	}
+	return n, nil
+}
@@ -0,0 +1,141 @@
+package examples
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func example(id string, layoutID int, model string, products ...string) Example {
+	return Example{
+		ExampleID:        id,
+		VerificationID:   "verif-" + id,
+		VerificationType: "LAYOUT_VS_CHECKING",
+		LayoutID:         layoutID,
+		LayoutPrefix:     "abc",
+		MachineModel:     model,
+		Products:         products,
+		ReferenceImage:   ImageRef{Key: ImageKey("2026/10/01", "verif-"+id, "reference"), Format: "png"},
+		CheckingImage:    ImageRef{Key: ImageKey("2026/10/01", "verif-"+id, "checking"), Format: "jpeg"},
+		Positions: []PositionAnswer{
+			{Position: "A01"},
+			{Position: "A02", Expected: "Coca Cola 330ml", Issue: "Missing"},
+		},
+		ReviewedAt: "2026-10-01T10:00:00Z",
+	}
+}
+
+func TestSelectRanksByRelevance(t *testing.T) {
+	retired := example("retired", 7, "m5")
+	retired.Status = StatusRetired
+	other := example("other-type", 7, "m5")
+	other.VerificationType = "PREVIOUS_VS_CURRENT"
+	candidates := []Example{
+		example("model", 9, "M5", "Pepsi"),
+		example("layout", 7, "m3"),
+		example("unrelated", 9, "m3"),
+		example("products", 9, "m3", "pepsi ", "Aquafina"),
+		example("self", 7, "m5"),
+		retired,
+		other,
+	}
+	q := Query{
+		VerificationID:   "verif-self",
+		VerificationType: "LAYOUT_VS_CHECKING",
+		LayoutID:         7,
+		LayoutPrefix:     "abc",
+		MachineModel:     "m5",
+		Products:         []string{"Pepsi", "Aquafina"},
+	}
+
+	selected := Select(candidates, q, Options{K: 3})
+	var ids []string
+	for _, s := range selected {
+		ids = append(ids, s.Example.ExampleID)
+	}
+	if want := []string{"layout", "model", "products"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("Expected %v, got %v", want, ids)
+	}
+	if !reflect.DeepEqual(selected[1].Reasons, []string{"machineModel", "product:Pepsi"}) || selected[1].Score != 3 {
+		t.Errorf("Unexpected score of the machine model example: %+v", selected[1])
+	}
+	if u := selected[0].Usage(); u.ExampleID != "layout" || u.VerificationID != "verif-layout" || u.EstimatedTokens == 0 {
+		t.Errorf("Unexpected usage %+v", u)
+	}
+}
+
+func TestSelectRespectsTokenBudget(t *testing.T) {
+	small := example("small", 7, "")
+	large := example("large", 7, "m5")
+	large.Answer = strings.Repeat("x", 4000)
+	q := Query{VerificationType: "LAYOUT_VS_CHECKING", LayoutID: 7, LayoutPrefix: "abc", MachineModel: "m5"}
+
+	budget := small.EstimatedTokens() + 100
+	selected := Select([]Example{large, small}, q, Options{K: 2, TokenBudget: budget})
+	if len(selected) != 1 || selected[0].Example.ExampleID != "small" {
+		t.Fatalf("Expected the large example to be skipped, got %+v", selected)
+	}
+	if TotalTokens(selected) > budget {
+		t.Errorf("Selection exceeds the budget: %d", TotalTokens(selected))
+	}
+	if Select([]Example{small}, q, Options{}) != nil {
+		t.Error("Expected no examples when K is zero")
+	}
+}
+
+func TestReviewedAnswer(t *testing.T) {
+	e := example("a", 7, "")
+	answer := e.ReviewedAnswer()
+	for _, want := range []string{
+		"**Discrepancies Found:** 1",
+		"1. **Position:** A02",
+		"**Found:** Empty",
+		"**Total Positions Checked:** 2",
+		"**VERIFICATION STATUS:** INCORRECT",
+	} {
+		if !strings.Contains(answer, want) {
+			t.Errorf("Expected %q in answer:\n%s", want, answer)
+		}
+	}
+	e.Answer = " reviewed "
+	if e.ReviewedAnswer() != "reviewed" {
+		t.Errorf("Expected the explicit answer, got %q", e.ReviewedAnswer())
+	}
+	if !strings.HasPrefix(e.Prompt(1, 2), "[Example 1 of 2] Reviewed verification verif-a (layout 7)") {
+		t.Errorf("Unexpected prompt %q", e.Prompt(1, 2))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := example("a", 7, "").Validate(); err != nil {
+		t.Errorf("Expected a valid example, got %v", err)
+	}
+	e := example("a", 7, "")
+	e.CheckingImage.Key = ""
+	if err := e.Validate(); err == nil {
+		t.Error("Expected missing images to be rejected")
+	}
+	e = example("a", 7, "")
+	e.Positions = nil
+	if err := e.Validate(); err == nil {
+		t.Error("Expected an example without answer to be rejected")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv(EnvExamplesTable, "")
+	o, err := FromEnv()
+	if err != nil || o.Enabled() || o.K != DefaultMaxExamples || o.TokenBudget != DefaultTokenBudget {
+		t.Errorf("Unexpected defaults %+v, %v", o, err)
+	}
+	t.Setenv(EnvExamplesTable, "FewShotExamples")
+	t.Setenv(EnvMaxExamples, "3")
+	t.Setenv(EnvTokenBudget, "0")
+	if o, err = FromEnv(); err != nil || !o.Enabled() || o.K != 3 || o.TokenBudget != 0 {
+		t.Errorf("Unexpected options %+v, %v", o, err)
+	}
+	t.Setenv(EnvMaxExamples, "-1")
+	if _, err = FromEnv(); err == nil {
+		t.Error("Expected a negative maximum to be rejected")
+	}
+}
@@ -0,0 +1,3 @@
+module workflow-function/shared/examples
+
+go 1.24.0
@@ -0,0 +1,152 @@
+package examples
+
+import (
+	"sort"
+	"strings"
+)
+
+// Relevance weights of the example selection
+const (
+	layoutWeight       = 4
+	machineModelWeight = 2
+	machineWeight      = 1
+	productWeight      = 1
+)
+
+// Query describes the verification the examples are selected for
+type Query struct {
+	VerificationID   string
+	VerificationType string
+	LayoutID         int
+	LayoutPrefix     string
+	VendingMachineID string
+	MachineModel     string
+	// Products are the products expected in the verification's layout
+	Products []string
+}
+
+// Selected is an example chosen for a verification
+type Selected struct {
+	Example         Example
+	Score           int
+	Reasons         []string
+	EstimatedTokens int
+}
+
+// Turn is a selected example with its base64 images loaded, ready to be
+// added to the conversation
+type Turn struct {
+	Selected
+	ReferenceBase64 string
+	CheckingBase64  string
+}
+
+// Usage records an example added to a verification's conversation, stored
+// under AttributeUsage
+type Usage struct {
+	ExampleID       string   `json:"exampleId" dynamodbav:"exampleId"`
+	VerificationID  string   `json:"verificationId" dynamodbav:"verificationId"`
+	Score           int      `json:"score" dynamodbav:"score"`
+	Reasons         []string `json:"reasons,omitempty" dynamodbav:"reasons,omitempty"`
+	EstimatedTokens int      `json:"estimatedTokens" dynamodbav:"estimatedTokens"`
+}
+
+// Usage returns the record of the selected example
+func (s Selected) Usage() Usage {
+	return Usage{
+		ExampleID:       s.Example.ExampleID,
+		VerificationID:  s.Example.VerificationID,
+		Score:           s.Score,
+		Reasons:         s.Reasons,
+		EstimatedTokens: s.EstimatedTokens,
+	}
+}
+
+// Score rates the relevance of an example for the query: the same layout
+// counts most, then the same machine model, then the same vending machine
+// and each expected product the example had trouble with. Reasons name the
+// matches.
+func Score(e Example, q Query) (int, []string) {
+	score := 0
+	var reasons []string
+	if q.LayoutID != 0 && e.LayoutID == q.LayoutID && e.LayoutPrefix == q.LayoutPrefix {
+		score += layoutWeight
+		reasons = append(reasons, "layout")
+	}
+	if q.MachineModel != "" && strings.EqualFold(e.MachineModel, q.MachineModel) {
+		score += machineModelWeight
+		reasons = append(reasons, "machineModel")
+	}
+	if q.VendingMachineID != "" && e.VendingMachineID == q.VendingMachineID {
+		score += machineWeight
+		reasons = append(reasons, "vendingMachine")
+	}
+	expected := make(map[string]bool, len(q.Products))
+	for _, p := range q.Products {
+		expected[normalizeProduct(p)] = true
+	}
+	for _, p := range e.Products {
+		if expected[normalizeProduct(p)] {
+			score += productWeight
+			reasons = append(reasons, "product:"+p)
+		}
+	}
+	return score, reasons
+}
+
+func normalizeProduct(p string) string {
+	return strings.ToLower(strings.Join(strings.Fields(p), " "))
+}
+
+// Select returns up to opts.K of the active candidates of the query's
+// verification type, most relevant first. Candidates without any match and
+// the query's own verification are skipped. An example that would exceed the
+// token budget is skipped in favor of the next, smaller one.
+func Select(candidates []Example, q Query, opts Options) []Selected {
+	if opts.K <= 0 {
+		return nil
+	}
+	var scored []Selected
+	for _, e := range candidates {
+		if !e.Active() || e.VerificationType != q.VerificationType || e.VerificationID == q.VerificationID {
+			continue
+		}
+		score, reasons := Score(e, q)
+		if score == 0 {
+			continue
+		}
+		scored = append(scored, Selected{Example: e, Score: score, Reasons: reasons, EstimatedTokens: e.EstimatedTokens()})
+	}
+	sort.SliceStable(scored, func(i, j int) bool {
+		if scored[i].Score != scored[j].Score {
+			return scored[i].Score > scored[j].Score
+		}
+		if scored[i].Example.ReviewedAt != scored[j].Example.ReviewedAt {
+			return scored[i].Example.ReviewedAt > scored[j].Example.ReviewedAt
+		}
+		return scored[i].Example.ExampleID < scored[j].Example.ExampleID
+	})
+
+	var out []Selected
+	tokens := 0
+	for _, s := range scored {
+		if len(out) == opts.K {
+			break
+		}
+		if opts.TokenBudget > 0 && tokens+s.EstimatedTokens > opts.TokenBudget {
+			continue
+		}
+		tokens += s.EstimatedTokens
+		out = append(out, s)
+	}
+	return out
+}
+
+// TotalTokens returns the estimated tokens of the selection
+func TotalTokens(selected []Selected) int {
+	total := 0
+	for _, s := range selected {
+		total += s.EstimatedTokens
+	}
+	return total
+}
@@ -0,0 +1,234 @@
+// Package experiments assigns verifications to prompt experiment variants.
+// An experiment splits the verifications rendering one template type across
+// weighted variants, each of which may pin a template version, model and
+// temperature. Assignment hashes the verification ID, so every function of
+// the workflow picks the same variant for a verification without sharing
+// state.
+package experiments
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sort"
+	"strings"
+)
+
+// EnvPromptExperiments holds the JSON experiment definitions. All functions
+// of the workflow must be configured with the same value.
+const EnvPromptExperiments = "PROMPT_EXPERIMENTS"
+
+// AttributeAssignments is the verification record attribute holding the
+// assignments of a verification keyed by experiment ID
+const AttributeAssignments = "experimentAssignments"
+
+// Variant is one arm of an experiment. Empty fields keep the function's
+// configured value.
+type Variant struct {
+	Name            string   `json:"name"`
+	Weight          int      `json:"weight"`
+	TemplateVersion string   `json:"templateVersion,omitempty"`
+	ModelID         string   `json:"modelId,omitempty"`
+	Temperature     *float64 `json:"temperature,omitempty"`
+}
+
+// Experiment splits the verifications rendering TemplateType across Variants
+// in proportion to their weights
+type Experiment struct {
+	ID           string    `json:"id"`
+	TemplateType string    `json:"templateType"`
+	Variants     []Variant `json:"variants"`
+	Disabled     bool      `json:"disabled,omitempty"`
+}
+
+// Assignment records the variant a verification was assigned to
+type Assignment struct {
+	ExperimentID    string   `json:"experimentId" dynamodbav:"experimentId"`
+	Variant         string   `json:"variant" dynamodbav:"variant"`
+	TemplateType    string   `json:"templateType" dynamodbav:"templateType"`
+	TemplateVersion string   `json:"templateVersion,omitempty" dynamodbav:"templateVersion,omitempty"`
+	ModelID         string   `json:"modelId,omitempty" dynamodbav:"modelId,omitempty"`
+	Temperature     *float64 `json:"temperature,omitempty" dynamodbav:"temperature,omitempty"`
+}
+
+// TurnUsage records the model and tokens of one turn of an assigned
+// verification, stored as UsageAttribute(turn) for the experiment report
+type TurnUsage struct {
+	ModelID        string `json:"modelId" dynamodbav:"modelId"`
+	InputTokens    int    `json:"inputTokens" dynamodbav:"inputTokens"`
+	OutputTokens   int    `json:"outputTokens" dynamodbav:"outputTokens"`
+	ThinkingTokens int    `json:"thinkingTokens,omitempty" dynamodbav:"thinkingTokens,omitempty"`
+	TotalTokens    int    `json:"totalTokens" dynamodbav:"totalTokens"`
+}
+
+// UsageAttribute returns the verification record attribute holding the
+// TurnUsage of a turn, e.g. turn1ExperimentUsage
+func UsageAttribute(turn int) string {
+	return fmt.Sprintf("turn%dExperimentUsage", turn)
+}
+
+// Validate checks the experiment has an ID, a template type and at least one
+// uniquely named variant with a positive weight
+func (e Experiment) Validate() error {
+	if e.ID == "" {
+		return fmt.Errorf("experiment id is required")
+	}
+	if e.TemplateType == "" {
+		return fmt.Errorf("experiment %s: templateType is required", e.ID)
+	}
+	if len(e.Variants) == 0 {
+		return fmt.Errorf("experiment %s: at least one variant is required", e.ID)
+	}
+	names := make(map[string]bool, len(e.Variants))
+	for _, v := range e.Variants {
+		if v.Name == "" {
+			return fmt.Errorf("experiment %s: variant name is required", e.ID)
+		}
+		if names[v.Name] {
+			return fmt.Errorf("experiment %s: duplicate variant %s", e.ID, v.Name)
+		}
+		names[v.Name] = true
+		if v.Weight <= 0 {
+			return fmt.Errorf("experiment %s: variant %s must have a positive weight", e.ID, v.Name)
+		}
+		if v.Temperature != nil && (*v.Temperature < 0 || *v.Temperature > 1) {
+			return fmt.Errorf("experiment %s: variant %s temperature must be between 0 and 1", e.ID, v.Name)
+		}
+	}
+	return nil
+}
+
+// Assign returns the variant of a verification. The same experiment and
+// verification ID always yield the same variant; changing the weights or
+// the order of the variants reassigns verifications.
+func (e Experiment) Assign(verificationID string) Assignment {
+	total := 0
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+	bucket := int(bucketOf(e.ID, verificationID) % uint64(total))
+
+	variant := e.Variants[len(e.Variants)-1]
+	for _, v := range e.Variants {
+		if bucket < v.Weight {
+			variant = v
+			break
+		}
+		bucket -= v.Weight
+	}
+	return Assignment{
+		ExperimentID:    e.ID,
+		Variant:         variant.Name,
+		TemplateType:    e.TemplateType,
+		TemplateVersion: variant.TemplateVersion,
+		ModelID:         variant.ModelID,
+		Temperature:     variant.Temperature,
+	}
+}
+
+// bucketOf hashes the verification ID salted with the experiment ID, so
+// concurrent experiments split verifications independently
+func bucketOf(experimentID, verificationID string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(experimentID + ":" + verificationID))
+	return h.Sum64()
+}
+
+// Registry holds the experiments of a function
+type Registry struct {
+	experiments []Experiment
+}
+
+// NewRegistry validates experiments and returns a registry of the enabled
+// ones. Only one enabled experiment may target a template type.
+func NewRegistry(experiments []Experiment) (*Registry, error) {
+	r := &Registry{}
+	ids := make(map[string]bool)
+	types := make(map[string]string)
+	for _, e := range experiments {
+		if err := e.Validate(); err != nil {
+			return nil, err
+		}
+		if ids[e.ID] {
+			return nil, fmt.Errorf("duplicate experiment %s", e.ID)
+		}
+		ids[e.ID] = true
+		if e.Disabled {
+			continue
+		}
+		if other, ok := types[e.TemplateType]; ok {
+			return nil, fmt.Errorf("experiments %s and %s both target template %s", other, e.ID, e.TemplateType)
+		}
+		types[e.TemplateType] = e.ID
+		r.experiments = append(r.experiments, e)
+	}
+	return r, nil
+}
+
+// Parse reads experiment definitions from a JSON array. Empty input yields
+// an empty registry.
+func Parse(data string) (*Registry, error) {
+	if strings.TrimSpace(data) == "" {
+		return &Registry{}, nil
+	}
+	var experiments []Experiment
+	if err := json.Unmarshal([]byte(data), &experiments); err != nil {
+		return nil, fmt.Errorf("invalid experiment definitions: %w", err)
+	}
+	return NewRegistry(experiments)
+}
+
+// FromEnv reads the experiments from PROMPT_EXPERIMENTS
+func FromEnv() (*Registry, error) {
+	r, err := Parse(os.Getenv(EnvPromptExperiments))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", EnvPromptExperiments, err)
+	}
+	return r, nil
+}
+
+// Len returns the number of enabled experiments
+func (r *Registry) Len() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.experiments)
+}
+
+// Experiments returns the enabled experiments sorted by ID
+func (r *Registry) Experiments() []Experiment {
+	if r == nil {
+		return nil
+	}
+	out := append([]Experiment{}, r.experiments...)
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// AssignTemplate returns the assignment of a verification for the experiment
+// targeting templateType, if any
+func (r *Registry) AssignTemplate(templateType, verificationID string) (Assignment, bool) {
+	if r == nil || verificationID == "" {
+		return Assignment{}, false
+	}
+	for _, e := range r.experiments {
+		if e.TemplateType == templateType {
+			return e.Assign(verificationID), true
+		}
+	}
+	return Assignment{}, false
+}
+
+// AssignAll returns the assignments of a verification for every enabled
+// experiment keyed by experiment ID, as stored on the verification record
+func (r *Registry) AssignAll(verificationID string) map[string]Assignment {
+	if r == nil || verificationID == "" || len(r.experiments) == 0 {
+		return nil
+	}
+	out := make(map[string]Assignment, len(r.experiments))
+	for _, e := range r.experiments {
+		out[e.ID] = e.Assign(verificationID)
+	}
+	return out
+}
@@ -0,0 +1,70 @@
+package experiments
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+const definitions = `[
+	{"id": "pvc-v12", "templateType": "turn2-previous-vs-current", "variants": [
+		{"name": "control", "weight": 3, "templateVersion": "1.1.0"},
+		{"name": "treatment", "weight": 1, "templateVersion": "1.2.0", "modelId": "model-b", "temperature": 0.2}
+	]},
+	{"id": "old", "templateType": "turn1-previous-vs-current", "disabled": true, "variants": [
+		{"name": "a", "weight": 1}
+	]}
+]`
+
+func TestAssignIsDeterministicAndWeighted(t *testing.T) {
+	registry, err := Parse(definitions)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if registry.Len() != 1 {
+		t.Fatalf("Expected disabled experiments to be skipped, got %d", registry.Len())
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 8000; i++ {
+		id := fmt.Sprintf("verif-%d", i)
+		a, ok := registry.AssignTemplate("turn2-previous-vs-current", id)
+		if !ok {
+			t.Fatal("Expected an assignment")
+		}
+		if again, _ := registry.AssignTemplate("turn2-previous-vs-current", id); again.Variant != a.Variant {
+			t.Fatalf("Assignment of %s changed from %s to %s", id, a.Variant, again.Variant)
+		}
+		counts[a.Variant]++
+	}
+	if share := float64(counts["treatment"]) / 8000; math.Abs(share-0.25) > 0.02 {
+		t.Errorf("Expected about 25%% treatment, got %.3f (%v)", share, counts)
+	}
+
+	if _, ok := registry.AssignTemplate("turn1-previous-vs-current", "verif-1"); ok {
+		t.Error("Expected no assignment for a disabled experiment")
+	}
+	all := registry.AssignAll("verif-1")
+	if len(all) != 1 || all["pvc-v12"].TemplateType != "turn2-previous-vs-current" {
+		t.Errorf("Unexpected assignments %+v", all)
+	}
+}
+
+func TestParseRejectsInvalidDefinitions(t *testing.T) {
+	for _, invalid := range []string{
+		`{"id": "x"}`,
+		`[{"id": "x", "templateType": "turn1", "variants": []}]`,
+		`[{"id": "x", "templateType": "turn1", "variants": [{"name": "a", "weight": 0}]}]`,
+		`[{"id": "x", "templateType": "turn1", "variants": [{"name": "a", "weight": 1}, {"name": "a", "weight": 1}]}]`,
+		`[{"id": "x", "templateType": "turn1", "variants": [{"name": "a", "weight": 1, "temperature": 1.5}]}]`,
+		`[{"id": "x", "templateType": "turn1", "variants": [{"name": "a", "weight": 1}]},
+		  {"id": "y", "templateType": "turn1", "variants": [{"name": "a", "weight": 1}]}]`,
+	} {
+		if _, err := Parse(invalid); err == nil {
+			t.Errorf("Parse(%s) should fail", invalid)
+		}
+	}
+	if registry, err := Parse(""); err != nil || registry.Len() != 0 {
+		t.Errorf("Expected an empty registry, got %v", err)
+	}
+}
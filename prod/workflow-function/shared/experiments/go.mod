@@ -0,0 +1,3 @@
+module workflow-function/shared/experiments
+
+go 1.24.0
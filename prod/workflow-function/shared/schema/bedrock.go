@@ -72,6 +72,34 @@ type TokenUsage struct {
 	TotalTokens   int `json:"totalTokens"`
 }
 
+// ReasoningArtifact is the persisted record of the extended-thinking blocks
+// returned by Bedrock for a single turn. It is stored separately from the raw
+// and processed responses so reasoning can be inspected without re-parsing them.
+type ReasoningArtifact struct {
+	VerificationId  string           `json:"verificationId"`
+	TurnId          int              `json:"turnId"`
+	AnalysisStage   string           `json:"analysisStage,omitempty"`
+	ModelId         string           `json:"modelId,omitempty"`
+	RequestId       string           `json:"requestId,omitempty"`
+	BudgetTokens    int              `json:"budgetTokens,omitempty"`
+	EstimatedTokens int              `json:"estimatedTokens,omitempty"`
+	Blocks          []ReasoningBlock `json:"blocks"`
+	CreatedAt       string           `json:"createdAt"`
+}
+
+// ReasoningBlock is a single reasoning content block. Redacted blocks carry no
+// text; their encrypted payload is not persisted.
+type ReasoningBlock struct {
+	Text      string `json:"text,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	Redacted  bool   `json:"redacted,omitempty"`
+}
+
+// HasContent reports whether the artifact contains at least one reasoning block
+func (r *ReasoningArtifact) HasContent() bool {
+	return r != nil && len(r.Blocks) > 0
+}
+
 // SystemPrompt contains the system prompt configuration
 type SystemPrompt struct {
 	Content       string        `json:"content"`
@@ -37,6 +37,7 @@ const (
 	StatusTurn2PromptReady        = "TURN2_PROMPT_READY"
 	StatusTurn2Completed          = "TURN2_COMPLETED"
 	StatusTurn2Processed          = "TURN2_PROCESSED"
+	StatusTurn3Completed          = "TURN3_COMPLETED"
 	StatusResultsFinalized        = "RESULTS_FINALIZED"
 	StatusResultsStored           = "RESULTS_STORED"
 	StatusCompleted               = "COMPLETED"
@@ -71,9 +72,16 @@ const (
 	StatusTurn2BedrockCompleted   = "TURN2_BEDROCK_COMPLETED"
 	StatusTurn2ResponseProcessing = "TURN2_RESPONSE_PROCESSING"
 
+	// Turn 3 self-verification status (optional stage run after Turn 2)
+	StatusTurn3Started        = "TURN3_STARTED"
+	StatusTurn3PromptPrepared = "TURN3_PROMPT_PREPARED"
+	StatusTurn3BedrockInvoked = "TURN3_BEDROCK_INVOKED"
+	StatusTurn3Skipped        = "TURN3_SKIPPED"
+
 	// Error handling constants (ADD THESE)
 	StatusTurn1Error              = "TURN1_ERROR"
 	StatusTurn2Error              = "TURN2_ERROR"
+	StatusTurn3Error              = "TURN3_ERROR"
 	StatusTemplateProcessingError = "TEMPLATE_PROCESSING_ERROR"
 )
 
@@ -101,3 +109,9 @@ const (
 	TurnStatusCompleted = "COMPLETED"
 	TurnStatusFailed    = "FAILED"
 )
+
+// Layout status constants; layouts stored without a status are active
+const (
+	LayoutStatusActive  = "ACTIVE"
+	LayoutStatusRetired = "RETIRED"
+)
@@ -16,6 +16,9 @@ type VerificationContext struct {
 	LayoutId               int                 `json:"layoutId,omitempty"`
 	LayoutPrefix           string              `json:"layoutPrefix,omitempty"`
 	PreviousVerificationId string              `json:"previousVerificationId,omitempty"`
+	TenantId               string              `json:"tenantId,omitempty"`
+	// Locale selects the localized prompt templates, e.g. "vi"; empty for the default
+	Locale                 string              `json:"locale,omitempty"`
 	ReferenceImageUrl      string              `json:"referenceImageUrl"`
 	CheckingImageUrl       string              `json:"checkingImageUrl"`
 	TurnConfig             *TurnConfig         `json:"turnConfig,omitempty"`
@@ -55,6 +58,8 @@ type TurnTimestamps struct {
 	Turn1Completed string `json:"turn1Completed,omitempty"`
 	Turn2Started   string `json:"turn2Started,omitempty"`
 	Turn2Completed string `json:"turn2Completed,omitempty"`
+	Turn3Started   string `json:"turn3Started,omitempty"`
+	Turn3Completed string `json:"turn3Completed,omitempty"`
 	Completed      string `json:"completed,omitempty"`
 }
 
@@ -160,6 +165,20 @@ type LayoutMetadata struct {
 	SourceJsonUrl      string                 `json:"sourceJsonUrl" dynamodbav:"sourceJsonUrl"`
 	MachineStructure   map[string]interface{} `json:"machineStructure" dynamodbav:"machineStructure"`
 	ProductPositionMap map[string]interface{} `json:"productPositionMap" dynamodbav:"productPositionMap"`
+	// RenderGeometry is the renderer configuration the reference image was
+	// drawn with; layouts rendered before it was stored have none
+	RenderGeometry map[string]interface{} `json:"renderGeometry,omitempty" dynamodbav:"renderGeometry,omitempty"`
+	// MachineModel is the model of the vending machine, when the layout names it
+	MachineModel string `json:"machineModel,omitempty" dynamodbav:"machineModel,omitempty"`
+	// Version history of the vending machine's layouts; EffectiveTo is empty
+	// for the version currently in force
+	Version       int    `json:"version,omitempty" dynamodbav:"version,omitempty"`
+	EffectiveFrom string `json:"effectiveFrom,omitempty" dynamodbav:"effectiveFrom,omitempty"`
+	EffectiveTo   string `json:"effectiveTo,omitempty" dynamodbav:"effectiveTo,omitempty"`
+	// Retired layouts are kept but cannot be used for new verifications
+	LayoutStatus  string `json:"layoutStatus,omitempty" dynamodbav:"layoutStatus,omitempty"`
+	RetiredAt     string `json:"retiredAt,omitempty" dynamodbav:"retiredAt,omitempty"`
+	RetiredReason string `json:"retiredReason,omitempty" dynamodbav:"retiredReason,omitempty"`
 }
 
 // LayoutKey represents a composite key for layout metadata
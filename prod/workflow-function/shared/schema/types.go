@@ -48,6 +48,7 @@ type PromptTemplate struct {
 	TemplateId      string                 `json:"templateId"`
 	TemplateVersion string                 `json:"templateVersion"`
 	TemplateType    string                 `json:"templateType"` // "turn1-layout-vs-checking", etc.
+	Locale          string                 `json:"locale,omitempty"`
 	Content         string                 `json:"content"`
 	Variables       map[string]interface{} `json:"variables,omitempty"`
 	CreatedAt       string                 `json:"createdAt"`
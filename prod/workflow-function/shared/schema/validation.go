@@ -52,6 +52,7 @@ func ValidateVerificationContext(ctx *VerificationContext) Errors {
 			StatusVerificationRequested, StatusVerificationInitialized, StatusFetchingImages,
 			StatusImagesFetched, StatusPromptPrepared, StatusTurn1PromptReady, StatusTurn1Completed,
 			StatusTurn1Processed, StatusTurn2PromptReady, StatusTurn2Completed, StatusTurn2Processed,
+			StatusTurn3Completed,
 			StatusResultsFinalized, StatusResultsStored, StatusCompleted,
 			StatusInitializationFailed, StatusHistoricalFetchFailed, StatusImageFetchFailed,
 			StatusBedrockProcessingFailed, StatusVerificationFailed,
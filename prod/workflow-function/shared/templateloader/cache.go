@@ -35,6 +35,10 @@ type CacheStats struct {
 	LastAccess  time.Time `json:"last_access"`
 	LastSet     time.Time `json:"last_set"`
 	LastCleanup time.Time `json:"last_cleanup"`
+
+	// Shared cache state, see RedisCache
+	Degraded     bool  `json:"degraded,omitempty"`      // served by the fallback cache
+	RemoteErrors int64 `json:"remote_errors,omitempty"` // failed requests to the shared cache
 }
 
 // CacheItem represents a cached template with metadata
@@ -428,6 +432,7 @@ func (mc *MemoryCache) estimateSize(tmpl *template.Template, metadata *TemplateM
 		size += int64(len(metadata.Type))
 		size += int64(len(metadata.Version))
 		size += int64(len(metadata.Path))
+		size += int64(len(metadata.Content))
 		// Add more fields as needed
 	}
 
@@ -520,6 +525,16 @@ func (cf *CacheFactory) CreateCache(config CacheConfig) (TemplateCache, error) {
 			config.Memory.EvictionPolicy,
 			config.DefaultTTL,
 		)
+	case "redis":
+		fallback, err := NewMemoryCache(
+			config.Memory.MaxSize,
+			config.Memory.EvictionPolicy,
+			config.DefaultTTL,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return NewRedisCache(config.Redis, fallback, config.DefaultTTL)
 	case "none", "disabled":
 		return NewNoCache(), nil
 	default:
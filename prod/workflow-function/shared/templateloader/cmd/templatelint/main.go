@@ -0,0 +1,143 @@
+// Command templatelint parses every template under the given directories
+// with the template loader's functions and renders each against fixture data,
+// so template errors surface before deployment instead of inside a
+// verification.
+//
+//	go run ./cmd/templatelint [-fixtures file.json] [-diff] [-json] DIR...
+//
+// It exits with status 1 when a template fails to parse or render, breaks
+// its contract, or is a localized template that drops output markers of the
+// default-locale template.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"workflow-function/shared/templateloader"
+)
+
+func main() {
+	fixturesPath := flag.String("fixtures", "", "JSON file of additional fixtures ([{\"name\", \"templateTypes\", \"data\"}])")
+	showDiff := flag.Bool("diff", false, "print the changed lines between consecutive versions")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: templatelint [flags] DIR...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	fixtures := templateloader.DefaultFixtures()
+	if *fixturesPath != "" {
+		extra, err := loadFixtures(*fixturesPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "templatelint: %v\n", err)
+			os.Exit(2)
+		}
+		fixtures = append(fixtures, extra...)
+	}
+
+	var sources []templateloader.Source
+	for _, dir := range flag.Args() {
+		sources = append(sources, templateloader.NewFilesystemSource(dir))
+	}
+
+	report, err := templateloader.Lint(sources, templateloader.LintOptions{Fixtures: fixtures})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "templatelint: %v\n", err)
+		os.Exit(2)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Fprintf(os.Stderr, "templatelint: %v\n", err)
+			os.Exit(2)
+		}
+	} else {
+		printReport(os.Stdout, report, *showDiff)
+	}
+
+	if report.Failed() {
+		os.Exit(1)
+	}
+}
+
+// loadFixtures reads a JSON array of fixtures
+func loadFixtures(path string) ([]templateloader.Fixture, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures: %w", err)
+	}
+	var fixtures []templateloader.Fixture
+	if err := json.Unmarshal(content, &fixtures); err != nil {
+		return nil, fmt.Errorf("invalid fixtures in %s: %w", path, err)
+	}
+	return fixtures, nil
+}
+
+// printReport prints one line per template version and fixture, followed by
+// the version diffs
+func printReport(w io.Writer, report *templateloader.LintReport, showDiff bool) {
+	failed := 0
+	for _, r := range report.Results {
+		name := r.TemplateType
+		if r.Version != "" {
+			name += " v" + r.Version
+		}
+		if r.Fixture != "" {
+			name += " [" + r.Fixture + "]"
+		}
+
+		switch {
+		case r.Failed():
+			failed++
+			fmt.Fprintf(w, "FAIL %s\n", name)
+		case r.Fixture == "":
+			fmt.Fprintf(w, "ok   %s (no fixture, parsed only)\n", name)
+		default:
+			fmt.Fprintf(w, "ok   %s %d bytes, ~%d tokens\n", name, r.Size, r.EstimatedTokens)
+		}
+		if r.ParseError != "" {
+			fmt.Fprintf(w, "     parse: %s\n", r.ParseError)
+		}
+		if r.ExecError != "" {
+			fmt.Fprintf(w, "     render: %s\n", r.ExecError)
+		}
+		if len(r.UndeclaredFields) > 0 {
+			fmt.Fprintf(w, "     not in contract: %s\n", strings.Join(r.UndeclaredFields, ", "))
+		}
+		if r.ContractError != "" {
+			fmt.Fprintf(w, "     contract: %s\n", r.ContractError)
+		}
+		if len(r.MissingMarkers) > 0 {
+			fmt.Fprintf(w, "     missing markers: %s\n", strings.Join(r.MissingMarkers, ", "))
+		}
+		if r.ParseError == "" && !r.HasContract {
+			fmt.Fprintf(w, "     no contract\n")
+		}
+		if len(r.UnusedFields) > 0 {
+			fmt.Fprintf(w, "     unused: %s\n", strings.Join(r.UnusedFields, ", "))
+		}
+	}
+
+	for _, d := range report.Diffs {
+		fmt.Fprintf(w, "diff %s v%s -> v%s [%s]: +%d -%d lines\n", d.TemplateType, d.From, d.To, d.Fixture, d.Added, d.Removed)
+		if showDiff {
+			for _, line := range d.Lines {
+				fmt.Fprintf(w, "     %s\n", line)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "%d results, %d failed, %d diffs\n", len(report.Results), failed, len(report.Diffs))
+}
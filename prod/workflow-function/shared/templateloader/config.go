@@ -1,6 +1,7 @@
 package templateloader
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -73,7 +74,7 @@ type CacheConfig struct {
 	// Memory cache settings
 	Memory MemoryCacheConfig `yaml:"memory" json:"memory"`
 	
-	// Redis cache settings (for future extension)
+	// Redis cache settings, used when Type is "redis"
 	Redis RedisCacheConfig `yaml:"redis" json:"redis"`
 	
 	// General cache settings
@@ -88,12 +89,30 @@ type MemoryCacheConfig struct {
 	CleanupInterval time.Duration `yaml:"cleanup_interval" json:"cleanup_interval"`
 }
 
-// RedisCacheConfig configures Redis cache (placeholder for future)
+// RedisCacheConfig configures the Redis cache shared by all loaders of an
+// environment. The Memory settings size the fallback cache.
 type RedisCacheConfig struct {
 	Addr string `yaml:"addr" json:"addr"`
 	Password string `yaml:"password" json:"password"`
 	DB int `yaml:"db" json:"db"`
 	KeyPrefix string `yaml:"key_prefix" json:"key_prefix"`
+
+	// Connect over TLS, e.g. to ElastiCache with in-transit encryption. A
+	// password is only sent over TLS.
+	TLS bool `yaml:"tls" json:"tls"`
+
+	// TLS settings such as the root CAs; the server name defaults to the
+	// host of Addr
+	TLSConfig *tls.Config `yaml:"-" json:"-"`
+
+	// Namespace separates environments sharing a Redis, e.g. "dev" or "prod"
+	Namespace string `yaml:"namespace" json:"namespace"`
+
+	// Timeout of each Redis command, including connecting
+	DialTimeout time.Duration `yaml:"dial_timeout" json:"dial_timeout"`
+
+	// How long the memory cache is used after Redis could not be reached
+	RetryInterval time.Duration `yaml:"retry_interval" json:"retry_interval"`
 }
 
 // PerformanceConfig configures performance settings
@@ -264,11 +283,18 @@ func (cm *ConfigManager) applyEnvironmentOverrides(config *AdvancedConfig) error
 
 	// Apply standard environment overrides
 	standardOverrides := map[string]string{
-		"TEMPLATE_BASE_PATH":     "Config.BasePath",
-		"TEMPLATE_CACHE_ENABLED": "Config.CacheEnabled",
-		"TEMPLATE_CACHE_SIZE":    "CacheConfig.Memory.MaxSize",
-		"TEMPLATE_CACHE_TTL":     "CacheConfig.TTL",
-		"TEMPLATE_LOG_LEVEL":     "Logging.Level",
+		"TEMPLATE_BASE_PATH":       "Config.BasePath",
+		"TEMPLATE_CACHE_ENABLED":   "Config.CacheEnabled",
+		"TEMPLATE_CACHE_SIZE":      "CacheConfig.Memory.MaxSize",
+		"TEMPLATE_CACHE_TTL":       "CacheConfig.TTL",
+		"TEMPLATE_CACHE_TYPE":      "CacheConfig.Type",
+		"TEMPLATE_REDIS_ADDR":      "CacheConfig.Redis.Addr",
+		"TEMPLATE_REDIS_PASSWORD":  "CacheConfig.Redis.Password",
+		"TEMPLATE_REDIS_TLS":       "CacheConfig.Redis.TLS",
+		"TEMPLATE_REDIS_NAMESPACE": "CacheConfig.Redis.Namespace",
+		"TEMPLATE_LOG_LEVEL":       "Logging.Level",
+		"TEMPLATE_WATCH_ENABLED":   "Discovery.WatchEnabled",
+		"TEMPLATE_WATCH_INTERVAL":  "Discovery.WatchInterval",
 	}
 
 	for envVar, configPath := range standardOverrides {
@@ -309,6 +335,25 @@ func (cm *ConfigManager) setConfigValue(config *AdvancedConfig, path, value stri
 					return err
 				}
 				config.CacheConfig.TTL = duration
+			case "Type":
+				config.CacheConfig.Type = value
+			case "Redis":
+				if len(parts) >= 3 {
+					switch parts[2] {
+					case "Addr":
+						config.CacheConfig.Redis.Addr = value
+					case "Password":
+						config.CacheConfig.Redis.Password = value
+					case "TLS":
+						enabled, err := strconv.ParseBool(value)
+						if err != nil {
+							return err
+						}
+						config.CacheConfig.Redis.TLS = enabled
+					case "Namespace":
+						config.CacheConfig.Redis.Namespace = value
+					}
+				}
 			case "Memory":
 				if len(parts) >= 3 && parts[2] == "MaxSize" {
 					size, err := strconv.Atoi(value)
@@ -319,6 +364,23 @@ func (cm *ConfigManager) setConfigValue(config *AdvancedConfig, path, value stri
 				}
 			}
 		}
+	case "Discovery":
+		if len(parts) >= 2 {
+			switch parts[1] {
+			case "WatchEnabled":
+				enabled, err := strconv.ParseBool(value)
+				if err != nil {
+					return err
+				}
+				config.Discovery.WatchEnabled = enabled
+			case "WatchInterval":
+				interval, err := time.ParseDuration(value)
+				if err != nil {
+					return err
+				}
+				config.Discovery.WatchInterval = interval
+			}
+		}
 	case "Logging":
 		if len(parts) >= 2 && parts[1] == "Level" {
 			config.Logging.Level = value
@@ -365,6 +427,36 @@ func NewConfigValidator() *ConfigValidator {
 					return nil
 				},
 			},
+			{
+				Name:        "redis_addr_set",
+				Description: "Redis cache needs an address",
+				Required:    true,
+				Validator: func(v interface{}) error {
+					config, ok := v.(*AdvancedConfig)
+					if !ok {
+						return fmt.Errorf("invalid config type")
+					}
+					if strings.EqualFold(config.CacheConfig.Type, "redis") && config.CacheConfig.Redis.Addr == "" {
+						return fmt.Errorf("redis cache address cannot be empty")
+					}
+					return nil
+				},
+			},
+			{
+				Name:        "redis_password_tls",
+				Description: "Redis password is only sent over TLS",
+				Required:    true,
+				Validator: func(v interface{}) error {
+					config, ok := v.(*AdvancedConfig)
+					if !ok {
+						return fmt.Errorf("invalid config type")
+					}
+					if strings.EqualFold(config.CacheConfig.Type, "redis") && config.CacheConfig.Redis.Password != "" && !config.CacheConfig.Redis.TLS {
+						return fmt.Errorf("redis password requires tls")
+					}
+					return nil
+				},
+			},
 			{
 				Name:        "log_level_valid",
 				Description: "Log level must be valid",
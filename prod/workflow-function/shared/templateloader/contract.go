@@ -0,0 +1,371 @@
+package templateloader
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// contractComment matches the {{/* contract ... */}} header of a template
+var contractComment = regexp.MustCompile(`(?s)\{\{-?\s*/\*\s*contract\b(.*?)\*/\s*-?\}\}`)
+
+// FieldType is the type a contract requires of a data field
+type FieldType string
+
+const (
+	FieldString FieldType = "string"
+	FieldInt    FieldType = "int"
+	FieldNumber FieldType = "number"
+	FieldBool   FieldType = "bool"
+	FieldList   FieldType = "list"
+	FieldMap    FieldType = "map"
+	FieldAny    FieldType = "any"
+)
+
+// ContractField is a data field a template version reads. Path is a dotted
+// field path such as MachineStructure.RowCount.
+type ContractField struct {
+	Path     string    `json:"path"`
+	Type     FieldType `json:"type"`
+	Required bool      `json:"required"`
+}
+
+// Contract declares the data fields a template version reads. It is the
+// YAML header of the template:
+//
+//	{{/* contract
+//	requires:
+//	  RowCount: int
+//	  RowLabels: list
+//	optional:
+//	  Location: string
+//	*/ -}}
+//
+// A deprecation comment, if any, must precede the contract.
+type Contract struct {
+	// Fields sorted by path
+	Fields []ContractField `json:"fields"`
+}
+
+// ContractError lists the contract violations of template data
+type ContractError struct {
+	Violations []string `json:"violations"`
+}
+
+// Error implements the error interface
+func (ce *ContractError) Error() string {
+	return "template data violates contract: " + strings.Join(ce.Violations, "; ")
+}
+
+// ParseContract reads the contract header of template content. Templates
+// without a header have no contract and yield nil.
+func ParseContract(content []byte) (*Contract, error) {
+	match := contractComment.FindSubmatch(content)
+	if match == nil {
+		return nil, nil
+	}
+
+	var header struct {
+		Requires map[string]string `yaml:"requires"`
+		Optional map[string]string `yaml:"optional"`
+	}
+	if err := yaml.Unmarshal(match[1], &header); err != nil {
+		return nil, fmt.Errorf("invalid contract: %w", err)
+	}
+
+	contract := &Contract{}
+	for _, group := range []struct {
+		fields   map[string]string
+		required bool
+	}{{header.Requires, true}, {header.Optional, false}} {
+		for path, typeName := range group.fields {
+			fieldType := FieldType(strings.ToLower(strings.TrimSpace(typeName)))
+			switch fieldType {
+			case FieldString, FieldInt, FieldNumber, FieldBool, FieldList, FieldMap, FieldAny:
+			default:
+				return nil, fmt.Errorf("invalid contract: field %s has unknown type %q", path, typeName)
+			}
+			if _, exists := contract.Field(path); exists {
+				return nil, fmt.Errorf("invalid contract: field %s is both required and optional", path)
+			}
+			contract.Fields = append(contract.Fields, ContractField{Path: path, Type: fieldType, Required: group.required})
+		}
+	}
+	sort.Slice(contract.Fields, func(i, j int) bool { return contract.Fields[i].Path < contract.Fields[j].Path })
+	return contract, nil
+}
+
+// Field returns the contract field of a path
+func (c *Contract) Field(path string) (ContractField, bool) {
+	for _, field := range c.Fields {
+		if field.Path == path {
+			return field, true
+		}
+	}
+	return ContractField{}, false
+}
+
+// Declares reports whether the contract declares a top-level field, either
+// itself or through a nested path such as MachineStructure.RowCount
+func (c *Contract) Declares(name string) bool {
+	for _, field := range c.Fields {
+		if field.Path == name || strings.HasPrefix(field.Path, name+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks data, a map or struct as passed to the template, supplies
+// every required field and that the supplied fields have the declared types.
+// Nil pointers and interfaces count as missing.
+func (c *Contract) Validate(data interface{}) error {
+	var violations []string
+	for _, field := range c.Fields {
+		value, ok := lookupPath(data, field.Path)
+		if !ok {
+			if field.Required {
+				violations = append(violations, fmt.Sprintf("missing required field %s (%s)", field.Path, field.Type))
+			}
+			continue
+		}
+		if !hasType(value, field.Type) {
+			violations = append(violations, fmt.Sprintf("field %s is %s, want %s", field.Path, value.Type(), field.Type))
+		}
+	}
+	if len(violations) > 0 {
+		return &ContractError{Violations: violations}
+	}
+	return nil
+}
+
+// Bind validates data against the contract and returns typed accessors of
+// its fields
+func (c *Contract) Bind(data interface{}) (*Values, error) {
+	if err := c.Validate(data); err != nil {
+		return nil, err
+	}
+	return &Values{data: data}, nil
+}
+
+// Values gives typed access to the fields of template data. Accessors return
+// the zero value for fields that are missing or of another type.
+type Values struct {
+	data interface{}
+}
+
+// Has reports whether the data supplies a field
+func (v *Values) Has(path string) bool {
+	_, ok := lookupPath(v.data, path)
+	return ok
+}
+
+// String returns a string field
+func (v *Values) String(path string) string {
+	if value, ok := lookupPath(v.data, path); ok && value.Kind() == reflect.String {
+		return value.String()
+	}
+	return ""
+}
+
+// Int returns an integer field, or a number field with an integral value
+func (v *Values) Int(path string) int {
+	value, ok := lookupPath(v.data, path)
+	if !ok || !hasType(value, FieldInt) {
+		return 0
+	}
+	return int(toFloat(value))
+}
+
+// Float returns a number field
+func (v *Values) Float(path string) float64 {
+	value, ok := lookupPath(v.data, path)
+	if !ok || !hasType(value, FieldNumber) {
+		return 0
+	}
+	return toFloat(value)
+}
+
+// Bool returns a boolean field
+func (v *Values) Bool(path string) bool {
+	if value, ok := lookupPath(v.data, path); ok && value.Kind() == reflect.Bool {
+		return value.Bool()
+	}
+	return false
+}
+
+// Strings returns the string elements of a list field
+func (v *Values) Strings(path string) []string {
+	value, ok := lookupPath(v.data, path)
+	if !ok || !hasType(value, FieldList) {
+		return nil
+	}
+	out := make([]string, 0, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		if elem := indirect(value.Index(i)); elem.IsValid() && elem.Kind() == reflect.String {
+			out = append(out, elem.String())
+		}
+	}
+	return out
+}
+
+// lookupPath resolves a dotted path through maps keyed by strings and
+// exported struct fields, as template field access does
+func lookupPath(data interface{}, path string) (reflect.Value, bool) {
+	value := reflect.ValueOf(data)
+	for _, name := range strings.Split(path, ".") {
+		value = indirect(value)
+		if !value.IsValid() {
+			return reflect.Value{}, false
+		}
+		switch value.Kind() {
+		case reflect.Map:
+			if value.Type().Key().Kind() != reflect.String {
+				return reflect.Value{}, false
+			}
+			value = value.MapIndex(reflect.ValueOf(name).Convert(value.Type().Key()))
+		case reflect.Struct:
+			field, ok := value.Type().FieldByName(name)
+			if !ok || !field.IsExported() {
+				return reflect.Value{}, false
+			}
+			value = value.FieldByIndex(field.Index)
+		default:
+			return reflect.Value{}, false
+		}
+	}
+	value = indirect(value)
+	return value, value.IsValid()
+}
+
+// indirect dereferences pointers and interfaces; nil ones yield an invalid
+// value
+func indirect(value reflect.Value) reflect.Value {
+	for value.IsValid() && (value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface) {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+// hasType reports whether a value has a contract field type. Numbers decoded
+// from JSON are floats, so integral floats satisfy int.
+func hasType(value reflect.Value, fieldType FieldType) bool {
+	switch fieldType {
+	case FieldString:
+		return value.Kind() == reflect.String
+	case FieldInt:
+		switch value.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return true
+		case reflect.Float32, reflect.Float64:
+			return value.Float() == float64(int64(value.Float()))
+		}
+		return false
+	case FieldNumber:
+		switch value.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return true
+		}
+		return false
+	case FieldBool:
+		return value.Kind() == reflect.Bool
+	case FieldList:
+		return value.Kind() == reflect.Slice || value.Kind() == reflect.Array
+	case FieldMap:
+		return value.Kind() == reflect.Map || value.Kind() == reflect.Struct
+	default:
+		return true
+	}
+}
+
+// toFloat converts a numeric value to float64
+func toFloat(value reflect.Value) float64 {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		return value.Float()
+	}
+	return 0
+}
+
+// Contract returns the contract of a template version, nil for templates
+// without one
+func (l *Loader) Contract(templateType, version string) (*Contract, error) {
+	_, meta, err := l.load(templateType, version)
+	if err != nil {
+		return nil, err
+	}
+	return l.contractOf(meta)
+}
+
+// ValidateData checks data against the contract of a template version, for
+// callers executing the template returned by LoadTemplateWithVersion
+// themselves. RenderTemplateWithVersion validates the data it renders.
+func (l *Loader) ValidateData(templateType, version string, data interface{}) error {
+	_, meta, err := l.load(templateType, version)
+	if err != nil {
+		return err
+	}
+	return l.validateData(meta, data)
+}
+
+// validateData checks data against the contract of loaded template content
+func (l *Loader) validateData(meta *TemplateMetadata, data interface{}) error {
+	contract, err := l.contractOf(meta)
+	if err != nil || contract == nil {
+		return err
+	}
+	if err := contract.Validate(data); err != nil {
+		return &TemplateError{
+			Type:         ErrorTypeValidation,
+			Message:      err.Error(),
+			TemplateType: meta.Type,
+			Version:      meta.Version,
+			Path:         meta.Path,
+			Cause:        err,
+		}
+	}
+	return nil
+}
+
+// contractOf parses the contract of loaded template content once per
+// content checksum. Metadata without content, as custom caches may return,
+// has no contract.
+func (l *Loader) contractOf(meta *TemplateMetadata) (*Contract, error) {
+	if meta == nil || meta.Content == "" {
+		return nil, nil
+	}
+	checksum := meta.Checksum
+	if checksum == "" {
+		checksum = contentChecksum(meta.Content)
+	}
+
+	l.mu.RLock()
+	contract, ok := l.contracts[checksum]
+	l.mu.RUnlock()
+	if ok {
+		return contract, nil
+	}
+
+	contract, err := ParseContract([]byte(meta.Content))
+	if err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	l.contracts[checksum] = contract
+	l.mu.Unlock()
+	return contract, nil
+}
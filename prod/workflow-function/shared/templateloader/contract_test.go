@@ -0,0 +1,209 @@
+package templateloader
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const gridContract = `{{/* contract
+requires:
+  RowCount: int
+  RowLabels: list
+  Machine.Columns: int
+optional:
+  Location: string
+*/ -}}
+`
+
+func TestParseContract(t *testing.T) {
+	contract, err := ParseContract([]byte(gridContract + "{{.RowCount}}"))
+	if err != nil {
+		t.Fatalf("ParseContract failed: %v", err)
+	}
+	want := []ContractField{
+		{Path: "Location", Type: FieldString},
+		{Path: "Machine.Columns", Type: FieldInt, Required: true},
+		{Path: "RowCount", Type: FieldInt, Required: true},
+		{Path: "RowLabels", Type: FieldList, Required: true},
+	}
+	if !reflect.DeepEqual(contract.Fields, want) {
+		t.Errorf("Expected fields %+v, got %+v", want, contract.Fields)
+	}
+	if !contract.Declares("Machine") || contract.Declares("Mach") {
+		t.Error("Expected Machine to be declared through Machine.Columns only")
+	}
+
+	if contract, err := ParseContract([]byte("{{.RowCount}}")); contract != nil || err != nil {
+		t.Errorf("Expected no contract, got %+v, %v", contract, err)
+	}
+	for _, header := range []string{
+		"{{/* contract\nrequires:\n  RowCount: integer\n*/}}",
+		"{{/* contract\nrequires:\n  RowCount: int\noptional:\n  RowCount: int\n*/}}",
+		"{{/* contract\nrequires: [RowCount]\n*/}}",
+	} {
+		if _, err := ParseContract([]byte(header)); err == nil {
+			t.Errorf("Expected %q to be invalid", header)
+		}
+	}
+}
+
+func TestContractValidate(t *testing.T) {
+	contract, err := ParseContract([]byte(gridContract))
+	if err != nil {
+		t.Fatalf("ParseContract failed: %v", err)
+	}
+
+	type machine struct{ Columns int }
+	type data struct {
+		RowCount  int
+		RowLabels []string
+		Machine   *machine
+		Location  string
+	}
+	if err := contract.Validate(&data{RowCount: 2, RowLabels: []string{"A", "B"}, Machine: &machine{10}}); err != nil {
+		t.Errorf("Expected struct data to satisfy the contract, got %v", err)
+	}
+
+	// Numbers decoded from JSON are floats
+	jsonData := map[string]interface{}{
+		"RowCount":  2.0,
+		"RowLabels": []interface{}{"A", "B"},
+		"Machine":   map[string]interface{}{"Columns": 10.0},
+	}
+	if err := contract.Validate(jsonData); err != nil {
+		t.Errorf("Expected JSON data to satisfy the contract, got %v", err)
+	}
+
+	err = contract.Validate(map[string]interface{}{"RowCount": 2.5, "RowLabels": "A, B", "Location": 3})
+	var contractErr *ContractError
+	if !errors.As(err, &contractErr) {
+		t.Fatalf("Expected a ContractError, got %v", err)
+	}
+	want := []string{
+		"field Location is int, want string",
+		"missing required field Machine.Columns (int)",
+		"field RowCount is float64, want int",
+		"field RowLabels is string, want list",
+	}
+	if !reflect.DeepEqual(contractErr.Violations, want) {
+		t.Errorf("Expected violations %q, got %q", want, contractErr.Violations)
+	}
+
+	if err := contract.Validate(&data{RowCount: 2, RowLabels: []string{"A"}}); err == nil {
+		t.Error("Expected a nil Machine to count as missing")
+	}
+}
+
+func TestContractBindValues(t *testing.T) {
+	contract, err := ParseContract([]byte(gridContract))
+	if err != nil {
+		t.Fatalf("ParseContract failed: %v", err)
+	}
+	values, err := contract.Bind(map[string]interface{}{
+		"RowCount":  6.0,
+		"RowLabels": []interface{}{"A", "B", 3},
+		"Machine":   map[string]interface{}{"Columns": 10},
+		"Location":  "Building A",
+	})
+	if err != nil {
+		t.Fatalf("Bind failed: %v", err)
+	}
+	if values.Int("RowCount") != 6 || values.Int("Machine.Columns") != 10 || values.Float("RowCount") != 6 {
+		t.Error("Unexpected numeric values")
+	}
+	if got := values.Strings("RowLabels"); !reflect.DeepEqual(got, []string{"A", "B"}) {
+		t.Errorf("Expected string elements of RowLabels, got %v", got)
+	}
+	if values.String("Location") != "Building A" || values.String("RowCount") != "" {
+		t.Error("Unexpected string values")
+	}
+	if values.Has("Machine.Rows") || values.Bool("Cropped") {
+		t.Error("Expected missing fields to be absent and zero-valued")
+	}
+
+	if _, err := contract.Bind(map[string]interface{}{"RowCount": 6}); err == nil {
+		t.Error("Expected Bind to reject data violating the contract")
+	}
+}
+
+func TestLoaderValidatesDataBeforeRendering(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, filepath.Join(dir, "grid", "v1.0.0.tmpl"), gridContract+"{{.RowCount}} rows", 0)
+	writeTemplate(t, filepath.Join(dir, "plain", "v1.0.0.tmpl"), "{{.RowCount}} rows", 0)
+	writeTemplate(t, filepath.Join(dir, "broken", "v1.0.0.tmpl"), "{{/* contract\nrequires: [RowCount]\n*/}}", 0)
+
+	loader, err := New(Config{BasePath: dir, CacheEnabled: true})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	valid := map[string]interface{}{"RowCount": 6, "RowLabels": []string{"A"}, "Machine": map[string]interface{}{"Columns": 10}}
+	for i := 0; i < 2; i++ {
+		out, err := loader.RenderTemplateWithVersion("grid", "1.0.0", valid)
+		if err != nil || out != "6 rows" {
+			t.Fatalf("Expected the contract header to render nothing, got %q, %v", out, err)
+		}
+	}
+
+	_, err = loader.RenderTemplateWithVersion("grid", "1.0.0", map[string]interface{}{"RowCount": 6})
+	var templateErr *TemplateError
+	var contractErr *ContractError
+	if !errors.As(err, &templateErr) || templateErr.Type != ErrorTypeValidation || !errors.As(err, &contractErr) {
+		t.Fatalf("Expected a validation error wrapping a ContractError, got %v", err)
+	}
+	if len(contractErr.Violations) != 2 {
+		t.Errorf("Expected two violations, got %q", contractErr.Violations)
+	}
+	if err := loader.ValidateData("grid", "1.0.0", valid); err != nil {
+		t.Errorf("ValidateData failed: %v", err)
+	}
+
+	if contract, err := loader.Contract("plain", "1.0.0"); contract != nil || err != nil {
+		t.Errorf("Expected no contract, got %+v, %v", contract, err)
+	}
+	if out, err := loader.RenderTemplateWithVersion("plain", "1.0.0", map[string]interface{}{"RowCount": 6}); err != nil || out != "6 rows" {
+		t.Errorf("Expected templates without contract to render, got %q, %v", out, err)
+	}
+
+	if _, err := loader.LoadTemplateWithVersion("broken", "1.0.0"); !errors.As(err, &templateErr) || templateErr.Type != ErrorTypeParsing {
+		t.Errorf("Expected an invalid contract to fail loading, got %v", err)
+	}
+}
+
+func TestLintChecksContracts(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, filepath.Join(dir, "grid", "v1.0.0.tmpl"),
+		gridContract+"{{.RowCount}} {{range .RowLabels}}{{.}}{{end}} {{with .Machine}}{{.Columns}}{{end}}", 0)
+	writeTemplate(t, filepath.Join(dir, "grid", "v1.1.0.tmpl"),
+		gridContract+"{{.RowCount}} {{range .RowLabels}}{{$.Sizes}}{{end}} {{.Total}}", 0)
+
+	report, err := Lint([]Source{NewFilesystemSource(dir)}, LintOptions{Fixtures: []Fixture{
+		{Name: "grid", TemplateTypes: []string{"grid"}, Data: map[string]interface{}{
+			"RowCount": 6, "RowLabels": []string{"A"}, "Machine": map[string]interface{}{"Columns": 10},
+			"Sizes": 1, "Total": 60,
+		}},
+		{Name: "no-machine", TemplateTypes: []string{"grid"}, Data: map[string]interface{}{
+			"RowCount": 6, "RowLabels": []string{"A"}, "Machine": map[string]interface{}{}, "Sizes": 1, "Total": 60,
+		}},
+	}})
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+
+	results := make(map[string]LintResult)
+	for _, r := range report.Results {
+		results[r.Version+"@"+r.Fixture] = r
+	}
+	if r := results["1.0.0@grid"]; r.Failed() || !r.HasContract {
+		t.Errorf("Expected a passing result with contract, got %+v", r)
+	}
+	if r := results["1.0.0@no-machine"]; !strings.Contains(r.ContractError, "missing required field Machine.Columns") {
+		t.Errorf("Expected the fixture to violate the contract, got %+v", r)
+	}
+	if r := results["1.1.0@grid"]; !reflect.DeepEqual(r.UndeclaredFields, []string{"Sizes", "Total"}) || !r.Failed() {
+		t.Errorf("Expected Sizes and Total to be undeclared, got %+v", r)
+	}
+}
@@ -0,0 +1,216 @@
+package templateloader
+
+// DefaultFixtures returns the data the workflow functions render their
+// templates with, for layout-vs-checking and previous-vs-current
+// verifications with and without historical data. Every fixture carries all
+// fields the function sets, zero-valued where the function leaves them
+// unset, as the PrepareSystemPrompt TemplateData struct and the turn
+// functions' template contexts do.
+func DefaultFixtures() []Fixture {
+	return []Fixture{
+		{
+			Name:          "layout-vs-checking",
+			TemplateTypes: []string{"layout-vs-checking"},
+			Data:          systemFixtureData(true, false),
+		},
+		{
+			Name:          "previous-vs-current",
+			TemplateTypes: []string{"previous-vs-current"},
+			Data:          systemFixtureData(false, true),
+		},
+		{
+			Name:          "previous-vs-current-no-history",
+			TemplateTypes: []string{"previous-vs-current"},
+			Data:          systemFixtureData(false, false),
+		},
+		{
+			Name:          "turn1-layout-vs-checking",
+			TemplateTypes: []string{"turn1-layout-vs-checking"},
+			Data:          turnFixtureData(1, "LAYOUT_VS_CHECKING", false),
+		},
+		{
+			Name:          "turn1-previous-vs-current",
+			TemplateTypes: []string{"turn1-previous-vs-current"},
+			Data:          turnFixtureData(1, "PREVIOUS_VS_CURRENT", true),
+		},
+		{
+			Name:          "turn1-previous-vs-current-no-history",
+			TemplateTypes: []string{"turn1-previous-vs-current"},
+			Data:          turnFixtureData(1, "PREVIOUS_VS_CURRENT", false),
+		},
+		{
+			Name:          "turn2-layout-vs-checking",
+			TemplateTypes: []string{TemplateTypeTurn2LayoutVsChecking},
+			Data:          turnFixtureData(2, "LAYOUT_VS_CHECKING", false),
+		},
+		{
+			Name:          "turn2-previous-vs-current",
+			TemplateTypes: []string{TemplateTypeTurn2PreviousVsCurrent},
+			Data:          turnFixtureData(2, "PREVIOUS_VS_CURRENT", false),
+		},
+		{
+			Name:          "turn3-self-verification",
+			TemplateTypes: []string{TemplateTypeTurn3SelfVerification},
+			Data:          turn3FixtureData(true),
+		},
+		{
+			Name:          "turn3-self-verification-uncropped",
+			TemplateTypes: []string{TemplateTypeTurn3SelfVerification},
+			Data:          turn3FixtureData(false),
+		},
+	}
+}
+
+// fixtureMachineStructure is the machine structure of the fixtures, a
+// 6-row machine with 10 slots per row
+func fixtureMachineStructure() map[string]interface{} {
+	return map[string]interface{}{
+		"RowCount":      6,
+		"ColumnsPerRow": 10,
+		"RowOrder":      []string{"A", "B", "C", "D", "E", "F"},
+		"ColumnOrder":   []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"},
+	}
+}
+
+// systemFixtureData mirrors the PrepareSystemPrompt TemplateData of a
+// verification with layout metadata or with historical data
+func systemFixtureData(layout, history bool) map[string]interface{} {
+	data := map[string]interface{}{
+		"VerificationType":           "PREVIOUS_VS_CURRENT",
+		"VerificationID":             "verif-20251018093000-a1b2",
+		"VerificationAt":             "2025-10-18T09:30:00Z",
+		"VendingMachineID":           "VM-3245",
+		"Location":                   "",
+		"MachineStructure":           nil,
+		"RowCount":                   0,
+		"ColumnCount":                0,
+		"RowLabels":                  "",
+		"ColumnLabels":               "",
+		"TotalPositions":             0,
+		"ProductMappings":            nil,
+		"PreviousVerificationID":     "",
+		"PreviousVerificationAt":     "",
+		"PreviousVerificationStatus": "",
+		"HoursSinceLastVerification": 0.0,
+		"VerificationSummary":        nil,
+	}
+
+	if layout || history {
+		data["MachineStructure"] = fixtureMachineStructure()
+		data["RowCount"] = 6
+		data["ColumnCount"] = 10
+		data["RowLabels"] = "A, B, C, D, E, F"
+		data["ColumnLabels"] = "1, 2, 3, 4, 5, 6, 7, 8, 9, 10"
+		data["TotalPositions"] = 60
+	}
+
+	if layout {
+		data["VerificationType"] = "LAYOUT_VS_CHECKING"
+		data["Location"] = "Building A, Floor 2"
+		data["ProductMappings"] = []map[string]interface{}{
+			{"Position": "A01", "ProductID": 3486, "ProductName": "Mì Hảo Hảo Tôm Chua Cay"},
+			{"Position": "A02", "ProductID": 3486, "ProductName": "Mì Hảo Hảo Tôm Chua Cay"},
+			{"Position": "B01", "ProductID": 3487, "ProductName": "Nước Tăng Lực Red Bull"},
+		}
+	}
+
+	if history {
+		data["PreviousVerificationID"] = "verif-20251017093000-c3d4"
+		data["PreviousVerificationAt"] = "2025-10-17T09:30:00Z"
+		data["PreviousVerificationStatus"] = "INCORRECT"
+		data["HoursSinceLastVerification"] = 24.0
+		data["VerificationSummary"] = fixtureVerificationSummary()
+	}
+
+	return data
+}
+
+// fixtureVerificationSummary is the summary of the previous verification
+func fixtureVerificationSummary() map[string]interface{} {
+	return map[string]interface{}{
+		"TotalPositionsChecked": 60,
+		"CorrectPositions":      57,
+		"DiscrepantPositions":   3,
+		"MissingProducts":       2,
+		"IncorrectProductTypes": 1,
+		"UnexpectedProducts":    0,
+		"EmptyPositionsCount":   4,
+		"OverallAccuracy":       95.0,
+		"OverallConfidence":     92.5,
+		"VerificationStatus":    "INCORRECT",
+		"VerificationOutcome":   "Discrepancies Detected",
+	}
+}
+
+// turnFixtureData mirrors the template context of the Turn 1 and Turn 2
+// prompt services. Historical data is flattened into the Turn 1 context
+// only.
+func turnFixtureData(turn int, verificationType string, history bool) map[string]interface{} {
+	data := map[string]interface{}{
+		"VerificationType": verificationType,
+		"SystemPrompt":     "You are a vending machine verification assistant.",
+		"VendingMachineId": "VM-3245",
+		"VendingMachineID": "VM-3245",
+		"TemplateVersion":  "1.0",
+		"CreatedAt":        "2025-10-18T09:30:05Z",
+	}
+
+	if turn == 2 {
+		data["VerificationContext"] = map[string]interface{}{
+			"VerificationId":   "verif-20251018093000-a1b2",
+			"VerificationAt":   "2025-10-18T09:30:00Z",
+			"VerificationType": verificationType,
+			"VendingMachineId": "VM-3245",
+		}
+		data["Turn1Response"] = map[string]interface{}{
+			"turnId":   1,
+			"response": map[string]interface{}{"content": "ROW STATUS ANALYSIS (Reference Image): ..."},
+		}
+	}
+
+	if verificationType == "LAYOUT_VS_CHECKING" {
+		layoutMetadata := fixtureMachineStructure()
+		layoutMetadata["Location"] = "Building A, Floor 2"
+		data["RowCount"] = 6
+		data["ColumnCount"] = 10
+		data["RowLabels"] = []string{"A", "B", "C", "D", "E", "F"}
+		data["LayoutId"] = 23591
+		data["LayoutPrefix"] = "kvg2"
+		data["LayoutMetadata"] = layoutMetadata
+		data["Location"] = "Building A, Floor 2"
+	}
+
+	if history {
+		summary := fixtureVerificationSummary()
+		data["PreviousVerificationId"] = "verif-20251017093000-c3d4"
+		data["PreviousVerificationAt"] = "2025-10-17T09:30:00Z"
+		data["PreviousVerificationStatus"] = "INCORRECT"
+		data["HoursSinceLastVerification"] = 24.0
+		data["VerificationSummary"] = summary
+	}
+
+	return data
+}
+
+// turn3FixtureData mirrors the template data of the Turn 3 self-verification
+// turn, with the checking image cropped to the discrepant rows or whole
+func turn3FixtureData(cropped bool) map[string]interface{} {
+	discrepancies := []map[string]interface{}{
+		{"Item": "A03", "Expected": "Mì Hảo Hảo Tôm Chua Cay", "Found": "", "Type": "Missing Product"},
+		{"Item": "B01", "Expected": "Nước Tăng Lực Red Bull", "Found": "Coca-Cola", "Type": "Incorrect Product Type"},
+	}
+	var cropRows []string
+	if cropped {
+		cropRows = []string{"A", "B"}
+	} else {
+		discrepancies = discrepancies[:1]
+	}
+	return map[string]interface{}{
+		"VerificationType": "LAYOUT_VS_CHECKING",
+		"VendingMachineId": "VM-3245",
+		"Discrepancies":    discrepancies,
+		"Cropped":          cropped,
+		"CropRows":         cropRows,
+		"TemplateVersion":  "1.0",
+	}
+}
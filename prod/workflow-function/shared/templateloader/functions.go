@@ -53,6 +53,14 @@ var DefaultFunctions = template.FuncMap{
 	// Machine structure functions
 	"lastRowLabel":  lastRowLabelFunction,
 	"maxSlotNumber": maxSlotNumberFunction,
+
+	// Machine grid functions, see Grid
+	"grid":              NewGrid,
+	"positionID":        PositionID,
+	"normalizePosition": NormalizePosition,
+	"planogramTable":    planogramTableFunction,
+	"rowProducts":       rowProductsFunction,
+	"checklist":         Checklist,
 }
 
 // indexFunction safely gets an element from a slice
@@ -250,7 +258,8 @@ func PadRightFunction(s string, length int) string {
 	return s + padding
 }
 
-// lastRowLabelFunction returns the last row label based on row count
+// lastRowLabelFunction returns the last row label of a machine structure or
+// grid, or based on row count
 // For PREVIOUS_VS_CURRENT templates, this provides a default structure
 func lastRowLabelFunction(rowCount ...interface{}) string {
 	// Default to 5 rows (A-E) if no row count provided
@@ -260,6 +269,8 @@ func lastRowLabelFunction(rowCount ...interface{}) string {
 	if len(rowCount) > 0 {
 		if rc, ok := rowCount[0].(int); ok && rc > 0 {
 			count = rc
+		} else if g := NewGrid(rowCount[0]); g != nil {
+			return g.LastRow()
 		}
 	}
 
@@ -274,7 +285,8 @@ func lastRowLabelFunction(rowCount ...interface{}) string {
 	return string(rune('A' + count - 1))
 }
 
-// maxSlotNumberFunction returns the maximum slot number based on column count
+// maxSlotNumberFunction returns the maximum slot number of a machine
+// structure or grid, or based on column count
 // For PREVIOUS_VS_CURRENT templates, this provides a default structure
 func maxSlotNumberFunction(columnCount ...interface{}) string {
 	// Default to 8 slots (01-08) if no column count provided
@@ -284,6 +296,8 @@ func maxSlotNumberFunction(columnCount ...interface{}) string {
 	if len(columnCount) > 0 {
 		if cc, ok := columnCount[0].(int); ok && cc > 0 {
 			count = cc
+		} else if g := NewGrid(columnCount[0]); g != nil {
+			return g.LastSlot()
 		}
 	}
 
@@ -291,6 +305,16 @@ func maxSlotNumberFunction(columnCount ...interface{}) string {
 	return fmt.Sprintf("%02d", count)
 }
 
+// planogramTableFunction renders the planned products of a grid as a table
+func planogramTableFunction(g *Grid) string {
+	return g.PlanogramTable()
+}
+
+// rowProductsFunction lists the planned products of a grid row
+func rowProductsFunction(row GridRow) string {
+	return row.ProductList()
+}
+
 // RegisterExtraFunctions adds additional convenience functions to the default set
 func RegisterExtraFunctions(funcMap template.FuncMap) {
 	funcMap["capitalize"] = CapitalizeFunction
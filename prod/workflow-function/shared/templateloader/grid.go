@@ -0,0 +1,465 @@
+package templateloader
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Grid is the row and slot layout of a vending machine, built from its
+// machine structure and, when given, the product planned for each position.
+// Templates build it with the grid function and range over its rows to
+// generate the output skeleton of the actual machine instead of an example.
+type Grid struct {
+	// Rows in physical order, top to bottom
+	Rows []GridRow
+	// Slots are the two-digit slot numbers of the widest row, left to right
+	Slots []string
+
+	hasProducts bool
+}
+
+// GridRow is one physical row of a Grid
+type GridRow struct {
+	Label string
+	// Number is the 1-based position of the row from the top
+	Number    int
+	Top       bool
+	Bottom    bool
+	Positions []GridPosition
+}
+
+// GridPosition is one slot of a GridRow
+type GridPosition struct {
+	// ID is the position ID used in verification reports, e.g. A01
+	ID   string
+	Row  string
+	Slot string
+	// Product is the planned product, empty for an empty slot or when the
+	// grid has no products
+	Product   string
+	ProductID int
+}
+
+// gridProduct is the planned product of a position
+type gridProduct struct {
+	name string
+	id   int
+}
+
+// Field names, as exported struct fields and as JSON keys, the grid reads
+// machine structures and product positions from
+var (
+	rowOrderFields    = []string{"RowOrder", "rowOrder"}
+	columnOrderFields = []string{"ColumnOrder", "columnOrder"}
+	rowCountFields    = []string{"RowCount", "rowCount"}
+	columnCountFields = []string{"ColumnsPerRow", "columnsPerRow", "ColumnCount", "columnCount"}
+	subLayoutFields   = []string{"SubLayouts", "subLayouts"}
+	structureFields   = []string{"MachineStructure", "machineStructure"}
+	positionMapFields = []string{"ProductPositionMap", "productPositionMap"}
+	positionFields    = []string{"Position", "position"}
+	productNameFields = []string{"ProductName", "productName", "ProductTemplateName", "productTemplateName"}
+	productIDFields   = []string{"ProductID", "productId", "ProductId"}
+)
+
+// NewGrid builds the grid of a machine structure, a *MachineStructure or the
+// machineStructure map of layout metadata; a *Grid is returned as it is.
+// Given layout metadata itself, NewGrid reads its machineStructure, and its
+// productPositionMap when no products are given.
+// Rows follow RowOrder, or are labelled A, B, ... from RowCount; slots follow
+// ColumnOrder, or are numbered from ColumnsPerRow. Rows of multi-door
+// structures have the slot count of their door. The optional products, a
+// product position map keyed by position or a list of product mappings with
+// a Position, fill in the planned product of each position. NewGrid returns
+// nil when the structure has no rows or slots.
+func NewGrid(structure interface{}, products ...interface{}) *Grid {
+	if g, ok := structure.(*Grid); ok {
+		return g
+	}
+	if nested, ok := lookupField(structure, structureFields); ok {
+		if len(products) == 0 {
+			if positions, ok := lookupField(structure, positionMapFields); ok {
+				products = []interface{}{positions.Interface()}
+			}
+		}
+		structure = nested.Interface()
+	}
+
+	rows := fieldStrings(structure, rowOrderFields)
+	if len(rows) == 0 {
+		for i := 0; i < fieldInt(structure, rowCountFields); i++ {
+			rows = append(rows, rowLabel(i))
+		}
+	}
+	slots := fieldStrings(structure, columnOrderFields)
+	if len(slots) == 0 {
+		for i := 1; i <= fieldInt(structure, columnCountFields); i++ {
+			slots = append(slots, strconv.Itoa(i))
+		}
+	}
+	if len(rows) == 0 || len(slots) == 0 {
+		return nil
+	}
+	for i := range slots {
+		slots[i] = slotNumber(slots[i])
+	}
+
+	var index map[string]gridProduct
+	for _, p := range products {
+		for position, product := range productIndex(p) {
+			if index == nil {
+				index = make(map[string]gridProduct)
+			}
+			index[position] = product
+		}
+	}
+
+	doorSlots := subLayoutColumns(structure)
+	g := &Grid{Slots: slots, hasProducts: len(index) > 0}
+	for i, label := range rows {
+		row := GridRow{
+			Label:  label,
+			Number: i + 1,
+			Top:    i == 0,
+			Bottom: i == len(rows)-1,
+		}
+		rowSlots := slots
+		if n, ok := doorSlots[label]; ok && n > 0 && n < len(slots) {
+			rowSlots = slots[:n]
+		}
+		for _, slot := range rowSlots {
+			id := label + slot
+			product := index[id]
+			row.Positions = append(row.Positions, GridPosition{
+				ID:        id,
+				Row:       label,
+				Slot:      slot,
+				Product:   product.name,
+				ProductID: product.id,
+			})
+		}
+		g.Rows = append(g.Rows, row)
+	}
+	return g
+}
+
+// RowCount returns the number of rows
+func (g *Grid) RowCount() int {
+	if g == nil {
+		return 0
+	}
+	return len(g.Rows)
+}
+
+// SlotCount returns the number of slots of the widest row
+func (g *Grid) SlotCount() int {
+	if g == nil {
+		return 0
+	}
+	return len(g.Slots)
+}
+
+// Total returns the number of positions
+func (g *Grid) Total() int {
+	if g == nil {
+		return 0
+	}
+	total := 0
+	for _, row := range g.Rows {
+		total += len(row.Positions)
+	}
+	return total
+}
+
+// HasProducts reports whether the grid knows the planned products
+func (g *Grid) HasProducts() bool {
+	return g != nil && g.hasProducts
+}
+
+// RowLabels returns the row labels, top to bottom
+func (g *Grid) RowLabels() []string {
+	if g == nil {
+		return nil
+	}
+	labels := make([]string, len(g.Rows))
+	for i, row := range g.Rows {
+		labels[i] = row.Label
+	}
+	return labels
+}
+
+// PositionIDs returns the IDs of all positions, row by row
+func (g *Grid) PositionIDs() []string {
+	if g == nil {
+		return nil
+	}
+	var ids []string
+	for _, row := range g.Rows {
+		ids = append(ids, row.PositionIDs()...)
+	}
+	return ids
+}
+
+// FirstRow returns the label of the top row
+func (g *Grid) FirstRow() string {
+	if g.RowCount() == 0 {
+		return ""
+	}
+	return g.Rows[0].Label
+}
+
+// LastRow returns the label of the bottom row
+func (g *Grid) LastRow() string {
+	if g.RowCount() == 0 {
+		return ""
+	}
+	return g.Rows[len(g.Rows)-1].Label
+}
+
+// FirstSlot returns the leftmost slot number
+func (g *Grid) FirstSlot() string {
+	if g.SlotCount() == 0 {
+		return ""
+	}
+	return g.Slots[0]
+}
+
+// LastSlot returns the rightmost slot number of the widest row
+func (g *Grid) LastSlot() string {
+	if g.SlotCount() == 0 {
+		return ""
+	}
+	return g.Slots[len(g.Slots)-1]
+}
+
+// FirstPosition returns the ID of the top left position
+func (g *Grid) FirstPosition() string {
+	if g.RowCount() == 0 || len(g.Rows[0].Positions) == 0 {
+		return ""
+	}
+	return g.Rows[0].Positions[0].ID
+}
+
+// LastPosition returns the ID of the bottom right position
+func (g *Grid) LastPosition() string {
+	if g.RowCount() == 0 {
+		return ""
+	}
+	positions := g.Rows[len(g.Rows)-1].Positions
+	if len(positions) == 0 {
+		return ""
+	}
+	return positions[len(positions)-1].ID
+}
+
+// PositionIDs returns the IDs of the row's positions, left to right
+func (r GridRow) PositionIDs() []string {
+	ids := make([]string, len(r.Positions))
+	for i, p := range r.Positions {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+// LastSlot returns the rightmost slot number of the row
+func (r GridRow) LastSlot() string {
+	if len(r.Positions) == 0 {
+		return ""
+	}
+	return r.Positions[len(r.Positions)-1].Slot
+}
+
+// ProductList lists the planned products of the row left to right, with
+// consecutive slots of the same product merged, e.g.
+// "01-03 Coca-Cola; 04 Empty; 05 Pepsi"
+func (r GridRow) ProductList() string {
+	var parts []string
+	for i := 0; i < len(r.Positions); {
+		j := i
+		for j+1 < len(r.Positions) && r.Positions[j+1].Product == r.Positions[i].Product {
+			j++
+		}
+		slots := r.Positions[i].Slot
+		if j > i {
+			slots += "-" + r.Positions[j].Slot
+		}
+		parts = append(parts, slots+" "+productOrEmpty(r.Positions[i].Product))
+		i = j + 1
+	}
+	return strings.Join(parts, "; ")
+}
+
+// PlanogramTable renders the planned product of every position as a
+// Markdown table with one line per row
+func (g *Grid) PlanogramTable() string {
+	if g.RowCount() == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("| Row |")
+	for _, slot := range g.Slots {
+		fmt.Fprintf(&b, " %s |", slot)
+	}
+	b.WriteString("\n|-----|")
+	b.WriteString(strings.Repeat("----|", len(g.Slots)))
+	for _, row := range g.Rows {
+		fmt.Fprintf(&b, "\n| %s |", row.Label)
+		for i := range g.Slots {
+			cell := "-"
+			if i < len(row.Positions) {
+				cell = strings.ReplaceAll(productOrEmpty(row.Positions[i].Product), "|", "/")
+			}
+			fmt.Fprintf(&b, " %s |", cell)
+		}
+	}
+	return b.String()
+}
+
+func productOrEmpty(name string) string {
+	if name == "" {
+		return "Empty"
+	}
+	return name
+}
+
+// PositionID formats the ID of a position from its row label and slot, e.g.
+// PositionID("A", 3) and PositionID("A", "03") both return "A03"
+func PositionID(row string, slot interface{}) string {
+	return row + slotNumber(fmt.Sprint(slot))
+}
+
+// Checklist numbers items one per line, "1. A01", "2. A02", ... Items are
+// strings, grid rows, listed as "Row A", or grid positions, listed by ID.
+func Checklist(items interface{}) string {
+	var lines []string
+	switch v := items.(type) {
+	case []string:
+		lines = v
+	case []GridRow:
+		for _, row := range v {
+			lines = append(lines, "Row "+row.Label)
+		}
+	case []GridPosition:
+		for _, p := range v {
+			lines = append(lines, p.ID)
+		}
+	}
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%d. %s", i+1, line)
+	}
+	return b.String()
+}
+
+// rowLabel returns the label of the i-th row from the top: A, B, ..., Z,
+// AA, AB, ...
+func rowLabel(i int) string {
+	label := ""
+	for i >= 0 {
+		label = string(rune('A'+i%26)) + label
+		i = i/26 - 1
+	}
+	return label
+}
+
+// productIndex maps the normalized positions of a product position map or
+// a list of product mappings to their planned products
+func productIndex(products interface{}) map[string]gridProduct {
+	value := indirect(reflect.ValueOf(products))
+	if !value.IsValid() {
+		return nil
+	}
+	index := make(map[string]gridProduct)
+	switch value.Kind() {
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		iter := value.MapRange()
+		for iter.Next() {
+			index[NormalizePosition(iter.Key().String())] = productOf(iter.Value().Interface())
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			item := value.Index(i).Interface()
+			if position := fieldString(item, positionFields); position != "" {
+				index[NormalizePosition(position)] = productOf(item)
+			}
+		}
+	}
+	return index
+}
+
+func productOf(info interface{}) gridProduct {
+	return gridProduct{
+		name: strings.TrimSpace(fieldString(info, productNameFields)),
+		id:   fieldInt(info, productIDFields),
+	}
+}
+
+// subLayoutColumns maps the rows of each door of a multi-door structure to
+// the slot count of the door
+func subLayoutColumns(structure interface{}) map[string]int {
+	value, ok := lookupField(structure, subLayoutFields)
+	if !ok || (value.Kind() != reflect.Slice && value.Kind() != reflect.Array) {
+		return nil
+	}
+	columns := make(map[string]int)
+	for i := 0; i < value.Len(); i++ {
+		door := value.Index(i).Interface()
+		n := fieldInt(door, columnCountFields)
+		for _, row := range fieldStrings(door, rowOrderFields) {
+			columns[row] = n
+		}
+	}
+	return columns
+}
+
+// lookupField returns the first of the field names the data supplies
+func lookupField(data interface{}, names []string) (reflect.Value, bool) {
+	for _, name := range names {
+		if value, ok := lookupPath(data, name); ok {
+			return value, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func fieldString(data interface{}, names []string) string {
+	if value, ok := lookupField(data, names); ok && value.Kind() == reflect.String {
+		return value.String()
+	}
+	return ""
+}
+
+func fieldInt(data interface{}, names []string) int {
+	if value, ok := lookupField(data, names); ok && hasType(value, FieldInt) {
+		return int(toFloat(value))
+	}
+	return 0
+}
+
+// fieldStrings returns the elements of a list field, formatting numbers
+// decoded from JSON without a fraction
+func fieldStrings(data interface{}, names []string) []string {
+	value, ok := lookupField(data, names)
+	if !ok || !hasType(value, FieldList) {
+		return nil
+	}
+	out := make([]string, 0, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		elem := indirect(value.Index(i))
+		switch {
+		case !elem.IsValid():
+		case elem.Kind() == reflect.String:
+			out = append(out, elem.String())
+		case hasType(elem, FieldInt):
+			out = append(out, strconv.Itoa(int(toFloat(elem))))
+		}
+	}
+	return out
+}
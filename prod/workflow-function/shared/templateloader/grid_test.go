@@ -0,0 +1,145 @@
+package templateloader
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestNewGrid(t *testing.T) {
+	type machineStructure struct {
+		RowCount      int
+		ColumnsPerRow int
+		RowOrder      []string
+		ColumnOrder   []string
+	}
+	type productMapping struct {
+		Position    string
+		ProductID   int
+		ProductName string
+	}
+
+	g := NewGrid(&machineStructure{RowCount: 2, ColumnsPerRow: 3, RowOrder: []string{"A", "B"}, ColumnOrder: []string{"1", "2", "3"}},
+		[]productMapping{{Position: "A01", ProductID: 7, ProductName: "Pepsi"}, {Position: "B3", ProductID: 8, ProductName: "Coke"}})
+	if g.RowCount() != 2 || g.SlotCount() != 3 || g.Total() != 6 || !g.HasProducts() {
+		t.Fatalf("Unexpected grid %+v", g)
+	}
+	if got := g.PositionIDs(); !reflect.DeepEqual(got, []string{"A01", "A02", "A03", "B01", "B02", "B03"}) {
+		t.Errorf("Unexpected position IDs %v", got)
+	}
+	if g.FirstPosition() != "A01" || g.LastPosition() != "B03" || g.LastRow() != "B" || g.LastSlot() != "03" {
+		t.Errorf("Unexpected bounds %s-%s", g.FirstPosition(), g.LastPosition())
+	}
+	if p := g.Rows[1].Positions[2]; p.Product != "Coke" || p.ProductID != 8 {
+		t.Errorf("Expected B3 to match B03, got %+v", p)
+	}
+	if !g.Rows[0].Top || g.Rows[0].Bottom || !g.Rows[1].Bottom || g.Rows[1].Number != 2 {
+		t.Errorf("Unexpected row flags %+v", g.Rows)
+	}
+
+	// Layout metadata decoded from JSON, with a narrower second door
+	var structure map[string]interface{}
+	if err := json.Unmarshal([]byte(`{
+		"rowCount": 3, "columnsPerRow": 10,
+		"rowOrder": ["D1-A", "D1-B", "D2-A"],
+		"columnOrder": ["1", "2", "3", "4", "5", "6", "7", "8", "9", "10"],
+		"subLayouts": [
+			{"rowOrder": ["D1-A", "D1-B"], "columnsPerRow": 10},
+			{"rowOrder": ["D2-A"], "columnsPerRow": 4}
+		]}`), &structure); err != nil {
+		t.Fatal(err)
+	}
+	positions := map[string]interface{}{
+		"D2-A4": map[string]interface{}{"productId": 3486.0, "productTemplateName": "Mì Hảo Hảo"},
+	}
+	g = NewGrid(structure, positions)
+	if g.Total() != 24 || g.LastPosition() != "D2-A04" || g.LastSlot() != "10" {
+		t.Errorf("Expected the second door to have 4 slots, got %d positions ending at %s", g.Total(), g.LastPosition())
+	}
+	if p := g.Rows[2].Positions[3]; p.Product != "Mì Hảo Hảo" || p.ProductID != 3486 {
+		t.Errorf("Unexpected product %+v", p)
+	}
+
+	// Layout metadata holding both the structure and the product positions
+	g = NewGrid(map[string]interface{}{"machineStructure": structure, "productPositionMap": positions})
+	if g.Total() != 24 || g.Rows[2].Positions[3].Product != "Mì Hảo Hảo" {
+		t.Errorf("Expected the grid of the layout metadata, got %+v", g)
+	}
+
+	// Counts only
+	g = NewGrid(map[string]interface{}{"RowCount": 28, "ColumnCount": 2})
+	if g.HasProducts() || g.LastRow() != "AB" || g.LastPosition() != "AB02" {
+		t.Errorf("Expected rows A to AB, got %v", g.RowLabels())
+	}
+
+	var missing *machineStructure
+	for _, structure := range []interface{}{nil, missing, map[string]interface{}{"RowCount": 0}} {
+		if g := NewGrid(structure); g != nil {
+			t.Errorf("Expected no grid for %#v, got %+v", structure, g)
+		}
+	}
+}
+
+func TestGridRendering(t *testing.T) {
+	g := NewGrid(map[string]interface{}{"RowOrder": []string{"A", "B"}, "ColumnsPerRow": 4}, map[string]interface{}{
+		"A1": map[string]interface{}{"productName": "Pepsi"},
+		"A2": map[string]interface{}{"productName": "Pepsi"},
+		"A4": map[string]interface{}{"productName": "7 | Up"},
+		"B2": map[string]interface{}{"productName": "Coke"},
+	})
+
+	if got, want := g.Rows[0].ProductList(), "01-02 Pepsi; 03 Empty; 04 7 | Up"; got != want {
+		t.Errorf("ProductList() = %q, want %q", got, want)
+	}
+	want := "| Row | 01 | 02 | 03 | 04 |\n|-----|----|----|----|----|\n| A | Pepsi | Pepsi | Empty | 7 / Up |\n| B | Empty | Coke | Empty | Empty |"
+	if got := g.PlanogramTable(); got != want {
+		t.Errorf("PlanogramTable() =\n%s\nwant\n%s", got, want)
+	}
+	if got := Checklist(g.Rows); got != "1. Row A\n2. Row B" {
+		t.Errorf("Unexpected row checklist %q", got)
+	}
+	if got := Checklist(g.Rows[1].Positions[:2]); got != "1. B01\n2. B02" {
+		t.Errorf("Unexpected position checklist %q", got)
+	}
+
+	if PositionID("C", 3) != "C03" || PositionID("C", "3") != "C03" || PositionID("C", "03") != "C03" {
+		t.Error("Expected PositionID to pad slot numbers to two digits")
+	}
+}
+
+func TestGridTemplateFunctions(t *testing.T) {
+	tmpl := template.Must(template.New("grid").Funcs(DefaultFunctions).Parse(
+		`{{with grid .MachineStructure .ProductMappings}}{{range .Rows}}* **Row {{.Label}}{{if .Top}} (Top){{else if .Bottom}} (Bottom){{end}}:** {{rowProducts .}}
+{{end}}{{.Total}} positions, {{.FirstPosition}}-{{.LastPosition}}; last row {{lastRowLabel .}}, last slot {{maxSlotNumber $.MachineStructure}}{{else}}detect{{end}}`))
+
+	render := func(data map[string]interface{}) string {
+		var b strings.Builder
+		if err := tmpl.Execute(&b, data); err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		return b.String()
+	}
+
+	data := map[string]interface{}{
+		"MachineStructure": fixtureMachineStructure(),
+		"ProductMappings":  []map[string]interface{}{{"Position": "A01", "ProductName": "Pepsi"}},
+	}
+	out := render(data)
+	for _, want := range []string{"* **Row A (Top):** 01 Pepsi; 02-10 Empty\n", "* **Row C:** 01-10 Empty\n", "* **Row F (Bottom):**", "60 positions, A01-F10; last row F, last slot 10"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in\n%s", want, out)
+		}
+	}
+
+	if out := render(map[string]interface{}{"MachineStructure": nil, "ProductMappings": nil}); out != "detect" {
+		t.Errorf("Expected the fallback without a machine structure, got %q", out)
+	}
+	if got := lastRowLabelFunction(); got != "E" {
+		t.Errorf("Expected the default last row E, got %q", got)
+	}
+	if got := maxSlotNumberFunction(12); got != "12" {
+		t.Errorf("Expected slot 12 from the column count, got %q", got)
+	}
+}
@@ -0,0 +1,481 @@
+package templateloader
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// Fixture is named template data that lint renders the templates of its
+// template types with
+type Fixture struct {
+	Name string `json:"name"`
+	// TemplateTypes lists the template types the fixture applies to
+	TemplateTypes []string               `json:"templateTypes"`
+	Data          map[string]interface{} `json:"data"`
+}
+
+// appliesTo reports whether the fixture renders templateType; fixtures apply
+// to the localized templates of their template types as well
+func (f Fixture) appliesTo(templateType string) bool {
+	templateType, _ = splitLocale(normalizeTemplateType(templateType))
+	for _, t := range f.TemplateTypes {
+		if normalizeTemplateType(t) == templateType {
+			return true
+		}
+	}
+	return false
+}
+
+// LintOptions configures Lint
+type LintOptions struct {
+	// Functions available to the templates; DefaultFunctions when nil
+	Functions template.FuncMap
+
+	// Fixtures rendered with the templates; DefaultFixtures() when nil
+	Fixtures []Fixture
+}
+
+// LintResult is the outcome of parsing a template version and rendering it
+// with one fixture. Fixture is empty for templates without a fixture and for
+// templates that fail to parse.
+type LintResult struct {
+	Source       string `json:"source"`
+	TemplateType string `json:"template_type"`
+	Version      string `json:"version,omitempty"`
+	Location     string `json:"location"`
+	Fixture      string `json:"fixture,omitempty"`
+	// Locale is set for localized templates
+	Locale string `json:"locale,omitempty"`
+
+	ParseError string `json:"parse_error,omitempty"`
+	ExecError  string `json:"exec_error,omitempty"`
+
+	// HasContract is set for templates declaring a contract header.
+	// UndeclaredFields lists the fields the template reads that its contract
+	// does not declare, and ContractError the violations of the fixture.
+	HasContract      bool     `json:"has_contract"`
+	UndeclaredFields []string `json:"undeclared_fields,omitempty"`
+	ContractError    string   `json:"contract_error,omitempty"`
+
+	// MissingMarkers lists the output markers, such as
+	// **VERIFICATION SUMMARY:**, of the default-locale output of the same
+	// version and fixture that the output of a localized template lacks
+	MissingMarkers []string `json:"missing_markers,omitempty"`
+
+	// UnusedFields lists the top-level fixture fields the template never
+	// references
+	UnusedFields    []string `json:"unused_fields,omitempty"`
+	Size            int      `json:"size"`
+	EstimatedTokens int      `json:"estimated_tokens"`
+
+	output string
+}
+
+// Failed reports whether the template failed to parse or render, breaks its
+// contract or, for localized templates, drops output markers
+func (r LintResult) Failed() bool {
+	return r.ParseError != "" || r.ExecError != "" || r.ContractError != "" ||
+		len(r.UndeclaredFields) > 0 || len(r.MissingMarkers) > 0
+}
+
+// VersionDiff compares the output of two consecutive versions of a template
+// rendered with the same fixture
+type VersionDiff struct {
+	Source       string   `json:"source"`
+	TemplateType string   `json:"template_type"`
+	Fixture      string   `json:"fixture"`
+	From         string   `json:"from"`
+	To           string   `json:"to"`
+	Added        int      `json:"added"`
+	Removed      int      `json:"removed"`
+	Lines        []string `json:"lines,omitempty"`
+}
+
+// LintReport is the outcome of a lint run
+type LintReport struct {
+	Results []LintResult  `json:"results"`
+	Diffs   []VersionDiff `json:"diffs,omitempty"`
+}
+
+// Failed reports whether any template failed to parse or render, breaks its
+// contract or drops output markers
+func (r *LintReport) Failed() bool {
+	for _, result := range r.Results {
+		if result.Failed() {
+			return true
+		}
+	}
+	return false
+}
+
+// Lint parses every template of the sources with the lint functions and
+// renders each with the fixtures of its template type using missingkey=error,
+// so references to fields the data lacks fail as they would at runtime with
+// struct data. Templates with a contract must declare every top-level field
+// they read, and the fixtures must satisfy the contract. Consecutive
+// versions of a template rendered with the same fixture are diffed.
+// Localized templates must keep the output markers of the default-locale
+// template of the same version, as the result parsers rely on them.
+func Lint(sources []Source, opts LintOptions) (*LintReport, error) {
+	functions := opts.Functions
+	if functions == nil {
+		functions = DefaultFunctions
+	}
+	fixtures := opts.Fixtures
+	if fixtures == nil {
+		fixtures = DefaultFixtures()
+	}
+
+	report := &LintReport{}
+	for _, source := range sources {
+		files, err := source.List()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list templates of %s: %w", source.Name(), err)
+		}
+		sortSourceFiles(files)
+
+		// rendered holds the previous version's output per fixture, markers
+		// the markers of default-locale outputs by version and fixture
+		var previous SourceFile
+		var rendered map[string]string
+		markers := make(map[string][]string)
+		for _, file := range files {
+			if file.TemplateType != previous.TemplateType {
+				rendered = nil
+			}
+			baseType, locale := splitLocale(file.TemplateType)
+			outputs := make(map[string]string)
+			for _, result := range lintFile(source, file, functions, fixtures) {
+				result.Locale = locale
+				if result.Fixture != "" && result.ExecError == "" {
+					key := baseType + ":" + file.Version + ":" + result.Fixture
+					if locale == DefaultLocale {
+						markers[key] = outputMarkers(result.output)
+					} else if want, ok := markers[key]; ok {
+						result.MissingMarkers = missingMarkers(want, result.output)
+					}
+				}
+				if result.Fixture != "" && !result.Failed() {
+					outputs[result.Fixture] = result.output
+					if before, ok := rendered[result.Fixture]; ok {
+						report.Diffs = append(report.Diffs, diffVersions(source, previous, file, result.Fixture, before, result.output))
+					}
+				}
+				report.Results = append(report.Results, result)
+			}
+			previous, rendered = file, outputs
+		}
+	}
+	return report, nil
+}
+
+// lintFile parses a template file and renders it with each fixture of its
+// template type
+func lintFile(source Source, file SourceFile, functions template.FuncMap, fixtures []Fixture) []LintResult {
+	base := LintResult{
+		Source:       source.Name(),
+		TemplateType: file.TemplateType,
+		Version:      file.Version,
+		Location:     file.Location,
+	}
+
+	content, _, err := source.Read(file.TemplateType, file.Version)
+	if err != nil {
+		base.ParseError = err.Error()
+		return []LintResult{base}
+	}
+	tmpl, err := template.New(file.TemplateType).Funcs(functions).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		base.ParseError = err.Error()
+		return []LintResult{base}
+	}
+	contract, err := ParseContract(content)
+	if err != nil {
+		base.ParseError = err.Error()
+		return []LintResult{base}
+	}
+	if contract != nil {
+		base.HasContract = true
+		for field := range rootFields(tmpl) {
+			if !contract.Declares(field) {
+				base.UndeclaredFields = append(base.UndeclaredFields, field)
+			}
+		}
+		sort.Strings(base.UndeclaredFields)
+	}
+	referenced := referencedFields(tmpl)
+
+	var results []LintResult
+	for _, fixture := range fixtures {
+		if !fixture.appliesTo(file.TemplateType) {
+			continue
+		}
+		result := base
+		result.Fixture = fixture.Name
+		if contract != nil {
+			if err := contract.Validate(fixture.Data); err != nil {
+				result.ContractError = err.Error()
+			}
+		}
+
+		var buf strings.Builder
+		if err := tmpl.Execute(&buf, fixture.Data); err != nil {
+			result.ExecError = err.Error()
+		} else {
+			result.output = buf.String()
+			result.Size = len(result.output)
+			// Rough estimation: 1 token per 4 characters for English text
+			result.EstimatedTokens = len(result.output) / 4
+		}
+		for field := range fixture.Data {
+			if !referenced[field] {
+				result.UnusedFields = append(result.UnusedFields, field)
+			}
+		}
+		sort.Strings(result.UnusedFields)
+		results = append(results, result)
+	}
+	if len(results) == 0 {
+		return []LintResult{base}
+	}
+	return results
+}
+
+// outputMarkerPattern matches the bold labels, such as
+// **VERIFICATION SUMMARY:** or **Issue:**, that structure prompt output
+var outputMarkerPattern = regexp.MustCompile(`\*\*[^*\n]+:\*\*`)
+
+// outputMarkers returns the distinct output markers of rendered output
+func outputMarkers(output string) []string {
+	seen := make(map[string]bool)
+	var markers []string
+	for _, marker := range outputMarkerPattern.FindAllString(output, -1) {
+		if !seen[marker] {
+			seen[marker] = true
+			markers = append(markers, marker)
+		}
+	}
+	return markers
+}
+
+// missingMarkers returns the markers that output lacks
+func missingMarkers(markers []string, output string) []string {
+	var missing []string
+	for _, marker := range markers {
+		if !strings.Contains(output, marker) {
+			missing = append(missing, marker)
+		}
+	}
+	return missing
+}
+
+// sortSourceFiles orders files by template type, then flat templates before
+// versions in SemVer precedence
+func sortSourceFiles(files []SourceFile) {
+	sort.SliceStable(files, func(i, j int) bool {
+		a, b := files[i], files[j]
+		if a.TemplateType != b.TemplateType {
+			return a.TemplateType < b.TemplateType
+		}
+		va, errA := ParseVersion(a.Version)
+		vb, errB := ParseVersion(b.Version)
+		if errA == nil && errB == nil {
+			if c := va.Compare(vb); c != 0 {
+				return c < 0
+			}
+		}
+		return a.Version < b.Version
+	})
+}
+
+// referencedFields returns the field names a template and its associated
+// templates reference anywhere, e.g. RowCount for {{.MachineStructure.RowCount}}.
+// Fields are not resolved against the dot they apply to, so a nested field
+// sharing the name of a top-level field marks the latter as used.
+func referencedFields(tmpl *template.Template) map[string]bool {
+	fields := make(map[string]bool)
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.IfNode:
+			walkBranch(&n.BranchNode, walk)
+		case *parse.RangeNode:
+			walkBranch(&n.BranchNode, walk)
+		case *parse.WithNode:
+			walkBranch(&n.BranchNode, walk)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.FieldNode:
+			for _, ident := range n.Ident {
+				fields[ident] = true
+			}
+		case *parse.ChainNode:
+			walk(n.Node)
+			for _, ident := range n.Field {
+				fields[ident] = true
+			}
+		case *parse.VariableNode:
+			for _, ident := range n.Ident[1:] {
+				fields[ident] = true
+			}
+		}
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			walk(t.Tree.Root)
+		}
+	}
+	return fields
+}
+
+// rootFields returns the top-level data fields a template reads: fields of
+// the dot outside range and with actions, and fields of $
+func rootFields(tmpl *template.Template) map[string]bool {
+	fields := make(map[string]bool)
+	var walk func(node parse.Node, root bool)
+	walk = func(node parse.Node, root bool) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child, root)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe, root)
+		case *parse.IfNode:
+			walk(n.Pipe, root)
+			walk(n.List, root)
+			walk(n.ElseList, root)
+		case *parse.RangeNode:
+			// range and with move the dot in their body only
+			walk(n.Pipe, root)
+			walk(n.List, false)
+			walk(n.ElseList, root)
+		case *parse.WithNode:
+			walk(n.Pipe, root)
+			walk(n.List, false)
+			walk(n.ElseList, root)
+		case *parse.TemplateNode:
+			walk(n.Pipe, root)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd, root)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg, root)
+			}
+		case *parse.ChainNode:
+			walk(n.Node, root)
+		case *parse.FieldNode:
+			if root {
+				fields[n.Ident[0]] = true
+			}
+		case *parse.VariableNode:
+			if n.Ident[0] == "$" && len(n.Ident) > 1 {
+				fields[n.Ident[1]] = true
+			}
+		}
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			walk(t.Tree.Root, true)
+		}
+	}
+	return fields
+}
+
+// walkBranch walks the pipeline and both lists of an if, range or with action
+func walkBranch(n *parse.BranchNode, walk func(parse.Node)) {
+	walk(n.Pipe)
+	walk(n.List)
+	if n.ElseList != nil {
+		walk(n.ElseList)
+	}
+}
+
+// diffVersions compares the rendered output of two versions line by line
+func diffVersions(source Source, from, to SourceFile, fixture, before, after string) VersionDiff {
+	diff := VersionDiff{
+		Source:       source.Name(),
+		TemplateType: to.TemplateType,
+		Fixture:      fixture,
+		From:         from.Version,
+		To:           to.Version,
+	}
+	diff.Lines = diffLines(strings.Split(before, "\n"), strings.Split(after, "\n"))
+	for _, line := range diff.Lines {
+		switch line[0] {
+		case '+':
+			diff.Added++
+		case '-':
+			diff.Removed++
+		}
+	}
+	return diff
+}
+
+// diffLines returns the lines removed from a, prefixed with "-", and added
+// in b, prefixed with "+", in order, using their longest common subsequence
+func diffLines(a, b []string) []string {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, "-"+a[i])
+			i++
+		default:
+			lines = append(lines, "+"+b[j])
+			j++
+		}
+	}
+	return lines
+}
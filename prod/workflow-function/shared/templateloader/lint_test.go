@@ -0,0 +1,78 @@
+package templateloader
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLintReportsErrorsUnusedFieldsAndDiffs(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, filepath.Join(dir, "grid", "v1.0.0.tmpl"), "Rows: {{.RowCount}}\nEnd", 0)
+	writeTemplate(t, filepath.Join(dir, "grid", "v1.1.0.tmpl"), "Rows: {{.RowCount}} (last {{lastRowLabel .RowCount}})\nEnd", 0)
+	writeTemplate(t, filepath.Join(dir, "grid", "v1.2.0.tmpl"), "Rows: {{firstRowLabel}}", 0)
+	writeTemplate(t, filepath.Join(dir, "history", "v1.0.0.tmpl"), "{{.MachineStructure.RowCount}}", 0)
+	writeTemplate(t, filepath.Join(dir, "untested", "v1.0.0.tmpl"), "static", 0)
+
+	report, err := Lint([]Source{NewFilesystemSource(dir)}, LintOptions{Fixtures: []Fixture{
+		{Name: "six-rows", TemplateTypes: []string{"grid"}, Data: map[string]interface{}{"RowCount": 6, "Location": ""}},
+		{Name: "no-history", TemplateTypes: []string{"history"}, Data: map[string]interface{}{"RowCount": 6}},
+	}})
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	if !report.Failed() {
+		t.Error("Expected the report to fail")
+	}
+
+	results := make(map[string]LintResult)
+	for _, r := range report.Results {
+		results[r.TemplateType+"@"+r.Version] = r
+	}
+	if r := results["grid@1.0.0"]; r.Failed() || r.Fixture != "six-rows" || r.EstimatedTokens != len("Rows: 6\nEnd")/4 {
+		t.Errorf("Unexpected result %+v", r)
+	}
+	if r := results["grid@1.1.0"]; !reflect.DeepEqual(r.UnusedFields, []string{"Location"}) {
+		t.Errorf("Expected Location to be unused, got %v", r.UnusedFields)
+	}
+	if r := results["grid@1.2.0"]; !strings.Contains(r.ParseError, `function "firstRowLabel" not defined`) {
+		t.Errorf("Expected an undefined function error, got %+v", r)
+	}
+	if r := results["history@1.0.0"]; !strings.Contains(r.ExecError, `no entry for key "MachineStructure"`) {
+		t.Errorf("Expected a missing key error, got %+v", r)
+	}
+	if r := results["untested@1.0.0"]; r.Failed() || r.Fixture != "" {
+		t.Errorf("Expected a parsed template without fixture, got %+v", r)
+	}
+
+	if len(report.Diffs) != 1 {
+		t.Fatalf("Expected one diff, got %+v", report.Diffs)
+	}
+	diff := report.Diffs[0]
+	if diff.From != "1.0.0" || diff.To != "1.1.0" || diff.Added != 1 || diff.Removed != 1 {
+		t.Errorf("Unexpected diff %+v", diff)
+	}
+	if want := []string{"-Rows: 6", "+Rows: 6 (last F)"}; !reflect.DeepEqual(diff.Lines, want) {
+		t.Errorf("Expected diff lines %q, got %q", want, diff.Lines)
+	}
+}
+
+func TestDefaultFixturesRenderTurnTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, filepath.Join(dir, "turn1-layout-vs-checking", "v1.0.tmpl"),
+		"{{.RowCount}} rows {{index .RowLabels 0}}-{{index .RowLabels (add .RowCount -1)}}", 0)
+	writeTemplate(t, filepath.Join(dir, TemplateTypeTurn3SelfVerification, "v1.0.tmpl"),
+		"{{range $i, $d := .Discrepancies}}{{add $i 1}}. {{$d.Item}}{{end}}{{if .Cropped}} {{join .CropRows \", \"}}{{end}}", 0)
+
+	report, err := Lint([]Source{NewFilesystemSource(dir)}, LintOptions{})
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	if report.Failed() {
+		t.Fatalf("Expected the default fixtures to render, got %+v", report.Results)
+	}
+	if len(report.Results) != 3 {
+		t.Errorf("Expected one Turn 1 and two Turn 3 results, got %d", len(report.Results))
+	}
+}
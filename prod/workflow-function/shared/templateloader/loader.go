@@ -2,11 +2,7 @@ package templateloader
 
 import (
 	"fmt"
-	"io/ioutil"
 	"log"
-	"path/filepath"
-	"sort"
-	"strconv"
 	"strings"
 	"sync"
 	"text/template"
@@ -18,15 +14,36 @@ type Config struct {
 	BasePath     string           `yaml:"base_path" json:"base_path"`
 	CacheEnabled bool             `yaml:"cache_enabled" json:"cache_enabled"`
 	CustomFuncs  template.FuncMap `yaml:"-" json:"-"`
+
+	// Source overrides where templates are read from; nil reads BasePath
+	Source Source `yaml:"-" json:"-"`
+
+	// PinnedVersions maps template types, or patterns such as "turn1-*", to
+	// the version used instead of the latest one, see PinnedVersionsFromEnv
+	PinnedVersions map[string]string `yaml:"pinned_versions" json:"pinned_versions"`
 }
 
 // Loader handles template loading and rendering
 type Loader struct {
 	basePath  string
+	source    Source
+	pins      map[string]string
 	cache     TemplateCache
 	functions template.FuncMap
-	versions  map[string]string
+	catalog   map[string][]VersionInfo // available versions by template type
+	resolved  map[string]string        // last version chosen by constraint, for logging
+	contracts map[string]*Contract     // parsed contracts by content checksum
 	mu        sync.RWMutex
+
+	// Hot-reload state, see watcher.go
+	discovery      DiscoveryConfig
+	files          map[string]templateFile   // last seen template files by location
+	cached         map[string]cachedTemplate // cache entries by cache key
+	subscribers    map[int]ChangeHandler
+	nextSubscriber int
+	stopWatch      chan struct{}
+	watchDone      chan struct{}
+	watchMu        sync.Mutex
 }
 
 // TemplateLoader defines the interface for template operations
@@ -37,31 +54,75 @@ type TemplateLoader interface {
 	RenderTemplateWithVersion(templateType, version string, data interface{}) (string, error)
 	GetLatestVersion(templateType string) string
 	ListVersions(templateType string) []string
+	VersionInfos(templateType string) []VersionInfo
+	ResolveVersion(templateType, constraint string) (string, error)
+	ResolveLocale(templateType, locale, version string) string
 	ClearCache() error
 	RefreshVersions() error
 }
 
 // New creates a new template loader with the given configuration
 func New(config Config) (*Loader, error) {
-	if config.BasePath == "" {
-		config.BasePath = "/opt/templates"
-	}
-
-	loader := &Loader{
-		basePath:  config.BasePath,
-		functions: make(template.FuncMap),
-		versions:  make(map[string]string),
-	}
-
 	// Initialize cache if enabled using MemoryCache, otherwise NoCache
+	var cache TemplateCache = NewNoCache()
 	if config.CacheEnabled {
 		mc, err := NewMemoryCache(100, "LRU", time.Hour)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create cache: %w", err)
 		}
-		loader.cache = mc
-	} else {
-		loader.cache = NewNoCache()
+		cache = mc
+	}
+	return newLoader(config, cache, DiscoveryConfig{})
+}
+
+// NewWithAdvancedConfig creates a template loader whose cache is built from
+// config.CacheConfig. When config.Discovery.WatchEnabled is set, template
+// files are checked every WatchInterval and edited templates are reloaded;
+// call StopWatcher to stop.
+func NewWithAdvancedConfig(config *AdvancedConfig) (*Loader, error) {
+	var cache TemplateCache = NewNoCache()
+	if config.Config.CacheEnabled {
+		var err error
+		cache, err = NewCacheFactory().CreateCache(config.CacheConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cache: %w", err)
+		}
+	}
+
+	loader, err := newLoader(config.Config, cache, config.Discovery)
+	if err != nil {
+		return nil, err
+	}
+	if config.Discovery.WatchEnabled {
+		if err := loader.StartWatcher(config.Discovery.WatchInterval); err != nil {
+			return nil, fmt.Errorf("failed to start template watcher: %w", err)
+		}
+	}
+	return loader, nil
+}
+
+// newLoader creates a loader with the given cache and discovery settings
+func newLoader(config Config, cache TemplateCache, discovery DiscoveryConfig) (*Loader, error) {
+	if config.BasePath == "" {
+		config.BasePath = "/opt/templates"
+	}
+
+	loader := &Loader{
+		basePath:    config.BasePath,
+		source:      config.Source,
+		pins:        config.PinnedVersions,
+		cache:       cache,
+		functions:   make(template.FuncMap),
+		catalog:     make(map[string][]VersionInfo),
+		resolved:    make(map[string]string),
+		contracts:   make(map[string]*Contract),
+		discovery:   discovery,
+		files:       make(map[string]templateFile),
+		cached:      make(map[string]cachedTemplate),
+		subscribers: make(map[int]ChangeHandler),
+	}
+	if loader.source == nil {
+		loader.source = NewFilesystemSource(config.BasePath)
 	}
 
 	// Add default functions
@@ -76,21 +137,25 @@ func New(config Config) (*Loader, error) {
 		}
 	}
 
+	// Shared caches parse templates with the loader's functions
+	if compiling, ok := cache.(CompilingCache); ok {
+		compiling.Funcs(loader.functions)
+	}
+
 	// Discover available template versions
 	if err := loader.discoverVersions(); err != nil {
 		return nil, fmt.Errorf("failed to discover template versions: %w", err)
 	}
 
-	// Conceptual: start file watcher for hot-reload if enabled via AdvancedConfig
-	// This is not fully implemented but outlines the necessary structure.
-	// if config.Discovery.WatchEnabled {
-	//         go loader.startWatcher(config.Discovery.WatchInterval)
-	// }
+	// Record the template files so changes can be detected
+	if err := loader.snapshotTemplateFiles(); err != nil {
+		return nil, err
+	}
 
 	return loader, nil
 }
 
-// LoadTemplate loads the latest version of a template
+// LoadTemplate loads the pinned or latest version of a template
 func (l *Loader) LoadTemplate(templateType string) (*template.Template, error) {
 	version := l.GetLatestVersion(templateType)
 	if version == "" {
@@ -99,54 +164,97 @@ func (l *Loader) LoadTemplate(templateType string) (*template.Template, error) {
 	return l.LoadTemplateWithVersion(templateType, version)
 }
 
-// LoadTemplateWithVersion loads a specific version of a template
+// LoadTemplateWithVersion loads the version of a template satisfying a
+// version or constraint such as "^1.2" or "latest-stable", see Constraint.
+// "v1.0", "1.0" and "1.0.0" name the same version; an exact version without
+// a versioned file falls back to the flat template.
 func (l *Loader) LoadTemplateWithVersion(templateType, version string) (*template.Template, error) {
+	tmpl, _, err := l.load(templateType, version)
+	return tmpl, err
+}
+
+// load returns a template version and its metadata, from the cache when
+// possible. The metadata may be nil for templates cached without it.
+func (l *Loader) load(templateType, version string) (*template.Template, *TemplateMetadata, error) {
+	constraint, err := ParseConstraint(version)
+	if err != nil {
+		return nil, nil, err
+	}
+	resolved, err := l.ResolveVersion(templateType, version)
+	switch {
+	case err == nil:
+		version = resolved
+	case constraint.Exact():
+		version = normalizeVersion(version)
+	default:
+		return nil, nil, err
+	}
+
 	// Check cache first if enabled
 	if l.cache != nil {
 		cacheKey := fmt.Sprintf("%s:%s", templateType, version)
 		if tmpl, meta, ok := l.cache.Get(cacheKey); ok {
-			_ = meta // metadata currently unused
-			return tmpl, nil
+			return tmpl, meta, nil
 		}
 	}
 
 	// Try versioned template first
-	templatePath := l.getVersionedTemplatePath(templateType, version)
-	content, err := ioutil.ReadFile(templatePath)
+	flat := false
+	content, templatePath, err := l.source.Read(templateType, version)
 	if err != nil {
 		// Try flat file structure as fallback
-		templatePath = l.getFlatTemplatePath(templateType)
-		content, err = ioutil.ReadFile(templatePath)
+		flat = true
+		content, templatePath, err = l.source.Read(templateType, "")
 		if err != nil {
-			return nil, fmt.Errorf("failed to read template file at %s: %w", templatePath, err)
+			return nil, nil, err
 		}
 	}
 
 	// Parse template with custom functions
 	tmpl, err := template.New(templateType).Funcs(l.functions).Parse(string(content))
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse template: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	if _, err := ParseContract(content); err != nil {
+		return nil, nil, &TemplateError{
+			Type:         ErrorTypeParsing,
+			Message:      err.Error(),
+			TemplateType: templateType,
+			Version:      version,
+			Path:         templatePath,
+			Cause:        err,
+		}
+	}
+
+	metadata := TemplateMetadata{
+		Type:     templateType,
+		Version:  version,
+		Path:     templatePath,
+		LoadedAt: time.Now(),
+		Size:     int64(len(content)),
+		Checksum: contentChecksum(string(content)),
+		Content:  string(content),
 	}
 
 	// Cache the template if caching is enabled
 	if l.cache != nil {
 		cacheKey := fmt.Sprintf("%s:%s", templateType, version)
-		metadata := TemplateMetadata{
-			Type:     templateType,
-			Version:  version,
-			Path:     templatePath,
-			LoadedAt: time.Now(),
-			Size:     int64(len(content)),
-		}
 		if errCache := l.cache.Set(cacheKey, tmpl, &metadata, 0); errCache != nil {
 			log.Printf("Warning: failed to cache template %s:%s - %v", templateType, version, errCache)
+		} else {
+			l.trackCacheKey(cacheKey, cachedTemplate{
+				location:     templatePath,
+				templateType: l.normalizeTemplateType(templateType),
+				version:      version,
+				flat:         flat,
+			})
 		}
 	}
 
-	return tmpl, nil
+	return tmpl, &metadata, nil
 }
 
-// RenderTemplate renders the latest version of a template with data
+// RenderTemplate renders the pinned or latest version of a template with data
 func (l *Loader) RenderTemplate(templateType string, data interface{}) (string, error) {
 	version := l.GetLatestVersion(templateType)
 	if version == "" {
@@ -157,10 +265,13 @@ func (l *Loader) RenderTemplate(templateType string, data interface{}) (string,
 
 // RenderTemplateWithVersion renders a specific version of a template with data
 func (l *Loader) RenderTemplateWithVersion(templateType, version string, data interface{}) (string, error) {
-	tmpl, err := l.LoadTemplateWithVersion(templateType, version)
+	tmpl, meta, err := l.load(templateType, version)
 	if err != nil {
 		return "", err
 	}
+	if err := l.validateData(meta, data); err != nil {
+		return "", err
+	}
 
 	var buf strings.Builder
 	if err := tmpl.Execute(&buf, data); err != nil {
@@ -170,42 +281,61 @@ func (l *Loader) RenderTemplateWithVersion(templateType, version string, data in
 	return buf.String(), nil
 }
 
-// GetLatestVersion returns the latest version for a template type
+// GetLatestVersion returns the version satisfying the version or constraint
+// pinned for a template type, else the latest stable version, else the
+// latest pre-release. An exact pin without a versioned file is returned as
+// is for the flat template.
 func (l *Loader) GetLatestVersion(templateType string) string {
+	templateType = l.normalizeTemplateType(templateType)
+	if pin := pinnedVersion(l.pins, templateType); pin != "" {
+		version, err := l.ResolveVersion(templateType, pin)
+		if err == nil {
+			return version
+		}
+		if c, parseErr := ParseConstraint(pin); parseErr == nil && c.Exact() {
+			return pin
+		}
+		log.Printf("Warning: pinned version of template %s cannot be resolved - %v", templateType, err)
+		return ""
+	}
+
 	l.mu.RLock()
-	defer l.mu.RUnlock()
-	return l.versions[templateType]
+	latest, ok := latestVersion(l.catalog[templateType])
+	l.mu.RUnlock()
+	if !ok {
+		return ""
+	}
+	l.logResolution(templateType, ConstraintLatestStable, latest)
+	return latest.Version
 }
 
-// ListVersions returns all available versions for a template type, sorted
+// ListVersions returns the available versions of a template type in
+// ascending SemVer order
 func (l *Loader) ListVersions(templateType string) []string {
-	templateDir := filepath.Join(l.basePath, l.normalizeTemplateType(templateType))
-
-	files, err := ioutil.ReadDir(templateDir)
-	if err != nil {
-		return []string{}
-	}
-
-	var versions []string
-	for _, file := range files {
-		if !file.IsDir() && strings.HasSuffix(file.Name(), ".tmpl") {
-			if strings.HasPrefix(file.Name(), "v") {
-				version := strings.TrimSuffix(strings.TrimPrefix(file.Name(), "v"), ".tmpl")
-				versions = append(versions, version)
-			}
-		}
+	infos := l.VersionInfos(templateType)
+	versions := make([]string, len(infos))
+	for i, info := range infos {
+		versions[i] = info.Version
 	}
-
-	// Sort versions semantically
-	sort.Sort(semVerSlice(versions))
 	return versions
 }
 
+// VersionInfos returns the available versions of a template type in
+// ascending SemVer order, with pre-release and deprecation flags
+func (l *Loader) VersionInfos(templateType string) []VersionInfo {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return append([]VersionInfo{}, l.catalog[l.normalizeTemplateType(templateType)]...)
+}
+
 // ClearCache clears the template cache
 func (l *Loader) ClearCache() error {
 	if l.cache == nil {
 		return nil
 	}
+	l.watchMu.Lock()
+	l.cached = make(map[string]cachedTemplate)
+	l.watchMu.Unlock()
 	return l.cache.Clear()
 }
 
@@ -216,107 +346,29 @@ func (l *Loader) RefreshVersions() error {
 
 // Private methods
 
-// discoverVersions scans the template directory to find available versions
+// discoverVersions lists the template source to find available versions.
+// Template types whose versions were all removed are dropped.
 func (l *Loader) discoverVersions() error {
-	entries, err := ioutil.ReadDir(l.basePath)
+	files, err := l.source.List()
 	if err != nil {
-		return fmt.Errorf("failed to read template base directory %s: %w", l.basePath, err)
+		return err
 	}
 
+	catalog := l.buildCatalog(files)
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
-
-	for _, entry := range entries {
-		if entry.IsDir() {
-			templateType := entry.Name()
-			versions := l.ListVersions(templateType)
-			if len(versions) > 0 {
-				l.versions[templateType] = versions[len(versions)-1] // Latest version
-			}
-		}
-	}
+	l.catalog = catalog
 
 	return nil
 }
 
 // normalizeTemplateType converts template type to filesystem format
 func (l *Loader) normalizeTemplateType(templateType string) string {
-	return strings.ReplaceAll(strings.ToLower(templateType), "_", "-")
+	return normalizeTemplateType(templateType)
 }
 
-// getVersionedTemplatePath returns the path for a versioned template
-func (l *Loader) getVersionedTemplatePath(templateType, version string) string {
-	templateDir := l.normalizeTemplateType(templateType)
-	filename := fmt.Sprintf("v%s.tmpl", version)
-	return filepath.Join(l.basePath, templateDir, filename)
-}
-
-// getFlatTemplatePath returns the path for a flat template structure
-func (l *Loader) getFlatTemplatePath(templateType string) string {
-	templateDir := l.normalizeTemplateType(templateType)
-	filename := templateDir + ".tmpl"
-	return filepath.Join(l.basePath, filename)
-}
-
-// semVerSlice implements sort.Interface for semantic version sorting
-type semVerSlice []string
-
-func (s semVerSlice) Len() int      { return len(s) }
-func (s semVerSlice) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
-func (s semVerSlice) Less(i, j int) bool {
-	return compareVersions(s[i], s[j]) < 0
-}
-
-// compareVersions compares two semantic version strings
-func compareVersions(v1, v2 string) int {
-	parts1 := strings.Split(v1, ".")
-	parts2 := strings.Split(v2, ".")
-
-	maxLen := len(parts1)
-	if len(parts2) > maxLen {
-		maxLen = len(parts2)
-	}
-
-	for i := 0; i < maxLen; i++ {
-		var n1, n2 int
-		if i < len(parts1) {
-			n1 = parseVersionPart(parts1[i])
-		}
-		if i < len(parts2) {
-			n2 = parseVersionPart(parts2[i])
-		}
-
-		if n1 < n2 {
-			return -1
-		} else if n1 > n2 {
-			return 1
-		}
-	}
-
-	return 0
-}
-
-// parseVersionPart parses a version part to integer, handling non-numeric parts gracefully
-func parseVersionPart(s string) int {
-	num, err := strconv.Atoi(s)
-	if err != nil {
-		// For non-numeric parts, use ASCII value sum as fallback
-		sum := 0
-		for _, r := range s {
-			sum += int(r)
-		}
-		return sum
-	}
-	return num
-}
-
-// startWatcher outlines a periodic refresh routine for template versions.
-// When enabled, it would refresh versions and clear outdated cache entries.
-func (l *Loader) startWatcher(interval time.Duration) {
-	ticker := time.NewTicker(interval)
-	for range ticker.C {
-		// In a full implementation, discoverVersions would detect changed
-		// templates and l.cache.Delete(...) would remove stale entries.
-		l.RefreshVersions()
-	}
+// normalizeTemplateType converts template type to filesystem format
+func normalizeTemplateType(templateType string) string {
+	return strings.ReplaceAll(strings.ToLower(templateType), "_", "-")
 }
@@ -0,0 +1,100 @@
+package templateloader
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultLocale is the locale of the templates directly under a template
+// type. Localized templates are laid out as <type>/<locale>/v<version>.tmpl
+// and loaded under the template type <type>/<locale>, see
+// LocalizedTemplateType.
+const DefaultLocale = ""
+
+// localePattern matches normalized locales such as "vi" or "vi-vn"
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// NormalizeLocale converts a locale such as "vi_VN" or "vi-VN" to the
+// directory form "vi-vn". Empty and invalid locales yield DefaultLocale.
+func NormalizeLocale(locale string) string {
+	locale = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(locale)), "_", "-")
+	if !localePattern.MatchString(locale) {
+		return DefaultLocale
+	}
+	return locale
+}
+
+// LocalizedTemplateType returns the template type the templates of a locale
+// are loaded under, the template type itself for the default locale
+func LocalizedTemplateType(templateType, locale string) string {
+	if locale = NormalizeLocale(locale); locale != DefaultLocale {
+		return templateType + "/" + locale
+	}
+	return templateType
+}
+
+// splitLocale splits a localized template type into the template type and
+// locale
+func splitLocale(templateType string) (string, string) {
+	if i := strings.LastIndex(templateType, "/"); i >= 0 && localePattern.MatchString(templateType[i+1:]) {
+		return templateType[:i], templateType[i+1:]
+	}
+	return templateType, DefaultLocale
+}
+
+// localeCandidates returns a locale, its language and the default locale,
+// most specific first
+func localeCandidates(locale string) []string {
+	locale = NormalizeLocale(locale)
+	var candidates []string
+	for locale != DefaultLocale {
+		candidates = append(candidates, locale)
+		i := strings.LastIndex(locale, "-")
+		if i < 0 {
+			break
+		}
+		locale = locale[:i]
+	}
+	return append(candidates, DefaultLocale)
+}
+
+// ResolveLocale returns the most specific of a locale, its language ("vi"
+// for "vi-vn") and the default locale that provides a version of a template
+// type. The version is a version or constraint as for
+// LoadTemplateWithVersion; empty selects the pinned or latest version.
+// Render LocalizedTemplateType(templateType, locale) with the result.
+func (l *Loader) ResolveLocale(templateType, locale, version string) string {
+	templateType = l.normalizeTemplateType(templateType)
+	constraint := version
+	if constraint == "" {
+		constraint = pinnedVersion(l.pins, templateType)
+	}
+	if constraint == "" {
+		constraint = ConstraintLatest
+	}
+
+	candidates := localeCandidates(locale)
+	for _, candidate := range candidates[:len(candidates)-1] {
+		if _, err := l.ResolveVersion(LocalizedTemplateType(templateType, candidate), constraint); err == nil {
+			return candidate
+		}
+	}
+	return DefaultLocale
+}
+
+// Locales returns the locales with versions of a template type, excluding
+// the default locale
+func (l *Loader) Locales(templateType string) []string {
+	templateType = l.normalizeTemplateType(templateType)
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	var locales []string
+	for localized := range l.catalog {
+		if base, locale := splitLocale(localized); base == templateType && locale != DefaultLocale {
+			locales = append(locales, locale)
+		}
+	}
+	sort.Strings(locales)
+	return locales
+}
@@ -0,0 +1,90 @@
+package templateloader
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeLocale(t *testing.T) {
+	for in, want := range map[string]string{
+		"vi":      "vi",
+		"vi_VN":   "vi-vn",
+		" VI-vn ": "vi-vn",
+		"":        DefaultLocale,
+		"v":       DefaultLocale,
+		"../en":   DefaultLocale,
+		"en/us":   DefaultLocale,
+	} {
+		if got := NormalizeLocale(in); got != want {
+			t.Errorf("NormalizeLocale(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := LocalizedTemplateType("turn2-layout-vs-checking", "vi_VN"); got != "turn2-layout-vs-checking/vi-vn" {
+		t.Errorf("Unexpected localized template type %q", got)
+	}
+	if got := LocalizedTemplateType("turn2-layout-vs-checking", ""); got != "turn2-layout-vs-checking" {
+		t.Errorf("Expected the default locale to keep the template type, got %q", got)
+	}
+}
+
+func TestResolveLocale(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, filepath.Join(dir, "grid", "v1.0.0.tmpl"), "rows", 0)
+	writeTemplate(t, filepath.Join(dir, "grid", "v1.1.0.tmpl"), "rows", 0)
+	writeTemplate(t, filepath.Join(dir, "grid", "vi", "v1.0.0.tmpl"), "hàng", 0)
+	writeTemplate(t, filepath.Join(dir, "grid", "Fr", "v1.0.0.tmpl"), "rangées", 0)
+	writeTemplate(t, filepath.Join(dir, "grid", "vi", "nested", "v1.0.0.tmpl"), "ignored", 0)
+
+	loader, err := New(Config{BasePath: dir, CacheEnabled: true, PinnedVersions: map[string]string{"grid": "1.0.0"}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if got := loader.Locales("grid"); !reflect.DeepEqual(got, []string{"vi"}) {
+		t.Errorf("Expected only the normalized locale directory, got %v", got)
+	}
+
+	for _, tc := range []struct {
+		locale, version, want string
+	}{
+		{"vi-VN", "", "vi"},
+		{"vi", "1.0.0", "vi"},
+		{"vi", "1.1.0", DefaultLocale},
+		{"fr", "", DefaultLocale},
+		{"", "", DefaultLocale},
+	} {
+		if got := loader.ResolveLocale("grid", tc.locale, tc.version); got != tc.want {
+			t.Errorf("ResolveLocale(%q, %q) = %q, want %q", tc.locale, tc.version, got, tc.want)
+		}
+	}
+
+	out, err := loader.RenderTemplate(LocalizedTemplateType("grid", "vi"), nil)
+	if err != nil || out != "hàng" {
+		t.Errorf("Expected the pinned localized version, got %q, %v", out, err)
+	}
+}
+
+func TestLintChecksLocalizedMarkers(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, filepath.Join(dir, "report", "v1.0.0.tmpl"), "**SUMMARY:**\n**Status:** {{.Status}}", 0)
+	writeTemplate(t, filepath.Join(dir, "report", "vi", "v1.0.0.tmpl"), "Tóm tắt\n**SUMMARY:**\n**Status:** {{.Status}}", 0)
+	writeTemplate(t, filepath.Join(dir, "report", "de", "v1.0.0.tmpl"), "**ZUSAMMENFASSUNG:**\n**Status:** {{.Status}}", 0)
+
+	report, err := Lint([]Source{NewFilesystemSource(dir)}, LintOptions{Fixtures: []Fixture{
+		{Name: "report", TemplateTypes: []string{"report"}, Data: map[string]interface{}{"Status": "CORRECT"}},
+	}})
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+
+	results := make(map[string]LintResult)
+	for _, r := range report.Results {
+		results[r.TemplateType] = r
+	}
+	if r := results["report/vi"]; r.Failed() || r.Locale != "vi" || r.Fixture != "report" {
+		t.Errorf("Expected the localized template to pass, got %+v", r)
+	}
+	if r := results["report/de"]; !reflect.DeepEqual(r.MissingMarkers, []string{"**SUMMARY:**"}) || !r.Failed() {
+		t.Errorf("Expected **SUMMARY:** to be missing, got %+v", r)
+	}
+}
@@ -0,0 +1,41 @@
+package templateloader
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// positionRe matches a position: an optional door, an optional "Row" word, a
+// row label of one or two letters, an optional "position", "column" or
+// "slot" word and a slot number of up to three digits
+var positionRe = regexp.MustCompile(`(?i)\b(?:(D\d+)\s*-\s*)?(?:row\s+)?([A-Z]{1,2})\s*[-,]?\s*(?:(?:position|col(?:umn)?|slot)\s+)?(\d{1,3})\b`)
+
+// NormalizePosition returns the canonical position ID of the first position
+// in a product position map key or model output: "A1", "a-01", "**A01**",
+// "Position A 1" and "Row A, slot 1" all become "A01", and "D2-A1" becomes
+// "D2-A01". Position keys and reported positions are compared through it.
+// Text without a position is returned trimmed.
+func NormalizePosition(position string) string {
+	position = strings.TrimSpace(position)
+	m := positionRe.FindStringSubmatch(position)
+	if m == nil {
+		return position
+	}
+	id := strings.ToUpper(m[2]) + slotNumber(m[3])
+	if m[1] != "" {
+		return strings.ToUpper(m[1]) + "-" + id
+	}
+	return id
+}
+
+// slotNumber zero-pads a numeric slot to two digits: "1" becomes "01"
+func slotNumber(slot string) string {
+	slot = strings.TrimSpace(slot)
+	n, err := strconv.Atoi(slot)
+	if err != nil || n < 0 {
+		return slot
+	}
+	return fmt.Sprintf("%02d", n)
+}
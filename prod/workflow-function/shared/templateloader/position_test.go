@@ -0,0 +1,29 @@
+package templateloader
+
+import "testing"
+
+func TestNormalizePosition(t *testing.T) {
+	for in, want := range map[string]string{
+		"A1":                "A01",
+		"a-1":               "A01",
+		"a-01":              "A01",
+		"B07":               "B07",
+		" **C4** ":          "C04",
+		"Position B 7":      "B07",
+		"Row B slot 3":      "B03",
+		"row c, position 4": "C04",
+		"Row C 04":          "C04",
+		"D2-A1":             "D2-A01",
+		"d2 - a1":           "D2-A01",
+		"D2-A10":            "D2-A10",
+		"AA12":              "AA12",
+		"AB100":             "AB100",
+		"A":                 "A",
+		"12":                "12",
+		"Row C":             "Row C",
+	} {
+		if got := NormalizePosition(in); got != want {
+			t.Errorf("NormalizePosition(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,461 @@
+package templateloader
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Defaults of RedisCacheConfig
+const (
+	DefaultRedisKeyPrefix     = "templateloader:"
+	DefaultRedisDialTimeout   = 2 * time.Second
+	DefaultRedisRetryInterval = 30 * time.Second
+)
+
+// CompilingCache is a cache that stores template sources rather than parsed
+// templates and parses them again on Get. The loader passes its function map
+// to such caches so shared templates parse with the same functions.
+type CompilingCache interface {
+	TemplateCache
+	Funcs(funcs template.FuncMap)
+}
+
+// redisEntry is the value stored in Redis for a template
+type redisEntry struct {
+	Metadata *TemplateMetadata `json:"metadata"`
+	Content  string            `json:"content"`
+}
+
+// compiledTemplate is a template parsed from a Redis entry
+type compiledTemplate struct {
+	checksum string
+	tmpl     *template.Template
+}
+
+// RedisCache shares templates between processes through Redis. Entries hold
+// the template source and metadata and are parsed locally with the loader's
+// function map; a parsed template is reused while the checksum in Redis is
+// unchanged. When Redis cannot be reached the cache degrades to a MemoryCache,
+// which also receives every Set, and tries Redis again after RetryInterval.
+type RedisCache struct {
+	client        *redisClient
+	prefix        string
+	defaultTTL    time.Duration
+	retryInterval time.Duration
+	fallback      *MemoryCache
+
+	mu            sync.Mutex
+	functions     template.FuncMap
+	compiled      map[string]compiledTemplate
+	degradedUntil time.Time
+	stats         CacheStats
+}
+
+// NewRedisCache creates a Redis cache for the environment namespace of
+// config. An unreachable Redis is not an error; the fallback is used until
+// Redis answers.
+func NewRedisCache(config RedisCacheConfig, fallback *MemoryCache, defaultTTL time.Duration) (*RedisCache, error) {
+	if config.Addr == "" {
+		return nil, fmt.Errorf("redis cache address cannot be empty")
+	}
+	if fallback == nil {
+		return nil, fmt.Errorf("redis cache needs a fallback cache")
+	}
+	if config.Password != "" && !config.TLS {
+		return nil, fmt.Errorf("redis password requires tls so that AUTH is not sent in cleartext")
+	}
+	if config.KeyPrefix == "" {
+		config.KeyPrefix = DefaultRedisKeyPrefix
+	}
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = DefaultRedisDialTimeout
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = DefaultRedisRetryInterval
+	}
+
+	prefix := config.KeyPrefix
+	if config.Namespace != "" {
+		prefix += config.Namespace + ":"
+	}
+
+	var tlsConfig *tls.Config
+	if config.TLS {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		if config.TLSConfig != nil {
+			tlsConfig = config.TLSConfig.Clone()
+		}
+		if tlsConfig.ServerName == "" {
+			host, _, err := net.SplitHostPort(config.Addr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid redis cache address %q: %w", config.Addr, err)
+			}
+			tlsConfig.ServerName = host
+		}
+	}
+
+	return &RedisCache{
+		client:        newRedisClient(config.Addr, config.Password, config.DB, config.DialTimeout, tlsConfig),
+		prefix:        prefix,
+		defaultTTL:    defaultTTL,
+		retryInterval: config.RetryInterval,
+		fallback:      fallback,
+		functions:     make(template.FuncMap),
+		compiled:      make(map[string]compiledTemplate),
+	}, nil
+}
+
+// Funcs sets the functions templates read from Redis are parsed with
+func (rc *RedisCache) Funcs(funcs template.FuncMap) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.functions = funcs
+	rc.compiled = make(map[string]compiledTemplate)
+}
+
+// Get returns a template from Redis, parsing it unless the same content was
+// parsed before
+func (rc *RedisCache) Get(key string) (*template.Template, *TemplateMetadata, bool) {
+	if rc.degraded() {
+		return rc.getFallback(key)
+	}
+
+	reply, err := rc.client.do("GET", rc.prefix+key)
+	if err != nil {
+		rc.markDegraded(err)
+		return rc.getFallback(key)
+	}
+	rc.markAvailable()
+
+	data, _ := reply.([]byte)
+	if data == nil {
+		rc.recordAccess(false)
+		return nil, nil, false
+	}
+	var entry redisEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Metadata == nil {
+		log.Printf("Warning: ignoring malformed cached template %s - %v", key, err)
+		rc.recordAccess(false)
+		return nil, nil, false
+	}
+
+	tmpl, err := rc.compile(key, &entry)
+	if err != nil {
+		log.Printf("Warning: failed to parse cached template %s - %v", key, err)
+		rc.recordAccess(false)
+		return nil, nil, false
+	}
+	rc.recordAccess(true)
+
+	metadata := *entry.Metadata
+	metadata.Content = entry.Content
+	return tmpl, &metadata, true
+}
+
+// Set stores a template in Redis and in the fallback cache. Templates
+// without metadata content cannot be parsed by other processes and are only
+// kept in the fallback cache.
+func (rc *RedisCache) Set(key string, tmpl *template.Template, metadata *TemplateMetadata, ttl time.Duration) error {
+	if ttl == 0 {
+		ttl = rc.defaultTTL
+	}
+	if err := rc.fallback.Set(key, tmpl, metadata, ttl); err != nil {
+		return err
+	}
+
+	rc.mu.Lock()
+	rc.stats.Sets++
+	rc.stats.LastSet = time.Now()
+	rc.mu.Unlock()
+
+	if metadata == nil || metadata.Content == "" || rc.degraded() {
+		return nil
+	}
+
+	entry := redisEntry{Metadata: metadata, Content: metadata.Content}
+	if entry.Metadata.Checksum == "" {
+		withChecksum := *metadata
+		withChecksum.Checksum = contentChecksum(metadata.Content)
+		entry.Metadata = &withChecksum
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode template %s: %w", key, err)
+	}
+
+	args := []string{"SET", rc.prefix + key, string(data)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	if _, err := rc.client.do(args...); err != nil {
+		rc.markDegraded(err)
+		return nil
+	}
+	rc.markAvailable()
+
+	if tmpl != nil {
+		rc.mu.Lock()
+		rc.compiled[key] = compiledTemplate{checksum: entry.Metadata.Checksum, tmpl: tmpl}
+		rc.mu.Unlock()
+	}
+	return nil
+}
+
+// Delete removes a template from Redis and from the fallback cache
+func (rc *RedisCache) Delete(key string) error {
+	rc.fallback.Delete(key)
+
+	rc.mu.Lock()
+	delete(rc.compiled, key)
+	rc.stats.Deletes++
+	rc.mu.Unlock()
+
+	if rc.degraded() {
+		return nil
+	}
+	if _, err := rc.client.do("DEL", rc.prefix+key); err != nil {
+		rc.markDegraded(err)
+		return nil
+	}
+	rc.markAvailable()
+	return nil
+}
+
+// Clear removes the templates of the namespace from Redis and the fallback
+// cache
+func (rc *RedisCache) Clear() error {
+	rc.fallback.Clear()
+
+	rc.mu.Lock()
+	rc.compiled = make(map[string]compiledTemplate)
+	rc.mu.Unlock()
+
+	if rc.degraded() {
+		return nil
+	}
+	keys, err := rc.scan()
+	if err != nil {
+		rc.markDegraded(err)
+		return nil
+	}
+	for start := 0; start < len(keys); start += 100 {
+		end := start + 100
+		if end > len(keys) {
+			end = len(keys)
+		}
+		args := []string{"DEL"}
+		for _, key := range keys[start:end] {
+			args = append(args, rc.prefix+key)
+		}
+		if _, err := rc.client.do(args...); err != nil {
+			rc.markDegraded(err)
+			return nil
+		}
+	}
+	rc.markAvailable()
+	return nil
+}
+
+// Size returns the number of templates in the namespace
+func (rc *RedisCache) Size() int {
+	return len(rc.Keys())
+}
+
+// Stats returns the statistics of both tiers: hits, misses, sets and
+// deletes of the cache, evictions of the fallback cache, and the size of
+// the namespace in Redis, or of the fallback cache while degraded
+func (rc *RedisCache) Stats() CacheStats {
+	size := rc.Size()
+	fallback := rc.fallback.Stats()
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	stats := rc.stats
+	stats.Size = size
+	stats.MaxSize = fallback.MaxSize
+	stats.Evictions = fallback.Evictions
+	stats.LastCleanup = fallback.LastCleanup
+	stats.Degraded = time.Now().Before(rc.degradedUntil)
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// Keys returns the keys of the namespace without the namespace prefix
+func (rc *RedisCache) Keys() []string {
+	if rc.degraded() {
+		return rc.fallback.Keys()
+	}
+	keys, err := rc.scan()
+	if err != nil {
+		rc.markDegraded(err)
+		return rc.fallback.Keys()
+	}
+	rc.markAvailable()
+	return keys
+}
+
+// HasExpired reports whether a template is missing from Redis, where
+// expired keys are removed
+func (rc *RedisCache) HasExpired(key string) bool {
+	if rc.degraded() {
+		return rc.fallback.HasExpired(key)
+	}
+	reply, err := rc.client.do("PTTL", rc.prefix+key)
+	if err != nil {
+		rc.markDegraded(err)
+		return rc.fallback.HasExpired(key)
+	}
+	rc.markAvailable()
+	ttl, _ := reply.(int64)
+	return ttl == -2
+}
+
+// Cleanup removes expired templates from the fallback cache; Redis expires
+// keys itself
+func (rc *RedisCache) Cleanup() int {
+	return rc.fallback.Cleanup()
+}
+
+// Close closes the Redis connection and stops the fallback cache
+func (rc *RedisCache) Close() error {
+	rc.client.close()
+	return rc.fallback.Close()
+}
+
+// compile returns the parsed template of an entry, reusing the template
+// parsed for the same content
+func (rc *RedisCache) compile(key string, entry *redisEntry) (*template.Template, error) {
+	checksum := entry.Metadata.Checksum
+	if checksum == "" {
+		checksum = contentChecksum(entry.Content)
+	}
+
+	rc.mu.Lock()
+	compiled, ok := rc.compiled[key]
+	functions := rc.functions
+	rc.mu.Unlock()
+	if ok && compiled.checksum == checksum {
+		return compiled.tmpl, nil
+	}
+
+	tmpl, err := template.New(entry.Metadata.Type).Funcs(functions).Parse(entry.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	rc.mu.Lock()
+	rc.compiled[key] = compiledTemplate{checksum: checksum, tmpl: tmpl}
+	rc.mu.Unlock()
+
+	// Keep the template for when Redis becomes unreachable
+	metadata := *entry.Metadata
+	metadata.Content = entry.Content
+	rc.fallback.Set(key, tmpl, &metadata, rc.defaultTTL)
+	return tmpl, nil
+}
+
+// scan returns the keys of the namespace without the namespace prefix
+func (rc *RedisCache) scan() ([]string, error) {
+	pattern := escapeRedisPattern(rc.prefix) + "*"
+	keys := []string{}
+	cursor := "0"
+	for {
+		reply, err := rc.client.do("SCAN", cursor, "MATCH", pattern, "COUNT", "100")
+		if err != nil {
+			return nil, err
+		}
+		parts, ok := reply.([]interface{})
+		if !ok || len(parts) != 2 {
+			return nil, fmt.Errorf("redis: unexpected SCAN reply %v", reply)
+		}
+		next, _ := parts[0].([]byte)
+		batch, _ := parts[1].([]interface{})
+		for _, key := range batch {
+			if name, ok := key.([]byte); ok {
+				keys = append(keys, strings.TrimPrefix(string(name), rc.prefix))
+			}
+		}
+		cursor = string(next)
+		if cursor == "0" || cursor == "" {
+			return keys, nil
+		}
+	}
+}
+
+// getFallback reads a template from the fallback cache
+func (rc *RedisCache) getFallback(key string) (*template.Template, *TemplateMetadata, bool) {
+	tmpl, metadata, ok := rc.fallback.Get(key)
+	rc.recordAccess(ok)
+	return tmpl, metadata, ok
+}
+
+// recordAccess counts a hit or miss
+func (rc *RedisCache) recordAccess(hit bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if hit {
+		rc.stats.Hits++
+	} else {
+		rc.stats.Misses++
+	}
+	rc.stats.LastAccess = time.Now()
+}
+
+// degraded reports whether the fallback cache is used instead of Redis
+func (rc *RedisCache) degraded() bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return time.Now().Before(rc.degradedUntil)
+}
+
+// markDegraded switches to the fallback cache for the retry interval
+func (rc *RedisCache) markDegraded(err error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.stats.RemoteErrors++
+	if rc.degradedUntil.IsZero() {
+		log.Printf("Warning: redis template cache unavailable, using memory cache for %s - %v", rc.retryInterval, err)
+	}
+	rc.degradedUntil = time.Now().Add(rc.retryInterval)
+}
+
+// markAvailable records a successful Redis request after degradation
+func (rc *RedisCache) markAvailable() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if !rc.degradedUntil.IsZero() {
+		log.Printf("Redis template cache available again")
+		rc.degradedUntil = time.Time{}
+	}
+}
+
+// contentChecksum returns the SHA-256 of template content as hex
+func contentChecksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// escapeRedisPattern escapes the glob characters of a SCAN pattern
+func escapeRedisPattern(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
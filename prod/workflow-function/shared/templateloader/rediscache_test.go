@@ -0,0 +1,462 @@
+package templateloader
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is an in-process Redis stand-in speaking RESP for the commands
+// RedisCache uses. With a password it serves TLS, which clientTLS trusts.
+type fakeRedis struct {
+	t         *testing.T
+	addr      string
+	password  string
+	serverTLS *tls.Config
+	clientTLS *tls.Config
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]bool
+	values   map[string]string
+	expires  map[string]time.Time
+	commands map[string]int
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+	f := &fakeRedis{
+		t:        t,
+		password: password,
+		values:   make(map[string]string),
+		expires:  make(map[string]time.Time),
+		commands: make(map[string]int),
+	}
+	if password != "" {
+		f.serverTLS, f.clientTLS = newTestTLSConfigs(t)
+	}
+	f.start("127.0.0.1:0")
+	t.Cleanup(f.stop)
+	return f
+}
+
+// start listens on addr and serves connections
+func (f *fakeRedis) start(addr string) {
+	f.t.Helper()
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	if f.serverTLS != nil {
+		listener = tls.NewListener(listener, f.serverTLS)
+	}
+	f.mu.Lock()
+	f.listener, f.addr, f.conns = listener, listener.Addr().String(), make(map[net.Conn]bool)
+	f.mu.Unlock()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			f.mu.Lock()
+			f.conns[conn] = true
+			f.mu.Unlock()
+			go f.serve(conn)
+		}
+	}()
+}
+
+// stop closes the listener and all connections, like a Redis outage
+func (f *fakeRedis) stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.listener != nil {
+		f.listener.Close()
+		f.listener = nil
+	}
+	for conn := range f.conns {
+		conn.Close()
+	}
+}
+
+// newTestTLSConfigs returns a server config with a self-signed certificate
+// for 127.0.0.1 and a client config trusting it
+func newTestTLSConfigs(t *testing.T) (server, client *tls.Config) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fake redis"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	server = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	return server, &tls.Config{RootCAs: roots}
+}
+
+func (f *fakeRedis) count(command string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.commands[command]
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	authenticated := f.password == ""
+	for {
+		reply, err := readRESP(rd)
+		if err != nil {
+			return
+		}
+		parts, _ := reply.([]interface{})
+		args := make([]string, len(parts))
+		for i, part := range parts {
+			b, _ := part.([]byte)
+			args[i] = string(b)
+		}
+		if len(args) == 0 {
+			return
+		}
+
+		command := strings.ToUpper(args[0])
+		var out string
+		switch {
+		case command == "AUTH":
+			f.mu.Lock()
+			f.commands[command]++
+			f.mu.Unlock()
+			authenticated = len(args) == 2 && args[1] == f.password
+			out = "+OK\r\n"
+			if !authenticated {
+				out = "-WRONGPASS invalid password\r\n"
+			}
+		case !authenticated:
+			out = "-NOAUTH Authentication required.\r\n"
+		default:
+			out = f.execute(command, args[1:])
+		}
+		if _, err := conn.Write([]byte(out)); err != nil {
+			return
+		}
+	}
+}
+
+func bulk(s string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
+}
+
+func (f *fakeRedis) execute(command string, args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commands[command]++
+
+	for key, expiresAt := range f.expires {
+		if time.Now().After(expiresAt) {
+			delete(f.values, key)
+			delete(f.expires, key)
+		}
+	}
+
+	switch command {
+	case "SELECT", "PING":
+		return "+OK\r\n"
+	case "GET":
+		value, ok := f.values[args[0]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(value)
+	case "SET":
+		f.values[args[0]] = args[1]
+		delete(f.expires, args[0])
+		if len(args) == 4 && strings.EqualFold(args[2], "PX") {
+			ms, _ := strconv.Atoi(args[3])
+			f.expires[args[0]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		return "+OK\r\n"
+	case "DEL":
+		deleted := 0
+		for _, key := range args {
+			if _, ok := f.values[key]; ok {
+				delete(f.values, key)
+				delete(f.expires, key)
+				deleted++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", deleted)
+	case "PTTL":
+		if _, ok := f.values[args[0]]; !ok {
+			return ":-2\r\n"
+		}
+		expiresAt, ok := f.expires[args[0]]
+		if !ok {
+			return ":-1\r\n"
+		}
+		return fmt.Sprintf(":%d\r\n", time.Until(expiresAt).Milliseconds())
+	case "SCAN":
+		// One key per page to exercise the cursor
+		pattern := strings.ReplaceAll(args[2], `\`, "")
+		var keys []string
+		for key := range f.values {
+			if matched, _ := path.Match(pattern, key); matched {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		cursor, _ := strconv.Atoi(args[0])
+		if cursor >= len(keys) {
+			return "*2\r\n" + bulk("0") + "*0\r\n"
+		}
+		next := strconv.Itoa(cursor + 1)
+		if cursor+1 >= len(keys) {
+			next = "0"
+		}
+		return "*2\r\n" + bulk(next) + "*1\r\n" + bulk(keys[cursor])
+	default:
+		return "-ERR unknown command '" + command + "'\r\n"
+	}
+}
+
+func newTestRedisCache(t *testing.T, redis *fakeRedis, namespace string) *RedisCache {
+	t.Helper()
+	fallback, err := NewMemoryCache(10, "LRU", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache, err := NewRedisCache(RedisCacheConfig{
+		Addr:          redis.addr,
+		Password:      redis.password,
+		TLS:           redis.clientTLS != nil,
+		TLSConfig:     redis.clientTLS,
+		Namespace:     namespace,
+		DialTimeout:   time.Second,
+		RetryInterval: 50 * time.Millisecond,
+	}, fallback, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cache.Close() })
+	return cache
+}
+
+func TestRedisCacheSharesTemplatesBetweenLoaders(t *testing.T) {
+	redis := newFakeRedis(t, "secret")
+	dir := t.TempDir()
+	writeTemplate(t, filepath.Join(dir, "turn1", "v1.0.0.tmpl"), `{{upper .Name}} {{shout .Name}}`, time.Hour)
+	funcs := map[string]interface{}{"shout": func(s string) string { return s + "!" }}
+
+	first, err := newLoader(Config{BasePath: dir, CustomFuncs: funcs}, newTestRedisCache(t, redis, "prod"), DiscoveryConfig{})
+	if err != nil {
+		t.Fatalf("newLoader failed: %v", err)
+	}
+	if got := render(t, first, "turn1", "1.0.0"); got != "X x!" {
+		t.Fatalf("Unexpected render %q", got)
+	}
+
+	// A second process without the template file parses the shared source
+	// with its own function map
+	secondCache := newTestRedisCache(t, redis, "prod")
+	second, err := newLoader(Config{BasePath: t.TempDir(), CustomFuncs: funcs}, secondCache, DiscoveryConfig{})
+	if err != nil {
+		t.Fatalf("newLoader failed: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if got := render(t, second, "turn1", "v1.0.0"); got != "X x!" {
+			t.Fatalf("Unexpected shared render %q", got)
+		}
+	}
+	tmpl, metadata, ok := secondCache.Get("turn1:1.0.0")
+	if !ok || metadata.Version != "1.0.0" || metadata.Checksum != contentChecksum(metadata.Content) {
+		t.Fatalf("Unexpected cached metadata %+v", metadata)
+	}
+	if again, _, _ := secondCache.Get("turn1:1.0.0"); again != tmpl {
+		t.Error("Expected unchanged content to reuse the parsed template")
+	}
+
+	stats := secondCache.Stats()
+	if stats.Hits != 4 || stats.Misses != 0 || stats.Size != 1 || stats.Degraded {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	if redis.count("GET") != 5 {
+		t.Errorf("Expected every Get to read Redis, got %d GETs", redis.count("GET"))
+	}
+}
+
+func TestRedisCacheNamespacesAndTTL(t *testing.T) {
+	redis := newFakeRedis(t, "")
+	prod := newTestRedisCache(t, redis, "prod")
+	dev := newTestRedisCache(t, redis, "dev")
+
+	set := func(cache *RedisCache, key, content string, ttl time.Duration) {
+		t.Helper()
+		metadata := &TemplateMetadata{Type: "turn1", Version: "1.0.0", Content: content}
+		if err := cache.Set(key, nil, metadata, ttl); err != nil {
+			t.Fatal(err)
+		}
+	}
+	set(prod, "turn1:1.0.0", "prod", 0)
+	set(prod, "turn2:1.0.0", "prod", 50*time.Millisecond)
+	set(dev, "turn1:1.0.0", "dev", 0)
+
+	keys := prod.Keys()
+	sort.Strings(keys)
+	if strings.Join(keys, ",") != "turn1:1.0.0,turn2:1.0.0" {
+		t.Errorf("Unexpected prod keys %v", keys)
+	}
+	if tmpl, _, ok := dev.Get("turn1:1.0.0"); !ok || tmpl.Root.String() != "dev" {
+		t.Errorf("Expected the dev namespace to keep its own template")
+	}
+
+	if prod.HasExpired("turn2:1.0.0") {
+		t.Error("Expected turn2 to be cached")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if !prod.HasExpired("turn2:1.0.0") {
+		t.Error("Expected turn2 to expire")
+	}
+	if _, _, ok := prod.Get("turn2:1.0.0"); ok {
+		t.Error("Expected a miss for an expired template")
+	}
+
+	if err := prod.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if prod.Size() != 0 || dev.Size() != 1 {
+		t.Errorf("Expected Clear to empty only its namespace, prod %d, dev %d", prod.Size(), dev.Size())
+	}
+}
+
+func TestRedisCacheDegradesToMemoryCache(t *testing.T) {
+	redis := newFakeRedis(t, "")
+	cache := newTestRedisCache(t, redis, "")
+	metadata := &TemplateMetadata{Type: "turn1", Version: "1.0.0", Content: "before"}
+	if err := cache.Set("turn1:1.0.0", nil, metadata, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := cache.Get("turn1:1.0.0"); !ok {
+		t.Fatal("Expected a hit")
+	}
+
+	redis.stop()
+	if _, _, ok := cache.Get("turn1:1.0.0"); !ok {
+		t.Error("Expected the fallback cache to serve the template")
+	}
+	if err := cache.Set("turn2:1.0.0", nil, &TemplateMetadata{Type: "turn2", Content: "offline"}, 0); err != nil {
+		t.Errorf("Expected Set to succeed while degraded, got %v", err)
+	}
+	stats := cache.Stats()
+	if !stats.Degraded || stats.RemoteErrors != 1 || stats.Size != 2 || stats.Hits != 2 {
+		t.Errorf("Unexpected degraded stats %+v", stats)
+	}
+
+	// Redis is retried after the retry interval
+	redis.start(redis.addr)
+	time.Sleep(60 * time.Millisecond)
+	if _, _, ok := cache.Get("turn1:1.0.0"); !ok {
+		t.Error("Expected the template to be read from Redis again")
+	}
+	if stats := cache.Stats(); stats.Degraded {
+		t.Errorf("Expected Redis to be used again, got %+v", stats)
+	}
+}
+
+func TestCacheFactoryCreatesRedisCache(t *testing.T) {
+	redis := newFakeRedis(t, "")
+	config := GetDefaultAdvancedConfig().CacheConfig
+	config.Type = "redis"
+	config.Redis = RedisCacheConfig{Addr: redis.addr, Namespace: "test"}
+
+	cache, err := NewCacheFactory().CreateCache(config)
+	if err != nil {
+		t.Fatalf("CreateCache failed: %v", err)
+	}
+	if redisCache, ok := cache.(*RedisCache); !ok || redisCache.prefix != "templateloader:test:" {
+		t.Fatalf("Expected a namespaced RedisCache, got %#v", cache)
+	}
+
+	config.Redis.Addr = ""
+	if _, err := NewCacheFactory().CreateCache(config); err == nil {
+		t.Error("Expected an error without an address")
+	}
+}
+
+func TestRedisCacheRequiresTLSForPassword(t *testing.T) {
+	fallback, err := NewMemoryCache(10, "LRU", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRedisCache(RedisCacheConfig{Addr: "127.0.0.1:6379", Password: "secret"}, fallback, time.Hour); err == nil {
+		t.Error("Expected a password without TLS to be refused")
+	}
+
+	config := GetDefaultAdvancedConfig()
+	config.Config.BasePath = t.TempDir()
+	config.CacheConfig.Type = "redis"
+	config.CacheConfig.Redis = RedisCacheConfig{Addr: "127.0.0.1:6379", Password: "secret"}
+	if err := NewConfigManager().validator.Validate(config); err == nil {
+		t.Error("Expected config validation to refuse a password without TLS")
+	}
+	config.CacheConfig.Redis.TLS = true
+	if err := NewConfigManager().validator.Validate(config); err != nil {
+		t.Errorf("Expected a password over TLS to validate, got %v", err)
+	}
+}
+
+func TestRedisCacheDoesNotTrustUnknownCertificates(t *testing.T) {
+	redis := newFakeRedis(t, "secret")
+	fallback, err := NewMemoryCache(10, "LRU", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache, err := NewRedisCache(RedisCacheConfig{
+		Addr:        redis.addr,
+		Password:    redis.password,
+		TLS:         true,
+		DialTimeout: time.Second,
+	}, fallback, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+
+	if _, _, found := cache.Get("turn1"); found {
+		t.Error("Expected a miss")
+	}
+	if !cache.Stats().Degraded || redis.count("AUTH") != 0 {
+		t.Errorf("Expected the untrusted server to get no AUTH, got %d", redis.count("AUTH"))
+	}
+}
@@ -0,0 +1,170 @@
+package templateloader
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// redisError is an error reply of the Redis server
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// redisClient is a minimal RESP client for the commands RedisCache uses. It
+// holds one connection, serializes commands and reconnects after a network
+// error, which suits Lambdas serving one request at a time.
+type redisClient struct {
+	addr     string
+	password string
+	db       int
+	timeout  time.Duration
+	tls      *tls.Config // nil for plain TCP
+
+	mu   sync.Mutex
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+func newRedisClient(addr, password string, db int, timeout time.Duration, tlsConfig *tls.Config) *redisClient {
+	return &redisClient{addr: addr, password: password, db: db, timeout: timeout, tls: tlsConfig}
+}
+
+// do sends a command and returns its reply: a string for status replies,
+// int64 for integers, []byte or nil for bulk strings and []interface{} for
+// arrays
+func (c *redisClient) do(args ...string) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		if err := c.connect(); err != nil {
+			return nil, err
+		}
+	}
+	reply, err := c.roundTrip(args)
+	if _, isReply := err.(redisError); err != nil && !isReply {
+		c.closeConn()
+	}
+	return reply, err
+}
+
+// close closes the connection
+func (c *redisClient) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeConn()
+	return nil
+}
+
+// connect dials the server and authenticates (must be called with mu held)
+func (c *redisClient) connect() error {
+	var conn net.Conn
+	var err error
+	if c.tls != nil {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: c.timeout}, "tcp", c.addr, c.tls)
+	} else {
+		conn, err = net.DialTimeout("tcp", c.addr, c.timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to redis at %s: %w", c.addr, err)
+	}
+	c.conn, c.rd = conn, bufio.NewReader(conn)
+
+	if c.password != "" {
+		if _, err := c.roundTrip([]string{"AUTH", c.password}); err != nil {
+			c.closeConn()
+			return fmt.Errorf("failed to authenticate to redis at %s: %w", c.addr, err)
+		}
+	}
+	if c.db != 0 {
+		if _, err := c.roundTrip([]string{"SELECT", strconv.Itoa(c.db)}); err != nil {
+			c.closeConn()
+			return fmt.Errorf("failed to select redis database %d: %w", c.db, err)
+		}
+	}
+	return nil
+}
+
+// closeConn drops the connection (must be called with mu held)
+func (c *redisClient) closeConn() {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn, c.rd = nil, nil
+	}
+}
+
+// roundTrip writes a command and reads its reply (must be called with mu held)
+func (c *redisClient) roundTrip(args []string) (interface{}, error) {
+	if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	if _, err := c.conn.Write(buf); err != nil {
+		return nil, err
+	}
+	return readRESP(c.rd)
+}
+
+// readRESP reads one RESP value
+func readRESP(rd *bufio.Reader) (interface{}, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, payload := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, redisError(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(payload)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(payload)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			if values[i], err = readRESP(rd); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply type %q", kind)
+	}
+}
@@ -0,0 +1,182 @@
+package templateloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+)
+
+// S3API is the part of the S3 client used by S3Source
+type S3API interface {
+	ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// DefaultS3Timeout bounds each S3 request of an S3Source
+const DefaultS3Timeout = 10 * time.Second
+
+// S3Source reads templates stored as <prefix>/<type>/v<version>.tmpl objects.
+// Read objects are kept with their ETag and only downloaded again when the
+// object changed.
+type S3Source struct {
+	client  S3API
+	bucket  string
+	prefix  string
+	timeout time.Duration
+
+	mu      sync.Mutex
+	objects map[string]s3Object // read objects by key
+}
+
+// s3Object is a read template object
+type s3Object struct {
+	etag    string
+	content []byte
+}
+
+// NewS3Source creates a source reading templates under prefix in bucket
+func NewS3Source(client S3API, bucket, prefix string) *S3Source {
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return &S3Source{
+		client:  client,
+		bucket:  bucket,
+		prefix:  prefix,
+		timeout: DefaultS3Timeout,
+		objects: make(map[string]s3Object),
+	}
+}
+
+// NewS3SourceFromURI creates a source from a URI such as s3://bucket/templates
+func NewS3SourceFromURI(client S3API, uri string) (*S3Source, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(uri), "s3://")
+	bucket, prefix, _ := strings.Cut(rest, "/")
+	if !ok || bucket == "" {
+		return nil, fmt.Errorf("invalid template S3 URI %q: expected s3://bucket/prefix", uri)
+	}
+	return NewS3Source(client, bucket, prefix), nil
+}
+
+// EnvTemplateS3URI is the environment variable the workflow functions read
+// the S3 URI of their templates from
+const EnvTemplateS3URI = "TEMPLATE_S3_URI"
+
+// NewS3FallbackSource returns the templates at an S3 URI, falling back to the
+// templates bundled under basePath for versions missing in S3 or when S3
+// cannot be reached
+func NewS3FallbackSource(client S3API, uri, basePath string) (Source, error) {
+	source, err := NewS3SourceFromURI(client, uri)
+	if err != nil {
+		return nil, err
+	}
+	return NewFallbackSource(source, NewFilesystemSource(basePath)), nil
+}
+
+// Name returns the S3 URI of the source
+func (ss *S3Source) Name() string {
+	return "s3://" + ss.bucket + "/" + strings.TrimSuffix(ss.prefix, "/")
+}
+
+// List returns the template objects under the prefix
+func (ss *S3Source) List() ([]SourceFile, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ss.timeout)
+	defer cancel()
+
+	var files []SourceFile
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(ss.bucket),
+		Prefix: aws.String(ss.prefix),
+	}
+	for {
+		output, err := ss.client.ListObjectsV2(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list templates in %s: %w", ss.Name(), err)
+		}
+		for _, object := range output.Contents {
+			key := aws.ToString(object.Key)
+			templateType, version, ok := parseTemplateName(strings.TrimPrefix(key, ss.prefix))
+			if !ok {
+				continue
+			}
+			files = append(files, SourceFile{
+				TemplateType: templateType,
+				Version:      version,
+				Location:     ss.location(key),
+				ModTime:      aws.ToTime(object.LastModified),
+				Size:         aws.ToInt64(object.Size),
+				ETag:         strings.Trim(aws.ToString(object.ETag), `"`),
+			})
+		}
+		if !aws.ToBool(output.IsTruncated) || output.NextContinuationToken == nil {
+			return files, nil
+		}
+		input.ContinuationToken = output.NextContinuationToken
+	}
+}
+
+// Read downloads a template object, or returns the object read before when
+// its ETag did not change
+func (ss *S3Source) Read(templateType, version string) ([]byte, string, error) {
+	key := ss.prefix + normalizeTemplateType(templateType) + ".tmpl"
+	if version != "" {
+		key = ss.prefix + normalizeTemplateType(templateType) + "/" + versionFileName(version)
+	}
+	location := ss.location(key)
+
+	ss.mu.Lock()
+	cached, hasCached := ss.objects[key]
+	ss.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), ss.timeout)
+	defer cancel()
+
+	input := &s3.GetObjectInput{Bucket: aws.String(ss.bucket), Key: aws.String(key)}
+	if hasCached {
+		input.IfNoneMatch = aws.String(`"` + cached.etag + `"`)
+	}
+	output, err := ss.client.GetObject(ctx, input)
+	if err != nil {
+		if hasCached && isNotModified(err) {
+			return cached.content, location, nil
+		}
+		return nil, location, fmt.Errorf("failed to read template %s: %w", location, err)
+	}
+	defer output.Body.Close()
+
+	content, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, location, fmt.Errorf("failed to read template %s: %w", location, err)
+	}
+
+	ss.mu.Lock()
+	ss.objects[key] = s3Object{etag: strings.Trim(aws.ToString(output.ETag), `"`), content: content}
+	ss.mu.Unlock()
+	return content, location, nil
+}
+
+// location returns the S3 URI of a key
+func (ss *S3Source) location(key string) string {
+	return "s3://" + ss.bucket + "/" + key
+}
+
+// isNotModified reports whether a conditional GetObject found the object
+// unchanged (HTTP 304)
+func isNotModified(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NotModified" {
+		return true
+	}
+	var statusErr interface{ HTTPStatusCode() int }
+	return errors.As(err, &statusErr) && statusErr.HTTPStatusCode() == http.StatusNotModified
+}
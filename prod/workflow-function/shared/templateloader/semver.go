@@ -0,0 +1,337 @@
+package templateloader
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version as defined by SemVer 2.0. Missing minor and
+// patch numbers are zero, so "v1.0" is the same version as "1.0.0".
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease []string // dot-separated identifiers after "-"
+	Build      string   // after "+", ignored for precedence
+}
+
+// ParseVersion parses versions such as "1.2.0", "v1.2" or "1.2.0-rc.1+build.5"
+func ParseVersion(s string) (Version, error) {
+	var v Version
+	rest := normalizeVersion(s)
+	if rest == "" {
+		return v, fmt.Errorf("invalid version %q: empty", s)
+	}
+
+	if i := strings.IndexByte(rest, '+'); i >= 0 {
+		v.Build = rest[i+1:]
+		rest = rest[:i]
+		if !validIdentifiers(v.Build, false) {
+			return Version{}, fmt.Errorf("invalid version %q: bad build metadata", s)
+		}
+	}
+	if i := strings.IndexByte(rest, '-'); i >= 0 {
+		prerelease := rest[i+1:]
+		rest = rest[:i]
+		if !validIdentifiers(prerelease, true) {
+			return Version{}, fmt.Errorf("invalid version %q: bad pre-release", s)
+		}
+		v.Prerelease = strings.Split(prerelease, ".")
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) > 3 {
+		return Version{}, fmt.Errorf("invalid version %q: more than three numbers", s)
+	}
+	numbers := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := parseVersionNumber(part)
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid version %q: %w", s, err)
+		}
+		*numbers[i] = n
+	}
+	return v, nil
+}
+
+// parseVersionNumber parses a major, minor or patch number
+func parseVersionNumber(s string) (int, error) {
+	if s == "" || (len(s) > 1 && s[0] == '0') {
+		return 0, fmt.Errorf("bad number %q", s)
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("bad number %q", s)
+	}
+	return n, nil
+}
+
+// validIdentifiers checks dot-separated pre-release or build identifiers;
+// numeric pre-release identifiers must not have leading zeros
+func validIdentifiers(s string, prerelease bool) bool {
+	if s == "" {
+		return false
+	}
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+		for _, r := range id {
+			if !(r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+				return false
+			}
+		}
+		if prerelease && isNumeric(id) && len(id) > 1 && id[0] == '0' {
+			return false
+		}
+	}
+	return true
+}
+
+func isNumeric(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// String formats the version without the "v" prefix
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// IsPrerelease reports whether the version has a pre-release
+func (v Version) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// Compare returns -1, 0 or 1 by SemVer precedence: a pre-release is lower
+// than its release, and pre-release identifiers compare numerically when
+// both are numbers, else in ASCII order, numbers first
+func (v Version) Compare(o Version) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+
+	switch {
+	case len(v.Prerelease) == 0 && len(o.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(o.Prerelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.Prerelease) && i < len(o.Prerelease); i++ {
+		if c := comparePrerelease(v.Prerelease[i], o.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return sign(len(v.Prerelease) - len(o.Prerelease))
+}
+
+// comparePrerelease compares two pre-release identifiers
+func comparePrerelease(a, b string) int {
+	aNum, bNum := isNumeric(a), isNumeric(b)
+	switch {
+	case aNum && bNum:
+		if len(a) != len(b) {
+			return sign(len(a) - len(b))
+		}
+		return strings.Compare(a, b)
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Constraint keywords
+const (
+	ConstraintLatest       = "latest"        // highest version, pre-releases included
+	ConstraintLatestStable = "latest-stable" // highest version without pre-release
+)
+
+// comparator is one bound of a range constraint
+type comparator struct {
+	op      string // =, >, >=, <, <=
+	version Version
+}
+
+func (c comparator) matches(v Version) bool {
+	cmp := v.Compare(c.version)
+	switch c.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// Constraint selects template versions. Supported forms:
+//
+//	1.2.0, v1.0          exactly that version
+//	^1.2                 >=1.2.0 <2.0.0 (^0.2 is >=0.2.0 <0.3.0)
+//	~1.1.0               >=1.1.0 <1.2.0
+//	>=1.0.0 <2.0.0       space-separated comparators, all must match
+//	latest, *            highest version, pre-releases included
+//	latest-stable        highest version without pre-release
+//
+// Ranges only match pre-releases of a version one of their bounds is a
+// pre-release of, so >=1.2.0-beta matches 1.2.0-rc1 but not 1.3.0-rc1.
+type Constraint struct {
+	raw         string
+	exact       *Version
+	comparators []comparator
+	prerelease  bool // any pre-release may match
+}
+
+// ParseConstraint parses a version or version constraint
+func ParseConstraint(s string) (*Constraint, error) {
+	raw := strings.TrimSpace(s)
+	c := &Constraint{raw: raw}
+
+	switch strings.ToLower(raw) {
+	case "", ConstraintLatest, "*":
+		c.prerelease = true
+		return c, nil
+	case ConstraintLatestStable:
+		return c, nil
+	}
+
+	for _, field := range strings.Fields(raw) {
+		comparators, err := parseComparators(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %w", s, err)
+		}
+		c.comparators = append(c.comparators, comparators...)
+	}
+	if len(c.comparators) == 1 && c.comparators[0].op == "=" {
+		c.exact = &c.comparators[0].version
+		c.prerelease = true
+	}
+	return c, nil
+}
+
+// parseComparators parses one field of a constraint into its bounds
+func parseComparators(field string) ([]comparator, error) {
+	switch {
+	case strings.HasPrefix(field, "^"):
+		v, parts, err := parsePartialVersion(field[1:])
+		if err != nil {
+			return nil, err
+		}
+		upper := Version{Major: v.Major + 1}
+		switch {
+		case v.Major == 0 && v.Minor == 0 && parts == 3:
+			upper = Version{Patch: v.Patch + 1}
+		case v.Major == 0 && parts >= 2:
+			upper = Version{Minor: v.Minor + 1}
+		}
+		return []comparator{{">=", v}, {"<", upper}}, nil
+	case strings.HasPrefix(field, "~"):
+		v, parts, err := parsePartialVersion(field[1:])
+		if err != nil {
+			return nil, err
+		}
+		upper := Version{Major: v.Major, Minor: v.Minor + 1}
+		if parts == 1 {
+			upper = Version{Major: v.Major + 1}
+		}
+		return []comparator{{">=", v}, {"<", upper}}, nil
+	}
+
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(field, op) {
+			v, err := ParseVersion(field[len(op):])
+			if err != nil {
+				return nil, err
+			}
+			return []comparator{{op, v}}, nil
+		}
+	}
+	v, err := ParseVersion(field)
+	if err != nil {
+		return nil, err
+	}
+	return []comparator{{"=", v}}, nil
+}
+
+// parsePartialVersion parses a version and returns how many of major, minor
+// and patch were given
+func parsePartialVersion(s string) (Version, int, error) {
+	v, err := ParseVersion(s)
+	if err != nil {
+		return Version{}, 0, err
+	}
+	core := normalizeVersion(s)
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core = core[:i]
+	}
+	return v, strings.Count(core, ".") + 1, nil
+}
+
+// String returns the constraint as given
+func (c *Constraint) String() string {
+	return c.raw
+}
+
+// Exact reports whether the constraint names a single version
+func (c *Constraint) Exact() bool {
+	return c.exact != nil
+}
+
+// Check reports whether a version satisfies the constraint
+func (c *Constraint) Check(v Version) bool {
+	if v.IsPrerelease() && !c.prerelease && !c.allowsPrereleaseOf(v) {
+		return false
+	}
+	for _, comp := range c.comparators {
+		if !comp.matches(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// allowsPrereleaseOf reports whether a bound is a pre-release of the same
+// major, minor and patch version as v
+func (c *Constraint) allowsPrereleaseOf(v Version) bool {
+	for _, comp := range c.comparators {
+		b := comp.version
+		if b.IsPrerelease() && b.Major == v.Major && b.Minor == v.Minor && b.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package templateloader
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseVersion(t *testing.T) {
+	valid := map[string]string{
+		"1.2.3":              "1.2.3",
+		"v1.0":               "1.0.0",
+		"2":                  "2.0.0",
+		"1.2.0-rc.1+build.5": "1.2.0-rc.1+build.5",
+		"1.0.0-x-y.0a":       "1.0.0-x-y.0a",
+	}
+	for in, want := range valid {
+		v, err := ParseVersion(in)
+		if err != nil || v.String() != want {
+			t.Errorf("ParseVersion(%q) = %s, %v, want %s", in, v, err, want)
+		}
+	}
+	for _, in := range []string{"", "v", "1.2.3.4", "01.0.0", "1.0.0-", "1.0.0-01", "1.0.0-a..b", "1.0.0+", "1.x.0", "1.0.0-beta_1"} {
+		if _, err := ParseVersion(in); err == nil {
+			t.Errorf("ParseVersion(%q) should fail", in)
+		}
+	}
+}
+
+func TestVersionPrecedence(t *testing.T) {
+	// Ascending precedence from the SemVer 2.0 specification
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2",
+		"1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.2.0-beta", "1.2.0-rc1", "1.2.0", "1.10.0", "2.0.0",
+	}
+	shuffled := append([]string{}, ordered...)
+	sort.Sort(sort.Reverse(sort.StringSlice(shuffled)))
+	sort.Slice(shuffled, func(i, j int) bool {
+		a, _ := ParseVersion(shuffled[i])
+		b, _ := ParseVersion(shuffled[j])
+		return a.Compare(b) < 0
+	})
+	if strings.Join(shuffled, " ") != strings.Join(ordered, " ") {
+		t.Errorf("Unexpected order %v", shuffled)
+	}
+
+	a, _ := ParseVersion("1.0.0+build.1")
+	b, _ := ParseVersion("v1.0")
+	if a.Compare(b) != 0 {
+		t.Error("Expected build metadata to be ignored")
+	}
+}
+
+func TestConstraintCheck(t *testing.T) {
+	cases := []struct {
+		constraint string
+		match      []string
+		noMatch    []string
+	}{
+		{"^1.2", []string{"1.2.0", "1.9.9"}, []string{"1.1.9", "2.0.0", "1.3.0-beta"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0", "0.2.2"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"~1.1.0", []string{"1.1.0", "1.1.7"}, []string{"1.2.0", "1.0.9"}},
+		{"~1", []string{"1.0.0", "1.9.0"}, []string{"2.0.0"}},
+		{">=1.0.0 <2.0.0", []string{"1.0.0", "1.5.0"}, []string{"2.0.0", "0.9.0"}},
+		{">=1.2.0-beta", []string{"1.2.0-rc1", "1.2.0", "3.0.0"}, []string{"1.2.0-alpha", "1.3.0-rc1"}},
+		{"latest", []string{"1.0.0", "3.0.0-rc.1"}, nil},
+		{"latest-stable", []string{"1.0.0"}, []string{"3.0.0-rc.1"}},
+		{"v1.0", []string{"1.0.0"}, []string{"1.0.1"}},
+	}
+	for _, tc := range cases {
+		c, err := ParseConstraint(tc.constraint)
+		if err != nil {
+			t.Fatalf("ParseConstraint(%q) failed: %v", tc.constraint, err)
+		}
+		for _, version := range tc.match {
+			if v, _ := ParseVersion(version); !c.Check(v) {
+				t.Errorf("%s should match %s", tc.constraint, version)
+			}
+		}
+		for _, version := range tc.noMatch {
+			if v, _ := ParseVersion(version); c.Check(v) {
+				t.Errorf("%s should not match %s", tc.constraint, version)
+			}
+		}
+	}
+
+	for _, invalid := range []string{"^", "~x", ">=1.0.0 <", "newest"} {
+		if _, err := ParseConstraint(invalid); err == nil {
+			t.Errorf("ParseConstraint(%q) should fail", invalid)
+		}
+	}
+}
+
+func TestResolveVersionConstraints(t *testing.T) {
+	dir := t.TempDir()
+	for version, content := range map[string]string{
+		"1.0.0":      "v1.0.0",
+		"1.1.0":      "v1.1.0",
+		"1.1.5":      "{{/* deprecated: use 1.2.0 */}}v1.1.5",
+		"1.2.0-beta": "v1.2.0-beta",
+		"1.2.0-rc1":  "v1.2.0-rc1",
+		"2.0.0-rc.1": "v2.0.0-rc.1",
+	} {
+		writeTemplate(t, filepath.Join(dir, "turn1", "v"+version+".tmpl"), content, time.Hour)
+	}
+	writeTemplate(t, filepath.Join(dir, "turn1", "vnext.tmpl"), "unparsable", time.Hour)
+
+	loader, err := New(Config{BasePath: dir, CacheEnabled: true})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	if got := strings.Join(loader.ListVersions("turn1"), ","); got != "1.0.0,1.1.0,1.1.5,1.2.0-beta,1.2.0-rc1,2.0.0-rc.1" {
+		t.Fatalf("Unexpected versions %s", got)
+	}
+	versions := loader.VersionInfos("turn1")
+	if !versions[2].Deprecated || versions[2].DeprecationNote != "use 1.2.0" || !versions[3].Prerelease || !versions[1].IsLatest {
+		t.Errorf("Unexpected version metadata %+v", versions)
+	}
+
+	for constraint, want := range map[string]string{
+		"v1.0":          "1.0.0",
+		"^1.0":          "1.1.0", // 1.1.5 is deprecated
+		"~1.1.5":        "1.1.5", // only a deprecated version matches
+		">=1.2.0-beta":  "1.2.0-rc1",
+		"^1.2.0-beta":   "1.2.0-rc1",
+		"latest":        "2.0.0-rc.1",
+		"latest-stable": "1.1.0",
+	} {
+		if got, err := loader.ResolveVersion("turn1", constraint); err != nil || got != want {
+			t.Errorf("ResolveVersion(%q) = %s, %v, want %s", constraint, got, err, want)
+		}
+	}
+	if got := loader.GetLatestVersion("turn1"); got != "1.1.0" {
+		t.Errorf("Expected the latest stable version, got %s", got)
+	}
+	if out, err := loader.RenderTemplateWithVersion("turn1", "^1.2.0-beta", nil); err != nil || out != "v1.2.0-rc1" {
+		t.Errorf("Unexpected render %q, %v", out, err)
+	}
+
+	_, err = loader.ResolveVersion("turn1", "^3")
+	if err == nil || !strings.Contains(err.Error(), `satisfies "^3"`) || !strings.Contains(err.Error(), "available: 1.0.0") {
+		t.Errorf("Expected an unresolvable constraint error, got %v", err)
+	}
+	if _, err := loader.LoadTemplateWithVersion("turn1", "~9.0"); err == nil {
+		t.Error("Expected LoadTemplateWithVersion to fail for an unresolvable constraint")
+	}
+}
+
+func TestPinnedConstraint(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, filepath.Join(dir, "turn2", "v1.4.0.tmpl"), "1.4", time.Hour)
+	writeTemplate(t, filepath.Join(dir, "turn2", "v2.0.0.tmpl"), "2.0", time.Hour)
+
+	loader, err := New(Config{BasePath: dir, PinnedVersions: map[string]string{"turn2": "^1", "turn3": "^1"}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if out, err := loader.RenderTemplate("turn2", nil); err != nil || out != "1.4" {
+		t.Errorf("Expected the pinned range, got %q, %v", out, err)
+	}
+	if got := loader.GetLatestVersion("turn3"); got != "" {
+		t.Errorf("Expected no version for an unresolvable pin, got %s", got)
+	}
+}
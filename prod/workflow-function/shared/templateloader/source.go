@@ -0,0 +1,262 @@
+package templateloader
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Source is where the loader reads templates from. Templates are laid out as
+// <type>/v<version>.tmpl, or <type>.tmpl for flat templates, under the root
+// of the source; localized versions as <type>/<locale>/v<version>.tmpl under
+// the template type <type>/<locale>.
+type Source interface {
+	// Name describes the source in logs and errors, e.g. a directory or an S3 URI
+	Name() string
+
+	// List returns the template files of the source
+	List() ([]SourceFile, error)
+
+	// Read returns the content and location of a template version. An
+	// empty version reads the flat template.
+	Read(templateType, version string) ([]byte, string, error)
+}
+
+// SourceFile is a template file of a source
+type SourceFile struct {
+	TemplateType string `json:"template_type"`
+	// Version is empty for flat templates
+	Version string `json:"version,omitempty"`
+	// Location is the path or URI of the file
+	Location string    `json:"location"`
+	ModTime  time.Time `json:"mod_time"`
+	Size     int64     `json:"size"`
+	// ETag is a content hash provided by the source, if any
+	ETag string `json:"etag,omitempty"`
+}
+
+// versionFileName returns the file name of a template version
+func versionFileName(version string) string {
+	return fmt.Sprintf("v%s.tmpl", normalizeVersion(version))
+}
+
+// parseTemplateName parses a path relative to the source root into the
+// template type and version; ok is false for files that are not templates
+func parseTemplateName(rel string) (templateType, version string, ok bool) {
+	dir, name := path.Split(rel)
+	dir = strings.TrimSuffix(dir, "/")
+	if !strings.HasSuffix(name, ".tmpl") {
+		return "", "", false
+	}
+	if i := strings.Index(dir, "/"); i >= 0 {
+		// Only versions are localized: <type>/<locale>/v<version>.tmpl
+		if base, locale := splitLocale(dir); base != dir[:i] || locale == DefaultLocale {
+			return "", "", false
+		}
+	}
+	if dir == "" {
+		return strings.TrimSuffix(name, ".tmpl"), "", true
+	}
+	if !strings.HasPrefix(name, "v") {
+		return "", "", false
+	}
+	return dir, strings.TrimSuffix(strings.TrimPrefix(name, "v"), ".tmpl"), true
+}
+
+// normalizeVersion removes the "v" prefix of versions such as "v1.2.0"
+func normalizeVersion(version string) string {
+	return strings.TrimPrefix(strings.TrimSpace(version), "v")
+}
+
+// FilesystemSource reads templates from a directory, such as the templates
+// bundled into a Lambda image under /opt/templates
+type FilesystemSource struct {
+	basePath string
+}
+
+// NewFilesystemSource creates a source reading templates under basePath
+func NewFilesystemSource(basePath string) *FilesystemSource {
+	return &FilesystemSource{basePath: basePath}
+}
+
+// Name returns the base path
+func (fs *FilesystemSource) Name() string {
+	return fs.basePath
+}
+
+// List returns the versioned, localized and flat templates of the directory
+func (fs *FilesystemSource) List() ([]SourceFile, error) {
+	entries, err := os.ReadDir(fs.basePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template base directory %s: %w", fs.basePath, err)
+	}
+
+	var files []SourceFile
+	add := func(rel string) {
+		templateType, version, ok := parseTemplateName(rel)
+		if !ok {
+			return
+		}
+		location := filepath.Join(fs.basePath, filepath.FromSlash(rel))
+		info, err := os.Stat(location)
+		if err != nil || info.IsDir() {
+			return
+		}
+		files = append(files, SourceFile{
+			TemplateType: templateType,
+			Version:      version,
+			Location:     location,
+			ModTime:      info.ModTime(),
+			Size:         info.Size(),
+		})
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			add(entry.Name())
+			continue
+		}
+		versionEntries, err := os.ReadDir(filepath.Join(fs.basePath, entry.Name()))
+		if err != nil {
+			continue
+		}
+		for _, versionEntry := range versionEntries {
+			if !versionEntry.IsDir() {
+				add(entry.Name() + "/" + versionEntry.Name())
+				continue
+			}
+			if NormalizeLocale(versionEntry.Name()) != versionEntry.Name() {
+				continue
+			}
+			localeDir := entry.Name() + "/" + versionEntry.Name()
+			localeEntries, err := os.ReadDir(filepath.Join(fs.basePath, filepath.FromSlash(localeDir)))
+			if err != nil {
+				continue
+			}
+			for _, localeEntry := range localeEntries {
+				if !localeEntry.IsDir() {
+					add(localeDir + "/" + localeEntry.Name())
+				}
+			}
+		}
+	}
+	return files, nil
+}
+
+// Read reads a template file
+func (fs *FilesystemSource) Read(templateType, version string) ([]byte, string, error) {
+	location := filepath.Join(fs.basePath, normalizeTemplateType(templateType)+".tmpl")
+	if version != "" {
+		location = filepath.Join(fs.basePath, normalizeTemplateType(templateType), versionFileName(version))
+	}
+	content, err := os.ReadFile(location)
+	if err != nil {
+		return nil, location, fmt.Errorf("failed to read template file at %s: %w", location, err)
+	}
+	return content, location, nil
+}
+
+// FallbackSource reads templates from a primary source, such as S3, and
+// falls back to a second source, such as the bundled templates, for
+// templates the primary source cannot provide
+type FallbackSource struct {
+	primary  Source
+	fallback Source
+}
+
+// NewFallbackSource creates a source preferring primary over fallback
+func NewFallbackSource(primary, fallback Source) *FallbackSource {
+	return &FallbackSource{primary: primary, fallback: fallback}
+}
+
+// Name describes both sources
+func (fs *FallbackSource) Name() string {
+	return fmt.Sprintf("%s (fallback %s)", fs.primary.Name(), fs.fallback.Name())
+}
+
+// List merges the files of both sources; a version in the primary source
+// hides the same version in the fallback source. When the primary source
+// cannot be listed only the fallback files are returned.
+func (fs *FallbackSource) List() ([]SourceFile, error) {
+	primary, primaryErr := fs.primary.List()
+	if primaryErr != nil {
+		log.Printf("Warning: failed to list templates in %s, using %s - %v", fs.primary.Name(), fs.fallback.Name(), primaryErr)
+	}
+	fallback, fallbackErr := fs.fallback.List()
+	if primaryErr != nil && fallbackErr != nil {
+		return nil, fmt.Errorf("failed to list templates: %v; fallback: %w", primaryErr, fallbackErr)
+	}
+
+	seen := make(map[string]bool)
+	files := append([]SourceFile{}, primary...)
+	for _, file := range primary {
+		seen[normalizeTemplateType(file.TemplateType)+":"+file.Version] = true
+	}
+	for _, file := range fallback {
+		if !seen[normalizeTemplateType(file.TemplateType)+":"+file.Version] {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// Read reads a template from the primary source, else from the fallback source
+func (fs *FallbackSource) Read(templateType, version string) ([]byte, string, error) {
+	content, location, err := fs.primary.Read(templateType, version)
+	if err == nil {
+		return content, location, nil
+	}
+	content, location, fallbackErr := fs.fallback.Read(templateType, version)
+	if fallbackErr != nil {
+		return nil, location, fmt.Errorf("%v; fallback: %w", err, fallbackErr)
+	}
+	return content, location, nil
+}
+
+// Environment variables that pin the version of prompt templates, by
+// template type pattern
+var promptVersionEnvironment = map[string]string{
+	"turn1-*": "TURN1_PROMPT_VERSION",
+	"turn2-*": "TURN2_PROMPT_VERSION",
+	"turn3-*": "TURN3_PROMPT_VERSION",
+}
+
+// PinnedVersionsFromEnv returns the pinned versions set by
+// TURN1_PROMPT_VERSION, TURN2_PROMPT_VERSION and TURN3_PROMPT_VERSION, for
+// Config.PinnedVersions
+func PinnedVersionsFromEnv() map[string]string {
+	pins := make(map[string]string)
+	for pattern, envVar := range promptVersionEnvironment {
+		if version := strings.TrimSpace(os.Getenv(envVar)); version != "" {
+			pins[pattern] = version
+		}
+	}
+	return pins
+}
+
+// pinnedVersion returns the version pinned for a template type: an exact
+// match, else the first matching pattern in sorted order. Localized template
+// types use the pin of their template type.
+func pinnedVersion(pins map[string]string, templateType string) string {
+	// Localized templates share the pins of their template type
+	templateType, _ = splitLocale(templateType)
+	if version, ok := pins[templateType]; ok {
+		return normalizeVersion(version)
+	}
+	patterns := make([]string, 0, len(pins))
+	for pattern := range pins {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, templateType); matched {
+			return normalizeVersion(pins[pattern])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,262 @@
+package templateloader
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// fakeS3 is an in-memory bucket answering conditional GetObject requests
+// like S3
+type fakeS3 struct {
+	mu        sync.Mutex
+	objects   map[string]string
+	listErr   error
+	downloads int
+}
+
+func newFakeS3(objects map[string]string) *fakeS3 {
+	return &fakeS3{objects: objects}
+}
+
+func (f *fakeS3) put(key, body string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[key] = body
+}
+
+func etag(body string) string {
+	sum := md5.Sum([]byte(body))
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func (f *fakeS3) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+
+	var keys []string
+	for key := range f.objects {
+		if strings.HasPrefix(key, aws.ToString(params.Prefix)) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	// Two keys per page to exercise pagination
+	start := 0
+	if params.ContinuationToken != nil {
+		fmt.Sscan(aws.ToString(params.ContinuationToken), &start)
+	}
+	end := start + 2
+	output := &s3.ListObjectsV2Output{}
+	if end < len(keys) {
+		output.IsTruncated = aws.Bool(true)
+		output.NextContinuationToken = aws.String(fmt.Sprint(end))
+	} else {
+		end = len(keys)
+	}
+	for _, key := range keys[start:end] {
+		output.Contents = append(output.Contents, types.Object{
+			Key:          aws.String(key),
+			ETag:         aws.String(etag(f.objects[key])),
+			Size:         aws.Int64(int64(len(f.objects[key]))),
+			LastModified: aws.Time(time.Unix(0, 0)),
+		})
+	}
+	return output, nil
+}
+
+func (f *fakeS3) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	body, ok := f.objects[aws.ToString(params.Key)]
+	if !ok {
+		return nil, &types.NoSuchKey{Message: aws.String("not found")}
+	}
+	if aws.ToString(params.IfNoneMatch) == etag(body) {
+		return nil, &smithy.GenericAPIError{Code: "NotModified", Message: "Not Modified"}
+	}
+	f.downloads++
+	return &s3.GetObjectOutput{
+		Body: io.NopCloser(bytes.NewReader([]byte(body))),
+		ETag: aws.String(etag(body)),
+	}, nil
+}
+
+func TestS3SourceDiscoversVersions(t *testing.T) {
+	client := newFakeS3(map[string]string{
+		"prompts/turn1-layout-vs-checking/v1.2.0.tmpl":  "turn1 v1.2.0",
+		"prompts/turn1-layout-vs-checking/v1.10.0.tmpl": "turn1 v1.10.0 {{.Name}}",
+		"prompts/turn1-layout-vs-checking/notes.txt":    "ignored",
+		"prompts/turn2-layout-vs-checking/v1.0.0.tmpl":  "turn2 v1.0.0",
+		"prompts/README.md":                             "ignored",
+		"other/turn3-self-verification/v9.0.0.tmpl":     "outside the prefix",
+	})
+	source, err := NewS3SourceFromURI(client, "s3://templates-bucket/prompts/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loader, err := New(Config{CacheEnabled: true, Source: source})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if got := loader.ListVersions("turn1_layout_vs_checking"); strings.Join(got, ",") != "1.2.0,1.10.0" {
+		t.Errorf("Unexpected versions %v", got)
+	}
+	if got := loader.GetLatestVersion("turn3-self-verification"); got != "" {
+		t.Errorf("Expected no version outside the prefix, got %s", got)
+	}
+	out, err := loader.RenderTemplate("turn1-layout-vs-checking", map[string]string{"Name": "x"})
+	if err != nil || out != "turn1 v1.10.0 x" {
+		t.Errorf("RenderTemplate returned %q, %v", out, err)
+	}
+}
+
+func TestS3SourceReadUsesETag(t *testing.T) {
+	client := newFakeS3(map[string]string{"turn1/v1.0.0.tmpl": "first"})
+	source := NewS3Source(client, "bucket", "")
+
+	for i := 0; i < 2; i++ {
+		content, location, err := source.Read("turn1", "v1.0.0")
+		if err != nil || string(content) != "first" || location != "s3://bucket/turn1/v1.0.0.tmpl" {
+			t.Fatalf("Read returned %q, %s, %v", content, location, err)
+		}
+	}
+	if client.downloads != 1 {
+		t.Errorf("Expected an unchanged object to be downloaded once, got %d downloads", client.downloads)
+	}
+
+	client.put("turn1/v1.0.0.tmpl", "second")
+	if content, _, err := source.Read("turn1", "1.0.0"); err != nil || string(content) != "second" {
+		t.Errorf("Expected the changed object, got %q, %v", content, err)
+	}
+	if _, _, err := source.Read("turn1", "2.0.0"); err == nil {
+		t.Error("Expected an error for a missing version")
+	}
+}
+
+func TestFallbackToBundledTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, filepath.Join(dir, "turn2-layout-vs-checking", "v1.0.0.tmpl"), "bundled v1.0.0", time.Hour)
+	writeTemplate(t, filepath.Join(dir, "turn2-layout-vs-checking", "v1.1.0.tmpl"), "bundled v1.1.0", time.Hour)
+	client := newFakeS3(map[string]string{
+		"turn2-layout-vs-checking/v1.1.0.tmpl": "s3 v1.1.0",
+		"turn2-layout-vs-checking/v1.2.0.tmpl": "s3 v1.2.0",
+	})
+	source := NewFallbackSource(NewS3Source(client, "bucket", ""), NewFilesystemSource(dir))
+
+	loader, err := New(Config{BasePath: dir, CacheEnabled: true, Source: source})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if got := loader.ListVersions("turn2-layout-vs-checking"); strings.Join(got, ",") != "1.0.0,1.1.0,1.2.0" {
+		t.Errorf("Unexpected merged versions %v", got)
+	}
+	for version, want := range map[string]string{"1.0.0": "bundled v1.0.0", "1.1.0": "s3 v1.1.0", "1.2.0": "s3 v1.2.0"} {
+		if got, err := loader.RenderTemplateWithVersion("turn2-layout-vs-checking", version, nil); err != nil || got != want {
+			t.Errorf("%s: got %q, %v, want %q", version, got, err, want)
+		}
+	}
+
+	// S3 unavailable at start-up: the bundled templates are used
+	client.listErr = fmt.Errorf("access denied")
+	loader, err = New(Config{BasePath: dir, CacheEnabled: true, Source: source})
+	if err != nil {
+		t.Fatalf("New failed without S3: %v", err)
+	}
+	if got := loader.GetLatestVersion("turn2-layout-vs-checking"); got != "1.1.0" {
+		t.Errorf("Expected the bundled latest version, got %s", got)
+	}
+}
+
+func TestPinnedVersions(t *testing.T) {
+	t.Setenv("TURN1_PROMPT_VERSION", "v1.0.0")
+	t.Setenv("TURN2_PROMPT_VERSION", "")
+	pins := PinnedVersionsFromEnv()
+	if len(pins) != 1 || pins["turn1-*"] != "v1.0.0" {
+		t.Fatalf("Unexpected pins %v", pins)
+	}
+
+	dir := t.TempDir()
+	writeTemplate(t, filepath.Join(dir, "turn1-layout-vs-checking", "v1.0.0.tmpl"), "pinned", time.Hour)
+	writeTemplate(t, filepath.Join(dir, "turn1-layout-vs-checking", "v1.1.0.tmpl"), "latest", time.Hour)
+	writeTemplate(t, filepath.Join(dir, "turn2-layout-vs-checking", "v1.0.0.tmpl"), "turn2 v1.0.0", time.Hour)
+	writeTemplate(t, filepath.Join(dir, "turn2-layout-vs-checking", "v2.0.0.tmpl"), "turn2 v2.0.0", time.Hour)
+	pins["turn2-layout-vs-checking"] = "1.0.0"
+	pins["turn2-*"] = "2.0.0"
+
+	loader, err := New(Config{BasePath: dir, CacheEnabled: true, PinnedVersions: pins})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if out, err := loader.RenderTemplate("turn1-layout-vs-checking", nil); err != nil || out != "pinned" {
+		t.Errorf("Expected the pinned version, got %q, %v", out, err)
+	}
+	if got := loader.GetLatestVersion("turn2-layout-vs-checking"); got != "1.0.0" {
+		t.Errorf("Expected the exact pin to win over the pattern, got %s", got)
+	}
+	if out, err := loader.RenderTemplateWithVersion("turn1-layout-vs-checking", "v1.1.0", nil); err != nil || out != "latest" {
+		t.Errorf("Expected an explicit version to ignore the pin, got %q, %v", out, err)
+	}
+}
+
+func TestParseTemplateName(t *testing.T) {
+	cases := map[string][3]string{
+		"turn1/v1.0.0.tmpl": {"turn1", "1.0.0", "true"},
+		"turn1.tmpl":        {"turn1", "", "true"},
+		"turn1/draft.tmpl":  {"", "", "false"},
+		"turn1/v1.0.0.txt":  {"", "", "false"},
+		"a/b/v1.0.0.tmpl":   {"", "", "false"},
+	}
+	for rel, want := range cases {
+		templateType, version, ok := parseTemplateName(rel)
+		if templateType != want[0] || version != want[1] || fmt.Sprint(ok) != want[2] {
+			t.Errorf("%s: got %q, %q, %v", rel, templateType, version, ok)
+		}
+	}
+
+	for _, uri := range []string{"bucket/prefix", "s3://", "s3:///prefix"} {
+		if _, err := NewS3SourceFromURI(nil, uri); err == nil {
+			t.Errorf("%s: expected an error", uri)
+		}
+	}
+}
+
+func TestCheckForChangesComparesS3ETags(t *testing.T) {
+	client := newFakeS3(map[string]string{"turn1/v1.0.0.tmpl": "before"})
+	loader, err := New(Config{CacheEnabled: true, Source: NewS3Source(client, "bucket", "")})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if out, _ := loader.RenderTemplate("turn1", nil); out != "before" {
+		t.Fatalf("Unexpected render %q", out)
+	}
+
+	client.put("turn1/v1.0.0.tmpl", "after")
+	changes, err := loader.CheckForChanges()
+	if err != nil || len(changes) != 1 || changes[0].Type != ChangeModified || changes[0].Checksum != strings.Trim(etag("after"), `"`) {
+		t.Fatalf("Expected a modified object, got %+v, %v", changes, err)
+	}
+	if out, _ := loader.RenderTemplate("turn1", nil); out != "after" {
+		t.Errorf("Expected the changed object, got %q", out)
+	}
+}
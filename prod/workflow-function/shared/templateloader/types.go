@@ -15,6 +15,10 @@ type TemplateMetadata struct {
 	Size     int64                  `json:"size"`
 	Checksum string                 `json:"checksum,omitempty"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// Content is the template source, kept so caches shared between
+	// processes, such as RedisCache, can compile the template again
+	Content string `json:"-"`
 }
 
 // TemplateInfo represents information about an available template
@@ -78,6 +82,20 @@ type ValidationResult struct {
 	Rules    []ValidationRule `json:"rules,omitempty"`
 }
 
+// Template types used by the workflow functions. The loader resolves any
+// directory under the base path; these name the ones referenced from code.
+const (
+	// TemplateTypeTurn2LayoutVsChecking is the Turn 2 prompt for LAYOUT_VS_CHECKING
+	TemplateTypeTurn2LayoutVsChecking = "turn2-layout-vs-checking"
+
+	// TemplateTypeTurn2PreviousVsCurrent is the Turn 2 prompt for PREVIOUS_VS_CURRENT
+	TemplateTypeTurn2PreviousVsCurrent = "turn2-previous-vs-current"
+
+	// TemplateTypeTurn3SelfVerification is the optional Turn 3 prompt that
+	// re-examines the discrepancies reported in Turn 2
+	TemplateTypeTurn3SelfVerification = "turn3-self-verification"
+)
+
 // TemplateSource defines where templates are loaded from
 type TemplateSource int
 
@@ -227,5 +245,13 @@ type VersionInfo struct {
 	Path     string    `json:"path"`
 	Size     int64     `json:"size"`
 	ModTime  time.Time `json:"mod_time"`
-	IsLatest bool      `json:"is_latest"`
+	IsLatest bool      `json:"is_latest"` // chosen when no version is pinned
+
+	Prerelease bool `json:"prerelease,omitempty"`
+	// Deprecated is set by a {{/* deprecated: note */}} comment at the start
+	// of the template
+	Deprecated      bool   `json:"deprecated,omitempty"`
+	DeprecationNote string `json:"deprecation_note,omitempty"`
+
+	semver Version
 }
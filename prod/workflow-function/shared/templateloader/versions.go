@@ -0,0 +1,170 @@
+package templateloader
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// deprecationComment matches a {{/* deprecated */}} or
+// {{/* deprecated: note */}} comment at the start of a template
+var deprecationComment = regexp.MustCompile(`^\s*\{\{-?\s*/\*\s*(?i:deprecated)\b:?\s*(.*?)\s*\*/\s*-?\}\}`)
+
+// deprecation returns whether template content is marked deprecated and the
+// note of the marker
+func deprecation(content []byte) (bool, string) {
+	match := deprecationComment.FindSubmatch(content)
+	if match == nil {
+		return false, ""
+	}
+	return true, string(match[1])
+}
+
+// buildCatalog groups the versioned files of a source by template type,
+// sorted by SemVer precedence. Files whose version does not parse are
+// skipped.
+func (l *Loader) buildCatalog(files []SourceFile) map[string][]VersionInfo {
+	catalog := make(map[string][]VersionInfo)
+	for _, file := range files {
+		if file.Version == "" {
+			continue
+		}
+		semver, err := ParseVersion(file.Version)
+		if err != nil {
+			log.Printf("Warning: skipping template %s - %v", file.Location, err)
+			continue
+		}
+		info := VersionInfo{
+			Version:    file.Version,
+			Prerelease: semver.IsPrerelease(),
+			Path:       file.Location,
+			Size:       file.Size,
+			ModTime:    file.ModTime,
+			semver:     semver,
+		}
+		if content, _, err := l.source.Read(file.TemplateType, file.Version); err == nil {
+			info.Deprecated, info.DeprecationNote = deprecation(content)
+		}
+		templateType := normalizeTemplateType(file.TemplateType)
+		catalog[templateType] = append(catalog[templateType], info)
+	}
+
+	for _, versions := range catalog {
+		sort.SliceStable(versions, func(i, j int) bool {
+			if c := versions[i].semver.Compare(versions[j].semver); c != 0 {
+				return c < 0
+			}
+			return versions[i].Version < versions[j].Version
+		})
+		if latest, ok := latestVersion(versions); ok {
+			for i := range versions {
+				versions[i].IsLatest = versions[i].Version == latest.Version
+			}
+		}
+	}
+	return catalog
+}
+
+// ResolveVersion returns the available version of a template type that
+// satisfies a version or constraint, see Constraint. Ranges and
+// latest-stable prefer versions that are not deprecated; an exact version
+// prefers the file named exactly like it, so "v1.0" reads v1.0.tmpl when it
+// exists and v1.0.0.tmpl otherwise.
+func (l *Loader) ResolveVersion(templateType, constraint string) (string, error) {
+	c, err := ParseConstraint(constraint)
+	if err != nil {
+		return "", err
+	}
+	templateType = l.normalizeTemplateType(templateType)
+
+	l.mu.RLock()
+	versions := l.catalog[templateType]
+	l.mu.RUnlock()
+	if len(versions) == 0 {
+		return "", fmt.Errorf("no versions found for template type %s", templateType)
+	}
+
+	info, ok := selectVersion(versions, c)
+	if !ok {
+		available := make([]string, len(versions))
+		for i, v := range versions {
+			available[i] = v.Version
+		}
+		return "", fmt.Errorf("no version of template %s satisfies %q (available: %s)",
+			templateType, constraint, strings.Join(available, ", "))
+	}
+	l.logResolution(templateType, c.String(), info)
+	return info.Version, nil
+}
+
+// latestVersion returns the latest stable version, else the latest
+// pre-release
+func latestVersion(versions []VersionInfo) (VersionInfo, bool) {
+	for _, constraint := range []string{ConstraintLatestStable, ConstraintLatest} {
+		c, _ := ParseConstraint(constraint)
+		if info, ok := selectVersion(versions, c); ok {
+			return info, true
+		}
+	}
+	return VersionInfo{}, false
+}
+
+// selectVersion picks the version satisfying a constraint from versions
+// sorted in ascending order
+func selectVersion(versions []VersionInfo, c *Constraint) (VersionInfo, bool) {
+	if c.Exact() {
+		want := normalizeVersion(c.String())
+		var equal *VersionInfo
+		for i := range versions {
+			if versions[i].Version == want {
+				return versions[i], true
+			}
+			if equal == nil && versions[i].semver.Compare(*c.exact) == 0 {
+				equal = &versions[i]
+			}
+		}
+		if equal != nil {
+			return *equal, true
+		}
+		return VersionInfo{}, false
+	}
+
+	var deprecated *VersionInfo
+	for i := len(versions) - 1; i >= 0; i-- {
+		if !c.Check(versions[i].semver) {
+			continue
+		}
+		if !versions[i].Deprecated {
+			return versions[i], true
+		}
+		if deprecated == nil {
+			deprecated = &versions[i]
+		}
+	}
+	if deprecated != nil {
+		return *deprecated, true
+	}
+	return VersionInfo{}, false
+}
+
+// logResolution logs the version chosen for a constraint when it differs
+// from the previous choice, and warns about deprecated versions
+func (l *Loader) logResolution(templateType, constraint string, info VersionInfo) {
+	key := templateType + "@" + constraint
+	l.mu.Lock()
+	previous, seen := l.resolved[key]
+	l.resolved[key] = info.Version
+	l.mu.Unlock()
+	if seen && previous == info.Version {
+		return
+	}
+
+	if normalizeVersion(constraint) != info.Version {
+		log.Printf("Resolved template %s version %q to %s", templateType, constraint, info.Version)
+	}
+	if info.Deprecated {
+		log.Printf("Warning: template %s version %s is deprecated - %s", templateType, info.Version, info.DeprecationNote)
+	}
+}
@@ -0,0 +1,295 @@
+package templateloader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"time"
+)
+
+// ChangeType is the kind of change detected for a template file
+type ChangeType string
+
+const (
+	// ChangeAdded indicates a new template file
+	ChangeAdded ChangeType = "added"
+
+	// ChangeModified indicates a template file whose content changed
+	ChangeModified ChangeType = "modified"
+
+	// ChangeRemoved indicates a template file that was deleted
+	ChangeRemoved ChangeType = "removed"
+)
+
+// TemplateChange describes a template file that changed in the source
+type TemplateChange struct {
+	Type         ChangeType `json:"type"`
+	TemplateType string     `json:"template_type"`
+	// Version is empty for flat templates
+	Version    string    `json:"version,omitempty"`
+	Path       string    `json:"path"`
+	Checksum   string    `json:"checksum,omitempty"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// ChangeHandler receives template changes detected by the watcher
+type ChangeHandler func(change TemplateChange)
+
+// templateFile is the last seen state of a template file
+type templateFile struct {
+	SourceFile
+	checksum string
+}
+
+// cachedTemplate is where a cache entry was loaded from
+type cachedTemplate struct {
+	location     string
+	templateType string
+	version      string
+	flat         bool // loaded from the flat template as fallback for version
+}
+
+// Subscribe registers a handler for template changes and returns a function
+// that removes it. Handlers run on the goroutine that detected the change,
+// after the affected cache entries were invalidated.
+func (l *Loader) Subscribe(handler ChangeHandler) func() {
+	l.watchMu.Lock()
+	defer l.watchMu.Unlock()
+
+	id := l.nextSubscriber
+	l.nextSubscriber++
+	l.subscribers[id] = handler
+
+	return func() {
+		l.watchMu.Lock()
+		defer l.watchMu.Unlock()
+		delete(l.subscribers, id)
+	}
+}
+
+// StartWatcher checks the template files for changes every interval until
+// StopWatcher is called
+func (l *Loader) StartWatcher(interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("watch interval must be positive, got %s", interval)
+	}
+
+	l.watchMu.Lock()
+	defer l.watchMu.Unlock()
+	if l.stopWatch != nil {
+		return fmt.Errorf("template watcher is already running")
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	l.stopWatch, l.watchDone = stop, done
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if _, err := l.CheckForChanges(); err != nil {
+					log.Printf("Warning: template watcher failed to check %s - %v", l.source.Name(), err)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// StopWatcher stops the watcher and waits for a running check to finish
+func (l *Loader) StopWatcher() {
+	l.watchMu.Lock()
+	stop, done := l.stopWatch, l.watchDone
+	l.stopWatch, l.watchDone = nil, nil
+	l.watchMu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// CheckForChanges compares the template files with the last check. Files
+// whose modification time or size changed are compared by ETag, or by
+// SHA-256 when the source has no ETags, so touching a file without changing
+// its content is not a change. For every change the cache entries loaded
+// from that file are deleted, versions are re-discovered and subscribers are
+// notified.
+func (l *Loader) CheckForChanges() ([]TemplateChange, error) {
+	current, err := l.scanTemplateFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	l.watchMu.Lock()
+	now := time.Now()
+	var changes []TemplateChange
+	for path, file := range current {
+		previous, known := l.files[path]
+		switch {
+		case known && previous.ModTime.Equal(file.ModTime) && previous.Size == file.Size:
+			file.checksum = previous.checksum
+		default:
+			checksum, err := l.checksum(file.SourceFile)
+			if err != nil {
+				// Removed between the scan and the read; the next check reports it
+				delete(current, path)
+				continue
+			}
+			file.checksum = checksum
+			if !known {
+				changes = append(changes, file.change(ChangeAdded, path, now))
+			} else if checksum != previous.checksum {
+				changes = append(changes, file.change(ChangeModified, path, now))
+			}
+		}
+		current[path] = file
+	}
+	for path, previous := range l.files {
+		if _, exists := current[path]; !exists {
+			changes = append(changes, previous.change(ChangeRemoved, path, now))
+		}
+	}
+	l.files = current
+
+	for _, change := range changes {
+		l.invalidate(change)
+	}
+	handlers := make([]ChangeHandler, 0, len(l.subscribers))
+	ids := make([]int, 0, len(l.subscribers))
+	for id := range l.subscribers {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		handlers = append(handlers, l.subscribers[id])
+	}
+	l.watchMu.Unlock()
+
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+
+	if err := l.discoverVersions(); err != nil {
+		return changes, err
+	}
+	for _, change := range changes {
+		for _, handler := range handlers {
+			handler(change)
+		}
+	}
+	return changes, nil
+}
+
+// trackCacheKey records where a cache entry was loaded from (must be
+// called without watchMu held)
+func (l *Loader) trackCacheKey(key string, entry cachedTemplate) {
+	l.watchMu.Lock()
+	defer l.watchMu.Unlock()
+	l.cached[key] = entry
+}
+
+// invalidate deletes the cache entries loaded from the changed file. A new
+// versioned file also replaces the flat fallback cached for that version.
+// Must be called with watchMu held.
+func (l *Loader) invalidate(change TemplateChange) {
+	templateType := normalizeTemplateType(change.TemplateType)
+	for key, entry := range l.cached {
+		replacesFallback := change.Type == ChangeAdded && change.Version != "" && entry.flat &&
+			entry.templateType == templateType && entry.version == change.Version
+		if entry.location != change.Path && !replacesFallback {
+			continue
+		}
+		if err := l.cache.Delete(key); err != nil {
+			log.Printf("Warning: failed to invalidate cached template %s - %v", key, err)
+		}
+		delete(l.cached, key)
+	}
+}
+
+// snapshotTemplateFiles records the current template files so the first
+// check only reports later changes
+func (l *Loader) snapshotTemplateFiles() error {
+	files, err := l.scanTemplateFiles()
+	if err != nil {
+		return err
+	}
+	for path, file := range files {
+		checksum, err := l.checksum(file.SourceFile)
+		if err != nil {
+			delete(files, path)
+			continue
+		}
+		file.checksum = checksum
+		files[path] = file
+	}
+
+	l.watchMu.Lock()
+	defer l.watchMu.Unlock()
+	l.files = files
+	return nil
+}
+
+// scanTemplateFiles lists the template files of the source by location,
+// without the files matching the discovery exclude patterns
+func (l *Loader) scanTemplateFiles() (map[string]templateFile, error) {
+	sourceFiles, err := l.source.List()
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]templateFile)
+	for _, file := range sourceFiles {
+		if !l.excluded(path.Base(file.Location)) {
+			files[file.Location] = templateFile{SourceFile: file}
+		}
+	}
+	return files, nil
+}
+
+// excluded reports whether a file name matches a discovery exclude pattern
+func (l *Loader) excluded(name string) bool {
+	for _, pattern := range l.discovery.ExcludePatterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// change returns the change event of the file
+func (f templateFile) change(changeType ChangeType, location string, detectedAt time.Time) TemplateChange {
+	return TemplateChange{
+		Type:         changeType,
+		TemplateType: f.TemplateType,
+		Version:      f.Version,
+		Path:         location,
+		Checksum:     f.checksum,
+		DetectedAt:   detectedAt,
+	}
+}
+
+// checksum returns the ETag of a file, or the SHA-256 of its content as hex
+// when the source has no ETags
+func (l *Loader) checksum(file SourceFile) (string, error) {
+	if file.ETag != "" {
+		return file.ETag, nil
+	}
+	content, _, err := l.source.Read(file.TemplateType, file.Version)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,196 @@
+package templateloader
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// writeTemplate writes a template file and moves its modification time
+// forward, so changes are seen on filesystems with coarse timestamps
+func writeTemplate(t *testing.T, path, content string, age time.Duration) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(-age)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestLoader(t *testing.T) (*Loader, string) {
+	t.Helper()
+	dir := t.TempDir()
+	writeTemplate(t, filepath.Join(dir, "turn1", "v1.0.0.tmpl"), "turn1 v1 {{.Name}}", time.Hour)
+	writeTemplate(t, filepath.Join(dir, "turn1", "v1.1.0.tmpl"), "turn1 v1.1 {{.Name}}", time.Hour)
+	writeTemplate(t, filepath.Join(dir, "turn2", "v1.0.0.tmpl"), "turn2 v1 {{.Name}}", time.Hour)
+
+	loader, err := New(Config{BasePath: dir, CacheEnabled: true})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return loader, dir
+}
+
+func render(t *testing.T, loader *Loader, templateType, version string) string {
+	t.Helper()
+	out, err := loader.RenderTemplateWithVersion(templateType, version, map[string]string{"Name": "x"})
+	if err != nil {
+		t.Fatalf("render %s:%s failed: %v", templateType, version, err)
+	}
+	return out
+}
+
+func cachedKeys(loader *Loader) []string {
+	keys := loader.cache.Keys()
+	sort.Strings(keys)
+	return keys
+}
+
+func TestCheckForChangesInvalidatesModifiedVersionOnly(t *testing.T) {
+	loader, dir := newTestLoader(t)
+	render(t, loader, "turn1", "1.0.0")
+	render(t, loader, "turn1", "1.1.0")
+	render(t, loader, "turn2", "1.0.0")
+
+	var events []TemplateChange
+	loader.Subscribe(func(change TemplateChange) { events = append(events, change) })
+
+	path := filepath.Join(dir, "turn1", "v1.1.0.tmpl")
+	writeTemplate(t, path, "turn1 v1.1 edited {{.Name}}", 0)
+
+	changes, err := loader.CheckForChanges()
+	if err != nil {
+		t.Fatalf("CheckForChanges failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Type != ChangeModified || changes[0].TemplateType != "turn1" ||
+		changes[0].Version != "1.1.0" || changes[0].Path != path || changes[0].Checksum == "" {
+		t.Fatalf("Unexpected changes %+v", changes)
+	}
+	if len(events) != 1 || events[0] != changes[0] {
+		t.Errorf("Subscriber received %+v", events)
+	}
+
+	if keys := cachedKeys(loader); len(keys) != 2 || keys[0] != "turn1:1.0.0" || keys[1] != "turn2:1.0.0" {
+		t.Errorf("Expected only turn1:1.1.0 to be invalidated, cache has %v", keys)
+	}
+	if got := render(t, loader, "turn1", "1.1.0"); got != "turn1 v1.1 edited x" {
+		t.Errorf("Expected the edited template, got %q", got)
+	}
+}
+
+func TestCheckForChangesIgnoresTouchedFiles(t *testing.T) {
+	loader, dir := newTestLoader(t)
+	render(t, loader, "turn2", "1.0.0")
+
+	now := time.Now()
+	if err := os.Chtimes(filepath.Join(dir, "turn2", "v1.0.0.tmpl"), now, now); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := loader.CheckForChanges()
+	if err != nil || len(changes) != 0 {
+		t.Fatalf("Expected no changes, got %+v, %v", changes, err)
+	}
+	if keys := cachedKeys(loader); len(keys) != 1 {
+		t.Errorf("Expected the cache to be kept, got %v", keys)
+	}
+}
+
+func TestCheckForChangesAddedAndRemovedVersions(t *testing.T) {
+	loader, dir := newTestLoader(t)
+
+	added := filepath.Join(dir, "turn2", "v1.2.0.tmpl")
+	writeTemplate(t, added, "turn2 v1.2 {{.Name}}", 0)
+	writeTemplate(t, filepath.Join(dir, "turn2", "v1.3.0.tmpl.bak"), "ignored", 0)
+	changes, err := loader.CheckForChanges()
+	if err != nil || len(changes) != 1 || changes[0].Type != ChangeAdded || changes[0].Path != added {
+		t.Fatalf("Expected the added version, got %+v, %v", changes, err)
+	}
+	if latest := loader.GetLatestVersion("turn2"); latest != "1.2.0" {
+		t.Errorf("Expected latest version 1.2.0, got %s", latest)
+	}
+
+	if err := os.RemoveAll(filepath.Join(dir, "turn1")); err != nil {
+		t.Fatal(err)
+	}
+	changes, err = loader.CheckForChanges()
+	if err != nil || len(changes) != 2 || changes[0].Type != ChangeRemoved || changes[1].Type != ChangeRemoved {
+		t.Fatalf("Expected two removed versions, got %+v, %v", changes, err)
+	}
+	if latest := loader.GetLatestVersion("turn1"); latest != "" {
+		t.Errorf("Expected turn1 to be dropped, got version %s", latest)
+	}
+}
+
+func TestAddedVersionReplacesCachedFlatFallback(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, filepath.Join(dir, "turn3.tmpl"), "flat {{.Name}}", time.Hour)
+	loader, err := New(Config{BasePath: dir, CacheEnabled: true})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if got := render(t, loader, "turn3", "1.0.0"); got != "flat x" {
+		t.Fatalf("Expected the flat template, got %q", got)
+	}
+
+	writeTemplate(t, filepath.Join(dir, "turn3", "v1.0.0.tmpl"), "versioned {{.Name}}", 0)
+	if _, err := loader.CheckForChanges(); err != nil {
+		t.Fatalf("CheckForChanges failed: %v", err)
+	}
+	if got := render(t, loader, "turn3", "1.0.0"); got != "versioned x" {
+		t.Errorf("Expected the versioned template, got %q", got)
+	}
+}
+
+func TestWatcherFromAdvancedConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, filepath.Join(dir, "turn1", "v1.0.0.tmpl"), "before {{.Name}}", time.Hour)
+
+	config := GetDefaultAdvancedConfig()
+	config.Config.BasePath = dir
+	config.Discovery.WatchEnabled = true
+	config.Discovery.WatchInterval = 10 * time.Millisecond
+	config.Discovery.ExcludePatterns = []string{"*.draft.tmpl"}
+	loader, err := NewWithAdvancedConfig(config)
+	if err != nil {
+		t.Fatalf("NewWithAdvancedConfig failed: %v", err)
+	}
+	defer loader.StopWatcher()
+
+	if err := loader.StartWatcher(time.Second); err == nil {
+		t.Error("Expected an error when starting a second watcher")
+	}
+
+	received := make(chan TemplateChange, 10)
+	unsubscribe := loader.Subscribe(func(change TemplateChange) { received <- change })
+	defer unsubscribe()
+
+	render(t, loader, "turn1", "1.0.0")
+	writeTemplate(t, filepath.Join(dir, "turn1.draft.tmpl"), "excluded", 0)
+	writeTemplate(t, filepath.Join(dir, "turn1", "v1.0.0.tmpl"), "after {{.Name}}", 0)
+
+	select {
+	case change := <-received:
+		if change.Type != ChangeModified || change.TemplateType != "turn1" {
+			t.Errorf("Unexpected change %+v", change)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Watcher did not report the modified template")
+	}
+	if got := render(t, loader, "turn1", "1.0.0"); got != "after x" {
+		t.Errorf("Expected the reloaded template, got %q", got)
+	}
+
+	loader.StopWatcher()
+	select {
+	case change := <-received:
+		t.Errorf("Unexpected change %+v", change)
+	default:
+	}
+}
@@ -0,0 +1,448 @@
+package turnexecutor
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"workflow-function/shared/errors"
+	"workflow-function/shared/examples"
+	"workflow-function/shared/experiments"
+	"workflow-function/shared/logger"
+	"workflow-function/shared/schema"
+)
+
+// DynamoClient is the subset of the DynamoDB API used by DynamoStatus.
+// *dynamodb.Client implements it.
+type DynamoClient interface {
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+}
+
+// TurnCompletion is the outcome of a turn recorded on the verification
+// record.
+type TurnCompletion struct {
+	Turn    int
+	Status  schema.StatusHistoryEntry
+	Metrics *schema.TurnMetrics
+	// ProcessedRef, ConversationRef and ReasoningRef are skipped when empty
+	ProcessedRef    schema.S3Reference
+	ConversationRef schema.S3Reference
+	ReasoningRef    schema.S3Reference
+	// VerificationStatus and ComparisonSummary are skipped when empty
+	VerificationStatus string
+	ComparisonSummary  string
+	// Discrepancies replaces the stored list when non-nil; an empty slice
+	// clears it
+	Discrepancies []schema.Discrepancy
+	// Retracted records discrepancies withdrawn by a later turn
+	Retracted []schema.Discrepancy
+}
+
+// DynamoStatus records statuses, conversation turns and turn outcomes in
+// the verification and conversation tables. Verification records are
+// addressed by (verificationId, verificationAt).
+type DynamoStatus struct {
+	client            DynamoClient
+	verificationTable string
+	conversationTable string
+	maxRetries        int
+	log               logger.Logger
+}
+
+// NewDynamoStatus creates a DynamoStatus. Failed writes are attempted up to
+// maxRetries times.
+func NewDynamoStatus(client DynamoClient, verificationTable, conversationTable string, maxRetries int, log logger.Logger) *DynamoStatus {
+	if maxRetries <= 0 {
+		maxRetries = 1
+	}
+	return &DynamoStatus{
+		client:            client,
+		verificationTable: verificationTable,
+		conversationTable: conversationTable,
+		maxRetries:        maxRetries,
+		log:               log,
+	}
+}
+
+// ForVerification returns a StatusSink writing to the verification record
+// created at verificationAt.
+func (d *DynamoStatus) ForVerification(verificationAt string) StatusSink {
+	return &verificationSink{status: d, verificationAt: verificationAt}
+}
+
+type verificationSink struct {
+	status         *DynamoStatus
+	verificationAt string
+}
+
+func (s *verificationSink) UpdateStatus(ctx context.Context, verificationID, status string, metrics map[string]interface{}) error {
+	return s.status.UpdateCurrentStatus(ctx, verificationID, s.verificationAt, status, metrics)
+}
+
+// UpdateCurrentStatus sets currentStatus and, when given, statusMetrics
+// without extending the status history.
+func (d *DynamoStatus) UpdateCurrentStatus(ctx context.Context, verificationID, verificationAt, status string, metrics map[string]interface{}) error {
+	update := expression.Set(expression.Name("currentStatus"), expression.Value(status)).
+		Set(expression.Name("lastUpdatedAt"), expression.Value(schema.FormatISO8601()))
+	if len(metrics) > 0 {
+		update = update.Set(expression.Name("statusMetrics"), expression.Value(metrics))
+	}
+	return d.updateVerification(ctx, "UpdateCurrentStatus", verificationID, verificationAt, update)
+}
+
+// AppendStatus sets currentStatus and appends entry to the status history.
+func (d *DynamoStatus) AppendStatus(ctx context.Context, verificationID, verificationAt string, entry schema.StatusHistoryEntry) error {
+	update, err := statusUpdate(entry)
+	if err != nil {
+		return err
+	}
+	return d.updateVerification(ctx, "AppendStatus", verificationID, verificationAt, update)
+}
+
+// UpdateErrorTracking sets errorTracking on the verification record.
+func (d *DynamoStatus) UpdateErrorTracking(ctx context.Context, verificationID, verificationAt string, tracking *schema.ErrorTracking) error {
+	avTracking, err := attributevalue.MarshalMap(tracking)
+	if err != nil {
+		return errors.WrapError(err, errors.ErrorTypeDynamoDB, "failed to marshal error tracking", false)
+	}
+	update := expression.Set(expression.Name("errorTracking"), expression.Value(&types.AttributeValueMemberM{Value: avTracking})).
+		Set(expression.Name("lastUpdatedAt"), expression.Value(schema.FormatISO8601()))
+	return d.updateVerification(ctx, "UpdateErrorTracking", verificationID, verificationAt, update)
+}
+
+// CompleteTurn records the outcome of a turn: the status is appended once,
+// metrics are stored under processingMetrics.turn<N> and the artifact
+// references are stored as turn<N>ProcessedPath, turn<N>ConversationRef and
+// turn<N>ReasoningPath. Turns 1 and 2 replace processingMetrics, later turns
+// add to it.
+func (d *DynamoStatus) CompleteTurn(ctx context.Context, verificationID, verificationAt string, c *TurnCompletion) error {
+	if c == nil || c.Turn <= 0 {
+		return errors.NewValidationError("turn completion required", map[string]interface{}{"verificationId": verificationID})
+	}
+	update, err := statusUpdate(c.Status)
+	if err != nil {
+		return err
+	}
+
+	if c.Metrics != nil {
+		avMetrics, err := attributevalue.MarshalMap(c.Metrics)
+		if err != nil {
+			return errors.WrapError(err, errors.ErrorTypeDynamoDB, "failed to marshal turn metrics", false)
+		}
+		metrics := &types.AttributeValueMemberM{Value: avMetrics}
+		name := fmt.Sprintf("turn%d", c.Turn)
+		if c.Turn <= 2 {
+			update = update.Set(expression.Name("processingMetrics"),
+				expression.Value(&types.AttributeValueMemberM{Value: map[string]types.AttributeValue{name: metrics}}))
+		} else {
+			update = update.Set(expression.Name("processingMetrics."+name), expression.Value(metrics))
+		}
+	}
+
+	if c.ProcessedRef.Key != "" {
+		update = update.Set(expression.Name(fmt.Sprintf("turn%dProcessedPath", c.Turn)), expression.Value(s3URI(c.ProcessedRef)))
+		if c.Turn == 1 {
+			update = update.Set(expression.Name("processedTurn1MarkdownRef"), expression.Value(c.ProcessedRef))
+		}
+	}
+	if c.ConversationRef.Key != "" {
+		update = update.Set(expression.Name(fmt.Sprintf("turn%dConversationRef", c.Turn)), expression.Value(c.ConversationRef))
+	}
+	if c.ReasoningRef.Key != "" {
+		update = update.Set(expression.Name(fmt.Sprintf("turn%dReasoningPath", c.Turn)), expression.Value(s3URI(c.ReasoningRef)))
+	}
+	if c.VerificationStatus != "" {
+		update = update.Set(expression.Name("verificationStatus"), expression.Value(c.VerificationStatus))
+	}
+	if c.ComparisonSummary != "" {
+		update = update.Set(expression.Name("verificationSummary"),
+			expression.Value(map[string]interface{}{"comparisonSummary": c.ComparisonSummary}))
+	}
+	if c.Discrepancies != nil {
+		avDiscrepancies, err := attributevalue.MarshalList(c.Discrepancies)
+		if err != nil {
+			return errors.WrapError(err, errors.ErrorTypeDynamoDB, "failed to marshal discrepancies", false)
+		}
+		update = update.Set(expression.Name("discrepancies"), expression.Value(&types.AttributeValueMemberL{Value: avDiscrepancies}))
+	}
+	if len(c.Retracted) > 0 {
+		avRetracted, err := attributevalue.MarshalList(c.Retracted)
+		if err != nil {
+			return errors.WrapError(err, errors.ErrorTypeDynamoDB, "failed to marshal retracted discrepancies", false)
+		}
+		update = update.Set(expression.Name("retractedDiscrepancies"), expression.Value(&types.AttributeValueMemberL{Value: avRetracted}))
+	}
+
+	return d.updateVerification(ctx, fmt.Sprintf("CompleteTurn%d", c.Turn), verificationID, verificationAt, update)
+}
+
+// RecordExperimentAssignments stores the prompt experiment assignments of a
+// verification together with the model and token usage of a turn, as read by
+// the experiment report. Nothing is written without assignments.
+func (d *DynamoStatus) RecordExperimentAssignments(ctx context.Context, verificationID, verificationAt string, turn int, assignments map[string]experiments.Assignment, usage experiments.TurnUsage) error {
+	if len(assignments) == 0 {
+		return nil
+	}
+	avAssignments, err := attributevalue.MarshalMap(assignments)
+	if err != nil {
+		return errors.WrapError(err, errors.ErrorTypeDynamoDB, "failed to marshal experiment assignments", false)
+	}
+	avUsage, err := attributevalue.MarshalMap(usage)
+	if err != nil {
+		return errors.WrapError(err, errors.ErrorTypeDynamoDB, "failed to marshal experiment usage", false)
+	}
+
+	update := expression.Set(expression.Name(experiments.AttributeAssignments), expression.Value(&types.AttributeValueMemberM{Value: avAssignments})).
+		Set(expression.Name(experiments.UsageAttribute(turn)), expression.Value(&types.AttributeValueMemberM{Value: avUsage}))
+	return d.updateVerification(ctx, "RecordExperimentAssignments", verificationID, verificationAt, update)
+}
+
+// RecordFewShotExamples stores the few-shot examples added to a verification's
+// conversation. Nothing is written without examples.
+func (d *DynamoStatus) RecordFewShotExamples(ctx context.Context, verificationID, verificationAt string, usage []examples.Usage) error {
+	if len(usage) == 0 {
+		return nil
+	}
+	avUsage, err := attributevalue.MarshalList(usage)
+	if err != nil {
+		return errors.WrapError(err, errors.ErrorTypeDynamoDB, "failed to marshal few-shot example usage", false)
+	}
+
+	update := expression.Set(expression.Name(examples.AttributeUsage), expression.Value(&types.AttributeValueMemberL{Value: avUsage}))
+	return d.updateVerification(ctx, "RecordFewShotExamples", verificationID, verificationAt, update)
+}
+
+// UpdateConversationTurn appends turn to the latest conversation record of
+// the verification, creating the record when none exists. Processed paths
+// found in turn.Metadata are copied to the record.
+func (d *DynamoStatus) UpdateConversationTurn(ctx context.Context, verificationID string, turn *schema.TurnResponse) error {
+	if verificationID == "" || turn == nil {
+		return errors.NewValidationError("verification ID and turn data required",
+			map[string]interface{}{"operation": "UpdateConversationTurn", "table": d.conversationTable})
+	}
+	return d.retry(ctx, "UpdateConversationTurn", d.conversationTable, func() error {
+		return d.updateConversationTurn(ctx, verificationID, turn)
+	})
+}
+
+func (d *DynamoStatus) updateConversationTurn(ctx context.Context, verificationID string, turn *schema.TurnResponse) error {
+	result, err := d.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(d.conversationTable),
+		KeyConditionExpression: aws.String("verificationId = :id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":id": &types.AttributeValueMemberS{Value: verificationID},
+		},
+		ScanIndexForward: aws.Bool(false),
+		Limit:            aws.Int32(1),
+	})
+	if err != nil {
+		return errors.WrapError(err, errors.ErrorTypeDynamoDB, "failed to query conversation for update", true).
+			WithContext("verificationId", verificationID).
+			WithContext("table", d.conversationTable)
+	}
+
+	exists := len(result.Items) > 0
+	tracker := schema.ConversationTracker{
+		MaxTurns:       2,
+		TurnStatus:     "ACTIVE",
+		ConversationAt: schema.FormatISO8601(),
+	}
+	if exists {
+		if err := attributevalue.UnmarshalMap(result.Items[0], &tracker); err != nil {
+			return errors.WrapError(err, errors.ErrorTypeDynamoDB, "failed to unmarshal conversation tracker", false).
+				WithContext("verificationId", verificationID)
+		}
+	}
+	if tracker.History == nil {
+		tracker.History = make([]interface{}, 0)
+	}
+	if tracker.Metadata == nil {
+		tracker.Metadata = make(map[string]interface{})
+	}
+	for key, value := range turn.Metadata {
+		path, ok := value.(string)
+		if !ok || path == "" {
+			continue
+		}
+		switch key {
+		case "turn1ProcessedPath":
+			tracker.Turn1ProcessedPath = path
+		case "turn2ProcessedPath":
+			tracker.Turn2ProcessedPath = path
+		case fmt.Sprintf("turn%dProcessedPath", turn.TurnId):
+		default:
+			continue
+		}
+		tracker.Metadata[key] = path
+	}
+
+	tracker.History = append(tracker.History, map[string]interface{}{
+		"turnId":     turn.TurnId,
+		"timestamp":  turn.Timestamp,
+		"stage":      turn.Stage,
+		"latencyMs":  turn.LatencyMs,
+		"tokenUsage": turn.TokenUsage,
+		"s3References": map[string]interface{}{
+			"prompt":   fmt.Sprintf("prompts/turn%d-prompt.json", turn.TurnId),
+			"response": fmt.Sprintf("responses/turn%d-raw-response.json", turn.TurnId),
+		},
+	})
+	tracker.CurrentTurn = turn.TurnId
+	// Optional turns (e.g. Turn3 self-verification) extend the conversation
+	if tracker.CurrentTurn > tracker.MaxTurns {
+		tracker.MaxTurns = tracker.CurrentTurn
+	}
+
+	item, err := attributevalue.MarshalMap(tracker)
+	if err != nil {
+		return errors.WrapError(err, errors.ErrorTypeDynamoDB, "failed to marshal conversation tracker", false).
+			WithContext("verificationId", verificationID)
+	}
+	item["verificationId"] = &types.AttributeValueMemberS{Value: verificationID}
+	if _, err := d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.conversationTable),
+		Item:      item,
+	}); err != nil {
+		return errors.WrapError(err, errors.ErrorTypeDynamoDB, "failed to record conversation turn", true).
+			WithContext("verificationId", verificationID).
+			WithContext("table", d.conversationTable).
+			WithContext("existing_record", exists)
+	}
+	return nil
+}
+
+func (d *DynamoStatus) updateVerification(ctx context.Context, operation, verificationID, verificationAt string, update expression.UpdateBuilder) error {
+	if verificationID == "" || verificationAt == "" {
+		return errors.NewValidationError("verificationId and verificationAt are required",
+			map[string]interface{}{"operation": operation, "table": d.verificationTable})
+	}
+	expr, err := expression.NewBuilder().WithUpdate(update).Build()
+	if err != nil {
+		return errors.WrapError(err, errors.ErrorTypeDynamoDB, "failed to build update expression", false).
+			WithContext("operation", operation)
+	}
+	input := &dynamodb.UpdateItemInput{
+		TableName: aws.String(d.verificationTable),
+		Key: map[string]types.AttributeValue{
+			"verificationId": &types.AttributeValueMemberS{Value: verificationID},
+			"verificationAt": &types.AttributeValueMemberS{Value: verificationAt},
+		},
+		UpdateExpression:          expr.Update(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+	return d.retry(ctx, operation, d.verificationTable, func() error {
+		if _, err := d.client.UpdateItem(ctx, input); err != nil {
+			return errors.WrapError(err, errors.ErrorTypeDynamoDB, "failed to update verification record", true).
+				WithContext("operation", operation).
+				WithContext("verificationId", verificationID).
+				WithContext("table", d.verificationTable)
+		}
+		return nil
+	})
+}
+
+// retry runs op with exponential backoff until it succeeds, fails with a
+// non-retryable error or the attempts are exhausted.
+func (d *DynamoStatus) retry(ctx context.Context, operation, table string, op func() error) error {
+	var last *errors.WorkflowError
+	for attempt := 1; attempt <= d.maxRetries; attempt++ {
+		err := op()
+		if err == nil {
+			return nil
+		}
+		var wfErr *errors.WorkflowError
+		if !goerrors.As(err, &wfErr) {
+			wfErr = errors.AnalyzeDynamoDBError(operation, table, err)
+		}
+		last = wfErr
+
+		d.log.Warn("dynamodb_operation_attempt_failed", map[string]interface{}{
+			"operation":    operation,
+			"table":        table,
+			"attempt":      attempt,
+			"max_attempts": d.maxRetries,
+			"error_code":   wfErr.Code,
+			"retryable":    wfErr.Retryable,
+			"error":        err.Error(),
+		})
+		if !wfErr.Retryable && !errors.IsDynamoDBRetryableError(err) {
+			return wfErr
+		}
+		if attempt == d.maxRetries {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryDelay(wfErr, attempt)):
+		}
+	}
+	last.RetryCount = d.maxRetries - 1
+	last.MaxRetries = d.maxRetries
+	return last
+}
+
+// retryDelay follows the retry strategy of the error, starting at 200ms and
+// capped at 5s. Throttling waits at least a second.
+func retryDelay(err *errors.WorkflowError, attempt int) time.Duration {
+	const (
+		baseDelay = 200 * time.Millisecond
+		maxDelay  = 5 * time.Second
+	)
+	exponential := time.Duration(float64(baseDelay) * math.Pow(2, float64(attempt-1)))
+	if exponential > maxDelay {
+		exponential = maxDelay
+	}
+
+	var delay time.Duration
+	switch err.RetryStrategy {
+	case errors.RetryImmediate:
+		delay = 0
+	case errors.RetryLinear:
+		delay = baseDelay * time.Duration(attempt)
+	case errors.RetryJittered:
+		jitter := time.Duration(rand.Float64() * float64(exponential) * 0.25)
+		if rand.Float64() < 0.5 {
+			delay = exponential - jitter
+		} else {
+			delay = exponential + jitter
+		}
+	default:
+		delay = exponential
+	}
+	if err.Code == string(errors.DynamoDBThrottlingException) && delay < time.Second {
+		delay = time.Second
+	}
+	return delay
+}
+
+func statusUpdate(entry schema.StatusHistoryEntry) (expression.UpdateBuilder, error) {
+	avEntry, err := attributevalue.MarshalMap(entry)
+	if err != nil {
+		return expression.UpdateBuilder{}, errors.WrapError(err, errors.ErrorTypeDynamoDB,
+			"failed to marshal status history entry", false).
+			WithContext("status", entry.Status)
+	}
+	return expression.Set(expression.Name("currentStatus"), expression.Value(entry.Status)).
+		Set(expression.Name("lastUpdatedAt"), expression.Value(entry.Timestamp)).
+		Set(expression.Name("statusHistory"), expression.ListAppend(
+			expression.IfNotExists(expression.Name("statusHistory"), expression.Value(&types.AttributeValueMemberL{Value: []types.AttributeValue{}})),
+			expression.Value(&types.AttributeValueMemberL{Value: []types.AttributeValue{&types.AttributeValueMemberM{Value: avEntry}}}),
+		)), nil
+}
+
+func s3URI(ref schema.S3Reference) string {
+	return fmt.Sprintf("s3://%s/%s", ref.Bucket, ref.Key)
+}
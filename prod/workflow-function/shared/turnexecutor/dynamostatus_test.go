@@ -0,0 +1,198 @@
+package turnexecutor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"workflow-function/shared/examples"
+	"workflow-function/shared/experiments"
+	"workflow-function/shared/logger"
+	"workflow-function/shared/schema"
+)
+
+type fakeDynamo struct {
+	updates  []*dynamodb.UpdateItemInput
+	puts     []*dynamodb.PutItemInput
+	items    []map[string]types.AttributeValue
+	failures int
+}
+
+func (f *fakeDynamo) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	f.updates = append(f.updates, params)
+	if f.failures > 0 {
+		f.failures--
+		return nil, fmt.Errorf("ProvisionedThroughputExceededException: slow down")
+	}
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+func (f *fakeDynamo) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	f.puts = append(f.puts, params)
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamo) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return &dynamodb.QueryOutput{Items: f.items}, nil
+}
+
+func testStatus(client DynamoClient) *DynamoStatus {
+	return NewDynamoStatus(client, "verifications", "conversations", 1, logger.New("test", "turnexecutor"))
+}
+
+// setPaths returns the attribute paths assigned by an update expression.
+func setPaths(in *dynamodb.UpdateItemInput) []string {
+	expr := strings.TrimPrefix(*in.UpdateExpression, "SET ")
+	var paths []string
+	for _, clause := range strings.Split(expr, ", ") {
+		lhs := strings.TrimSpace(strings.SplitN(clause, "=", 2)[0])
+		var parts []string
+		for _, name := range strings.Split(lhs, ".") {
+			parts = append(parts, in.ExpressionAttributeNames[name])
+		}
+		paths = append(paths, strings.Join(parts, "."))
+	}
+	return paths
+}
+
+func TestStatusSinkUsesCompositeKey(t *testing.T) {
+	client := &fakeDynamo{}
+	sink := testStatus(client).ForVerification("2026-10-18T10:00:00Z")
+
+	if err := sink.UpdateStatus(context.Background(), "v1", "TURN2_STARTED", nil); err != nil {
+		t.Fatalf("UpdateStatus: %v", err)
+	}
+	key := client.updates[0].Key
+	if key["verificationId"].(*types.AttributeValueMemberS).Value != "v1" ||
+		key["verificationAt"].(*types.AttributeValueMemberS).Value != "2026-10-18T10:00:00Z" {
+		t.Errorf("unexpected key %+v", key)
+	}
+	if got := strings.Join(setPaths(client.updates[0]), ","); got != "currentStatus,lastUpdatedAt" {
+		t.Errorf("status update sets %s", got)
+	}
+
+	if err := testStatus(client).UpdateCurrentStatus(context.Background(), "v1", "", "X", nil); err == nil {
+		t.Error("expected an error without verificationAt")
+	}
+}
+
+func TestCompleteTurnMetricsPath(t *testing.T) {
+	tests := []struct {
+		turn    int
+		metrics string
+	}{
+		{turn: 2, metrics: "processingMetrics"},
+		{turn: 3, metrics: "processingMetrics.turn3"},
+	}
+
+	for _, tt := range tests {
+		client := &fakeDynamo{}
+		err := testStatus(client).CompleteTurn(context.Background(), "v1", "2026-10-18T10:00:00Z", &TurnCompletion{
+			Turn:         tt.turn,
+			Status:       schema.StatusHistoryEntry{Status: "DONE", Timestamp: "2026-10-18T10:01:00Z"},
+			Metrics:      &schema.TurnMetrics{TotalTimeMs: 5},
+			ProcessedRef: schema.S3Reference{Bucket: "state", Key: "md"},
+		})
+		if err != nil {
+			t.Fatalf("turn %d: %v", tt.turn, err)
+		}
+		if len(client.updates) != 1 {
+			t.Fatalf("turn %d: expected a single update, got %d", tt.turn, len(client.updates))
+		}
+		paths := strings.Join(setPaths(client.updates[0]), ",")
+		for _, want := range []string{"statusHistory", tt.metrics, fmt.Sprintf("turn%dProcessedPath", tt.turn)} {
+			if !strings.Contains(","+paths+",", ","+want+",") {
+				t.Errorf("turn %d: expected %s to be set, got %s", tt.turn, want, paths)
+			}
+		}
+	}
+}
+
+func TestRecordExperimentAssignments(t *testing.T) {
+	client := &fakeDynamo{}
+	status := testStatus(client)
+
+	if err := status.RecordExperimentAssignments(context.Background(), "v1", "2026-10-18T10:00:00Z", 1, nil, experiments.TurnUsage{}); err != nil {
+		t.Fatalf("RecordExperimentAssignments: %v", err)
+	}
+	if len(client.updates) != 0 {
+		t.Fatalf("expected no update without assignments, got %d", len(client.updates))
+	}
+
+	assignments := map[string]experiments.Assignment{
+		"t1-v2": {ExperimentID: "t1-v2", Variant: "treatment", TemplateType: "turn1-layout-vs-checking"},
+	}
+	usage := experiments.TurnUsage{ModelID: "model-b", InputTokens: 10, OutputTokens: 5, TotalTokens: 15}
+	if err := status.RecordExperimentAssignments(context.Background(), "v1", "2026-10-18T10:00:00Z", 1, assignments, usage); err != nil {
+		t.Fatalf("RecordExperimentAssignments: %v", err)
+	}
+	if got := strings.Join(setPaths(client.updates[0]), ","); got != "experimentAssignments,turn1ExperimentUsage" {
+		t.Errorf("experiment update sets %s", got)
+	}
+}
+
+func TestRecordFewShotExamples(t *testing.T) {
+	client := &fakeDynamo{}
+	status := testStatus(client)
+
+	if err := status.RecordFewShotExamples(context.Background(), "v1", "2026-10-18T10:00:00Z", nil); err != nil {
+		t.Fatalf("RecordFewShotExamples: %v", err)
+	}
+	if len(client.updates) != 0 {
+		t.Fatalf("expected no update without examples, got %d", len(client.updates))
+	}
+
+	usage := []examples.Usage{{ExampleID: "ex-1", VerificationID: "verif-0", Score: 5}}
+	if err := status.RecordFewShotExamples(context.Background(), "v1", "2026-10-18T10:00:00Z", usage); err != nil {
+		t.Fatalf("RecordFewShotExamples: %v", err)
+	}
+	if got := strings.Join(setPaths(client.updates[0]), ","); got != "fewShotExamples" {
+		t.Errorf("few-shot update sets %s", got)
+	}
+	list, ok := client.updates[0].ExpressionAttributeValues[":0"].(*types.AttributeValueMemberL)
+	if !ok || len(list.Value) != 1 {
+		t.Errorf("expected the usage list as the first value, got %+v", client.updates[0].ExpressionAttributeValues)
+	}
+}
+
+func TestUpdateConversationTurnExtendsMaxTurns(t *testing.T) {
+	client := &fakeDynamo{}
+	status := testStatus(client)
+
+	err := status.UpdateConversationTurn(context.Background(), "v1", &schema.TurnResponse{
+		TurnId:   3,
+		Metadata: map[string]interface{}{"turn3ProcessedPath": "s3://state/md", "other": "ignored"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateConversationTurn: %v", err)
+	}
+	item := client.puts[0].Item
+	if item["verificationId"].(*types.AttributeValueMemberS).Value != "v1" {
+		t.Errorf("verificationId not set: %+v", item)
+	}
+	if n := item["maxTurns"].(*types.AttributeValueMemberN).Value; n != "3" {
+		t.Errorf("maxTurns = %s, want 3", n)
+	}
+	meta := item["metadata"].(*types.AttributeValueMemberM).Value
+	if _, ok := meta["turn3ProcessedPath"]; !ok || meta["other"] != nil {
+		t.Errorf("unexpected metadata %+v", meta)
+	}
+}
+
+func TestRetryStopsAfterMaxRetries(t *testing.T) {
+	client := &fakeDynamo{failures: 5}
+	status := NewDynamoStatus(client, "verifications", "conversations", 2, logger.New("test", "turnexecutor"))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := status.AppendStatus(ctx, "v1", "2026-10-18T10:00:00Z", schema.StatusHistoryEntry{Status: "X"}); err == nil {
+		t.Fatal("expected the failing update to return an error")
+	}
+	if len(client.updates) > 2 {
+		t.Errorf("expected at most 2 attempts, got %d", len(client.updates))
+	}
+}
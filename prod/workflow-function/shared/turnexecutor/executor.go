@@ -0,0 +1,419 @@
+package turnexecutor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"workflow-function/shared/bedrock"
+	"workflow-function/shared/errors"
+	"workflow-function/shared/logger"
+	"workflow-function/shared/schema"
+	"workflow-function/shared/templateloader"
+)
+
+// Converser invokes the Bedrock Converse API. *bedrock.BedrockClient
+// implements it.
+type Converser interface {
+	Converse(ctx context.Context, request *bedrock.ConverseRequest) (*bedrock.ConverseResponse, int64, error)
+}
+
+// TurnMetadataSource is implemented by Conversers that describe how their
+// last response was produced, e.g. an ensemble vote. The metadata is merged
+// into the metadata of the recorded turn.
+type TurnMetadataSource interface {
+	TurnMetadata() map[string]interface{}
+}
+
+// Renderer renders a prompt template. templateloader.TemplateLoader
+// implements it.
+type Renderer interface {
+	RenderTemplateWithVersion(templateType, version string, data interface{}) (string, error)
+}
+
+// Store loads turn inputs and persists turn outputs. It hides the S3 layout
+// of the calling function.
+type Store interface {
+	LoadImage(ctx context.Context, verificationID, role string) (*Image, error)
+	LoadTurn(ctx context.Context, verificationID string, turn int) (*schema.TurnResponse, error)
+	StorePrompt(ctx context.Context, verificationID string, turn int, prompt string) error
+	StoreTurn(ctx context.Context, verificationID string, turn *schema.TurnResponse) error
+}
+
+// StatusSink receives the statuses emitted while a turn runs.
+type StatusSink interface {
+	UpdateStatus(ctx context.Context, verificationID, status string, metrics map[string]interface{}) error
+}
+
+// Image is a base64 encoded image attached to a prompt.
+type Image struct {
+	Base64 string
+	Format string
+}
+
+// Input carries the per-request data of a turn. Images and History entries
+// take precedence over the Store, which lets callers pass already loaded or
+// transformed (e.g. cropped) inputs.
+type Input struct {
+	VerificationID string
+	SystemPrompt   string
+	TemplateData   map[string]interface{}
+	Images         map[string]*Image
+	History        map[int]*schema.TurnResponse
+	// Prefix messages are sent before the history, e.g. few-shot examples
+	Prefix []bedrock.MessageWrapper
+	// Metadata is merged into the metadata of the recorded turn
+	Metadata map[string]interface{}
+}
+
+// Result is the outcome of an executed turn.
+type Result struct {
+	Turn      *schema.TurnResponse
+	Prompt    string
+	Content   string
+	Thinking  string
+	Parsed    interface{}
+	ModelID   string
+	RequestID string
+	LatencyMs int64
+	Usage     schema.TokenUsage
+	Reasoning []bedrock.ContentBlock
+	// RenderDuration is the time spent rendering the prompt
+	RenderDuration time.Duration
+	Duration       time.Duration
+}
+
+// Operations recorded on the errors returned by Execute, so callers can tell
+// a prompt failure from an input or Bedrock failure.
+const (
+	OperationRenderPrompt  = "render_prompt"
+	OperationLoadInputs    = "load_inputs"
+	OperationConverse      = "converse"
+	OperationParseResponse = "parse_response"
+)
+
+// Executor runs turns described by a TurnSpec.
+type Executor struct {
+	converser Converser
+	renderer  Renderer
+	store     Store
+	status    StatusSink
+	log       logger.Logger
+}
+
+// New creates an Executor. status may be nil when no statuses are emitted.
+// A Store must be attached with WithStore before Execute is called.
+func New(converser Converser, renderer Renderer, status StatusSink, log logger.Logger) *Executor {
+	return &Executor{converser: converser, renderer: renderer, status: status, log: log}
+}
+
+// WithConverser returns a copy of the executor that invokes converser, e.g.
+// an ensemble wrapping the default client.
+func (e *Executor) WithConverser(converser Converser) *Executor {
+	c := *e
+	c.converser = converser
+	return &c
+}
+
+// WithStatus returns a copy of the executor that emits statuses to status.
+// Sinks keyed by the verification record are usually bound to a request.
+func (e *Executor) WithStatus(status StatusSink) *Executor {
+	c := *e
+	c.status = status
+	return &c
+}
+
+// WithStore returns a copy of the executor that uses store. Stores are
+// usually bound to a single request.
+func (e *Executor) WithStore(store Store) *Executor {
+	c := *e
+	c.store = store
+	return &c
+}
+
+// Execute runs the turn described by spec for the given input.
+func (e *Executor) Execute(ctx context.Context, spec *TurnSpec, in *Input) (*Result, error) {
+	start := time.Now()
+	if err := spec.Validate(); err != nil {
+		return nil, errors.NewValidationError(err.Error(), map[string]interface{}{"operation": "turn_execute"})
+	}
+	if e.store == nil {
+		return nil, errors.NewValidationError("turn executor has no store",
+			map[string]interface{}{"operation": "turn_execute", "turn": spec.Turn})
+	}
+	if in == nil || in.VerificationID == "" || in.SystemPrompt == "" {
+		return nil, errors.NewValidationError("verification id and system prompt are required",
+			map[string]interface{}{"operation": "turn_execute", "turn": spec.Turn})
+	}
+
+	result, err := e.execute(ctx, spec, in, start)
+	if err != nil {
+		e.emit(ctx, in.VerificationID, spec.Statuses.Error, map[string]interface{}{
+			"turn":  spec.Turn,
+			"error": err.Error(),
+		})
+		e.log.Error("turn_execution_failed", map[string]interface{}{
+			"verification_id": in.VerificationID,
+			"turn":            spec.Turn,
+			"error":           err.Error(),
+		})
+		return nil, err
+	}
+	return result, nil
+}
+
+func (e *Executor) execute(ctx context.Context, spec *TurnSpec, in *Input, start time.Time) (*Result, error) {
+	e.emit(ctx, in.VerificationID, spec.Statuses.Started, map[string]interface{}{"turn": spec.Turn})
+
+	renderStart := time.Now()
+	prompt, err := e.renderer.RenderTemplateWithVersion(spec.RenderedTemplateType(), spec.TemplateVersion, in.TemplateData)
+	if err != nil {
+		if wfErr, ok := err.(*errors.WorkflowError); ok {
+			// Already classified by the renderer
+			return nil, wfErr.WithOperation(OperationRenderPrompt)
+		}
+		return nil, errors.WrapError(err, errors.ErrorTypeTemplate,
+			fmt.Sprintf("failed to render turn %d prompt", spec.Turn), false).
+			WithOperation(OperationRenderPrompt).
+			WithContext("template_type", spec.RenderedTemplateType()).
+			WithContext("template_version", spec.TemplateVersion)
+	}
+	renderDuration := time.Since(renderStart)
+	if err := e.store.StorePrompt(ctx, in.VerificationID, spec.Turn, prompt); err != nil {
+		e.log.Warn("turn_prompt_store_failed", map[string]interface{}{
+			"verification_id": in.VerificationID,
+			"turn":            spec.Turn,
+			"error":           err.Error(),
+		})
+	}
+	e.emit(ctx, in.VerificationID, spec.Statuses.PromptPrepared, map[string]interface{}{
+		"turn":             spec.Turn,
+		"prompt_length":    len(prompt),
+		"template_type":    spec.TemplateType,
+		"template_version": spec.TemplateVersion,
+		"locale":           spec.Locale,
+	})
+
+	messages, err := e.history(ctx, spec, in)
+	if err != nil {
+		return nil, err
+	}
+	images, err := e.images(ctx, spec, in)
+	if err != nil {
+		return nil, err
+	}
+	messages = append(messages, bedrock.CreateUserMessageWithContent(turnText(spec.Turn, prompt), images))
+
+	request := &bedrock.ConverseRequest{
+		ModelId:         spec.ModelID,
+		System:          in.SystemPrompt,
+		Messages:        messages,
+		InferenceConfig: spec.Inference,
+	}
+	if err := bedrock.ValidateConverseRequest(request); err != nil {
+		return nil, errors.WrapError(err, errors.ErrorTypeValidation,
+			"Bedrock request validation failed", false).
+			WithOperation(OperationConverse).
+			WithContext("turn", spec.Turn).
+			WithContext("message_count", len(messages))
+	}
+
+	e.emit(ctx, in.VerificationID, spec.Statuses.BedrockInvoked, map[string]interface{}{
+		"turn":          spec.Turn,
+		"model_id":      spec.ModelID,
+		"message_count": len(messages),
+		"image_count":   len(images),
+	})
+
+	callCtx := ctx
+	if spec.Timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, spec.Timeout)
+		defer cancel()
+	}
+	response, latencyMs, err := e.converser.Converse(callCtx, request)
+	if err != nil {
+		if wfErr, ok := err.(*errors.WorkflowError); ok {
+			// Keep the client's classification, e.g. throttling
+			return nil, wfErr.WithOperation(OperationConverse).
+				WithContext("turn", spec.Turn).
+				WithContext("model_id", spec.ModelID)
+		}
+		return nil, errors.WrapError(err, errors.ErrorTypeBedrock,
+			fmt.Sprintf("failed to invoke Bedrock for turn %d", spec.Turn), true).
+			WithOperation(OperationConverse).
+			WithContext("model_id", spec.ModelID).
+			WithContext("message_count", len(messages))
+	}
+
+	result := &Result{
+		RenderDuration: renderDuration,
+		Prompt:         prompt,
+		Content:        bedrock.ExtractTextFromResponse(response),
+		Thinking:       bedrock.ExtractThinkingFromResponse(response),
+		ModelID:        response.ModelID,
+		RequestID:      response.RequestID,
+		LatencyMs:      latencyMs,
+		Reasoning:      bedrock.ExtractReasoningBlocks(response),
+	}
+	if response.Usage != nil {
+		result.Usage = schema.TokenUsage{
+			InputTokens:  response.Usage.InputTokens,
+			OutputTokens: response.Usage.OutputTokens,
+			TotalTokens:  response.Usage.TotalTokens,
+		}
+	}
+
+	if spec.Parser != nil {
+		if result.Parsed, err = spec.Parser.Parse(result.Content); err != nil {
+			return nil, errors.WrapError(err, errors.ErrorTypeConversion,
+				fmt.Sprintf("failed to parse turn %d response", spec.Turn), false).
+				WithOperation(OperationParseResponse).
+				WithContext("response_length", len(result.Content))
+		}
+	}
+
+	metadata := map[string]interface{}{
+		"template_type":    spec.TemplateType,
+		"template_version": spec.TemplateVersion,
+		"has_thinking":     len(result.Reasoning) > 0,
+	}
+	if spec.Locale != templateloader.DefaultLocale {
+		metadata["locale"] = spec.Locale
+	}
+	for k, v := range in.Metadata {
+		metadata[k] = v
+	}
+	if src, ok := e.converser.(TurnMetadataSource); ok {
+		for k, v := range src.TurnMetadata() {
+			metadata[k] = v
+		}
+	}
+	if result.Parsed != nil {
+		metadata["parsed"] = result.Parsed
+	}
+	usage := result.Usage
+	result.Turn = &schema.TurnResponse{
+		TurnId:    spec.Turn,
+		Timestamp: schema.FormatISO8601(),
+		Prompt:    prompt,
+		Response: schema.BedrockApiResponse{
+			Content:    result.Content,
+			Thinking:   result.Thinking,
+			StopReason: response.StopReason,
+			ModelId:    response.ModelID,
+			RequestId:  response.RequestID,
+		},
+		LatencyMs:  latencyMs,
+		TokenUsage: &usage,
+		Stage:      spec.Stage,
+		Metadata:   metadata,
+	}
+	if err := e.store.StoreTurn(ctx, in.VerificationID, result.Turn); err != nil {
+		e.log.Warn("turn_store_failed", map[string]interface{}{
+			"verification_id": in.VerificationID,
+			"turn":            spec.Turn,
+			"error":           err.Error(),
+		})
+	}
+
+	result.Duration = time.Since(start)
+	e.emit(ctx, in.VerificationID, spec.Statuses.Completed, map[string]interface{}{
+		"turn":        spec.Turn,
+		"latency_ms":  latencyMs,
+		"duration_ms": result.Duration.Milliseconds(),
+	})
+	e.log.Info("turn_execution_completed", map[string]interface{}{
+		"verification_id": in.VerificationID,
+		"turn":            spec.Turn,
+		"stage":           spec.Stage,
+		"model_id":        response.ModelID,
+		"input_tokens":    result.Usage.InputTokens,
+		"output_tokens":   result.Usage.OutputTokens,
+		"latency_ms":      latencyMs,
+	})
+	return result, nil
+}
+
+// history replays the input's prefix messages, then the spec's previous turns
+// as alternating user/assistant messages.
+func (e *Executor) history(ctx context.Context, spec *TurnSpec, in *Input) ([]bedrock.MessageWrapper, error) {
+	messages := make([]bedrock.MessageWrapper, 0, len(in.Prefix)+2*len(spec.History)+1)
+	messages = append(messages, in.Prefix...)
+	for _, t := range spec.History {
+		turn := in.History[t]
+		if turn == nil {
+			var err error
+			if turn, err = e.store.LoadTurn(ctx, in.VerificationID, t); err != nil {
+				return nil, storeError(err, fmt.Sprintf("failed to load turn %d history", t), spec.Turn)
+			}
+		}
+		if turn == nil {
+			continue
+		}
+		if p := strings.TrimSpace(turn.Prompt); p != "" {
+			messages = append(messages, bedrock.MessageWrapper{
+				Role:    "user",
+				Content: []bedrock.ContentBlock{{Type: bedrock.ContentTypeText, Text: turnText(t, p)}},
+			})
+		}
+		if c := strings.TrimSpace(turn.Response.Content); c != "" {
+			messages = append(messages, bedrock.CreateAssistantMessageWithText(c))
+		}
+	}
+	return messages, nil
+}
+
+// images resolves the spec's image roles to content blocks.
+func (e *Executor) images(ctx context.Context, spec *TurnSpec, in *Input) ([]bedrock.ContentBlock, error) {
+	blocks := make([]bedrock.ContentBlock, 0, len(spec.Images))
+	for _, role := range spec.Images {
+		img := in.Images[role]
+		if img == nil {
+			var err error
+			if img, err = e.store.LoadImage(ctx, in.VerificationID, role); err != nil {
+				return nil, storeError(err, fmt.Sprintf("failed to load %s image", role), spec.Turn)
+			}
+		}
+		if img == nil || img.Base64 == "" {
+			return nil, errors.NewValidationError(fmt.Sprintf("%s image is empty", role),
+				map[string]interface{}{"turn": spec.Turn}).WithOperation(OperationLoadInputs)
+		}
+		blocks = append(blocks, bedrock.CreateImageContentFromBytes(bedrock.NormalizeImageFormat(img.Format), img.Base64))
+	}
+	return blocks, nil
+}
+
+// storeError keeps the classification of workflow errors returned by the
+// Store and wraps anything else as a retryable S3 error.
+func storeError(err error, message string, turn int) error {
+	if wfErr, ok := err.(*errors.WorkflowError); ok {
+		return wfErr.WithOperation(OperationLoadInputs).WithContext("turn", turn)
+	}
+	return errors.WrapError(err, errors.ErrorTypeS3, message, true).
+		WithOperation(OperationLoadInputs).
+		WithContext("turn", turn)
+}
+
+func (e *Executor) emit(ctx context.Context, verificationID, status string, metrics map[string]interface{}) {
+	if status == "" || e.status == nil {
+		return
+	}
+	if err := e.status.UpdateStatus(ctx, verificationID, status, metrics); err != nil {
+		e.log.Warn("turn_status_update_failed", map[string]interface{}{
+			"verification_id": verificationID,
+			"status":          status,
+			"error":           err.Error(),
+		})
+	}
+}
+
+// turnText prefixes prompts of follow-up turns with their turn number, the
+// convention the Turn2 adapter uses.
+func turnText(turn int, prompt string) string {
+	if turn <= 1 {
+		return prompt
+	}
+	return fmt.Sprintf("[Turn %d] %s", turn, prompt)
+}
@@ -0,0 +1,321 @@
+package turnexecutor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"workflow-function/shared/bedrock"
+	"workflow-function/shared/errors"
+	"workflow-function/shared/experiments"
+	"workflow-function/shared/logger"
+	"workflow-function/shared/schema"
+)
+
+type fakeConverser struct {
+	request *bedrock.ConverseRequest
+	err     error
+}
+
+func (f *fakeConverser) Converse(ctx context.Context, request *bedrock.ConverseRequest) (*bedrock.ConverseResponse, int64, error) {
+	f.request = request
+	if f.err != nil {
+		return nil, 0, f.err
+	}
+	return &bedrock.ConverseResponse{
+		ModelID: "test-model",
+		Content: []bedrock.ContentBlock{{Type: bedrock.ContentTypeText, Text: "answer"}},
+		Usage:   &bedrock.TokenUsage{InputTokens: 3, OutputTokens: 2, TotalTokens: 5},
+	}, 7, nil
+}
+
+type fakeRenderer struct{}
+
+func (fakeRenderer) RenderTemplateWithVersion(templateType, version string, data interface{}) (string, error) {
+	return fmt.Sprintf("%s@%s", templateType, version), nil
+}
+
+type fakeStore struct {
+	stored  *schema.TurnResponse
+	prompts map[int]string
+}
+
+func (s *fakeStore) LoadImage(ctx context.Context, verificationID, role string) (*Image, error) {
+	return &Image{Base64: "aGVsbG8=", Format: "png"}, nil
+}
+
+func (s *fakeStore) LoadTurn(ctx context.Context, verificationID string, turn int) (*schema.TurnResponse, error) {
+	return &schema.TurnResponse{TurnId: turn, Prompt: "stored prompt", Response: schema.BedrockApiResponse{Content: "stored answer"}}, nil
+}
+
+func (s *fakeStore) StorePrompt(ctx context.Context, verificationID string, turn int, prompt string) error {
+	s.prompts[turn] = prompt
+	return nil
+}
+
+func (s *fakeStore) StoreTurn(ctx context.Context, verificationID string, turn *schema.TurnResponse) error {
+	s.stored = turn
+	return nil
+}
+
+type recordingSink struct {
+	statuses []string
+}
+
+func (r *recordingSink) UpdateStatus(ctx context.Context, verificationID, status string, metrics map[string]interface{}) error {
+	r.statuses = append(r.statuses, status)
+	return nil
+}
+
+func testSpec() *TurnSpec {
+	return &TurnSpec{
+		Turn:            3,
+		Stage:           "SELF_VERIFICATION",
+		TemplateType:    "turn3-self-verification",
+		TemplateVersion: "1.0",
+		Images:          []string{ImageRoleChecking},
+		History:         []int{1, 2},
+		Statuses:        StatusSet{Started: "STARTED", BedrockInvoked: "INVOKED", Completed: "DONE", Error: "FAILED"},
+		ModelID:         "test-model",
+		Inference:       bedrock.InferenceConfig{MaxTokens: 100},
+	}
+}
+
+func TestExecuteBuildsConversationFromSpec(t *testing.T) {
+	conv := &fakeConverser{}
+	store := &fakeStore{prompts: map[int]string{}}
+	sink := &recordingSink{}
+	spec := testSpec()
+	spec.Parser = ParserFunc(func(content string) (interface{}, error) { return len(content), nil })
+
+	exec := New(conv, fakeRenderer{}, sink, logger.New("test", "turnexecutor")).WithStore(store)
+	res, err := exec.Execute(context.Background(), spec, &Input{
+		VerificationID: "verif-1",
+		SystemPrompt:   "system",
+		History: map[int]*schema.TurnResponse{
+			2: {Prompt: "turn2 prompt", Response: schema.BedrockApiResponse{Content: "turn2 answer"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+
+	msgs := conv.request.Messages
+	if len(msgs) != 5 {
+		t.Fatalf("expected 5 messages, got %d", len(msgs))
+	}
+	if got := msgs[0].Content[0].Text; got != "stored prompt" {
+		t.Errorf("turn1 history should come from the store, got %q", got)
+	}
+	if got := msgs[2].Content[0].Text; got != "[Turn 2] turn2 prompt" {
+		t.Errorf("unexpected turn2 history prompt %q", got)
+	}
+	last := msgs[4]
+	if last.Content[0].Text != "[Turn 3] turn3-self-verification@1.0" || len(last.Content) != 2 || last.Content[1].Image == nil {
+		t.Errorf("unexpected final user message: %+v", last)
+	}
+
+	if res.Parsed != len("answer") {
+		t.Errorf("parser result not returned, got %v", res.Parsed)
+	}
+	if store.stored == nil || store.stored.TurnId != 3 || store.stored.Stage != "SELF_VERIFICATION" {
+		t.Errorf("turn not stored as expected: %+v", store.stored)
+	}
+	if store.prompts[3] == "" {
+		t.Error("prompt was not stored")
+	}
+	if want := []string{"STARTED", "INVOKED", "DONE"}; fmt.Sprint(sink.statuses) != fmt.Sprint(want) {
+		t.Errorf("statuses = %v, want %v", sink.statuses, want)
+	}
+}
+
+func TestExecuteSendsPrefixBeforeHistory(t *testing.T) {
+	conv := &fakeConverser{}
+	exec := New(conv, fakeRenderer{}, nil, logger.New("test", "turnexecutor")).
+		WithStore(&fakeStore{prompts: map[int]string{}})
+	_, err := exec.Execute(context.Background(), testSpec(), &Input{
+		VerificationID: "verif-1",
+		SystemPrompt:   "system",
+		Prefix: []bedrock.MessageWrapper{
+			bedrock.CreateUserMessageWithContent("example prompt", nil),
+			bedrock.CreateAssistantMessageWithText("example answer"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+
+	msgs := conv.request.Messages
+	if len(msgs) != 7 {
+		t.Fatalf("expected 7 messages, got %d", len(msgs))
+	}
+	if msgs[0].Content[0].Text != "example prompt" || msgs[1].Content[0].Text != "example answer" {
+		t.Errorf("prefix messages should come first, got %+v", msgs[:2])
+	}
+	if got := msgs[2].Content[0].Text; got != "stored prompt" {
+		t.Errorf("history should follow the prefix, got %q", got)
+	}
+}
+
+func TestExecuteEmitsErrorStatus(t *testing.T) {
+	sink := &recordingSink{}
+	exec := New(&fakeConverser{err: fmt.Errorf("throttled")}, fakeRenderer{}, sink, logger.New("test", "turnexecutor")).
+		WithStore(&fakeStore{prompts: map[int]string{}})
+
+	if _, err := exec.Execute(context.Background(), testSpec(), &Input{VerificationID: "verif-1", SystemPrompt: "system"}); err == nil {
+		t.Fatal("expected error from failing converser")
+	}
+	if n := len(sink.statuses); n == 0 || sink.statuses[n-1] != "FAILED" {
+		t.Errorf("expected FAILED as last status, got %v", sink.statuses)
+	}
+}
+
+func TestSpecValidateRejectsFutureHistory(t *testing.T) {
+	spec := testSpec()
+	spec.History = []int{3}
+	if err := spec.Validate(); err == nil {
+		t.Error("expected error for history turn that does not precede the spec turn")
+	}
+}
+
+func TestSpecWithAssignment(t *testing.T) {
+	spec := testSpec()
+	temperature := 0.2
+
+	got := spec.WithAssignment(experiments.Assignment{TemplateType: spec.TemplateType, ModelID: "model-b", Temperature: &temperature})
+	if got.TemplateVersion != "1.0" || got.ModelID != "model-b" || got.Inference.Temperature == nil || *got.Inference.Temperature != 0.2 {
+		t.Errorf("unexpected spec %+v", got)
+	}
+	if spec.ModelID != "test-model" || spec.Inference.Temperature != nil {
+		t.Error("WithAssignment modified the original spec")
+	}
+
+	got = spec.WithAssignment(experiments.Assignment{TemplateType: "turn2-previous-vs-current", TemplateVersion: "2.0", ModelID: "model-b"})
+	if got.TemplateVersion != "1.0" || got.ModelID != "test-model" {
+		t.Errorf("assignment for another template type applied: %+v", got)
+	}
+}
+
+// fakeLocales provides the templates of locale for every template type
+type fakeLocales struct{ locale string }
+
+func (f fakeLocales) ResolveLocale(templateType, locale, version string) string {
+	return f.locale
+}
+
+func TestSpecWithLocale(t *testing.T) {
+	spec := testSpec()
+
+	if got := spec.WithLocale(nil, "vi"); got.Locale != "" || got.RenderedTemplateType() != "turn3-self-verification" {
+		t.Errorf("expected the default templates without a resolver, got %q", got.RenderedTemplateType())
+	}
+	if got := spec.WithLocale(fakeLocales{locale: "vi"}, ""); got.Locale != "" {
+		t.Errorf("expected the default templates without a locale, got %q", got.Locale)
+	}
+
+	localized := spec.WithLocale(fakeLocales{locale: "vi"}, "vi-VN")
+	if localized.TemplateType != "turn3-self-verification" || localized.RenderedTemplateType() != "turn3-self-verification/vi" {
+		t.Errorf("unexpected localized spec %q / %q", localized.TemplateType, localized.RenderedTemplateType())
+	}
+	if spec.Locale != "" {
+		t.Error("WithLocale modified the original spec")
+	}
+
+	conv := &fakeConverser{}
+	_, err := New(conv, fakeRenderer{}, nil, logger.New("test", "turnexecutor")).
+		WithStore(&fakeStore{prompts: map[int]string{}}).
+		Execute(context.Background(), localized, &Input{VerificationID: "verif-1", SystemPrompt: "system"})
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	msgs := conv.request.Messages
+	if got := msgs[len(msgs)-1].Content[0].Text; got != "[Turn 3] turn3-self-verification/vi@1.0" {
+		t.Errorf("expected the localized template to be rendered, got %q", got)
+	}
+}
+
+type failingRenderer struct{ err error }
+
+func (f failingRenderer) RenderTemplateWithVersion(templateType, version string, data interface{}) (string, error) {
+	return "", f.err
+}
+
+func TestExecuteKeepsClassifiedRenderErrors(t *testing.T) {
+	classified := errors.NewValidationError("prompt exceeds token budget", nil)
+	tests := []struct {
+		err  error
+		want errors.ErrorType
+	}{
+		{classified, errors.ErrorTypeValidation},
+		{fmt.Errorf("unexpected"), errors.ErrorTypeTemplate},
+	}
+
+	for _, tt := range tests {
+		exec := New(&fakeConverser{}, failingRenderer{tt.err}, nil, logger.New("test", "turnexecutor")).
+			WithStore(&fakeStore{prompts: map[int]string{}})
+		_, err := exec.Execute(context.Background(), testSpec(), &Input{VerificationID: "verif-1", SystemPrompt: "system"})
+		wfErr, ok := err.(*errors.WorkflowError)
+		if !ok || wfErr.Type != tt.want || wfErr.Operation != OperationRenderPrompt {
+			t.Errorf("render error %v: got %v, want type %s", tt.err, err, tt.want)
+		}
+	}
+}
+
+func TestExecuteKeepsClassifiedConverseErrors(t *testing.T) {
+	throttled := &errors.WorkflowError{Type: errors.ErrorTypeThrottling, Message: "slow down", Retryable: true}
+	tests := []struct {
+		err  error
+		want errors.ErrorType
+	}{
+		{throttled, errors.ErrorTypeThrottling},
+		{fmt.Errorf("connection reset"), errors.ErrorTypeBedrock},
+	}
+
+	for _, tt := range tests {
+		exec := New(&fakeConverser{err: tt.err}, fakeRenderer{}, nil, logger.New("test", "turnexecutor")).
+			WithStore(&fakeStore{prompts: map[int]string{}})
+		_, err := exec.Execute(context.Background(), testSpec(), &Input{VerificationID: "verif-1", SystemPrompt: "system"})
+		wfErr, ok := err.(*errors.WorkflowError)
+		if !ok || wfErr.Type != tt.want || wfErr.Operation != OperationConverse {
+			t.Errorf("converse error %v: got %v, want type %s", tt.err, err, tt.want)
+		}
+	}
+}
+
+func TestExecuteRecordsInputFailures(t *testing.T) {
+	exec := New(&fakeConverser{}, fakeRenderer{}, nil, logger.New("test", "turnexecutor")).
+		WithStore(&fakeStore{prompts: map[int]string{}})
+	_, err := exec.Execute(context.Background(), testSpec(), &Input{
+		VerificationID: "verif-1",
+		SystemPrompt:   "system",
+		Images:         map[string]*Image{ImageRoleChecking: {Format: "png"}},
+	})
+	if wfErr, ok := err.(*errors.WorkflowError); !ok || wfErr.Operation != OperationLoadInputs {
+		t.Errorf("empty image: got %#v", err)
+	}
+}
+
+type describingConverser struct {
+	fakeConverser
+}
+
+func (d *describingConverser) TurnMetadata() map[string]interface{} {
+	return map[string]interface{}{"ensemble": "3 samples", "model_id": "from-converser"}
+}
+
+func TestExecuteMergesConverserMetadata(t *testing.T) {
+	store := &fakeStore{prompts: map[int]string{}}
+	exec := New(&describingConverser{}, fakeRenderer{}, nil, logger.New("test", "turnexecutor")).WithStore(store)
+	_, err := exec.Execute(context.Background(), testSpec(), &Input{
+		VerificationID: "verif-1",
+		SystemPrompt:   "system",
+		Metadata:       map[string]interface{}{"model_id": "from-input"},
+	})
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	metadata := store.stored.Metadata
+	if metadata["ensemble"] != "3 samples" || metadata["model_id"] != "from-converser" {
+		t.Errorf("converser metadata not merged: %v", metadata)
+	}
+}
@@ -0,0 +1,243 @@
+package turnexecutor
+
+import (
+	goerrors "errors"
+	"strings"
+
+	"workflow-function/shared/bedrock"
+	"workflow-function/shared/errors"
+	"workflow-function/shared/logger"
+	"workflow-function/shared/schema"
+	"workflow-function/shared/templateloader"
+)
+
+// TemplateSource loads and renders prompt templates.
+// templateloader.TemplateLoader implements it.
+type TemplateSource interface {
+	RenderTemplate(templateType string, data interface{}) (string, error)
+	RenderTemplateWithVersion(templateType, version string, data interface{}) (string, error)
+}
+
+// PromptRenderer is the Renderer shared by the turn functions. It renders the
+// latest template version when the spec pins none, classifies rendering
+// failures and enforces a prompt token budget.
+type PromptRenderer struct {
+	source    TemplateSource
+	maxTokens int
+	log       logger.Logger
+}
+
+// NewPromptRenderer creates a PromptRenderer without a token budget.
+func NewPromptRenderer(source TemplateSource, log logger.Logger) *PromptRenderer {
+	return &PromptRenderer{source: source, log: log}
+}
+
+// WithTokenBudget returns a copy of the renderer that rejects prompts whose
+// estimated token count exceeds tokens.
+func (r *PromptRenderer) WithTokenBudget(tokens int) *PromptRenderer {
+	c := *r
+	c.maxTokens = tokens
+	return &c
+}
+
+// RenderTemplateWithVersion renders templateType at version, or at its latest
+// version when version is empty.
+func (r *PromptRenderer) RenderTemplateWithVersion(templateType, version string, data interface{}) (string, error) {
+	var (
+		prompt string
+		err    error
+	)
+	if version != "" {
+		prompt, err = r.source.RenderTemplateWithVersion(templateType, version, data)
+	} else {
+		prompt, err = r.source.RenderTemplate(templateType, data)
+	}
+	if err != nil {
+		r.log.Error("template_rendering_failed", map[string]interface{}{
+			"error":            err.Error(),
+			"template_type":    templateType,
+			"template_version": version,
+		})
+		return "", classifyTemplateError(err, templateType, version)
+	}
+
+	// Rough estimate of 4 characters per token
+	if estimate := len(prompt) / 4; r.maxTokens > 0 && estimate > r.maxTokens {
+		return "", errors.NewValidationError("prompt exceeds token budget", map[string]interface{}{
+			"template_type":    templateType,
+			"estimated_tokens": estimate,
+			"max_budget":       r.maxTokens,
+			"prompt_length":    len(prompt),
+		})
+	}
+	return prompt, nil
+}
+
+// ResolveLocale returns the locale rendered for locale when the source
+// resolves locales, as templateloader.Loader does, else the default locale.
+func (r *PromptRenderer) ResolveLocale(templateType, locale, version string) string {
+	if resolver, ok := r.source.(LocaleResolver); ok {
+		return resolver.ResolveLocale(templateType, locale, version)
+	}
+	return templateloader.DefaultLocale
+}
+
+// classifyTemplateError maps a rendering failure to a workflow error. Data
+// mismatches and contract violations are validation errors; missing or
+// malformed templates are internal errors.
+func classifyTemplateError(err error, templateType, version string) error {
+	var contractErr *templateloader.ContractError
+	if goerrors.As(err, &contractErr) {
+		return errors.NewValidationError(
+			"template data does not satisfy the template contract",
+			map[string]interface{}{
+				"error_category":   "template_contract_violation",
+				"template_type":    templateType,
+				"template_version": version,
+				"violations":       contractErr.Violations,
+				"original_error":   err.Error(),
+			})
+	}
+
+	msg := strings.ToLower(err.Error())
+	has := func(words ...string) bool {
+		for _, w := range words {
+			if strings.Contains(msg, w) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var category string
+	switch {
+	case has("template", "not found"):
+		category = "missing_template"
+	case has("parse", "syntax"):
+		category = "template_syntax_error"
+	case has("execute", "field") || has("function", "not defined"):
+		return errors.NewValidationError(
+			"template execution failed due to data structure mismatch or missing function",
+			map[string]interface{}{
+				"error_category":   "template_data_mismatch",
+				"template_type":    templateType,
+				"template_version": version,
+				"original_error":   err.Error(),
+			})
+	default:
+		category = "unknown_template_error"
+	}
+	return errors.NewInternalError("prompt_renderer", err).
+		WithContext("error_category", category).
+		WithContext("template_type", templateType).
+		WithContext("template_version", version).
+		WithContext("original_error", err.Error())
+}
+
+// AddLayoutContext adds the layout fields of a LAYOUT_VS_CHECKING prompt to
+// data: RowCount and ColumnCount (-1 when unknown), RowLabels, LayoutId,
+// LayoutPrefix, LayoutMetadata and Location.
+func AddLayoutContext(data map[string]interface{}, layoutID int, layoutPrefix string, layout map[string]interface{}) {
+	rowCount := intValue(layout["RowCount"], -1)
+	data["RowCount"] = rowCount
+	data["ColumnCount"] = intValue(layout["ColumnCount"], -1)
+	data["RowLabels"] = rowLabels(layout, rowCount)
+	data["LayoutId"] = layoutID
+	data["LayoutPrefix"] = layoutPrefix
+	data["LayoutMetadata"] = layout
+
+	for _, key := range []string{"Location", "location"} {
+		if location, ok := layout[key].(string); ok {
+			data["Location"] = location
+			break
+		}
+	}
+}
+
+// rowLabels returns the layout's RowLabels if it covers rowCount rows, and
+// A, B, C, ... otherwise.
+func rowLabels(layout map[string]interface{}, rowCount int) []string {
+	if rowCount <= 0 {
+		return []string{}
+	}
+	switch labels := layout["RowLabels"].(type) {
+	case []string:
+		if len(labels) >= rowCount {
+			return labels
+		}
+	case []interface{}:
+		out := make([]string, 0, len(labels))
+		for _, l := range labels {
+			if s, ok := l.(string); ok {
+				out = append(out, s)
+			}
+		}
+		if len(out) >= rowCount {
+			return out
+		}
+	}
+
+	out := make([]string, rowCount)
+	for i := range out {
+		out[i] = string(rune('A' + i))
+	}
+	return out
+}
+
+func intValue(v interface{}, def int) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	}
+	return def
+}
+
+// TemplateProcessor describes how the turn's prompt was produced, in the
+// format persisted as processing/turn<N>-template-processor.json.
+func (r *Result) TemplateProcessor(spec *TurnSpec, data map[string]interface{}) *schema.TemplateProcessor {
+	return &schema.TemplateProcessor{
+		Template: &schema.PromptTemplate{
+			TemplateId:      spec.RenderedTemplateType(),
+			TemplateVersion: spec.TemplateVersion,
+			TemplateType:    spec.TemplateType,
+			Locale:          spec.Locale,
+			Content:         r.Prompt,
+		},
+		ContextData:     data,
+		ProcessedPrompt: r.Prompt,
+		ProcessingTime:  r.RenderDuration.Milliseconds(),
+		InputTokens:     r.Usage.InputTokens,
+		OutputTokens:    r.Usage.OutputTokens,
+	}
+}
+
+// ReasoningArtifact returns the turn's reasoning blocks in the persisted
+// artifact format, or nil when the model returned none.
+func (r *Result) ReasoningArtifact(verificationID, stage string, budgetTokens int) *schema.ReasoningArtifact {
+	if len(r.Reasoning) == 0 {
+		return nil
+	}
+	artifact := &schema.ReasoningArtifact{
+		VerificationId: verificationID,
+		TurnId:         r.Turn.TurnId,
+		AnalysisStage:  stage,
+		ModelId:        r.ModelID,
+		RequestId:      r.RequestID,
+		BudgetTokens:   budgetTokens,
+		CreatedAt:      schema.FormatISO8601(),
+	}
+	for _, b := range r.Reasoning {
+		artifact.Blocks = append(artifact.Blocks, schema.ReasoningBlock{
+			Text:      b.Text,
+			Signature: b.Signature,
+			Redacted:  b.Type == bedrock.ContentTypeRedactedThinking,
+		})
+		// Rough estimate (~4 characters per token) since Bedrock does not report reasoning tokens separately
+		artifact.EstimatedTokens += len(b.Text) / 4
+	}
+	return artifact
+}
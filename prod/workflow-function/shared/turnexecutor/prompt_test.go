@@ -0,0 +1,123 @@
+package turnexecutor
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"workflow-function/shared/errors"
+	"workflow-function/shared/logger"
+	"workflow-function/shared/templateloader"
+)
+
+type fakeSource struct {
+	rendered string
+	version  string
+	err      error
+}
+
+func (f *fakeSource) RenderTemplate(templateType string, data interface{}) (string, error) {
+	f.version = "latest"
+	return f.rendered, f.err
+}
+
+func (f *fakeSource) RenderTemplateWithVersion(templateType, version string, data interface{}) (string, error) {
+	f.version = version
+	return f.rendered, f.err
+}
+
+func TestPromptRendererVersionFallback(t *testing.T) {
+	source := &fakeSource{rendered: "prompt"}
+	renderer := NewPromptRenderer(source, logger.New("test", "turnexecutor"))
+
+	if _, err := renderer.RenderTemplateWithVersion("turn1-default", "", nil); err != nil || source.version != "latest" {
+		t.Errorf("expected the latest version without a pinned version, got %q (%v)", source.version, err)
+	}
+	if _, err := renderer.RenderTemplateWithVersion("turn1-default", "2.1.0", nil); err != nil || source.version != "2.1.0" {
+		t.Errorf("expected the pinned version, got %q (%v)", source.version, err)
+	}
+}
+
+func TestPromptRendererClassifiesErrors(t *testing.T) {
+	tests := []struct {
+		err      string
+		typ      errors.ErrorType
+		category string
+	}{
+		{"template turn9 not found", errors.ErrorTypeInternal, "missing_template"},
+		{"template: turn1:3: unexpected EOF in parse", errors.ErrorTypeInternal, "missing_template"},
+		{"bad syntax", errors.ErrorTypeInternal, "template_syntax_error"},
+		{"can't evaluate field RowCount", errors.ErrorTypeValidation, "template_data_mismatch"},
+		{"boom", errors.ErrorTypeInternal, "unknown_template_error"},
+	}
+
+	for _, tt := range tests {
+		renderer := NewPromptRenderer(&fakeSource{err: fmt.Errorf("%s", tt.err)}, logger.New("test", "turnexecutor"))
+		_, err := renderer.RenderTemplateWithVersion("turn1-default", "1.0", nil)
+		wfErr, ok := err.(*errors.WorkflowError)
+		if !ok {
+			t.Fatalf("%q: expected a WorkflowError, got %T", tt.err, err)
+		}
+		category := wfErr.Context["error_category"]
+		if category == nil {
+			category = wfErr.Details["error_category"]
+		}
+		if wfErr.Type != tt.typ || category != tt.category {
+			t.Errorf("%q: got %s/%v, want %s/%s", tt.err, wfErr.Type, category, tt.typ, tt.category)
+		}
+	}
+}
+
+func TestPromptRendererClassifiesContractErrors(t *testing.T) {
+	contractErr := &templateloader.ContractError{Violations: []string{"RowCount: required field is missing"}}
+	renderer := NewPromptRenderer(&fakeSource{err: fmt.Errorf("render turn1-default: %w", contractErr)}, logger.New("test", "turnexecutor"))
+
+	_, err := renderer.RenderTemplateWithVersion("turn1-default", "1.0", nil)
+	wfErr, ok := err.(*errors.WorkflowError)
+	if !ok {
+		t.Fatalf("expected a WorkflowError, got %T", err)
+	}
+	if wfErr.Type != errors.ErrorTypeValidation || wfErr.Details["error_category"] != "template_contract_violation" {
+		t.Errorf("got %s/%v, want a template_contract_violation validation error", wfErr.Type, wfErr.Details["error_category"])
+	}
+	if !reflect.DeepEqual(wfErr.Details["violations"], contractErr.Violations) {
+		t.Errorf("violations = %v", wfErr.Details["violations"])
+	}
+}
+
+func TestPromptRendererTokenBudget(t *testing.T) {
+	source := &fakeSource{rendered: strings.Repeat("x", 400)}
+	renderer := NewPromptRenderer(source, logger.New("test", "turnexecutor"))
+
+	if _, err := renderer.WithTokenBudget(100).RenderTemplateWithVersion("t", "1", nil); err != nil {
+		t.Errorf("prompt at the budget was rejected: %v", err)
+	}
+	if _, err := renderer.WithTokenBudget(99).RenderTemplateWithVersion("t", "1", nil); err == nil {
+		t.Error("expected a prompt over the budget to be rejected")
+	}
+}
+
+func TestAddLayoutContext(t *testing.T) {
+	data := map[string]interface{}{}
+	AddLayoutContext(data, 7, "AB", map[string]interface{}{
+		"RowCount":    float64(3),
+		"ColumnCount": 4,
+		"RowLabels":   []interface{}{"A", "B"},
+		"location":    "Lobby",
+	})
+
+	if data["RowCount"] != 3 || data["ColumnCount"] != 4 || data["LayoutId"] != 7 || data["Location"] != "Lobby" {
+		t.Errorf("unexpected layout context %+v", data)
+	}
+	// Two labels do not cover three rows, so defaults are generated
+	if got := data["RowLabels"]; !reflect.DeepEqual(got, []string{"A", "B", "C"}) {
+		t.Errorf("RowLabels = %v", got)
+	}
+
+	empty := map[string]interface{}{}
+	AddLayoutContext(empty, 0, "", nil)
+	if empty["RowCount"] != -1 || empty["ColumnCount"] != -1 || len(empty["RowLabels"].([]string)) != 0 {
+		t.Errorf("unexpected context without layout %+v", empty)
+	}
+}
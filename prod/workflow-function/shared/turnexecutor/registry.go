@@ -0,0 +1,53 @@
+package turnexecutor
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Registry holds the turn specs of a workflow keyed by turn number, so new
+// turns are added by registering a spec rather than by new handler code.
+type Registry struct {
+	mu    sync.RWMutex
+	specs map[int]*TurnSpec
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{specs: make(map[int]*TurnSpec)}
+}
+
+// Register validates spec and adds it, replacing any spec for the same turn.
+func (r *Registry) Register(spec *TurnSpec) error {
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.specs[spec.Turn] = spec
+	return nil
+}
+
+// Spec returns the spec registered for turn.
+func (r *Registry) Spec(turn int) (*TurnSpec, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	spec, ok := r.specs[turn]
+	if !ok {
+		return nil, fmt.Errorf("no spec registered for turn %d", turn)
+	}
+	return spec, nil
+}
+
+// Turns returns the registered turn numbers in ascending order.
+func (r *Registry) Turns() []int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	turns := make([]int, 0, len(r.specs))
+	for t := range r.specs {
+		turns = append(turns, t)
+	}
+	sort.Ints(turns)
+	return turns
+}
@@ -0,0 +1,202 @@
+package turnexecutor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"workflow-function/shared/schema"
+)
+
+// PromptInfo describes the request context written next to a rendered
+// prompt in prompts/turn<N>-prompt.json.
+type PromptInfo struct {
+	VerificationType string
+	TemplateVersion  string
+	// Objective is recorded as contextualInstructions.analysisObjective
+	Objective string
+	// ImageRole names the image attached to the prompt
+	ImageRole string
+	// SourceURL is the original image URL; the base64 key is used when empty
+	SourceURL      string
+	ContextSources []string
+}
+
+type turnRefs struct {
+	raw          schema.S3Reference
+	conversation *schema.S3Reference
+}
+
+type imageRef struct {
+	ref    schema.S3Reference
+	format string
+}
+
+// RequestStore binds an S3Store to a single request and implements Store.
+// Images and previous turns are resolved from the references registered
+// with WithImage and WithTurn; the references of stored prompts and turns
+// are kept for the caller.
+type RequestStore struct {
+	*S3Store
+	images     map[string]imageRef
+	loaded     map[string]*Image
+	turns      map[int]turnRefs
+	prompts    map[int]PromptInfo
+	promptRefs map[int]schema.S3Reference
+	turnRefs   map[int]schema.S3Reference
+}
+
+// ForRequest returns a Store for one request.
+func (s *S3Store) ForRequest() *RequestStore {
+	return &RequestStore{
+		S3Store:    s,
+		images:     make(map[string]imageRef),
+		loaded:     make(map[string]*Image),
+		turns:      make(map[int]turnRefs),
+		prompts:    make(map[int]PromptInfo),
+		promptRefs: make(map[int]schema.S3Reference),
+		turnRefs:   make(map[int]schema.S3Reference),
+	}
+}
+
+// WithImage registers the base64 image available for role. An empty format
+// is detected from the image data when it is loaded.
+func (r *RequestStore) WithImage(role string, ref schema.S3Reference, format string) *RequestStore {
+	r.images[role] = imageRef{ref: ref, format: format}
+	return r
+}
+
+// WithTurn registers the stored raw response of a previous turn.
+// conversation may be nil.
+func (r *RequestStore) WithTurn(turn int, raw schema.S3Reference, conversation *schema.S3Reference) *RequestStore {
+	r.turns[turn] = turnRefs{raw: raw, conversation: conversation}
+	return r
+}
+
+// WithPrompt registers the context recorded with the prompt of turn.
+func (r *RequestStore) WithPrompt(turn int, info PromptInfo) *RequestStore {
+	r.prompts[turn] = info
+	return r
+}
+
+// ImageRef returns the reference registered for role.
+func (r *RequestStore) ImageRef(role string) schema.S3Reference {
+	return r.images[role].ref
+}
+
+// PromptRef returns the reference of the stored prompt of turn.
+func (r *RequestStore) PromptRef(turn int) schema.S3Reference {
+	return r.promptRefs[turn]
+}
+
+// TurnRef returns the reference of the stored raw response of turn.
+func (r *RequestStore) TurnRef(turn int) schema.S3Reference {
+	return r.turnRefs[turn]
+}
+
+// LoadImage loads the image registered for role. Images are loaded once per
+// request.
+func (r *RequestStore) LoadImage(ctx context.Context, verificationID, role string) (*Image, error) {
+	if img, ok := r.loaded[role]; ok {
+		return img, nil
+	}
+	src, ok := r.images[role]
+	if !ok {
+		return nil, fmt.Errorf("no %s image registered for this request", role)
+	}
+	data, err := r.LoadBase64Image(ctx, src.ref)
+	if err != nil {
+		return nil, err
+	}
+	format := src.format
+	if format == "" {
+		format = detectImageFormat(data)
+	}
+	img := &Image{Base64: data, Format: format}
+	r.loaded[role] = img
+	return img, nil
+}
+
+// LoadTurn loads the raw response registered for turn.
+func (r *RequestStore) LoadTurn(ctx context.Context, verificationID string, turn int) (*schema.TurnResponse, error) {
+	src, ok := r.turns[turn]
+	if !ok {
+		return nil, fmt.Errorf("turn %d response is not stored for this request", turn)
+	}
+	return r.LoadTurnResponse(ctx, src.raw, src.conversation)
+}
+
+// StorePrompt stores prompts/turn<N>-prompt.json with the context registered
+// by WithPrompt.
+func (r *RequestStore) StorePrompt(ctx context.Context, verificationID string, turn int, prompt string) error {
+	info := r.prompts[turn]
+	doc := map[string]interface{}{
+		"verificationId":   verificationID,
+		"promptType":       fmt.Sprintf("TURN%d", turn),
+		"verificationType": info.VerificationType,
+		"messageStructure": map[string]interface{}{
+			"role":    "user",
+			"content": []map[string]interface{}{{"type": "text", "text": prompt}},
+		},
+		"contextualInstructions": map[string]interface{}{"analysisObjective": info.Objective},
+		"templateVersion":        info.TemplateVersion,
+		"createdAt":              schema.FormatISO8601(),
+		"generationMetadata": map[string]interface{}{
+			"promptSource":   "TEMPLATE_BASED",
+			"contextSources": info.ContextSources,
+		},
+	}
+	if src, ok := r.images[info.ImageRole]; ok {
+		sourceURL := info.SourceURL
+		if sourceURL == "" {
+			sourceURL = src.ref.Key
+		}
+		doc["imageReference"] = map[string]interface{}{
+			"imageType": info.ImageRole,
+			"base64StorageReference": map[string]interface{}{
+				"bucket": src.ref.Bucket,
+				"key":    src.ref.Key,
+			},
+			"sourceUrl": sourceURL,
+		}
+	}
+
+	ref, err := r.StoreJSON(ctx, verificationID, fmt.Sprintf("prompts/turn%d-prompt.json", turn), doc)
+	if err != nil {
+		return err
+	}
+	r.promptRefs[turn] = ref
+	return nil
+}
+
+// StoreTurn stores responses/turn<N>-raw-response.json. The image keys of
+// the request are recorded in turn.ImageUrls and the reasoning summary is
+// copied to the top level, as earlier raw responses did.
+func (r *RequestStore) StoreTurn(ctx context.Context, verificationID string, turn *schema.TurnResponse) error {
+	if turn.ImageUrls == nil && len(r.images) > 0 {
+		turn.ImageUrls = make(map[string]string, len(r.images))
+		for role, src := range r.images {
+			turn.ImageUrls[role] = src.ref.Key
+		}
+	}
+	doc := struct {
+		*schema.TurnResponse
+		Thinking string `json:"thinking,omitempty"`
+	}{TurnResponse: turn, Thinking: turn.Response.Thinking}
+
+	ref, err := r.StoreJSON(ctx, verificationID, fmt.Sprintf("responses/turn%d-raw-response.json", turn.TurnId), doc)
+	if err != nil {
+		return err
+	}
+	r.turnRefs[turn.TurnId] = ref
+	return nil
+}
+
+// detectImageFormat recognises JPEG data by its base64 signature and
+// defaults to PNG.
+func detectImageFormat(base64Data string) string {
+	if strings.HasPrefix(base64Data, "/9j/") {
+		return "jpeg"
+	}
+	return "png"
+}
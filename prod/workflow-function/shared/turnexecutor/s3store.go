@@ -0,0 +1,600 @@
+package turnexecutor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"workflow-function/shared/bedrock"
+	"workflow-function/shared/errors"
+	"workflow-function/shared/logger"
+	"workflow-function/shared/s3state"
+	"workflow-function/shared/schema"
+)
+
+// InitializationData is the initialization.json written by Initialize.
+type InitializationData struct {
+	SchemaVersion       string                     `json:"schemaVersion"`
+	VerificationContext schema.VerificationContext `json:"verificationContext"`
+	SystemPrompt        SystemPromptData           `json:"systemPrompt"`
+	LayoutMetadata      *schema.LayoutMetadata     `json:"layoutMetadata,omitempty"`
+}
+
+// SystemPromptData describes the system prompt referenced by the
+// initialization data.
+type SystemPromptData struct {
+	Content       string `json:"content"`
+	PromptID      string `json:"promptId"`
+	PromptVersion string `json:"promptVersion"`
+}
+
+// ImageMetadata is the images/metadata.json written by FetchImages.
+type ImageMetadata struct {
+	VerificationID     string                 `json:"verificationId"`
+	VerificationType   string                 `json:"verificationType"`
+	ReferenceImage     ImageInfoEnhanced      `json:"referenceImage"`
+	CheckingImage      ImageInfoEnhanced      `json:"checkingImage"`
+	ProcessingMetadata ProcessingMetadataInfo `json:"processingMetadata"`
+	Version            string                 `json:"version"`
+}
+
+// ImageInfoEnhanced describes one fetched image and its base64 copy.
+type ImageInfoEnhanced struct {
+	OriginalMetadata OriginalImageMetadata `json:"originalMetadata"`
+	Base64Metadata   Base64ImageMetadata   `json:"base64Metadata"`
+	StorageMetadata  StorageImageMetadata  `json:"storageMetadata"`
+	ImageType        string                `json:"imageType"`
+	Validation       ImageValidation       `json:"validation"`
+}
+
+type OriginalImageMetadata struct {
+	SourceURL       string          `json:"sourceUrl"`
+	SourceBucket    string          `json:"sourceBucket"`
+	SourceKey       string          `json:"sourceKey"`
+	ContentType     string          `json:"contentType"`
+	OriginalSize    int64           `json:"originalSize"`
+	ImageDimensions ImageDimensions `json:"imageDimensions"`
+}
+
+type Base64ImageMetadata struct {
+	OriginalSize      int64           `json:"originalSize"`
+	EncodedSize       int64           `json:"encodedSize"`
+	EncodingTimestamp string          `json:"encodingTimestamp"`
+	EncodingMethod    string          `json:"encodingMethod"`
+	CompressionRatio  float64         `json:"compressionRatio"`
+	QualitySettings   QualitySettings `json:"qualitySettings"`
+}
+
+type StorageImageMetadata struct {
+	Bucket       string     `json:"bucket"`
+	Key          string     `json:"key"`
+	StoredSize   int64      `json:"storedSize"`
+	StorageClass string     `json:"storageClass"`
+	Encryption   Encryption `json:"encryption"`
+}
+
+type ImageDimensions struct {
+	Width       int     `json:"width"`
+	Height      int     `json:"height"`
+	AspectRatio float64 `json:"aspectRatio"`
+}
+
+type QualitySettings struct {
+	Optimized bool `json:"optimized"`
+}
+
+type Encryption struct {
+	Method string `json:"method"`
+}
+
+type ImageValidation struct {
+	IsValid           bool `json:"isValid"`
+	BedrockCompatible bool `json:"bedrockCompatible"`
+	SizeWithinLimits  bool `json:"sizeWithinLimits"`
+}
+
+type ProcessingMetadataInfo struct {
+	ProcessedAt          string   `json:"processedAt"`
+	ProcessingTimeMs     int64    `json:"processingTimeMs"`
+	TotalImagesProcessed int      `json:"totalImagesProcessed"`
+	ProcessingSteps      []string `json:"processingSteps"`
+	ParallelProcessing   bool     `json:"parallelProcessing"`
+}
+
+// Conversation is the message exchange of one turn as persisted in
+// responses/turn<N>-conversation.json.
+type Conversation struct {
+	Turn  int
+	Stage string
+	// Prior holds the messages of earlier turns. A leading system message
+	// is kept; otherwise System is used.
+	Prior      []schema.BedrockMessage
+	System     string
+	UserPrompt string
+	// ImageRef is the base64 image attached to the user message, if any
+	ImageRef  schema.S3Reference
+	Assistant string
+	Usage     *schema.TokenUsage
+	LatencyMs int64
+	RequestID string
+	ModelID   string
+	// Metadata is merged into bedrockMetadata
+	Metadata map[string]interface{}
+}
+
+// S3Store reads workflow inputs from and writes turn artifacts to the state
+// bucket. Artifacts of a verification are written below
+// <date partition>/<verificationId>/.
+type S3Store struct {
+	state     s3state.Manager
+	partition func() string
+	log       logger.Logger
+}
+
+// NewS3Store creates an S3Store. partition returns the current date
+// partition, e.g. config.CurrentDatePartition.
+func NewS3Store(state s3state.Manager, partition func() string, log logger.Logger) *S3Store {
+	return &S3Store{state: state, partition: partition, log: log}
+}
+
+// Bucket returns the state bucket.
+func (s *S3Store) Bucket() string {
+	return s.state.GetStateBucket()
+}
+
+func (s *S3Store) datePath(verificationID string) string {
+	return fmt.Sprintf("%s/%s", s.partition(), verificationID)
+}
+
+// LoadSystemPrompt returns promptContent.systemMessage of a rich system
+// prompt document.
+func (s *S3Store) LoadSystemPrompt(ctx context.Context, ref schema.S3Reference) (string, error) {
+	raw, err := s.retrieve(ref, "system_prompt")
+	if err != nil {
+		return "", err
+	}
+
+	var wrapper struct {
+		PromptContent struct {
+			SystemMessage string `json:"systemMessage"`
+		} `json:"promptContent"`
+	}
+	if err := json.Unmarshal(raw, &wrapper); err != nil {
+		return "", validationFailure("BadSystemPrompt",
+			fmt.Sprintf("expected rich JSON object, got err %v", err))
+	}
+	if wrapper.PromptContent.SystemMessage == "" {
+		return "", validationFailure("MissingSystemMessage",
+			"promptContent.systemMessage is empty or missing")
+	}
+	return wrapper.PromptContent.SystemMessage, nil
+}
+
+// LoadBase64Image returns the content of a .base64 image object.
+func (s *S3Store) LoadBase64Image(ctx context.Context, ref schema.S3Reference) (string, error) {
+	if err := validateReference(ref, "base64_image"); err != nil {
+		return "", err
+	}
+	if !strings.HasSuffix(ref.Key, ".base64") {
+		return "", validationFailure("ExpectBase64Ext", "image object must end with .base64")
+	}
+	raw, err := s.retrieve(ref, "base64_image")
+	if err != nil {
+		return "", err
+	}
+	b := bytes.TrimSpace(raw)
+	if len(b) == 0 {
+		return "", validationFailure("EmptyBase64", "base64 file is empty")
+	}
+	return string(b), nil
+}
+
+// LoadJSON unmarshals the object at ref into target.
+func (s *S3Store) LoadJSON(ctx context.Context, ref schema.S3Reference, target interface{}) error {
+	if err := validateReference(ref, "load_json"); err != nil {
+		return err
+	}
+	if err := s.state.RetrieveJSON(&s3state.Reference{Bucket: ref.Bucket, Key: ref.Key, Size: ref.Size}, target); err != nil {
+		return errors.WrapError(err, errors.ErrorTypeS3, "failed to load JSON data", true).
+			WithContext("s3_key", ref.Key).
+			WithContext("bucket", ref.Bucket)
+	}
+	return nil
+}
+
+// LoadInitializationData loads initialization.json. Validation issues are
+// logged, not returned.
+func (s *S3Store) LoadInitializationData(ctx context.Context, ref schema.S3Reference) (*InitializationData, error) {
+	var data InitializationData
+	if err := s.LoadJSON(ctx, ref, &data); err != nil {
+		return nil, errors.WrapError(err, errors.ErrorTypeS3, "failed to load initialization data", true).
+			WithContext("data_type", "initialization")
+	}
+	if issues := schema.ValidateVerificationContextEnhanced(&data.VerificationContext); len(issues) > 0 {
+		s.log.Warn("initialization_data_validation_warnings", map[string]interface{}{
+			"validation_errors": issues.Error(),
+			"verification_id":   data.VerificationContext.VerificationId,
+		})
+	}
+	return &data, nil
+}
+
+// LoadImageMetadata loads images/metadata.json. Validation issues are
+// logged, not returned.
+func (s *S3Store) LoadImageMetadata(ctx context.Context, ref schema.S3Reference) (*ImageMetadata, error) {
+	var metadata ImageMetadata
+	if err := s.LoadJSON(ctx, ref, &metadata); err != nil {
+		return nil, errors.WrapError(err, errors.ErrorTypeS3, "failed to load image metadata", true).
+			WithContext("data_type", "image_metadata")
+	}
+	if metadata.ReferenceImage.StorageMetadata.Key == "" || metadata.CheckingImage.StorageMetadata.Key == "" {
+		s.log.Warn("image_metadata_validation_warnings", map[string]interface{}{
+			"validation_errors": "image storage metadata incomplete",
+			"verification_id":   metadata.VerificationID,
+		})
+	}
+	return &metadata, nil
+}
+
+// LoadLayoutMetadata loads a layout metadata document.
+func (s *S3Store) LoadLayoutMetadata(ctx context.Context, ref schema.S3Reference) (*schema.LayoutMetadata, error) {
+	var layout schema.LayoutMetadata
+	if err := s.LoadJSON(ctx, ref, &layout); err != nil {
+		return nil, errors.WrapError(err, errors.ErrorTypeS3, "failed to load layout metadata", true).
+			WithContext("data_type", "layout_metadata")
+	}
+	return &layout, nil
+}
+
+// LoadTurnResponse loads a turn<N>-raw-response.json. Both the
+// schema.TurnResponse layout and the legacy layout with a content block list
+// are accepted. A missing prompt or answer is recovered from conversationRef
+// when given.
+func (s *S3Store) LoadTurnResponse(ctx context.Context, ref schema.S3Reference, conversationRef *schema.S3Reference) (*schema.TurnResponse, error) {
+	raw, err := s.retrieve(ref, "turn_raw_response")
+	if err != nil {
+		return nil, err
+	}
+	resp, err := decodeTurnResponse(raw)
+	if err != nil {
+		return nil, validationFailure("BadTurnResponse", fmt.Sprintf("failed to parse turn raw response: %v", err)).
+			WithContext("s3_key", ref.Key)
+	}
+
+	if (resp.Prompt == "" || resp.Response.Content == "") && conversationRef != nil && conversationRef.Key != "" {
+		var conv struct {
+			Messages []schema.BedrockMessage `json:"messages"`
+		}
+		if err := s.LoadJSON(ctx, *conversationRef, &conv); err != nil {
+			s.log.Warn("turn_conversation_load_failed", map[string]interface{}{
+				"error": err.Error(),
+				"key":   conversationRef.Key,
+			})
+		} else {
+			if resp.Prompt == "" {
+				resp.Prompt = firstText(conv.Messages, "user")
+			}
+			if resp.Response.Content == "" {
+				resp.Response.Content = firstText(conv.Messages, "assistant")
+			}
+		}
+	}
+
+	if err := validateTurnResponse(resp); err != nil {
+		return nil, validationFailure("InvalidTurnResponse", err.Error()).WithContext("s3_key", ref.Key)
+	}
+	return resp, nil
+}
+
+// StoreJSON stores data as <date partition>/<verificationId>/<key>.
+func (s *S3Store) StoreJSON(ctx context.Context, verificationID, key string, data interface{}) (schema.S3Reference, error) {
+	if verificationID == "" {
+		return schema.S3Reference{}, errors.NewValidationError("verification ID required",
+			map[string]interface{}{"operation": "store_json", "key": key})
+	}
+	ref, err := s.state.StoreJSON(s.datePath(verificationID), key, data)
+	if err != nil {
+		return schema.S3Reference{}, errors.WrapError(err, errors.ErrorTypeS3, "failed to store JSON data", true).
+			WithContext("verification_id", verificationID).
+			WithContext("key", key)
+	}
+	return fromStateReference(ref), nil
+}
+
+// StoreJSONAtReference stores data at an absolute reference, e.g. to update
+// initialization.json in place.
+func (s *S3Store) StoreJSONAtReference(ctx context.Context, ref schema.S3Reference, data interface{}) (schema.S3Reference, error) {
+	if err := validateReference(ref, "store_json_at_reference"); err != nil {
+		return schema.S3Reference{}, err
+	}
+	stored, err := s.state.StoreJSON("", ref.Key, data)
+	if err != nil {
+		return schema.S3Reference{}, errors.WrapError(err, errors.ErrorTypeS3, "failed to store JSON data", true).
+			WithContext("bucket", ref.Bucket).
+			WithContext("s3_key", ref.Key)
+	}
+	return fromStateReference(stored), nil
+}
+
+// StoreMarkdown stores the Markdown rendering of a turn as
+// responses/turn<N>-processed-response.md.
+func (s *S3Store) StoreMarkdown(ctx context.Context, verificationID string, turn int, markdown string) (schema.S3Reference, error) {
+	if verificationID == "" || strings.TrimSpace(markdown) == "" {
+		return schema.S3Reference{}, errors.NewValidationError("verification ID and markdown content required",
+			map[string]interface{}{"operation": "store_markdown", "turn": turn})
+	}
+	key := fmt.Sprintf("responses/turn%d-processed-response.md", turn)
+	ref, err := s.state.StoreWithContentType(s.datePath(verificationID), key, []byte(markdown), "text/markdown; charset=utf-8")
+	if err != nil {
+		return schema.S3Reference{}, errors.WrapError(err, errors.ErrorTypeS3, "failed to store markdown", true).
+			WithContext("verification_id", verificationID).
+			WithContext("turn", turn)
+	}
+	return fromStateReference(ref), nil
+}
+
+// StoreReasoning stores the reasoning blocks of a turn as
+// responses/turn<N>-reasoning.json.
+func (s *S3Store) StoreReasoning(ctx context.Context, verificationID string, artifact *schema.ReasoningArtifact) (schema.S3Reference, error) {
+	if !artifact.HasContent() {
+		return schema.S3Reference{}, errors.NewValidationError("reasoning blocks required",
+			map[string]interface{}{"operation": "store_reasoning"})
+	}
+	return s.StoreJSON(ctx, verificationID, fmt.Sprintf("responses/turn%d-reasoning.json", artifact.TurnId), artifact)
+}
+
+// StoreTemplateProcessor stores processing/template-processor.json.
+func (s *S3Store) StoreTemplateProcessor(ctx context.Context, verificationID string, processor *schema.TemplateProcessor) (schema.S3Reference, error) {
+	if processor == nil {
+		return schema.S3Reference{}, errors.NewValidationError("template processor required",
+			map[string]interface{}{"operation": "store_template_processor"})
+	}
+	if issues := schema.ValidateTemplateProcessor(processor); len(issues) > 0 {
+		s.log.Warn("template_processor_validation_issues", map[string]interface{}{
+			"validation_errors": issues.Error(),
+			"verification_id":   verificationID,
+		})
+	}
+	return s.StoreJSON(ctx, verificationID, "processing/template-processor.json", processor)
+}
+
+// StoreProcessingMetrics stores processing/processing-metrics.json.
+func (s *S3Store) StoreProcessingMetrics(ctx context.Context, verificationID string, metrics *schema.ProcessingMetrics) (schema.S3Reference, error) {
+	if metrics == nil {
+		return schema.S3Reference{}, errors.NewValidationError("processing metrics required",
+			map[string]interface{}{"operation": "store_processing_metrics"})
+	}
+	if issues := schema.ValidateProcessingMetrics(metrics); len(issues) > 0 {
+		s.log.Warn("processing_metrics_validation_issues", map[string]interface{}{
+			"validation_errors": issues.Error(),
+			"verification_id":   verificationID,
+		})
+	}
+	return s.StoreJSON(ctx, verificationID, "processing/processing-metrics.json", metrics)
+}
+
+// StoreConversation stores responses/turn<N>-conversation.json.
+func (s *S3Store) StoreConversation(ctx context.Context, verificationID string, conv *Conversation) (schema.S3Reference, error) {
+	if conv == nil {
+		return schema.S3Reference{}, errors.NewValidationError("conversation required",
+			map[string]interface{}{"operation": "store_conversation"})
+	}
+
+	prior := conv.Prior
+	messages := make([]interface{}, 0, len(prior)+3)
+	if len(prior) > 0 && prior[0].Role == "system" {
+		messages = append(messages, prior[0])
+		prior = prior[1:]
+	} else {
+		messages = append(messages, map[string]interface{}{
+			"role":    "system",
+			"content": []map[string]interface{}{{"text": conv.System}},
+		})
+	}
+	for _, msg := range prior {
+		messages = append(messages, msg)
+	}
+	userContent := []map[string]interface{}{{"text": conv.UserPrompt}}
+	if conv.ImageRef.Key != "" {
+		userContent = append(userContent, map[string]interface{}{
+			"image": map[string]interface{}{
+				"format": "png",
+				"source": map[string]interface{}{
+					"s3Uri": fmt.Sprintf("s3://%s/%s", conv.ImageRef.Bucket, conv.ImageRef.Key),
+				},
+			},
+		})
+	}
+	messages = append(messages,
+		map[string]interface{}{"role": "user", "content": userContent},
+		map[string]interface{}{
+			"role":    "assistant",
+			"content": []map[string]interface{}{{"type": "text", "text": conv.Assistant}},
+		})
+
+	data := map[string]interface{}{
+		"verificationId": verificationID,
+		"timestamp":      schema.FormatISO8601(),
+		"turnId":         conv.Turn,
+		"analysisStage":  conv.Stage,
+		"messages":       messages,
+	}
+	if conv.Usage != nil {
+		data["tokenUsage"] = map[string]interface{}{
+			"input":    conv.Usage.InputTokens,
+			"output":   conv.Usage.OutputTokens,
+			"thinking": conv.Usage.ThinkingTokens,
+			"total":    conv.Usage.TotalTokens,
+		}
+	}
+	if conv.LatencyMs > 0 {
+		data["latencyMs"] = conv.LatencyMs
+		data["processingMetadata"] = map[string]interface{}{
+			"executionTimeMs": conv.LatencyMs,
+			"retryAttempts":   0,
+		}
+	}
+	if conv.RequestID != "" {
+		meta := map[string]interface{}{
+			"modelId":    conv.ModelID,
+			"requestId":  conv.RequestID,
+			"stopReason": "end_turn",
+		}
+		for k, v := range conv.Metadata {
+			meta[k] = v
+		}
+		data["bedrockMetadata"] = meta
+	}
+
+	return s.StoreJSON(ctx, verificationID, fmt.Sprintf("responses/turn%d-conversation.json", conv.Turn), data)
+}
+
+func (s *S3Store) retrieve(ref schema.S3Reference, operation string) ([]byte, error) {
+	if err := validateReference(ref, operation); err != nil {
+		return nil, err
+	}
+	raw, err := s.state.Retrieve(&s3state.Reference{Bucket: ref.Bucket, Key: ref.Key, Size: ref.Size})
+	if err != nil {
+		s.log.Error("s3_retrieval_failed", map[string]interface{}{
+			"error":     err.Error(),
+			"bucket":    ref.Bucket,
+			"key":       ref.Key,
+			"operation": operation,
+		})
+		wfErr := &errors.WorkflowError{
+			Type:      errors.ErrorTypeS3,
+			Code:      "ReadFailed",
+			Message:   fmt.Sprintf("failed to read %s: %v", strings.ReplaceAll(operation, "_", " "), err),
+			Retryable: true,
+			Severity:  errors.ErrorSeverityHigh,
+			APISource: errors.APISourceUnknown,
+			Timestamp: time.Now(),
+		}
+		return nil, wfErr.WithContext("s3_key", ref.Key).WithContext("bucket", ref.Bucket)
+	}
+	return raw, nil
+}
+
+// decodeTurnResponse accepts the schema.TurnResponse layout and the legacy
+// layout whose response.content is a list of typed blocks.
+func decodeTurnResponse(raw []byte) (*schema.TurnResponse, error) {
+	var resp schema.TurnResponse
+	err := json.Unmarshal(raw, &resp)
+	if err == nil {
+		return &resp, nil
+	}
+
+	var legacy struct {
+		TurnId    int               `json:"turnId"`
+		Timestamp string            `json:"timestamp"`
+		Prompt    string            `json:"prompt"`
+		ImageUrls map[string]string `json:"imageUrls,omitempty"`
+		Response  struct {
+			Content []map[string]interface{} `json:"content"`
+		} `json:"response"`
+		LatencyMs       int64                  `json:"latencyMs"`
+		TokenUsage      *schema.TokenUsage     `json:"tokenUsage,omitempty"`
+		Stage           string                 `json:"analysisStage"`
+		Metadata        map[string]interface{} `json:"metadata,omitempty"`
+		BedrockMetadata struct {
+			ModelId    string `json:"modelId"`
+			RequestId  string `json:"requestId"`
+			StopReason string `json:"stopReason,omitempty"`
+		} `json:"bedrockMetadata,omitempty"`
+	}
+	if legacyErr := json.Unmarshal(raw, &legacy); legacyErr != nil {
+		return nil, err
+	}
+	resp = schema.TurnResponse{
+		TurnId:     legacy.TurnId,
+		Timestamp:  legacy.Timestamp,
+		Prompt:     legacy.Prompt,
+		ImageUrls:  legacy.ImageUrls,
+		LatencyMs:  legacy.LatencyMs,
+		TokenUsage: legacy.TokenUsage,
+		Stage:      legacy.Stage,
+		Metadata:   legacy.Metadata,
+		Response: schema.BedrockApiResponse{
+			ModelId:    legacy.BedrockMetadata.ModelId,
+			RequestId:  legacy.BedrockMetadata.RequestId,
+			StopReason: legacy.BedrockMetadata.StopReason,
+		},
+	}
+	for _, c := range legacy.Response.Content {
+		switch c["type"] {
+		case "text":
+			resp.Response.Content, _ = c["text"].(string)
+		case "thinking":
+			resp.Response.Thinking, _ = c["text"].(string)
+		}
+	}
+	return &resp, nil
+}
+
+// validateTurnResponse applies the Turn1 response contract to Turn1 and
+// requires an answer for any turn.
+func validateTurnResponse(resp *schema.TurnResponse) error {
+	if resp.TurnId != bedrock.ExpectedTurn1Number {
+		if resp.Response.Content == "" {
+			return fmt.Errorf("response content cannot be empty")
+		}
+		return nil
+	}
+	check := bedrock.Turn1Response{
+		TurnID:        resp.TurnId,
+		Timestamp:     resp.Timestamp,
+		Prompt:        resp.Prompt,
+		Response:      bedrock.TextResponse{Content: resp.Response.Content, StopReason: resp.Response.StopReason},
+		LatencyMs:     resp.LatencyMs,
+		AnalysisStage: resp.Stage,
+		BedrockMetadata: bedrock.BedrockMetadata{
+			ModelID:         resp.Response.ModelId,
+			RequestID:       resp.Response.RequestId,
+			InvokeLatencyMs: resp.LatencyMs,
+			APIType:         bedrock.APITypeConverse,
+		},
+		APIType: bedrock.APITypeConverse,
+	}
+	return bedrock.ValidateTurn1Response(&check)
+}
+
+func firstText(messages []schema.BedrockMessage, role string) string {
+	for _, msg := range messages {
+		if msg.Role != role {
+			continue
+		}
+		for _, c := range msg.Content {
+			if c.Type == "text" && c.Text != "" {
+				return c.Text
+			}
+		}
+	}
+	return ""
+}
+
+func validateReference(ref schema.S3Reference, operation string) error {
+	if ref.Bucket == "" || ref.Key == "" {
+		return errors.NewValidationError("S3 bucket and key required",
+			map[string]interface{}{"operation": operation, "bucket": ref.Bucket, "key": ref.Key})
+	}
+	return nil
+}
+
+func validationFailure(code, message string) *errors.WorkflowError {
+	return &errors.WorkflowError{
+		Type:      errors.ErrorTypeValidation,
+		Code:      code,
+		Message:   message,
+		Retryable: false,
+		Severity:  errors.ErrorSeverityCritical,
+		APISource: errors.APISourceUnknown,
+		Timestamp: time.Now(),
+	}
+}
+
+func fromStateReference(ref *s3state.Reference) schema.S3Reference {
+	return schema.S3Reference{Bucket: ref.Bucket, Key: ref.Key, Size: ref.Size}
+}
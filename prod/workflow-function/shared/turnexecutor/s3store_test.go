@@ -0,0 +1,178 @@
+package turnexecutor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"workflow-function/shared/logger"
+	"workflow-function/shared/s3state"
+	"workflow-function/shared/schema"
+)
+
+// memoryState is an in-memory s3state.Manager.
+type memoryState struct {
+	objects map[string][]byte
+}
+
+func newMemoryState() *memoryState {
+	return &memoryState{objects: map[string][]byte{}}
+}
+
+func (m *memoryState) key(category, key string) string {
+	if category == "" {
+		return key
+	}
+	return category + "/" + key
+}
+
+func (m *memoryState) Store(category, key string, data []byte) (*s3state.Reference, error) {
+	return m.StoreWithContentType(category, key, data, "application/octet-stream")
+}
+
+func (m *memoryState) StoreWithContentType(category, key string, data []byte, contentType string) (*s3state.Reference, error) {
+	k := m.key(category, key)
+	m.objects[k] = data
+	return &s3state.Reference{Bucket: "state", Key: k, Size: int64(len(data))}, nil
+}
+
+func (m *memoryState) Retrieve(ref *s3state.Reference) ([]byte, error) {
+	data, ok := m.objects[ref.Key]
+	if !ok {
+		return nil, fmt.Errorf("no such key %s", ref.Key)
+	}
+	return data, nil
+}
+
+func (m *memoryState) StoreJSON(category, key string, data interface{}) (*s3state.Reference, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return m.StoreWithContentType(category, key, raw, "application/json")
+}
+
+func (m *memoryState) RetrieveJSON(ref *s3state.Reference, target interface{}) error {
+	raw, err := m.Retrieve(ref)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, target)
+}
+
+func (m *memoryState) SaveToEnvelope(envelope *s3state.Envelope, category, filename string, data interface{}) error {
+	_, err := m.StoreJSON(category, filename, data)
+	return err
+}
+
+func (m *memoryState) GetStateBucket() string { return "state" }
+
+func testStore(state *memoryState) *S3Store {
+	return NewS3Store(state, func() string { return "2026/10/18" }, logger.New("test", "turnexecutor"))
+}
+
+func TestRequestStoreRoundTrip(t *testing.T) {
+	state := newMemoryState()
+	state.objects["2026/10/18/v1/images/checking-base64.base64"] = []byte(" aGVsbG8= \n")
+	store := testStore(state).ForRequest().
+		WithImage(ImageRoleChecking, schema.S3Reference{Bucket: "state", Key: "2026/10/18/v1/images/checking-base64.base64"}, "png").
+		WithPrompt(2, PromptInfo{VerificationType: "LAYOUT_VS_CHECKING", Objective: "compare", ImageRole: ImageRoleChecking})
+	ctx := context.Background()
+
+	img, err := store.LoadImage(ctx, "v1", ImageRoleChecking)
+	if err != nil || img.Base64 != "aGVsbG8=" || img.Format != "png" {
+		t.Fatalf("LoadImage = %+v, %v", img, err)
+	}
+	if _, err := store.LoadImage(ctx, "v1", ImageRoleReference); err == nil {
+		t.Error("expected an error for an unregistered image role")
+	}
+
+	state.objects["2026/10/18/v1/images/reference-base64.base64"] = []byte("/9j/4AAQSkZJRg==")
+	store.WithImage(ImageRoleReference, schema.S3Reference{Bucket: "state", Key: "2026/10/18/v1/images/reference-base64.base64"}, "")
+	if img, err := store.LoadImage(ctx, "v1", ImageRoleReference); err != nil || img.Format != "jpeg" {
+		t.Errorf("expected the JPEG format to be detected, got %+v, %v", img, err)
+	}
+
+	if err := store.StorePrompt(ctx, "v1", 2, "[prompt]"); err != nil {
+		t.Fatalf("StorePrompt: %v", err)
+	}
+	if got := store.PromptRef(2).Key; got != "2026/10/18/v1/prompts/turn2-prompt.json" {
+		t.Errorf("prompt stored at %s", got)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(state.objects[store.PromptRef(2).Key], &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["promptType"] != "TURN2" || doc["imageReference"].(map[string]interface{})["sourceUrl"] != store.ImageRef(ImageRoleChecking).Key {
+		t.Errorf("unexpected prompt document %+v", doc)
+	}
+
+	turn := &schema.TurnResponse{
+		TurnId:   2,
+		Prompt:   "[prompt]",
+		Response: schema.BedrockApiResponse{Content: "answer", Thinking: "because"},
+		Stage:    "CHECKING_ANALYSIS",
+	}
+	if err := store.StoreTurn(ctx, "v1", turn); err != nil {
+		t.Fatalf("StoreTurn: %v", err)
+	}
+	if turn.ImageUrls[ImageRoleChecking] != store.ImageRef(ImageRoleChecking).Key {
+		t.Errorf("image keys not recorded: %v", turn.ImageUrls)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(state.objects[store.TurnRef(2).Key], &raw); err != nil {
+		t.Fatal(err)
+	}
+	if raw["thinking"] != "because" {
+		t.Errorf("expected top-level thinking, got %v", raw["thinking"])
+	}
+
+	loaded, err := testStore(state).ForRequest().WithTurn(2, store.TurnRef(2), nil).LoadTurn(ctx, "v1", 2)
+	if err != nil || loaded.Prompt != "[prompt]" || loaded.Response.Content != "answer" {
+		t.Errorf("LoadTurn = %+v, %v", loaded, err)
+	}
+}
+
+func TestLoadTurnResponseRecoversFromConversation(t *testing.T) {
+	state := newMemoryState()
+	// Legacy layout: content blocks and no prompt
+	state.objects["raw.json"] = []byte(`{"turnId":2,"response":{"content":[{"type":"text","text":""}]},"analysisStage":"CHECKING_ANALYSIS"}`)
+	state.objects["conv.json"] = []byte(`{"messages":[{"role":"user","content":[{"type":"text","text":"question"}]},{"role":"assistant","content":[{"type":"text","text":"reply"}]}]}`)
+
+	resp, err := testStore(state).LoadTurnResponse(context.Background(),
+		schema.S3Reference{Bucket: "state", Key: "raw.json"},
+		&schema.S3Reference{Bucket: "state", Key: "conv.json"})
+	if err != nil {
+		t.Fatalf("LoadTurnResponse: %v", err)
+	}
+	if resp.Prompt != "question" || resp.Response.Content != "reply" {
+		t.Errorf("expected prompt and answer from the conversation, got %+v", resp)
+	}
+}
+
+func TestStoreConversationKeepsPriorSystemMessage(t *testing.T) {
+	state := newMemoryState()
+	prior := []schema.BedrockMessage{
+		{Role: "system", Content: []schema.BedrockContent{{Type: "text", Text: "turn1 system"}}},
+		{Role: "user", Content: []schema.BedrockContent{{Type: "text", Text: "turn1 prompt"}}},
+	}
+	ref, err := testStore(state).StoreConversation(context.Background(), "v1", &Conversation{
+		Turn: 2, Stage: "CHECKING_ANALYSIS", Prior: prior, System: "ignored", UserPrompt: "turn2 prompt", Assistant: "turn2 answer",
+	})
+	if err != nil {
+		t.Fatalf("StoreConversation: %v", err)
+	}
+	var conv struct {
+		Messages []map[string]interface{} `json:"messages"`
+	}
+	if err := json.Unmarshal(state.objects[ref.Key], &conv); err != nil {
+		t.Fatal(err)
+	}
+	if len(conv.Messages) != 4 || conv.Messages[0]["role"] != "system" || conv.Messages[3]["role"] != "assistant" {
+		t.Errorf("unexpected messages %+v", conv.Messages)
+	}
+	if ref.Key != "2026/10/18/v1/responses/turn2-conversation.json" {
+		t.Errorf("conversation stored at %s", ref.Key)
+	}
+}
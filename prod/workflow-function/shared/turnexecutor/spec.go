@@ -0,0 +1,141 @@
+// Package turnexecutor runs a single conversation turn of the verification
+// workflow from a declarative TurnSpec: render the turn's template, replay the
+// requested previous turns as history, attach the requested images, call the
+// Bedrock Converse API, parse the answer and persist the turn.
+package turnexecutor
+
+import (
+	"fmt"
+	"time"
+
+	"workflow-function/shared/bedrock"
+	"workflow-function/shared/experiments"
+	"workflow-function/shared/templateloader"
+)
+
+// Image roles understood by the stores of the verification workflow
+const (
+	ImageRoleReference = "reference"
+	ImageRoleChecking  = "checking"
+)
+
+// StatusSet lists the statuses emitted while a turn runs. Empty values are
+// not emitted, e.g. when the caller records completion itself.
+type StatusSet struct {
+	Started        string
+	PromptPrepared string
+	BedrockInvoked string
+	Completed      string
+	Error          string
+}
+
+// ResponseParser turns the model's text answer into the turn's structured
+// result.
+type ResponseParser interface {
+	Parse(content string) (interface{}, error)
+}
+
+// ParserFunc adapts a function to ResponseParser.
+type ParserFunc func(content string) (interface{}, error)
+
+// Parse calls f(content).
+func (f ParserFunc) Parse(content string) (interface{}, error) {
+	return f(content)
+}
+
+// TurnSpec declares how a turn is executed.
+type TurnSpec struct {
+	// Turn is the 1-based turn number recorded in the conversation history
+	Turn int
+	// Stage is the analysis stage recorded on the turn, e.g. bedrock.AnalysisStageTurn1
+	Stage string
+	// TemplateType and TemplateVersion select the prompt template
+	TemplateType    string
+	TemplateVersion string
+	// Locale selects the localized templates of TemplateType, see WithLocale;
+	// empty renders the default templates
+	Locale string
+	// Images lists the image roles attached to the prompt, in order
+	Images []string
+	// History lists the previous turns replayed before the prompt, in order
+	History []int
+	// Parser is applied to the model's text answer; nil leaves Result.Parsed empty
+	Parser ResponseParser
+	// Statuses emitted while the turn runs
+	Statuses StatusSet
+
+	// ModelID, Inference and Timeout configure the Bedrock call
+	ModelID   string
+	Inference bedrock.InferenceConfig
+	Timeout   time.Duration
+}
+
+// Validate checks that the spec can be executed.
+func (s *TurnSpec) Validate() error {
+	if s == nil {
+		return fmt.Errorf("turn spec is nil")
+	}
+	if s.Turn < 1 {
+		return fmt.Errorf("turn number must be at least 1, got %d", s.Turn)
+	}
+	if s.TemplateType == "" {
+		return fmt.Errorf("turn %d: template type is required", s.Turn)
+	}
+	if s.ModelID == "" {
+		return fmt.Errorf("turn %d: model id is required", s.Turn)
+	}
+	if s.Inference.MaxTokens <= 0 {
+		return fmt.Errorf("turn %d: max tokens must be positive", s.Turn)
+	}
+	for _, t := range s.History {
+		if t < 1 || t >= s.Turn {
+			return fmt.Errorf("turn %d: history turn %d must precede it", s.Turn, t)
+		}
+	}
+	return nil
+}
+
+// WithAssignment returns a copy of the spec using the template version, model
+// and temperature of an experiment variant. Empty variant fields keep the
+// spec's values, and assignments for another template type are ignored.
+func (s *TurnSpec) WithAssignment(a experiments.Assignment) *TurnSpec {
+	c := *s
+	if a.TemplateType != s.TemplateType {
+		return &c
+	}
+	if a.TemplateVersion != "" {
+		c.TemplateVersion = a.TemplateVersion
+	}
+	if a.ModelID != "" {
+		c.ModelID = a.ModelID
+	}
+	if a.Temperature != nil {
+		temperature := *a.Temperature
+		c.Inference.Temperature = &temperature
+	}
+	return &c
+}
+
+// LocaleResolver selects the locale of a template type's templates.
+// templateloader.Loader and PromptRenderer implement it.
+type LocaleResolver interface {
+	ResolveLocale(templateType, locale, version string) string
+}
+
+// WithLocale returns a copy of the spec rendering the templates of locale, or
+// of its language, when resolver finds them for the spec's template version.
+// Otherwise, and with a nil resolver, the default templates are rendered.
+func (s *TurnSpec) WithLocale(resolver LocaleResolver, locale string) *TurnSpec {
+	c := *s
+	c.Locale = templateloader.DefaultLocale
+	if resolver != nil && locale != "" {
+		c.Locale = resolver.ResolveLocale(s.TemplateType, locale, s.TemplateVersion)
+	}
+	return &c
+}
+
+// RenderedTemplateType returns the template type rendered for the spec,
+// TemplateType localized for Locale.
+func (s *TurnSpec) RenderedTemplateType() string {
+	return templateloader.LocalizedTemplateType(s.TemplateType, s.Locale)
+}